@@ -8,15 +8,10 @@ import (
 	"github.com/bdstest/zohosync/internal/config"
 	"github.com/bdstest/zohosync/internal/ui/cli"
 	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/internal/version"
 	"github.com/spf13/cobra"
 )
 
-var (
-	version   = "dev"
-	buildDate = "unknown"
-	commit    = "unknown"
-)
-
 var rootCmd = &cobra.Command{
 	Use:   "zohosync-cli",
 	Short: "ZohoSync CLI - Sync your Zoho WorkDrive files",
@@ -24,7 +19,7 @@ var rootCmd = &cobra.Command{
 	
 Secure, lightweight sync client for Linux that keeps your files synchronized
 between your local machine and Zoho WorkDrive.`,
-	Version: fmt.Sprintf("%s (Built: %s, Commit: %s)", version, buildDate, commit),
+	Version: fmt.Sprintf("%s (Built: %s, Commit: %s)", version.Version, version.BuildDate, version.Commit),
 }
 
 func init() {
@@ -37,10 +32,32 @@ func init() {
 
 	// Add commands
 	rootCmd.AddCommand(cliInstance.CreateLoginCommand())
+	rootCmd.AddCommand(cliInstance.CreateSetupCommand())
+	rootCmd.AddCommand(cliInstance.CreatePresetsCommand())
 	rootCmd.AddCommand(cliInstance.CreateStatusCommand())
 	rootCmd.AddCommand(cliInstance.CreateSyncCommand())
 	rootCmd.AddCommand(cliInstance.CreateListCommand())
-	rootCmd.AddCommand(cliInstance.CreateVersionCommand(version, buildDate, commit))
+	rootCmd.AddCommand(cliInstance.CreatePullCommand())
+	rootCmd.AddCommand(cliInstance.CreatePushCommand())
+	rootCmd.AddCommand(cliInstance.CreatePauseCommand())
+	rootCmd.AddCommand(cliInstance.CreateResumeCommand())
+	rootCmd.AddCommand(cliInstance.CreateCancelCommand())
+	rootCmd.AddCommand(cliInstance.CreateResyncCommand())
+	rootCmd.AddCommand(cliInstance.CreateReindexCommand())
+	rootCmd.AddCommand(cliInstance.CreateConflictsCommand())
+	rootCmd.AddCommand(cliInstance.CreateSetPolicyCommand())
+	rootCmd.AddCommand(cliInstance.CreateDiffCommand())
+	rootCmd.AddCommand(cliInstance.CreateAuditCommand())
+	rootCmd.AddCommand(cliInstance.CreateDeletionsCommand())
+	rootCmd.AddCommand(cliInstance.CreateFailedCommand())
+	rootCmd.AddCommand(cliInstance.CreateRetryFailedCommand())
+	rootCmd.AddCommand(cliInstance.CreateWatchesCommand())
+	rootCmd.AddCommand(cliInstance.CreateLocalTrashCommand())
+	rootCmd.AddCommand(cliInstance.CreateConfigCommand())
+	rootCmd.AddCommand(cliInstance.CreateBootstrapCommand())
+	rootCmd.AddCommand(cliInstance.CreateWorkspacesCommand())
+	rootCmd.AddCommand(cliInstance.CreateWhoamiCommand())
+	rootCmd.AddCommand(cliInstance.CreateVersionCommand(version.Version, version.BuildDate, version.Commit))
 }
 
 func main() {
@@ -2,45 +2,181 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/auth"
 	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/internal/health"
+	"github.com/bdstest/zohosync/internal/notify"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/sync"
 	"github.com/bdstest/zohosync/internal/utils"
-)
-
-var (
-	version   = "dev"
-	buildDate = "unknown"
-	commit    = "unknown"
+	"github.com/bdstest/zohosync/internal/version"
+	"github.com/bdstest/zohosync/internal/web"
+	"github.com/bdstest/zohosync/pkg/types"
 )
 
 func main() {
+	concurrency := flag.Int("concurrency", 0, "Maximum number of concurrent transfers (overrides sync.max_concurrent_syncs)")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
-	
+	if flagSet("concurrency") {
+		if *concurrency < 1 {
+			fmt.Fprintf(os.Stderr, "--concurrency must be at least 1, got %d\n", *concurrency)
+			os.Exit(2)
+		}
+		cfg.Sync.MaxConcurrentSyncs = *concurrency
+	}
+
 	// Initialize logger
 	logger := utils.InitLogger(cfg.App.LogLevel)
 	logger.Info("Starting ZohoSync daemon")
-	logger.Infof("Version: %s, Build: %s, Commit: %s", version, buildDate, commit)
-	
+	logger.Infof("Version: %s, Build: %s, Commit: %s", version.Version, version.BuildDate, version.Commit)
+	defer utils.CloseLogger()
+
+	// Reopen the log file on SIGHUP so logrotate can rotate it
+	stopReopen := utils.ReopenLoggerOnSignal()
+	defer stopReopen()
+
+	// Initialize database
+	dbPath := filepath.Join(os.Getenv("HOME"), ".config", "zohosync", "zohosync.db")
+	database, err := storage.NewDatabase(dbPath)
+	if err != nil {
+		logger.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer database.Close()
+
+	tokens := auth.NewTokenStore(cfg.Auth, database)
+
+	// Pick up a login made with the standalone CLI before the upgrade
+	if _, err := auth.ImportLegacyTokens(context.Background(), database, tokens, api.NewClientWithConfig(nil, cfg), auth.LegacyTokenPath()); err != nil {
+		logger.Warnf("Failed to import legacy tokens: %v", err)
+	}
+
+	token, err := tokens.GetAuthToken()
+	if err != nil {
+		logger.Fatalf("Failed to load auth token: %v", err)
+	}
+	if token == nil && !api.IsLocalRemote(cfg) {
+		fmt.Fprintln(os.Stderr, "Not authenticated - run 'zohosync-cli login' first")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Start sync engine
+	backend, err := api.NewBackend(cfg, token)
+	if err != nil {
+		logger.Fatalf("Failed to set up remote: %v", err)
+	}
+	syncEngine := sync.NewEngine(backend, database, cfg)
+
+	// The Zoho client is nil for a local remote, which needs no
+	// bandwidth limit, auth or API health checks
+	apiClient, _ := backend.(*api.Client)
+	if apiClient == nil {
+		logger.Infof("Syncing against local remote %s", cfg.Remote.Path)
+	}
+
+	// Apply bandwidth limit changes from the config file on SIGHUP
+	stopReload := config.ReloadOnSignal(func(reloaded *types.Config) {
+		if apiClient == nil {
+			return
+		}
+		apiClient.SetBandwidthLimit(api.BandwidthLimitBytes(reloaded.Network))
+		logger.Infof("Reloaded config, bandwidth limit is now %d KiB/s", reloaded.Network.BandwidthLimit)
+	})
+	defer stopReload()
+
+	if notifier := notify.FromConfig(cfg.Notifications); notifier != nil {
+		syncEngine.SetNotifier(notifier)
+	}
+	if err := syncEngine.Start(ctx); err != nil {
+		logger.Fatalf("Failed to start sync engine: %v", err)
+	}
+	defer syncEngine.Stop()
+
+	if syncEngine.IsPaused() {
+		logger.Info("Sync is paused; run 'zohosync-cli resume' to continue")
+	}
+
+	// Start control socket
+	controlServer := control.NewServer(control.DefaultSocketPath())
+	control.RegisterEngineHandlers(controlServer, syncEngine)
+	if err := controlServer.Start(ctx); err != nil {
+		logger.Fatalf("Failed to start control socket: %v", err)
+	}
+	defer controlServer.Close()
+
+	// Start health endpoints for supervisors
+	if cfg.App.HealthAddr != "" {
+		checks := []health.Check{health.DatabaseCheck(database)}
+		if apiClient != nil {
+			checks = append(checks, health.AuthCheck(tokens), health.APICheck(apiClient))
+		}
+		healthServer := health.NewServer(cfg.App.HealthAddr, checks...)
+		if err := healthServer.Start(ctx); err != nil {
+			logger.Fatalf("Failed to start health server: %v", err)
+		}
+		defer healthServer.Close()
+	}
+
+	// Start the web UI for machines without a desktop
+	if cfg.App.WebAddr != "" {
+		if cfg.App.WebToken == "" {
+			logger.Debug("Web UI is off until app.web_token is set")
+		} else {
+			webServer := web.NewServer(cfg.App.WebAddr, cfg.App.WebToken, controlServer, database)
+			if err := webServer.Start(ctx); err != nil {
+				logger.Fatalf("Failed to start web UI: %v", err)
+			}
+			defer webServer.Close()
+		}
+	}
+
 	// Setup signal handling
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	
+
 	// Main daemon loop
 	logger.Info("Daemon started successfully")
-	
+
 	// Wait for shutdown signal
 	sig := <-sigChan
 	logger.Infof("Received signal: %v, shutting down...", sig)
-	
+
+	// Let in-flight transfers finish before the deferred cleanup runs
+	result := syncEngine.Shutdown(syncEngine.ShutdownGrace())
+	for _, path := range result.Deferred {
+		logger.Infof("Deferred transfer of %s to the next start", path)
+	}
+
 	// Cleanup
 	logger.Info("Daemon stopped")
 }
+
+// flagSet reports whether the named flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
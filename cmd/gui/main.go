@@ -2,6 +2,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"image/color"
 	"os"
@@ -13,6 +14,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	
+	"github.com/bdstest/zohosync/internal/api"
 	"github.com/bdstest/zohosync/internal/auth"
 	"github.com/bdstest/zohosync/internal/config"
 	"github.com/bdstest/zohosync/internal/storage"
@@ -21,12 +23,6 @@ import (
 	"github.com/bdstest/zohosync/pkg/types"
 )
 
-var (
-	version   = "dev"
-	buildDate = "unknown"
-	commit    = "unknown"
-)
-
 func main() {
 	// Load configuration
 	cfg, err := config.LoadConfig()
@@ -54,8 +50,15 @@ func main() {
 	}
 	defer database.Close()
 
+	tokens := auth.NewTokenStore(cfg.Auth, database)
+
+	// Pick up a login made with the standalone CLI before the upgrade
+	if _, err := auth.ImportLegacyTokens(context.Background(), database, tokens, api.NewClientWithConfig(nil, cfg), auth.LegacyTokenPath()); err != nil {
+		logger.Warnf("Failed to import legacy tokens: %v", err)
+	}
+
 	// Check authentication status
-	token, err := database.GetAuthToken()
+	token, err := tokens.GetAuthToken()
 	if err != nil {
 		logger.Errorf("Failed to check auth status: %v", err)
 	}
@@ -68,19 +71,19 @@ func main() {
 		// Show authentication window
 		authWindow := gui.NewAuthWindow(myWindow, cfg, database, func(newToken *types.TokenInfo) {
 			logger.Info("Authentication successful, starting main application")
-			showMainWindow(myWindow, cfg, database, newToken)
+			showMainWindow(myWindow, cfg, tokens, newToken)
 		})
 		authWindow.Show()
 	} else {
 		// User is already authenticated, show main window
-		showMainWindow(myWindow, cfg, database, token)
+		showMainWindow(myWindow, cfg, tokens, token)
 	}
 
 	myWindow.ShowAndRun()
 }
 
 // showMainWindow displays the main application window
-func showMainWindow(window fyne.Window, config *types.Config, database *storage.Database, token *types.TokenInfo) {
+func showMainWindow(window fyne.Window, config *types.Config, tokens auth.TokenStore, token *types.TokenInfo) {
 	// Create main UI
 	welcomeLabel := widget.NewLabelWithStyle("ZohoSync", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
 	
@@ -107,7 +110,7 @@ func showMainWindow(window fyne.Window, config *types.Config, database *storage.
 
 	logoutButton := widget.NewButton("🚪 Logout", func() {
 		// Clear stored token
-		database.SaveAuthToken(nil)
+		tokens.ClearAuthToken()
 		fyne.CurrentApp().Quit()
 	})
 
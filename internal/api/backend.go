@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Remote types selectable with the remote.type config setting
+const (
+	RemoteTypeZoho  = "zoho"
+	RemoteTypeLocal = "local"
+)
+
+// RemoteBackend is the storage the sync engine mirrors local folders to.
+// The Zoho WorkDrive client is the production implementation; the local
+// filesystem backend stands in for it offline and in tests.
+type RemoteBackend interface {
+	ListFilesPage(ctx context.Context, folderID string, offset, limit int) ([]FileInfo, error)
+	GetFileInfo(ctx context.Context, fileID string) (*FileInfo, error)
+	DownloadFile(ctx context.Context, fileID string) (io.ReadCloser, error)
+	CreateFolder(ctx context.Context, parentID, name string) (*FileInfo, error)
+	InitiateUploadWithMetadata(ctx context.Context, filename string, fileSize int64, parentID string, metadata *UploadMetadata) (*FileUploadInfo, error)
+	UploadContent(ctx context.Context, session *FileUploadInfo, content io.Reader, size int64) (*FileInfo, error)
+	AbortUpload(ctx context.Context, uploadID string) error
+	DeleteFile(ctx context.Context, fileID string) error
+	DeleteFolder(ctx context.Context, folderID string) error
+	MoveFolder(ctx context.Context, folderID, newParentID, newName string) (*FileInfo, error)
+	CopyFile(ctx context.Context, sourceID, parentID, name string) (*FileInfo, error)
+}
+
+var (
+	_ RemoteBackend = (*Client)(nil)
+	_ RemoteBackend = (*LocalFSBackend)(nil)
+)
+
+// IsLocalRemote reports whether the config selects the local filesystem
+// backend, which needs no Zoho account
+func IsLocalRemote(cfg *types.Config) bool {
+	return cfg.Remote.Type == RemoteTypeLocal
+}
+
+// NewBackend creates the remote backend selected by the config. The token
+// is only used by the Zoho backend and may be nil for a local remote.
+func NewBackend(cfg *types.Config, token *types.TokenInfo) (RemoteBackend, error) {
+	switch cfg.Remote.Type {
+	case "", RemoteTypeZoho:
+		if _, err := TLSConfig(cfg.Network); err != nil {
+			return nil, err
+		}
+		return NewClientWithConfig(token, cfg), nil
+	case RemoteTypeLocal:
+		if cfg.Remote.Path == "" {
+			return nil, fmt.Errorf("remote.path is required for a local remote")
+		}
+		return NewLocalFSBackend(cfg.Remote.Path)
+	default:
+		return nil, fmt.Errorf("unknown remote type %q (expected %s or %s)", cfg.Remote.Type, RemoteTypeZoho, RemoteTypeLocal)
+	}
+}
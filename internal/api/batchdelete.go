@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// MaxBatchDeleteSize is the largest number of files deleted by one bulk
+// request; longer lists are split across several requests
+const MaxBatchDeleteSize = 100
+
+// BatchDeleter is implemented by backends that can delete many files or
+// folders with a single request
+type BatchDeleter interface {
+	// BatchDelete deletes every ID, folders with all of their contents. It
+	// returns the error of each ID that could not be deleted, and an error
+	// if the request itself failed, in which case the IDs not in the map
+	// may not have been attempted.
+	BatchDelete(ctx context.Context, ids []string) (map[string]error, error)
+}
+
+var _ BatchDeleter = (*Client)(nil)
+
+// BatchDelete deletes files and folders using the bulk delete endpoint, at
+// most MaxBatchDeleteSize at a time. Servers without the endpoint are sent
+// one DELETE per ID instead.
+func (c *Client) BatchDelete(ctx context.Context, ids []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for start := 0; start < len(ids); start += MaxBatchDeleteSize {
+		end := start + MaxBatchDeleteSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		supported, err := c.bulkDelete(ctx, ids[start:end], failed)
+		if err != nil {
+			return failed, err
+		}
+		if !supported {
+			c.logger.Infof("Bulk delete is not available, deleting %d files one at a time", len(ids)-start)
+			for _, id := range ids[start:] {
+				if err := ctx.Err(); err != nil {
+					return failed, err
+				}
+				if err := c.DeleteFile(ctx, id); err != nil {
+					failed[id] = err
+				}
+			}
+			return failed, nil
+		}
+	}
+	return failed, nil
+}
+
+// bulkDelete deletes one batch of IDs, recording per-ID failures in failed.
+// It returns false if the server has no bulk delete endpoint.
+func (c *Client) bulkDelete(ctx context.Context, ids []string, failed map[string]error) (bool, error) {
+	resp, err := c.makeRequest(ctx, "POST", "/files/delete", map[string]interface{}{"ids": ids})
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return false, nil
+	default:
+		return true, newResponseError("batch delete", resp)
+	}
+
+	var result struct {
+		Data []struct {
+			ID     string `json:"id"`
+			Status int    `json:"status"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return true, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	reported := make(map[string]int, len(result.Data))
+	for _, item := range result.Data {
+		reported[item.ID] = item.Status
+	}
+	deleted := 0
+	for _, id := range ids {
+		status, ok := reported[id]
+		switch {
+		case !ok:
+			failed[id] = fmt.Errorf("batch delete did not report file %s", id)
+		case status != http.StatusOK && status != http.StatusNoContent:
+			failed[id] = newStatusError("delete", status)
+		default:
+			deleted++
+		}
+	}
+
+	c.invalidate(ids...)
+	c.logger.Infof("Deleted %d of %d files in one batch", deleted, len(ids))
+	return true, nil
+}
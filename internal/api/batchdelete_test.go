@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchDeleteUsesBulkEndpoint(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var batches [][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "POST", r.Method)
+		require.Equal(t, "/files/delete", r.URL.Path)
+
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		batches = append(batches, body.IDs)
+
+		var data []map[string]interface{}
+		for _, id := range body.IDs {
+			switch id {
+			case "locked":
+				data = append(data, map[string]interface{}{"id": id, "status": http.StatusForbidden})
+			case "unreported":
+			default:
+				data = append(data, map[string]interface{}{"id": id, "status": http.StatusNoContent})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	ids := []string{"locked", "unreported"}
+	for i := 0; i < MaxBatchDeleteSize; i++ {
+		ids = append(ids, fmt.Sprintf("file%d", i))
+	}
+
+	failed, err := client.BatchDelete(context.Background(), ids)
+	require.NoError(t, err)
+
+	require.Len(t, batches, 2, "long lists are split into batches")
+	assert.Len(t, batches[0], MaxBatchDeleteSize)
+	assert.Len(t, batches[1], 2)
+
+	require.Len(t, failed, 2)
+	var statusErr *StatusError
+	require.ErrorAs(t, failed["locked"], &statusErr)
+	assert.Equal(t, http.StatusForbidden, statusErr.StatusCode)
+	assert.Error(t, failed["unreported"])
+}
+
+func TestBatchDeleteFallsBackToSingleDeletes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var deletes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		deletes = append(deletes, r.URL.Path)
+		if r.URL.Path == "/files/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	failed, err := client.BatchDelete(context.Background(), []string{"a", "missing", "b"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/files/a", "/files/missing", "/files/b"}, deletes)
+	require.Len(t, failed, 1)
+	assert.Error(t, failed["missing"])
+}
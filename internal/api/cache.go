@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// metadataCache holds file metadata and folder listing pages for a short
+// while, so the several passes of a sync cycle over the same folders do not
+// ask the API again. A TTL of zero disables it.
+type metadataCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	now   func() time.Time
+	files map[string]cachedFile
+	pages map[string]map[pageKey]cachedPage
+}
+
+// cachedFile is the cached metadata of one file or folder
+type cachedFile struct {
+	info    FileInfo
+	fetched time.Time
+}
+
+// pageKey identifies one page of a folder listing
+type pageKey struct {
+	offset, limit int
+}
+
+// cachedPage is one cached page of a folder listing
+type cachedPage struct {
+	files   []FileInfo
+	fetched time.Time
+}
+
+// newMetadataCache creates an empty cache keeping entries for ttl
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	return &metadataCache{
+		ttl:   ttl,
+		now:   time.Now,
+		files: make(map[string]cachedFile),
+		pages: make(map[string]map[pageKey]cachedPage),
+	}
+}
+
+// bypassCacheKey marks a context whose requests skip the cache
+type bypassCacheKey struct{}
+
+// WithoutCache returns a context whose metadata requests always go to the
+// server, for calls whose decisions must not rest on stale metadata. The
+// responses still refresh the cache.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+// usable reports whether cached entries may answer requests made with ctx
+func (mc *metadataCache) usable(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return mc.ttl > 0 && !bypass
+}
+
+// setTTL changes how long entries are kept, dropping them all
+func (mc *metadataCache) setTTL(ttl time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.ttl = ttl
+	mc.files = make(map[string]cachedFile)
+	mc.pages = make(map[string]map[pageKey]cachedPage)
+}
+
+// file returns the cached metadata of a file if it is younger than the TTL
+func (mc *metadataCache) file(ctx context.Context, fileID string) (*FileInfo, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry, ok := mc.files[fileID]
+	if !ok || !mc.usable(ctx) || mc.now().Sub(entry.fetched) > mc.ttl {
+		return nil, false
+	}
+	info := entry.info
+	return &info, true
+}
+
+// putFile caches the metadata of a file
+func (mc *metadataCache) putFile(info *FileInfo) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.ttl > 0 && info.ID != "" {
+		mc.files[info.ID] = cachedFile{info: *info, fetched: mc.now()}
+	}
+}
+
+// page returns a cached page of a folder listing if it is younger than the
+// TTL
+func (mc *metadataCache) page(ctx context.Context, folderID string, offset, limit int) ([]FileInfo, bool) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry, ok := mc.pages[folderID][pageKey{offset, limit}]
+	if !ok || !mc.usable(ctx) || mc.now().Sub(entry.fetched) > mc.ttl {
+		return nil, false
+	}
+	return append([]FileInfo(nil), entry.files...), true
+}
+
+// putPage caches a page of a folder listing
+func (mc *metadataCache) putPage(folderID string, offset, limit int, files []FileInfo) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.ttl <= 0 {
+		return
+	}
+	if mc.pages[folderID] == nil {
+		mc.pages[folderID] = make(map[pageKey]cachedPage)
+	}
+	mc.pages[folderID][pageKey{offset, limit}] = cachedPage{files: append([]FileInfo(nil), files...), fetched: mc.now()}
+}
+
+// invalidate drops everything cached about the given files or folders: their
+// metadata, their listings, and the listings of the folders holding them
+func (mc *metadataCache) invalidate(ids ...string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if entry, ok := mc.files[id]; ok && entry.info.ParentID != "" {
+			delete(mc.pages, entry.info.ParentID)
+		}
+		delete(mc.files, id)
+		delete(mc.pages, id)
+
+		// The parent may not be known from the file's own metadata
+		for folderID, pages := range mc.pages {
+			if pagesContain(pages, id) {
+				delete(mc.pages, folderID)
+			}
+		}
+	}
+}
+
+// pagesContain reports whether a listing includes the file id
+func pagesContain(pages map[pageKey]cachedPage, id string) bool {
+	for _, page := range pages {
+		for _, file := range page.files {
+			if file.ID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetCacheTTL sets how long file metadata and folder listings are reused.
+// Zero disables the cache.
+func (c *Client) SetCacheTTL(ttl time.Duration) {
+	c.cache.setTTL(ttl)
+}
+
+// invalidate drops cached metadata and listings affected by a change to the
+// given files or folders
+func (c *Client) invalidate(ids ...string) {
+	c.cache.invalidate(ids...)
+	for _, id := range ids {
+		c.folders.invalidate(id)
+	}
+}
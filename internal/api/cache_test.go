@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCachingClient creates a client with a one minute metadata cache,
+// pointed at a server holding file1 inside folder1, and counts the GET
+// requests it receives per path
+func newCachingClient(t *testing.T) (*Client, map[string]*int32) {
+	t.Helper()
+	gets := map[string]*int32{
+		"/files/file1":         new(int32),
+		"/files/folder1/files": new(int32),
+	}
+	file := FileInfo{ID: "file1", Name: "report.txt", ParentID: "folder1"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		count, ok := gets[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(count, 1)
+		if r.URL.Path == "/files/file1" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": file})
+		} else {
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": []FileInfo{file}})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+	client.SetCacheTTL(time.Minute)
+	return client, gets
+}
+
+func TestGetFileInfoIsCached(t *testing.T) {
+	client, gets := newCachingClient(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		info, err := client.GetFileInfo(ctx, "file1")
+		require.NoError(t, err)
+		assert.Equal(t, "report.txt", info.Name)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(gets["/files/file1"]), "the second call is answered from the cache")
+
+	_, err := client.GetFileInfo(WithoutCache(ctx), "file1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(gets["/files/file1"]), "bypassing the cache asks the server")
+}
+
+func TestDeleteInvalidatesCache(t *testing.T) {
+	client, gets := newCachingClient(t)
+	ctx := context.Background()
+
+	_, err := client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+	_, err = client.ListFiles(ctx, "folder1", 50)
+	require.NoError(t, err)
+
+	require.NoError(t, client.DeleteFile(ctx, "file1"))
+
+	_, err = client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+	_, err = client.ListFiles(ctx, "folder1", 50)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(gets["/files/file1"]))
+	assert.Equal(t, int32(2), atomic.LoadInt32(gets["/files/folder1/files"]), "the parent's listing is dropped too")
+}
+
+func TestCacheEntriesExpire(t *testing.T) {
+	client, gets := newCachingClient(t)
+	now := time.Now()
+	client.cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	_, err = client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(gets["/files/file1"]))
+}
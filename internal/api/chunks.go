@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrChunkedUploadUnsupported is returned when the server does not
+	// accept uploads in chunks; the content has to be sent in one request
+	ErrChunkedUploadUnsupported = errors.New("server does not support chunked uploads")
+
+	// ErrChunkOutOfOrder is returned when the server only accepts the
+	// chunks of an upload in sequence
+	ErrChunkOutOfOrder = errors.New("server requires chunks in order")
+)
+
+// ChunkedUploader is implemented by backends that accept the content of an
+// upload session as separate byte ranges, which may be sent concurrently
+type ChunkedUploader interface {
+	UploadChunk(ctx context.Context, session *FileUploadInfo, index int, offset int64, content io.Reader, length, total int64) error
+	CommitUpload(ctx context.Context, session *FileUploadInfo, chunks int) (*FileInfo, error)
+}
+
+var _ ChunkedUploader = (*Client)(nil)
+
+// sessionURL returns the URL content for an upload session is sent to
+func (c *Client) sessionURL(session *FileUploadInfo) string {
+	if session.UploadURL != "" {
+		return session.UploadURL
+	}
+	return fmt.Sprintf("%s/upload/%s", c.uploadURL, session.UploadID)
+}
+
+// UploadChunk sends the bytes at offset of a file of total bytes as chunk
+// index of an upload session. Chunks share the client's bandwidth limit.
+func (c *Client) UploadChunk(ctx context.Context, session *FileUploadInfo, index int, offset int64, content io.Reader, length, total int64) error {
+	target := fmt.Sprintf("%s/chunks/%d", c.sessionURL(session), index)
+	ctx, watchdog := c.watchStalls(ctx)
+	defer watchdog.stop()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", target, withProgress(ctx, watchdog.reader(c.limiter.Reader(ctx, content)), length))
+	if err != nil {
+		return fmt.Errorf("failed to create chunk request: %w", err)
+	}
+	req.ContentLength = length
+	req.Header.Set("Authorization", "Bearer "+c.token.AccessToken)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, total))
+
+	watchdog.begin()
+	resp, err := c.do(req)
+	watchdog.end()
+	if err != nil {
+		return fmt.Errorf("chunk upload failed: %w", watchdog.err(err))
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return ErrChunkedUploadUnsupported
+	case http.StatusConflict:
+		return fmt.Errorf("chunk %d: %w", index, ErrChunkOutOfOrder)
+	default:
+		return newResponseError("chunk upload", resp)
+	}
+}
+
+// CommitUpload assembles the uploaded chunks of a session into the file and
+// returns it. It is subject to session.IfMatch like UploadContent.
+func (c *Client) CommitUpload(ctx context.Context, session *FileUploadInfo, chunks int) (*FileInfo, error) {
+	body, err := json.Marshal(map[string]interface{}{"chunks": chunks})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.sessionURL(session)+"/commit", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create commit request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token.AccessToken)
+	req.Header.Set("Content-Type", "application/json")
+	if session.IfMatch != "" {
+		req.Header.Set("If-Match", session.IfMatch)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("upload commit failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, newResponseError("upload commit", resp)
+	}
+
+	var result struct {
+		Data FileInfo `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.invalidate(result.Data.ID, result.Data.ParentID, session.parentID)
+	c.logger.Infof("Committed %d chunks of upload %s", chunks, session.UploadID)
+	return &result.Data, nil
+}
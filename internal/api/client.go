@@ -24,29 +24,90 @@ type Client struct {
 	uploadURL   string
 	downloadURL string
 	token       *types.TokenInfo
+	folders     *folderCache
+	cache       *metadataCache
+	validators  *validatorCache
+	limiter     *RateLimiter
+	stallTimeout time.Duration
+	requestTimeout time.Duration
+	requestLog  string
+	userAgent   string
 	logger      *utils.Logger
 }
 
 // NewClient creates a new Zoho WorkDrive API client
 func NewClient(token *types.TokenInfo) *Client {
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: &http.Client{},
 		baseURL:     config.APIBaseURL,
 		uploadURL:   config.UploadBaseURL,
 		downloadURL: config.DownloadBaseURL,
 		token:       token,
+		folders:     newFolderCache(),
+		cache:       newMetadataCache(0),
+		validators:  newValidatorCache(),
+		limiter:     NewRateLimiter(0),
+		stallTimeout: DefaultStallTimeout,
+		requestTimeout: DefaultRequestTimeout,
+		requestLog:  RequestLogRequests,
+		userAgent:   UserAgent(""),
 		logger:      utils.GetLogger(),
 	}
 }
 
+// NewClientWithConfig creates a client for the configured API whose HTTP
+// transport and timeouts are tuned from the network and sync settings. The
+// network timeout bounds API requests; transfers are bounded by the stall
+// timeout instead.
+func NewClientWithConfig(token *types.TokenInfo, cfg *types.Config) *Client {
+	client := NewClient(token)
+	client.applyAPIConfig(cfg.API)
+
+	timeout := time.Duration(cfg.Network.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	client.httpClient = &http.Client{
+		Transport: NewTransport(cfg.Network, cfg.Sync.MaxConcurrentSyncs),
+	}
+	client.SetRequestTimeout(timeout)
+	client.SetBandwidthLimit(BandwidthLimitBytes(cfg.Network))
+	client.SetStallTimeout(time.Duration(cfg.Network.StallTimeout) * time.Second)
+	client.SetCacheTTL(time.Duration(cfg.API.CacheTTL) * time.Second)
+	client.SetRequestLog(cfg.API.RequestLog)
+	client.SetUserAgent(cfg.Network.UserAgent)
+	return client
+}
+
+// BandwidthLimitBytes converts the configured bandwidth limit, in KiB per
+// second, to bytes per second
+func BandwidthLimitBytes(network types.NetworkConfig) int64 {
+	return int64(network.BandwidthLimit) * 1024
+}
+
+// SetBandwidthLimit caps the combined throughput of uploads and downloads.
+// Zero removes the limit. It is safe to call while transfers are running.
+func (c *Client) SetBandwidthLimit(bytesPerSecond int64) {
+	c.limiter.SetLimit(bytesPerSecond)
+}
+
 // SetToken updates the authentication token
 func (c *Client) SetToken(token *types.TokenInfo) {
 	c.token = token
 }
 
-// makeRequest performs an authenticated HTTP request
+// SetBaseURL points all API, upload and download requests at baseURL,
+// which is mainly useful for testing against a mock server
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = baseURL
+	c.uploadURL = baseURL
+	c.downloadURL = baseURL
+}
+
+// makeRequest performs an authenticated HTTP request. GETs are made
+// conditional on the last response to the same endpoint when the server
+// sent validators for it, and a 304 answer is returned as that response.
 func (c *Client) makeRequest(ctx context.Context, method, endpoint string, body interface{}) (*http.Response, error) {
 	var reqBody io.Reader
 
@@ -68,11 +129,23 @@ func (c *Client) makeRequest(ctx context.Context, method, endpoint string, body
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	conditional := method == http.MethodGet && body == nil
+	var kept *validatedResponse
+	if conditional {
+		kept = c.validators.addConditions(req, endpoint)
+	}
+
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
+	if conditional {
+		if resp.StatusCode == http.StatusNotModified && kept != nil {
+			c.logger.Debugf("%s not modified, reusing the last response", endpoint)
+		}
+		return c.validators.update(endpoint, resp, kept)
+	}
 	return resp, nil
 }
 
@@ -96,7 +169,7 @@ func (c *Client) GetUserInfo(ctx context.Context) (*UserInfo, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return nil, newResponseError("API request", resp)
 	}
 
 	var result struct {
@@ -124,14 +197,32 @@ type FileInfo struct {
 	IsFolder     bool      `json:"is_folder"`
 	DownloadURL  string    `json:"download_url"`
 	Permission   string    `json:"permission"`
+	Executable   bool      `json:"executable,omitempty"`
+	Version      string    `json:"version,omitempty"`
+	// Hash is the hex digest of the content computed with HashAlgorithm,
+	// when the server reports one
+	Hash          string `json:"hash,omitempty"`
+	HashAlgorithm string `json:"hash_algorithm,omitempty"`
 }
 
 // ListFiles retrieves files from a specific folder
 func (c *Client) ListFiles(ctx context.Context, folderID string, limit int) ([]FileInfo, error) {
+	return c.ListFilesPage(ctx, folderID, 0, limit)
+}
+
+// ListFilesPage retrieves one page of a folder's children starting at offset
+func (c *Client) ListFilesPage(ctx context.Context, folderID string, offset, limit int) ([]FileInfo, error) {
+	if files, ok := c.cache.page(ctx, folderID, offset, limit); ok {
+		return files, nil
+	}
+
 	endpoint := fmt.Sprintf("/files/%s/files", folderID)
 	
 	// Add query parameters
 	params := url.Values{}
+	if offset > 0 {
+		params.Add("offset", strconv.Itoa(offset))
+	}
 	if limit > 0 {
 		params.Add("limit", strconv.Itoa(limit))
 	}
@@ -146,7 +237,7 @@ func (c *Client) ListFiles(ctx context.Context, folderID string, limit int) ([]F
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return nil, newResponseError("API request", resp)
 	}
 
 	var result struct {
@@ -157,6 +248,7 @@ func (c *Client) ListFiles(ctx context.Context, folderID string, limit int) ([]F
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	c.cache.putPage(folderID, offset, limit, result.Data)
 	c.logger.Infof("Retrieved %d files from folder %s", len(result.Data), folderID)
 	return result.Data, nil
 }
@@ -170,7 +262,7 @@ func (c *Client) GetRootFolder(ctx context.Context) (*FileInfo, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return nil, newResponseError("API request", resp)
 	}
 
 	var result struct {
@@ -188,19 +280,24 @@ func (c *Client) GetRootFolder(ctx context.Context) (*FileInfo, error) {
 // DownloadFile downloads a file from Zoho WorkDrive
 func (c *Client) DownloadFile(ctx context.Context, fileID string) (io.ReadCloser, error) {
 	endpoint := fmt.Sprintf("/files/%s/download", fileID)
-	
+	ctx, watchdog := c.watchStalls(ctx)
+
+	watchdog.begin()
 	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	watchdog.end()
 	if err != nil {
-		return nil, err
+		watchdog.stop()
+		return nil, watchdog.err(err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
-		return nil, fmt.Errorf("download failed with status %d", resp.StatusCode)
+		watchdog.stop()
+		return nil, newResponseError("download", resp)
 	}
 
 	c.logger.Infof("Started download for file %s", fileID)
-	return resp.Body, nil
+	return progressBody(ctx, watchdog.body(ctx, c.limiter, resp.Body), 0, resp.ContentLength), nil
 }
 
 // CreateFolder creates a new folder
@@ -218,7 +315,7 @@ func (c *Client) CreateFolder(ctx context.Context, parentID, name string) (*File
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("folder creation failed with status %d", resp.StatusCode)
+		return nil, newResponseError("folder creation", resp)
 	}
 
 	var result struct {
@@ -229,6 +326,7 @@ func (c *Client) CreateFolder(ctx context.Context, parentID, name string) (*File
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	c.invalidate(parentID)
 	c.logger.Infof("Created folder '%s' in parent %s", name, parentID)
 	return &result.Data, nil
 }
@@ -238,15 +336,38 @@ type FileUploadInfo struct {
 	UploadID    string `json:"upload_id"`
 	UploadURL   string `json:"upload_url"`
 	ExpiresAt   time.Time `json:"expires_at"`
+	// IfMatch, when set, is the remote version the upload expects to
+	// replace; the server rejects the upload if the file has changed since
+	IfMatch     string `json:"-"`
+	// parentID is the folder the upload goes into
+	parentID    string
+}
+
+// UploadMetadata carries optional file attributes sent along with an upload
+type UploadMetadata struct {
+	ModifiedTime time.Time
+	Executable   bool
 }
 
 // InitiateUpload initiates a file upload session
 func (c *Client) InitiateUpload(ctx context.Context, filename string, fileSize int64, parentID string) (*FileUploadInfo, error) {
+	return c.InitiateUploadWithMetadata(ctx, filename, fileSize, parentID, nil)
+}
+
+// InitiateUploadWithMetadata initiates a file upload session, asking the
+// server to record the given modification time and executable bit
+func (c *Client) InitiateUploadWithMetadata(ctx context.Context, filename string, fileSize int64, parentID string, metadata *UploadMetadata) (*FileUploadInfo, error) {
 	body := map[string]interface{}{
 		"filename":  filename,
 		"file_size": fileSize,
 		"parent_id": parentID,
 	}
+	if metadata != nil {
+		if !metadata.ModifiedTime.IsZero() {
+			body["modified_time"] = metadata.ModifiedTime.UTC().Format(time.RFC3339)
+		}
+		body["executable"] = metadata.Executable
+	}
 
 	endpoint := "/upload/initiate"
 	req, err := http.NewRequestWithContext(ctx, "POST", c.uploadURL+endpoint, nil)
@@ -261,14 +382,14 @@ func (c *Client) InitiateUpload(ctx context.Context, filename string, fileSize i
 	jsonBody, _ := json.Marshal(body)
 	req.Body = io.NopCloser(bytes.NewBuffer(jsonBody))
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("upload initiation failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("upload initiation failed with status %d", resp.StatusCode)
+		return nil, newResponseError("upload initiation", resp)
 	}
 
 	var result struct {
@@ -279,10 +400,78 @@ func (c *Client) InitiateUpload(ctx context.Context, filename string, fileSize i
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	result.Data.parentID = parentID
 	c.logger.Infof("Initiated upload for file '%s'", filename)
 	return &result.Data, nil
 }
 
+// UploadContent sends the file content for an initiated upload session and
+// returns the created file
+func (c *Client) UploadContent(ctx context.Context, session *FileUploadInfo, content io.Reader, size int64) (*FileInfo, error) {
+	ctx, watchdog := c.watchStalls(ctx)
+	defer watchdog.stop()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.sessionURL(session), withProgress(ctx, watchdog.reader(c.limiter.Reader(ctx, content)), size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Authorization", "Bearer "+c.token.AccessToken)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if session.IfMatch != "" {
+		req.Header.Set("If-Match", session.IfMatch)
+	}
+
+	watchdog.begin()
+	resp, err := c.do(req)
+	watchdog.end()
+	if err != nil {
+		return nil, fmt.Errorf("upload failed: %w", watchdog.err(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, newResponseError("upload", resp)
+	}
+
+	var result struct {
+		Data FileInfo `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.invalidate(result.Data.ID, result.Data.ParentID, session.parentID)
+	c.logger.Infof("Completed upload %s", session.UploadID)
+	return &result.Data, nil
+}
+
+// AbortUpload abandons an upload session so the server can discard it.
+// Sessions the server no longer knows about are treated as already aborted.
+func (c *Client) AbortUpload(ctx context.Context, uploadID string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/upload/%s", c.uploadURL, uploadID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create abort request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token.AccessToken)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("upload abort failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+	default:
+		return newResponseError("upload abort", resp)
+	}
+
+	c.logger.Infof("Aborted upload %s", uploadID)
+	return nil
+}
+
 // DeleteFile deletes a file or folder
 func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
 	endpoint := fmt.Sprintf("/files/%s", fileID)
@@ -294,15 +483,93 @@ func (c *Client) DeleteFile(ctx context.Context, fileID string) error {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("delete failed with status %d", resp.StatusCode)
+		return newResponseError("delete", resp)
 	}
 
+	c.invalidate(fileID)
 	c.logger.Infof("Deleted file %s", fileID)
 	return nil
 }
 
+// DeleteFolder deletes a folder together with all of its contents
+func (c *Client) DeleteFolder(ctx context.Context, folderID string) error {
+	if err := c.DeleteFile(ctx, folderID); err != nil {
+		return err
+	}
+
+	c.logger.Infof("Deleted folder %s recursively", folderID)
+	return nil
+}
+
+// MoveFolder moves and/or renames a folder, carrying all of its contents
+func (c *Client) MoveFolder(ctx context.Context, folderID, newParentID, newName string) (*FileInfo, error) {
+	endpoint := fmt.Sprintf("/files/%s", folderID)
+	body := map[string]interface{}{
+		"parent_id": newParentID,
+		"name":      newName,
+	}
+
+	resp, err := c.makeRequest(ctx, "PATCH", endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newResponseError("folder move", resp)
+	}
+
+	var result struct {
+		Data FileInfo `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.invalidate(folderID, newParentID)
+	c.logger.Infof("Moved folder %s to '%s' in parent %s", folderID, newName, newParentID)
+	return &result.Data, nil
+}
+
+// CopyFile creates a server-side copy of a file in parentID under name,
+// without transferring its content again
+func (c *Client) CopyFile(ctx context.Context, sourceID, parentID, name string) (*FileInfo, error) {
+	endpoint := fmt.Sprintf("/files/%s/copy", sourceID)
+	body := map[string]interface{}{
+		"parent_id": parentID,
+		"name":      name,
+	}
+
+	resp, err := c.makeRequest(ctx, "POST", endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, newResponseError("file copy", resp)
+	}
+
+	var result struct {
+		Data FileInfo `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.invalidate(parentID)
+	c.logger.Infof("Copied file %s to '%s' in parent %s", sourceID, name, parentID)
+	return &result.Data, nil
+}
+
 // GetFileInfo retrieves metadata for a specific file
 func (c *Client) GetFileInfo(ctx context.Context, fileID string) (*FileInfo, error) {
+	if info, ok := c.cache.file(ctx, fileID); ok {
+		return info, nil
+	}
+
 	endpoint := fmt.Sprintf("/files/%s", fileID)
 	
 	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
@@ -312,7 +579,7 @@ func (c *Client) GetFileInfo(ctx context.Context, fileID string) (*FileInfo, err
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return nil, newResponseError("API request", resp)
 	}
 
 	var result struct {
@@ -322,6 +589,10 @@ func (c *Client) GetFileInfo(ctx context.Context, fileID string) (*FileInfo, err
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	if result.Data.Version == "" {
+		result.Data.Version = resp.Header.Get("ETag")
+	}
 
+	c.cache.putFile(&result.Data)
 	return &result.Data, nil
 }
\ No newline at end of file
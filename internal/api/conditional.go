@@ -0,0 +1,151 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxValidatedBody is the largest response body kept for conditional
+// requests; larger responses are fetched in full every time
+const maxValidatedBody = 1 << 20
+
+// maxValidatedEntries bounds how many endpoints have a response kept
+const maxValidatedEntries = 1024
+
+// validatedResponse is the last response of a GET endpoint together with
+// the validators the server sent for it
+type validatedResponse struct {
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+// validatorCache keeps the last JSON response of each GET endpoint that
+// came with an ETag or Last-Modified header. The next request for the
+// endpoint is made conditional, and a 304 Not Modified answer is served
+// from the kept response, so metadata polled every cycle is only
+// transferred when it changed. Unlike the metadata cache it never serves
+// anything the server has not just confirmed.
+type validatorCache struct {
+	mu      sync.Mutex
+	entries map[string]*validatedResponse
+}
+
+// newValidatorCache creates an empty cache
+func newValidatorCache() *validatorCache {
+	return &validatorCache{entries: make(map[string]*validatedResponse)}
+}
+
+// addConditions makes req conditional on the response kept for endpoint,
+// if there is one, and returns that response
+func (vc *validatorCache) addConditions(req *http.Request, endpoint string) *validatedResponse {
+	vc.mu.Lock()
+	entry := vc.entries[endpoint]
+	vc.mu.Unlock()
+	if entry == nil {
+		return nil
+	}
+
+	if entry.etag != "" {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	if entry.lastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.lastModified)
+	}
+	return entry
+}
+
+// update handles the response to a GET of endpoint. A 304 answer to a
+// conditional request is replaced by the kept response; any other
+// successful JSON response with validators is kept for the next request.
+func (vc *validatorCache) update(endpoint string, resp *http.Response, kept *validatedResponse) (*http.Response, error) {
+	switch {
+	case resp.StatusCode == http.StatusNotModified && kept != nil:
+		resp.Body.Close()
+		return kept.response(resp), nil
+	case resp.StatusCode != http.StatusOK:
+		vc.forget(endpoint)
+		return resp, nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" || !isJSON(resp.Header) || resp.ContentLength > maxValidatedBody {
+		vc.forget(endpoint)
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxValidatedBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxValidatedBody {
+		// Too large to keep; hand on what was read along with the rest
+		vc.forget(endpoint)
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if _, ok := vc.entries[endpoint]; !ok && len(vc.entries) >= maxValidatedEntries {
+		for key := range vc.entries {
+			delete(vc.entries, key)
+			break
+		}
+	}
+	vc.entries[endpoint] = &validatedResponse{
+		etag:         etag,
+		lastModified: lastModified,
+		header:       resp.Header.Clone(),
+		body:         body,
+	}
+	return resp, nil
+}
+
+// forget drops the response kept for endpoint
+func (vc *validatorCache) forget(endpoint string) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	delete(vc.entries, endpoint)
+}
+
+// response rebuilds the kept response in answer to notModified, taking
+// any headers the server updated from it
+func (entry *validatedResponse) response(notModified *http.Response) *http.Response {
+	header := entry.header.Clone()
+	for key, values := range notModified.Header {
+		if key != "Content-Length" {
+			header[key] = values
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       notModified.Request,
+	}
+}
+
+// isJSON reports whether a response holds JSON, which rules out file
+// downloads
+func isJSON(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
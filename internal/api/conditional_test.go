@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotModifiedResponseReusesLastResponse(t *testing.T) {
+	var (
+		requests    int32
+		bodiesSent  int32
+		version     atomic.Value
+		conditional atomic.Value
+	)
+	version.Store(`"v1"`)
+	conditional.Store("")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		conditional.Store(r.Header.Get("If-None-Match"))
+
+		etag := version.Load().(string)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		atomic.AddInt32(&bodiesSent, 1)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": FileInfo{ID: "file1", Name: "report-" + etag[2:3] + ".txt"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+	ctx := context.Background()
+
+	info, err := client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+	assert.Equal(t, "report-1.txt", info.Name)
+	assert.Equal(t, "", conditional.Load(), "nothing is kept before the first response")
+
+	info, err = client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+	assert.Equal(t, `"v1"`, conditional.Load(), "the second request is conditional")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&bodiesSent), "the server sent no body the second time")
+	assert.Equal(t, "report-1.txt", info.Name, "the last response is reused")
+	assert.Equal(t, `"v1"`, info.Version)
+
+	// Once the file changes the server sends it in full again
+	version.Store(`"v2"`)
+	info, err = client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+	assert.Equal(t, "report-2.txt", info.Name)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&bodiesSent))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestDownloadsAreNotMadeConditional(t *testing.T) {
+	var conditional int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			atomic.AddInt32(&conditional, 1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte("file content"))
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	for i := 0; i < 2; i++ {
+		body, err := client.DownloadFile(context.Background(), "file1")
+		require.NoError(t, err)
+		body.Close()
+	}
+	assert.Zero(t, atomic.LoadInt32(&conditional), "file content is not kept for conditional requests")
+}
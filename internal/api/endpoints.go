@@ -0,0 +1,45 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// BaseURL builds the prefix of every API endpoint from the api settings,
+// taking the default for any that are empty. Slashes around the parts are
+// optional, so "/workdrive/api/" and "workdrive/api" are the same base path,
+// and a base path of "/" leaves it out.
+func BaseURL(cfg types.APIConfig) string {
+	host := strings.TrimRight(cfg.Host, "/")
+	if host == "" {
+		host = config.APIHost
+	}
+	basePath := strings.Trim(cfg.BasePath, "/")
+	if cfg.BasePath == "" {
+		basePath = strings.Trim(config.APIBasePath, "/")
+	}
+	version := strings.Trim(cfg.Version, "/")
+	if version == "" {
+		version = config.APIVersion
+	}
+
+	url := host
+	for _, part := range []string{basePath, version} {
+		if part != "" {
+			url += "/" + part
+		}
+	}
+	return url
+}
+
+// applyAPIConfig points a client at the configured API. A host other than
+// the default, such as a mock server, serves uploads and downloads as well.
+func (c *Client) applyAPIConfig(cfg types.APIConfig) {
+	c.baseURL = BaseURL(cfg)
+	if host := strings.TrimRight(cfg.Host, "/"); host != "" && host != config.APIHost {
+		c.uploadURL = c.baseURL
+		c.downloadURL = c.baseURL
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  types.APIConfig
+		want string
+	}{
+		{"defaults", types.APIConfig{}, config.APIBaseURL},
+		{"new version", types.APIConfig{Version: "v2"}, "https://workdrive.zoho.com/api/v2"},
+		{"mock server", types.APIConfig{Host: "http://localhost:8080/", BasePath: "workdrive/api/", Version: "v1"}, "http://localhost:8080/workdrive/api/v1"},
+		{"no base path", types.APIConfig{Host: "http://localhost:8080", BasePath: "/", Version: "v1"}, "http://localhost:8080/v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BaseURL(tt.cfg))
+		})
+	}
+}
+
+func TestClientUsesConfiguredAPIPrefix(t *testing.T) {
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		if r.URL.Path != "/workdrive/api/v1/files/root/files" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]interface{}{{"id": "file1", "name": "report.txt"}},
+		})
+	}))
+	defer server.Close()
+
+	cfg := &types.Config{API: types.APIConfig{Host: server.URL, BasePath: "/workdrive/api", Version: "v1"}}
+	client := NewClientWithConfig(&types.TokenInfo{AccessToken: "test_token"}, cfg)
+
+	files, err := client.ListFiles(context.Background(), "root", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "/workdrive/api/v1/files/root/files", requested)
+	require.Len(t, files, 1)
+	assert.Equal(t, "file1", files[0].ID)
+
+	// Uploads go to the same server as the API
+	assert.Equal(t, server.URL+"/workdrive/api/v1/upload/u1", client.sessionURL(&FileUploadInfo{UploadID: "u1"}))
+}
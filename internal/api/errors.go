@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StatusError is returned when the API responds with an unexpected HTTP status
+type StatusError struct {
+	Operation  string
+	StatusCode int
+	// RequestID is the correlation ID of the request, as logged
+	RequestID string
+}
+
+func (e *StatusError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s failed with status %d (request %s)", e.Operation, e.StatusCode, e.RequestID)
+	}
+	return fmt.Sprintf("%s failed with status %d", e.Operation, e.StatusCode)
+}
+
+// newStatusError creates a status error for the given operation
+func newStatusError(operation string, statusCode int) *StatusError {
+	return &StatusError{
+		Operation:  operation,
+		StatusCode: statusCode,
+	}
+}
+
+// newResponseError creates a status error for the response to a request
+// sent by Client.do, tagged with the request's correlation ID
+func newResponseError(operation string, resp *http.Response) *StatusError {
+	err := newStatusError(operation, resp.StatusCode)
+	if resp.Request != nil {
+		err.RequestID = resp.Request.Header.Get(RequestIDHeader)
+	}
+	return err
+}
+
+// RequestError is returned when a request got no response at all
+type RequestError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%v (request %s)", e.Err, e.RequestID)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// RequestID returns the correlation ID of the API request err came from, so
+// it can be found in the request log, or "" if there is none
+func RequestID(err error) string {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.RequestID != "" {
+		return statusErr.RequestID
+	}
+	var requestErr *RequestError
+	if errors.As(err, &requestErr) {
+		return requestErr.RequestID
+	}
+	return ""
+}
+
+// IsPreconditionFailed reports whether err is the server rejecting a
+// conditional request because the remote file changed
+func IsPreconditionFailed(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusPreconditionFailed
+}
+
+// IsNotFound reports whether err is the server saying a file or folder does
+// not exist, such as one that was deleted remotely
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// IsStorageFull reports whether err is the server refusing an upload
+// because the drive has no space left, as opposed to a rate limit
+func IsStorageFull(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusInsufficientStorage
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrExportUnsupported is returned when a document cannot be exported in the
+// requested format
+var ErrExportUnsupported = errors.New("export format not supported")
+
+// ExportFormats lists the formats each type of Zoho native document can be
+// exported as, keyed by FileInfo.Type, with the default format first
+var ExportFormats = map[string][]string{
+	"writer": {"docx", "odt", "rtf", "pdf", "txt", "html"},
+	"sheet":  {"xlsx", "ods", "csv", "tsv", "pdf"},
+	"show":   {"pptx", "odp", "pdf"},
+}
+
+// DocumentExporter is implemented by backends holding native documents that
+// have no file content of their own and are downloaded by exporting them
+type DocumentExporter interface {
+	ExportDocument(ctx context.Context, fileID, format string) (io.ReadCloser, error)
+}
+
+var _ DocumentExporter = (*Client)(nil)
+
+// IsNativeDocument reports whether a file type is a Zoho native document
+func IsNativeDocument(fileType string) bool {
+	_, ok := ExportFormats[fileType]
+	return ok
+}
+
+// CheckExportFormat returns an error wrapping ErrExportUnsupported unless a
+// document of fileType can be exported as format
+func CheckExportFormat(fileType, format string) error {
+	formats, ok := ExportFormats[fileType]
+	if !ok {
+		return fmt.Errorf("%w: %q is not a native document type", ErrExportUnsupported, fileType)
+	}
+	for _, supported := range formats {
+		if supported == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s documents cannot be exported as %q (supported: %s)",
+		ErrExportUnsupported, fileType, format, strings.Join(formats, ", "))
+}
+
+// ExportDocument downloads a native document converted to format
+func (c *Client) ExportDocument(ctx context.Context, fileID, format string) (io.ReadCloser, error) {
+	endpoint := fmt.Sprintf("/files/%s/export?%s", fileID, url.Values{"format": {format}}.Encode())
+
+	ctx, watchdog := c.watchStalls(ctx)
+
+	watchdog.begin()
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	watchdog.end()
+	if err != nil {
+		watchdog.stop()
+		return nil, watchdog.err(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+		resp.Body.Close()
+		watchdog.stop()
+		return nil, fmt.Errorf("%w: file %s cannot be exported as %q", ErrExportUnsupported, fileID, format)
+	default:
+		resp.Body.Close()
+		watchdog.stop()
+		return nil, newResponseError("export", resp)
+	}
+
+	c.logger.Infof("Started export of file %s as %s", fileID, format)
+	return progressBody(ctx, watchdog.body(ctx, c.limiter, resp.Body), 0, resp.ContentLength), nil
+}
@@ -0,0 +1,359 @@
+package api
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localRootID is the ID of the backend's top-level folder. The sync engine
+// addresses the remote root as "root", which is accepted as an alias.
+const localRootID = "/"
+
+// localTempPrefix marks partial uploads, which are hidden from listings
+const localTempPrefix = ".zohosync-upload-"
+
+// LocalFSBackend is a RemoteBackend that keeps the "remote" copy in a local
+// directory, for offline use, LAN shares and tests. File IDs are slash
+// separated paths relative to that directory, so they stay valid across
+// restarts; upload IDs encode their target for the same reason.
+type LocalFSBackend struct {
+	root string
+}
+
+// localUpload is the state of an upload session, carried in its ID
+type localUpload struct {
+	Target     string    `json:"target"`
+	Size       int64     `json:"size"`
+	ModTime    time.Time `json:"mod_time,omitempty"`
+	Executable bool      `json:"executable,omitempty"`
+}
+
+// NewLocalFSBackend creates a backend rooted at dir, creating it if needed
+func NewLocalFSBackend(dir string) (*LocalFSBackend, error) {
+	if strings.HasPrefix(dir, "~/") {
+		dir = filepath.Join(os.Getenv("HOME"), dir[2:])
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote path %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create remote directory %s: %w", root, err)
+	}
+	return &LocalFSBackend{root: root}, nil
+}
+
+// Root returns the directory the backend stores files in
+func (b *LocalFSBackend) Root() string {
+	return b.root
+}
+
+// ListFilesPage lists one page of the children of a folder, sorted by name
+func (b *LocalFSBackend) ListFilesPage(ctx context.Context, folderID string, offset, limit int) ([]FileInfo, error) {
+	id, dir, err := b.resolve(folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, localError("list", err)
+	}
+
+	var files []FileInfo
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), localTempPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, *b.fileInfo(path.Join(id, entry.Name()), info))
+	}
+
+	if offset >= len(files) {
+		return nil, nil
+	}
+	files = files[offset:]
+	if limit > 0 && len(files) > limit {
+		files = files[:limit]
+	}
+	return files, nil
+}
+
+// GetFileInfo returns the metadata of a file or folder
+func (b *LocalFSBackend) GetFileInfo(ctx context.Context, fileID string) (*FileInfo, error) {
+	id, target, err := b.resolve(fileID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, localError("API request", err)
+	}
+	return b.fileInfo(id, info), nil
+}
+
+// DownloadFile opens a file for reading
+func (b *LocalFSBackend) DownloadFile(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	_, target, err := b.resolve(fileID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if err != nil {
+		return nil, localError("download", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, localError("download", err)
+	}
+	return progressBody(ctx, file, 0, info.Size()), nil
+}
+
+// CreateFolder creates a folder, returning the existing one if name is
+// already taken by a folder
+func (b *LocalFSBackend) CreateFolder(ctx context.Context, parentID, name string) (*FileInfo, error) {
+	id, target, err := b.child(parentID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Mkdir(target, 0755); err != nil && !os.IsExist(err) {
+		return nil, localError("folder creation", err)
+	}
+	return b.GetFileInfo(ctx, id)
+}
+
+// InitiateUploadWithMetadata starts an upload of name into parentID. No
+// state is kept; the returned ID describes the upload.
+func (b *LocalFSBackend) InitiateUploadWithMetadata(ctx context.Context, filename string, fileSize int64, parentID string, metadata *UploadMetadata) (*FileUploadInfo, error) {
+	id, _, err := b.child(parentID, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	upload := localUpload{Target: id, Size: fileSize}
+	if metadata != nil {
+		upload.ModTime = metadata.ModifiedTime
+		upload.Executable = metadata.Executable
+	}
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileUploadInfo{
+		UploadID:  base64.RawURLEncoding.EncodeToString(data),
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}, nil
+}
+
+// UploadContent writes the content of an upload session to its target. The
+// file is replaced atomically, and only if it still matches session.IfMatch.
+func (b *LocalFSBackend) UploadContent(ctx context.Context, session *FileUploadInfo, content io.Reader, size int64) (*FileInfo, error) {
+	data, err := base64.RawURLEncoding.DecodeString(session.UploadID)
+	if err != nil {
+		return nil, newStatusError("upload", http.StatusNotFound)
+	}
+	var upload localUpload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, newStatusError("upload", http.StatusNotFound)
+	}
+
+	id, target, err := b.resolve(upload.Target)
+	if err != nil {
+		return nil, err
+	}
+	if session.IfMatch != "" {
+		if current, err := os.Stat(target); err == nil && localVersion(current) != session.IfMatch {
+			return nil, newStatusError("upload", http.StatusPreconditionFailed)
+		}
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(target), localTempPrefix+"*")
+	if err != nil {
+		return nil, localError("upload", err)
+	}
+	defer os.Remove(temp.Name())
+
+	written, err := io.Copy(temp, withProgress(ctx, content, size))
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("upload failed: %w", err)
+	}
+	if size >= 0 && written != size {
+		return nil, fmt.Errorf("upload failed: wrote %d of %d bytes", written, size)
+	}
+
+	mode := os.FileMode(0644)
+	if upload.Executable {
+		mode = 0755
+	}
+	if err := os.Chmod(temp.Name(), mode); err != nil {
+		return nil, localError("upload", err)
+	}
+	if !upload.ModTime.IsZero() {
+		if err := os.Chtimes(temp.Name(), upload.ModTime, upload.ModTime); err != nil {
+			return nil, localError("upload", err)
+		}
+	}
+	if err := os.Rename(temp.Name(), target); err != nil {
+		return nil, localError("upload", err)
+	}
+
+	return b.GetFileInfo(ctx, id)
+}
+
+// AbortUpload is a no-op; partial uploads never outlive UploadContent
+func (b *LocalFSBackend) AbortUpload(ctx context.Context, uploadID string) error {
+	return nil
+}
+
+// DeleteFile deletes a file, or a folder that is empty
+func (b *LocalFSBackend) DeleteFile(ctx context.Context, fileID string) error {
+	id, target, err := b.resolve(fileID)
+	if err != nil {
+		return err
+	}
+	if id == localRootID {
+		return fmt.Errorf("refusing to delete the remote root")
+	}
+	if err := os.Remove(target); err != nil {
+		return localError("delete", err)
+	}
+	return nil
+}
+
+// DeleteFolder deletes a folder together with all of its contents
+func (b *LocalFSBackend) DeleteFolder(ctx context.Context, folderID string) error {
+	id, target, err := b.resolve(folderID)
+	if err != nil {
+		return err
+	}
+	if id == localRootID {
+		return fmt.Errorf("refusing to delete the remote root")
+	}
+	if err := os.RemoveAll(target); err != nil {
+		return localError("delete", err)
+	}
+	return nil
+}
+
+// MoveFolder moves and/or renames a folder, carrying all of its contents
+func (b *LocalFSBackend) MoveFolder(ctx context.Context, folderID, newParentID, newName string) (*FileInfo, error) {
+	_, source, err := b.resolve(folderID)
+	if err != nil {
+		return nil, err
+	}
+	id, target, err := b.child(newParentID, newName)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(source, target); err != nil {
+		return nil, localError("folder move", err)
+	}
+	return b.GetFileInfo(ctx, id)
+}
+
+// CopyFile copies a file into parentID under name
+func (b *LocalFSBackend) CopyFile(ctx context.Context, sourceID, parentID, name string) (*FileInfo, error) {
+	sourceInfo, err := b.GetFileInfo(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := b.DownloadFile(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	session, err := b.InitiateUploadWithMetadata(ctx, name, sourceInfo.Size, parentID, &UploadMetadata{
+		ModifiedTime: sourceInfo.ModifiedTime,
+		Executable:   sourceInfo.Executable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.UploadContent(ctx, session, source, sourceInfo.Size)
+}
+
+// resolve maps a file ID to its normalized form and its path on disk. IDs
+// are cleaned as absolute paths, so they cannot escape the root.
+func (b *LocalFSBackend) resolve(fileID string) (string, string, error) {
+	if fileID == "root" || fileID == "" {
+		fileID = localRootID
+	}
+	if !strings.HasPrefix(fileID, "/") {
+		return "", "", newStatusError("API request", http.StatusNotFound)
+	}
+	id := path.Clean(fileID)
+	return id, filepath.Join(b.root, filepath.FromSlash(id)), nil
+}
+
+// child resolves the entry name inside parentID
+func (b *LocalFSBackend) child(parentID, name string) (string, string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return "", "", fmt.Errorf("invalid name %q", name)
+	}
+	parent, _, err := b.resolve(parentID)
+	if err != nil {
+		return "", "", err
+	}
+	return b.resolve(path.Join(parent, name))
+}
+
+// fileInfo describes the entry with the given ID
+func (b *LocalFSBackend) fileInfo(id string, info os.FileInfo) *FileInfo {
+	file := &FileInfo{
+		ID:           id,
+		Name:         info.Name(),
+		ModifiedTime: info.ModTime(),
+		ParentID:     path.Dir(id),
+		Path:         id,
+		IsFolder:     info.IsDir(),
+		Executable:   !info.IsDir() && info.Mode()&0111 != 0,
+	}
+	if id == localRootID {
+		file.Name = ""
+		file.ParentID = ""
+	}
+	if file.IsFolder {
+		file.Type = "folder"
+	} else {
+		file.Type = "file"
+		file.Size = info.Size()
+		file.Version = localVersion(info)
+	}
+	return file
+}
+
+// localVersion identifies a revision of a file, standing in for an ETag
+func localVersion(info os.FileInfo) string {
+	return fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+}
+
+// localError converts filesystem errors into the status errors the Zoho
+// client returns, so the engine classifies both backends alike
+func localError(operation string, err error) error {
+	switch {
+	case os.IsNotExist(err):
+		return newStatusError(operation, http.StatusNotFound)
+	case os.IsPermission(err):
+		return newStatusError(operation, http.StatusForbidden)
+	default:
+		return fmt.Errorf("%s failed: %w", operation, err)
+	}
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadLocal uploads content as name into parentID through the backend
+func uploadLocal(t *testing.T, backend *LocalFSBackend, parentID, name, content string, metadata *UploadMetadata) *FileInfo {
+	t.Helper()
+	ctx := context.Background()
+
+	session, err := backend.InitiateUploadWithMetadata(ctx, name, int64(len(content)), parentID, metadata)
+	require.NoError(t, err)
+	info, err := backend.UploadContent(ctx, session, strings.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	return info
+}
+
+func TestLocalFSBackendUploadAndDownload(t *testing.T) {
+	backend, err := NewLocalFSBackend(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	folder, err := backend.CreateFolder(ctx, "root", "docs")
+	require.NoError(t, err)
+	assert.Equal(t, "/docs", folder.ID)
+	assert.True(t, folder.IsFolder)
+
+	modTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	info := uploadLocal(t, backend, folder.ID, "run.sh", "#!/bin/sh\n", &UploadMetadata{ModifiedTime: modTime, Executable: true})
+	assert.Equal(t, "/docs/run.sh", info.ID)
+	assert.Equal(t, "/docs", info.ParentID)
+	assert.Equal(t, int64(10), info.Size)
+	assert.True(t, info.Executable)
+	assert.True(t, info.ModifiedTime.Equal(modTime))
+	assert.NotEmpty(t, info.Version)
+
+	reader, err := backend.DownloadFile(ctx, info.ID)
+	require.NoError(t, err)
+	defer reader.Close()
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(content))
+
+	entries, err := os.ReadDir(filepath.Join(backend.Root(), "docs"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no partial upload is left behind")
+}
+
+func TestLocalFSBackendListsInPages(t *testing.T) {
+	backend, err := NewLocalFSBackend(t.TempDir())
+	require.NoError(t, err)
+	for _, name := range []string{"c.txt", "a.txt", "b.txt"} {
+		uploadLocal(t, backend, "root", name, name, nil)
+	}
+
+	first, err := backend.ListFilesPage(context.Background(), "root", 0, 2)
+	require.NoError(t, err)
+	second, err := backend.ListFilesPage(context.Background(), "root", 2, 2)
+	require.NoError(t, err)
+
+	require.Len(t, first, 2)
+	require.Len(t, second, 1)
+	assert.Equal(t, []string{"a.txt", "b.txt", "c.txt"}, []string{first[0].Name, first[1].Name, second[0].Name})
+}
+
+func TestLocalFSBackendRejectsStaleIfMatch(t *testing.T) {
+	backend, err := NewLocalFSBackend(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	original := uploadLocal(t, backend, "root", "plan.txt", "v1", nil)
+	require.NoError(t, os.WriteFile(filepath.Join(backend.Root(), "plan.txt"), []byte("changed elsewhere"), 0644))
+
+	session, err := backend.InitiateUploadWithMetadata(ctx, "plan.txt", 2, "root", nil)
+	require.NoError(t, err)
+	session.IfMatch = original.Version
+	_, err = backend.UploadContent(ctx, session, strings.NewReader("v2"), 2)
+	assert.True(t, IsPreconditionFailed(err))
+
+	content, err := os.ReadFile(filepath.Join(backend.Root(), "plan.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "changed elsewhere", string(content))
+}
+
+func TestLocalFSBackendMoveCopyAndDelete(t *testing.T) {
+	backend, err := NewLocalFSBackend(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	folder, err := backend.CreateFolder(ctx, "root", "old")
+	require.NoError(t, err)
+	file := uploadLocal(t, backend, folder.ID, "a.txt", "hello", nil)
+
+	moved, err := backend.MoveFolder(ctx, folder.ID, "root", "new")
+	require.NoError(t, err)
+	assert.Equal(t, "/new", moved.ID)
+
+	_, err = backend.GetFileInfo(ctx, file.ID)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, 404, statusErr.StatusCode)
+
+	copied, err := backend.CopyFile(ctx, "/new/a.txt", "root", "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "/b.txt", copied.ID)
+	assert.Equal(t, int64(5), copied.Size)
+
+	require.NoError(t, backend.DeleteFile(ctx, copied.ID))
+	assert.NoFileExists(t, filepath.Join(backend.Root(), "b.txt"))
+	assert.Error(t, backend.DeleteFile(ctx, moved.ID), "a folder with contents is deleted with DeleteFolder")
+	assert.Error(t, backend.DeleteFile(ctx, "root"))
+
+	require.NoError(t, backend.DeleteFolder(ctx, moved.ID))
+	assert.NoDirExists(t, filepath.Join(backend.Root(), "new"))
+	assert.Error(t, backend.DeleteFolder(ctx, "root"))
+}
+
+func TestLocalFSBackendStaysInsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	backend, err := NewLocalFSBackend(filepath.Join(parent, "remote"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644))
+
+	_, err = backend.GetFileInfo(context.Background(), "/../secret.txt")
+	assert.Error(t, err)
+	_, err = backend.CreateFolder(context.Background(), "root", "..")
+	assert.Error(t, err)
+}
+
+func TestNewBackendSelectsRemoteType(t *testing.T) {
+	backend, err := NewBackend(&types.Config{}, &types.TokenInfo{AccessToken: "token"})
+	require.NoError(t, err)
+	assert.IsType(t, &Client{}, backend)
+
+	backend, err = NewBackend(&types.Config{Remote: types.RemoteConfig{Type: RemoteTypeLocal, Path: t.TempDir()}}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &LocalFSBackend{}, backend)
+
+	_, err = NewBackend(&types.Config{Remote: types.RemoteConfig{Type: RemoteTypeLocal}}, nil)
+	assert.Error(t, err)
+	_, err = NewBackend(&types.Config{Remote: types.RemoteConfig{Type: "ftp"}}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrLockHeld is returned by AcquireLock when another client holds the lock
+var ErrLockHeld = errors.New("file is locked by another client")
+
+// Lock is an advisory lock on a remote file. It only keeps out clients that
+// ask for the lock too, and expires on its own so that a client that
+// crashes while holding it cannot block the file for good.
+type Lock struct {
+	ID        string    `json:"lock_id"`
+	FileID    string    `json:"file_id"`
+	Owner     string    `json:"owner,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Locker is implemented by backends that support advisory locks, which
+// clients sharing a workspace take before changing a file
+type Locker interface {
+	// AcquireLock locks fileID for ttl, failing with ErrLockHeld if
+	// another client holds an unexpired lock on it
+	AcquireLock(ctx context.Context, fileID string, ttl time.Duration) (*Lock, error)
+	// ReleaseLock gives up a lock before it expires
+	ReleaseLock(ctx context.Context, lock *Lock) error
+}
+
+var _ Locker = (*Client)(nil)
+
+// AcquireLock takes an advisory lock on a file
+func (c *Client) AcquireLock(ctx context.Context, fileID string, ttl time.Duration) (*Lock, error) {
+	endpoint := fmt.Sprintf("/files/%s/lock", fileID)
+	body := map[string]interface{}{"ttl": int(ttl.Seconds())}
+
+	resp, err := c.makeRequest(ctx, "POST", endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data Lock `json:"data"`
+	}
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusConflict, http.StatusLocked:
+		// The body describes the lock in the way, if the server says
+		if json.NewDecoder(resp.Body).Decode(&result) == nil && result.Data.Owner != "" {
+			return nil, fmt.Errorf("%w (%s, until %s)", ErrLockHeld, result.Data.Owner, result.Data.ExpiresAt.Local().Format("15:04:05"))
+		}
+		return nil, ErrLockHeld
+	default:
+		return nil, newResponseError("lock", resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Data.FileID == "" {
+		result.Data.FileID = fileID
+	}
+	return &result.Data, nil
+}
+
+// ReleaseLock releases an advisory lock. A lock that already expired counts
+// as released.
+func (c *Client) ReleaseLock(ctx context.Context, lock *Lock) error {
+	endpoint := fmt.Sprintf("/files/%s/lock/%s", lock.FileID, lock.ID)
+
+	resp, err := c.makeRequest(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return newResponseError("unlock", resp)
+	}
+}
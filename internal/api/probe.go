@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Prober is implemented by backends that can cheaply check whether the
+// remote can be reached, without transferring anything
+type Prober interface {
+	// Probe returns an error if the remote cannot be reached. Any answer
+	// from the remote, even an error status, counts as reachable.
+	Probe(ctx context.Context) error
+}
+
+var (
+	_ Prober = (*Client)(nil)
+	_ Prober = (*LocalFSBackend)(nil)
+)
+
+// Probe sends a HEAD request to the API and reports whether it got through
+func (c *Client) Probe(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create probe request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("API unreachable: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Probe checks that the remote directory is still there, for instance that
+// the share it lives on is mounted
+func (b *LocalFSBackend) Probe(ctx context.Context) error {
+	if _, err := os.Stat(b.root); err != nil {
+		return fmt.Errorf("remote unreachable: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ProgressFunc is called as the bytes of a download or upload move, with
+// the bytes moved so far and the total, or -1 if the total is not known
+type ProgressFunc func(bytesDone, total int64)
+
+// progressKey carries the ProgressFunc of a context
+type progressKey struct{}
+
+// WithProgress returns a context whose downloads and uploads call fn as
+// their bytes move. A resumed download counts from where it resumes, against
+// the size of the whole file; a chunk counts only its own bytes. A retried
+// request starts over.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressReader reports the bytes read through it to a ProgressFunc
+type progressReader struct {
+	reader io.Reader
+	fn     ProgressFunc
+	done   int64
+	total  int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.done += int64(n)
+		r.fn(r.done, r.total)
+	}
+	return n, err
+}
+
+// withProgress wraps r, of total bytes, to report its reads to the
+// ProgressFunc of ctx, if it has one
+func withProgress(ctx context.Context, r io.Reader, total int64) io.Reader {
+	return withProgressFrom(ctx, r, 0, total)
+}
+
+// withProgressFrom is withProgress for content that starts done bytes into
+// the total
+func withProgressFrom(ctx context.Context, r io.Reader, done, total int64) io.Reader {
+	fn := progressFunc(ctx)
+	if fn == nil {
+		return r
+	}
+	if total < 0 {
+		total = -1
+	}
+	return &progressReader{reader: r, fn: fn, done: done, total: total}
+}
+
+// progressBody wraps a download body like withProgressFrom, keeping its
+// Close
+func progressBody(ctx context.Context, body io.ReadCloser, done, total int64) io.ReadCloser {
+	if progressFunc(ctx) == nil {
+		return body
+	}
+	return limitedReadCloser{Reader: withProgressFrom(ctx, body, done, total), Closer: body}
+}
+
+// responseProgress returns where the body of a download response starts
+// within the file and the size of the file, -1 if unknown. Partial content
+// says both in its Content-Range.
+func responseProgress(resp *http.Response) (int64, int64) {
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, resp.ContentLength
+	}
+	var first, last, size int64
+	if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/%d", &first, &last, &size); err != nil {
+		return 0, -1
+	}
+	return first, size
+}
+
+// progressFunc returns the ProgressFunc of ctx, or nil
+func progressFunc(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
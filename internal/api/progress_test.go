@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressRecorder collects the calls of a ProgressFunc
+type progressRecorder struct {
+	done   []int64
+	totals []int64
+}
+
+func (r *progressRecorder) record(done, total int64) {
+	r.done = append(r.done, done)
+	r.totals = append(r.totals, total)
+}
+
+// assertProgress checks that the byte counts only grew, from past start,
+// and ended at size, each against size as the total
+func (r *progressRecorder) assertProgress(t *testing.T, start, size int64) {
+	t.Helper()
+	require.Greater(t, len(r.done), 1, "progress is reported as the bytes move")
+	assert.Greater(t, r.done[0], start)
+	for i := 1; i < len(r.done); i++ {
+		assert.Greater(t, r.done[i], r.done[i-1], "byte counts increase")
+	}
+	assert.Equal(t, size, r.done[len(r.done)-1])
+	for _, total := range r.totals {
+		assert.Equal(t, size, total)
+	}
+}
+
+func TestTransfersReportProgress(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 64<<10)
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/big/download":
+			http.ServeContent(w, r, "big", time.Time{}, bytes.NewReader(content))
+		case "/upload/upload1":
+			uploaded, _ = io.ReadAll(r.Body)
+			w.Write([]byte(`{"data":{"id":"big"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	var downloads progressRecorder
+	body, err := client.DownloadFile(WithProgress(context.Background(), downloads.record), "big")
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	body.Close()
+	assert.Equal(t, content, data)
+	downloads.assertProgress(t, 0, int64(len(content)))
+
+	// A resumed download counts the bytes it resumes after
+	var resumed progressRecorder
+	body, partial, err := client.DownloadFileRange(WithProgress(context.Background(), resumed.record), "big", 1000, "")
+	require.NoError(t, err)
+	require.True(t, partial)
+	data, err = io.ReadAll(body)
+	require.NoError(t, err)
+	body.Close()
+	assert.Equal(t, content[1000:], data)
+	resumed.assertProgress(t, 1000, int64(len(content)))
+
+	var uploads progressRecorder
+	session := &FileUploadInfo{UploadID: "upload1"}
+	_, err = client.UploadContent(WithProgress(context.Background(), uploads.record), session, bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	assert.Equal(t, content, uploaded)
+	uploads.assertProgress(t, 0, int64(len(content)))
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+)
+
+// Bounds on how much a rate-limited reader reads at once, so that waits stay
+// short and a changed limit takes effect quickly
+const (
+	minLimitedRead = 1024
+	maxLimitedRead = 32 * 1024
+)
+
+// RateLimiter is a token bucket that caps transfer throughput in bytes per
+// second. A limit of zero means unlimited. The limit can be changed while
+// transfers are running.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+	// changed is closed and replaced whenever the limit changes so that
+	// waiters recompute their delay
+	changed chan struct{}
+}
+
+// NewRateLimiter creates a limiter allowing bytesPerSecond, or unlimited
+// throughput if bytesPerSecond is zero or negative
+func NewRateLimiter(bytesPerSecond int64) *RateLimiter {
+	l := &RateLimiter{clock: clock.Real{}, changed: make(chan struct{})}
+	l.SetLimit(bytesPerSecond)
+	return l
+}
+
+// SetClock sets the clock the limiter measures and waits with
+func (l *RateLimiter) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = c
+	l.last = c.Now()
+}
+
+// SetLimit changes the allowed throughput. Transfers waiting on the limiter
+// pick up the new rate immediately.
+func (l *RateLimiter) SetLimit(bytesPerSecond int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.refill(now)
+
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+	if l.rate == 0 {
+		// Coming from unlimited, start with a full bucket
+		l.tokens = float64(bytesPerSecond)
+	}
+	l.rate = float64(bytesPerSecond)
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Limit returns the current limit in bytes per second, zero if unlimited
+func (l *RateLimiter) Limit() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(l.rate)
+}
+
+// refill adds the tokens earned since the last update, holding at most one
+// second's worth. The caller must hold l.mu.
+func (l *RateLimiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.rate {
+			l.tokens = l.rate
+		}
+	}
+	l.last = now
+}
+
+// WaitN accounts for n transferred bytes and blocks until the limit allows
+// more, or ctx is done
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	l.refill(l.clock.Now())
+	l.tokens -= float64(n)
+	l.mu.Unlock()
+
+	for {
+		l.mu.Lock()
+		if l.rate <= 0 {
+			l.tokens = 0
+			l.mu.Unlock()
+			return nil
+		}
+		l.refill(l.clock.Now())
+		if l.tokens >= 0 {
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+		changed := l.changed
+		after := l.clock.After(wait)
+		l.mu.Unlock()
+
+		select {
+		case <-after:
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// readSize returns how many bytes a limited reader should read at once
+func (l *RateLimiter) readSize() int {
+	limit := l.Limit()
+	switch {
+	case limit == 0 || limit/10 > maxLimitedRead:
+		return maxLimitedRead
+	case limit/10 < minLimitedRead:
+		return minLimitedRead
+	default:
+		return int(limit / 10)
+	}
+}
+
+// Reader wraps r so that reads from it are throttled by the limiter
+func (l *RateLimiter) Reader(ctx context.Context, r io.Reader) io.Reader {
+	return &limitedReader{ctx: ctx, reader: r, limiter: l}
+}
+
+// limitedReader throttles reads through a RateLimiter
+type limitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *RateLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if size := r.limiter.readSize(); len(p) > size {
+		p = p[:size]
+	}
+
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// limitedReadCloser throttles reads while closing the underlying body
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
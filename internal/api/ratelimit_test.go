@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// zeroReader yields an endless stream of zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// countingWriter counts the bytes written to it
+type countingWriter struct {
+	n atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n.Add(int64(len(p)))
+	return len(p), nil
+}
+
+func TestRateLimiterSetLimitMidTransfer(t *testing.T) {
+	limiter := NewRateLimiter(400 * 1024)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	counter := &countingWriter{}
+	go io.Copy(counter, limiter.Reader(ctx, zeroReader{}))
+
+	throughput := func(window time.Duration) float64 {
+		start := counter.n.Load()
+		time.Sleep(window)
+		return float64(counter.n.Load()-start) / window.Seconds()
+	}
+
+	// Let the initial burst drain before measuring
+	time.Sleep(200 * time.Millisecond)
+	fast := throughput(500 * time.Millisecond)
+
+	limiter.SetLimit(50 * 1024)
+	time.Sleep(100 * time.Millisecond)
+	slow := throughput(500 * time.Millisecond)
+
+	assert.InDelta(t, 400*1024, fast, 150*1024)
+	assert.Less(t, slow, fast/3)
+	assert.Equal(t, int64(50*1024), limiter.Limit())
+}
+
+func TestRateLimiterWaitersSeeNewLimit(t *testing.T) {
+	limiter := NewRateLimiter(1024)
+
+	done := make(chan error, 1)
+	go func() {
+		// Ten seconds' worth of data at the initial limit
+		done <- limiter.WaitN(context.Background(), 11*1024)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	limiter.SetLimit(0)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("waiter did not pick up the removed limit")
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	limiter := NewRateLimiter(1024)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := limiter.WaitN(ctx, 10*1024)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimiterWaitsOnClock(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	limiter := NewRateLimiter(1024)
+	limiter.SetClock(fake)
+
+	done := make(chan error, 1)
+	go func() {
+		// One second's worth beyond the initial full bucket
+		done <- limiter.WaitN(context.Background(), 2048)
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(999 * time.Millisecond)
+	fake.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatal("waiter returned before the limit allowed")
+	default:
+	}
+
+	fake.Advance(time.Millisecond)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("waiter did not return once the limit allowed")
+	}
+}
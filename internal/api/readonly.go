@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrReadOnly is returned for requests that would change anything remotely
+// when the login only grants read access
+var ErrReadOnly = errors.New("the login only grants read access to WorkDrive; set auth.scope_profile to full and run 'zohosync-cli login' again to sync local changes")
+
+// writeScopes are the OAuth scopes that allow changing files
+var writeScopes = []string{"WorkDrive.files.ALL", "WorkDrive.files.CREATE", "WorkDrive.files.UPDATE"}
+
+// GrantsWrites reports whether a token scope allows changing files. Tokens
+// saved before their scope was recorded have none and are assumed to.
+func GrantsWrites(scope string) bool {
+	if strings.TrimSpace(scope) == "" {
+		return true
+	}
+	granted := strings.FieldsFunc(scope, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	for _, s := range granted {
+		for _, write := range writeScopes {
+			if strings.EqualFold(s, write) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadOnly reports whether the client refuses requests that change
+// anything, because its token only grants read access
+func (c *Client) ReadOnly() bool {
+	return c.token != nil && !GrantsWrites(c.token.Scope)
+}
+
+// checkWritable fails requests other than reads with ErrReadOnly if the
+// client is read-only, before they reach the server
+func (c *Client) checkWritable(req *http.Request) error {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || !c.ReadOnly() {
+		return nil
+	}
+	return ErrReadOnly
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyClientRejectsUploads(t *testing.T) {
+	var writes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			atomic.AddInt32(&writes, 1)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"id":"file1","name":"report.txt"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token", Scope: "WorkDrive.files.READ,WorkDrive.folders.READ"})
+	client.SetBaseURL(server.URL)
+	require.True(t, client.ReadOnly())
+	ctx := context.Background()
+
+	_, err := client.InitiateUpload(ctx, "report.txt", 6, "root")
+	require.ErrorIs(t, err, ErrReadOnly)
+	assert.Contains(t, err.Error(), "auth.scope_profile to full")
+	assert.Contains(t, err.Error(), "zohosync-cli login")
+
+	_, err = client.CreateFolder(ctx, "root", "Reports")
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.Zero(t, atomic.LoadInt32(&writes), "nothing is sent to the server")
+
+	// Reading still works
+	info, err := client.GetFileInfo(ctx, "file1")
+	require.NoError(t, err)
+	assert.Equal(t, "report.txt", info.Name)
+
+	// A login granting write access lifts the restriction
+	client.SetToken(&types.TokenInfo{AccessToken: "test_token", Scope: "WorkDrive.files.ALL,WorkDrive.folders.ALL"})
+	assert.False(t, client.ReadOnly())
+	_, err = client.CreateFolder(ctx, "root", "Reports")
+	assert.NotErrorIs(t, err, ErrReadOnly)
+}
+
+func TestGrantsWrites(t *testing.T) {
+	assert.True(t, GrantsWrites(""), "tokens without a recorded scope are assumed to")
+	assert.True(t, GrantsWrites("workdrive.files.all"))
+	assert.True(t, GrantsWrites("WorkDrive.files.READ WorkDrive.files.CREATE"))
+	assert.False(t, GrantsWrites("WorkDrive.files.READ,WorkDrive.folders.ALL"), "changing folders alone does not upload files")
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries the correlation ID of each request, so that the
+// request log, the errors it causes and the server's own logs can be
+// matched up
+const RequestIDHeader = "X-Request-ID"
+
+// Request log levels, set by api.request_log. Requests are logged at debug
+// level, so nothing shows unless the log level is debug as well.
+const (
+	// RequestLogOff logs nothing
+	RequestLogOff = "off"
+	// RequestLogRequests logs a line per request with its outcome
+	RequestLogRequests = "requests"
+	// RequestLogHeaders also logs the request and response headers, with
+	// credentials redacted
+	RequestLogHeaders = "headers"
+)
+
+// redactedHeaders are never logged as they are
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// SetRequestLog sets how much is logged about each request. Unknown values
+// log requests without headers.
+func (c *Client) SetRequestLog(level string) {
+	switch level = strings.ToLower(strings.TrimSpace(level)); level {
+	case RequestLogOff, RequestLogHeaders:
+		c.requestLog = level
+	default:
+		c.requestLog = RequestLogRequests
+	}
+}
+
+// do sends req with the client's User-Agent, tagged with a new correlation
+// ID, and logs it. A request that
+// gets no response fails with a RequestError carrying the ID; status errors
+// for the response get it through newResponseError. Requests that would
+// change anything fail with ErrReadOnly if the client is read-only. Unless
+// it is a transfer, the request and the reading of its response are bound
+// by the request timeout.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.checkWritable(req); err != nil {
+		return nil, err
+	}
+
+	id := newRequestID()
+	req.Header.Set(RequestIDHeader, id)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	req, release := c.withDeadline(req)
+	started := time.Now()
+	resp, err := c.httpClient.Do(req)
+	c.logRequest(id, req, resp, err, time.Since(started))
+	if err != nil {
+		release()
+		return nil, &RequestError{RequestID: id, Err: err}
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: release}
+	return resp, nil
+}
+
+// logRequest logs a request and its outcome at debug level. Only the path
+// of the URL is logged, as query strings can hold credentials.
+func (c *Client) logRequest(id string, req *http.Request, resp *http.Response, err error, elapsed time.Duration) {
+	if c.requestLog == RequestLogOff || !c.logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+
+	fields := logrus.Fields{
+		"request_id": id,
+		"method":     req.Method,
+		"endpoint":   req.URL.Path,
+		"duration":   elapsed.Round(time.Millisecond),
+	}
+	if req.ContentLength > 0 {
+		fields["bytes_sent"] = req.ContentLength
+	}
+	if resp != nil {
+		fields["status"] = resp.StatusCode
+		if resp.ContentLength >= 0 {
+			fields["bytes_received"] = resp.ContentLength
+		}
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	if c.requestLog == RequestLogHeaders {
+		fields["request_headers"] = redactHeaders(req.Header)
+		if resp != nil {
+			fields["response_headers"] = redactHeaders(resp.Header)
+		}
+	}
+	c.logger.WithFields(fields).Debug("API request")
+}
+
+// redactHeaders returns a copy of headers with credentials replaced
+func redactHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for _, name := range redactedHeaders {
+		if redacted.Get(name) != "" {
+			redacted.Set(name, "REDACTED")
+		}
+	}
+	return redacted
+}
+
+// newRequestID returns a random correlation ID
+func newRequestID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLoggedClient returns a client for server whose debug log is captured
+// as JSON lines
+func newLoggedClient(server *httptest.Server, level string) (*Client, *bytes.Buffer) {
+	var output bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&output)
+	logger.SetLevel(logrus.DebugLevel)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	client := NewClient(&types.TokenInfo{AccessToken: "secret_token"})
+	client.SetBaseURL(server.URL)
+	client.SetRequestLog(level)
+	client.logger = logger
+	return client, &output
+}
+
+// requestLogs returns the request log entries in output
+func requestLogs(t *testing.T, output *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		if entry["msg"] == "API request" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestRequestLogLinksErrorToRequest(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(RequestIDHeader)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client, output := newLoggedClient(server, RequestLogHeaders)
+
+	_, err := client.GetUserInfo(context.Background())
+	require.Error(t, err)
+
+	id := RequestID(err)
+	require.NotEmpty(t, id)
+	assert.Equal(t, received, id, "the server is sent the correlation ID")
+	assert.Contains(t, err.Error(), id)
+
+	logged := output.String()
+	assert.NotContains(t, logged, "secret_token", "credentials are redacted")
+	assert.Contains(t, logged, "REDACTED")
+
+	entries := requestLogs(t, output)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, id, entry["request_id"])
+	assert.Equal(t, "GET", entry["method"])
+	assert.Equal(t, "/users/me", entry["endpoint"])
+	assert.Equal(t, float64(http.StatusServiceUnavailable), entry["status"])
+	assert.Contains(t, entry, "duration")
+}
+
+func TestRequestLogWithoutResponse(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	client, output := newLoggedClient(server, RequestLogRequests)
+	server.Close()
+
+	_, err := client.GetUserInfo(context.Background())
+	require.Error(t, err)
+	var requestErr *RequestError
+	require.True(t, errors.As(err, &requestErr))
+
+	entries := requestLogs(t, output)
+	require.Len(t, entries, 1)
+	assert.Equal(t, requestErr.RequestID, entries[0]["request_id"])
+	assert.Contains(t, entries[0], "error")
+	assert.NotContains(t, entries[0], "request_headers", "headers are only logged when asked for")
+}
+
+func TestRequestLogOff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"id":"u1"}}`))
+	}))
+	defer server.Close()
+	client, output := newLoggedClient(server, RequestLogOff)
+
+	_, err := client.GetUserInfo(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, requestLogs(t, output))
+}
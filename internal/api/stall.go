@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultStallTimeout is how long a transfer may go without moving any data
+// before it is abandoned
+const DefaultStallTimeout = 60 * time.Second
+
+// ErrTransferStalled is returned when an upload or download stops moving
+// data for longer than the stall timeout. The transfer can be retried.
+var ErrTransferStalled = errors.New("transfer stalled")
+
+// SetStallTimeout sets how long a transfer may go without moving data before
+// it is cancelled. Zero disables stall detection.
+func (c *Client) SetStallTimeout(timeout time.Duration) {
+	c.stallTimeout = timeout
+}
+
+// watchStalls returns a context for a transfer that is cancelled when the
+// transfer stalls, along with the watchdog that tracks its progress. The
+// watchdog is nil when stall detection is off; its methods then do nothing.
+// The context is marked as a transfer so the request timeout leaves it be.
+func (c *Client) watchStalls(ctx context.Context) (context.Context, *stallWatchdog) {
+	ctx = asTransfer(ctx)
+	if c.stallTimeout <= 0 {
+		return ctx, nil
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, &stallWatchdog{ctx: ctx, cancel: cancel, timeout: c.stallTimeout}
+}
+
+// stallWatchdog cancels a transfer that goes too long without progress. Time
+// only counts while the transfer waits on the network, between begin and
+// end, so a caller that is slow to read a download does not stall it; a
+// transfer that is merely slow keeps making progress and is left alone.
+type stallWatchdog struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timeout time.Duration
+
+	mu       sync.Mutex
+	busy     int
+	deadline time.Time
+	timer    *time.Timer
+}
+
+// begin marks the start of a wait on the network
+func (w *stallWatchdog) begin() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.busy++
+	if w.busy > 1 {
+		return
+	}
+	w.deadline = time.Now().Add(w.timeout)
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.timeout, w.expire)
+	} else {
+		w.timer.Reset(w.timeout)
+	}
+}
+
+// progress records that data moved
+func (w *stallWatchdog) progress() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.busy > 0 {
+		w.deadline = time.Now().Add(w.timeout)
+	}
+}
+
+// end marks the end of a wait on the network
+func (w *stallWatchdog) end() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.busy > 0 {
+		w.busy--
+	}
+	if w.busy == 0 && w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// expire cancels the transfer unless it made progress since the timer was
+// set, in which case the timer is set again for the remaining time
+func (w *stallWatchdog) expire() {
+	w.mu.Lock()
+	if w.busy == 0 {
+		w.mu.Unlock()
+		return
+	}
+	if remaining := time.Until(w.deadline); remaining > 0 {
+		w.timer.Reset(remaining)
+		w.mu.Unlock()
+		return
+	}
+	w.mu.Unlock()
+	w.cancel(ErrTransferStalled)
+}
+
+// stop ends the watch and releases its context
+func (w *stallWatchdog) stop() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.busy = 0
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mu.Unlock()
+	w.cancel(nil)
+}
+
+// err replaces an error caused by the watchdog cancelling the transfer with
+// ErrTransferStalled
+func (w *stallWatchdog) err(err error) error {
+	if w == nil || err == nil || err == io.EOF {
+		return err
+	}
+	if errors.Is(context.Cause(w.ctx), ErrTransferStalled) {
+		return fmt.Errorf("%w: no data transferred for %s", ErrTransferStalled, w.timeout)
+	}
+	return err
+}
+
+// reader returns r with each read counted as a wait on the network
+func (w *stallWatchdog) reader(r io.Reader) io.Reader {
+	if w == nil {
+		return r
+	}
+	return &stallReader{reader: r, watchdog: w}
+}
+
+// body wraps a download body so reads are watched and closing it ends the
+// watch. Reads are also throttled by limiter.
+func (w *stallWatchdog) body(ctx context.Context, limiter *RateLimiter, body io.ReadCloser) io.ReadCloser {
+	reader := limiter.Reader(ctx, w.reader(body))
+	if w == nil {
+		return limitedReadCloser{Reader: reader, Closer: body}
+	}
+	return limitedReadCloser{Reader: reader, Closer: stallCloser{body, w}}
+}
+
+// stallReader reports the reads of a transfer to its watchdog
+type stallReader struct {
+	reader   io.Reader
+	watchdog *stallWatchdog
+}
+
+func (r *stallReader) Read(p []byte) (int, error) {
+	r.watchdog.begin()
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.watchdog.progress()
+	}
+	r.watchdog.end()
+	return n, r.watchdog.err(err)
+}
+
+// stallCloser closes a download body and ends the watch of its transfer
+type stallCloser struct {
+	closer   io.Closer
+	watchdog *stallWatchdog
+}
+
+func (c stallCloser) Close() error {
+	err := c.closer.Close()
+	c.watchdog.stop()
+	return err
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStallTestClient returns a client for server with a short stall timeout
+func newStallTestClient(server *httptest.Server) *Client {
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+	client.SetStallTimeout(200 * time.Millisecond)
+	return client
+}
+
+// hang blocks a handler until the client gives up or the test ends
+func hang(t *testing.T, r *http.Request) {
+	select {
+	case <-r.Context().Done():
+	case <-time.After(10 * time.Second):
+		t.Error("the client did not give up on the stalled transfer")
+	}
+}
+
+func TestDownloadStallTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 100)))
+		w.(http.Flusher).Flush()
+		hang(t, r)
+	}))
+	defer server.Close()
+
+	body, err := newStallTestClient(server).DownloadFile(context.Background(), "file1")
+	require.NoError(t, err)
+	defer body.Close()
+
+	start := time.Now()
+	data, err := io.ReadAll(body)
+	assert.ErrorIs(t, err, ErrTransferStalled)
+	assert.Len(t, data, 100)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestSlowDownloadDoesNotStall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow, but never quiet for as long as the stall timeout
+		for i := 0; i < 8; i++ {
+			w.Write([]byte("x"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	body, err := newStallTestClient(server).DownloadFile(context.Background(), "file1")
+	require.NoError(t, err)
+	defer body.Close()
+
+	// Time the caller spends away from the body does not count either
+	first := make([]byte, 1)
+	_, err = io.ReadFull(body, first)
+	require.NoError(t, err)
+	time.Sleep(500 * time.Millisecond)
+
+	rest, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, 8, 1+len(rest))
+}
+
+func TestUploadStallTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		hang(t, r)
+	}))
+	defer server.Close()
+
+	session := &FileUploadInfo{UploadID: "upload1"}
+	_, err := newStallTestClient(server).UploadContent(context.Background(), session, bytes.NewReader([]byte("content")), 7)
+	assert.ErrorIs(t, err, ErrTransferStalled)
+}
+
+func TestStallTimeoutDisabled(t *testing.T) {
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetStallTimeout(0)
+
+	ctx, watchdog := client.watchStalls(context.Background())
+	assert.Nil(t, watchdog)
+	assert.NoError(t, ctx.Err())
+	assert.True(t, isTransfer(ctx), "transfers are still exempt from the request timeout")
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// transferKey marks the context of a request that moves file content
+type transferKey struct{}
+
+// asTransfer marks ctx as carrying a transfer. Transfers are not bound by
+// the request timeout, since a large file on a slow link can take far
+// longer while still moving; the stall watchdog ends those that stop.
+func asTransfer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, transferKey{}, true)
+}
+
+// isTransfer reports whether ctx was marked by asTransfer
+func isTransfer(ctx context.Context) bool {
+	transfer, _ := ctx.Value(transferKey{}).(bool)
+	return transfer
+}
+
+// SetRequestTimeout sets how long a request other than a transfer may take,
+// including reading its response. Zero removes the limit.
+func (c *Client) SetRequestTimeout(timeout time.Duration) {
+	c.requestTimeout = timeout
+}
+
+// withDeadline bounds req by the request timeout unless it is a transfer.
+// The returned function releases the deadline and must be called once the
+// response has been read.
+func (c *Client) withDeadline(req *http.Request) (*http.Request, context.CancelFunc) {
+	if c.requestTimeout <= 0 || isTransfer(req.Context()) {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), c.requestTimeout)
+	return req.WithContext(ctx), cancel
+}
+
+// cancelOnClose releases a request's deadline when its response body is
+// closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
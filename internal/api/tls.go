@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// ErrPinMismatch is returned when a server presents a certificate whose
+// public key does not match network.pin_sha256
+var ErrPinMismatch = errors.New("server certificate does not match the pinned public key")
+
+// TLSConfig builds the TLS settings for API connections from the network
+// config: extra trusted roots from ca_file, added to the system ones, and a
+// pin on the SHA-256 hash of the server's public key from pin_sha256. It
+// returns nil when neither is set, which keeps Go's defaults.
+func TLSConfig(network types.NetworkConfig) (*tls.Config, error) {
+	if network.CAFile == "" && network.PinSHA256 == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if network.CAFile != "" {
+		pem, err := os.ReadFile(network.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read network.ca_file: %w", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("network.ca_file %s holds no PEM certificates", network.CAFile)
+		}
+		config.RootCAs = roots
+	}
+
+	if network.PinSHA256 != "" {
+		pin, err := parsePin(network.PinSHA256)
+		if err != nil {
+			return nil, err
+		}
+		// Runs after the usual chain verification, so a pinned key still
+		// needs a certificate the roots trust
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return fmt.Errorf("%w: %s presented no certificate", ErrPinMismatch, state.ServerName)
+			}
+			got := PublicKeyPin(state.PeerCertificates[0])
+			if subtle.ConstantTimeCompare(got, pin) != 1 {
+				return fmt.Errorf("%w: %s presented sha256/%s", ErrPinMismatch,
+					state.ServerName, base64.StdEncoding.EncodeToString(got))
+			}
+			return nil
+		}
+	}
+
+	return config, nil
+}
+
+// PublicKeyPin returns the SHA-256 hash of a certificate's public key, the
+// value pinned by network.pin_sha256
+func PublicKeyPin(cert *x509.Certificate) []byte {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return sum[:]
+}
+
+// parsePin decodes a base64 public key hash, optionally written with the
+// "sha256/" prefix used by HPKP
+func parsePin(pin string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(pin), "sha256/"))
+	if err != nil || len(decoded) != sha256.Size {
+		return nil, fmt.Errorf("network.pin_sha256 must be a base64 SHA-256 hash, got %q", pin)
+	}
+	return decoded, nil
+}
+
+// failingDialer refuses every connection with err, so that a TLS setup
+// that could not be built fails closed instead of falling back to defaults
+func failingDialer(err error) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, fmt.Errorf("TLS configuration is invalid: %w", err)
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTLSTestServer starts an HTTPS server with a self-signed certificate and
+// writes that certificate to a PEM file, as a private CA would be
+func newTLSTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(block), 0644))
+	return server, caFile
+}
+
+// get makes one request through a transport built from network
+func get(network types.NetworkConfig, url string) error {
+	transport := NewTransport(network, 1)
+	defer transport.CloseIdleConnections()
+
+	resp, err := (&http.Client{Transport: transport}).Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestTransportTrustsCAFile(t *testing.T) {
+	server, caFile := newTLSTestServer(t)
+
+	assert.Error(t, get(types.NetworkConfig{}, server.URL), "a self-signed server is not trusted by default")
+	assert.NoError(t, get(types.NetworkConfig{CAFile: caFile}, server.URL))
+}
+
+func TestTransportPinsPublicKey(t *testing.T) {
+	server, caFile := newTLSTestServer(t)
+	pin := base64.StdEncoding.EncodeToString(PublicKeyPin(server.Certificate()))
+
+	assert.NoError(t, get(types.NetworkConfig{CAFile: caFile, PinSHA256: "sha256/" + pin}, server.URL))
+
+	wrong := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	err := get(types.NetworkConfig{CAFile: caFile, PinSHA256: wrong}, server.URL)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrPinMismatch)
+	assert.Contains(t, err.Error(), pin, "the error names the key the server presented")
+}
+
+func TestInvalidTLSConfigFailsClosed(t *testing.T) {
+	server, _ := newTLSTestServer(t)
+
+	for name, network := range map[string]types.NetworkConfig{
+		"missing ca file": {CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		"malformed pin":   {PinSHA256: "not-a-hash"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := TLSConfig(network)
+			require.Error(t, err)
+
+			err = get(network, server.URL)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "TLS configuration is invalid")
+
+			_, err = NewBackend(&types.Config{Network: network}, &types.TokenInfo{})
+			assert.Error(t, err)
+		})
+	}
+}
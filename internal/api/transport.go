@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Transport defaults used when the network config leaves a value unset
+const (
+	DefaultIdleConnTimeout = 90 * time.Second
+	DefaultRequestTimeout  = 30 * time.Second
+
+	// apiHosts is the number of distinct hosts the client talks to
+	// (API, upload and download)
+	apiHosts = 3
+)
+
+// NewTransport builds an HTTP transport tuned for many small requests to
+// WorkDrive. Idle connections per host are sized to twice the sync
+// concurrency so that parallel transfers reuse connections instead of
+// opening new ones, and HTTP/2 is attempted unless disabled. The TLS
+// settings come from TLSConfig; if they cannot be built, every connection
+// fails with the reason.
+func NewTransport(network types.NetworkConfig, maxConcurrent int) *http.Transport {
+	if maxConcurrent <= 0 {
+		maxConcurrent = config.DefaultMaxConcurrentSyncs
+	}
+
+	perHost := network.MaxIdleConnsPerHost
+	if perHost <= 0 {
+		perHost = 2 * maxConcurrent
+	}
+
+	maxIdle := network.MaxIdleConns
+	if maxIdle < perHost*apiHosts {
+		maxIdle = perHost * apiHosts
+	}
+
+	idleTimeout := time.Duration(network.IdleConnTimeout) * time.Second
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultIdleConnTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     network.EnableHTTP2,
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   perHost,
+		IdleConnTimeout:       idleTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	tlsConfig, err := TLSConfig(network)
+	if err != nil {
+		transport.DialContext = failingDialer(err)
+	} else {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport
+}
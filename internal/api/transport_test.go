@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientWithConfigTunesTransport(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := &types.Config{
+		Sync:    types.SyncConfig{MaxConcurrentSyncs: 8},
+		Network: types.NetworkConfig{Timeout: 45, IdleConnTimeout: 120, EnableHTTP2: true},
+	}
+	client := NewClientWithConfig(&types.TokenInfo{AccessToken: "test_token"}, cfg)
+
+	assert.Zero(t, client.httpClient.Timeout, "transfers are not cut off while they move")
+	assert.Equal(t, 45*time.Second, client.requestTimeout)
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if assert.True(t, ok, "client should use a tuned *http.Transport") {
+		assert.Greater(t, transport.MaxIdleConnsPerHost, http.DefaultMaxIdleConnsPerHost)
+		assert.Equal(t, 16, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, 48, transport.MaxIdleConns)
+		assert.Equal(t, 120*time.Second, transport.IdleConnTimeout)
+		assert.True(t, transport.ForceAttemptHTTP2)
+	}
+}
+
+// trickle yields one byte per read, every interval
+type trickle struct {
+	left     int
+	interval time.Duration
+}
+
+func (r *trickle) Read(p []byte) (int, error) {
+	if r.left == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.interval)
+	r.left--
+	p[0] = 'x'
+	return 1, nil
+}
+
+func TestTransfersOutlastNetworkTimeout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			io.Copy(io.Discard, r.Body)
+			w.Write([]byte(`{"data":{"id":"file1"}}`))
+			return
+		}
+		// Slow, but moving a byte every 100ms for well over the timeout
+		io.Copy(flushWriter{w}, &trickle{left: 15, interval: 100 * time.Millisecond})
+	}))
+	defer server.Close()
+
+	cfg := &types.Config{Network: types.NetworkConfig{Timeout: 1}}
+	client := NewClientWithConfig(&types.TokenInfo{AccessToken: "test_token"}, cfg)
+	client.SetBaseURL(server.URL)
+
+	body, err := client.DownloadFile(context.Background(), "file1")
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Len(t, data, 15)
+
+	session := &FileUploadInfo{UploadID: "upload1"}
+	_, err = client.UploadContent(context.Background(), session, &trickle{left: 15, interval: 100 * time.Millisecond}, 15)
+	assert.NoError(t, err)
+}
+
+func TestRequestTimeoutBoundsAPICalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+	client.SetRequestTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	_, err := client.GetFileInfo(context.Background(), "file1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+// flushWriter flushes every write so it reaches the client at once
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.w.(http.Flusher).Flush()
+	return n, err
+}
+
+func TestNewTransportExplicitLimits(t *testing.T) {
+	transport := NewTransport(types.NetworkConfig{MaxIdleConns: 500, MaxIdleConnsPerHost: 32}, 4)
+
+	assert.Equal(t, 32, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 500, transport.MaxIdleConns)
+	assert.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+	assert.False(t, transport.ForceAttemptHTTP2)
+}
+
+// benchmarkConnectionReuse issues bursts of concurrent requests and reports
+// how many new connections each burst needed
+func benchmarkConnectionReuse(b *testing.B, transport *http.Transport) {
+	const burst = 8
+	var opened int64
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&opened, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{Transport: transport}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < burst; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := client.Get(server.URL)
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				resp.Body.Close()
+			}()
+		}
+		wg.Wait()
+	}
+
+	b.ReportMetric(float64(atomic.LoadInt64(&opened))/float64(b.N), "conns/op")
+}
+
+func BenchmarkDefaultTransport(b *testing.B) {
+	benchmarkConnectionReuse(b, &http.Transport{})
+}
+
+func BenchmarkTunedTransport(b *testing.B) {
+	benchmarkConnectionReuse(b, NewTransport(types.NetworkConfig{EnableHTTP2: true}, 4))
+}
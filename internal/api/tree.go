@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FolderCacheTTL is how long folder listings used for browsing are reused
+const FolderCacheTTL = 30 * time.Second
+
+// folderListPageSize is the page size used when listing a whole folder
+const folderListPageSize = 200
+
+// FolderNode is a folder in a browsable remote tree
+type FolderNode struct {
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	HasChildren bool          `json:"has_children"`
+	Denied      bool          `json:"denied,omitempty"`
+	Children    []*FolderNode `json:"children,omitempty"`
+}
+
+// folderCache holds recent folder listings so expanding a tree node does
+// not list the same folder twice
+type folderCache struct {
+	mu      sync.Mutex
+	entries map[string]folderCacheEntry
+}
+
+// folderCacheEntry is one cached listing
+type folderCacheEntry struct {
+	children []FileInfo
+	fetched  time.Time
+}
+
+// newFolderCache creates an empty folder cache
+func newFolderCache() *folderCache {
+	return &folderCache{entries: make(map[string]folderCacheEntry)}
+}
+
+// get returns a listing that is younger than FolderCacheTTL
+func (fc *folderCache) get(folderID string) ([]FileInfo, bool) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	entry, ok := fc.entries[folderID]
+	if !ok || time.Since(entry.fetched) > FolderCacheTTL {
+		return nil, false
+	}
+	return entry.children, true
+}
+
+// put stores a listing
+func (fc *folderCache) put(folderID string, children []FileInfo) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.entries[folderID] = folderCacheEntry{children: children, fetched: time.Now()}
+}
+
+// invalidate drops a folder's listing
+func (fc *folderCache) invalidate(folderID string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	delete(fc.entries, folderID)
+}
+
+// GetFolderTree returns the folders below rootID down to depth levels, with
+// depth 1 returning only its immediate subfolders. Every node reports
+// whether it has subfolders, so nodes at the depth limit can be expanded
+// later with another call. Subfolders the user may not list are marked
+// Denied and not expandable instead of failing the whole tree. The root
+// node carries only its ID.
+func (c *Client) GetFolderTree(ctx context.Context, rootID string, depth int) (*FolderNode, error) {
+	root := &FolderNode{ID: rootID}
+	if err := c.expandFolder(ctx, root, depth, true); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// expandFolder fills in a node's subfolders down to depth levels
+func (c *Client) expandFolder(ctx context.Context, node *FolderNode, depth int, isRoot bool) error {
+	children, err := c.listFolder(ctx, node.ID)
+	if err != nil {
+		var statusErr *StatusError
+		if !isRoot && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden {
+			node.Denied = true
+			return nil
+		}
+		return err
+	}
+
+	for _, child := range children {
+		if !child.IsFolder {
+			continue
+		}
+		node.HasChildren = true
+		if depth <= 0 {
+			// Knowing that there is a subfolder is enough at the depth limit
+			return nil
+		}
+
+		childNode := &FolderNode{ID: child.ID, Name: child.Name}
+		if err := c.expandFolder(ctx, childNode, depth-1, false); err != nil {
+			return err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return nil
+}
+
+// listFolder returns every child of a folder, using the browsing cache
+func (c *Client) listFolder(ctx context.Context, folderID string) ([]FileInfo, error) {
+	if children, ok := c.folders.get(folderID); ok {
+		return children, nil
+	}
+
+	var children []FileInfo
+	for offset := 0; ; offset += folderListPageSize {
+		page, err := c.ListFilesPage(ctx, folderID, offset, folderListPageSize)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, page...)
+		if len(page) < folderListPageSize {
+			break
+		}
+	}
+
+	c.folders.put(folderID, children)
+	return children, nil
+}
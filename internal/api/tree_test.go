@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFolderTree(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	folder := func(id string) FileInfo { return FileInfo{ID: id, Name: id, IsFolder: true} }
+	listings := map[string][]FileInfo{
+		"root":     {folder("projects"), folder("private"), folder("archive"), {ID: "notes", Name: "notes.txt"}},
+		"projects": {folder("website"), {ID: "plan", Name: "plan.md"}},
+		"website":  {folder("assets")},
+		"archive":  {},
+	}
+
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/files/"), "/files")
+		requests[id]++
+		if id == "private" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": listings[id]})
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	tree, err := client.GetFolderTree(context.Background(), "root", 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, "root", tree.ID)
+	assert.True(t, tree.HasChildren)
+	require.Len(t, tree.Children, 3, "files are not part of the tree")
+
+	projects, private, archive := tree.Children[0], tree.Children[1], tree.Children[2]
+	assert.Equal(t, "projects", projects.Name)
+	assert.True(t, projects.HasChildren)
+	require.Len(t, projects.Children, 1)
+
+	// The second level is the depth limit: expandable but not expanded
+	website := projects.Children[0]
+	assert.Equal(t, "website", website.ID)
+	assert.True(t, website.HasChildren)
+	assert.Empty(t, website.Children)
+
+	assert.True(t, private.Denied)
+	assert.False(t, private.HasChildren)
+	assert.False(t, archive.HasChildren)
+
+	// Expanding a node again is served from the cache
+	subtree, err := client.GetFolderTree(context.Background(), "projects", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "website", subtree.Children[0].ID)
+	assert.Equal(t, 1, requests["projects"])
+	assert.Equal(t, 1, requests["website"])
+}
+
+func TestGetFolderTreeFailsWhenRootIsUnreadable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	_, err := client.GetFolderTree(context.Background(), "root", 1)
+	assert.Error(t, err)
+}
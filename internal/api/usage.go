@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// StorageUsage is how much of the drive's storage is in use, in bytes
+type StorageUsage struct {
+	Used  int64 `json:"used"`
+	Total int64 `json:"total"`
+}
+
+// UsageReporter is implemented by backends that can tell how much storage
+// is in use
+type UsageReporter interface {
+	GetStorageUsage(ctx context.Context) (*StorageUsage, error)
+}
+
+var _ UsageReporter = (*Client)(nil)
+
+// GetStorageUsage retrieves the storage used by the current user
+func (c *Client) GetStorageUsage(ctx context.Context) (*StorageUsage, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/users/me/storage", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newResponseError("storage usage", resp)
+	}
+
+	var result struct {
+		Data StorageUsage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result.Data, nil
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/version"
+)
+
+// UserAgent returns the User-Agent sent with every request: override if
+// network.user_agent sets one, or else ZohoSync/<version> (<os>; <arch>)
+func UserAgent(override string) string {
+	if override = strings.TrimSpace(override); override != "" {
+		return override
+	}
+	return fmt.Sprintf("ZohoSync/%s (%s; %s)", version.Version, runtime.GOOS, runtime.GOARCH)
+}
+
+// SetUserAgent sets the User-Agent of the client's requests. An empty
+// value restores the default.
+func (c *Client) SetUserAgent(userAgent string) {
+	c.userAgent = UserAgent(userAgent)
+}
+
+// userAgentTransport sets the User-Agent of requests sent through it
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// UserAgentTransport wraps base, or http.DefaultTransport if nil, to send
+// userAgent with every request, for HTTP clients the API client does not
+// make itself, such as the OAuth token exchange
+func UserAgentTransport(base http.RoundTripper, userAgent string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &userAgentTransport{base: base, userAgent: userAgent}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/version"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestsIdentifyTheClient(t *testing.T) {
+	var agents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Header.Get("User-Agent"))
+		switch r.URL.Path {
+		case "/users/me":
+			w.Write([]byte(`{"data":{"id":"user1"}}`))
+		case "/upload/upload1":
+			w.Write([]byte(`{"data":{"id":"file1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	_, err := client.GetUserInfo(context.Background())
+	require.NoError(t, err)
+	_, err = client.UploadContent(context.Background(), &FileUploadInfo{UploadID: "upload1"}, bytes.NewReader([]byte("data")), 4)
+	require.NoError(t, err)
+
+	require.Len(t, agents, 2)
+	format := regexp.MustCompile(`^ZohoSync/\S+ \([a-z0-9]+; [a-z0-9]+\)$`)
+	for _, agent := range agents {
+		assert.Regexp(t, format, agent)
+		assert.Equal(t, "ZohoSync/"+version.Version+" ("+runtime.GOOS+"; "+runtime.GOARCH+")", agent)
+	}
+
+	// network.user_agent replaces it, and clearing it restores the default
+	client.SetUserAgent("acme-backup/2.1")
+	_, err = client.GetUserInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "acme-backup/2.1", agents[2])
+	client.SetUserAgent("")
+	_, err = client.GetUserInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, agents[0], agents[3])
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Workspace permissions
+const (
+	PermissionRead   = "read"
+	PermissionWrite  = "write"
+	PermissionDelete = "delete"
+)
+
+// ErrWorkspaceNotFound is returned when a remote path names a workspace the
+// user has no access to
+var ErrWorkspaceNotFound = errors.New("workspace not found")
+
+// Workspace is a space in WorkDrive the user can sync: their personal
+// drive or a team folder. Its ID is also the ID of its top folder.
+type Workspace struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	Permissions []string `json:"permissions"`
+}
+
+// Can reports whether the user has permission in the workspace
+func (w Workspace) Can(permission string) bool {
+	for _, p := range w.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkspaceLister is implemented by backends with more than one workspace
+type WorkspaceLister interface {
+	ListWorkspaces(ctx context.Context) ([]Workspace, error)
+}
+
+var _ WorkspaceLister = (*Client)(nil)
+
+// ListWorkspaces returns the workspaces the user can access
+func (c *Client) ListWorkspaces(ctx context.Context) ([]Workspace, error) {
+	resp, err := c.makeRequest(ctx, "GET", "/workspaces", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newResponseError("workspace listing", resp)
+	}
+
+	var result struct {
+		Data []Workspace `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return result.Data, nil
+}
+
+// FindWorkspace returns the workspace called name, ignoring case, or with
+// name as its ID
+func FindWorkspace(workspaces []Workspace, name string) (*Workspace, error) {
+	for i := range workspaces {
+		if workspaces[i].ID == name {
+			return &workspaces[i], nil
+		}
+	}
+	for i := range workspaces {
+		if strings.EqualFold(workspaces[i].Name, name) {
+			return &workspaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrWorkspaceNotFound, name)
+}
+
+// ParseRemotePath splits a remote folder path of the form
+// "workspace:/path/in/workspace", or "workspace:" for its top folder, into
+// the workspace and the path. Paths starting with a slash are in the
+// personal drive and have no workspace.
+func ParseRemotePath(remote string) (workspace, path string) {
+	if strings.HasPrefix(remote, "/") {
+		return "", remote
+	}
+	if i := strings.Index(remote, ":/"); i > 0 {
+		return remote[:i], remote[i+1:]
+	}
+	if name := strings.TrimSuffix(remote, ":"); name != remote && name != "" && !strings.Contains(name, "/") {
+		return name, "/"
+	}
+	return "", remote
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListWorkspaces(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/workspaces", r.URL.Path)
+		w.Write([]byte(`{"data":[
+			{"id":"root","name":"My WorkDrive","type":"privatespace","permissions":["read","write","delete"]},
+			{"id":"ws-42","name":"Marketing","type":"teamfolder","permissions":["read"]}
+		]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	workspaces, err := client.ListWorkspaces(context.Background())
+	require.NoError(t, err)
+	require.Len(t, workspaces, 2)
+	assert.Equal(t, "My WorkDrive", workspaces[0].Name)
+	assert.True(t, workspaces[0].Can(PermissionWrite))
+	assert.Equal(t, "teamfolder", workspaces[1].Type)
+	assert.False(t, workspaces[1].Can(PermissionWrite))
+
+	found, err := FindWorkspace(workspaces, "marketing")
+	require.NoError(t, err)
+	assert.Equal(t, "ws-42", found.ID)
+	found, err = FindWorkspace(workspaces, "ws-42")
+	require.NoError(t, err)
+	assert.Equal(t, "Marketing", found.Name)
+	_, err = FindWorkspace(workspaces, "Sales")
+	assert.ErrorIs(t, err, ErrWorkspaceNotFound)
+}
+
+func TestParseRemotePath(t *testing.T) {
+	tests := []struct {
+		remote, workspace, path string
+	}{
+		{"/My Folders/Documents", "", "/My Folders/Documents"},
+		{"Marketing:/Campaigns/2024", "Marketing", "/Campaigns/2024"},
+		{"Marketing:/", "Marketing", "/"},
+		{"Marketing:", "Marketing", "/"},
+		{"/Notes: draft/v1", "", "/Notes: draft/v1"},
+		{"Documents", "", "Documents"},
+	}
+	for _, tt := range tests {
+		workspace, path := ParseRemotePath(tt.remote)
+		assert.Equal(t, tt.workspace, workspace, tt.remote)
+		assert.Equal(t, tt.path, path, tt.remote)
+	}
+}
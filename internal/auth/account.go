@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Config keys recording which account the sync state belongs to
+const (
+	accountIDKey    = "account_id"
+	accountEmailKey = "account_email"
+)
+
+// AccountMismatchError is returned when a login is for a different account
+// than the one the existing sync state belongs to and the switch was not
+// confirmed
+type AccountMismatchError struct {
+	Stored string
+	New    string
+}
+
+func (e *AccountMismatchError) Error() string {
+	return fmt.Sprintf("logged in as %s, but the sync state belongs to %s", e.New, e.Stored)
+}
+
+// ConfirmAccountSwitch asks the user whether to continue with a different
+// account than the one the sync state belongs to
+type ConfirmAccountSwitch func(stored, new string) bool
+
+// CompleteLogin verifies a newly obtained token and stores it in tokens,
+// replacing only the previous token so that sync folders and file state
+// survive a re-login. If the token belongs to a different account than the
+// stored sync state, the token is only kept if confirm approves the switch.
+func CompleteLogin(ctx context.Context, database *storage.Database, tokens TokenStore, client *api.Client, token *types.TokenInfo, confirm ConfirmAccountSwitch) (*api.UserInfo, error) {
+	client.SetToken(token)
+	userInfo, err := client.GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify authentication: %w", err)
+	}
+
+	storedID, err := database.GetConfigValue(accountIDKey)
+	if err != nil {
+		return nil, err
+	}
+	if storedID != "" && storedID != userInfo.ID {
+		storedEmail, _ := database.GetConfigValue(accountEmailKey)
+		if storedEmail == "" {
+			storedEmail = storedID
+		}
+		if confirm == nil || !confirm(storedEmail, userInfo.Email) {
+			return nil, &AccountMismatchError{Stored: storedEmail, New: userInfo.Email}
+		}
+		utils.GetLogger().Warnf("Switching account from %s to %s; existing sync state is kept", storedEmail, userInfo.Email)
+	}
+
+	if err := tokens.SaveAuthToken(token); err != nil {
+		return nil, fmt.Errorf("failed to save token: %w", err)
+	}
+	if err := database.SetConfigValue(accountIDKey, userInfo.ID); err != nil {
+		return nil, err
+	}
+	if err := database.SetConfigValue(accountEmailKey, userInfo.Email); err != nil {
+		return nil, err
+	}
+
+	return userInfo, nil
+}
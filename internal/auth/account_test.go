@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// newAccountServer serves /users/me for the given user
+func newAccountServer(t *testing.T, id, email string) *api.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/users/me", r.URL.Path)
+		w.Write([]byte(`{"data":{"id":"` + id + `","email":"` + email + `"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := api.NewClient(&types.TokenInfo{})
+	client.SetBaseURL(server.URL)
+	return client
+}
+
+// newLoggedInDatabase creates a database with sync state for user-1
+func newLoggedInDatabase(t *testing.T) *storage.Database {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	require.NoError(t, database.SaveAuthToken(&types.TokenInfo{AccessToken: "expired", ExpiresAt: time.Now().Add(-time.Hour)}))
+	require.NoError(t, database.SetConfigValue(accountIDKey, "user-1"))
+	require.NoError(t, database.SetConfigValue(accountEmailKey, "me@example.com"))
+	require.NoError(t, database.SetConfigValue("sync_paused", "true"))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: "/home/me/Zoho/doc.txt", RemoteID: "r1", Hash: "abc", SyncStatus: "synced",
+	}))
+	return database
+}
+
+func TestReloginSameAccountKeepsSyncState(t *testing.T) {
+	database := newLoggedInDatabase(t)
+	client := newAccountServer(t, "user-1", "me@example.com")
+
+	token := &types.TokenInfo{AccessToken: "fresh", ExpiresAt: time.Now().Add(time.Hour)}
+	userInfo, err := CompleteLogin(context.Background(), database, database, client, token, func(stored, new string) bool {
+		t.Error("the same account must not ask for confirmation")
+		return false
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "me@example.com", userInfo.Email)
+
+	saved, err := database.GetAuthToken()
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", saved.AccessToken)
+
+	file, err := database.GetFileMetadata("/home/me/Zoho/doc.txt")
+	require.NoError(t, err)
+	require.NotNil(t, file, "file metadata survives the re-login")
+	assert.Equal(t, "r1", file.RemoteID)
+	assert.Equal(t, "synced", file.SyncStatus)
+
+	paused, err := database.GetConfigValue("sync_paused")
+	require.NoError(t, err)
+	assert.Equal(t, "true", paused)
+}
+
+func TestReloginDifferentAccountNeedsConfirmation(t *testing.T) {
+	database := newLoggedInDatabase(t)
+	client := newAccountServer(t, "user-2", "other@example.com")
+	token := &types.TokenInfo{AccessToken: "other", ExpiresAt: time.Now().Add(time.Hour)}
+
+	var asked []string
+	_, err := CompleteLogin(context.Background(), database, database, client, token, func(stored, new string) bool {
+		asked = append(asked, stored, new)
+		return false
+	})
+	var mismatch *AccountMismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, []string{"me@example.com", "other@example.com"}, asked)
+
+	saved, err := database.GetAuthToken()
+	require.NoError(t, err)
+	assert.Equal(t, "expired", saved.AccessToken, "a declined switch keeps the old token")
+
+	_, err = CompleteLogin(context.Background(), database, database, client, token, func(stored, new string) bool { return true })
+	require.NoError(t, err)
+	accountID, _ := database.GetConfigValue(accountIDKey)
+	assert.Equal(t, "user-2", accountID)
+}
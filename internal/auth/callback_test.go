@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// takenPort holds a port for the rest of the test and returns it
+func takenPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// newCallbackClient creates a client whose redirect URI is on port
+func newCallbackClient(port int, flexible bool) *OAuthClient {
+	return NewOAuthClient(&types.Config{Auth: types.AuthConfig{
+		ClientID:             "client",
+		ClientSecret:         "secret",
+		RedirectURI:          fmt.Sprintf("http://localhost:%d/callback", port),
+		FlexibleRedirectPort: flexible,
+	}})
+}
+
+func TestCallbackPortInUse(t *testing.T) {
+	port := takenPort(t)
+
+	t.Run("fixed port", func(t *testing.T) {
+		client := newCallbackClient(port, false)
+		err := client.ListenCallback()
+		require.ErrorIs(t, err, ErrCallbackPortInUse)
+		assert.Contains(t, err.Error(), "auth.flexible_redirect_port")
+	})
+
+	t.Run("flexible port", func(t *testing.T) {
+		client := newCallbackClient(port, true)
+		require.NoError(t, client.ListenCallback())
+		defer client.CloseCallback()
+
+		redirect, err := url.Parse(client.RedirectURI())
+		require.NoError(t, err)
+		assert.NotEqual(t, fmt.Sprint(port), redirect.Port())
+		assert.Equal(t, "localhost", redirect.Hostname())
+		assert.Equal(t, "/callback", redirect.Path)
+
+		// The authorization URL sends the user back to the port bound
+		authURL, err := client.GetAuthURL()
+		require.NoError(t, err)
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, client.RedirectURI(), parsed.Query().Get("redirect_uri"))
+	})
+}
+
+func TestCallbackServersDoNotShareHandlers(t *testing.T) {
+	port := takenPort(t)
+	client := newCallbackClient(port, true)
+
+	// Two logins in a row each serve their own callback
+	for attempt := 0; attempt < 2; attempt++ {
+		require.NoError(t, client.ListenCallback())
+		done := make(chan error, 1)
+		go func() {
+			_, err := client.StartCallbackServer(context.Background())
+			done <- err
+		}()
+
+		resp, err := http.Get(client.RedirectURI() + "?error=access_denied")
+		require.NoError(t, err)
+		resp.Body.Close()
+		select {
+		case err := <-done:
+			assert.ErrorContains(t, err, "access_denied", "attempt %d", attempt)
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback server did not return")
+		}
+	}
+
+	request, err := http.NewRequest(http.MethodGet, "http://localhost/callback", nil)
+	require.NoError(t, err)
+	_, pattern := http.DefaultServeMux.Handler(request)
+	assert.Empty(t, pattern, "nothing is registered on the default mux")
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrKeyringNotFound is returned by a keyring holding no secret for the
+// service and account asked for
+var ErrKeyringNotFound = errors.New("secret not found in keyring")
+
+// Keyring is a platform secret store
+type Keyring interface {
+	Set(service, account, secret string) error
+	Get(service, account string) (string, error)
+	Delete(service, account string) error
+}
+
+// SystemKeyring returns the keyring of the desktop session. On Linux it is
+// the Secret Service (GNOME Keyring, KWallet), reached through libsecret's
+// secret-tool; other platforms have none yet.
+func SystemKeyring() (Keyring, error) {
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("no keyring support on %s", runtime.GOOS)
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil, errors.New("no D-Bus session bus for the Secret Service")
+	}
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, errors.New("secret-tool not found; install libsecret-tools")
+	}
+	return secretTool{path: path}, nil
+}
+
+// secretTool is the Secret Service keyring driven by secret-tool
+type secretTool struct {
+	path string
+}
+
+// run runs secret-tool with stdin as its input and returns its output.
+// A failure that printed nothing is reported as ErrKeyringNotFound, which
+// is how secret-tool reports that no secret matched.
+func (k secretTool) run(stdin string, args ...string) (string, error) {
+	cmd := exec.Command(k.path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return "", ErrKeyringNotFound
+		}
+		return "", fmt.Errorf("secret-tool %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+func (k secretTool) Set(service, account, secret string) error {
+	_, err := k.run(secret, "store", "--label", service+" "+account, "service", service, "account", account)
+	if errors.Is(err, ErrKeyringNotFound) {
+		return errors.New("secret-tool store failed")
+	}
+	return err
+}
+
+func (k secretTool) Get(service, account string) (string, error) {
+	return k.run("", "lookup", "service", service, "account", account)
+}
+
+func (k secretTool) Delete(service, account string) error {
+	_, err := k.run("", "clear", "service", service, "account", account)
+	return err
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// LegacyTokenFile is the name of the token file of the standalone
+// zohosync-cli
+const LegacyTokenFile = "zoho_tokens.json"
+
+// legacyArchiveSuffix is appended to a legacy token file once it has been
+// imported, so the old CLI stops using it
+const legacyArchiveSuffix = ".imported"
+
+// legacyImportKey is the config key recording that the legacy token import
+// has run, whatever its outcome
+const legacyImportKey = "legacy_tokens_imported"
+
+// LegacyTokenPath returns where a token file of the standalone CLI is looked
+// for: the config directory
+func LegacyTokenPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "zohosync", LegacyTokenFile)
+}
+
+// ImportLegacyTokens moves the token of the standalone CLI into the token
+// store when it has none yet, so upgrading does not log the user out. It
+// runs once: the first time it is called it is recorded in the config and
+// later calls do nothing. The first readable file among paths is verified
+// through client like a re-login, including the check that it is for the
+// account the sync state belongs to, then imported and renamed aside. Files
+// that cannot be parsed are left alone with a warning. It reports whether a
+// token was imported.
+func ImportLegacyTokens(ctx context.Context, database *storage.Database, tokens TokenStore, client *api.Client, paths ...string) (bool, error) {
+	done, err := database.GetConfigValue(legacyImportKey)
+	if err != nil || done != "" {
+		return false, err
+	}
+	imported, err := importLegacyTokens(ctx, database, tokens, client, paths)
+	if markErr := database.SetConfigValue(legacyImportKey, "true"); markErr != nil && err == nil {
+		err = markErr
+	}
+	return imported, err
+}
+
+// importLegacyTokens is ImportLegacyTokens without the record of its run
+func importLegacyTokens(ctx context.Context, database *storage.Database, tokens TokenStore, client *api.Client, paths []string) (bool, error) {
+	existing, err := tokens.GetAuthToken()
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		return false, nil
+	}
+
+	logger := utils.GetLogger()
+	for _, path := range paths {
+		token, err := readLegacyToken(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			logger.Warnf("Ignoring legacy token file %s: %v", path, err)
+			continue
+		}
+
+		if _, err := CompleteLogin(ctx, database, tokens, client, token, nil); err != nil {
+			return false, fmt.Errorf("legacy token file %s not imported, log in again: %w", path, err)
+		}
+		if err := os.Rename(path, path+legacyArchiveSuffix); err != nil {
+			logger.Warnf("Imported legacy token file %s but could not archive it: %v", path, err)
+		}
+		logger.Infof("Imported authentication from legacy token file %s", path)
+		return true, nil
+	}
+	return false, nil
+}
+
+// readLegacyToken parses a token file of the standalone CLI. The file only
+// records how long the token was valid for when it was written, so the
+// expiry is counted from the file's modification time.
+func readLegacyToken(path string) (*types.TokenInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var token types.TokenInfo
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("malformed token file: %w", err)
+	}
+	if token.AccessToken == "" && token.RefreshToken == "" {
+		return nil, errors.New("token file holds no token")
+	}
+
+	if token.ExpiresAt.IsZero() {
+		token.ExpiresAt = info.ModTime().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+	return &token, nil
+}
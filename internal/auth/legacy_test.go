@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// newEmptyDatabase creates a database without a token
+func newEmptyDatabase(t *testing.T) *storage.Database {
+	t.Helper()
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	return database
+}
+
+func TestImportLegacyTokens(t *testing.T) {
+	database := newEmptyDatabase(t)
+	path := filepath.Join(t.TempDir(), LegacyTokenFile)
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"access_token": "1000.access",
+		"refresh_token": "1000.refresh",
+		"token_type": "Bearer",
+		"expires_in": 3600,
+		"scope": "WorkDrive.files.ALL"
+	}`), 0600))
+	written := time.Now().Add(-10 * time.Minute).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(path, written, written))
+
+	client := newAccountServer(t, "user-1", "me@example.com")
+	imported, err := ImportLegacyTokens(context.Background(), database, database, client, filepath.Join(t.TempDir(), "missing.json"), path)
+	require.NoError(t, err)
+	assert.True(t, imported)
+
+	token, err := database.GetAuthToken()
+	require.NoError(t, err)
+	require.NotNil(t, token)
+	assert.Equal(t, "1000.access", token.AccessToken)
+	assert.Equal(t, "1000.refresh", token.RefreshToken)
+	assert.Equal(t, "WorkDrive.files.ALL", token.Scope)
+	assert.WithinDuration(t, written.Add(time.Hour), token.ExpiresAt, time.Second)
+	accountID, err := database.GetConfigValue(accountIDKey)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", accountID, "the account is recorded as on a login")
+
+	// The file is archived so the old CLI stops using it
+	assert.NoFileExists(t, path)
+	assert.FileExists(t, path+legacyArchiveSuffix)
+}
+
+func TestImportLegacyTokensRunsOnce(t *testing.T) {
+	database := newEmptyDatabase(t)
+	client := newAccountServer(t, "user-1", "me@example.com")
+	path := filepath.Join(t.TempDir(), LegacyTokenFile)
+
+	imported, err := ImportLegacyTokens(context.Background(), database, database, client, path)
+	require.NoError(t, err)
+	assert.False(t, imported)
+
+	// A token file that turns up later is not imported
+	require.NoError(t, os.WriteFile(path, []byte(`{"access_token":"old","expires_in":3600}`), 0600))
+	imported, err = ImportLegacyTokens(context.Background(), database, database, client, path)
+	require.NoError(t, err)
+	assert.False(t, imported)
+
+	token, err := database.GetAuthToken()
+	require.NoError(t, err)
+	assert.Nil(t, token)
+	assert.FileExists(t, path)
+}
+
+func TestImportLegacyTokensChecksAccount(t *testing.T) {
+	database := newLoggedInDatabase(t)
+	require.NoError(t, database.ClearAuthToken())
+	client := newAccountServer(t, "user-2", "other@example.com")
+	path := filepath.Join(t.TempDir(), LegacyTokenFile)
+	require.NoError(t, os.WriteFile(path, []byte(`{"access_token":"other","expires_in":3600}`), 0600))
+
+	imported, err := ImportLegacyTokens(context.Background(), database, database, client, path)
+	var mismatch *AccountMismatchError
+	require.ErrorAs(t, err, &mismatch)
+	assert.False(t, imported)
+
+	token, err := database.GetAuthToken()
+	require.NoError(t, err)
+	assert.Nil(t, token, "a token for another account is not taken over")
+	accountID, _ := database.GetConfigValue(accountIDKey)
+	assert.Equal(t, "user-1", accountID)
+
+	// Nor is it tried again
+	imported, err = ImportLegacyTokens(context.Background(), database, database, client, path)
+	require.NoError(t, err)
+	assert.False(t, imported)
+}
+
+func TestImportLegacyTokensKeepsExistingToken(t *testing.T) {
+	database := newEmptyDatabase(t)
+	require.NoError(t, database.SaveAuthToken(&types.TokenInfo{AccessToken: "current", ExpiresAt: time.Now().Add(time.Hour)}))
+	path := filepath.Join(t.TempDir(), LegacyTokenFile)
+	require.NoError(t, os.WriteFile(path, []byte(`{"access_token":"old","expires_in":3600}`), 0600))
+
+	client := newAccountServer(t, "user-1", "me@example.com")
+	imported, err := ImportLegacyTokens(context.Background(), database, database, client, path)
+	require.NoError(t, err)
+	assert.False(t, imported)
+
+	token, err := database.GetAuthToken()
+	require.NoError(t, err)
+	assert.Equal(t, "current", token.AccessToken)
+	assert.FileExists(t, path)
+}
+
+func TestImportLegacyTokensSkipsMalformedFiles(t *testing.T) {
+	database := newEmptyDatabase(t)
+	dir := t.TempDir()
+	garbled := filepath.Join(dir, "garbled.json")
+	empty := filepath.Join(dir, "empty.json")
+	require.NoError(t, os.WriteFile(garbled, []byte(`{"access_token":`), 0600))
+	require.NoError(t, os.WriteFile(empty, []byte(`{}`), 0600))
+
+	client := newAccountServer(t, "user-1", "me@example.com")
+	imported, err := ImportLegacyTokens(context.Background(), database, database, client, garbled, empty)
+	require.NoError(t, err)
+	assert.False(t, imported)
+
+	token, err := database.GetAuthToken()
+	require.NoError(t, err)
+	assert.Nil(t, token)
+	assert.FileExists(t, garbled, "a malformed file is left for the user to look at")
+}
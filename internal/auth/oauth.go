@@ -7,13 +7,18 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"syscall"
 	"time"
 	"errors"
 	"strings"
 
 	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/clock"
 	"github.com/bdstest/zohosync/internal/config"
 	"github.com/bdstest/zohosync/internal/utils"
 	"golang.org/x/oauth2"
@@ -27,16 +32,34 @@ type OAuthClient struct {
 	state       string
 	redirectURI string
 	logger      *utils.Logger
+
+	// The state above is only valid until stateExpires. Logins are also
+	// persisted in store, if set, to survive a restart.
+	stateExpires time.Time
+	store        StateStore
+	clock        clock.Clock
+
+	// scopeErr is set if auth.scope_profile names no profile
+	scopeErr error
+
+	// listener is the callback server's socket once ListenCallback has
+	// bound it; flexiblePort lets it move to a nearby port
+	listener     net.Listener
+	flexiblePort bool
+
+	// userAgent is sent with token requests
+	userAgent string
 }
 
 // NewOAuthClient creates a new OAuth client
 func NewOAuthClient(cfg *types.Config) *OAuthClient {
+	scopes, scopeErr := RequestedScopes(cfg.Auth)
 	return &OAuthClient{
 		config: &oauth2.Config{
 			ClientID:     cfg.Auth.ClientID,
 			ClientSecret: cfg.Auth.ClientSecret,
 			RedirectURL:  cfg.Auth.RedirectURI,
-			Scopes:       cfg.Auth.Scopes,
+			Scopes:       scopes,
 			Endpoint: oauth2.Endpoint{
 				AuthURL:  config.AuthURL,
 				TokenURL: config.TokenURL,
@@ -44,9 +67,30 @@ func NewOAuthClient(cfg *types.Config) *OAuthClient {
 		},
 		redirectURI: cfg.Auth.RedirectURI,
 		logger:      utils.GetLogger(),
+		clock:       clock.Real{},
+		scopeErr:    scopeErr,
+		flexiblePort: cfg.Auth.FlexibleRedirectPort,
+		userAgent:    api.UserAgent(cfg.Network.UserAgent),
 	}
 }
 
+// tokenContext returns ctx with an HTTP client for the token endpoint that
+// sends the configured User-Agent, based on the client ctx carries, if any
+func (o *OAuthClient) tokenContext(ctx context.Context) context.Context {
+	client := &http.Client{}
+	if existing, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && existing != nil {
+		copied := *existing
+		client = &copied
+	}
+	client.Transport = api.UserAgentTransport(client.Transport, o.userAgent)
+	return context.WithValue(ctx, oauth2.HTTPClient, client)
+}
+
+// SetClock sets the clock used to expire logins and tokens
+func (o *OAuthClient) SetClock(c clock.Clock) {
+	o.clock = c
+}
+
 // GeneratePKCE generates PKCE code verifier and challenge
 func (o *OAuthClient) GeneratePKCE() error {
 	// Generate code verifier (43-128 characters)
@@ -73,8 +117,12 @@ func (o *OAuthClient) GenerateState() error {
 	return nil
 }
 
-// GetAuthURL returns the OAuth authorization URL with PKCE
+// GetAuthURL returns the OAuth authorization URL with PKCE, asking for the
+// scopes of the configured scope profile
 func (o *OAuthClient) GetAuthURL() (string, error) {
+	if o.scopeErr != nil {
+		return "", o.scopeErr
+	}
 	if err := o.GeneratePKCE(); err != nil {
 		return "", err
 	}
@@ -83,6 +131,11 @@ func (o *OAuthClient) GetAuthURL() (string, error) {
 		return "", err
 	}
 
+	o.stateExpires = o.clock.Now().Add(AuthStateTTL)
+	if err := o.persistState(o.stateExpires); err != nil {
+		return "", err
+	}
+
 	authURL := o.config.AuthCodeURL(o.state,
 		oauth2.SetAuthURLParam("code_challenge", o.challenge),
 		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
@@ -96,13 +149,14 @@ func (o *OAuthClient) GetAuthURL() (string, error) {
 // ExchangeCodeForToken exchanges authorization code for access token
 func (o *OAuthClient) ExchangeCodeForToken(ctx context.Context, code, state string) (*types.TokenInfo, error) {
 	// Verify state parameter
-	if state != o.state {
-		return nil, fmt.Errorf("invalid state parameter")
+	verifier, err := o.verifierForState(state)
+	if err != nil {
+		return nil, err
 	}
 
 	// Exchange code for token with PKCE
-	token, err := o.config.Exchange(ctx, code,
-		oauth2.SetAuthURLParam("code_verifier", o.verifier),
+	token, err := o.config.Exchange(o.tokenContext(ctx), code,
+		oauth2.SetAuthURLParam("code_verifier", verifier),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code for token: %w", err)
@@ -114,25 +168,34 @@ func (o *OAuthClient) ExchangeCodeForToken(ctx context.Context, code, state stri
 		RefreshToken: token.RefreshToken,
 		TokenType:    token.TokenType,
 		ExpiresAt:    token.Expiry,
-		Scope:        "",
+		Scope:        grantedScope(token, o.config.Scopes),
 	}
 
 	if token.Valid() {
-		tokenInfo.ExpiresIn = int(time.Until(token.Expiry).Seconds())
+		tokenInfo.ExpiresIn = int(token.Expiry.Sub(o.clock.Now()).Seconds())
 	}
 
 	o.logger.Info("Successfully exchanged code for token")
 	return tokenInfo, nil
 }
 
-// RefreshToken refreshes an expired access token
+// RefreshToken refreshes an expired access token, retrying with backoff
+// when the token endpoint cannot be reached or fails with a server error
 func (o *OAuthClient) RefreshToken(ctx context.Context, refreshToken string) (*types.TokenInfo, error) {
 	token := &oauth2.Token{
 		RefreshToken: refreshToken,
 	}
 
-	tokenSource := o.config.TokenSource(ctx, token)
-	newToken, err := tokenSource.Token()
+	var newToken *oauth2.Token
+	tokenCtx := o.tokenContext(ctx)
+	err := retryRefresh(ctx, o.clock, func() error {
+		var err error
+		newToken, err = o.config.TokenSource(tokenCtx, token).Token()
+		if err != nil {
+			return transientOAuthError(err)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to refresh token: %w", err)
 	}
@@ -145,7 +208,7 @@ func (o *OAuthClient) RefreshToken(ctx context.Context, refreshToken string) (*t
 	}
 
 	if newToken.Valid() {
-		tokenInfo.ExpiresIn = int(time.Until(newToken.Expiry).Seconds())
+		tokenInfo.ExpiresIn = int(newToken.Expiry.Sub(o.clock.Now()).Seconds())
 	}
 
 	o.logger.Info("Successfully refreshed token")
@@ -197,35 +260,33 @@ func GenerateCodeChallenge(verifier string) string {
 	return strings.TrimRight(challenge, "=")
 }
 
-// RefreshToken refreshes an access token using refresh token
+// RefreshToken refreshes an access token using refresh token. Network and
+// server errors are retried with backoff, up to RefreshAttempts times. The
+// refresh token is kept if the response does not issue a new one.
 func RefreshToken(config *OAuthConfig, token *Token) (*Token, error) {
 	if config == nil || token == nil || token.RefreshToken == "" {
 		return nil, errors.New("invalid config or token")
 	}
-	
-	data := url.Values{}
-	data.Set("grant_type", "refresh_token")
-	data.Set("refresh_token", token.RefreshToken)
-	data.Set("client_id", config.ClientID)
-	data.Set("client_secret", config.ClientSecret)
-	
-	resp, err := http.PostForm(config.TokenURL, data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to refresh token: %w", err)
+
+	client := config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
 	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("token refresh failed with status: %d", resp.StatusCode)
+	c := config.Clock
+	if c == nil {
+		c = clock.Real{}
 	}
-	
-	// Parse response and return new token
-	// Implementation would parse JSON response and create new Token
-	return &Token{
-		AccessToken:  "new_access_token",
-		RefreshToken: "new_refresh_token", 
-		ExpiresAt:    time.Now().Add(time.Hour),
-	}, nil
+
+	var refreshed *Token
+	err := retryRefresh(context.Background(), c, func() error {
+		var err error
+		refreshed, err = refreshOnce(context.Background(), client, config, token, c.Now())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refreshed, nil
 }
 
 // OAuthConfig represents OAuth configuration
@@ -235,6 +296,12 @@ type OAuthConfig struct {
 	RedirectURI  string
 	TokenURL     string
 	Scopes       []string
+	// HTTPClient sends token requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+	// UserAgent is sent with token requests; empty sends the default
+	UserAgent string
+	// Clock times retries and expiry; nil uses the system clock
+	Clock clock.Clock
 }
 
 // Token represents an OAuth token
@@ -251,42 +318,142 @@ func (o *OAuthClient) ValidateToken(token *types.TokenInfo) bool {
 	}
 
 	// Check if token is expired (with 5 minute buffer)
-	if time.Now().Add(5 * time.Minute).After(token.ExpiresAt) {
+	if o.clock.Now().Add(5 * time.Minute).After(token.ExpiresAt) {
 		return false
 	}
 
 	return true
 }
 
-// StartCallbackServer starts a local HTTP server for OAuth callback
+// CallbackPortAttempts is how many ports after the redirect URI's are tried
+// for the callback server when auth.flexible_redirect_port is set
+const CallbackPortAttempts = 10
+
+// ErrCallbackPortInUse is returned when the port of the redirect URI is held
+// by another process and no other port may be used
+var ErrCallbackPortInUse = errors.New("callback port already in use")
+
+// RedirectURI returns the redirect URI of the login, which names the port
+// actually bound once ListenCallback has run
+func (o *OAuthClient) RedirectURI() string {
+	return o.redirectURI
+}
+
+// ListenCallback binds the port of the callback server. Call it before
+// GetAuthURL, since the port bound may differ from the redirect URI's: if
+// that port is in use and auth.flexible_redirect_port is set, the next
+// CallbackPortAttempts ports of a loopback redirect URI are tried and the
+// redirect URI is changed to the one bound.
+func (o *OAuthClient) ListenCallback() error {
+	if o.listener != nil {
+		return nil
+	}
+
+	redirectURL, err := url.Parse(o.redirectURI)
+	if err != nil {
+		return fmt.Errorf("invalid redirect URI: %w", err)
+	}
+	port := 80
+	if redirectURL.Port() != "" {
+		if port, err = strconv.Atoi(redirectURL.Port()); err != nil {
+			return fmt.Errorf("invalid redirect URI port: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err == nil {
+		o.listener = listener
+		return nil
+	}
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		return fmt.Errorf("failed to start callback server: %w", err)
+	}
+	if !o.flexiblePort || !isLoopbackHost(redirectURL.Hostname()) {
+		return fmt.Errorf("%w: port %d of %s is taken, perhaps by an earlier login still waiting; "+
+			"stop it, or set auth.flexible_redirect_port if the OAuth app accepts any loopback port",
+			ErrCallbackPortInUse, port, o.redirectURI)
+	}
+
+	for next := port + 1; next <= port+CallbackPortAttempts; next++ {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", next))
+		if err != nil {
+			continue
+		}
+		redirectURL.Host = net.JoinHostPort(redirectURL.Hostname(), strconv.Itoa(next))
+		o.logger.Warnf("Port %d is in use, receiving the login callback on port %d", port, next)
+		o.listener = listener
+		o.redirectURI = redirectURL.String()
+		o.config.RedirectURL = o.redirectURI
+		return nil
+	}
+	return fmt.Errorf("%w: ports %d to %d are all taken", ErrCallbackPortInUse, port, port+CallbackPortAttempts)
+}
+
+// CloseCallback releases the port bound by ListenCallback for a login that
+// will not wait for its callback
+func (o *OAuthClient) CloseCallback() error {
+	if o.listener == nil {
+		return nil
+	}
+	err := o.listener.Close()
+	o.listener = nil
+	return err
+}
+
+// isLoopbackHost reports whether a redirect URI host is this machine
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// StartCallbackServer serves the OAuth callback on the port bound by
+// ListenCallback, binding it first if need be, until a login completes,
+// fails or ctx ends
 func (o *OAuthClient) StartCallbackServer(ctx context.Context) (*types.TokenInfo, error) {
 	resultChan := make(chan *types.TokenInfo, 1)
 	errorChan := make(chan error, 1)
 
-	// Parse redirect URI to get port
+	// Parse redirect URI to get the callback path
 	redirectURL, err := url.Parse(o.redirectURI)
 	if err != nil {
 		return nil, fmt.Errorf("invalid redirect URI: %w", err)
 	}
-
-	server := &http.Server{
-		Addr: fmt.Sprintf(":%s", redirectURL.Port()),
+	if err := o.ListenCallback(); err != nil {
+		return nil, err
+	}
+	listener := o.listener
+	o.listener = nil
+
+	// Each login has its own handler, so repeated logins do not pile up
+	// handlers on the default mux
+	mux := http.NewServeMux()
+	server := &http.Server{Handler: mux}
+
+	// fail reports the first error; a repeated callback must not block
+	fail := func(err error) {
+		select {
+		case errorChan <- err:
+		default:
+		}
 	}
 
 	// Handle OAuth callback
-	http.HandleFunc(redirectURL.Path, func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(redirectURL.Path, func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
 		state := r.URL.Query().Get("state")
 		errorParam := r.URL.Query().Get("error")
 
 		if errorParam != "" {
-			errorChan <- fmt.Errorf("OAuth error: %s", errorParam)
+			fail(fmt.Errorf("OAuth error: %s", errorParam))
 			fmt.Fprintf(w, "<h1>Authentication Failed</h1><p>Error: %s</p>", errorParam)
 			return
 		}
 
 		if code == "" {
-			errorChan <- fmt.Errorf("no authorization code received")
+			fail(fmt.Errorf("no authorization code received"))
 			fmt.Fprintf(w, "<h1>Authentication Failed</h1><p>No authorization code received</p>")
 			return
 		}
@@ -294,19 +461,22 @@ func (o *OAuthClient) StartCallbackServer(ctx context.Context) (*types.TokenInfo
 		// Exchange code for token
 		token, err := o.ExchangeCodeForToken(r.Context(), code, state)
 		if err != nil {
-			errorChan <- err
+			fail(err)
 			fmt.Fprintf(w, "<h1>Authentication Failed</h1><p>Error: %s</p>", err.Error())
 			return
 		}
 
-		resultChan <- token
+		select {
+		case resultChan <- token:
+		default:
+		}
 		fmt.Fprintf(w, "<h1>Authentication Successful!</h1><p>You can now close this window and return to ZohoSync.</p>")
 	})
 
 	// Start server in goroutine
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errorChan <- fmt.Errorf("callback server error: %w", err)
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fail(fmt.Errorf("callback server error: %w", err))
 		}
 	}()
 
@@ -322,4 +492,5 @@ func (o *OAuthClient) StartCallbackServer(ctx context.Context) (*types.TokenInfo
 		server.Close()
 		return nil, fmt.Errorf("authentication timeout")
 	}
-}
\ No newline at end of file
+}
+
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AuthStateTTL is how long a login can wait for its OAuth callback
+const AuthStateTTL = 5 * time.Minute
+
+// ErrInvalidState is returned when a callback's state parameter matches no
+// login in progress, or one that has expired
+var ErrInvalidState = errors.New("invalid state parameter")
+
+// StateStore persists the PKCE verifier of a login in progress, so that the
+// callback can still be completed by a process started after the
+// authorization URL was generated. It is implemented by storage.Database.
+type StateStore interface {
+	SaveOAuthState(stateHash string, sealedVerifier []byte, expiresAt time.Time) error
+	TakeOAuthState(stateHash string, now time.Time) ([]byte, error)
+}
+
+// SetStateStore persists the state and verifier of each authorization URL
+// generated from now on in store, and accepts callbacks for any unexpired
+// state found there
+func (o *OAuthClient) SetStateStore(store StateStore) {
+	o.store = store
+}
+
+// persistState stores the current state and verifier. Only a hash of the
+// state is kept, and the verifier is sealed with a key derived from the
+// client secret, so the database alone cannot complete a login.
+func (o *OAuthClient) persistState(expiresAt time.Time) error {
+	if o.store == nil {
+		return nil
+	}
+	sealed, err := o.sealVerifier(o.state, o.verifier)
+	if err != nil {
+		return err
+	}
+	return o.store.SaveOAuthState(stateHash(o.state), sealed, expiresAt)
+}
+
+// verifierForState returns the PKCE verifier of the login that generated
+// state, consuming any persisted copy of it
+func (o *OAuthClient) verifierForState(state string) (string, error) {
+	now := o.clock.Now()
+	var persisted []byte
+	if o.store != nil && state != "" {
+		var err error
+		if persisted, err = o.store.TakeOAuthState(stateHash(state), now); err != nil {
+			return "", err
+		}
+	}
+
+	if state != "" && state == o.state {
+		if !o.stateExpires.IsZero() && now.After(o.stateExpires) {
+			return "", fmt.Errorf("%w: the login expired, please try again", ErrInvalidState)
+		}
+		return o.verifier, nil
+	}
+	if persisted == nil {
+		return "", ErrInvalidState
+	}
+	return o.openVerifier(state, persisted)
+}
+
+// stateHash returns the key a state is persisted under
+func stateHash(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+// stateCipher returns the AEAD used to seal persisted verifiers
+func (o *OAuthClient) stateCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("zohosync oauth state\x00" + o.config.ClientSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealVerifier encrypts a verifier, binding it to its state
+func (o *OAuthClient) sealVerifier(state, verifier string) ([]byte, error) {
+	aead, err := o.stateCipher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal OAuth state: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to seal OAuth state: %w", err)
+	}
+	return aead.Seal(nonce, nonce, []byte(verifier), []byte(state)), nil
+}
+
+// openVerifier decrypts a verifier sealed for state
+func (o *OAuthClient) openVerifier(state string, sealed []byte) (string, error) {
+	aead, err := o.stateCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidState
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	verifier, err := aead.Open(nil, nonce, ciphertext, []byte(state))
+	if err != nil {
+		return "", fmt.Errorf("%w: it was stored with a different client secret", ErrInvalidState)
+	}
+	return string(verifier), nil
+}
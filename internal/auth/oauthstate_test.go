@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// newTokenServer accepts a code exchange only if its PKCE verifier matches
+// the challenge of the authorization URL
+func newTokenServer(t *testing.T, challenge *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != *challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newStateTestClient(database *storage.Database, tokenURL string) *OAuthClient {
+	client := NewOAuthClient(&types.Config{Auth: types.AuthConfig{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURI:  "http://localhost:8080/callback",
+	}})
+	client.config.Endpoint.TokenURL = tokenURL
+	client.SetStateStore(database)
+	return client
+}
+
+func TestLoginCompletesInNewProcess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dbPath := filepath.Join(t.TempDir(), "zohosync.db")
+	database, err := storage.NewDatabase(dbPath)
+	require.NoError(t, err)
+
+	var challenge string
+	server := newTokenServer(t, &challenge)
+
+	authURL, err := newStateTestClient(database, server.URL).GetAuthURL()
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	state := parsed.Query().Get("state")
+	challenge = parsed.Query().Get("code_challenge")
+
+	// The process restarts while the browser is on the consent page
+	require.NoError(t, database.Close())
+	database, err = storage.NewDatabase(dbPath)
+	require.NoError(t, err)
+	defer database.Close()
+
+	restarted := newStateTestClient(database, server.URL)
+	token, err := restarted.ExchangeCodeForToken(context.Background(), "code", state)
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+
+	// Each state completes one login only
+	_, err = newStateTestClient(database, server.URL).ExchangeCodeForToken(context.Background(), "code", state)
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
+
+func TestPersistedLoginExpires(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	var challenge string
+	server := newTokenServer(t, &challenge)
+
+	authURL, err := newStateTestClient(database, server.URL).GetAuthURL()
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	challenge = parsed.Query().Get("code_challenge")
+
+	restarted := newStateTestClient(database, server.URL)
+	later := clock.NewFake(time.Now())
+	later.Advance(AuthStateTTL + time.Minute)
+	restarted.SetClock(later)
+	_, err = restarted.ExchangeCodeForToken(context.Background(), "code", parsed.Query().Get("state"))
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
+
+func TestPersistedLoginNeedsClientSecret(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	var challenge string
+	server := newTokenServer(t, &challenge)
+
+	authURL, err := newStateTestClient(database, server.URL).GetAuthURL()
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+
+	other := newStateTestClient(database, server.URL)
+	other.config.ClientSecret = "another secret"
+	_, err = other.ExchangeCodeForToken(context.Background(), "code", parsed.Query().Get("state"))
+	assert.ErrorIs(t, err, ErrInvalidState)
+}
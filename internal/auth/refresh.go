@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/backoff"
+	"github.com/bdstest/zohosync/internal/clock"
+	"golang.org/x/oauth2"
+)
+
+// RefreshAttempts is how many times a token refresh is tried when it fails
+// for reasons that may pass, such as a network error or a server error
+const RefreshAttempts = 3
+
+// refreshTimeout bounds a single refresh request
+const refreshTimeout = 30 * time.Second
+
+// refreshBackoff spaces out the attempts of a token refresh
+var refreshBackoff = backoff.Policy{
+	Initial: time.Second,
+	Max:     10 * time.Second,
+	Factor:  2,
+}
+
+// transientError marks a refresh failure worth retrying
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transientError) Unwrap() error {
+	return e.err
+}
+
+// transientStatus reports whether a token endpoint status may pass on
+// its own
+func transientStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryRefresh calls refresh until it succeeds, fails for good, has been
+// tried RefreshAttempts times, or ctx ends, waiting on c between attempts
+func retryRefresh(ctx context.Context, c clock.Clock, refresh func() error) error {
+	var err error
+	for attempt := 0; attempt < RefreshAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-c.After(refreshBackoff.Delay(attempt - 1)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = refresh()
+		var transient *transientError
+		if !errors.As(err, &transient) {
+			return err
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", RefreshAttempts, err)
+}
+
+// tokenResponse is the JSON body of a token endpoint response. Zoho reports
+// some failures with a 200 status and an error field.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Error        string `json:"error"`
+}
+
+// refreshOnce makes a single refresh request. Failures that may pass are
+// returned as transientError.
+func refreshOnce(ctx context.Context, client *http.Client, config *OAuthConfig, token *Token, now time.Time) (*Token, error) {
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", token.RefreshToken)
+	data.Set("client_id", config.ClientID)
+	data.Set("client_secret", config.ClientSecret)
+
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.TokenURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create refresh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", api.UserAgent(config.UserAgent))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &transientError{fmt.Errorf("failed to refresh token: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("token refresh failed with status: %d", resp.StatusCode)
+		if transientStatus(resp.StatusCode) {
+			return nil, &transientError{err}
+		}
+		return nil, err
+	}
+
+	var body tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("malformed token response: %w", err)
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("token refresh rejected: %s", body.Error)
+	}
+	if body.AccessToken == "" {
+		return nil, errors.New("malformed token response: no access token")
+	}
+
+	refreshed := &Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+	}
+	if refreshed.RefreshToken == "" {
+		// Zoho only issues a refresh token with the first login
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	if body.ExpiresIn > 0 {
+		refreshed.ExpiresAt = now.Add(time.Duration(body.ExpiresIn) * time.Second)
+	}
+	return refreshed, nil
+}
+
+// transientOAuthError marks the errors of an oauth2 token source that may
+// pass: those without a response, and server errors
+func transientOAuthError(err error) error {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) && retrieveErr.Response != nil && !transientStatus(retrieveErr.Response.StatusCode) {
+		return err
+	}
+	return &transientError{err}
+}
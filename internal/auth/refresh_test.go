@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRefreshServer serves the token endpoint with the given responses in
+// turn, repeating the last one, and counts the requests
+func newRefreshServer(t *testing.T, responses ...func(w http.ResponseWriter, r *http.Request)) (*OAuthConfig, *int32) {
+	t.Helper()
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&requests, 1))
+		if n > len(responses) {
+			n = len(responses)
+		}
+		assert.Equal(t, "refresh_token", r.FormValue("grant_type"))
+		assert.Equal(t, "old_refresh_token", r.FormValue("refresh_token"))
+		assert.Regexp(t, `^ZohoSync/\S+ \(`, r.Header.Get("User-Agent"))
+		responses[n-1](w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return &OAuthConfig{
+		ClientID:     "test_client",
+		ClientSecret: "test_secret",
+		TokenURL:     server.URL + "/oauth/v2/token",
+	}, &requests
+}
+
+// respond writes a token endpoint response
+func respond(status int, body string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+var expiredToken = &Token{AccessToken: "old_access_token", RefreshToken: "old_refresh_token"}
+
+func TestRefreshTokenParsesResponse(t *testing.T) {
+	config, requests := newRefreshServer(t,
+		respond(http.StatusOK, `{"access_token":"fresh","token_type":"Bearer","expires_in":3600}`))
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	config.Clock = clock.NewFake(now)
+
+	token, err := RefreshToken(config, expiredToken)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", token.AccessToken)
+	assert.Equal(t, "old_refresh_token", token.RefreshToken, "the refresh token is kept when no new one is issued")
+	assert.Equal(t, now.Add(time.Hour), token.ExpiresAt)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestRefreshTokenRetriesTransientFailures(t *testing.T) {
+	config, requests := newRefreshServer(t,
+		respond(http.StatusServiceUnavailable, `{}`),
+		respond(http.StatusOK, `{"access_token":"fresh","refresh_token":"rotated","expires_in":60}`))
+	fake := clock.NewFake(time.Now())
+	config.Clock = fake
+
+	done := make(chan struct{})
+	var token *Token
+	var err error
+	go func() {
+		defer close(done)
+		token, err = RefreshToken(config, expiredToken)
+	}()
+
+	// The retry waits for the backoff before trying again
+	fake.BlockUntil(1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+	fake.Advance(refreshBackoff.Max)
+	<-done
+
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", token.AccessToken)
+	assert.Equal(t, "rotated", token.RefreshToken)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestRefreshTokenRejectsMalformedResponse(t *testing.T) {
+	for name, body := range map[string]string{
+		"invalid JSON":    `{"access_token":`,
+		"no access token": `{"expires_in":3600}`,
+		"error field":     `{"error":"invalid_code"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			config, requests := newRefreshServer(t, respond(http.StatusOK, body))
+			config.Clock = clock.NewFake(time.Now())
+
+			_, err := RefreshToken(config, expiredToken)
+			assert.Error(t, err)
+			assert.Equal(t, int32(1), atomic.LoadInt32(requests), "a malformed response is not retried")
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"golang.org/x/oauth2"
+)
+
+// RequiredScopes are the OAuth scopes syncing needs: reading and writing
+// files, and creating, moving and listing folders
+var RequiredScopes = []string{"WorkDrive.files.ALL", "WorkDrive.folders.ALL"}
+
+// Scope profiles, set by auth.scope_profile, pick the scopes a login asks for
+const (
+	// ScopeProfileFull asks for everything syncing needs
+	ScopeProfileFull = "full"
+	// ScopeProfileReadOnly only asks to read files and folders, so local
+	// changes are never uploaded
+	ScopeProfileReadOnly = "readonly"
+)
+
+// scopeProfiles are the scopes of each profile
+var scopeProfiles = map[string][]string{
+	ScopeProfileFull:     RequiredScopes,
+	ScopeProfileReadOnly: {"WorkDrive.files.READ", "WorkDrive.folders.READ"},
+}
+
+// RequestedScopes returns the scopes a login asks for: those of the
+// configured scope profile, or auth.scopes as listed if there is none
+func RequestedScopes(cfg types.AuthConfig) ([]string, error) {
+	profile := strings.ToLower(strings.TrimSpace(cfg.ScopeProfile))
+	if profile == "" {
+		if len(cfg.Scopes) == 0 {
+			return RequiredScopes, nil
+		}
+		return cfg.Scopes, nil
+	}
+	scopes, ok := scopeProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown auth.scope_profile %q (expected %s or %s)", cfg.ScopeProfile, ScopeProfileFull, ScopeProfileReadOnly)
+	}
+	return append([]string(nil), scopes...), nil
+}
+
+// ParseScopes splits the scope of a token into its scopes. Zoho separates
+// them with commas, OAuth 2.0 with spaces; either is accepted.
+func ParseScopes(scope string) []string {
+	return strings.FieldsFunc(scope, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+}
+
+// MissingScopes returns the scopes in required that the token scope does not
+// grant. Scope names are compared case-insensitively, as Zoho does.
+func MissingScopes(scope string, required []string) []string {
+	granted := make(map[string]bool)
+	for _, s := range ParseScopes(scope) {
+		granted[strings.ToLower(s)] = true
+	}
+
+	var missing []string
+	for _, s := range required {
+		if !granted[strings.ToLower(s)] {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// grantedScope returns the scope granted with token. A server that leaves it
+// out of the token response granted the requested scopes.
+func grantedScope(token *oauth2.Token, requested []string) string {
+	if scope, ok := token.Extra("scope").(string); ok && scope != "" {
+		return scope
+	}
+	return strings.Join(requested, ",")
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingScopes(t *testing.T) {
+	assert.Empty(t, MissingScopes("WorkDrive.files.ALL,WorkDrive.folders.ALL", RequiredScopes))
+	assert.Empty(t, MissingScopes("workdrive.files.all workdrive.folders.all", RequiredScopes), "scopes are compared case-insensitively")
+	assert.Equal(t, []string{"WorkDrive.folders.ALL"}, MissingScopes("WorkDrive.files.ALL, WorkDrive.workspace.READ", RequiredScopes))
+	assert.Equal(t, RequiredScopes, MissingScopes("", RequiredScopes))
+}
+
+func TestScopeProfileDrivesAuthURL(t *testing.T) {
+	config := &types.Config{Auth: types.AuthConfig{
+		ClientID:     "client",
+		RedirectURI:  "http://localhost:8080/callback",
+		Scopes:       RequiredScopes,
+		ScopeProfile: "readonly",
+	}}
+
+	authURL, err := NewOAuthClient(config).GetAuthURL()
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "WorkDrive.files.READ WorkDrive.folders.READ", parsed.Query().Get("scope"), "the profile wins over auth.scopes")
+
+	config.Auth.ScopeProfile = ""
+	scopes, err := RequestedScopes(config.Auth)
+	require.NoError(t, err)
+	assert.Equal(t, RequiredScopes, scopes, "without a profile auth.scopes are asked for")
+
+	config.Auth.ScopeProfile = "admin"
+	_, err = NewOAuthClient(config).GetAuthURL()
+	assert.ErrorContains(t, err, "unknown auth.scope_profile")
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Token stores selectable with auth.token_store
+const (
+	TokenStoreDB      = "db"
+	TokenStoreKeyring = "keyring"
+)
+
+// tokenRefKey is the config key holding where the token is kept when it is
+// not in the database
+const tokenRefKey = "token_ref"
+
+// Service and account the token is filed under in the keyring
+const (
+	keyringService = "zohosync"
+	keyringAccount = "oauth-token"
+)
+
+// TokenStore keeps the OAuth token between runs. It is implemented by
+// storage.Database, which keeps the token in the database itself.
+type TokenStore interface {
+	SaveAuthToken(token *types.TokenInfo) error
+	// GetAuthToken returns the stored token, or nil if there is none
+	GetAuthToken() (*types.TokenInfo, error)
+	ClearAuthToken() error
+}
+
+// NewTokenStore returns the token store chosen by auth.token_store. If the
+// keyring is chosen but none is available the database is used instead,
+// with a warning.
+func NewTokenStore(cfg types.AuthConfig, database *storage.Database) TokenStore {
+	return newTokenStore(cfg, database, SystemKeyring)
+}
+
+// newTokenStore is NewTokenStore with the keyring looked up by open
+func newTokenStore(cfg types.AuthConfig, database *storage.Database, open func() (Keyring, error)) TokenStore {
+	switch strings.ToLower(cfg.TokenStore) {
+	case "", TokenStoreDB:
+		return database
+	case TokenStoreKeyring:
+		keyring, err := open()
+		if err != nil {
+			utils.GetLogger().Warnf("No keyring available, keeping the token in the database: %v", err)
+			return database
+		}
+		return &keyringTokenStore{keyring: keyring, database: database}
+	default:
+		utils.GetLogger().Warnf("Unknown auth.token_store %q, keeping the token in the database", cfg.TokenStore)
+		return database
+	}
+}
+
+// keyringTokenStore keeps the token in the platform keyring, leaving only a
+// reference to it in the database
+type keyringTokenStore struct {
+	keyring  Keyring
+	database *storage.Database
+}
+
+// keyringRef is the reference to the token recorded in the database
+func keyringRef() string {
+	return TokenStoreKeyring + ":" + keyringService + "/" + keyringAccount
+}
+
+// SaveAuthToken stores the token in the keyring and removes any copy left
+// in the database
+func (s *keyringTokenStore) SaveAuthToken(token *types.TokenInfo) error {
+	if token == nil {
+		return s.ClearAuthToken()
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+	if err := s.keyring.Set(keyringService, keyringAccount, string(data)); err != nil {
+		return fmt.Errorf("failed to save token to the keyring: %w", err)
+	}
+	if err := s.database.SetConfigValue(tokenRefKey, keyringRef()); err != nil {
+		return err
+	}
+	return s.database.ClearAuthToken()
+}
+
+// GetAuthToken returns the token from the keyring. A token still in the
+// database, saved before the keyring was chosen, is moved to the keyring.
+func (s *keyringTokenStore) GetAuthToken() (*types.TokenInfo, error) {
+	ref, err := s.database.GetConfigValue(tokenRefKey)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		token, err := s.database.GetAuthToken()
+		if err != nil || token == nil {
+			return token, err
+		}
+		if err := s.SaveAuthToken(token); err != nil {
+			return nil, err
+		}
+		utils.GetLogger().Info("Moved the authentication token from the database to the keyring")
+		return token, nil
+	}
+	if ref != keyringRef() {
+		return nil, fmt.Errorf("token is kept in %q, not the keyring", ref)
+	}
+
+	data, err := s.keyring.Get(keyringService, keyringAccount)
+	if errors.Is(err, ErrKeyringNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token from the keyring: %w", err)
+	}
+	var token types.TokenInfo
+	if err := json.Unmarshal([]byte(data), &token); err != nil {
+		return nil, fmt.Errorf("malformed token in the keyring: %w", err)
+	}
+	return &token, nil
+}
+
+// ClearAuthToken removes the token from the keyring and the database
+func (s *keyringTokenStore) ClearAuthToken() error {
+	if err := s.keyring.Delete(keyringService, keyringAccount); err != nil && !errors.Is(err, ErrKeyringNotFound) {
+		return fmt.Errorf("failed to delete token from the keyring: %w", err)
+	}
+	if err := s.database.SetConfigValue(tokenRefKey, ""); err != nil {
+		return err
+	}
+	return s.database.ClearAuthToken()
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// mockKeyring keeps secrets in memory
+type mockKeyring map[string]string
+
+func (k mockKeyring) Set(service, account, secret string) error {
+	k[service+"/"+account] = secret
+	return nil
+}
+
+func (k mockKeyring) Get(service, account string) (string, error) {
+	secret, ok := k[service+"/"+account]
+	if !ok {
+		return "", ErrKeyringNotFound
+	}
+	return secret, nil
+}
+
+func (k mockKeyring) Delete(service, account string) error {
+	if _, ok := k[service+"/"+account]; !ok {
+		return ErrKeyringNotFound
+	}
+	delete(k, service+"/"+account)
+	return nil
+}
+
+// openKeyring returns a keyring opener that finds keyring
+func openKeyring(keyring Keyring) func() (Keyring, error) {
+	return func() (Keyring, error) { return keyring, nil }
+}
+
+// storedToken reads the token row of the database itself
+func storedToken(t *testing.T, database *storage.Database) *types.TokenInfo {
+	t.Helper()
+	token, err := database.GetAuthToken()
+	require.NoError(t, err)
+	return token
+}
+
+func TestKeyringTokenStoreKeepsOnlyAReference(t *testing.T) {
+	database := newEmptyDatabase(t)
+	keyring := mockKeyring{}
+	tokens := newTokenStore(types.AuthConfig{TokenStore: TokenStoreKeyring}, database, openKeyring(keyring))
+
+	loaded, err := tokens.GetAuthToken()
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+
+	token := &types.TokenInfo{
+		AccessToken:  "1000.access",
+		RefreshToken: "1000.refresh",
+		TokenType:    "Bearer",
+		ExpiresAt:    time.Now().Add(time.Hour).Truncate(time.Second),
+		Scope:        "WorkDrive.files.ALL",
+	}
+	require.NoError(t, tokens.SaveAuthToken(token))
+	assert.Len(t, keyring, 1)
+	assert.Contains(t, keyring["zohosync/oauth-token"], "1000.refresh")
+	assert.Nil(t, storedToken(t, database), "the database holds no secret")
+	ref, err := database.GetConfigValue(tokenRefKey)
+	require.NoError(t, err)
+	assert.Equal(t, "keyring:zohosync/oauth-token", ref)
+
+	loaded, err = tokens.GetAuthToken()
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, token.AccessToken, loaded.AccessToken)
+	assert.Equal(t, token.RefreshToken, loaded.RefreshToken)
+	assert.True(t, token.ExpiresAt.Equal(loaded.ExpiresAt))
+	assert.Equal(t, token.Scope, loaded.Scope)
+
+	require.NoError(t, tokens.ClearAuthToken())
+	assert.Empty(t, keyring)
+	loaded, err = tokens.GetAuthToken()
+	require.NoError(t, err)
+	assert.Nil(t, loaded)
+}
+
+func TestKeyringTokenStoreMovesDatabaseToken(t *testing.T) {
+	database := newEmptyDatabase(t)
+	require.NoError(t, database.SaveAuthToken(&types.TokenInfo{AccessToken: "old", RefreshToken: "1000.refresh"}))
+
+	keyring := mockKeyring{}
+	tokens := newTokenStore(types.AuthConfig{TokenStore: TokenStoreKeyring}, database, openKeyring(keyring))
+	loaded, err := tokens.GetAuthToken()
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, "1000.refresh", loaded.RefreshToken)
+
+	assert.Len(t, keyring, 1)
+	assert.Nil(t, storedToken(t, database), "the token left the database")
+}
+
+func TestTokenStoreFallsBackToDatabase(t *testing.T) {
+	database := newEmptyDatabase(t)
+	unavailable := func() (Keyring, error) { return nil, errors.New("no D-Bus session bus") }
+
+	for _, name := range []string{"", TokenStoreDB, TokenStoreKeyring, "vault"} {
+		tokens := newTokenStore(types.AuthConfig{TokenStore: name}, database, unavailable)
+		assert.Same(t, database, tokens, name)
+	}
+
+	tokens := newTokenStore(types.AuthConfig{TokenStore: TokenStoreKeyring}, database, unavailable)
+	require.NoError(t, tokens.SaveAuthToken(&types.TokenInfo{AccessToken: "access"}))
+	assert.Equal(t, "access", storedToken(t, database).AccessToken)
+	require.NoError(t, tokens.ClearAuthToken())
+	assert.Nil(t, storedToken(t, database))
+}
@@ -0,0 +1,101 @@
+// Package backoff computes exponential retry delays with jitter, so that
+// clients that fail together do not all retry at the same moment
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Jitter is how much of a backoff delay is randomized
+type Jitter string
+
+const (
+	// JitterNone uses the exponential delay as it is
+	JitterNone Jitter = "none"
+	// JitterFull picks a delay between zero and the exponential delay,
+	// spreading retries the most
+	JitterFull Jitter = "full"
+	// JitterEqual keeps half the exponential delay and randomizes the other
+	// half, so that a retry never comes much sooner than without jitter
+	JitterEqual Jitter = "equal"
+)
+
+// DefaultJitter is used when no jitter is configured
+const DefaultJitter = JitterEqual
+
+// ParseJitter returns the jitter named by s, or DefaultJitter if s names
+// none
+func ParseJitter(s string) Jitter {
+	switch jitter := Jitter(strings.ToLower(strings.TrimSpace(s))); jitter {
+	case JitterNone, JitterFull, JitterEqual:
+		return jitter
+	default:
+		return DefaultJitter
+	}
+}
+
+// Policy describes a backoff: the delay starts at Initial and grows by
+// Factor with each attempt up to Max, then Jitter randomizes it. An empty
+// Jitter means DefaultJitter.
+type Policy struct {
+	Initial time.Duration
+	Max     time.Duration
+	Factor  float64
+	Jitter  Jitter
+	// Rand returns a random number in [0, 1). Nil uses math/rand, which is
+	// seeded differently in every process.
+	Rand func() float64
+}
+
+// Delay returns how long to wait before retrying after attempt failures,
+// counting from zero
+func (p Policy) Delay(attempt int) time.Duration {
+	return p.Apply(p.Exponential(attempt))
+}
+
+// Exponential returns the delay before attempt without jitter
+func (p Policy) Exponential(attempt int) time.Duration {
+	factor := p.Factor
+	if factor < 1 {
+		factor = 1
+	}
+	if attempt < 0 {
+		attempt = 0
+	}
+
+	delay := float64(p.Initial) * math.Pow(factor, float64(attempt))
+	if p.Max > 0 && delay > float64(p.Max) {
+		return p.Max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// Apply randomizes delay according to the policy's jitter
+func (p Policy) Apply(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return delay
+	}
+
+	switch p.Jitter {
+	case JitterNone:
+		return delay
+	case JitterFull:
+		return time.Duration(p.random() * float64(delay))
+	default:
+		half := delay / 2
+		return half + time.Duration(p.random()*float64(delay-half))
+	}
+}
+
+func (p Policy) random() float64 {
+	if p.Rand != nil {
+		return p.Rand()
+	}
+	return rand.Float64()
+}
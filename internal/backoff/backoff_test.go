@@ -0,0 +1,61 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialGrowsToMax(t *testing.T) {
+	policy := Policy{Initial: time.Second, Max: 30 * time.Second, Factor: 2}
+
+	assert.Equal(t, time.Second, policy.Exponential(0))
+	assert.Equal(t, 2*time.Second, policy.Exponential(1))
+	assert.Equal(t, 16*time.Second, policy.Exponential(4))
+	assert.Equal(t, 30*time.Second, policy.Exponential(5))
+	assert.Equal(t, 30*time.Second, policy.Exponential(100))
+}
+
+func TestJitterStaysWithinBounds(t *testing.T) {
+	for _, tc := range []struct {
+		jitter   Jitter
+		min, max float64
+	}{
+		{JitterNone, 1, 1},
+		{JitterFull, 0, 1},
+		{JitterEqual, 0.5, 1},
+		{"", 0.5, 1},
+	} {
+		policy := Policy{Initial: time.Second, Max: 30 * time.Second, Factor: 2, Jitter: tc.jitter}
+		for attempt := 0; attempt < 8; attempt++ {
+			exponential := policy.Exponential(attempt)
+			for i := 0; i < 100; i++ {
+				delay := policy.Delay(attempt)
+				assert.GreaterOrEqual(t, delay, time.Duration(tc.min*float64(exponential)), "jitter %q", tc.jitter)
+				assert.LessOrEqual(t, delay, time.Duration(tc.max*float64(exponential)), "jitter %q", tc.jitter)
+			}
+		}
+	}
+}
+
+func TestJitterUsesRandomSource(t *testing.T) {
+	low := Policy{Initial: 10 * time.Second, Factor: 2, Rand: func() float64 { return 0 }}
+	high := low
+	high.Rand = func() float64 { return 0.999 }
+
+	low.Jitter, high.Jitter = JitterFull, JitterFull
+	assert.Zero(t, low.Delay(0))
+	assert.InDelta(t, float64(9990*time.Millisecond), float64(high.Delay(0)), float64(time.Millisecond))
+
+	low.Jitter, high.Jitter = JitterEqual, JitterEqual
+	assert.Equal(t, 5*time.Second, low.Delay(0))
+	assert.InDelta(t, float64(9995*time.Millisecond), float64(high.Delay(0)), float64(time.Millisecond))
+}
+
+func TestParseJitter(t *testing.T) {
+	assert.Equal(t, JitterFull, ParseJitter(" Full "))
+	assert.Equal(t, JitterNone, ParseJitter("none"))
+	assert.Equal(t, DefaultJitter, ParseJitter(""))
+	assert.Equal(t, DefaultJitter, ParseJitter("sometimes"))
+}
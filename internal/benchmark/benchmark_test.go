@@ -0,0 +1,132 @@
+package benchmark
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tree the benchmarks run on can be reshaped from the command line, e.g.
+//
+//	go test -run '^$' -bench . -count 10 ./internal/benchmark -tree.files 10000
+var (
+	treeFiles   = flag.Int("tree.files", DefaultShape.Files, "number of files in the benchmark tree")
+	treeDepth   = flag.Int("tree.depth", DefaultShape.Depth, "folder levels below the root of the benchmark tree")
+	treeFanOut  = flag.Int("tree.fanout", DefaultShape.FanOut, "subfolders per folder of the benchmark tree")
+	treeMinSize = flag.Int64("tree.minsize", DefaultShape.MinSize, "smallest file of the benchmark tree in bytes")
+	treeMaxSize = flag.Int64("tree.maxsize", DefaultShape.MaxSize, "largest file of the benchmark tree in bytes")
+)
+
+// flagShape returns the tree shape given on the command line
+func flagShape() TreeShape {
+	return TreeShape{
+		Files:   *treeFiles,
+		Depth:   *treeDepth,
+		FanOut:  *treeFanOut,
+		MinSize: *treeMinSize,
+		MaxSize: *treeMaxSize,
+		Seed:    DefaultShape.Seed,
+	}
+}
+
+// newBenchSetup builds a setup of the command line shape whose tree has
+// already been uploaded
+func newBenchSetup(b *testing.B) *Setup {
+	b.Helper()
+	b.Setenv("HOME", b.TempDir())
+
+	setup, err := NewSetup(b.TempDir(), flagShape())
+	require.NoError(b, err)
+	b.Cleanup(func() { setup.Close() })
+	require.NoError(b, setup.Sync(context.Background()))
+	return setup
+}
+
+func TestGenerateTreeFollowsShape(t *testing.T) {
+	shape := TreeShape{Files: 50, Depth: 2, FanOut: 3, MinSize: 10, MaxSize: 20, Seed: 7}
+	tree, err := GenerateTree(t.TempDir(), shape)
+	require.NoError(t, err)
+
+	assert.Len(t, tree.Folders, 3+9)
+	assert.Len(t, tree.Files, 50)
+	var total int64
+	for _, path := range tree.Files {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, info.Size(), shape.MinSize)
+		assert.LessOrEqual(t, info.Size(), shape.MaxSize)
+		total += info.Size()
+	}
+	assert.Equal(t, tree.Bytes, total)
+
+	// The same seed gives the same tree
+	again, err := GenerateTree(t.TempDir(), shape)
+	require.NoError(t, err)
+	assert.Equal(t, tree.Bytes, again.Bytes)
+}
+
+func TestInvalidShapesAreRejected(t *testing.T) {
+	for name, shape := range map[string]TreeShape{
+		"no files":   {Files: 0},
+		"no fan-out": {Files: 1, Depth: 2},
+		"bad sizes":  {Files: 1, MinSize: 10, MaxSize: 5},
+	} {
+		_, err := GenerateTree(filepath.Join(t.TempDir(), "tree"), shape)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRunReportsEveryPhase(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	shape := TreeShape{Files: 40, Depth: 2, FanOut: 2, MinSize: 100, MaxSize: 4096, Seed: 1}
+
+	report, err := Run(context.Background(), t.TempDir(), shape)
+	require.NoError(t, err)
+	t.Log("\n" + report.String())
+
+	for _, phase := range []string{PhasePlan, PhaseHash, PhaseSync, PhaseReplan} {
+		result, ok := report.Result(phase)
+		require.True(t, ok, phase)
+		assert.Equal(t, 40, result.Files, phase)
+		assert.Positive(t, result.FilesPerSecond(), phase)
+	}
+	synced, _ := report.Result(PhaseSync)
+	assert.Positive(t, synced.MBPerSecond())
+}
+
+func BenchmarkPlanSync(b *testing.B) {
+	setup := newBenchSetup(b)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		differing, err := setup.Plan(ctx)
+		if err != nil || differing != 0 {
+			b.Fatalf("planned %d operations: %v", differing, err)
+		}
+	}
+	b.ReportMetric(float64(len(setup.Tree.Files)*b.N)/b.Elapsed().Seconds(), "files/s")
+}
+
+func BenchmarkHashTree(b *testing.B) {
+	b.Setenv("HOME", b.TempDir())
+	tree, err := GenerateTree(b.TempDir(), flagShape())
+	require.NoError(b, err)
+	b.SetBytes(tree.Bytes)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := HashTree(tree, utils.DefaultHashAlgorithm); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(tree.Files)*b.N)/b.Elapsed().Seconds(), "files/s")
+}
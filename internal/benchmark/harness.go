@@ -0,0 +1,237 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Phases of a harness run, in the order they run
+const (
+	// PhasePlan compares the tree with an empty remote folder
+	PhasePlan = "plan"
+	// PhaseHash hashes every file of the tree
+	PhaseHash = "hash"
+	// PhaseSync uploads the tree with an initial sync
+	PhaseSync = "sync"
+	// PhaseReplan compares the tree with its uploaded copy, walking both
+	PhaseReplan = "replan"
+)
+
+// Result is the throughput of one phase
+type Result struct {
+	Phase    string
+	Files    int
+	Bytes    int64
+	Duration time.Duration
+}
+
+// FilesPerSecond returns how many files the phase handled per second
+func (r Result) FilesPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Files) / r.Duration.Seconds()
+}
+
+// MBPerSecond returns how many megabytes the phase handled per second
+func (r Result) MBPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / (1 << 20) / r.Duration.Seconds()
+}
+
+// String formats the result as one line of a report
+func (r Result) String() string {
+	return fmt.Sprintf("%-7s %8d files %10.1f files/s %8.1f MB/s %12s",
+		r.Phase, r.Files, r.FilesPerSecond(), r.MBPerSecond(), r.Duration.Round(time.Microsecond))
+}
+
+// Report is the outcome of a harness run
+type Report struct {
+	Shape   TreeShape
+	Results []Result
+}
+
+// Result returns the result of a phase, if it ran
+func (r *Report) Result(phase string) (Result, bool) {
+	for _, result := range r.Results {
+		if result.Phase == phase {
+			return result, true
+		}
+	}
+	return Result{}, false
+}
+
+// String formats the report as a table
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "tree: %d files, depth %d, fan-out %d, %d-%d bytes\n",
+		r.Shape.Files, r.Shape.Depth, r.Shape.FanOut, r.Shape.MinSize, r.Shape.MaxSize)
+	for _, result := range r.Results {
+		b.WriteString(result.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Setup is a synthetic tree together with an engine syncing it to a local
+// backend, all kept under one directory
+type Setup struct {
+	Tree      *Tree
+	Folder    types.FolderConfig
+	Engine    *sync.Engine
+	Database  *storage.Database
+	RemoteDir string
+}
+
+// NewSetup generates a tree of the given shape under dir and an engine that
+// syncs it to an empty remote folder under dir. The caller closes it.
+func NewSetup(dir string, shape TreeShape) (*Setup, error) {
+	tree, err := GenerateTree(filepath.Join(dir, "local"), shape)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteDir := filepath.Join(dir, "remote")
+	if err := os.MkdirAll(remoteDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", remoteDir, err)
+	}
+	folder := types.FolderConfig{Local: tree.Root, Remote: "/", SyncMode: "bidirectional", Enabled: true}
+	config := &types.Config{
+		Remote:  types.RemoteConfig{Type: api.RemoteTypeLocal, Path: remoteDir},
+		Sync:    types.SyncConfig{PreserveMetadata: true},
+		Folders: []types.FolderConfig{folder},
+	}
+	backend, err := api.NewBackend(config, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := storage.NewDatabase(filepath.Join(dir, "zohosync.db"))
+	if err != nil {
+		return nil, err
+	}
+	return &Setup{
+		Tree:      tree,
+		Folder:    folder,
+		Engine:    sync.NewEngine(backend, database, config),
+		Database:  database,
+		RemoteDir: remoteDir,
+	}, nil
+}
+
+// Close closes the database of the setup
+func (s *Setup) Close() error {
+	return s.Database.Close()
+}
+
+// Plan compares the tree with the remote folder and returns how many paths
+// differ
+func (s *Setup) Plan(ctx context.Context) (int, error) {
+	diff, err := s.Engine.Diff(ctx, s.Folder)
+	if err != nil {
+		return 0, err
+	}
+	return len(diff.Entries), nil
+}
+
+// Sync uploads the tree with an initial sync
+func (s *Setup) Sync(ctx context.Context) error {
+	result, err := s.Engine.InitialSync(ctx, s.Folder)
+	if err != nil {
+		return err
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d of %d files failed to sync: %v", result.Failed, len(s.Tree.Files), result.Errors[0])
+	}
+	if result.Uploaded != len(s.Tree.Files) {
+		return fmt.Errorf("uploaded %d of %d files", result.Uploaded, len(s.Tree.Files))
+	}
+	return nil
+}
+
+// HashTree hashes every file of the tree and returns the bytes read
+func HashTree(tree *Tree, algorithm string) (int64, error) {
+	var total int64
+	for _, path := range tree.Files {
+		if _, err := utils.HashFile(path, algorithm); err != nil {
+			return total, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return total, err
+		}
+		total += info.Size()
+	}
+	return total, nil
+}
+
+// Run generates a tree of the given shape under dir and times each phase
+// against it in turn: planning it against an empty remote, hashing it,
+// uploading it, and planning it again against its uploaded copy
+func Run(ctx context.Context, dir string, shape TreeShape) (*Report, error) {
+	setup, err := NewSetup(dir, shape)
+	if err != nil {
+		return nil, err
+	}
+	defer setup.Close()
+
+	report := &Report{Shape: shape}
+	entries := len(setup.Tree.Files) + len(setup.Tree.Folders)
+	bytes := setup.Tree.Bytes
+	phases := []struct {
+		name string
+		run  func() error
+	}{
+		{PhasePlan, func() error {
+			return expectDiffering(ctx, setup, entries)
+		}},
+		{PhaseHash, func() error {
+			_, err := HashTree(setup.Tree, utils.DefaultHashAlgorithm)
+			return err
+		}},
+		{PhaseSync, func() error {
+			return setup.Sync(ctx)
+		}},
+		{PhaseReplan, func() error {
+			return expectDiffering(ctx, setup, 0)
+		}},
+	}
+
+	for _, phase := range phases {
+		start := time.Now()
+		if err := phase.run(); err != nil {
+			return report, fmt.Errorf("%s phase failed: %w", phase.name, err)
+		}
+		result := Result{Phase: phase.name, Files: len(setup.Tree.Files), Duration: time.Since(start)}
+		if phase.name == PhaseHash || phase.name == PhaseSync {
+			result.Bytes = bytes
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// expectDiffering plans the setup and checks how many paths differ, which
+// tells a planner that skipped part of the tree from a slow one
+func expectDiffering(ctx context.Context, setup *Setup, want int) error {
+	differing, err := setup.Plan(ctx)
+	if err != nil {
+		return err
+	}
+	if differing != want {
+		return fmt.Errorf("planned %d operations, want %d", differing, want)
+	}
+	return nil
+}
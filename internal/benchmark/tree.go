@@ -0,0 +1,107 @@
+// Package benchmark measures the throughput of the sync engine on synthetic
+// trees. It syncs against the local backend, so it needs no network and can
+// run in CI to catch regressions in the walker, planner and hasher.
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+// TreeShape describes a synthetic tree
+type TreeShape struct {
+	// Files is how many files the tree holds
+	Files int
+	// Depth is how many levels of folders there are below the root
+	Depth int
+	// FanOut is how many subfolders each folder above the last level has
+	FanOut int
+	// MinSize and MaxSize bound the size of each file in bytes
+	MinSize int64
+	MaxSize int64
+	// Seed makes file sizes and contents reproducible
+	Seed int64
+}
+
+// DefaultShape is a tree of a thousand small files in 85 folders
+var DefaultShape = TreeShape{
+	Files:   1000,
+	Depth:   3,
+	FanOut:  4,
+	MinSize: 1 << 10,
+	MaxSize: 64 << 10,
+	Seed:    1,
+}
+
+// Validate checks that a shape describes a tree that can be generated
+func (s TreeShape) Validate() error {
+	switch {
+	case s.Files <= 0:
+		return errors.New("a tree needs at least one file")
+	case s.Depth < 0:
+		return errors.New("depth cannot be negative")
+	case s.Depth > 0 && s.FanOut <= 0:
+		return errors.New("a nested tree needs a fan-out of at least one")
+	case s.MinSize < 0 || s.MaxSize < s.MinSize:
+		return fmt.Errorf("invalid file size range %d-%d", s.MinSize, s.MaxSize)
+	}
+	return nil
+}
+
+// Tree is a generated tree
+type Tree struct {
+	Root    string
+	Folders []string
+	Files   []string
+	Bytes   int64
+}
+
+// GenerateTree writes a tree of the given shape under root. Files are spread
+// evenly over the root and its folders and filled with pseudo-random data,
+// so hashing them does real work.
+func GenerateTree(root string, shape TreeShape) (*Tree, error) {
+	if err := shape.Validate(); err != nil {
+		return nil, err
+	}
+
+	tree := &Tree{Root: root}
+	folders := []string{root}
+	level := []string{root}
+	for depth := 0; depth < shape.Depth; depth++ {
+		var next []string
+		for _, parent := range level {
+			for i := 0; i < shape.FanOut; i++ {
+				next = append(next, filepath.Join(parent, fmt.Sprintf("dir%02d", i)))
+			}
+		}
+		folders = append(folders, next...)
+		tree.Folders = append(tree.Folders, next...)
+		level = next
+	}
+	for _, folder := range folders {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create %s: %w", folder, err)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(shape.Seed))
+	content := make([]byte, shape.MaxSize)
+	for i := 0; i < shape.Files; i++ {
+		size := shape.MinSize
+		if shape.MaxSize > shape.MinSize {
+			size += rng.Int63n(shape.MaxSize - shape.MinSize + 1)
+		}
+		rng.Read(content[:size])
+
+		path := filepath.Join(folders[i%len(folders)], fmt.Sprintf("file%06d.dat", i))
+		if err := os.WriteFile(path, content[:size], 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		tree.Files = append(tree.Files, path)
+		tree.Bytes += size
+	}
+	return tree, nil
+}
@@ -0,0 +1,51 @@
+// Package clock abstracts time so that schedules, timeouts and backoff can be
+// driven deterministically in tests
+package clock
+
+import "time"
+
+// Clock tells the time and waits for it to pass
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// Ticker delivers ticks like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the Clock of the system time
+type Real struct{}
+
+// Now returns the current time
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker returns a ticker wrapping time.NewTicker
+func (Real) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// After waits like time.After
+func (Real) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Sleep pauses like time.Sleep
+func (Real) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// realTicker adapts time.Ticker to the Ticker interface
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,151 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when advanced, firing the tickers
+// and waits that are due
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+	waits   []fakeWait
+	changed chan struct{}
+}
+
+// fakeWait is a pending After or Sleep
+type fakeWait struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFake creates a fake clock reading now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now, changed: make(chan struct{})}
+}
+
+// Now returns the fake time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTicker returns a ticker that fires as the clock is advanced past each
+// period
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTicker{clock: f, c: make(chan time.Time, 1), period: d, next: f.now.Add(d)}
+	f.tickers = append(f.tickers, t)
+	f.notify()
+	return t
+}
+
+// After returns a channel that receives the time once the clock has been
+// advanced by d
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.waits = append(f.waits, fakeWait{at: f.now.Add(d), c: c})
+	f.notify()
+	return c
+}
+
+// Sleep blocks until the clock has been advanced by d
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// Advance moves the time forward by d. Tickers drop ticks a busy receiver
+// has not taken, just like time.Ticker.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+	for _, t := range f.tickers {
+		for !t.next.After(f.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.period)
+		}
+	}
+
+	pending := f.waits[:0]
+	for _, w := range f.waits {
+		if w.at.After(f.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.c <- f.now
+	}
+	f.waits = pending
+	f.notify()
+}
+
+// Waiters returns the number of running tickers and pending waits
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.tickers) + len(f.waits)
+}
+
+// BlockUntil blocks until at least n tickers and waits are pending, so a
+// test can advance the clock knowing the code under test is waiting on it
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		if len(f.tickers)+len(f.waits) >= n {
+			f.mu.Unlock()
+			return
+		}
+		changed := f.changed
+		f.mu.Unlock()
+		<-changed
+	}
+}
+
+// notify wakes up BlockUntil; the clock must be locked
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// fakeTicker is a Ticker of a Fake clock
+type fakeTicker struct {
+	clock  *Fake
+	c      chan time.Time
+	period time.Duration
+	next   time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+// Stop removes the ticker from its clock
+func (t *fakeTicker) Stop() {
+	f := t.clock
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, other := range f.tickers {
+		if other == t {
+			f.tickers = append(f.tickers[:i], f.tickers[i+1:]...)
+			f.notify()
+			return
+		}
+	}
+}
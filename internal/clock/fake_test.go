@@ -0,0 +1,73 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+func TestFakeTickerFiresWhenAdvanced(t *testing.T) {
+	clock := NewFake(epoch)
+	ticker := clock.NewTicker(10 * time.Second)
+
+	clock.Advance(9 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticked before its period passed")
+	default:
+	}
+
+	// Ticks a busy receiver misses are dropped, as with time.Ticker
+	clock.Advance(25 * time.Second)
+	assert.Equal(t, epoch.Add(10*time.Second), <-ticker.C())
+	select {
+	case <-ticker.C():
+		t.Fatal("missed ticks were queued")
+	default:
+	}
+
+	assert.Equal(t, 1, clock.Waiters())
+	ticker.Stop()
+	assert.Zero(t, clock.Waiters())
+	assert.Equal(t, epoch.Add(34*time.Second), clock.Now())
+}
+
+func TestFakeSleepWakesWhenAdvanced(t *testing.T) {
+	clock := NewFake(epoch)
+
+	woke := make(chan struct{})
+	go func() {
+		clock.Sleep(time.Minute)
+		close(woke)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-woke:
+		t.Fatal("woke before the sleep was over")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case <-woke:
+	case <-time.After(time.Second):
+		t.Fatal("did not wake once the sleep was over")
+	}
+	assert.Zero(t, clock.Waiters())
+}
+
+func TestFakeAfterNonPositiveFiresImmediately(t *testing.T) {
+	clock := NewFake(epoch)
+	select {
+	case now := <-clock.After(0):
+		assert.Equal(t, epoch, now)
+	default:
+		require.Fail(t, "After(0) did not fire")
+	}
+}
@@ -2,10 +2,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
@@ -22,6 +24,8 @@ func LoadConfig() (*types.Config, error) {
 	
 	// Set defaults
 	setDefaults()
+	bindCredentialEnv()
+	bindEncryptionEnv()
 	
 	// Read config file
 	if err := viper.ReadInConfig(); err != nil {
@@ -29,33 +33,99 @@ func LoadConfig() (*types.Config, error) {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
 			return createDefaultConfig()
 		}
+		if file := viper.ConfigFileUsed(); file != "" {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
 		return nil, err
 	}
 	
-	// Unmarshal config
+	// Unmarshal config, pointing any error at the offending value
+	source := readConfigSource(viper.ConfigFileUsed())
 	var config types.Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := viper.Unmarshal(&config, decodeWithYAMLTags); err != nil {
+		return nil, source.explainDecodeError(err)
+	}
+	if err := source.validateSettings(&config); err != nil {
+		return nil, err
+	}
+	if err := resolveClientSecret(&config.Auth); err != nil {
+		return nil, err
+	}
+	if err := resolvePassphrase(&config.Encryption); err != nil {
+		return nil, err
+	}
+	if err := normalizeFolders(config.Folders); err != nil {
 		return nil, err
 	}
 	
 	return &config, nil
 }
 
+// decodeWithYAMLTags makes viper honour the yaml tags on the config types so
+// that snake_case keys such as sync_mode map onto their fields
+func decodeWithYAMLTags(dc *mapstructure.DecoderConfig) {
+	dc.TagName = "yaml"
+}
+
 func setDefaults() {
 	viper.SetDefault("app.name", "ZohoSync")
 	viper.SetDefault("app.version", "0.1.0")
 	viper.SetDefault("app.log_level", "info")
+	viper.SetDefault("app.health_addr", "127.0.0.1:8765")
+	viper.SetDefault("app.web_addr", "127.0.0.1:8766")
+	viper.SetDefault("app.operations_retention", 30)
+	viper.SetDefault("app.failed_operations_retention", 90)
 	
 	viper.SetDefault("auth.redirect_uri", "http://localhost:8080/callback")
 	viper.SetDefault("auth.scopes", []string{"WorkDrive.files.ALL", "WorkDrive.folders.ALL"})
+	viper.SetDefault("auth.token_store", "db")
+	viper.SetDefault("auth.flexible_redirect_port", false)
 	
 	viper.SetDefault("sync.interval", 300)
 	viper.SetDefault("sync.conflict_resolution", "newer")
-	viper.SetDefault("sync.max_concurrent_syncs", 5)
+	viper.SetDefault("sync.conflict_mode", "inline")
+	viper.SetDefault("sync.max_concurrent_syncs", DefaultMaxConcurrentSyncs)
+	viper.SetDefault("sync.preserve_metadata", true)
+	viper.SetDefault("sync.hash_algorithm", "sha256")
+	viper.SetDefault("sync.stable_for", 2)
+	viper.SetDefault("sync.shutdown_grace", 30)
+	viper.SetDefault("sync.chunk_size", 16)
+	viper.SetDefault("sync.chunk_concurrency", 4)
+	viper.SetDefault("sync.queue_order", "smallest-first")
+	viper.SetDefault("sync.delete_threshold", 100)
+	viper.SetDefault("sync.retry_budget", 5)
+	viper.SetDefault("sync.local_trash", true)
+	viper.SetDefault("sync.local_trash_retention", 30)
+	viper.SetDefault("sync.follow_remote_renames", true)
+	viper.SetDefault("sync.scan_concurrency", 2)
+	viper.SetDefault("sync.scan_throughput", 0)
+	viper.SetDefault("sync.skip_open_files", false)
+	viper.SetDefault("sync.advisory_locks", false)
+	viper.SetDefault("sync.lock_ttl", 120)
+	viper.SetDefault("sync.verify_uploads", true)
+	viper.SetDefault("sync.manual_conflict_timeout", 0)
+	viper.SetDefault("sync.manual_conflict_fallback", "keep_both")
+	viper.SetDefault("sync.content_cache_size", 0)
 	
 	viper.SetDefault("network.timeout", 30)
 	viper.SetDefault("network.max_retries", 3)
+	viper.SetDefault("network.idle_conn_timeout", 90)
+	viper.SetDefault("network.enable_http2", true)
+	viper.SetDefault("network.stall_timeout", 60)
+	viper.SetDefault("network.retry_jitter", "equal")
 	
+	viper.SetDefault("notifications.rate_limit", 900)
+	viper.SetDefault("notifications.failure_threshold", 3)
+	viper.SetDefault("notifications.smtp.port", 587)
+
+	viper.SetDefault("remote.type", "zoho")
+
+	viper.SetDefault("api.host", APIHost)
+	viper.SetDefault("api.base_path", APIBasePath)
+	viper.SetDefault("api.version", APIVersion)
+	viper.SetDefault("api.cache_ttl", DefaultAPICacheTTL)
+	viper.SetDefault("api.request_log", DefaultRequestLog)
+
 	viper.SetDefault("ui.theme", "light")
 	viper.SetDefault("ui.show_notifications", true)
 	viper.SetDefault("ui.minimize_to_tray", true)
@@ -64,28 +134,84 @@ func setDefaults() {
 func createDefaultConfig() (*types.Config, error) {
 	config := &types.Config{
 		App: types.AppConfig{
-			Name:     "ZohoSync",
-			Version:  "0.1.0",
-			LogLevel: "info",
+			Name:                      "ZohoSync",
+			Version:                   "0.1.0",
+			LogLevel:                  "info",
+			HealthAddr:                "127.0.0.1:8765",
+			WebAddr:                   "127.0.0.1:8766",
+			OperationsRetention:       30,
+			FailedOperationsRetention: 90,
 		},
 		Auth: types.AuthConfig{
-			RedirectURI: "http://localhost:8080/callback",
-			Scopes:      []string{"WorkDrive.files.ALL", "WorkDrive.folders.ALL"},
+			ClientID:         viper.GetString("auth.client_id"),
+			ClientSecret:     viper.GetString("auth.client_secret"),
+			ClientSecretFile: viper.GetString("auth.client_secret_file"),
+			RedirectURI:      "http://localhost:8080/callback",
+			Scopes:           []string{"WorkDrive.files.ALL", "WorkDrive.folders.ALL"},
+			ScopeProfile:     "full",
+			TokenStore:       "db",
 		},
 		Sync: types.SyncConfig{
-			Interval:           300,
-			ConflictResolution: "newer",
-			MaxConcurrentSyncs: 5,
+			Interval:            300,
+			ConflictResolution:  "newer",
+			ConflictMode:        "inline",
+			MaxConcurrentSyncs:  DefaultMaxConcurrentSyncs,
+			PreserveMetadata:    true,
+			HashAlgorithm:       "sha256",
+			StableFor:           2,
+			ShutdownGrace:       30,
+			ChunkSize:           16,
+			ChunkConcurrency:    4,
+			QueueOrder:          "smallest-first",
+			DeleteThreshold:     100,
+			RetryBudget:         5,
+			LocalTrash:          true,
+			LocalTrashRetention: 30,
+			FollowRemoteRenames: true,
+			ScanConcurrency:     2,
+			LockTTL:             120,
+			VerifyUploads:       true,
+			ManualConflictFallback: "keep_both",
+			ContentCacheSize:       0,
 		},
 		Network: types.NetworkConfig{
-			Timeout:    30,
-			MaxRetries: 3,
+			Timeout:         30,
+			MaxRetries:      3,
+			IdleConnTimeout: 90,
+			EnableHTTP2:     true,
+			StallTimeout:    60,
+			RetryJitter:     "equal",
+		},
+		Notifications: types.NotificationsConfig{
+			RateLimit:        900,
+			FailureThreshold: 3,
+			SMTP:             types.SMTPConfig{Port: 587},
+		},
+		Remote: types.RemoteConfig{
+			Type: "zoho",
+		},
+		API: types.APIConfig{
+			Host:     APIHost,
+			BasePath: APIBasePath,
+			Version:  APIVersion,
+			CacheTTL: DefaultAPICacheTTL,
+			RequestLog: DefaultRequestLog,
 		},
 		UI: types.UIConfig{
 			Theme:             "light",
 			ShowNotifications: true,
 			MinimizeToTray:    true,
 		},
+		Encryption: types.EncryptionConfig{
+			Passphrase:     viper.GetString("encryption.passphrase"),
+			PassphraseFile: viper.GetString("encryption.passphrase_file"),
+		},
+	}
+	if err := resolveClientSecret(&config.Auth); err != nil {
+		return nil, err
+	}
+	if err := resolvePassphrase(&config.Encryption); err != nil {
+		return nil, err
 	}
 	
 	return config, nil
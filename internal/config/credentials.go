@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/spf13/viper"
+)
+
+// Environment variables that supply the OAuth client credentials. They take
+// precedence over the secret file and the config file.
+const (
+	EnvClientID         = "ZOHOSYNC_CLIENT_ID"
+	EnvClientSecret     = "ZOHOSYNC_CLIENT_SECRET"
+	EnvClientSecretFile = "ZOHOSYNC_CLIENT_SECRET_FILE"
+)
+
+// bindCredentialEnv lets the credential environment variables override the
+// matching config keys
+func bindCredentialEnv() {
+	viper.BindEnv("auth.client_id", EnvClientID)
+	viper.BindEnv("auth.client_secret", EnvClientSecret)
+	viper.BindEnv("auth.client_secret_file", EnvClientSecretFile)
+}
+
+// resolveClientSecret reads the client secret from the configured secret
+// file unless it was given directly in the environment
+func resolveClientSecret(auth *types.AuthConfig) error {
+	if os.Getenv(EnvClientSecret) != "" || auth.ClientSecretFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(expandHome(auth.ClientSecretFile))
+	if err != nil {
+		return fmt.Errorf("failed to read client secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return fmt.Errorf("client secret file %s is empty", auth.ClientSecretFile)
+	}
+	auth.ClientSecret = secret
+	return nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return os.Getenv("HOME") + path[1:]
+	}
+	return path
+}
+
+// secretFromOutside reports whether the client secret came from the
+// environment or a secret file rather than the config file, in which case
+// it must not be written back to the config file
+func secretFromOutside(auth types.AuthConfig) bool {
+	return os.Getenv(EnvClientSecret) != "" || auth.ClientSecretFile != ""
+}
+
+// ValidateCredentials checks that both OAuth client credentials are set
+// before a login is attempted
+func ValidateCredentials(auth types.AuthConfig) error {
+	var missing []string
+	if auth.ClientID == "" {
+		missing = append(missing, fmt.Sprintf("client ID (set %s or auth.client_id)", EnvClientID))
+	}
+	if auth.ClientSecret == "" {
+		missing = append(missing, fmt.Sprintf("client secret (set %s, auth.client_secret_file or auth.client_secret)", EnvClientSecret))
+	}
+	if len(missing) > 0 {
+		return errors.New("missing OAuth " + strings.Join(missing, " and "))
+	}
+	return nil
+}
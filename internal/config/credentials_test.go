@@ -0,0 +1,123 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// setupHome points HOME at a temporary directory, optionally writing a
+// config file, and resets viper's global state
+func setupHome(t *testing.T, configYAML string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvClientID, "")
+	t.Setenv(EnvClientSecret, "")
+	t.Setenv(EnvClientSecretFile, "")
+	t.Setenv(EnvEncryptionPassphrase, "")
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	if configYAML != "" {
+		dir := filepath.Join(home, ".config", "zohosync")
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(configYAML), 0600))
+	}
+	return home
+}
+
+// writeSecret writes a secret file and returns its path
+func writeSecret(t *testing.T, secret string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "client_secret")
+	require.NoError(t, os.WriteFile(path, []byte(secret), 0600))
+	return path
+}
+
+func TestCredentialsFromYAML(t *testing.T) {
+	setupHome(t, "auth:\n  client_id: yaml-id\n  client_secret: yaml-secret\n")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "yaml-id", cfg.Auth.ClientID)
+	assert.Equal(t, "yaml-secret", cfg.Auth.ClientSecret)
+}
+
+func TestSecretFileOverridesYAML(t *testing.T) {
+	secretPath := writeSecret(t, "file-secret\n")
+	setupHome(t, "auth:\n  client_id: yaml-id\n  client_secret: yaml-secret\n  client_secret_file: "+secretPath+"\n")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "yaml-id", cfg.Auth.ClientID)
+	assert.Equal(t, "file-secret", cfg.Auth.ClientSecret)
+}
+
+func TestEnvOverridesSecretFileAndYAML(t *testing.T) {
+	secretPath := writeSecret(t, "file-secret")
+	setupHome(t, "auth:\n  client_id: yaml-id\n  client_secret: yaml-secret\n  client_secret_file: "+secretPath+"\n")
+	t.Setenv(EnvClientID, "env-id")
+	t.Setenv(EnvClientSecret, "env-secret")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "env-id", cfg.Auth.ClientID)
+	assert.Equal(t, "env-secret", cfg.Auth.ClientSecret)
+}
+
+func TestCredentialsFromEnvWithoutConfigFile(t *testing.T) {
+	setupHome(t, "")
+	t.Setenv(EnvClientID, "env-id")
+	t.Setenv(EnvClientSecretFile, writeSecret(t, "file-secret"))
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "env-id", cfg.Auth.ClientID)
+	assert.Equal(t, "file-secret", cfg.Auth.ClientSecret)
+	assert.NoError(t, ValidateCredentials(cfg.Auth))
+}
+
+func TestMissingSecretFile(t *testing.T) {
+	setupHome(t, "auth:\n  client_secret_file: /nonexistent/secret\n")
+
+	_, err := LoadConfig()
+	assert.Error(t, err)
+}
+
+func TestValidateCredentials(t *testing.T) {
+	err := ValidateCredentials(types.AuthConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), EnvClientID)
+	assert.Contains(t, err.Error(), EnvClientSecret)
+
+	err = ValidateCredentials(types.AuthConfig{ClientID: "id"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), EnvClientID)
+
+	assert.NoError(t, ValidateCredentials(types.AuthConfig{ClientID: "id", ClientSecret: "secret"}))
+}
+
+func TestSaveConfigOmitsOutsideSecret(t *testing.T) {
+	setupHome(t, "")
+	t.Setenv(EnvClientSecret, "env-secret")
+
+	cfg := &types.Config{Auth: types.AuthConfig{ClientID: "id", ClientSecret: "env-secret"}}
+	require.NoError(t, SaveConfig(cfg))
+	assert.Equal(t, "env-secret", cfg.Auth.ClientSecret, "the caller's config is unchanged")
+
+	data, err := os.ReadFile(ConfigPath())
+	require.NoError(t, err)
+	var saved types.Config
+	require.NoError(t, yaml.Unmarshal(data, &saved))
+	assert.Equal(t, "id", saved.Auth.ClientID)
+	assert.Empty(t, saved.Auth.ClientSecret)
+}
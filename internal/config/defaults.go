@@ -7,13 +7,20 @@ const (
 	DefaultSyncInterval = 300 // seconds
 	DefaultTimeout     = 30   // seconds
 	DefaultMaxRetries  = 3
+	DefaultMaxConcurrentSyncs = 5
+	DefaultAPICacheTTL = 10 // seconds
+	DefaultRequestLog  = "requests"
 	
 	// OAuth endpoints
 	AuthURL  = "https://accounts.zoho.com/oauth/v2/auth"
 	TokenURL = "https://accounts.zoho.com/oauth/v2/token"
 	
-	// API endpoints
-	APIBaseURL     = "https://workdrive.zoho.com/api/v1"
+	// API endpoints; the api config section overrides the host, base path
+	// and version that make up APIBaseURL
+	APIHost        = "https://workdrive.zoho.com"
+	APIBasePath    = "/api"
+	APIVersion     = "v1"
+	APIBaseURL     = APIHost + APIBasePath + "/" + APIVersion
 	UploadBaseURL  = "https://upload.zoho.com/workdrive-api/v1"
 	DownloadBaseURL = "https://download.zoho.com/v1/workdrive"
 )
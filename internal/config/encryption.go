@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/spf13/viper"
+)
+
+// EnvEncryptionPassphrase supplies the encryption passphrase. It takes
+// precedence over the passphrase file and the config file.
+const EnvEncryptionPassphrase = "ZOHOSYNC_ENCRYPTION_PASSPHRASE"
+
+// bindEncryptionEnv lets the passphrase environment variable override the
+// config key
+func bindEncryptionEnv() {
+	viper.BindEnv("encryption.passphrase", EnvEncryptionPassphrase)
+}
+
+// resolvePassphrase reads the encryption passphrase from the configured
+// file unless it was given in the environment, and checks that encryption
+// has a passphrase when it is enabled
+func resolvePassphrase(enc *types.EncryptionConfig) error {
+	if os.Getenv(EnvEncryptionPassphrase) == "" && enc.PassphraseFile != "" {
+		data, err := os.ReadFile(expandHome(enc.PassphraseFile))
+		if err != nil {
+			return fmt.Errorf("failed to read encryption passphrase file: %w", err)
+		}
+		// Only the line break an editor adds is dropped; spaces are part
+		// of the passphrase
+		enc.Passphrase = strings.TrimRight(string(data), "\r\n")
+	}
+
+	if enc.Enabled && enc.Passphrase == "" {
+		return errors.New("encryption is enabled but no passphrase is set (set " +
+			EnvEncryptionPassphrase + ", encryption.passphrase_file or encryption.passphrase)")
+	}
+	return nil
+}
+
+// passphraseFromOutside reports whether the passphrase came from the
+// environment or a file rather than the config file, in which case it must
+// not be written back to the config file
+func passphraseFromOutside(enc types.EncryptionConfig) bool {
+	return os.Getenv(EnvEncryptionPassphrase) != "" || enc.PassphraseFile != ""
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPassphraseFromFile(t *testing.T) {
+	passphrasePath := writeSecret(t, " correct horse \n")
+	setupHome(t, "encryption:\n  enabled: true\n  encrypt_names: true\n  passphrase_file: "+passphrasePath+"\n")
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.True(t, cfg.Encryption.EncryptNames)
+	assert.Equal(t, " correct horse ", cfg.Encryption.Passphrase, "only the trailing line break is dropped")
+
+	require.NoError(t, SaveConfig(cfg))
+	data, err := os.ReadFile(ConfigPath())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "correct horse")
+	var saved struct {
+		Encryption struct {
+			PassphraseFile string `yaml:"passphrase_file"`
+		} `yaml:"encryption"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &saved))
+	assert.Equal(t, passphrasePath, saved.Encryption.PassphraseFile)
+}
+
+func TestEncryptionRequiresPassphrase(t *testing.T) {
+	setupHome(t, "encryption:\n  enabled: true\n")
+
+	_, err := LoadConfig()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), EnvEncryptionPassphrase)
+
+	t.Setenv(EnvEncryptionPassphrase, "env-passphrase")
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "env-passphrase", cfg.Encryption.Passphrase)
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormatVersion is the version of the files ExportConfig writes.
+// Files of a newer version are refused rather than half imported.
+const ExportFormatVersion = 1
+
+// exportFile is the portable form of a configuration
+type exportFile struct {
+	FormatVersion   int       `yaml:"format_version"`
+	AppVersion      string    `yaml:"app_version,omitempty"`
+	ExportedAt      time.Time `yaml:"exported_at"`
+	IncludesSecrets bool      `yaml:"includes_secrets"`
+	Config          yaml.Node `yaml:"config"`
+}
+
+// ExportConfig writes cfg to path as a file ImportConfig reads on another
+// machine. The client secret, SMTP password, encryption passphrase and web
+// UI token are left out unless includeSecrets is set, in which case only the owner can
+// read the file.
+func ExportConfig(cfg *types.Config, path string, includeSecrets bool) error {
+	copied := *cfg
+	if !includeSecrets {
+		clearSecrets(&copied)
+	}
+
+	file := exportFile{
+		FormatVersion:   ExportFormatVersion,
+		AppVersion:      cfg.App.Version,
+		ExportedAt:      time.Now().UTC().Truncate(time.Second),
+		IncludesSecrets: includeSecrets,
+	}
+	if err := file.Config.Encode(&copied); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	data, err := yaml.Marshal(&file)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	mode := os.FileMode(0644)
+	if includeSecrets {
+		mode = 0600
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// ImportConfig reads a file written by ExportConfig and applies it to
+// current, returning the result; current is left unchanged. Without
+// replace, settings missing from the file keep their current values and
+// folders are added to the current ones, replacing those with the same
+// local path. With replace, missing settings take their defaults and only
+// the file's folders are kept. Secrets the file leaves out, and the app
+// version, are always those of current. A plain config.yaml is accepted
+// too.
+func ImportConfig(current *types.Config, path string, replace bool) (*types.Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import: %w", err)
+	}
+
+	var file exportFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if file.FormatVersion > ExportFormatVersion {
+		return nil, fmt.Errorf("%s was exported by a newer version of ZohoSync (format %d, this one reads up to %d); upgrade to import it",
+			path, file.FormatVersion, ExportFormatVersion)
+	}
+	section := &file.Config
+	if file.FormatVersion == 0 {
+		// Not an export: read the whole file as a config
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		section = &doc
+		file.IncludesSecrets = true
+	}
+
+	var imported types.Config
+	if replace {
+		defaults, err := createDefaultConfig()
+		if err != nil {
+			return nil, err
+		}
+		imported = *defaults
+	} else {
+		imported = *current
+		imported.Folders = nil
+		// Decoding adds to maps in place, so give it one of its own
+		imported.Sync.ExportFormats = make(map[string]string, len(current.Sync.ExportFormats))
+		for kind, format := range current.Sync.ExportFormats {
+			imported.Sync.ExportFormats[kind] = format
+		}
+	}
+	// Decoding onto the starting point only overwrites what the file sets
+	if err := section.Decode(&imported); err != nil {
+		return nil, fmt.Errorf("failed to read config from %s: %w", path, err)
+	}
+	source := &configSource{file: path, doc: section}
+	if section.Kind == yaml.DocumentNode && len(section.Content) > 0 {
+		source.doc = section.Content[0]
+	}
+	if err := source.validateSettings(&imported); err != nil {
+		return nil, err
+	}
+
+	if !replace {
+		imported.Folders = mergeFolders(current.Folders, imported.Folders)
+	}
+	if !file.IncludesSecrets {
+		keepSecrets(&imported, current)
+	}
+	imported.App.Version = current.App.Version
+
+	if err := ValidateConfig(&imported); err != nil {
+		return nil, fmt.Errorf("invalid config in %s: %w", path, err)
+	}
+	return &imported, nil
+}
+
+// ValidateConfig checks the settings an imported config cannot be used
+// without, and every setting against its validate tag
+func ValidateConfig(cfg *types.Config) error {
+	seen := make(map[string]bool, len(cfg.Folders))
+	for i, folder := range cfg.Folders {
+		if folder.Local == "" || folder.Remote == "" {
+			return fmt.Errorf("folder %d needs both a local and a remote path", i+1)
+		}
+		local := filepath.Clean(folder.Local)
+		if seen[local] {
+			return fmt.Errorf("folder %s is listed twice", folder.Local)
+		}
+		seen[local] = true
+	}
+	return (&configSource{}).validateSettings(cfg)
+}
+
+// mergeFolders adds imported folders to current ones, an imported folder
+// replacing a current one with the same local path
+func mergeFolders(current, imported []types.FolderConfig) []types.FolderConfig {
+	replaced := make(map[string]bool, len(imported))
+	for _, folder := range imported {
+		replaced[filepath.Clean(folder.Local)] = true
+	}
+
+	merged := make([]types.FolderConfig, 0, len(current)+len(imported))
+	for _, folder := range current {
+		if !replaced[filepath.Clean(folder.Local)] {
+			merged = append(merged, folder)
+		}
+	}
+	return append(merged, imported...)
+}
+
+// clearSecrets removes the secrets from cfg, keeping the files they are
+// read from
+func clearSecrets(cfg *types.Config) {
+	cfg.Auth.ClientSecret = ""
+	cfg.Notifications.SMTP.Password = ""
+	cfg.Encryption.Passphrase = ""
+	cfg.App.WebToken = ""
+}
+
+// keepSecrets copies the secrets of current into cfg
+func keepSecrets(cfg, current *types.Config) {
+	cfg.Auth.ClientSecret = current.Auth.ClientSecret
+	cfg.Notifications.SMTP.Password = current.Notifications.SMTP.Password
+	cfg.Encryption.Passphrase = current.Encryption.Passphrase
+	cfg.App.WebToken = current.App.WebToken
+}
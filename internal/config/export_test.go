@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+func testConfig(t *testing.T) *types.Config {
+	t.Helper()
+	cfg, err := createDefaultConfig()
+	require.NoError(t, err)
+	cfg.Auth.ClientID = "1000.ABC"
+	cfg.Auth.ClientSecret = "client-secret"
+	cfg.Notifications.SMTP.Password = "smtp-password"
+	cfg.Notifications.SMTP.To = []string{"me@example.com"}
+	cfg.Sync.Interval = 120
+	cfg.Sync.ExportFormats = map[string]string{"writer": "odt"}
+	cfg.Folders = []types.FolderConfig{
+		{Local: "/home/me/Documents", Remote: "/My Folders/Documents", SyncMode: "bidirectional", Enabled: true},
+		{Local: "/home/me/Marketing", Remote: "Marketing:/Campaigns", SyncMode: "download_only", Enabled: true, Interval: 60},
+	}
+	return cfg
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	setupHome(t, "")
+	cfg := testConfig(t)
+	path := filepath.Join(t.TempDir(), "setup.yaml")
+
+	require.NoError(t, ExportConfig(cfg, path, true))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "exports with secrets are private")
+
+	// Replacing a fresh machine's config reproduces the exported one
+	fresh := &types.Config{App: types.AppConfig{Version: cfg.App.Version}}
+	imported, err := ImportConfig(fresh, path, true)
+	require.NoError(t, err)
+	assert.Equal(t, cfg, imported)
+}
+
+func TestExportLeavesOutSecrets(t *testing.T) {
+	setupHome(t, "")
+	cfg := testConfig(t)
+	path := filepath.Join(t.TempDir(), "setup.yaml")
+	require.NoError(t, ExportConfig(cfg, path, false))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "client-secret")
+	assert.NotContains(t, string(data), "smtp-password")
+
+	// The importing machine keeps its own secrets
+	other := testConfig(t)
+	other.Auth.ClientSecret = "other-secret"
+	other.Notifications.SMTP.Password = ""
+	other.Folders = nil
+	imported, err := ImportConfig(other, path, false)
+	require.NoError(t, err)
+	assert.Equal(t, "other-secret", imported.Auth.ClientSecret)
+	assert.Empty(t, imported.Notifications.SMTP.Password)
+	assert.Equal(t, cfg.Folders, imported.Folders)
+}
+
+func TestImportMergesFolders(t *testing.T) {
+	setupHome(t, "")
+	path := filepath.Join(t.TempDir(), "setup.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`format_version: 1
+config:
+  sync:
+    interval: 60
+    export_formats:
+      sheet: csv
+  folders:
+    - local: /home/me/Documents
+      remote: /Work/Documents
+      sync_mode: upload_only
+      enabled: true
+    - local: /home/me/Photos
+      remote: /Photos
+      enabled: true
+`), 0644))
+
+	current := testConfig(t)
+	imported, err := ImportConfig(current, path, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, 60, imported.Sync.Interval)
+	assert.Equal(t, current.Sync.ConflictResolution, imported.Sync.ConflictResolution, "unset settings are kept")
+	assert.Equal(t, map[string]string{"writer": "odt", "sheet": "csv"}, imported.Sync.ExportFormats)
+	assert.Equal(t, map[string]string{"writer": "odt"}, current.Sync.ExportFormats, "the current config is not modified")
+
+	require.Len(t, imported.Folders, 3)
+	assert.Equal(t, "/home/me/Marketing", imported.Folders[0].Local)
+	assert.Equal(t, "/Work/Documents", imported.Folders[1].Remote, "a folder with the same local path is replaced")
+	assert.Equal(t, "/home/me/Photos", imported.Folders[2].Local)
+}
+
+func TestImportHandlesVersions(t *testing.T) {
+	setupHome(t, "")
+	dir := t.TempDir()
+
+	newer := filepath.Join(dir, "newer.yaml")
+	require.NoError(t, os.WriteFile(newer, []byte("format_version: 99\nconfig:\n  sync:\n    interval: 60\n"), 0644))
+	_, err := ImportConfig(testConfig(t), newer, false)
+	assert.ErrorContains(t, err, "newer version")
+
+	// A plain config.yaml, with settings this version does not know
+	plain := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(plain, []byte("sync:\n  interval: 90\n  future_setting: true\n"), 0644))
+	imported, err := ImportConfig(testConfig(t), plain, false)
+	require.NoError(t, err)
+	assert.Equal(t, 90, imported.Sync.Interval)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("format_version: 1\nconfig:\n  folders:\n    - local: /a\n      remote: /b\n      sync_mode: sideways\n"), 0644))
+	_, err = ImportConfig(testConfig(t), invalid, false)
+	assert.ErrorContains(t, err, `invalid.yaml:6:18: folders[0].sync_mode: unknown value "sideways"`)
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// ExpandPath resolves environment variables and a leading ~ in a local
+// path and makes it absolute
+func ExpandPath(path string) (string, error) {
+	path = strings.TrimSpace(os.ExpandEnv(path))
+	if path == "" {
+		return "", errors.New("path is empty")
+	}
+	return filepath.Abs(expandHome(path))
+}
+
+// normalizeFolders replaces the local path of each sync folder with its
+// expanded absolute form, as the walker and watcher take it verbatim, and
+// checks that the folders which are enabled are existing directories
+func normalizeFolders(folders []types.FolderConfig) error {
+	for i := range folders {
+		folder := &folders[i]
+		local, err := ExpandPath(folder.Local)
+		if err != nil {
+			return fmt.Errorf("invalid local path %q of sync folder %s: %w", folder.Local, folder.Remote, err)
+		}
+
+		if folder.Enabled {
+			info, err := os.Stat(local)
+			if err != nil {
+				return fmt.Errorf("sync folder %s (%s) is not accessible: %w", folder.Local, local, err)
+			}
+			if !info.IsDir() {
+				return fmt.Errorf("sync folder %s (%s) is not a directory", folder.Local, local)
+			}
+		}
+		folder.Local = local
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolderPathsAreExpanded(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("ZOHOSYNC_TEST_DATA", data)
+	home := setupHome(t, `
+folders:
+  - local: ~/Documents
+    remote: /Documents
+    enabled: true
+  - local: $ZOHOSYNC_TEST_DATA/projects
+    remote: /Projects
+    enabled: true
+  - local: ${ZOHOSYNC_TEST_DATA}/archive/../photos
+    remote: /Photos
+    enabled: true
+`)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "Documents"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(data, "projects"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(data, "photos"), 0755))
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	require.Len(t, cfg.Folders, 3)
+	assert.Equal(t, filepath.Join(home, "Documents"), cfg.Folders[0].Local)
+	assert.Equal(t, filepath.Join(data, "projects"), cfg.Folders[1].Local)
+	assert.Equal(t, filepath.Join(data, "photos"), cfg.Folders[2].Local)
+}
+
+func TestRelativeFolderPathIsMadeAbsolute(t *testing.T) {
+	expanded, err := ExpandPath("Zoho/docs")
+	require.NoError(t, err)
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(wd, "Zoho", "docs"), expanded)
+}
+
+func TestMissingFolderIsRejected(t *testing.T) {
+	setupHome(t, `
+folders:
+  - local: ~/Missing
+    remote: /Missing
+    enabled: true
+  - local: ~/Unplugged
+    remote: /Unplugged
+    enabled: false
+`)
+
+	_, err := LoadConfig()
+	assert.ErrorContains(t, err, "~/Missing")
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// ReloadOnSignal re-reads the configuration file whenever the process
+// receives SIGHUP and passes it to apply, so settings that support it can
+// change without a restart. The returned function stops listening.
+func ReloadOnSignal(apply func(*types.Config)) func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				cfg, err := LoadConfig()
+				if err != nil {
+					utils.GetLogger().Errorf("Failed to reload config: %v", err)
+					continue
+				}
+				apply(cfg)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
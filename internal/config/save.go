@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigPath returns the location of the user's configuration file
+func ConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "zohosync", "config.yaml")
+}
+
+// LoadedConfigPath returns the configuration file LoadConfig read, or
+// ConfigPath if there was none
+func LoadedConfigPath() string {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path
+	}
+	return ConfigPath()
+}
+
+// SaveConfig writes the configuration to the user's configuration file,
+// replacing it atomically
+func SaveConfig(cfg *types.Config) error {
+	path := ConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	// Keep secrets supplied from outside out of the config file
+	if secretFromOutside(cfg.Auth) {
+		copied := *cfg
+		copied.Auth.ClientSecret = ""
+		cfg = &copied
+	}
+	if passphraseFromOutside(cfg.Encryption) {
+		copied := *cfg
+		copied.Encryption.Passphrase = ""
+		cfg = &copied
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
+)
+
+// SettingError is a config setting that cannot be used. Line and Column
+// point at its value in the config file; they are zero when the value did
+// not come from a file, e.g. from an environment variable.
+type SettingError struct {
+	File    string
+	Line    int
+	Column  int
+	Setting string
+	Problem string
+}
+
+func (e *SettingError) Error() string {
+	switch {
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Setting, e.Problem)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s: %s", e.File, e.Setting, e.Problem)
+	default:
+		return fmt.Sprintf("%s: %s", e.Setting, e.Problem)
+	}
+}
+
+// configSource is the YAML a config was read from, used to point errors at
+// the offending values. A nil document locates nothing.
+type configSource struct {
+	file string
+	doc  *yaml.Node
+}
+
+// readConfigSource parses the config file for locating errors. A file that
+// cannot be read or parsed locates nothing; reading it properly is left to
+// viper, which reports why.
+func readConfigSource(file string) *configSource {
+	source := &configSource{file: file}
+	if file == "" {
+		return source
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return source
+	}
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) == nil && len(doc.Content) > 0 {
+		source.doc = doc.Content[0]
+	}
+	return source
+}
+
+// settingError returns an error about setting located in the source
+func (s *configSource) settingError(setting, format string, args ...interface{}) *SettingError {
+	err := &SettingError{File: s.file, Setting: setting, Problem: fmt.Sprintf(format, args...)}
+	if node := s.lookup(setting); node != nil {
+		err.Line, err.Column = node.Line, node.Column
+	}
+	return err
+}
+
+// lookup returns the node holding a setting such as sync.interval or
+// folders[1].sync_mode, or nil if the file does not set it. Keys match
+// regardless of case, as viper reads them.
+func (s *configSource) lookup(setting string) *yaml.Node {
+	node := s.doc
+	for _, key := range strings.Split(setting, ".") {
+		index := -1
+		if open := strings.IndexByte(key, '['); open >= 0 && strings.HasSuffix(key, "]") {
+			i, err := strconv.Atoi(key[open+1 : len(key)-1])
+			if err != nil {
+				return nil
+			}
+			key, index = key[:open], i
+		}
+		node = mappingValue(node, key)
+		if index >= 0 && node != nil {
+			switch {
+			case node.Kind == yaml.SequenceNode && index < len(node.Content):
+				node = node.Content[index]
+			case node.Kind != yaml.SequenceNode && index == 0:
+				// A single value is read as a list of one
+			default:
+				return nil
+			}
+		}
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// mappingValue returns the value of key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// decodedSetting finds the setting named in a decoding error
+var decodedSetting = regexp.MustCompile(`'([^']+)'`)
+
+// explainDecodeError turns the error viper returns for values of the wrong
+// type into one error per setting, each saying what the setting takes and
+// where the value is
+func (s *configSource) explainDecodeError(err error) error {
+	var decodeErr *mapstructure.Error
+	if !errors.As(err, &decodeErr) {
+		return err
+	}
+	var errs []error
+	for _, message := range decodeErr.Errors {
+		match := decodedSetting.FindStringSubmatch(message)
+		if match == nil {
+			errs = append(errs, errors.New(message))
+			continue
+		}
+		setting := match[1]
+		field := settingType(reflect.TypeOf(types.Config{}), setting)
+		if field == nil {
+			errs = append(errs, s.settingError(setting, "%s", message))
+			continue
+		}
+		got := "a value of another type"
+		if node := s.lookup(setting); node != nil {
+			got = describeNode(node)
+		}
+		errs = append(errs, s.settingError(setting, "expected %s, got %s", describeType(field), got))
+	}
+	return errors.Join(errs...)
+}
+
+// settingType returns the type of a setting within the config type t, or
+// nil if t has no such setting
+func settingType(t reflect.Type, setting string) reflect.Type {
+	for _, key := range strings.Split(setting, ".") {
+		indexed := false
+		if open := strings.IndexByte(key, '['); open >= 0 {
+			key, indexed = key[:open], true
+		}
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		field, ok := fieldByYAMLName(t, key)
+		if !ok {
+			return nil
+		}
+		t = field.Type
+		if indexed {
+			if t.Kind() != reflect.Slice {
+				return nil
+			}
+			t = t.Elem()
+		}
+	}
+	return t
+}
+
+// fieldByYAMLName returns the field of struct type t a YAML key sets
+func fieldByYAMLName(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if strings.EqualFold(yamlName(t.Field(i)), key) {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlName returns the key a field is set by, or "" for fields that are
+// not read from YAML
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// describeType says what values a setting of type t takes
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.String:
+		return "text"
+	case reflect.Slice:
+		return "a list"
+	default:
+		return "a group of settings"
+	}
+}
+
+// describeNode says what a YAML value is, for errors
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.MappingNode:
+		return "a group of settings"
+	default:
+		return strconv.Quote(node.Value)
+	}
+}
+
+// validateSettings checks every setting against the constraints in its
+// validate tag and returns an error for each one that breaks them
+func (s *configSource) validateSettings(cfg *types.Config) error {
+	var errs []error
+	s.validateStruct(reflect.ValueOf(cfg).Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+func (s *configSource) validateStruct(value reflect.Value, prefix string, errs *[]error) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := yamlName(field)
+		if name == "" {
+			continue
+		}
+		setting := prefix + name
+		fieldValue := value.Field(i)
+
+		if rules := field.Tag.Get("validate"); rules != "" {
+			if problem := checkRules(rules, fieldValue); problem != "" {
+				*errs = append(*errs, s.settingError(setting, "%s", problem))
+			}
+		}
+		switch fieldValue.Kind() {
+		case reflect.Struct:
+			s.validateStruct(fieldValue, setting+".", errs)
+		case reflect.Slice:
+			if fieldValue.Type().Elem().Kind() != reflect.Struct {
+				continue
+			}
+			for j := 0; j < fieldValue.Len(); j++ {
+				s.validateStruct(fieldValue.Index(j), fmt.Sprintf("%s[%d].", setting, j), errs)
+			}
+		}
+	}
+}
+
+// checkRules checks a value against the rules of a validate tag and returns
+// what is wrong with it, or "" if nothing is
+func checkRules(rules string, value reflect.Value) string {
+	fold := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "fold":
+			fold = true
+		case "oneof":
+			current := value.String()
+			if current == "" {
+				continue
+			}
+			allowed := strings.Fields(arg)
+			if !oneOf(current, allowed, fold) {
+				return fmt.Sprintf("unknown value %q, expected one of: %s", current, strings.Join(allowed, ", "))
+			}
+		case "min":
+			limit, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				panic(fmt.Sprintf("config: invalid validate rule %q", rule))
+			}
+			if value.Int() < limit {
+				return fmt.Sprintf("must be at least %d, got %d", limit, value.Int())
+			}
+		default:
+			panic(fmt.Sprintf("config: unknown validate rule %q", rule))
+		}
+	}
+	return ""
+}
+
+// oneOf reports whether value is one of allowed. With fold, case and "-"
+// versus "_" are ignored, as the code reading the setting does.
+func oneOf(value string, allowed []string, fold bool) bool {
+	if fold {
+		value = foldValue(value)
+	}
+	for _, candidate := range allowed {
+		if fold {
+			candidate = foldValue(candidate)
+		}
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+func foldValue(value string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), "-", "_")
+}
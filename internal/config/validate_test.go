@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// loadError loads configYAML and returns the error it is rejected with
+func loadError(t *testing.T, configYAML string) error {
+	t.Helper()
+	home := setupHome(t, configYAML)
+	_, err := LoadConfig()
+	require.Error(t, err)
+	file := filepath.Join(home, ".config", "zohosync", "config.yaml")
+	var settingErr *SettingError
+	if errors.As(err, &settingErr) {
+		assert.Equal(t, file, settingErr.File)
+	}
+	return err
+}
+
+func TestInvalidEnumsNameAllowedValues(t *testing.T) {
+	err := loadError(t, "sync:\n  interval: 60\n  conflict_resolution: newest\nui:\n  theme: solarized\n")
+
+	var settingErr *SettingError
+	require.ErrorAs(t, err, &settingErr)
+	assert.Equal(t, "sync.conflict_resolution", settingErr.Setting)
+	assert.Equal(t, 3, settingErr.Line)
+	assert.Equal(t, 24, settingErr.Column)
+	assert.Contains(t, err.Error(), `config.yaml:3:24: sync.conflict_resolution: unknown value "newest", expected one of: newer, local, remote, keep_both, manual`)
+	assert.Contains(t, err.Error(), `config.yaml:5:10: ui.theme: unknown value "solarized", expected one of: light, dark`, "every problem is reported")
+}
+
+func TestInvalidFolderSettingsAreLocated(t *testing.T) {
+	err := loadError(t, `folders:
+  - local: /tmp/a
+    remote: /A
+    enabled: false
+  - local: /tmp/b
+    remote: /B
+    sync_mode: sideways
+    enabled: false
+    interval: -5
+`)
+	assert.Contains(t, err.Error(), `config.yaml:7:16: folders[1].sync_mode: unknown value "sideways", expected one of: bidirectional, upload_only, download_only`)
+	assert.Contains(t, err.Error(), `config.yaml:9:15: folders[1].interval: must be at least 0, got -5`)
+}
+
+func TestValuesOfTheWrongTypeAreLocated(t *testing.T) {
+	err := loadError(t, "sync:\n  interval: often\n  preserve_metadata: sometimes\nfolders: /tmp/a\n")
+	assert.Contains(t, err.Error(), `config.yaml:2:13: sync.interval: expected a whole number, got "often"`)
+	assert.Contains(t, err.Error(), `config.yaml:3:22: sync.preserve_metadata: expected true or false, got "sometimes"`)
+	assert.Contains(t, err.Error(), `config.yaml:4:10: folders[0]: expected a group of settings, got "/tmp/a"`)
+}
+
+func TestSyntaxErrorsNameTheFile(t *testing.T) {
+	err := loadError(t, "sync:\n  interval: 60\n conflict_resolution: newer\n")
+	assert.Contains(t, err.Error(), "config.yaml")
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestEquivalentSpellingsAreAccepted(t *testing.T) {
+	setupHome(t, "app:\n  log_level: DEBUG\nsync:\n  hash_algorithm: SHA256\nfolders:\n  - local: /tmp/a\n    remote: /A\n    sync_mode: Upload-Only\n    enabled: false\n")
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "Upload-Only", cfg.Folders[0].SyncMode)
+}
+
+func TestSettingErrorWithoutFile(t *testing.T) {
+	cfg := &types.Config{Sync: types.SyncConfig{QueueOrder: "random"}}
+	err := ValidateConfig(cfg)
+	assert.EqualError(t, err, `sync.queue_order: unknown value "random", expected one of: smallest-first, fifo, largest-first`)
+}
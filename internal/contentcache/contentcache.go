@@ -0,0 +1,263 @@
+// Package contentcache keeps copies of file contents named by their hash,
+// so content already on this machine does not have to be downloaded again.
+// The least recently used contents are evicted once the cache outgrows its
+// size limit.
+package contentcache
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotCached is returned by Open for content the cache does not hold
+var ErrNotCached = errors.New("content is not cached")
+
+// tempPrefix marks files being added, which New removes
+const tempPrefix = ".tmp-"
+
+// entry is one cached content
+type entry struct {
+	hash    string
+	size    int64
+	element *list.Element
+}
+
+// Cache is a content-addressable store in a directory. Contents are copied
+// in rather than hard linked, so editing a file in place cannot change what
+// the cache holds, and the modification times the cache keeps its order in
+// are its own. It is safe for concurrent use.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	// lru holds the hashes, most recently used first
+	lru  *list.List
+	size int64
+}
+
+// New opens the cache in dir, creating it if need be, and limits it to
+// maxBytes. Contents already in dir are ordered by when they were last used.
+func New(dir string, maxBytes int64) (*Cache, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("content cache size must be positive, got %d", maxBytes)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create content cache: %w", err)
+	}
+
+	type found struct {
+		hash   string
+		size   int64
+		usedAt time.Time
+	}
+	var contents []found
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), tempPrefix) {
+			// Left by an add that was cut short
+			os.Remove(path)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !validHash(d.Name()) {
+			return nil
+		}
+		contents = append(contents, found{hash: d.Name(), size: info.Size(), usedAt: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read content cache: %w", err)
+	}
+	sort.Slice(contents, func(i, j int) bool { return contents[i].usedAt.After(contents[j].usedAt) })
+
+	c := &Cache{dir: dir, maxBytes: maxBytes, entries: make(map[string]*entry), lru: list.New()}
+	for _, content := range contents {
+		e := &entry{hash: content.hash, size: content.size}
+		e.element = c.lru.PushBack(e)
+		c.entries[content.hash] = e
+		c.size += content.size
+	}
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+	return c, nil
+}
+
+// path returns where the content with hash is kept. Contents are spread
+// over subdirectories by the first two characters of their hash.
+func (c *Cache) path(hash string) string {
+	return filepath.Join(c.dir, hash[:2], hash)
+}
+
+// validHash reports whether hash can name a cached content
+func validHash(hash string) bool {
+	if len(hash) < 4 {
+		return false
+	}
+	for _, r := range hash {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Has reports whether the content with hash is cached
+func (c *Cache) Has(hash string) bool {
+	hash = strings.ToLower(hash)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[hash]
+	return ok
+}
+
+// Size returns the bytes the cache holds
+func (c *Cache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// Open returns the cached content with hash for reading and marks it as
+// just used. It returns ErrNotCached if there is none. The content can be
+// read to the end even if it is evicted meanwhile.
+func (c *Cache) Open(hash string) (*os.File, error) {
+	hash = strings.ToLower(hash)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[hash]
+	if !ok {
+		return nil, ErrNotCached
+	}
+	file, err := os.Open(c.path(hash))
+	if err != nil {
+		c.drop(e)
+		return nil, ErrNotCached
+	}
+	c.lru.MoveToFront(e.element)
+	now := time.Now()
+	os.Chtimes(c.path(hash), now, now)
+	return file, nil
+}
+
+// Add caches the content of the file at path under hash, which the caller
+// vouches for, then evicts the least recently used contents past the size
+// limit. Content already cached is only marked as used. Content larger than
+// the whole cache is not added.
+func (c *Cache) Add(hash, path string) error {
+	hash = strings.ToLower(hash)
+	if !validHash(hash) {
+		return fmt.Errorf("invalid content hash %q", hash)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() || info.Size() > c.maxBytes {
+		return nil
+	}
+
+	c.mu.Lock()
+	if e, ok := c.entries[hash]; ok {
+		c.lru.MoveToFront(e.element)
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+
+	target := c.path(hash)
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+	temp, err := copyToTemp(path, filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[hash]; ok {
+		// Added by someone else meanwhile
+		c.lru.MoveToFront(e.element)
+		return nil
+	}
+	if err := os.Rename(temp, target); err != nil {
+		return err
+	}
+	now := time.Now()
+	os.Chtimes(target, now, now)
+	e := &entry{hash: hash, size: info.Size()}
+	e.element = c.lru.PushFront(e)
+	c.entries[hash] = e
+	c.size += e.size
+	c.evict()
+	return nil
+}
+
+// Remove forgets the content with hash, for one found to be damaged
+func (c *Cache) Remove(hash string) {
+	hash = strings.ToLower(hash)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[hash]; ok {
+		c.drop(e)
+	}
+}
+
+// copyToTemp copies the file at path into a new temporary file in dir and
+// returns its path
+func copyToTemp(path, dir string) (string, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
+}
+
+// evict removes the least recently used contents until the cache fits its
+// size limit. The caller holds the lock.
+func (c *Cache) evict() {
+	for c.size > c.maxBytes {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			return
+		}
+		c.drop(oldest.Value.(*entry))
+	}
+}
+
+// drop removes a content from the cache. The caller holds the lock.
+func (c *Cache) drop(e *entry) {
+	os.Remove(c.path(e.hash))
+	c.lru.Remove(e.element)
+	delete(c.entries, e.hash)
+	c.size -= e.size
+}
@@ -0,0 +1,87 @@
+package contentcache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// source writes content to a file to add to a cache
+func source(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "source")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func read(t *testing.T, c *Cache, hash string) string {
+	t.Helper()
+	file, err := c.Open(hash)
+	require.NoError(t, err)
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c, err := New(t.TempDir(), 10)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Add("aaaa", source(t, "1111")))
+	require.NoError(t, c.Add("bbbb", source(t, "2222")))
+	assert.Equal(t, "1111", read(t, c, "AAAA"), "hashes are not case sensitive")
+
+	// bbbb is now the least recently used
+	require.NoError(t, c.Add("cccc", source(t, "3333")))
+	assert.True(t, c.Has("aaaa"))
+	assert.False(t, c.Has("bbbb"))
+	assert.True(t, c.Has("cccc"))
+	assert.Equal(t, int64(8), c.Size())
+
+	_, err = c.Open("bbbb")
+	assert.ErrorIs(t, err, ErrNotCached)
+
+	// Content larger than the cache is not kept
+	require.NoError(t, c.Add("dddd", source(t, strings.Repeat("4", 11))))
+	assert.False(t, c.Has("dddd"))
+	assert.Equal(t, int64(8), c.Size())
+
+	assert.Error(t, c.Add("../x", source(t, "5")))
+}
+
+func TestCacheKeepsItsOrderWhenReopened(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 100)
+	require.NoError(t, err)
+	require.NoError(t, c.Add("aaaa", source(t, "1111")))
+	require.NoError(t, c.Add("bbbb", source(t, "2222")))
+	require.NoError(t, c.Add("cccc", source(t, "3333")))
+
+	// aaaa was used last; an add cut short leaves a temporary file
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "bb", "bbbb"), old, old))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "cc", "cccc"), old.Add(time.Minute), old.Add(time.Minute)))
+	temp := filepath.Join(dir, "dd", tempPrefix+"1")
+	require.NoError(t, os.MkdirAll(filepath.Dir(temp), 0700))
+	require.NoError(t, os.WriteFile(temp, []byte("partial"), 0600))
+
+	c, err = New(dir, 8)
+	require.NoError(t, err)
+	assert.True(t, c.Has("aaaa"))
+	assert.False(t, c.Has("bbbb"), "the least recently used content is evicted")
+	assert.True(t, c.Has("cccc"))
+	assert.Equal(t, "3333", read(t, c, "cccc"))
+	assert.NoFileExists(t, temp)
+	assert.NoFileExists(t, filepath.Join(dir, "bb", "bbbb"))
+
+	c.Remove("cccc")
+	assert.False(t, c.Has("cccc"))
+	assert.Equal(t, int64(4), c.Size())
+}
@@ -0,0 +1,207 @@
+// Package control implements the daemon control socket used by the CLI
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/utils"
+)
+
+// ErrDaemonNotRunning is returned when no daemon is listening on the socket
+var ErrDaemonNotRunning = errors.New("zohosync daemon is not running")
+
+// Request is a command sent to the daemon over the control socket
+type Request struct {
+	Command string            `json:"command"`
+	Args    map[string]string `json:"args,omitempty"`
+}
+
+// Response is the daemon's reply to a Request
+type Response struct {
+	OK    bool            `json:"ok"`
+	State string          `json:"state,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// HandlerFunc handles a single control command
+type HandlerFunc func(ctx context.Context, req Request) Response
+
+// DefaultSocketPath returns the control socket location in the config directory
+func DefaultSocketPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "zohosync", "zohosync.sock")
+}
+
+// ErrorResponse builds a failed response from an error
+func ErrorResponse(err error) Response {
+	return Response{OK: false, Error: err.Error()}
+}
+
+// DataResponse builds a successful response carrying a JSON payload
+func DataResponse(data interface{}) Response {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return ErrorResponse(fmt.Errorf("failed to encode response: %w", err))
+	}
+	return Response{OK: true, Data: raw}
+}
+
+// Server listens on a unix socket and dispatches commands to handlers
+type Server struct {
+	socketPath string
+	listener   net.Listener
+	handlers   map[string]HandlerFunc
+	logger     *utils.Logger
+	mu         sync.RWMutex
+	wg         sync.WaitGroup
+}
+
+// NewServer creates a control server for the given socket path
+func NewServer(socketPath string) *Server {
+	return &Server{
+		socketPath: socketPath,
+		handlers:   make(map[string]HandlerFunc),
+		logger:     utils.GetLogger(),
+	}
+}
+
+// Handle registers a handler for a command
+func (s *Server) Handle(command string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[command] = handler
+}
+
+// Start begins accepting connections until ctx is done or Close is called
+func (s *Server) Start(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// Remove a stale socket left behind by a crashed daemon
+	if _, err := Send(s.socketPath, Request{Command: "ping"}); errors.Is(err, ErrDaemonNotRunning) {
+		os.Remove(s.socketPath)
+	} else if err == nil {
+		return fmt.Errorf("another daemon is already listening on %s", s.socketPath)
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to secure control socket: %w", err)
+	}
+	s.listener = listener
+
+	s.Handle("ping", func(ctx context.Context, req Request) Response {
+		return Response{OK: true}
+	})
+
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+
+	s.wg.Add(1)
+	go s.acceptLoop(ctx)
+
+	s.logger.Infof("Control socket listening on %s", s.socketPath)
+	return nil
+}
+
+// Close stops the server and removes the socket file
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	s.wg.Wait()
+	os.Remove(s.socketPath)
+	return err
+}
+
+// acceptLoop accepts connections until the listener is closed
+func (s *Server) acceptLoop(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logger.Errorf("Control socket accept error: %v", err)
+			continue
+		}
+		go s.serveConn(ctx, conn)
+	}
+}
+
+// serveConn reads a single request and writes a single response
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(ErrorResponse(fmt.Errorf("invalid request: %w", err)))
+		return
+	}
+
+	resp := s.Dispatch(ctx, req)
+	s.logger.Debugf("Control command %q handled (ok=%t)", req.Command, resp.OK)
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		s.logger.Errorf("Failed to write control response: %v", err)
+	}
+}
+
+// Dispatch runs the handler of req's command, letting other front ends such
+// as the web UI share the commands of the socket
+func (s *Server) Dispatch(ctx context.Context, req Request) Response {
+	s.mu.RLock()
+	handler, ok := s.handlers[req.Command]
+	s.mu.RUnlock()
+
+	if !ok {
+		return ErrorResponse(fmt.Errorf("unknown command: %s", req.Command))
+	}
+	return handler(ctx, req)
+}
+
+// Send delivers a request to the daemon and waits for its response
+func Send(socketPath string, req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", socketPath, 2*time.Second)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT) {
+			return nil, ErrDaemonNotRunning
+		}
+		return nil, fmt.Errorf("failed to connect to daemon: %w", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send command: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read daemon response: %w", err)
+	}
+
+	if !resp.OK && resp.Error != "" {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,71 @@
+package control
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseResumeOverSocket(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	dbPath := filepath.Join(home, "zohosync.db")
+	database, err := storage.NewDatabase(dbPath)
+	require.NoError(t, err)
+	defer database.Close()
+
+	client := api.NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+	engine := sync.NewEngine(client, database, &types.Config{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	socketPath := filepath.Join(home, "zohosync.sock")
+	control := NewServer(socketPath)
+	RegisterEngineHandlers(control, engine)
+	require.NoError(t, control.Start(ctx))
+	defer control.Close()
+
+	resp, err := Send(socketPath, Request{Command: "pause"})
+	require.NoError(t, err)
+	assert.Equal(t, string(types.SyncStatePaused), resp.State)
+	assert.True(t, engine.IsPaused())
+
+	// A restarted engine picks up the persisted paused state
+	restarted := sync.NewEngine(client, database, &types.Config{})
+	assert.True(t, restarted.IsPaused())
+
+	_, err = Send(socketPath, Request{Command: "sync-now"})
+	assert.Error(t, err, "a paused engine does not sync on request")
+
+	resp, err = Send(socketPath, Request{Command: "resume"})
+	require.NoError(t, err)
+	assert.Equal(t, string(types.SyncStateIdle), resp.State)
+	assert.False(t, engine.IsPaused())
+
+	resp, err = Send(socketPath, Request{Command: "sync-now"})
+	require.NoError(t, err)
+	assert.Equal(t, string(types.SyncStateSyncing), resp.State)
+
+	_, err = Send(socketPath, Request{Command: "bogus"})
+	assert.Error(t, err)
+}
+
+func TestSendWithoutDaemon(t *testing.T) {
+	_, err := Send(filepath.Join(t.TempDir(), "missing.sock"), Request{Command: "pause"})
+	assert.ErrorIs(t, err, ErrDaemonNotRunning)
+}
@@ -0,0 +1,114 @@
+package control
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// RegisterEngineHandlers exposes sync engine controls on the server
+func RegisterEngineHandlers(server *Server, engine *sync.Engine) {
+	server.Handle("pause", func(ctx context.Context, req Request) Response {
+		if err := engine.Pause(); err != nil {
+			return ErrorResponse(err)
+		}
+		return Response{OK: true, State: string(types.SyncStatePaused)}
+	})
+
+	server.Handle("resume", func(ctx context.Context, req Request) Response {
+		if err := engine.Resume(); err != nil {
+			return ErrorResponse(err)
+		}
+		return Response{OK: true, State: string(types.SyncStateIdle)}
+	})
+
+	server.Handle("sync-now", func(ctx context.Context, req Request) Response {
+		if engine.IsPaused() {
+			return ErrorResponse(fmt.Errorf("sync is paused; resume it first"))
+		}
+		engine.TriggerSync()
+		return Response{OK: true, State: string(types.SyncStateSyncing)}
+	})
+
+	server.Handle("cancel", func(ctx context.Context, req Request) Response {
+		path := req.Args["path"]
+		if path == "" {
+			return ErrorResponse(fmt.Errorf("cancel requires a path"))
+		}
+		if !engine.CancelTransfer(path) {
+			return ErrorResponse(fmt.Errorf("no transfer in progress for %s", path))
+		}
+		return Response{OK: true}
+	})
+
+	server.Handle("resync", func(ctx context.Context, req Request) Response {
+		path := req.Args["path"]
+		if path == "" {
+			return ErrorResponse(fmt.Errorf("resync requires a path"))
+		}
+		direction, err := sync.ParseResyncDirection(req.Args["direction"])
+		if err != nil {
+			return ErrorResponse(err)
+		}
+
+		paths, err := engine.MarkResync(path, direction)
+		if err != nil {
+			return ErrorResponse(err)
+		}
+		// Reply before the transfers finish; they can outlast the connection
+		go engine.SyncPaths(ctx, paths)
+		return DataResponse(paths)
+	})
+
+	server.Handle("deletions", func(ctx context.Context, req Request) Response {
+		return DataResponse(engine.PendingDeletions())
+	})
+
+	server.Handle("confirm-deletions", func(ctx context.Context, req Request) Response {
+		deleted, err := engine.ConfirmDeletions(ctx)
+		if err != nil {
+			return ErrorResponse(err)
+		}
+		return DataResponse(deleted)
+	})
+
+	server.Handle("reject-deletions", func(ctx context.Context, req Request) Response {
+		paths, err := engine.RejectDeletions()
+		if err != nil {
+			return ErrorResponse(err)
+		}
+		go engine.SyncPaths(ctx, paths)
+		return DataResponse(paths)
+	})
+
+	server.Handle("retry-failed", func(ctx context.Context, req Request) Response {
+		var paths []string
+		if path := req.Args["path"]; path != "" {
+			paths = append(paths, path)
+		}
+		retried, err := engine.RetryFailed(paths...)
+		if err != nil {
+			return ErrorResponse(err)
+		}
+		return DataResponse(retried)
+	})
+
+	server.Handle("watches", func(ctx context.Context, req Request) Response {
+		if req.Args["retry"] != "" {
+			engine.RetryWatches(ctx)
+		}
+		return DataResponse(engine.WatchStatus())
+	})
+
+	server.Handle("status", func(ctx context.Context, req Request) Response {
+		status, err := engine.GetSyncStatus()
+		if err != nil {
+			return ErrorResponse(err)
+		}
+		resp := DataResponse(status)
+		resp.State = string(status.State)
+		return resp
+	})
+}
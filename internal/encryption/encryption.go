@@ -0,0 +1,252 @@
+// Package encryption encrypts file contents and names with a key derived from
+// a passphrase, so that the remote storage only ever holds ciphertext.
+//
+// An encrypted file starts with a header carrying the scrypt parameters and
+// salt the key was derived with and a random per-file nonce. The content
+// follows in sealed AES-GCM chunks of ChunkSize plaintext bytes, each with
+// its own nonce derived from the file nonce and the chunk index. The last
+// chunk is marked so truncation is detected, and an empty file still has
+// one.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// ChunkSize is the amount of plaintext sealed in each chunk
+	ChunkSize = 64 << 10
+
+	// HeaderSize is the length of the header in front of every encrypted file
+	HeaderSize = len(magic) + 3 + saltSize + nonceSize
+
+	saltSize  = 16
+	nonceSize = 12
+	keySize   = 32
+	tagSize   = 16
+
+	// sealedChunkSize is the length of a full chunk once sealed
+	sealedChunkSize = ChunkSize + tagSize
+)
+
+// magic starts every encrypted file and identifies the format version
+const magic = "ZSE\x01"
+
+// nameSalt is the fixed salt of the name key. Names must encrypt the same
+// way on every machine sharing the passphrase so they can be looked up.
+const nameSalt = "zohosync/names"
+
+var (
+	// ErrNoPassphrase is returned when encryption is set up without a
+	// passphrase
+	ErrNoPassphrase = errors.New("encryption passphrase is empty")
+
+	// ErrWrongPassphrase is returned when content does not decrypt with the
+	// key derived from the passphrase
+	ErrWrongPassphrase = errors.New("wrong passphrase or corrupted content")
+
+	// ErrCorrupted is returned when encrypted content is malformed or was
+	// cut short
+	ErrCorrupted = errors.New("encrypted content is corrupted")
+
+	// ErrNotEncrypted is returned when content does not start with the
+	// encryption header
+	ErrNotEncrypted = errors.New("content is not encrypted")
+)
+
+// Params are the scrypt cost parameters a key is derived with, N being
+// 1<<LogN
+type Params struct {
+	LogN uint8
+	R    uint8
+	P    uint8
+}
+
+// DefaultParams are the scrypt parameters new files are encrypted with
+var DefaultParams = Params{LogN: 15, R: 8, P: 1}
+
+// Cipher encrypts and decrypts with the keys derived from one passphrase.
+// It is safe for concurrent use.
+type Cipher struct {
+	passphrase []byte
+	params     Params
+	salt       []byte
+
+	names   cipher.AEAD
+	nameMAC []byte
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+// New derives the keys for passphrase with DefaultParams
+func New(passphrase string) (*Cipher, error) {
+	return NewWithParams(passphrase, DefaultParams)
+}
+
+// NewWithParams derives the keys for passphrase with the given scrypt
+// parameters. Files are always decrypted with the parameters recorded in
+// their header.
+func NewWithParams(passphrase string, params Params) (*Cipher, error) {
+	if passphrase == "" {
+		return nil, ErrNoPassphrase
+	}
+
+	c := &Cipher{
+		passphrase: []byte(passphrase),
+		params:     params,
+		salt:       make([]byte, saltSize),
+		keys:       make(map[string]cipher.AEAD),
+	}
+	if _, err := rand.Read(c.salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	// Derive the content key now so the first upload does not pay for it
+	if _, err := c.contentKey(params, c.salt); err != nil {
+		return nil, err
+	}
+
+	key, err := deriveKey(c.passphrase, []byte(nameSalt), params, 2*keySize)
+	if err != nil {
+		return nil, err
+	}
+	if c.names, err = newAEAD(key[:keySize]); err != nil {
+		return nil, err
+	}
+	c.nameMAC = key[keySize:]
+	return c, nil
+}
+
+// contentKey returns the content cipher for a salt and parameters, deriving
+// it on first use
+func (c *Cipher) contentKey(params Params, salt []byte) (cipher.AEAD, error) {
+	id := string([]byte{params.LogN, params.R, params.P}) + string(salt)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if aead, ok := c.keys[id]; ok {
+		return aead, nil
+	}
+
+	key, err := deriveKey(c.passphrase, salt, params, keySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	c.keys[id] = aead
+	return aead, nil
+}
+
+// deriveKey runs scrypt over the passphrase
+func deriveKey(passphrase, salt []byte, params Params, length int) ([]byte, error) {
+	if params.LogN == 0 || params.LogN > 30 || params.R == 0 || params.P == 0 {
+		return nil, fmt.Errorf("invalid scrypt parameters N=2^%d r=%d p=%d", params.LogN, params.R, params.P)
+	}
+	key, err := scrypt.Key(passphrase, salt, 1<<params.LogN, int(params.R), int(params.P), length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+// newAEAD returns AES-GCM keyed with key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of chunk index of a file. The index is mixed
+// into the last eight bytes of the file nonce.
+func chunkNonce(dst, nonce []byte, index int64) []byte {
+	dst = append(dst[:0], nonce...)
+	counter := binary.BigEndian.Uint64(dst[nonceSize-8:]) ^ uint64(index)
+	binary.BigEndian.PutUint64(dst[nonceSize-8:], counter)
+	return dst
+}
+
+// chunkAAD marks whether a chunk is the last of its file
+func chunkAAD(last bool) []byte {
+	if last {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// chunkCount returns how many chunks a file of size plaintext bytes has
+func chunkCount(size int64) int64 {
+	if size <= 0 {
+		return 1
+	}
+	return (size + ChunkSize - 1) / ChunkSize
+}
+
+// EncryptedSize returns the size of a file of size plaintext bytes once
+// encrypted
+func EncryptedSize(size int64) int64 {
+	return int64(HeaderSize) + size + chunkCount(size)*tagSize
+}
+
+// PlaintextSize returns the size of the plaintext of an encrypted file of
+// size bytes. It reports false if no plaintext encrypts to that size.
+func PlaintextSize(size int64) (int64, bool) {
+	body := size - int64(HeaderSize)
+	if body < tagSize {
+		return 0, false
+	}
+	full, rest := body/sealedChunkSize, body%sealedChunkSize
+	switch {
+	case rest == 0:
+		return full * ChunkSize, true
+	case rest < tagSize, rest == tagSize && full > 0:
+		return 0, false
+	}
+	return full*ChunkSize + rest - tagSize, true
+}
+
+// IsEncrypted reports whether content starting with prefix carries the
+// encryption header. prefix needs at least as many bytes as the magic.
+func IsEncrypted(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(magic))
+}
+
+// EncryptName encrypts a file name. The same name always encrypts to the same
+// result, so encrypted names can be looked up and compared.
+func (c *Cipher) EncryptName(name string) string {
+	mac := hmac.New(sha256.New, c.nameMAC)
+	mac.Write([]byte(name))
+	nonce := mac.Sum(nil)[:nonceSize]
+
+	sealed := c.names.Seal(append([]byte(nil), nonce...), nonce, []byte(name), nil)
+	return base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// DecryptName decrypts a name made by EncryptName
+func (c *Cipher) DecryptName(name string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(name)
+	if err != nil || len(sealed) < nonceSize+tagSize {
+		return "", ErrNotEncrypted
+	}
+	plain, err := c.names.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrWrongPassphrase
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testParams keep key derivation cheap in tests
+var testParams = Params{LogN: 10, R: 8, P: 1}
+
+func newTestCipher(t *testing.T, passphrase string) *Cipher {
+	t.Helper()
+	c, err := NewWithParams(passphrase, testParams)
+	require.NoError(t, err)
+	return c
+}
+
+// encrypt returns the ciphertext of plain read through a Sealer
+func encrypt(t *testing.T, c *Cipher, plain []byte) []byte {
+	t.Helper()
+	sealer, err := c.NewSealer()
+	require.NoError(t, err)
+	reader := sealer.Reader(bytes.NewReader(plain), int64(len(plain)))
+
+	sealed, err := io.ReadAll(io.NewSectionReader(reader, 0, reader.Size()))
+	require.NoError(t, err)
+	return sealed
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := newTestCipher(t, "correct horse")
+
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3*ChunkSize + 17} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		sealed := encrypt(t, c, plain)
+		assert.Equal(t, EncryptedSize(int64(size)), int64(len(sealed)), "size %d", size)
+		restored, ok := PlaintextSize(int64(len(sealed)))
+		assert.True(t, ok)
+		assert.Equal(t, int64(size), restored)
+		if size > ChunkSize {
+			assert.NotContains(t, string(sealed), string(plain[:64]))
+		}
+
+		decrypted, err := io.ReadAll(c.NewReader(bytes.NewReader(sealed)))
+		require.NoError(t, err, "size %d", size)
+		assert.Equal(t, plain, decrypted, "size %d", size)
+	}
+}
+
+func TestReaderAtMatchesSequentialRead(t *testing.T) {
+	c := newTestCipher(t, "correct horse")
+	plain := make([]byte, 2*ChunkSize+100)
+	rand.Read(plain)
+
+	sealer, err := c.NewSealer()
+	require.NoError(t, err)
+	whole, err := io.ReadAll(io.NewSectionReader(sealer.Reader(bytes.NewReader(plain), int64(len(plain))), 0, EncryptedSize(int64(len(plain)))))
+	require.NoError(t, err)
+
+	// A second reader of the same sealer reads the same bytes at any offset,
+	// which is what lets chunks of one upload be read independently
+	reader := sealer.Reader(bytes.NewReader(plain), int64(len(plain)))
+	for _, off := range []int64{0, 10, int64(HeaderSize), ChunkSize + 5, int64(len(whole)) - 3} {
+		part := make([]byte, 40)
+		n, err := reader.ReadAt(part, off)
+		if off+40 > int64(len(whole)) {
+			assert.Equal(t, io.EOF, err)
+		} else {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, whole[off:off+int64(n)], part[:n], "offset %d", off)
+	}
+
+	// Each file gets its own nonce
+	other := encrypt(t, c, plain)
+	assert.NotEqual(t, whole[HeaderSize:], other[HeaderSize:])
+}
+
+func TestWrongPassphraseFails(t *testing.T) {
+	sealed := encrypt(t, newTestCipher(t, "correct horse"), []byte("secret plans"))
+
+	_, err := io.ReadAll(newTestCipher(t, "battery staple").NewReader(bytes.NewReader(sealed)))
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+}
+
+func TestTamperedContentFails(t *testing.T) {
+	c := newTestCipher(t, "correct horse")
+	plain := make([]byte, 2*ChunkSize)
+	sealed := encrypt(t, c, plain)
+
+	for _, cut := range []int{sealedChunkSize, 1} {
+		_, err := io.ReadAll(c.NewReader(bytes.NewReader(sealed[:len(sealed)-cut])))
+		assert.ErrorIs(t, err, ErrCorrupted, "truncation by %d bytes is detected", cut)
+	}
+
+	flipped := append([]byte(nil), sealed...)
+	flipped[len(flipped)-1] ^= 1
+	_, err := io.ReadAll(c.NewReader(bytes.NewReader(flipped)))
+	assert.ErrorIs(t, err, ErrCorrupted)
+
+	_, err = io.ReadAll(c.NewReader(bytes.NewReader([]byte("plain text"))))
+	assert.ErrorIs(t, err, ErrNotEncrypted)
+}
+
+func TestNameEncryption(t *testing.T) {
+	c := newTestCipher(t, "correct horse")
+
+	sealed := c.EncryptName("report.pdf")
+	assert.NotContains(t, sealed, "report")
+	assert.Equal(t, sealed, c.EncryptName("report.pdf"), "names encrypt deterministically")
+	assert.Equal(t, sealed, newTestCipher(t, "correct horse").EncryptName("report.pdf"), "names do not depend on the content salt")
+	assert.NotEqual(t, sealed, c.EncryptName("report.pdf2"))
+
+	name, err := c.DecryptName(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "report.pdf", name)
+
+	_, err = newTestCipher(t, "battery staple").DecryptName(sealed)
+	assert.ErrorIs(t, err, ErrWrongPassphrase)
+	_, err = c.DecryptName("report.pdf")
+	assert.Error(t, err)
+}
+
+func TestNoPassphrase(t *testing.T) {
+	_, err := New("")
+	assert.ErrorIs(t, err, ErrNoPassphrase)
+}
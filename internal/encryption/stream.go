@@ -0,0 +1,244 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Sealer encrypts the content of one file under a fresh nonce. Every reader
+// it opens produces the same ciphertext, so the parts of an upload can be
+// read independently.
+type Sealer struct {
+	aead   cipher.AEAD
+	header []byte
+	nonce  []byte
+}
+
+// NewSealer prepares the encryption of one file
+func (c *Cipher) NewSealer() (*Sealer, error) {
+	aead, err := c.contentKey(c.params, c.salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, HeaderSize)
+	header = append(header, magic...)
+	header = append(header, c.params.LogN, c.params.R, c.params.P)
+	header = append(header, c.salt...)
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	header = append(header, nonce...)
+
+	return &Sealer{aead: aead, header: header, nonce: nonce}, nil
+}
+
+// Reader returns the ciphertext of the size plaintext bytes in src
+func (s *Sealer) Reader(src io.ReaderAt, size int64) *Reader {
+	return &Reader{sealer: s, src: src, size: size, chunk: -1}
+}
+
+// Reader reads the ciphertext of a file at any offset, encrypting the chunks
+// it covers as they are reached. It is safe for concurrent use.
+type Reader struct {
+	sealer *Sealer
+	src    io.ReaderAt
+	size   int64
+
+	mu     sync.Mutex
+	chunk  int64
+	sealed []byte
+	plain  []byte
+	nonce  []byte
+}
+
+// Size returns the length of the ciphertext
+func (r *Reader) Size() int64 {
+	return EncryptedSize(r.size)
+}
+
+// ReadAt implements io.ReaderAt over the ciphertext
+func (r *Reader) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := r.Size()
+	n := 0
+	for n < len(p) && off < total {
+		var part []byte
+		if off < int64(HeaderSize) {
+			part = r.sealer.header[off:]
+		} else {
+			body := off - int64(HeaderSize)
+			index := body / sealedChunkSize
+			if err := r.seal(index); err != nil {
+				return n, err
+			}
+			part = r.sealed[body-index*sealedChunkSize:]
+		}
+		copied := copy(p[n:], part)
+		n += copied
+		off += int64(copied)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// seal encrypts chunk index into r.sealed unless it is already there
+func (r *Reader) seal(index int64) error {
+	if r.chunk == index {
+		return nil
+	}
+
+	offset := index * ChunkSize
+	length := r.size - offset
+	if length > ChunkSize {
+		length = ChunkSize
+	}
+	if cap(r.plain) < ChunkSize {
+		r.plain = make([]byte, ChunkSize)
+	}
+	plain := r.plain[:length]
+	if n, err := r.src.ReadAt(plain, offset); n < len(plain) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("failed to read chunk %d: %w", index, err)
+	}
+
+	last := index == chunkCount(r.size)-1
+	r.nonce = chunkNonce(r.nonce, r.sealer.nonce, index)
+	r.sealed = r.sealer.aead.Seal(r.sealed[:0], r.nonce, plain, chunkAAD(last))
+	r.chunk = index
+	return nil
+}
+
+// NewReader returns a reader of the plaintext of the encrypted content read
+// from src. Reads fail with ErrNotEncrypted if src lacks the header, with
+// ErrWrongPassphrase if the first chunk does not decrypt and with
+// ErrCorrupted if a later one does not or the content was cut short.
+func (c *Cipher) NewReader(src io.Reader) io.Reader {
+	return &decryptReader{cipher: c, src: src}
+}
+
+// decryptReader decrypts a stream chunk by chunk. It reads one byte past
+// each chunk to learn whether the chunk is the last.
+type decryptReader struct {
+	cipher *Cipher
+	src    io.Reader
+	aead   cipher.AEAD
+	nonce  []byte
+
+	buf   []byte
+	held  int
+	plain []byte
+	out   []byte
+	index int64
+	done  bool
+	err   error
+}
+
+// Read implements io.Reader
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			return 0, io.EOF
+		}
+		d.err = d.next()
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// readHeader reads the header and picks the key it names
+func (d *decryptReader) readHeader() error {
+	header := make([]byte, HeaderSize)
+	if n, err := io.ReadFull(d.src, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			if IsEncrypted(header[:n]) {
+				return ErrCorrupted
+			}
+			return ErrNotEncrypted
+		}
+		return err
+	}
+	if !IsEncrypted(header) {
+		return ErrNotEncrypted
+	}
+
+	fields := header[len(magic):]
+	params := Params{LogN: fields[0], R: fields[1], P: fields[2]}
+	salt := fields[3 : 3+saltSize]
+	aead, err := d.cipher.contentKey(params, salt)
+	if err != nil {
+		return err
+	}
+
+	d.aead = aead
+	d.nonce = append([]byte(nil), fields[3+saltSize:]...)
+	d.buf = make([]byte, sealedChunkSize+1)
+	return nil
+}
+
+// next decrypts the next chunk into d.out
+func (d *decryptReader) next() error {
+	if d.aead == nil {
+		if err := d.readHeader(); err != nil {
+			return err
+		}
+	}
+
+	n, err := io.ReadFull(d.src, d.buf[d.held:])
+	n += d.held
+	last := false
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		last = true
+	default:
+		return err
+	}
+
+	sealed := n
+	if !last {
+		sealed = n - 1
+	}
+	if sealed < tagSize {
+		return ErrCorrupted
+	}
+
+	nonce := chunkNonce(nil, d.nonce, d.index)
+	plain, err := d.aead.Open(d.plain[:0], nonce, d.buf[:sealed], chunkAAD(last))
+	if err != nil {
+		// A first chunk that opens with the other end marker was sealed with
+		// this key, so the content was cut short or extended rather than
+		// encrypted under another passphrase
+		if d.index == 0 {
+			if _, retry := d.aead.Open(nil, nonce, d.buf[:sealed], chunkAAD(!last)); retry != nil {
+				return ErrWrongPassphrase
+			}
+		}
+		return ErrCorrupted
+	}
+
+	d.plain, d.out = plain, plain
+	d.index++
+	if last {
+		d.done = true
+	} else {
+		d.buf[0] = d.buf[n-1]
+		d.held = 1
+	}
+	return nil
+}
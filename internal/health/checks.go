@@ -0,0 +1,84 @@
+// Package health implements the daemon's health and readiness checks
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/auth"
+	"github.com/bdstest/zohosync/internal/storage"
+)
+
+// Check is a named probe of one subsystem. Run returns nil when healthy.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Report describes the outcome of a set of checks
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// DatabaseCheck verifies the local database is open
+func DatabaseCheck(database *storage.Database) Check {
+	return Check{
+		Name: "database",
+		Run:  database.Ping,
+	}
+}
+
+// AuthCheck verifies a non-expired auth token is stored
+func AuthCheck(tokens auth.TokenStore) Check {
+	return Check{
+		Name: "auth",
+		Run: func(ctx context.Context) error {
+			token, err := tokens.GetAuthToken()
+			if err != nil {
+				return err
+			}
+			if token == nil || token.AccessToken == "" {
+				return errors.New("not authenticated")
+			}
+			if !token.ExpiresAt.IsZero() && time.Now().After(token.ExpiresAt) {
+				return fmt.Errorf("token expired at %s", token.ExpiresAt.Format(time.RFC3339))
+			}
+			return nil
+		},
+	}
+}
+
+// APICheck verifies the WorkDrive API is reachable with the current token
+func APICheck(client *api.Client) Check {
+	return Check{
+		Name: "api",
+		Run: func(ctx context.Context) error {
+			_, err := client.GetUserInfo(ctx)
+			return err
+		},
+	}
+}
+
+// RunChecks runs every check and reports whether all of them passed
+func RunChecks(ctx context.Context, checks []Check) (*Report, bool) {
+	report := &Report{Status: "ok", Checks: make(map[string]string, len(checks))}
+	healthy := true
+
+	for _, check := range checks {
+		if err := check.Run(ctx); err != nil {
+			report.Checks[check.Name] = err.Error()
+			healthy = false
+			continue
+		}
+		report.Checks[check.Name] = "ok"
+	}
+
+	if !healthy {
+		report.Status = "unavailable"
+	}
+	return report, healthy
+}
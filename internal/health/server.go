@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/utils"
+)
+
+// CheckTimeout bounds how long a readiness probe waits for its checks
+const CheckTimeout = 5 * time.Second
+
+// Server exposes /healthz and /readyz over HTTP
+type Server struct {
+	addr     string
+	checks   []Check
+	server   *http.Server
+	listener net.Listener
+	logger   *utils.Logger
+}
+
+// NewServer creates a health server for addr that reports ready when all
+// checks pass
+func NewServer(addr string, checks ...Check) *Server {
+	s := &Server{
+		addr:   addr,
+		checks: checks,
+		logger: utils.GetLogger(),
+	}
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler serving both endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/readyz", s.handleReady)
+	return mux
+}
+
+// Start begins serving until ctx is done or Close is called
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	s.listener = listener
+
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorf("Health server error: %v", err)
+		}
+	}()
+
+	s.logger.Infof("Health endpoints listening on http://%s", listener.Addr())
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// handleHealth reports that the process is alive
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, &Report{Status: "ok"})
+}
+
+// handleReady runs the readiness checks
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), CheckTimeout)
+	defer cancel()
+
+	report, healthy := RunChecks(ctx, s.checks)
+	status := http.StatusOK
+	if !healthy {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+// writeReport encodes a report as the JSON response body
+func writeReport(w http.ResponseWriter, status int, report *Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// get requests path from the health handler and decodes the report
+func get(t *testing.T, server *httptest.Server, path string) (int, Report) {
+	t.Helper()
+
+	resp, err := http.Get(server.URL + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var report Report
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	return resp.StatusCode, report
+}
+
+func TestHealthAndReadinessEndpoints(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	var apiDown atomic.Bool
+	workdrive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if apiDown.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"data":{"email":"user@example.com"}}`))
+	}))
+	defer workdrive.Close()
+
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	token := &types.TokenInfo{AccessToken: "test_token", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, database.SaveAuthToken(token))
+
+	client := api.NewClient(token)
+	client.SetBaseURL(workdrive.URL)
+
+	health := NewServer("127.0.0.1:0", DatabaseCheck(database), AuthCheck(database), APICheck(client))
+	server := httptest.NewServer(health.Handler())
+	defer server.Close()
+
+	status, report := get(t, server, "/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", report.Status)
+	assert.Equal(t, map[string]string{"database": "ok", "auth": "ok", "api": "ok"}, report.Checks)
+
+	apiDown.Store(true)
+	status, report = get(t, server, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "unavailable", report.Status)
+	assert.Equal(t, "ok", report.Checks["database"])
+	assert.NotEqual(t, "ok", report.Checks["api"])
+
+	// Liveness does not depend on the subsystems
+	status, report = get(t, server, "/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", report.Status)
+}
+
+func TestReadinessFailsWithoutToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	defer database.Close()
+
+	health := NewServer("127.0.0.1:0", DatabaseCheck(database), AuthCheck(database))
+	server := httptest.NewServer(health.Handler())
+	defer server.Close()
+
+	status, report := get(t, server, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "not authenticated", report.Checks["auth"])
+
+	database.Close()
+	_, report = get(t, server, "/readyz")
+	assert.NotEqual(t, "ok", report.Checks["database"])
+}
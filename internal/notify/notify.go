@@ -0,0 +1,135 @@
+// Package notify delivers alerts about sync problems to the user through
+// pluggable backends such as webhooks, email and desktop notifications
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultRateLimit is the minimum time between two notifications of the
+// same kind
+const DefaultRateLimit = 15 * time.Minute
+
+// Kind identifies what an event is about
+type Kind string
+
+const (
+	// KindSyncFailure means a file failed to sync repeatedly
+	KindSyncFailure Kind = "sync_failure"
+	// KindQuotaWarning means the server rejected work for quota or rate limits
+	KindQuotaWarning Kind = "quota_warning"
+	// KindReauthRequired means the user must log in again
+	KindReauthRequired Kind = "reauth_required"
+	// KindDeleteConfirmation means remote deletions are held back until the
+	// user confirms them
+	KindDeleteConfirmation Kind = "delete_confirmation"
+)
+
+// Event is a single notification
+type Event struct {
+	Kind    Kind      `json:"kind"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Path    string    `json:"path,omitempty"`
+	Time    time.Time `json:"time"`
+	// Suppressed counts earlier events of this kind held back by rate limiting
+	Suppressed int `json:"suppressed,omitempty"`
+}
+
+// Notifier delivers events to the user
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Multi fans an event out to several notifiers
+type Multi []Notifier
+
+// Notify delivers the event to every notifier and joins their errors
+func (m Multi) Notify(ctx context.Context, event Event) error {
+	var errs []error
+	for _, n := range m {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RateLimited passes on at most one event of each kind per interval, so a
+// single outage does not flood the user
+type RateLimited struct {
+	next       Notifier
+	interval   time.Duration
+	mu         sync.Mutex
+	last       map[Kind]time.Time
+	suppressed map[Kind]int
+}
+
+// NewRateLimited wraps next with a per-kind rate limit
+func NewRateLimited(next Notifier, interval time.Duration) *RateLimited {
+	return &RateLimited{
+		next:       next,
+		interval:   interval,
+		last:       make(map[Kind]time.Time),
+		suppressed: make(map[Kind]int),
+	}
+}
+
+// Notify forwards the event unless one of the same kind was sent recently
+func (r *RateLimited) Notify(ctx context.Context, event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	r.mu.Lock()
+	if last, ok := r.last[event.Kind]; ok && event.Time.Sub(last) < r.interval {
+		r.suppressed[event.Kind]++
+		r.mu.Unlock()
+		return nil
+	}
+	r.last[event.Kind] = event.Time
+	event.Suppressed = r.suppressed[event.Kind]
+	r.suppressed[event.Kind] = 0
+	r.mu.Unlock()
+
+	return r.next.Notify(ctx, event)
+}
+
+// FromConfig builds the rate-limited notifier for the configured backends,
+// or returns nil if none are configured
+func FromConfig(cfg types.NotificationsConfig) Notifier {
+	var backends Multi
+	if cfg.WebhookURL != "" {
+		backends = append(backends, NewWebhookNotifier(cfg.WebhookURL))
+	}
+	if cfg.SMTP.Host != "" && len(cfg.SMTP.To) > 0 {
+		backends = append(backends, NewSMTPNotifier(cfg.SMTP))
+	}
+	if len(backends) == 0 {
+		return nil
+	}
+
+	interval := time.Duration(cfg.RateLimit) * time.Second
+	if interval <= 0 {
+		interval = DefaultRateLimit
+	}
+	return NewRateLimited(backends, interval)
+}
+
+// summary renders an event as a one-line subject and a body
+func summary(event Event) (string, string) {
+	body := event.Message
+	if event.Path != "" {
+		body += "\n\nFile: " + event.Path
+	}
+	if event.Suppressed > 0 {
+		body += fmt.Sprintf("\n\n%d similar notification(s) were suppressed.", event.Suppressed)
+	}
+	return "ZohoSync: " + event.Title, body
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+func TestWebhookPayload(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var payload map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received <- payload
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), Event{
+		Kind:    KindSyncFailure,
+		Title:   "Sync failing",
+		Message: "Failed to sync 3 times in a row",
+		Path:    "/home/user/doc.txt",
+		Time:    at,
+	})
+	require.NoError(t, err)
+
+	payload := <-received
+	assert.Equal(t, map[string]interface{}{
+		"kind":    "sync_failure",
+		"title":   "Sync failing",
+		"message": "Failed to sync 3 times in a row",
+		"path":    "/home/user/doc.txt",
+		"time":    "2024-01-02T03:04:05Z",
+	}, payload)
+}
+
+func TestWebhookErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), Event{Kind: KindQuotaWarning})
+	assert.Error(t, err)
+}
+
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Notify(ctx context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestRateLimited(t *testing.T) {
+	rec := &recorder{}
+	limited := NewRateLimited(rec, time.Minute)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, limited.Notify(context.Background(), Event{Kind: KindSyncFailure, Time: start.Add(time.Duration(i) * time.Second)}))
+	}
+	// Other kinds are limited separately
+	require.NoError(t, limited.Notify(context.Background(), Event{Kind: KindReauthRequired, Time: start}))
+	require.NoError(t, limited.Notify(context.Background(), Event{Kind: KindSyncFailure, Time: start.Add(2 * time.Minute)}))
+
+	require.Len(t, rec.events, 3)
+	assert.Equal(t, KindSyncFailure, rec.events[0].Kind)
+	assert.Equal(t, KindReauthRequired, rec.events[1].Kind)
+	assert.Equal(t, 2, rec.events[2].Suppressed)
+}
+
+func TestFromConfig(t *testing.T) {
+	assert.Nil(t, FromConfig(types.NotificationsConfig{}))
+	assert.NotNil(t, FromConfig(types.NotificationsConfig{WebhookURL: "http://localhost/hook"}))
+	assert.NotNil(t, FromConfig(types.NotificationsConfig{SMTP: types.SMTPConfig{Host: "mail", To: []string{"me@example.com"}}}))
+}
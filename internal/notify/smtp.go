@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SMTPNotifier emails each event
+type SMTPNotifier struct {
+	config types.SMTPConfig
+}
+
+// NewSMTPNotifier creates a notifier that sends mail through the given server
+func NewSMTPNotifier(config types.SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{config: config}
+}
+
+// Notify emails the event to the configured recipients
+func (s *SMTPNotifier) Notify(ctx context.Context, event Event) error {
+	port := s.config.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	from := s.config.From
+	if from == "" {
+		from = s.config.Username
+	}
+
+	subject, body := summary(event)
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n",
+		from, strings.Join(s.config.To, ", "), subject, strings.ReplaceAll(body, "\n", "\r\n"))
+
+	// net/smtp has no context support, so only honour cancellation up front
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := smtp.SendMail(addr, auth, from, s.config.To, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send notification email: %w", err)
+	}
+	return nil
+}
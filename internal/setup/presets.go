@@ -0,0 +1,118 @@
+package setup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Preset is a named set of folder settings suited to one kind of content.
+// Applying it copies the settings into the folder's configuration, where
+// they can be changed like any other.
+type Preset struct {
+	Name        string
+	Description string
+	// Interval is the folder's sync interval in seconds; zero keeps the
+	// global interval
+	Interval int
+	// IncludeExtensions limits the folder to these file types
+	IncludeExtensions []string
+	// ChunkSize is the folder's upload chunk size in MiB; zero keeps
+	// sync.chunk_size
+	ChunkSize int
+	// Ignore seeds the folder's .syncignore
+	Ignore []string
+}
+
+// presets are the available presets, in display order
+var presets = []Preset{
+	{
+		Name:        "documents",
+		Description: "Office documents, PDFs and text, synced every minute",
+		Interval:    60,
+		IncludeExtensions: []string{
+			"doc", "docx", "odt", "rtf", "txt", "md",
+			"xls", "xlsx", "ods", "csv",
+			"ppt", "pptx", "odp", "pdf",
+		},
+	},
+	{
+		Name:        "code",
+		Description: "Source trees, leaving out version control and build output",
+		Ignore: []string{
+			".git", ".hg", ".svn",
+			"node_modules", "vendor", "target", "build", "dist", "bin", "obj",
+			"__pycache__", "*.pyc", ".venv", "*.o", "*.class",
+		},
+	},
+	{
+		Name:        "photos",
+		Description: "Photos and videos, uploaded in large chunks",
+		ChunkSize:   64,
+		IncludeExtensions: []string{
+			"jpg", "jpeg", "png", "gif", "heic", "heif", "webp", "tif", "tiff",
+			"raw", "cr2", "cr3", "nef", "arw", "dng",
+			"mp4", "mov", "m4v", "avi", "mkv",
+		},
+	},
+}
+
+// Presets returns the available presets
+func Presets() []Preset {
+	return append([]Preset(nil), presets...)
+}
+
+// FindPreset returns the preset called name
+func FindPreset(name string) (*Preset, error) {
+	names := make([]string, len(presets))
+	for i := range presets {
+		if strings.EqualFold(presets[i].Name, name) {
+			preset := presets[i]
+			return &preset, nil
+		}
+		names[i] = presets[i].Name
+	}
+	return nil, fmt.Errorf("unknown preset %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// Apply copies the preset's settings into folder
+func (p *Preset) Apply(folder *types.FolderConfig) {
+	folder.Preset = p.Name
+	if p.Interval > 0 {
+		folder.Interval = p.Interval
+	}
+	if len(p.IncludeExtensions) > 0 {
+		folder.IncludeExtensions = append([]string(nil), p.IncludeExtensions...)
+	}
+	if p.ChunkSize > 0 {
+		folder.ChunkSize = p.ChunkSize
+	}
+}
+
+// SeedIgnoreFile writes the preset's ignore patterns to the .syncignore of
+// the local folder, unless the preset has none or the folder already has
+// one of its own
+func (p *Preset) SeedIgnoreFile(local string) error {
+	if len(p.Ignore) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(local, sync.SyncIgnoreFile)
+	content := fmt.Sprintf("# Paths ZohoSync leaves out, seeded by the %s preset\n%s\n", p.Name, strings.Join(p.Ignore, "\n"))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,96 @@
+package setup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetsConfigureFolders(t *testing.T) {
+	documents, err := FindPreset("documents")
+	require.NoError(t, err)
+	folder := types.FolderConfig{Local: "/docs", Interval: 600}
+	documents.Apply(&folder)
+	assert.Equal(t, "documents", folder.Preset)
+	assert.Equal(t, 60, folder.Interval)
+	assert.Contains(t, folder.IncludeExtensions, "docx")
+	assert.Contains(t, folder.IncludeExtensions, "pdf")
+	assert.Zero(t, folder.ChunkSize)
+
+	code, err := FindPreset("Code")
+	require.NoError(t, err)
+	folder = types.FolderConfig{Local: "/src", Interval: 600}
+	code.Apply(&folder)
+	assert.Equal(t, "code", folder.Preset)
+	assert.Equal(t, 600, folder.Interval, "the folder keeps its interval")
+	assert.Empty(t, folder.IncludeExtensions)
+	assert.Contains(t, code.Ignore, ".git")
+	assert.Contains(t, code.Ignore, "node_modules")
+
+	photos, err := FindPreset("photos")
+	require.NoError(t, err)
+	folder = types.FolderConfig{Local: "/pictures"}
+	photos.Apply(&folder)
+	assert.Equal(t, 64, folder.ChunkSize)
+	assert.Contains(t, folder.IncludeExtensions, "jpg")
+	assert.Contains(t, folder.IncludeExtensions, "mov")
+	assert.Zero(t, folder.Interval)
+
+	// Changing the folder afterwards leaves the preset alone
+	folder.IncludeExtensions[0] = "bmp"
+	assert.Equal(t, "jpg", photos.IncludeExtensions[0])
+
+	_, err = FindPreset("music")
+	assert.ErrorContains(t, err, "documents, code, photos")
+}
+
+func TestSeedIgnoreFileKeepsExistingOne(t *testing.T) {
+	code, err := FindPreset("code")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, code.SeedIgnoreFile(dir))
+	data, err := os.ReadFile(filepath.Join(dir, sync.SyncIgnoreFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\nnode_modules\n")
+
+	custom := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(custom, sync.SyncIgnoreFile), []byte("*.log\n"), 0644))
+	require.NoError(t, code.SeedIgnoreFile(custom))
+	data, err = os.ReadFile(filepath.Join(custom, sync.SyncIgnoreFile))
+	require.NoError(t, err)
+	assert.Equal(t, "*.log\n", string(data))
+
+	documents, err := FindPreset("documents")
+	require.NoError(t, err)
+	none := t.TempDir()
+	require.NoError(t, documents.SeedIgnoreFile(none))
+	assert.NoFileExists(t, filepath.Join(none, sync.SyncIgnoreFile))
+}
+
+func TestRunWizardAppliesPreset(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	preset, err := FindPreset("code")
+	require.NoError(t, err)
+
+	cfg := &types.Config{}
+	folder, err := RunWizard(context.Background(), Deps{
+		Prompter:   &scriptedPrompter{t: t, inputs: []string{"~/Code"}, choices: []int{0, 0}},
+		Remote:     testRemote(),
+		Config:     cfg,
+		SaveConfig: func(*types.Config) error { return nil },
+		Preset:     preset,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "code", folder.Preset)
+	assert.Equal(t, "code", cfg.Folders[0].Preset)
+	assert.FileExists(t, filepath.Join(home, "Code", sync.SyncIgnoreFile))
+}
@@ -0,0 +1,235 @@
+// Package setup implements the first-run wizard shared by the CLI and GUI
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// ErrCancelled is returned by a Prompter when the user abandons the wizard
+var ErrCancelled = errors.New("setup cancelled")
+
+// Prompter asks the user questions. The CLI implements it on the terminal
+// and the GUI with dialogs.
+type Prompter interface {
+	// Info shows a message without expecting an answer
+	Info(message string)
+	// Input asks for free text, returning defaultValue for an empty answer
+	Input(question, defaultValue string) (string, error)
+	// Select asks the user to pick one of options and returns its index
+	Select(question string, options []string) (int, error)
+}
+
+// RemoteBrowser lists WorkDrive folders; *api.Client satisfies it
+type RemoteBrowser interface {
+	GetRootFolder(ctx context.Context) (*api.FileInfo, error)
+	ListFiles(ctx context.Context, folderID string, limit int) ([]api.FileInfo, error)
+}
+
+// Deps holds everything the wizard needs from its caller
+type Deps struct {
+	Prompter Prompter
+	Remote   RemoteBrowser
+	Config   *types.Config
+
+	// SaveConfig persists the updated configuration
+	SaveConfig func(*types.Config) error
+	// Scan performs the initial scan of the new folder; optional
+	Scan func(ctx context.Context, folder types.FolderConfig) error
+	// Preset is applied to the new folder; optional
+	Preset *Preset
+}
+
+// syncModes are the strategies offered to the user, in display order
+var syncModes = []struct {
+	strategy    sync.SyncStrategy
+	description string
+}{
+	{sync.StrategyBidirectional, "Two-way sync (changes flow both ways)"},
+	{sync.StrategyDownloadOnly, "Mirror from WorkDrive (never changes WorkDrive)"},
+	{sync.StrategyUploadOnly, "Back up to WorkDrive (never changes local files)"},
+}
+
+// maxListedFolders bounds how many remote subfolders are fetched per level
+const maxListedFolders = 500
+
+// RunWizard walks an authenticated user through choosing a local folder, a
+// remote folder and a sync mode, appends the result to the configuration,
+// saves it and runs the initial scan. Running it again adds another folder.
+func RunWizard(ctx context.Context, deps Deps) (*types.FolderConfig, error) {
+	p := deps.Prompter
+
+	if len(deps.Config.Folders) == 0 {
+		p.Info("Welcome to ZohoSync! Let's set up your first sync folder.")
+	} else {
+		p.Info(fmt.Sprintf("You already sync %d folder(s). Let's add another one.", len(deps.Config.Folders)))
+	}
+
+	local, err := chooseLocalFolder(p, deps.Config.Folders)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := chooseRemoteFolder(ctx, p, deps.Remote)
+	if err != nil {
+		return nil, err
+	}
+
+	options := make([]string, len(syncModes))
+	for i, mode := range syncModes {
+		options[i] = mode.description
+	}
+	choice, err := p.Select("How should this folder sync?", options)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := types.FolderConfig{
+		Local:    local,
+		Remote:   remote,
+		SyncMode: string(syncModes[choice].strategy),
+		Enabled:  true,
+	}
+	if deps.Preset != nil {
+		deps.Preset.Apply(&folder)
+	}
+
+	deps.Config.Folders = append(deps.Config.Folders, folder)
+	if err := deps.SaveConfig(deps.Config); err != nil {
+		deps.Config.Folders = deps.Config.Folders[:len(deps.Config.Folders)-1]
+		return nil, fmt.Errorf("failed to save configuration: %w", err)
+	}
+
+	if deps.Preset != nil {
+		if err := deps.Preset.SeedIgnoreFile(local); err != nil {
+			return &folder, fmt.Errorf("folder saved but its preset could not be applied: %w", err)
+		}
+		p.Info(fmt.Sprintf("Applied the %s preset; change its settings in the folder's configuration.", deps.Preset.Name))
+	}
+
+	if deps.Scan != nil {
+		p.Info("Scanning " + local + "...")
+		if err := deps.Scan(ctx, folder); err != nil {
+			return &folder, fmt.Errorf("folder saved but initial scan failed: %w", err)
+		}
+	}
+
+	p.Info(fmt.Sprintf("%s is now synced with %s (%s).", local, remote, folder.SyncMode))
+	return &folder, nil
+}
+
+// chooseLocalFolder asks for a local directory until the user gives one
+// that is not already covered by an existing sync folder
+func chooseLocalFolder(p Prompter, existing []types.FolderConfig) (string, error) {
+	defaultPath := filepath.Join(os.Getenv("HOME"), "ZohoSync")
+
+	for {
+		answer, err := p.Input("Local folder to sync", defaultPath)
+		if err != nil {
+			return "", err
+		}
+
+		local, err := config.ExpandPath(answer)
+		if err != nil {
+			p.Info(fmt.Sprintf("Invalid path: %v", err))
+			continue
+		}
+
+		if conflict := overlappingFolder(local, existing); conflict != "" {
+			p.Info(fmt.Sprintf("%s overlaps the existing sync folder %s; choose another.", local, conflict))
+			continue
+		}
+
+		if err := os.MkdirAll(local, 0755); err != nil {
+			p.Info(fmt.Sprintf("Cannot create %s: %v", local, err))
+			continue
+		}
+		return local, nil
+	}
+}
+
+// chooseRemoteFolder lets the user browse WorkDrive one level at a time and
+// returns the chosen folder's path
+func chooseRemoteFolder(ctx context.Context, p Prompter, remote RemoteBrowser) (string, error) {
+	root, err := remote.GetRootFolder(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to open WorkDrive: %w", err)
+	}
+
+	trail := []api.FileInfo{*root}
+	for {
+		current := trail[len(trail)-1]
+		currentPath := remotePath(trail)
+
+		children, err := remote.ListFiles(ctx, current.ID, maxListedFolders)
+		if err != nil {
+			return "", fmt.Errorf("failed to list %s: %w", currentPath, err)
+		}
+
+		var folders []api.FileInfo
+		for _, child := range children {
+			if child.IsFolder {
+				folders = append(folders, child)
+			}
+		}
+
+		options := []string{"Use " + currentPath}
+		if len(trail) > 1 {
+			options = append(options, ".. (up)")
+		}
+		offset := len(options)
+		for _, folder := range folders {
+			options = append(options, folder.Name+"/")
+		}
+
+		choice, err := p.Select("Choose the WorkDrive folder ("+currentPath+")", options)
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case choice == 0:
+			return currentPath, nil
+		case choice < offset:
+			trail = trail[:len(trail)-1]
+		default:
+			trail = append(trail, folders[choice-offset])
+		}
+	}
+}
+
+// remotePath renders the browse trail (excluding the root) as a path
+func remotePath(trail []api.FileInfo) string {
+	names := make([]string, 0, len(trail)-1)
+	for _, folder := range trail[1:] {
+		names = append(names, folder.Name)
+	}
+	return "/" + path.Join(names...)
+}
+
+// overlappingFolder returns the configured folder that contains or is
+// contained by local, if any
+func overlappingFolder(local string, existing []types.FolderConfig) string {
+	for _, folder := range existing {
+		other, err := config.ExpandPath(folder.Local)
+		if err != nil {
+			continue
+		}
+		if other == local ||
+			strings.HasPrefix(local, other+string(filepath.Separator)) ||
+			strings.HasPrefix(other, local+string(filepath.Separator)) {
+			return other
+		}
+	}
+	return ""
+}
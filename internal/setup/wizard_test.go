@@ -0,0 +1,145 @@
+package setup
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedPrompter answers prompts from a fixed script
+type scriptedPrompter struct {
+	t       *testing.T
+	inputs  []string
+	choices []int
+	infos   []string
+	menus   [][]string
+}
+
+func (s *scriptedPrompter) Info(message string) {
+	s.infos = append(s.infos, message)
+}
+
+func (s *scriptedPrompter) Input(question, defaultValue string) (string, error) {
+	if len(s.inputs) == 0 {
+		return "", ErrCancelled
+	}
+	answer := s.inputs[0]
+	s.inputs = s.inputs[1:]
+	return answer, nil
+}
+
+func (s *scriptedPrompter) Select(question string, options []string) (int, error) {
+	s.menus = append(s.menus, options)
+	if len(s.choices) == 0 {
+		return 0, ErrCancelled
+	}
+	choice := s.choices[0]
+	s.choices = s.choices[1:]
+	require.Less(s.t, choice, len(options), "scripted choice out of range for %q", question)
+	return choice, nil
+}
+
+// fakeRemote serves a small WorkDrive folder tree
+type fakeRemote map[string][]api.FileInfo
+
+func (f fakeRemote) GetRootFolder(ctx context.Context) (*api.FileInfo, error) {
+	return &api.FileInfo{ID: "root", Name: "My Folders", IsFolder: true}, nil
+}
+
+func (f fakeRemote) ListFiles(ctx context.Context, folderID string, limit int) ([]api.FileInfo, error) {
+	files, ok := f[folderID]
+	if !ok {
+		return nil, fmt.Errorf("unknown folder %s", folderID)
+	}
+	return files, nil
+}
+
+func testRemote() fakeRemote {
+	return fakeRemote{
+		"root": {
+			{ID: "projects", Name: "Projects", IsFolder: true},
+			{ID: "readme", Name: "README.txt"},
+			{ID: "archive", Name: "Archive", IsFolder: true},
+		},
+		"projects": {{ID: "shared", Name: "Shared", IsFolder: true}},
+		"shared":   {},
+		"archive":  {},
+	}
+}
+
+func TestRunWizardAddsFolder(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	existing := filepath.Join(home, "Existing")
+	cfg := &types.Config{Folders: []types.FolderConfig{{Local: existing, Remote: "/Archive", SyncMode: "bidirectional", Enabled: true}}}
+
+	prompter := &scriptedPrompter{
+		t: t,
+		// A folder inside an existing sync folder is rejected, then a new one is accepted
+		inputs: []string{filepath.Join(existing, "nested"), "~/Mirror"},
+		// Projects -> Shared -> up -> use /Projects, then download-only
+		choices: []int{1, 2, 1, 0, 1},
+	}
+
+	var saved, scanned []types.FolderConfig
+	folder, err := RunWizard(context.Background(), Deps{
+		Prompter: prompter,
+		Remote:   testRemote(),
+		Config:   cfg,
+		SaveConfig: func(c *types.Config) error {
+			saved = c.Folders
+			return nil
+		},
+		Scan: func(ctx context.Context, folder types.FolderConfig) error {
+			scanned = append(scanned, folder)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	expected := types.FolderConfig{
+		Local:    filepath.Join(home, "Mirror"),
+		Remote:   "/Projects",
+		SyncMode: "download_only",
+		Enabled:  true,
+	}
+	assert.Equal(t, expected, *folder)
+	assert.DirExists(t, expected.Local)
+
+	// Re-running appends rather than replacing existing folders
+	require.Len(t, cfg.Folders, 2)
+	assert.Equal(t, existing, cfg.Folders[0].Local)
+	assert.Equal(t, cfg.Folders, saved)
+	assert.Equal(t, []types.FolderConfig{expected}, scanned)
+
+	// Only folders are offered while browsing
+	assert.Equal(t, []string{"Use /", "Projects/", "Archive/"}, prompter.menus[0])
+	assert.Equal(t, []string{"Use /Projects", ".. (up)", "Shared/"}, prompter.menus[1])
+	assert.Contains(t, prompter.infos[1], "overlaps the existing sync folder")
+}
+
+func TestRunWizardCancelLeavesConfigUntouched(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := &types.Config{}
+
+	prompter := &scriptedPrompter{t: t, inputs: []string{"~/Docs"}, choices: []int{0}}
+	_, err := RunWizard(context.Background(), Deps{
+		Prompter: prompter,
+		Remote:   testRemote(),
+		Config:   cfg,
+		SaveConfig: func(c *types.Config) error {
+			t.Fatal("config must not be saved when the wizard is cancelled")
+			return nil
+		},
+	})
+
+	assert.ErrorIs(t, err, ErrCancelled)
+	assert.Empty(t, cfg.Folders)
+}
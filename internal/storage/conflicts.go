@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SaveConflict records a conflict. A file left unresolved by an earlier sync
+// keeps a single entry, which is updated until a side wins and remembers
+// when the conflict was first seen.
+func (d *Database) SaveConflict(conflict *types.ConflictInfo) error {
+	if conflict.DetectedAt.IsZero() {
+		conflict.DetectedAt = time.Now()
+	}
+
+	var (
+		id                    int64
+		firstSeen, detectedAt sql.NullTime
+	)
+	err := d.db.QueryRow(`
+	SELECT id, first_seen, detected_at FROM conflicts WHERE local_path = ? AND winner = ''
+	ORDER BY id DESC LIMIT 1
+	`, conflict.Path).Scan(&id, &firstSeen, &detectedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to look up conflict: %w", err)
+	}
+
+	if err == nil {
+		_, err = d.db.Exec(`
+		UPDATE conflicts SET strategy = ?, winner = ?, auto_resolved = ?, base_hash = ?,
+			local_hash = ?, remote_hash = ?, copy_path = ?, local_size = ?, remote_size = ?,
+			local_modified = ?, remote_modified = ?, detected_at = ?
+		WHERE id = ?
+		`, conflict.Strategy, conflict.Winner, conflict.AutoResolved, conflict.BaseHash,
+			conflict.LocalHash, conflict.RemoteHash, conflict.CopyPath, conflict.LocalSize, conflict.RemoteSize,
+			conflict.LocalModified, conflict.RemoteModified, conflict.DetectedAt, id)
+		if err != nil {
+			return fmt.Errorf("failed to save conflict: %w", err)
+		}
+		conflict.ID, conflict.FirstSeen = id, firstSeenTime(firstSeen, detectedAt)
+		return nil
+	}
+
+	conflict.FirstSeen = conflict.DetectedAt
+	result, err := d.db.Exec(`
+	INSERT INTO conflicts (local_path, strategy, winner, auto_resolved, base_hash, local_hash,
+		remote_hash, copy_path, local_size, remote_size, local_modified, remote_modified, detected_at,
+		first_seen)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, conflict.Path, conflict.Strategy, conflict.Winner, conflict.AutoResolved, conflict.BaseHash,
+		conflict.LocalHash, conflict.RemoteHash, conflict.CopyPath, conflict.LocalSize, conflict.RemoteSize,
+		conflict.LocalModified, conflict.RemoteModified, conflict.DetectedAt, conflict.FirstSeen)
+	if err != nil {
+		return fmt.Errorf("failed to save conflict: %w", err)
+	}
+	conflict.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetConflicts returns the most recent conflicts first, only resolved ones
+// if resolvedOnly is set. A limit of zero or less returns all of them.
+func (d *Database) GetConflicts(resolvedOnly bool, limit int) ([]types.ConflictInfo, error) {
+	query := `
+	SELECT id, local_path, strategy, winner, auto_resolved, base_hash, local_hash, remote_hash,
+		copy_path, local_size, remote_size, local_modified, remote_modified, detected_at,
+		first_seen
+	FROM conflicts`
+	if resolvedOnly {
+		query += " WHERE winner != ''"
+	}
+	query += " ORDER BY detected_at DESC, id DESC"
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	var conflicts []types.ConflictInfo
+	for rows.Next() {
+		var (
+			conflict       types.ConflictInfo
+			strategy       sql.NullString
+			winner         sql.NullString
+			baseHash       sql.NullString
+			localHash      sql.NullString
+			remoteHash     sql.NullString
+			copyPath       sql.NullString
+			localModified  sql.NullTime
+			remoteModified sql.NullTime
+			detectedAt     sql.NullTime
+			firstSeen      sql.NullTime
+		)
+		if err := rows.Scan(&conflict.ID, &conflict.Path, &strategy, &winner, &conflict.AutoResolved,
+			&baseHash, &localHash, &remoteHash, &copyPath, &conflict.LocalSize, &conflict.RemoteSize,
+			&localModified, &remoteModified, &detectedAt, &firstSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan conflict: %w", err)
+		}
+		conflict.Strategy = strategy.String
+		conflict.Winner = winner.String
+		conflict.BaseHash = baseHash.String
+		conflict.LocalHash = localHash.String
+		conflict.RemoteHash = remoteHash.String
+		conflict.CopyPath = copyPath.String
+		conflict.LocalModified = localModified.Time
+		conflict.RemoteModified = remoteModified.Time
+		conflict.DetectedAt = detectedAt.Time
+		conflict.FirstSeen = firstSeenTime(firstSeen, detectedAt)
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts, rows.Err()
+}
+
+// ConflictFirstSeen returns when the unresolved conflict of a path was first
+// detected, or the zero time if the path has none
+func (d *Database) ConflictFirstSeen(path string) (time.Time, error) {
+	var firstSeen, detectedAt sql.NullTime
+	err := d.db.QueryRow(`
+	SELECT first_seen, detected_at FROM conflicts WHERE local_path = ? AND winner = ''
+	ORDER BY id DESC LIMIT 1
+	`, path).Scan(&firstSeen, &detectedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to look up conflict: %w", err)
+	}
+	return firstSeenTime(firstSeen, detectedAt), nil
+}
+
+// firstSeenTime returns when a conflict was first seen. Conflicts logged
+// before first_seen was added only have the time they were detected.
+func firstSeenTime(firstSeen, detectedAt sql.NullTime) time.Time {
+	if firstSeen.Valid {
+		return firstSeen.Time
+	}
+	return detectedAt.Time
+}
@@ -2,11 +2,14 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/bdstest/zohosync/pkg/types"
 	"github.com/bdstest/zohosync/internal/utils"
@@ -16,6 +19,7 @@ import (
 // Database represents the local SQLite database
 type Database struct {
 	db     *sql.DB
+	path   string
 	logger *utils.Logger
 }
 
@@ -33,11 +37,17 @@ func NewDatabase(dbPath string) (*Database, error) {
 
 	database := &Database{
 		db:     db,
+		path:   dbPath,
 		logger: utils.GetLogger(),
 	}
 
+	if err := database.verifyIntegrity(); err != nil {
+		database.db.Close()
+		return nil, fmt.Errorf("database integrity check failed: %w", err)
+	}
+
 	if err := database.initialize(); err != nil {
-		db.Close()
+		database.db.Close()
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
@@ -57,8 +67,14 @@ func (d *Database) initialize() error {
 		modified_time DATETIME,
 		hash TEXT,
 		is_directory BOOLEAN DEFAULT FALSE,
+		mode INTEGER DEFAULT 0,
 		sync_status TEXT DEFAULT 'pending',
 		last_sync DATETIME,
+		attempts INTEGER DEFAULT 0, -- failed syncs since the last success
+		last_error TEXT DEFAULT '',
+		remote_modified DATETIME,
+		synced_hash TEXT DEFAULT '', -- hash and size at the last successful sync
+		synced_size INTEGER DEFAULT 0,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -70,6 +86,10 @@ func (d *Database) initialize() error {
 		operation_type TEXT NOT NULL, -- upload, download, delete, conflict
 		status TEXT DEFAULT 'pending', -- pending, success, failed
 		error_message TEXT,
+		error_type TEXT DEFAULT '', -- classification of a failure
+		direction TEXT DEFAULT '', -- up, down for transfers
+		bytes INTEGER DEFAULT 0,
+		duration_ms INTEGER DEFAULT 0,
 		started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		completed_at DATETIME,
 		FOREIGN KEY (file_id) REFERENCES files(id)
@@ -94,18 +114,119 @@ func (d *Database) initialize() error {
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	-- PKCE verifiers of OAuth logins waiting for their callback, keyed by a
+	-- hash of the state parameter and encrypted
+	CREATE TABLE IF NOT EXISTS oauth_states (
+		state_hash TEXT PRIMARY KEY,
+		sealed_verifier BLOB NOT NULL,
+		expires_at DATETIME NOT NULL
+	);
+
+	-- Upload sessions that were initiated but not yet completed
+	CREATE TABLE IF NOT EXISTS upload_sessions (
+		upload_id TEXT PRIMARY KEY,
+		local_path TEXT NOT NULL UNIQUE,
+		size INTEGER DEFAULT 0,
+		hash TEXT,
+		upload_url TEXT,
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Progress of hashing large files, so an interrupted hash resumes
+	CREATE TABLE IF NOT EXISTS hash_checkpoints (
+		local_path TEXT PRIMARY KEY,
+		algorithm TEXT NOT NULL,
+		block_size INTEGER NOT NULL,
+		size INTEGER NOT NULL,
+		modified_time DATETIME NOT NULL,
+		hashed INTEGER NOT NULL, -- bytes hashed so far, a whole number of blocks
+		state BLOB NOT NULL,
+		blocks TEXT DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Remote names substituted for local names WorkDrive would reject
+	CREATE TABLE IF NOT EXISTS name_mappings (
+		local_path TEXT PRIMARY KEY,
+		parent_dir TEXT NOT NULL,
+		remote_name TEXT NOT NULL,
+		UNIQUE(parent_dir, remote_name)
+	);
+
+	-- Conflicts between local and remote changes and how they were resolved
+	CREATE TABLE IF NOT EXISTS conflicts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		local_path TEXT NOT NULL,
+		strategy TEXT,
+		winner TEXT DEFAULT '', -- local, remote, or empty while unresolved
+		auto_resolved BOOLEAN DEFAULT FALSE,
+		base_hash TEXT,
+		local_hash TEXT,
+		remote_hash TEXT,
+		copy_path TEXT DEFAULT '', -- where the losing copy was kept, if anywhere
+		local_size INTEGER DEFAULT 0,
+		remote_size INTEGER DEFAULT 0,
+		local_modified DATETIME,
+		remote_modified DATETIME,
+		detected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		first_seen DATETIME -- when an unresolved conflict was first detected
+	);
+
+	-- Checkpoints of the first sync of each sync folder
+	CREATE TABLE IF NOT EXISTS initial_syncs (
+		folder TEXT PRIMARY KEY,
+		remote_id TEXT,
+		planned BOOLEAN DEFAULT FALSE, -- set once every operation is recorded
+		total INTEGER DEFAULT 0,
+		completed INTEGER DEFAULT 0, -- operations with a lower seq are done
+		started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- The planned operations of initial syncs that have not finished
+	CREATE TABLE IF NOT EXISTS initial_sync_ops (
+		folder TEXT NOT NULL,
+		seq INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		path TEXT NOT NULL,
+		remote_id TEXT,
+		size INTEGER DEFAULT 0,
+		modified_time DATETIME,
+		is_directory BOOLEAN DEFAULT FALSE,
+		PRIMARY KEY (folder, seq)
+	);
+
+	-- Conflict policies pinned on paths, overriding sync.conflict_resolution
+	-- for a file or everything under a folder
+	CREATE TABLE IF NOT EXISTS conflict_policies (
+		local_path TEXT PRIMARY KEY,
+		policy TEXT NOT NULL
+	);
+
+	-- The local roots of the configured sync folders
+	CREATE TABLE IF NOT EXISTS sync_folders (
+		folder TEXT PRIMARY KEY
+	);
+
 	-- Create indexes for better performance
 	CREATE INDEX IF NOT EXISTS idx_files_local_path ON files(local_path);
 	CREATE INDEX IF NOT EXISTS idx_files_remote_id ON files(remote_id);
 	CREATE INDEX IF NOT EXISTS idx_files_sync_status ON files(sync_status);
+	CREATE INDEX IF NOT EXISTS idx_files_hash ON files(hash);
 	CREATE INDEX IF NOT EXISTS idx_sync_operations_file_id ON sync_operations(file_id);
 	CREATE INDEX IF NOT EXISTS idx_sync_operations_status ON sync_operations(status);
+	CREATE INDEX IF NOT EXISTS idx_conflicts_local_path ON conflicts(local_path);
 	`
 
 	if _, err := d.db.Exec(schema); err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	if err := d.migrate(); err != nil {
+		return err
+	}
+
 	d.logger.Info("Database initialized successfully")
 	return nil
 }
@@ -115,14 +236,45 @@ func (d *Database) Close() error {
 	return d.db.Close()
 }
 
-// SaveFileMetadata saves or updates file metadata
+// Ping verifies the database connection is still usable
+func (d *Database) Ping(ctx context.Context) error {
+	return d.db.PingContext(ctx)
+}
+
+// SaveFileMetadata saves or updates file metadata. An existing row is
+// updated in place, so its failed attempt count is kept.
 func (d *Database) SaveFileMetadata(metadata *types.FileMetadata) error {
 	query := `
-	INSERT OR REPLACE INTO files 
-	(local_path, remote_id, remote_path, size, modified_time, hash, is_directory, sync_status, last_sync, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	INSERT INTO files 
+	(local_path, remote_id, remote_path, size, modified_time, hash, is_directory, mode, sync_status, remote_modified, synced_hash, synced_size, last_sync, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	ON CONFLICT(local_path) DO UPDATE SET
+		remote_id = excluded.remote_id,
+		remote_path = excluded.remote_path,
+		size = excluded.size,
+		modified_time = excluded.modified_time,
+		hash = excluded.hash,
+		is_directory = excluded.is_directory,
+		mode = excluded.mode,
+		sync_status = excluded.sync_status,
+		remote_modified = excluded.remote_modified,
+		synced_hash = CASE WHEN excluded.sync_status = 'synced' THEN excluded.hash ELSE files.synced_hash END,
+		synced_size = CASE WHEN excluded.sync_status = 'synced' THEN excluded.size ELSE files.synced_size END,
+		last_sync = excluded.last_sync,
+		updated_at = excluded.updated_at
 	`
 
+	var remoteModified sql.NullTime
+	if !metadata.RemoteModified.IsZero() {
+		remoteModified = sql.NullTime{Time: metadata.RemoteModified, Valid: true}
+	}
+	// A new row only has a synced state if it is saved as synced
+	var syncedHash string
+	var syncedSize int64
+	if metadata.SyncStatus == "synced" {
+		syncedHash, syncedSize = metadata.Hash, metadata.Size
+	}
+
 	_, err := d.db.Exec(query,
 		metadata.Path,
 		metadata.RemoteID,
@@ -131,7 +283,11 @@ func (d *Database) SaveFileMetadata(metadata *types.FileMetadata) error {
 		metadata.ModifiedTime,
 		metadata.Hash,
 		metadata.IsDirectory,
+		metadata.Mode,
 		metadata.SyncStatus,
+		remoteModified,
+		syncedHash,
+		syncedSize,
 		time.Now(),
 	)
 
@@ -146,7 +302,8 @@ func (d *Database) SaveFileMetadata(metadata *types.FileMetadata) error {
 // GetFileMetadata retrieves file metadata by local path
 func (d *Database) GetFileMetadata(localPath string) (*types.FileMetadata, error) {
 	query := `
-	SELECT id, local_path, remote_id, size, modified_time, hash, is_directory, sync_status
+	SELECT id, local_path, remote_id, size, modified_time, hash, is_directory, mode, sync_status, remote_modified,
+		COALESCE(synced_hash, ''), COALESCE(synced_size, 0)
 	FROM files WHERE local_path = ?
 	`
 
@@ -155,6 +312,7 @@ func (d *Database) GetFileMetadata(localPath string) (*types.FileMetadata, error
 	var metadata types.FileMetadata
 	var id int
 	var modifiedTime time.Time
+	var remoteModified sql.NullTime
 
 	err := row.Scan(
 		&id,
@@ -164,7 +322,11 @@ func (d *Database) GetFileMetadata(localPath string) (*types.FileMetadata, error
 		&modifiedTime,
 		&metadata.Hash,
 		&metadata.IsDirectory,
+		&metadata.Mode,
 		&metadata.SyncStatus,
+		&remoteModified,
+		&metadata.SyncedHash,
+		&metadata.SyncedSize,
 	)
 
 	if err != nil {
@@ -176,21 +338,192 @@ func (d *Database) GetFileMetadata(localPath string) (*types.FileMetadata, error
 
 	metadata.ID = fmt.Sprintf("%d", id)
 	metadata.ModifiedTime = modifiedTime
+	metadata.RemoteModified = remoteModified.Time
 
 	return &metadata, nil
 }
 
-// GetPendingFiles retrieves files that need synchronization
+// GetFileByRemoteID returns the file or folder recorded with remoteID, or
+// nil if there is none
+func (d *Database) GetFileByRemoteID(remoteID string) (*types.FileMetadata, error) {
+	var localPath string
+	err := d.db.QueryRow("SELECT local_path FROM files WHERE remote_id = ? ORDER BY id LIMIT 1", remoteID).Scan(&localPath)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up remote file %s: %w", remoteID, err)
+	}
+	return d.GetFileMetadata(localPath)
+}
+
+// FindUploadedByHash returns a synced file other than excludePath that has
+// already been uploaded with the given content hash and size, or nil if there
+// is none. Matching on size as well guards against hash collisions, and
+// leaving out files with changes waiting to sync against stale records.
+func (d *Database) FindUploadedByHash(hash string, size int64, excludePath string) (*types.FileMetadata, error) {
+	if hash == "" {
+		return nil, nil
+	}
+
+	query := `
+	SELECT id, local_path, remote_id, size, modified_time, hash, is_directory, mode, sync_status
+	FROM files
+	WHERE hash = ? AND size = ? AND local_path != ? AND remote_id != '' AND is_directory = 0
+		AND sync_status = 'synced'
+	LIMIT 1
+	`
+
+	var metadata types.FileMetadata
+	var id int
+	var modifiedTime time.Time
+
+	err := d.db.QueryRow(query, hash, size, excludePath).Scan(
+		&id,
+		&metadata.Path,
+		&metadata.RemoteID,
+		&metadata.Size,
+		&modifiedTime,
+		&metadata.Hash,
+		&metadata.IsDirectory,
+		&metadata.Mode,
+		&metadata.SyncStatus,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up file by hash: %w", err)
+	}
+
+	metadata.ID = fmt.Sprintf("%d", id)
+	metadata.ModifiedTime = modifiedTime
+
+	return &metadata, nil
+}
+
+// HasUploadedWithSize reports whether a file other than excludePath has
+// already been uploaded with the given size, which is when hashing a file
+// before its upload can find a duplicate
+func (d *Database) HasUploadedWithSize(size int64, excludePath string) (bool, error) {
+	var found int
+	err := d.db.QueryRow(`
+	SELECT 1 FROM files
+	WHERE size = ? AND local_path != ? AND remote_id != '' AND hash != '' AND is_directory = 0
+	LIMIT 1
+	`, size, excludePath).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up files by size: %w", err)
+	}
+	return true, nil
+}
+
+// GetPendingFiles retrieves files that need synchronization. It holds them
+// all in memory; IteratePendingFiles pages through them instead.
 func (d *Database) GetPendingFiles() ([]types.FileMetadata, error) {
+	var files []types.FileMetadata
+	err := d.IteratePendingFiles(pendingPageSize, func(metadata types.FileMetadata) error {
+		files = append(files, metadata)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// pendingPageSize is how many pending files GetPendingFiles reads per query
+const pendingPageSize = 1000
+
+// IteratePendingFiles calls fn for every file that needs synchronization,
+// most recently modified first, reading batchSize rows at a time so only
+// one batch is held in memory. No query is open while fn runs, so it may
+// update the files it is given; files whose status changes after their
+// batch was read are not visited again. An error from fn stops the
+// iteration and is returned.
+func (d *Database) IteratePendingFiles(batchSize int, fn func(types.FileMetadata) error) error {
+	if batchSize <= 0 {
+		batchSize = pendingPageSize
+	}
+
+	// Pages continue after the last row of the previous one in the sort
+	// order, which stays correct as rows change status between pages
 	query := `
-	SELECT id, local_path, remote_id, size, modified_time, hash, is_directory, sync_status
+	SELECT id, local_path, remote_id, size, modified_time, hash, is_directory, mode, sync_status,
+		COALESCE(synced_hash, ''), COALESCE(synced_size, 0), CAST(COALESCE(modified_time, '') AS TEXT)
 	FROM files WHERE sync_status IN ('pending', 'conflict', 'error')
-	ORDER BY modified_time DESC
+		AND (? OR COALESCE(modified_time, '') < ? OR (COALESCE(modified_time, '') = ? AND id < ?))
+	ORDER BY COALESCE(modified_time, '') DESC, id DESC
+	LIMIT ?
+	`
+
+	first, lastModified, lastID := true, "", 0
+	for {
+		rows, err := d.db.Query(query, first, lastModified, lastModified, lastID, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get pending files: %w", err)
+		}
+
+		batch := make([]types.FileMetadata, 0, batchSize)
+		for rows.Next() {
+			var metadata types.FileMetadata
+			var modifiedTime time.Time
+
+			if err := rows.Scan(
+				&lastID,
+				&metadata.Path,
+				&metadata.RemoteID,
+				&metadata.Size,
+				&modifiedTime,
+				&metadata.Hash,
+				&metadata.IsDirectory,
+				&metadata.Mode,
+				&metadata.SyncStatus,
+				&metadata.SyncedHash,
+				&metadata.SyncedSize,
+				&lastModified,
+			); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan file row: %w", err)
+			}
+
+			metadata.ID = fmt.Sprintf("%d", lastID)
+			metadata.ModifiedTime = modifiedTime
+			batch = append(batch, metadata)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to get pending files: %w", err)
+		}
+
+		for _, metadata := range batch {
+			if err := fn(metadata); err != nil {
+				return err
+			}
+		}
+		if len(batch) < batchSize {
+			return nil
+		}
+		first = false
+	}
+}
+
+// GetFilesUnder retrieves a path and every tracked descendant
+func (d *Database) GetFilesUnder(dirPath string) ([]types.FileMetadata, error) {
+	query := `
+	SELECT id, local_path, remote_id, size, modified_time, hash, is_directory, mode, sync_status
+	FROM files WHERE local_path = ? OR local_path LIKE ? ESCAPE '\'
+	ORDER BY local_path
 	`
 
-	rows, err := d.db.Query(query)
+	rows, err := d.db.Query(query, dirPath, escapeLike(dirPath)+"/%")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pending files: %w", err)
+		return nil, fmt.Errorf("failed to get files under %s: %w", dirPath, err)
 	}
 	defer rows.Close()
 
@@ -208,6 +541,7 @@ func (d *Database) GetPendingFiles() ([]types.FileMetadata, error) {
 			&modifiedTime,
 			&metadata.Hash,
 			&metadata.IsDirectory,
+			&metadata.Mode,
 			&metadata.SyncStatus,
 		)
 
@@ -220,17 +554,161 @@ func (d *Database) GetPendingFiles() ([]types.FileMetadata, error) {
 		files = append(files, metadata)
 	}
 
-	return files, nil
+	return files, rows.Err()
+}
+
+// GetFilesByStatus retrieves every tracked file with the given sync status
+func (d *Database) GetFilesByStatus(status string) ([]types.FileMetadata, error) {
+	query := `
+	SELECT id, local_path, remote_id, size, modified_time, hash, is_directory, mode, sync_status
+	FROM files WHERE sync_status = ?
+	ORDER BY local_path
+	`
+
+	rows, err := d.db.Query(query, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s files: %w", status, err)
+	}
+	defer rows.Close()
+
+	var files []types.FileMetadata
+	for rows.Next() {
+		var metadata types.FileMetadata
+		var id int
+		var modifiedTime time.Time
+
+		err := rows.Scan(
+			&id,
+			&metadata.Path,
+			&metadata.RemoteID,
+			&metadata.Size,
+			&modifiedTime,
+			&metadata.Hash,
+			&metadata.IsDirectory,
+			&metadata.Mode,
+			&metadata.SyncStatus,
+		)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan file row: %w", err)
+		}
+
+		metadata.ID = fmt.Sprintf("%d", id)
+		metadata.ModifiedTime = modifiedTime
+		files = append(files, metadata)
+	}
+
+	return files, rows.Err()
+}
+
+// SetSyncStatus changes the sync status of a tracked file, leaving the rest
+// of its record alone. It reports whether the file was tracked.
+func (d *Database) SetSyncStatus(localPath, status string) (bool, error) {
+	result, err := d.db.Exec(`
+	UPDATE files SET sync_status = ?, updated_at = CURRENT_TIMESTAMP WHERE local_path = ?
+	`, status, localPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to set sync status: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to set sync status: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// DeleteFilesUnder removes a directory and every tracked descendant in a
+// single transaction, returning the number of rows removed
+func (d *Database) DeleteFilesUnder(dirPath string) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		`DELETE FROM files WHERE local_path = ? OR local_path LIKE ? ESCAPE '\'`,
+		dirPath, escapeLike(dirPath)+"/%",
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete files under %s: %w", dirPath, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit delete: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	d.logger.Debugf("Removed %d file records under %s", count, dirPath)
+	return count, nil
+}
+
+// ClearFiles removes every tracked file, returning the number of rows
+// removed. The auth token, configuration and other state are kept.
+func (d *Database) ClearFiles() (int64, error) {
+	result, err := d.db.Exec("DELETE FROM files")
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear files: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	d.logger.Debugf("Removed %d file records", count)
+	return count, nil
+}
+
+// MoveFilesUnder rewrites the paths of a directory and every tracked
+// descendant from oldDir to newDir in a single transaction
+func (d *Database) MoveFilesUnder(oldDir, newDir string) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// SQLite substr() is 1-based and counts characters, not bytes
+	suffixStart := utf8.RuneCountInString(oldDir) + 1
+
+	result, err := tx.Exec(`
+	UPDATE files
+	SET local_path = ? || substr(local_path, ?),
+		remote_path = ? || substr(local_path, ?),
+		updated_at = CURRENT_TIMESTAMP
+	WHERE local_path = ? OR local_path LIKE ? ESCAPE '\'
+	`, newDir, suffixStart, newDir, suffixStart, oldDir, escapeLike(oldDir)+"/%")
+	if err != nil {
+		return 0, fmt.Errorf("failed to move files under %s: %w", oldDir, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit move: %w", err)
+	}
+
+	count, _ := result.RowsAffected()
+	d.logger.Debugf("Moved %d file records from %s to %s", count, oldDir, newDir)
+	return count, nil
+}
+
+// escapeLike escapes LIKE wildcards so a path can be used as a literal prefix
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(value)
 }
 
 // LogSyncOperation records a sync operation
 func (d *Database) LogSyncOperation(fileID, operationType, status, errorMessage string) error {
+	return d.LogOperation(types.OperationRecord{FileID: fileID, Type: operationType, Status: status, Error: errorMessage})
+}
+
+// LogOperation records a sync operation along with what it transferred or
+// how it failed
+func (d *Database) LogOperation(record types.OperationRecord) error {
 	query := `
-	INSERT INTO sync_operations (file_id, operation_type, status, error_message, started_at)
-	VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	INSERT INTO sync_operations (file_id, operation_type, status, error_message, error_type, direction, bytes, duration_ms, started_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 	`
 
-	_, err := d.db.Exec(query, fileID, operationType, status, errorMessage)
+	_, err := d.db.Exec(query, record.FileID, record.Type, record.Status, record.Error, record.ErrorType,
+		record.Direction, record.Bytes, record.Duration.Milliseconds())
 	if err != nil {
 		return fmt.Errorf("failed to log sync operation: %w", err)
 	}
@@ -282,6 +760,14 @@ func (d *Database) SaveAuthToken(token *types.TokenInfo) error {
 	return nil
 }
 
+// ClearAuthToken deletes the stored authentication token
+func (d *Database) ClearAuthToken() error {
+	if _, err := d.db.Exec("DELETE FROM auth_tokens"); err != nil {
+		return fmt.Errorf("failed to clear auth token: %w", err)
+	}
+	return nil
+}
+
 // GetAuthToken retrieves the stored authentication token
 func (d *Database) GetAuthToken() (*types.TokenInfo, error) {
 	query := `
@@ -320,8 +806,7 @@ func (d *Database) GetSyncStats() (*types.SyncStatus, error) {
 	query := `
 	SELECT 
 		COUNT(*) as total_files,
-		COUNT(CASE WHEN sync_status = 'synced' THEN 1 END) as synced_files,
-		MAX(last_sync) as last_sync
+		COUNT(CASE WHEN sync_status = 'synced' THEN 1 END) as synced_files
 	FROM files
 	`
 
@@ -330,11 +815,18 @@ func (d *Database) GetSyncStats() (*types.SyncStatus, error) {
 	var totalFiles, syncedFiles int
 	var lastSyncPtr *time.Time
 
-	err := row.Scan(&totalFiles, &syncedFiles, &lastSyncPtr)
+	err := row.Scan(&totalFiles, &syncedFiles)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sync stats: %w", err)
 	}
 
+	// MAX() would lose the column type the driver parses times by, so the
+	// latest row is read instead
+	err = d.db.QueryRow("SELECT last_sync FROM files WHERE last_sync IS NOT NULL ORDER BY last_sync DESC LIMIT 1").Scan(&lastSyncPtr)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get sync stats: %w", err)
+	}
+
 	status := &types.SyncStatus{
 		State:       types.SyncStateIdle,
 		TotalFiles:  totalFiles,
@@ -346,6 +838,10 @@ func (d *Database) GetSyncStats() (*types.SyncStatus, error) {
 		status.LastSync = *lastSyncPtr
 	}
 
+	if status.InitialSync, err = d.initialSyncProgress(); err != nil {
+		return nil, err
+	}
+
 	return status, nil
 }
 
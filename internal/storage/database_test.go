@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDatabase opens a database in a temporary directory
+func newTestDatabase(t *testing.T) (*Database, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	dbPath := filepath.Join(t.TempDir(), "zohosync.db")
+	database, err := NewDatabase(dbPath)
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	return database, dbPath
+}
+
+func TestRecoverTruncatedDatabase(t *testing.T) {
+	database, dbPath := newTestDatabase(t)
+	for i := 0; i < 200; i++ {
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+			Path:       fmt.Sprintf("/sync/file-%03d.txt", i),
+			SyncStatus: "synced",
+		}))
+	}
+	require.NoError(t, database.Close())
+
+	info, err := os.Stat(dbPath)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(dbPath, info.Size()/2))
+
+	recovered, err := NewDatabase(dbPath)
+	require.NoError(t, err)
+	defer recovered.Close()
+
+	require.NoError(t, recovered.SaveFileMetadata(&types.FileMetadata{Path: "/sync/new.txt", SyncStatus: "pending"}))
+	metadata, err := recovered.GetFileMetadata("/sync/new.txt")
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "pending", metadata.SyncStatus)
+
+	backups, err := filepath.Glob(dbPath + ".corrupt-*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
+
+func TestSalvageCarriesOverSyncState(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	expires := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, database.SaveNameMapping("/sync/a:b.txt", "a_b.txt"))
+	require.NoError(t, database.SetConflictPolicy("/sync/shared", "local"))
+	require.NoError(t, database.SetSyncFolders([]string{"/sync"}))
+	require.NoError(t, database.SaveUploadSession(&types.UploadSession{
+		UploadID: "upload1", Path: "/sync/video.mp4", Size: 10, Hash: "abc", ExpiresAt: expires,
+	}))
+
+	salvaged := database.salvageRows()
+	rebuilt, _ := newTestDatabase(t)
+	rebuilt.restoreRows(salvaged)
+
+	remoteName, err := rebuilt.GetRemoteName("/sync/a:b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a_b.txt", remoteName)
+
+	policy, err := rebuilt.GetConflictPolicy("/sync/shared")
+	require.NoError(t, err)
+	assert.Equal(t, "local", policy)
+
+	roots, err := rebuilt.folderRoots()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/sync"}, roots)
+
+	session, err := rebuilt.GetUploadSession("/sync/video.mp4")
+	require.NoError(t, err)
+	require.NotNil(t, session)
+	assert.Equal(t, "upload1", session.UploadID)
+	assert.True(t, expires.Equal(session.ExpiresAt))
+}
+
+func TestBackup(t *testing.T) {
+	database, dbPath := newTestDatabase(t)
+	require.NoError(t, database.SetConfigValue("theme", "dark"))
+
+	backupPath := dbPath + ".bak"
+	require.NoError(t, database.Backup(backupPath))
+	assert.Error(t, database.Backup(backupPath), "existing backups must not be overwritten")
+
+	snapshot, err := NewDatabase(backupPath)
+	require.NoError(t, err)
+	defer snapshot.Close()
+
+	value, err := snapshot.GetConfigValue("theme")
+	require.NoError(t, err)
+	assert.Equal(t, "dark", value)
+}
+
+func TestIteratePendingFilesVisitsEachOnce(t *testing.T) {
+	database, _ := newTestDatabase(t)
+
+	// Groups of files share a modification time, so batches end in the
+	// middle of ties
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	const total = 1050
+	for i := 0; i < total; i++ {
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+			Path:         fmt.Sprintf("/sync/file-%04d.txt", i),
+			ModifiedTime: base.Add(time.Duration(i/7) * time.Minute),
+			SyncStatus:   []string{"pending", "error", "conflict"}[i%3],
+		}))
+	}
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: "/sync/done.txt", ModifiedTime: base, SyncStatus: "synced"}))
+
+	seen := make(map[string]int)
+	var previous time.Time
+	err := database.IteratePendingFiles(100, func(file types.FileMetadata) error {
+		seen[file.Path]++
+		if !previous.IsZero() {
+			assert.False(t, file.ModifiedTime.After(previous), "%s is out of order", file.Path)
+		}
+		previous = file.ModifiedTime
+
+		// Syncing a file while iterating does not disturb the pages
+		_, err := database.SetSyncStatus(file.Path, "synced")
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, seen, total)
+	for path, count := range seen {
+		assert.Equal(t, 1, count, "%s visited %d times", path, count)
+	}
+	assert.NotContains(t, seen, "/sync/done.txt")
+
+	pending, err := database.GetPendingFiles()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestIteratePendingFilesStopsOnError(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	for i := 0; i < 10; i++ {
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: fmt.Sprintf("/sync/%d.txt", i), SyncStatus: "pending"}))
+	}
+
+	stop := fmt.Errorf("stop")
+	visited := 0
+	err := database.IteratePendingFiles(3, func(types.FileMetadata) error {
+		visited++
+		if visited == 5 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 5, visited)
+}
+
+func TestSyncedStateSurvivesQueuedChanges(t *testing.T) {
+	db, _ := newTestDatabase(t)
+	path := "/docs/report.txt"
+
+	require.NoError(t, db.SaveFileMetadata(&types.FileMetadata{Path: path, RemoteID: "r1", Size: 4, Hash: "base", SyncStatus: "synced"}))
+	require.NoError(t, db.SaveFileMetadata(&types.FileMetadata{Path: path, RemoteID: "r1", Size: 9, SyncStatus: "pending"}))
+
+	metadata, err := db.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Empty(t, metadata.Hash)
+	assert.Equal(t, "base", metadata.SyncedHash)
+	assert.Equal(t, int64(4), metadata.SyncedSize)
+
+	require.NoError(t, db.SaveFileMetadata(&types.FileMetadata{Path: path, RemoteID: "r1", Size: 9, Hash: "edited", SyncStatus: "synced"}))
+	metadata, err = db.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", metadata.SyncedHash)
+	assert.Equal(t, int64(9), metadata.SyncedSize)
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SyncStatusFailed marks a file that failed to sync more often than the
+// retry budget allows. Sync cycles leave it alone until it is retried.
+const SyncStatusFailed = "failed"
+
+// RecordSyncFailure counts a failed sync of a tracked file and sets the file
+// aside as failed once it has failed maxAttempts times in a row. A
+// maxAttempts of zero never sets files aside. It reports whether the file
+// was set aside.
+func (d *Database) RecordSyncFailure(localPath, message string, maxAttempts int) (bool, error) {
+	result, err := d.db.Exec(`
+	UPDATE files SET
+		attempts = attempts + 1,
+		last_error = ?,
+		sync_status = CASE WHEN ? > 0 AND attempts + 1 >= ? THEN ? ELSE sync_status END,
+		updated_at = CURRENT_TIMESTAMP
+	WHERE local_path = ?
+	`, message, maxAttempts, maxAttempts, SyncStatusFailed, localPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to record sync failure: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return false, err
+	}
+
+	var status string
+	if err := d.db.QueryRow("SELECT sync_status FROM files WHERE local_path = ?", localPath).Scan(&status); err != nil {
+		return false, fmt.Errorf("failed to record sync failure: %w", err)
+	}
+	return status == SyncStatusFailed, nil
+}
+
+// ResetSyncAttempts clears the failed attempt count of a file after it
+// synced
+func (d *Database) ResetSyncAttempts(localPath string) error {
+	_, err := d.db.Exec(`
+	UPDATE files SET attempts = 0, last_error = ''
+	WHERE local_path = ? AND (attempts > 0 OR last_error != '')
+	`, localPath)
+	if err != nil {
+		return fmt.Errorf("failed to reset sync attempts: %w", err)
+	}
+	return nil
+}
+
+// GetFailedFiles returns the files set aside as failed, most recent first
+func (d *Database) GetFailedFiles() ([]types.FailedFile, error) {
+	rows, err := d.db.Query(`
+	SELECT local_path, attempts, COALESCE(last_error, ''), updated_at
+	FROM files WHERE sync_status = ?
+	ORDER BY updated_at DESC, local_path
+	`, SyncStatusFailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []types.FailedFile
+	for rows.Next() {
+		var file types.FailedFile
+		if err := rows.Scan(&file.Path, &file.Attempts, &file.LastError, &file.FailedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan failed file row: %w", err)
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+// RetryFailedFiles makes failed files pending again with a fresh retry
+// budget: those in paths, or all of them if paths is empty. It returns how
+// many were re-queued.
+func (d *Database) RetryFailedFiles(paths ...string) (int, error) {
+	query := `
+	UPDATE files SET sync_status = 'pending', attempts = 0, last_error = '', updated_at = CURRENT_TIMESTAMP
+	WHERE sync_status = ?`
+	args := []interface{}{SyncStatusFailed}
+	if len(paths) > 0 {
+		query += " AND local_path IN (?" + strings.Repeat(", ?", len(paths)-1) + ")"
+		for _, path := range paths {
+			args = append(args, path)
+		}
+	}
+
+	result, err := d.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retry failed files: %w", err)
+	}
+	retried, err := result.RowsAffected()
+	return int(retried), err
+}
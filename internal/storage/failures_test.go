@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+func TestRecordSyncFailureSetsFileAsideAfterBudget(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	const path = "/home/me/Documents/locked.docx"
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "error"}))
+
+	failed, err := database.RecordSyncFailure(path, "forbidden", 3)
+	require.NoError(t, err)
+	assert.False(t, failed)
+
+	// Saving the metadata again keeps the count
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "error"}))
+	failed, err = database.RecordSyncFailure(path, "forbidden", 3)
+	require.NoError(t, err)
+	assert.False(t, failed)
+
+	failed, err = database.RecordSyncFailure(path, "still forbidden", 3)
+	require.NoError(t, err)
+	assert.True(t, failed)
+
+	pending, err := database.GetPendingFiles()
+	require.NoError(t, err)
+	assert.Empty(t, pending, "failed files are left out of sync cycles")
+
+	files, err := database.GetFailedFiles()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, path, files[0].Path)
+	assert.Equal(t, 3, files[0].Attempts)
+	assert.Equal(t, "still forbidden", files[0].LastError)
+}
+
+func TestRecordSyncFailureWithoutBudget(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	const path = "/home/me/Documents/flaky.txt"
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "error"}))
+
+	for i := 0; i < 10; i++ {
+		failed, err := database.RecordSyncFailure(path, "timeout", 0)
+		require.NoError(t, err)
+		assert.False(t, failed)
+	}
+
+	// Untracked files are not counted
+	failed, err := database.RecordSyncFailure("/home/me/untracked.txt", "timeout", 1)
+	require.NoError(t, err)
+	assert.False(t, failed)
+}
+
+func TestRetryFailedFiles(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	for _, path := range []string{"/a.txt", "/b.txt"} {
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "error"}))
+		_, err := database.RecordSyncFailure(path, "forbidden", 1)
+		require.NoError(t, err)
+	}
+
+	retried, err := database.RetryFailedFiles("/a.txt", "/missing.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, retried)
+
+	metadata, err := database.GetFileMetadata("/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", metadata.SyncStatus)
+
+	// A fresh budget: one more failure sets it aside again
+	failed, err := database.RecordSyncFailure("/a.txt", "forbidden", 2)
+	require.NoError(t, err)
+	assert.False(t, failed)
+	require.NoError(t, database.ResetSyncAttempts("/a.txt"))
+
+	retried, err = database.RetryFailedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, 1, retried)
+
+	files, err := database.GetFailedFiles()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
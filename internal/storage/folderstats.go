@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SetSyncFolders records the local roots of the configured sync folders,
+// replacing the ones recorded before
+func (d *Database) SetSyncFolders(folders []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM sync_folders"); err != nil {
+		return fmt.Errorf("failed to clear sync folders: %w", err)
+	}
+	for _, folder := range folders {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO sync_folders (folder) VALUES (?)", filepath.Clean(folder)); err != nil {
+			return fmt.Errorf("failed to record sync folder %s: %w", folder, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// folderRoots returns the known sync folder roots: the recorded ones and
+// those that ran an initial sync
+func (d *Database) folderRoots() ([]string, error) {
+	rows, err := d.db.Query(`
+	SELECT folder FROM sync_folders
+	UNION SELECT folder FROM initial_syncs
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync folders: %w", err)
+	}
+	defer rows.Close()
+
+	var roots []string
+	for rows.Next() {
+		var root string
+		if err := rows.Scan(&root); err != nil {
+			return nil, fmt.Errorf("failed to scan sync folder row: %w", err)
+		}
+		roots = append(roots, root)
+	}
+	return roots, rows.Err()
+}
+
+// GetFolderStats returns the sync state of the files under each sync folder,
+// keyed by the folder's local root. A file belongs to the innermost known
+// folder containing it; files outside every known folder are grouped under
+// the outermost directory holding them. Directories are not counted.
+func (d *Database) GetFolderStats() (map[string]types.FolderSyncStatus, error) {
+	roots, err := d.folderRoots()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := d.db.Query(`
+	SELECT local_path, sync_status, last_sync
+	FROM files WHERE is_directory = FALSE
+	ORDER BY local_path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get folder stats: %w", err)
+	}
+	defer rows.Close()
+
+	type fileRow struct {
+		path     string
+		status   string
+		lastSync sql.NullTime
+	}
+	var files []fileRow
+	for rows.Next() {
+		var file fileRow
+		var status sql.NullString
+		if err := rows.Scan(&file.path, &status, &file.lastSync); err != nil {
+			return nil, fmt.Errorf("failed to scan file row: %w", err)
+		}
+		file.status = status.String
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Roots for the files outside every known folder are derived from
+	// their directories, merging nested ones into the outermost
+	var derived []string
+	for _, file := range files {
+		if folderRoot(roots, file.path) == "" {
+			derived = append(derived, filepath.Dir(file.path))
+		}
+	}
+	sort.Strings(derived)
+
+	stats := make(map[string]types.FolderSyncStatus)
+	for _, root := range roots {
+		stats[root] = types.FolderSyncStatus{Folder: root}
+	}
+	for _, file := range files {
+		root := folderRoot(roots, file.path)
+		if root == "" {
+			root = outermostRoot(derived, file.path)
+		}
+
+		folder := stats[root]
+		folder.Folder = root
+		folder.TotalFiles++
+		if file.status == "synced" {
+			folder.SyncedFiles++
+			if file.lastSync.Valid && file.lastSync.Time.After(folder.LastSync) {
+				folder.LastSync = file.lastSync.Time
+			}
+		} else {
+			folder.PendingFiles++
+		}
+		stats[root] = folder
+	}
+	return stats, nil
+}
+
+// folderRoot returns the innermost of roots that contains path, or "" if
+// none does
+func folderRoot(roots []string, path string) string {
+	match := ""
+	for _, root := range roots {
+		if isUnder(path, root) && len(root) > len(match) {
+			match = root
+		}
+	}
+	return match
+}
+
+// outermostRoot returns the first of the sorted roots that contains path
+func outermostRoot(roots []string, path string) string {
+	for _, root := range roots {
+		if isUnder(path, root) {
+			return root
+		}
+	}
+	return filepath.Dir(path)
+}
+
+// isUnder reports whether path is root or lies below it
+func isUnder(path, root string) bool {
+	return path == root || strings.HasPrefix(path, strings.TrimSuffix(root, "/")+"/")
+}
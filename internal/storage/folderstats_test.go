@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+func TestGetFolderStatsGroupsByFolderRoot(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	require.NoError(t, database.SetSyncFolders([]string{"/home/me/Documents", "/home/me/Photos", "/home/me/Empty"}))
+
+	save := func(path, status string, isDir bool) {
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: status, IsDirectory: isDir}))
+	}
+	save("/home/me/Documents/a.txt", "synced", false)
+	save("/home/me/Documents/b.txt", "synced", false)
+	save("/home/me/Documents/sub", "synced", true)
+	save("/home/me/Documents/sub/c.txt", "synced", false)
+	save("/home/me/Photos/1.jpg", "synced", false)
+	save("/home/me/Photos/2.jpg", "pending", false)
+	save("/home/me/Photos/3.jpg", "conflict", false)
+	// A sibling whose name only starts like a folder root
+	save("/home/me/Photos-old/4.jpg", "pending", false)
+
+	stats, err := database.GetFolderStats()
+	require.NoError(t, err)
+
+	documents := stats["/home/me/Documents"]
+	assert.Equal(t, 3, documents.TotalFiles, "directories are not counted")
+	assert.Equal(t, 3, documents.SyncedFiles)
+	assert.Zero(t, documents.PendingFiles)
+	assert.WithinDuration(t, time.Now(), documents.LastSync, time.Minute)
+
+	photos := stats["/home/me/Photos"]
+	assert.Equal(t, "/home/me/Photos", photos.Folder)
+	assert.Equal(t, 3, photos.TotalFiles)
+	assert.Equal(t, 1, photos.SyncedFiles)
+	assert.Equal(t, 2, photos.PendingFiles)
+
+	empty, ok := stats["/home/me/Empty"]
+	assert.True(t, ok, "configured folders are reported even without files")
+	assert.Zero(t, empty.TotalFiles)
+	assert.True(t, empty.LastSync.IsZero())
+
+	// Files outside every known folder are grouped by their directory
+	old := stats["/home/me/Photos-old"]
+	assert.Equal(t, 1, old.TotalFiles)
+	assert.Equal(t, 1, old.PendingFiles)
+	assert.Len(t, stats, 4)
+}
+
+func TestGetSyncStatsReportsLastSync(t *testing.T) {
+	database, _ := newTestDatabase(t)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: "/home/me/a.txt", SyncStatus: "synced"}))
+
+	stats, err := database.GetSyncStats()
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.TotalFiles)
+	assert.WithinDuration(t, time.Now(), stats.LastSync, time.Minute)
+}
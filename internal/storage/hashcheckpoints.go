@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SaveHashCheckpoint records the progress of hashing a file, replacing any
+// earlier checkpoint for the same path
+func (d *Database) SaveHashCheckpoint(checkpoint *types.HashCheckpoint) error {
+	_, err := d.db.Exec(`
+	INSERT OR REPLACE INTO hash_checkpoints (local_path, algorithm, block_size, size, modified_time,
+		hashed, state, blocks, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, checkpoint.Path, checkpoint.Algorithm, checkpoint.BlockSize, checkpoint.Size, checkpoint.ModTime,
+		checkpoint.Offset, checkpoint.State, strings.Join(checkpoint.Blocks, ","))
+	if err != nil {
+		return fmt.Errorf("failed to save hash checkpoint: %w", err)
+	}
+	return nil
+}
+
+// GetHashCheckpoint returns the hash checkpoint of a path, or nil if there
+// is none
+func (d *Database) GetHashCheckpoint(localPath string) (*types.HashCheckpoint, error) {
+	var (
+		checkpoint types.HashCheckpoint
+		blocks     string
+	)
+	err := d.db.QueryRow(`
+	SELECT local_path, algorithm, block_size, size, modified_time, hashed, state, blocks
+	FROM hash_checkpoints WHERE local_path = ?
+	`, localPath).Scan(&checkpoint.Path, &checkpoint.Algorithm, &checkpoint.BlockSize, &checkpoint.Size,
+		&checkpoint.ModTime, &checkpoint.Offset, &checkpoint.State, &blocks)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hash checkpoint: %w", err)
+	}
+	if blocks != "" {
+		checkpoint.Blocks = strings.Split(blocks, ",")
+	}
+	return &checkpoint, nil
+}
+
+// DeleteHashCheckpoint forgets the hash checkpoint of a path
+func (d *Database) DeleteHashCheckpoint(localPath string) error {
+	if _, err := d.db.Exec("DELETE FROM hash_checkpoints WHERE local_path = ?", localPath); err != nil {
+		return fmt.Errorf("failed to delete hash checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// GetInitialSync returns the initial sync checkpoint of a sync folder, or
+// nil if its initial sync never started
+func (d *Database) GetInitialSync(folder string) (*types.InitialSyncState, error) {
+	var state types.InitialSyncState
+	err := d.db.QueryRow(`
+	SELECT folder, remote_id, planned, total, completed, started_at, updated_at
+	FROM initial_syncs WHERE folder = ?
+	`, folder).Scan(&state.Folder, &state.RemoteID, &state.Planned, &state.Total,
+		&state.Completed, &state.StartedAt, &state.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get initial sync: %w", err)
+	}
+	return &state, nil
+}
+
+// GetInitialSyncs returns the initial sync checkpoints of every sync folder
+func (d *Database) GetInitialSyncs() ([]types.InitialSyncState, error) {
+	rows, err := d.db.Query(`
+	SELECT folder, remote_id, planned, total, completed, started_at, updated_at
+	FROM initial_syncs ORDER BY folder
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get initial syncs: %w", err)
+	}
+	defer rows.Close()
+
+	var states []types.InitialSyncState
+	for rows.Next() {
+		var state types.InitialSyncState
+		if err := rows.Scan(&state.Folder, &state.RemoteID, &state.Planned, &state.Total,
+			&state.Completed, &state.StartedAt, &state.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan initial sync row: %w", err)
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// StartInitialSync (re)starts the initial sync of a sync folder, discarding
+// any operations planned by an earlier attempt
+func (d *Database) StartInitialSync(folder, remoteID string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM initial_sync_ops WHERE folder = ?", folder); err != nil {
+		return fmt.Errorf("failed to clear initial sync plan: %w", err)
+	}
+	if _, err := tx.Exec(`
+	INSERT OR REPLACE INTO initial_syncs (folder, remote_id, planned, total, completed, started_at, updated_at)
+	VALUES (?, ?, FALSE, 0, 0, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, folder, remoteID); err != nil {
+		return fmt.Errorf("failed to start initial sync: %w", err)
+	}
+	return tx.Commit()
+}
+
+// AddInitialSyncOps records a batch of planned operations in one
+// transaction
+func (d *Database) AddInitialSyncOps(folder string, ops []types.InitialSyncOp) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+	INSERT INTO initial_sync_ops (folder, seq, type, path, remote_id, size, modified_time, is_directory)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare initial sync plan insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, op := range ops {
+		if _, err := stmt.Exec(folder, op.Seq, op.Type, op.Path, op.RemoteID, op.Size,
+			op.ModifiedTime, op.IsDirectory); err != nil {
+			return fmt.Errorf("failed to record planned operation %s: %w", op.Path, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// FinishInitialSyncPlan marks the plan of an initial sync as complete
+func (d *Database) FinishInitialSyncPlan(folder string, total int) error {
+	_, err := d.db.Exec(`
+	UPDATE initial_syncs SET planned = TRUE, total = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE folder = ?
+	`, total, folder)
+	if err != nil {
+		return fmt.Errorf("failed to finish initial sync plan: %w", err)
+	}
+	return nil
+}
+
+// GetInitialSyncOps returns up to limit planned operations starting at seq
+// from, in order
+func (d *Database) GetInitialSyncOps(folder string, from, limit int) ([]types.InitialSyncOp, error) {
+	rows, err := d.db.Query(`
+	SELECT seq, type, path, remote_id, size, modified_time, is_directory
+	FROM initial_sync_ops WHERE folder = ? AND seq >= ?
+	ORDER BY seq LIMIT ?
+	`, folder, from, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get initial sync plan: %w", err)
+	}
+	defer rows.Close()
+
+	var ops []types.InitialSyncOp
+	for rows.Next() {
+		var op types.InitialSyncOp
+		if err := rows.Scan(&op.Seq, &op.Type, &op.Path, &op.RemoteID, &op.Size,
+			&op.ModifiedTime, &op.IsDirectory); err != nil {
+			return nil, fmt.Errorf("failed to scan planned operation: %w", err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// SetInitialSyncCompleted records that the operations before seq completed
+// are done. Once all of them are, the plan itself is dropped.
+func (d *Database) SetInitialSyncCompleted(folder string, completed int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+	UPDATE initial_syncs SET completed = ?, updated_at = CURRENT_TIMESTAMP WHERE folder = ?
+	`, completed, folder); err != nil {
+		return fmt.Errorf("failed to checkpoint initial sync: %w", err)
+	}
+	if _, err := tx.Exec(`
+	DELETE FROM initial_sync_ops WHERE folder = ? AND EXISTS (
+		SELECT 1 FROM initial_syncs WHERE folder = ? AND planned AND completed >= total
+	)
+	`, folder, folder); err != nil {
+		return fmt.Errorf("failed to clear finished initial sync plan: %w", err)
+	}
+	return tx.Commit()
+}
+
+// initialSyncProgress sums up the initial syncs still running, or returns
+// nil if there are none
+func (d *Database) initialSyncProgress() (*types.InitialSyncProgress, error) {
+	states, err := d.GetInitialSyncs()
+	if err != nil {
+		return nil, err
+	}
+
+	var progress *types.InitialSyncProgress
+	for i := range states {
+		if states[i].Done() {
+			continue
+		}
+		if progress == nil {
+			progress = &types.InitialSyncProgress{}
+		}
+		progress.Completed += states[i].Completed
+		progress.Total += states[i].Total
+		progress.Planning = progress.Planning || !states[i].Planned
+	}
+	return progress, nil
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitialSyncProgress(t *testing.T) {
+	database, _ := newTestDatabase(t)
+
+	stats, err := database.GetSyncStats()
+	require.NoError(t, err)
+	assert.Nil(t, stats.InitialSync)
+
+	require.NoError(t, database.StartInitialSync("/sync/docs", "root"))
+	stats, err = database.GetSyncStats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.InitialSync)
+	assert.True(t, stats.InitialSync.Planning)
+
+	ops := make([]types.InitialSyncOp, 8)
+	for i := range ops {
+		ops[i] = types.InitialSyncOp{Seq: i, Type: "download", Path: "file"}
+	}
+	require.NoError(t, database.AddInitialSyncOps("/sync/docs", ops))
+	require.NoError(t, database.FinishInitialSyncPlan("/sync/docs", len(ops)))
+	require.NoError(t, database.SetInitialSyncCompleted("/sync/docs", 2))
+
+	stats, err = database.GetSyncStats()
+	require.NoError(t, err)
+	require.NotNil(t, stats.InitialSync)
+	assert.False(t, stats.InitialSync.Planning)
+	assert.Equal(t, 25, stats.InitialSync.Percent())
+
+	remaining, err := database.GetInitialSyncOps("/sync/docs", 2, 100)
+	require.NoError(t, err)
+	require.Len(t, remaining, 6)
+	assert.Equal(t, 2, remaining[0].Seq)
+
+	require.NoError(t, database.SetInitialSyncCompleted("/sync/docs", len(ops)))
+	stats, err = database.GetSyncStats()
+	require.NoError(t, err)
+	assert.Nil(t, stats.InitialSync)
+
+	remaining, err = database.GetInitialSyncOps("/sync/docs", 0, 100)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+}
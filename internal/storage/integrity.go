@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// salvageTables lists the tables whose rows are carried over when a
+// corrupted database has to be rebuilt, in foreign-key friendly order
+var salvageTables = []string{
+	"files", "sync_operations", "config", "auth_tokens", "conflicts", "initial_syncs", "initial_sync_ops",
+	"name_mappings", "conflict_policies", "sync_folders", "upload_sessions",
+}
+
+// verifyIntegrity runs an integrity check and attempts recovery on failure
+func (d *Database) verifyIntegrity() error {
+	err := d.checkIntegrity()
+	if err == nil {
+		return nil
+	}
+
+	d.logger.Warnf("Database integrity check failed: %v", err)
+	return d.recover()
+}
+
+// checkIntegrity runs PRAGMA integrity_check and reports any problems found
+func (d *Database) checkIntegrity() error {
+	rows, err := d.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// recover tries to repair a database that failed its integrity check, first
+// by checkpointing the WAL and, failing that, by backing up the damaged file
+// and rebuilding the schema with whatever rows can still be read
+func (d *Database) recover() error {
+	if _, err := d.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err == nil {
+		if err := d.checkIntegrity(); err == nil {
+			d.logger.Info("Database recovered by checkpointing the WAL")
+			return nil
+		}
+	}
+
+	backupPath := fmt.Sprintf("%s.corrupt-%s", d.path, time.Now().Format("20060102-150405"))
+	if err := d.Backup(backupPath); err != nil {
+		d.logger.Warnf("Online backup failed (%v), copying database file instead", err)
+		if err := copyFile(d.path, backupPath); err != nil {
+			return fmt.Errorf("failed to back up corrupted database: %w", err)
+		}
+	}
+	d.logger.Warnf("Backed up corrupted database to %s", backupPath)
+
+	salvaged := d.salvageRows()
+
+	d.db.Close()
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Remove(d.path + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove corrupted database: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", d.path+"?_journal=WAL&_timeout=10000")
+	if err != nil {
+		return fmt.Errorf("failed to reopen database: %w", err)
+	}
+	d.db = db
+
+	if err := d.initialize(); err != nil {
+		return err
+	}
+
+	restored := d.restoreRows(salvaged)
+	d.logger.Warnf("Rebuilt database schema and restored %d salvageable rows", restored)
+	return nil
+}
+
+// salvagedTable holds the readable rows of a single table
+type salvagedTable struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+// salvageRows reads as many rows as possible from every known table
+func (d *Database) salvageRows() map[string]*salvagedTable {
+	salvaged := make(map[string]*salvagedTable)
+
+	for _, table := range salvageTables {
+		// Table names come from the fixed salvageTables list
+		rows, err := d.db.Query("SELECT * FROM " + table)
+		if err != nil {
+			d.logger.Warnf("Could not read table %s: %v", table, err)
+			continue
+		}
+
+		columns, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			continue
+		}
+
+		result := &salvagedTable{columns: columns}
+		for rows.Next() {
+			values := make([]interface{}, len(columns))
+			pointers := make([]interface{}, len(columns))
+			for i := range values {
+				pointers[i] = &values[i]
+			}
+			if err := rows.Scan(pointers...); err != nil {
+				break
+			}
+			result.rows = append(result.rows, values)
+		}
+		if err := rows.Err(); err != nil {
+			d.logger.Warnf("Stopped salvaging %s after %d rows: %v", table, len(result.rows), err)
+		}
+		rows.Close()
+
+		salvaged[table] = result
+	}
+
+	return salvaged
+}
+
+// restoreRows inserts salvaged rows into the freshly created schema
+func (d *Database) restoreRows(salvaged map[string]*salvagedTable) int {
+	restored := 0
+
+	for _, table := range salvageTables {
+		data, ok := salvaged[table]
+		if !ok || len(data.rows) == 0 {
+			continue
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(data.columns)), ", ")
+		query := fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)",
+			table, strings.Join(data.columns, ", "), placeholders)
+
+		for _, row := range data.rows {
+			if _, err := d.db.Exec(query, row...); err != nil {
+				d.logger.Debugf("Skipped unrecoverable row in %s: %v", table, err)
+				continue
+			}
+			restored++
+		}
+	}
+
+	return restored
+}
+
+// Backup writes a consistent snapshot of the database to path
+func (d *Database) Backup(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup destination already exists: %s", path)
+	}
+
+	if _, err := d.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	d.logger.Infof("Database backed up to %s", path)
+	return nil
+}
+
+// copyFile copies src to dst byte for byte
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Sync()
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Config keys bounding the last sync cycle that did anything
+const (
+	lastCycleStartKey = "metrics.last_cycle_start"
+	lastCycleEndKey   = "metrics.last_cycle_end"
+)
+
+// operationTimeFormat is how sync_operations.started_at is stored
+const operationTimeFormat = "2006-01-02 15:04:05"
+
+// SaveSyncCycle records the bounds of a sync cycle, whose transfers become
+// the last-cycle metrics
+func (d *Database) SaveSyncCycle(start, end time.Time) error {
+	if err := d.SetConfigValue(lastCycleStartKey, start.UTC().Format(time.RFC3339Nano)); err != nil {
+		return err
+	}
+	return d.SetConfigValue(lastCycleEndKey, end.UTC().Format(time.RFC3339Nano))
+}
+
+// GetSyncMetrics sums up the transfers and failures in the sync history,
+// both overall and in the last sync cycle recorded by SaveSyncCycle
+func (d *Database) GetSyncMetrics() (*types.SyncMetrics, error) {
+	total, err := d.transferMetrics(time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	metrics := &types.SyncMetrics{Total: *total}
+
+	start, end, err := d.lastSyncCycle()
+	if err != nil || start.IsZero() {
+		return metrics, err
+	}
+	if metrics.LastCycle, err = d.transferMetrics(start, end); err != nil {
+		return nil, err
+	}
+	// A cycle's speed is what it achieved end to end
+	metrics.LastCycle.Since, metrics.LastCycle.Until = start, end
+	metrics.LastCycle.Elapsed = end.Sub(start)
+	return metrics, nil
+}
+
+// lastSyncCycle returns the bounds saved by SaveSyncCycle, or zero times
+func (d *Database) lastSyncCycle() (time.Time, time.Time, error) {
+	var bounds [2]time.Time
+	for i, key := range []string{lastCycleStartKey, lastCycleEndKey} {
+		value, err := d.GetConfigValue(key)
+		if err != nil || value == "" {
+			return time.Time{}, time.Time{}, nil
+		}
+		if bounds[i], err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// transferMetrics sums up the operations logged between start and end, or
+// all of them if start is zero. Entries are stamped to the second, so the
+// bounds are widened to whole seconds.
+func (d *Database) transferMetrics(start, end time.Time) (*types.TransferMetrics, error) {
+	where, args := "1", []interface{}{}
+	if !start.IsZero() {
+		where = "started_at >= ? AND started_at <= ?"
+		args = append(args,
+			start.UTC().Truncate(time.Second).Format(operationTimeFormat),
+			end.UTC().Add(time.Second-1).Truncate(time.Second).Format(operationTimeFormat))
+	}
+
+	metrics := &types.TransferMetrics{Errors: make(map[string]int)}
+	var elapsedMS int64
+	err := d.db.QueryRow(`
+	SELECT
+		COALESCE(SUM(CASE WHEN direction = 'up' THEN bytes END), 0),
+		COALESCE(SUM(CASE WHEN direction = 'down' THEN bytes END), 0),
+		COUNT(CASE WHEN direction = 'up' THEN 1 END),
+		COUNT(CASE WHEN direction = 'down' THEN 1 END),
+		COALESCE(SUM(duration_ms), 0)
+	FROM sync_operations WHERE status = 'success' AND `+where, args...).Scan(
+		&metrics.BytesUp, &metrics.BytesDown, &metrics.FilesUp, &metrics.FilesDown, &elapsedMS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum transfers: %w", err)
+	}
+	metrics.Elapsed = time.Duration(elapsedMS) * time.Millisecond
+
+	rows, err := d.db.Query(`
+	SELECT COALESCE(NULLIF(error_type, ''), 'unknown'), COUNT(*)
+	FROM sync_operations WHERE status = 'failed' AND `+where+`
+	GROUP BY 1`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count errors: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var errorType string
+		var count int
+		if err := rows.Scan(&errorType, &count); err != nil {
+			return nil, fmt.Errorf("failed to count errors: %w", err)
+		}
+		metrics.Errors[errorType] = count
+	}
+	return metrics, rows.Err()
+}
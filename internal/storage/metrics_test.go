@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMetricsSumTransfersAndErrors(t *testing.T) {
+	database, _ := newTestDatabase(t)
+
+	// An earlier cycle, which only counts towards the totals
+	_, err := database.db.Exec(`
+	INSERT INTO sync_operations (file_id, operation_type, status, direction, bytes, duration_ms, started_at)
+	VALUES ('old', 'sync', 'success', 'down', 4096, 1000, datetime('now', '-1 day'))
+	`)
+	require.NoError(t, err)
+	_, err = database.db.Exec(`
+	INSERT INTO sync_operations (file_id, operation_type, status, error_message, error_type, started_at)
+	VALUES ('old', 'sync', 'failed', 'refused', 'network', datetime('now', '-1 day'))
+	`)
+	require.NoError(t, err)
+
+	start := time.Now()
+	records := []types.OperationRecord{
+		{FileID: "a", Type: "sync", Status: "success", Direction: types.DirectionUpload, Bytes: 1000, Duration: time.Second},
+		{FileID: "b", Type: "sync", Status: "success", Direction: types.DirectionUpload, Bytes: 3000, Duration: time.Second},
+		{FileID: "c", Type: "sync", Status: "success", Direction: types.DirectionDownload, Bytes: 2000, Duration: time.Second},
+		{FileID: "d", Type: "sync", Status: "failed", Error: "timeout", ErrorType: "network"},
+		{FileID: "e", Type: "sync", Status: "failed", Error: "quota exceeded", ErrorType: "quota"},
+		{FileID: "f", Type: "rename", Status: "success"},
+	}
+	for _, record := range records {
+		require.NoError(t, database.LogOperation(record))
+	}
+	require.NoError(t, database.SaveSyncCycle(start, start.Add(2*time.Second)))
+
+	metrics, err := database.GetSyncMetrics()
+	require.NoError(t, err)
+
+	total := metrics.Total
+	assert.Equal(t, int64(4000), total.BytesUp)
+	assert.Equal(t, int64(6096), total.BytesDown)
+	assert.Equal(t, 2, total.FilesUp)
+	assert.Equal(t, 2, total.FilesDown)
+	assert.Equal(t, 4*time.Second, total.Elapsed)
+	assert.Equal(t, map[string]int{"network": 2, "quota": 1}, total.Errors)
+	assert.Equal(t, 3, total.ErrorCount())
+
+	last := metrics.LastCycle
+	require.NotNil(t, last)
+	assert.Equal(t, int64(4000), last.BytesUp)
+	assert.Equal(t, int64(2000), last.BytesDown)
+	assert.Equal(t, 2, last.FilesUp)
+	assert.Equal(t, 1, last.FilesDown)
+	assert.Equal(t, map[string]int{"network": 1, "quota": 1}, last.Errors)
+	assert.Equal(t, 2*time.Second, last.Elapsed)
+	assert.InDelta(t, 3000, last.BytesPerSecond(), 0.01)
+	assert.InDelta(t, 1.5, last.FilesPerSecond(), 0.01)
+}
+
+func TestSyncMetricsWithoutCycle(t *testing.T) {
+	database, _ := newTestDatabase(t)
+
+	metrics, err := database.GetSyncMetrics()
+	require.NoError(t, err)
+	assert.Nil(t, metrics.LastCycle)
+	assert.Zero(t, metrics.Total.Files())
+	assert.Zero(t, metrics.Total.BytesPerSecond())
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+)
+
+// columnMigration describes a column added to a table after its initial release
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnMigrations brings databases created by older versions up to date
+var columnMigrations = []columnMigration{
+	{"files", "mode", "INTEGER DEFAULT 0"},
+	{"conflicts", "copy_path", "TEXT DEFAULT ''"},
+	{"files", "attempts", "INTEGER DEFAULT 0"},
+	{"files", "last_error", "TEXT DEFAULT ''"},
+	{"sync_operations", "direction", "TEXT DEFAULT ''"},
+	{"sync_operations", "bytes", "INTEGER DEFAULT 0"},
+	{"sync_operations", "duration_ms", "INTEGER DEFAULT 0"},
+	{"sync_operations", "error_type", "TEXT DEFAULT ''"},
+	{"conflicts", "first_seen", "DATETIME"},
+	{"files", "remote_modified", "DATETIME"},
+	{"files", "synced_hash", "TEXT DEFAULT ''"},
+	{"files", "synced_size", "INTEGER DEFAULT 0"},
+}
+
+// migrate adds any columns missing from an older schema
+func (d *Database) migrate() error {
+	for _, m := range columnMigrations {
+		exists, err := d.hasColumn(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		// Identifiers come from the fixed columnMigrations list
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := d.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+		d.logger.Infof("Migrated database: added column %s.%s", m.table, m.column)
+	}
+	return nil
+}
+
+// hasColumn reports whether a table already has the named column
+func (d *Database) hasColumn(table, column string) (bool, error) {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    bool
+			defaultVal interface{}
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, fmt.Errorf("failed to read table info: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// MigrateHashes recomputes stored hashes that were written with a different
+// algorithm, recognised by their digest length. Only files whose size and
+// modification time still match their record are hashed again, so a stored
+// hash never takes on content that has not been synced; the hash of any
+// other file is cleared so it is recomputed when the file is next queued.
+// Files are hashed outside any transaction and each row is updated on its
+// own, leaving the database free for other writers meanwhile. It returns the
+// number of rows updated.
+func (d *Database) MigrateHashes(hashLength int, hashFile func(path string) (string, error)) (int, error) {
+	rows, err := d.db.Query(`
+	SELECT id, local_path, size, modified_time, hash, COALESCE(synced_hash, '')
+	FROM files
+	WHERE (hash != '' AND length(hash) != ?)
+		OR (COALESCE(synced_hash, '') != '' AND length(synced_hash) != ?)`,
+		hashLength, hashLength,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find stale hashes: %w", err)
+	}
+
+	type staleRow struct {
+		id         int
+		path       string
+		size       int64
+		modified   time.Time
+		hash       string
+		syncedHash string
+	}
+	var stale []staleRow
+	for rows.Next() {
+		var row staleRow
+		var modified sql.NullTime
+		if err := rows.Scan(&row.id, &row.path, &row.size, &modified, &row.hash, &row.syncedHash); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan file row: %w", err)
+		}
+		row.modified = modified.Time
+		stale = append(stale, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, row := range stale {
+		var current string
+		if info, err := os.Stat(row.path); err != nil {
+			d.logger.Debugf("Clearing hash for %s: %v", row.path, err)
+		} else if info.Size() != row.size || !info.ModTime().Equal(row.modified) {
+			d.logger.Debugf("Clearing hash for %s: changed since it was recorded", row.path)
+		} else if current, err = hashFile(row.path); err != nil {
+			d.logger.Debugf("Clearing hash for %s: %v", row.path, err)
+			current = ""
+		}
+
+		hash := row.hash
+		if len(hash) != hashLength {
+			hash = current
+		}
+		// The synced hash can only be carried over when it was the stored hash
+		syncedHash := row.syncedHash
+		if len(syncedHash) != hashLength {
+			syncedHash = ""
+			if row.syncedHash == row.hash {
+				syncedHash = current
+			}
+		}
+
+		// The row is left alone if it was rewritten while the file was hashed
+		result, err := d.db.Exec(
+			"UPDATE files SET hash = ?, synced_hash = ? WHERE id = ? AND hash = ? AND COALESCE(synced_hash, '') = ?",
+			hash, syncedHash, row.id, row.hash, row.syncedHash,
+		)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to update hash for %s: %w", row.path, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			migrated++
+		}
+	}
+
+	if migrated > 0 {
+		d.logger.Infof("Migrated %d file hashes", migrated)
+	}
+	return migrated, nil
+}
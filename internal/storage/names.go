@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+)
+
+// SaveNameMapping records the remote name used for a local path whose own
+// name could not be uploaded as is
+func (d *Database) SaveNameMapping(localPath, remoteName string) error {
+	_, err := d.db.Exec(`
+	INSERT OR REPLACE INTO name_mappings (local_path, parent_dir, remote_name)
+	VALUES (?, ?, ?)
+	`, localPath, filepath.Dir(localPath), remoteName)
+	if err != nil {
+		return fmt.Errorf("failed to save name mapping: %w", err)
+	}
+	return nil
+}
+
+// GetRemoteName returns the remote name recorded for a local path, or an
+// empty string if the path has no mapping
+func (d *Database) GetRemoteName(localPath string) (string, error) {
+	var remoteName string
+	err := d.db.QueryRow(`SELECT remote_name FROM name_mappings WHERE local_path = ?`, localPath).Scan(&remoteName)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get name mapping: %w", err)
+	}
+	return remoteName, nil
+}
+
+// GetMappedLocalPath returns the local path whose remote name in parentDir is
+// remoteName, or an empty string if there is none
+func (d *Database) GetMappedLocalPath(parentDir, remoteName string) (string, error) {
+	var localPath string
+	err := d.db.QueryRow(`
+	SELECT local_path FROM name_mappings WHERE parent_dir = ? AND remote_name = ?
+	`, parentDir, remoteName).Scan(&localPath)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get name mapping: %w", err)
+	}
+	return localPath, nil
+}
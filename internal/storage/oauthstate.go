@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SaveOAuthState stores the sealed PKCE verifier of a login in progress
+// until expiresAt, dropping any that have already expired
+func (d *Database) SaveOAuthState(stateHash string, sealedVerifier []byte, expiresAt time.Time) error {
+	if _, err := d.db.Exec("DELETE FROM oauth_states WHERE expires_at <= ?", time.Now()); err != nil {
+		return fmt.Errorf("failed to clear expired OAuth states: %w", err)
+	}
+	_, err := d.db.Exec(`
+	INSERT OR REPLACE INTO oauth_states (state_hash, sealed_verifier, expires_at)
+	VALUES (?, ?, ?)
+	`, stateHash, sealedVerifier, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save OAuth state: %w", err)
+	}
+	return nil
+}
+
+// TakeOAuthState returns the sealed PKCE verifier stored for a state and
+// deletes it, so each state is used once. It returns nil if there is none or
+// it expired before now.
+func (d *Database) TakeOAuthState(stateHash string, now time.Time) ([]byte, error) {
+	var (
+		sealed    []byte
+		expiresAt time.Time
+	)
+	err := d.db.QueryRow(`
+	SELECT sealed_verifier, expires_at FROM oauth_states WHERE state_hash = ?
+	`, stateHash).Scan(&sealed, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OAuth state: %w", err)
+	}
+
+	if _, err := d.db.Exec("DELETE FROM oauth_states WHERE state_hash = ?", stateHash); err != nil {
+		return nil, fmt.Errorf("failed to delete OAuth state: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return nil, nil
+	}
+	return sealed, nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// PruneOperations deletes the sync history entries other than failures
+// that are older than olderThan and returns how many were deleted
+func (d *Database) PruneOperations(olderThan time.Duration) (int, error) {
+	return d.pruneOperations("status != 'failed'", olderThan)
+}
+
+// PruneFailedOperations deletes the failed sync history entries older than
+// olderThan and returns how many were deleted. Failures are usually kept
+// longer than the rest of the history, since they are what gets looked into.
+func (d *Database) PruneFailedOperations(olderThan time.Duration) (int, error) {
+	return d.pruneOperations("status = 'failed'", olderThan)
+}
+
+// pruneOperations deletes the sync_operations rows matching where that
+// finished or, if they never did, started before the cutoff
+func (d *Database) pruneOperations(where string, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().UTC().Add(-olderThan).Format("2006-01-02 15:04:05")
+	result, err := d.db.Exec(`
+	DELETE FROM sync_operations
+	WHERE `+where+` AND COALESCE(completed_at, started_at) < ?
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune sync operations: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	return int(deleted), err
+}
+
+// Vacuum rebuilds the database file to return the space freed by deleted
+// rows to the filesystem
+func (d *Database) Vacuum() error {
+	if _, err := d.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// GetRecentOperations returns the most recent entries of the sync history
+// first. A limit of zero or less returns all of them.
+func (d *Database) GetRecentOperations(limit int) ([]types.SyncOperation, error) {
+	query := `
+	SELECT o.id, f.local_path, o.operation_type, o.status, o.error_message, o.started_at
+	FROM sync_operations o LEFT JOIN files f ON f.id = o.file_id
+	ORDER BY o.started_at DESC, o.id DESC`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sync operations: %w", err)
+	}
+	defer rows.Close()
+
+	var operations []types.SyncOperation
+	for rows.Next() {
+		var (
+			operation types.SyncOperation
+			path      sql.NullString
+			message   sql.NullString
+			startedAt sql.NullTime
+		)
+		if err := rows.Scan(&operation.ID, &path, &operation.Type, &operation.Status, &message, &startedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sync operation: %w", err)
+		}
+		operation.Path = path.String
+		operation.Error = message.String
+		operation.StartedAt = startedAt.Time
+		operations = append(operations, operation)
+	}
+	return operations, rows.Err()
+}
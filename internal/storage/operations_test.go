@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPruneOperationsKeepsRecentAndFailed(t *testing.T) {
+	database, _ := newTestDatabase(t)
+
+	// age is in days before now
+	rows := []struct {
+		status string
+		age    int
+	}{
+		{"success", 40}, {"skipped", 40}, {"success", 10}, {"started", 40},
+		{"failed", 40}, {"failed", 100},
+	}
+	for _, row := range rows {
+		_, err := database.db.Exec(`
+		INSERT INTO sync_operations (file_id, operation_type, status, started_at)
+		VALUES ('', 'sync', ?, datetime('now', ?))
+		`, row.status, fmt.Sprintf("-%d days", row.age))
+		require.NoError(t, err)
+	}
+	require.NoError(t, database.LogSyncOperation("", "sync", "success", ""))
+
+	deleted, err := database.PruneOperations(30 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted, "old successes, skips and stale starts are pruned")
+
+	deleted, err = database.PruneFailedOperations(90 * 24 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted, "failures are kept for their own, longer retention")
+
+	var statuses []string
+	result, err := database.db.Query("SELECT status FROM sync_operations ORDER BY id")
+	require.NoError(t, err)
+	defer result.Close()
+	for result.Next() {
+		var status string
+		require.NoError(t, result.Scan(&status))
+		statuses = append(statuses, status)
+	}
+	assert.Equal(t, []string{"success", "failed", "success"}, statuses)
+
+	require.NoError(t, database.Vacuum())
+}
+
+func TestGetRecentOperations(t *testing.T) {
+	database, _ := newTestDatabase(t)
+
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: "/sync/a.txt", SyncStatus: "synced"}))
+	metadata, err := database.GetFileMetadata("/sync/a.txt")
+	require.NoError(t, err)
+
+	_, err = database.db.Exec(`
+	INSERT INTO sync_operations (file_id, operation_type, status, started_at)
+	VALUES (?, 'sync', 'started', datetime('now', '-1 hours'))
+	`, metadata.ID)
+	require.NoError(t, err)
+	require.NoError(t, database.LogSyncOperation(metadata.ID, "sync", "failed", "timeout"))
+	require.NoError(t, database.LogSyncOperation("999", "delete", "success", ""))
+
+	operations, err := database.GetRecentOperations(0)
+	require.NoError(t, err)
+	require.Len(t, operations, 3)
+	assert.Equal(t, "delete", operations[0].Type)
+	assert.Empty(t, operations[0].Path, "untracked files have no path")
+	assert.Equal(t, "/sync/a.txt", operations[1].Path)
+	assert.Equal(t, "failed", operations[1].Status)
+	assert.Equal(t, "timeout", operations[1].Error)
+	assert.Equal(t, "started", operations[2].Status)
+	assert.False(t, operations[2].StartedAt.IsZero())
+
+	operations, err = database.GetRecentOperations(1)
+	require.NoError(t, err)
+	assert.Len(t, operations, 1)
+}
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SetConflictPolicy pins a conflict policy on a local path. An empty policy
+// removes the one pinned on it.
+func (d *Database) SetConflictPolicy(localPath, policy string) error {
+	var err error
+	if policy == "" {
+		_, err = d.db.Exec("DELETE FROM conflict_policies WHERE local_path = ?", localPath)
+	} else {
+		_, err = d.db.Exec(`
+		INSERT OR REPLACE INTO conflict_policies (local_path, policy) VALUES (?, ?)
+		`, localPath, policy)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save conflict policy: %w", err)
+	}
+	return nil
+}
+
+// GetConflictPolicy returns the conflict policy that applies to a local
+// path: the one pinned on the path itself, or else on its nearest parent
+// folder. It returns an empty string if there is none.
+func (d *Database) GetConflictPolicy(localPath string) (string, error) {
+	var paths []string
+	for path := filepath.Clean(localPath); ; path = filepath.Dir(path) {
+		paths = append(paths, path)
+		if filepath.Dir(path) == path {
+			break
+		}
+	}
+
+	args := make([]interface{}, len(paths))
+	for i, path := range paths {
+		args[i] = path
+	}
+	query := fmt.Sprintf(`
+	SELECT policy FROM conflict_policies WHERE local_path IN (%s)
+	ORDER BY length(local_path) DESC LIMIT 1
+	`, strings.TrimSuffix(strings.Repeat("?,", len(paths)), ","))
+
+	var policy string
+	err := d.db.QueryRow(query, args...).Scan(&policy)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get conflict policy: %w", err)
+	}
+	return policy, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SaveUploadSession records an initiated upload, replacing any earlier
+// session for the same path
+func (d *Database) SaveUploadSession(session *types.UploadSession) error {
+	_, err := d.db.Exec(`
+	INSERT OR REPLACE INTO upload_sessions (upload_id, local_path, size, hash, upload_url, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`, session.UploadID, session.Path, session.Size, session.Hash, session.UploadURL, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to save upload session: %w", err)
+	}
+	return nil
+}
+
+// GetUploadSession returns the pending upload session for a path, or nil
+// if there is none
+func (d *Database) GetUploadSession(localPath string) (*types.UploadSession, error) {
+	row := d.db.QueryRow(`
+	SELECT upload_id, local_path, size, hash, upload_url, expires_at
+	FROM upload_sessions WHERE local_path = ?
+	`, localPath)
+
+	session, err := scanUploadSession(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload session: %w", err)
+	}
+	return session, nil
+}
+
+// GetExpiredUploadSessions returns sessions that expired before now
+func (d *Database) GetExpiredUploadSessions(now time.Time) ([]types.UploadSession, error) {
+	rows, err := d.db.Query(`
+	SELECT upload_id, local_path, size, hash, upload_url, expires_at
+	FROM upload_sessions WHERE expires_at < ?
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query upload sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []types.UploadSession
+	for rows.Next() {
+		session, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan upload session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteUploadSession forgets a completed or abandoned upload session
+func (d *Database) DeleteUploadSession(uploadID string) error {
+	if _, err := d.db.Exec("DELETE FROM upload_sessions WHERE upload_id = ?", uploadID); err != nil {
+		return fmt.Errorf("failed to delete upload session: %w", err)
+	}
+	return nil
+}
+
+// scanUploadSession reads one upload_sessions row
+func scanUploadSession(row interface{ Scan(...interface{}) error }) (*types.UploadSession, error) {
+	var (
+		session   types.UploadSession
+		hash      sql.NullString
+		uploadURL sql.NullString
+		expiresAt sql.NullTime
+	)
+	if err := row.Scan(&session.UploadID, &session.Path, &session.Size, &hash, &uploadURL, &expiresAt); err != nil {
+		return nil, err
+	}
+	session.Hash = hash.String
+	session.UploadURL = uploadURL.String
+	session.ExpiresAt = expiresAt.Time
+	return &session, nil
+}
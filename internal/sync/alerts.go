@@ -0,0 +1,110 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/notify"
+)
+
+// DefaultFailureThreshold is how many consecutive failures of a file are
+// tolerated before the user is notified
+const DefaultFailureThreshold = 3
+
+// notifyTimeout bounds how long a single notification may take to deliver
+const notifyTimeout = 30 * time.Second
+
+// alerter turns sync errors into user notifications
+type alerter struct {
+	mu       sync.Mutex
+	notifier notify.Notifier
+	failures map[string]int
+}
+
+// newAlerter creates an alerter with no notifier configured
+func newAlerter() *alerter {
+	return &alerter{failures: make(map[string]int)}
+}
+
+// SetNotifier sets where alerts about repeated failures, quota problems and
+// expired logins are sent. A nil notifier disables alerts.
+func (e *Engine) SetNotifier(n notify.Notifier) {
+	e.alerts.mu.Lock()
+	defer e.alerts.mu.Unlock()
+	e.alerts.notifier = n
+}
+
+// failureThreshold returns the configured number of failures before alerting
+func (e *Engine) failureThreshold() int {
+	if e.config.Notifications.FailureThreshold > 0 {
+		return e.config.Notifications.FailureThreshold
+	}
+	return DefaultFailureThreshold
+}
+
+// alert records a sync error and notifies the user if it warrants attention
+func (e *Engine) alert(syncErr *SyncError) {
+	a := e.alerts
+	key := filepath.Clean(syncErr.FilePath)
+
+	a.mu.Lock()
+	a.failures[key]++
+	count := a.failures[key]
+	n := a.notifier
+	a.mu.Unlock()
+
+	if n == nil {
+		return
+	}
+
+	var event notify.Event
+	switch {
+	case syncErr.Type == ErrorTypeAuth:
+		event = notify.Event{
+			Kind:    notify.KindReauthRequired,
+			Title:   "Login required",
+			Message: "ZohoSync could not authenticate with WorkDrive. Run 'zohosync-cli login' to sign in again.",
+		}
+	case syncErr.Type == ErrorTypeQuota, syncErr.Type == ErrorTypeStorageFull:
+		event = notify.Event{
+			Kind:    notify.KindQuotaWarning,
+			Title:   "Storage quota or rate limit reached",
+			Message: syncErr.Message,
+			Path:    syncErr.FilePath,
+		}
+	case count == e.failureThreshold():
+		event = notify.Event{
+			Kind:    notify.KindSyncFailure,
+			Title:   "Sync failing",
+			Message: fmt.Sprintf("Failed to sync %d times in a row: %s", count, syncErr.Message),
+			Path:    syncErr.FilePath,
+		}
+	default:
+		return
+	}
+	e.sendAlert(n, event)
+}
+
+// sendAlert delivers an event in the background
+func (e *Engine) sendAlert(n notify.Notifier, event notify.Event) {
+	event.Time = time.Now()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := n.Notify(ctx, event); err != nil {
+			e.logger.Warnf("Failed to send %s notification: %v", event.Kind, err)
+		}
+	}()
+}
+
+// clearFailures resets the failure count of a file after it syncs
+func (e *Engine) clearFailures(path string) {
+	a := e.alerts
+	a.mu.Lock()
+	delete(a.failures, filepath.Clean(path))
+	a.mu.Unlock()
+}
@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/notify"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// recordingNotifier collects the events it is sent
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, event notify.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingNotifier) kinds() []notify.Kind {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kinds := make([]notify.Kind, len(r.events))
+	for i, event := range r.events {
+		kinds[i] = event.Kind
+	}
+	return kinds
+}
+
+func TestAlertAfterRepeatedFailures(t *testing.T) {
+	config := &types.Config{Notifications: types.NotificationsConfig{FailureThreshold: 2}}
+	engine, _ := newTestEngine(t, config, nil)
+	recorder := &recordingNotifier{}
+	engine.SetNotifier(recorder)
+
+	engine.reportError("sync", "/tmp/a.txt", errors.New("boom"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, recorder.kinds(), "a single failure should not alert")
+
+	engine.reportError("sync", "/tmp/a.txt", errors.New("boom"))
+	require.Eventually(t, func() bool { return len(recorder.kinds()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, notify.KindSyncFailure, recorder.kinds()[0])
+	assert.Equal(t, "/tmp/a.txt", recorder.events[0].Path)
+
+	// A success resets the count
+	engine.clearFailures("/tmp/a.txt")
+	engine.reportError("sync", "/tmp/a.txt", errors.New("boom"))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, recorder.kinds(), 1)
+}
+
+func TestAlertOnAuthAndQuotaErrors(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, nil)
+	recorder := &recordingNotifier{}
+	engine.SetNotifier(recorder)
+
+	engine.reportError("sync", "/tmp/a.txt", &api.StatusError{StatusCode: 401})
+	require.Eventually(t, func() bool { return len(recorder.kinds()) == 1 }, time.Second, 10*time.Millisecond)
+	engine.reportError("sync", "/tmp/b.txt", &api.StatusError{StatusCode: 429})
+	require.Eventually(t, func() bool { return len(recorder.kinds()) == 2 }, time.Second, 10*time.Millisecond)
+
+	assert.ElementsMatch(t, []notify.Kind{notify.KindReauthRequired, notify.KindQuotaWarning}, recorder.kinds())
+}
@@ -0,0 +1,53 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"sort"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// bufferFileWrite records a file create or write and (re)arms the flush
+// timer
+func (e *Engine) bufferFileWrite(ctx context.Context, event fsnotify.Event) {
+	c := e.coalescer
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.written[event.Name] |= event.Op
+	e.rearmFlush(ctx)
+}
+
+// resolveAtomicSaves recognises editors' atomic saves among the buffered
+// file events and returns the files that changed, in order.
+//
+// Editors such as vim and emacs save by writing a temp file and renaming it
+// over the original, or by renaming the original aside and writing a new
+// one. Either way the file that was saved still exists once the events
+// settle and becomes a single change, whatever was removed or renamed at
+// its path on the way. A file that was created and is already gone again
+// is an intermediate artifact, such as the temp file renamed onto the
+// target or vim's 4913 write test, and is dropped along with its removal.
+// The entries resolved here are deleted from removed, leaving genuine
+// removals and renames, including files written just before they went.
+func resolveAtomicSaves(removed, written map[string]fsnotify.Op, exists func(path string) bool) []string {
+	var changed []string
+	for path, op := range written {
+		switch {
+		case exists(path):
+			changed = append(changed, path)
+			delete(removed, path)
+		case op&fsnotify.Create == fsnotify.Create:
+			delete(removed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// pathExists reports whether anything exists at path
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
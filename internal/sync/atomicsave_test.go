@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// vimSave saves path the way vim does with its default settings, replaying
+// each step's events to the engine as they happen on disk
+func vimSave(t *testing.T, engine *Engine, path, content string) {
+	t.Helper()
+	ctx := context.Background()
+	dir := filepath.Dir(path)
+	probe := filepath.Join(dir, "4913")
+	backup := path + "~"
+
+	steps := []struct {
+		do     func() error
+		events []fsnotify.Event
+	}{
+		// Check the directory is writable with a throwaway file
+		{func() error { return os.WriteFile(probe, nil, 0644) }, []fsnotify.Event{{Name: probe, Op: fsnotify.Create}}},
+		{func() error { return os.Remove(probe) }, []fsnotify.Event{{Name: probe, Op: fsnotify.Chmod}, {Name: probe, Op: fsnotify.Remove}}},
+		// Move the original aside as a backup
+		{func() error { return os.Rename(path, backup) }, []fsnotify.Event{{Name: path, Op: fsnotify.Rename}, {Name: backup, Op: fsnotify.Create}}},
+		// Write the new content and drop the backup
+		{func() error { return os.WriteFile(path, []byte(content), 0644) }, []fsnotify.Event{{Name: path, Op: fsnotify.Create}, {Name: path, Op: fsnotify.Write}}},
+		{func() error { return os.Remove(backup) }, []fsnotify.Event{{Name: path, Op: fsnotify.Chmod}, {Name: backup, Op: fsnotify.Remove}}},
+	}
+	for _, step := range steps {
+		require.NoError(t, step.do())
+		for _, event := range step.events {
+			engine.handleFileEvent(ctx, event)
+		}
+	}
+}
+
+func TestVimSaveBecomesOneChange(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, http.NotFound)
+	engine.coalesceWindow = time.Hour
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("draft"), 0644))
+
+	vimSave(t, engine, path, "final")
+
+	removed, created, written := engine.coalescer.drain()
+	assert.Equal(t, []string{path}, resolveAtomicSaves(removed, written, pathExists))
+	assert.Empty(t, removed, "no removal is left over")
+	assert.Empty(t, created)
+}
+
+func TestTempFileRenamedOverTarget(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "report.txt")
+	temp := filepath.Join(dir, "report.txt.3f9a2c")
+	require.NoError(t, os.WriteFile(target, []byte("final"), 0644))
+
+	// The temp file was written, then renamed onto the target
+	removed := map[string]fsnotify.Op{temp: fsnotify.Rename}
+	written := map[string]fsnotify.Op{temp: fsnotify.Create | fsnotify.Write, target: fsnotify.Create}
+
+	assert.Equal(t, []string{target}, resolveAtomicSaves(removed, written, pathExists))
+	assert.Empty(t, removed)
+}
+
+func TestWrittenThenDeletedFileIsStillRemoved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gone.txt")
+	removed := map[string]fsnotify.Op{path: fsnotify.Remove}
+	written := map[string]fsnotify.Op{path: fsnotify.Write}
+
+	assert.Empty(t, resolveAtomicSaves(removed, written, pathExists))
+	assert.Equal(t, fsnotify.Remove, removed[path])
+}
+
+func TestAtomicSaveQueuesOnlyTheSavedFile(t *testing.T) {
+	engine, database := newTestEngine(t, nil, http.NotFound)
+	engine.coalesceWindow = time.Hour
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("draft"), 0644))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, RemoteID: "file1", SyncStatus: "synced"}))
+
+	vimSave(t, engine, path, "final")
+	engine.flushDirectoryChanges(context.Background())
+
+	require.Eventually(t, func() bool {
+		saved, err := database.GetFileMetadata(path)
+		return err == nil && saved != nil && saved.SyncStatus == "pending"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	probe, err := database.GetFileMetadata(filepath.Join(dir, "4913"))
+	require.NoError(t, err)
+	assert.Nil(t, probe, "the write probe is never queued")
+}
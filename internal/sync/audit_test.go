@@ -0,0 +1,96 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hashingBackend adds the sha256 hashes WorkDrive reports to the listings of
+// a local backend rooted at dir
+type hashingBackend struct {
+	api.RemoteBackend
+	dir string
+}
+
+func (b *hashingBackend) ListFilesPage(ctx context.Context, folderID string, offset, limit int) ([]api.FileInfo, error) {
+	page, err := b.RemoteBackend.ListFilesPage(ctx, folderID, offset, limit)
+	for i := range page {
+		if page[i].IsFolder {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(b.dir, filepath.FromSlash(page[i].ID)))
+		if err != nil {
+			return nil, err
+		}
+		page[i].Hash, page[i].HashAlgorithm = fmt.Sprintf("%x", sha256.Sum256(data)), "sha256"
+	}
+	return page, err
+}
+
+func TestAuditFlagsContentMismatch(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	engine.backend = &hashingBackend{RemoteBackend: engine.backend, dir: remoteDir}
+	local := t.TempDir()
+	remote := filepath.Join(remoteDir, "Docs")
+	folder := types.FolderConfig{Local: local, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}
+	synced := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	writeFileAt(t, filepath.Join(local, "same.txt"), "same", synced)
+	writeFileAt(t, filepath.Join(remote, "same.txt"), "same", synced)
+	writeFileAt(t, filepath.Join(local, "notes", "minutes.txt"), "minutes", synced)
+	writeFileAt(t, filepath.Join(remote, "notes", "minutes.txt"), "minutes", synced)
+
+	// Same size and modification time, so only the hash tells them apart;
+	// the local hash is the one recorded at the last sync
+	writeFileAt(t, filepath.Join(local, "report.txt"), "figures: 42", synced)
+	writeFileAt(t, filepath.Join(remote, "report.txt"), "figures: 24", synced)
+	localHash := fmt.Sprintf("%x", sha256.Sum256([]byte("figures: 42")))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: filepath.Join(local, "report.txt"), RemoteID: "/Docs/report.txt", Size: 11,
+		ModifiedTime: synced, Hash: localHash, SyncStatus: "synced",
+	}))
+
+	audit, err := engine.Audit(context.Background(), folder)
+	require.NoError(t, err)
+	require.Len(t, audit.Entries, 1)
+	entry := audit.Entries[0]
+	assert.Equal(t, AuditHashMismatch, entry.Category)
+	assert.Equal(t, "report.txt", entry.Path)
+	assert.Equal(t, localHash, entry.LocalHash)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("figures: 24"))), entry.RemoteHash)
+	assert.Equal(t, 3, audit.Checked)
+	assert.Zero(t, audit.Unverified)
+
+	// Missing files and sizes that differ are flagged too
+	writeFileAt(t, filepath.Join(local, "draft.txt"), "draft", synced)
+	writeFileAt(t, filepath.Join(remote, "notes", "minutes.txt"), "minutes, amended", synced)
+	audit, err = engine.Audit(context.Background(), folder)
+	require.NoError(t, err)
+	categories := make(map[string]AuditCategory)
+	for _, entry := range audit.Entries {
+		categories[entry.Path] = entry.Category
+	}
+	assert.Equal(t, map[string]AuditCategory{
+		"draft.txt":         AuditOnlyLocal,
+		"notes/minutes.txt": AuditSizeMismatch,
+		"report.txt":        AuditHashMismatch,
+	}, categories)
+
+	// Auditing is read-only
+	content, err := os.ReadFile(filepath.Join(remote, "report.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "figures: 24", string(content))
+	record, err := database.GetFileMetadata(filepath.Join(local, "draft.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+var (
+	// ErrBootstrapNotEmpty is returned when a folder to bootstrap already
+	// has local content, which a regular sync would reconcile instead
+	ErrBootstrapNotEmpty = errors.New("local folder is not empty")
+
+	// ErrBootstrapSynced is returned when a folder to bootstrap was synced
+	// before. An empty folder that was synced means its files were deleted,
+	// which must not be undone by pulling them back.
+	ErrBootstrapSynced = errors.New("local folder was synced before")
+)
+
+// Bootstrap sets up a sync folder on a new machine from its remote copy. The
+// local folder must be empty and never synced; the whole remote tree is
+// then downloaded and recorded as synced, so later syncs only transfer what
+// changes. An interrupted bootstrap resumes where it stopped.
+func (e *Engine) Bootstrap(ctx context.Context, folder types.FolderConfig) (*SyncResult, error) {
+	if !ParseSyncStrategy(folder.SyncMode).AllowsDownload() {
+		return nil, fmt.Errorf("%s is %s and cannot be bootstrapped from the remote copy", folder.Local, folder.SyncMode)
+	}
+
+	state, err := e.database.GetInitialSync(folder.Local)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case state != nil && state.Done():
+		return nil, fmt.Errorf("%s: %w", folder.Local, ErrBootstrapSynced)
+	case state != nil && state.Planned:
+		// An earlier bootstrap was cut short; its plan only downloads
+		return e.InitialSync(ctx, folder)
+	}
+
+	tracked, err := e.database.GetFilesUnder(folder.Local)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracked) > 0 {
+		return nil, fmt.Errorf("%s: %w (use resync --direction down to restore deleted files)", folder.Local, ErrBootstrapSynced)
+	}
+	if empty, err := e.isLocalFolderEmpty(folder.Local); err != nil {
+		return nil, err
+	} else if !empty {
+		return nil, fmt.Errorf("%s: %w", folder.Local, ErrBootstrapNotEmpty)
+	}
+
+	// A missing remote folder is an error rather than something to create:
+	// there is nothing to bootstrap from
+	if _, err := e.resolveRemoteFolder(ctx, folder.Remote); err != nil {
+		return nil, fmt.Errorf("failed to find remote folder %s: %w", folder.Remote, err)
+	}
+
+	e.logger.Infof("Bootstrapping %s from %s", folder.Local, folder.Remote)
+	return e.InitialSync(ctx, folder)
+}
+
+// isLocalFolderEmpty reports whether dir is missing or holds nothing but
+// ignored files
+func (e *Engine) isLocalFolderEmpty(dir string) (bool, error) {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return true, nil
+	}
+
+	_, err := newLocalIterator(dir, e.shouldIgnoreFile).Next()
+	if err == io.EOF {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+	return false, nil
+}
@@ -0,0 +1,77 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapPullsRemoteTreeIntoEmptyFolder(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	remoteFiles := map[string]string{
+		"Documents/notes.txt":         "notes",
+		"Documents/reports/q1.csv":    "q1",
+		"Documents/reports/empty.txt": "",
+	}
+	for name, content := range remoteFiles {
+		path := filepath.Join(remoteDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	local := filepath.Join(t.TempDir(), "Documents")
+	folder := types.FolderConfig{Local: local, Remote: "/Documents", SyncMode: "bidirectional", Enabled: true}
+	engine.syncFolders = []types.FolderConfig{folder}
+
+	result, err := engine.Bootstrap(ctx, folder)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Downloaded)
+	assert.Zero(t, result.Uploaded)
+	assert.Zero(t, result.Failed)
+
+	for name, content := range remoteFiles {
+		data, err := os.ReadFile(filepath.Join(local, filepath.FromSlash(name[len("Documents/"):])))
+		require.NoError(t, err, name)
+		assert.Equal(t, content, string(data), name)
+	}
+
+	// The next sync has nothing to do and does not redo the initial sync
+	changes, err := engine.PlanSince(time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	pending, err := database.GetPendingFiles()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	state, err := database.GetInitialSync(local)
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.True(t, state.Done())
+
+	diff, err := engine.Diff(ctx, folder)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Entries)
+
+	// Emptying the folder afterwards is a deletion, not a new machine
+	require.NoError(t, os.RemoveAll(local))
+	_, err = engine.Bootstrap(ctx, folder)
+	assert.ErrorIs(t, err, ErrBootstrapSynced)
+}
+
+func TestBootstrapRefusesFolderWithContent(t *testing.T) {
+	engine, _, remoteDir := newLocalTestEngine(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(remoteDir, "Documents"), 0755))
+
+	local := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(local, "draft.txt"), []byte("draft"), 0644))
+
+	_, err := engine.Bootstrap(context.Background(), types.FolderConfig{Local: local, Remote: "/Documents", SyncMode: "bidirectional", Enabled: true})
+	assert.ErrorIs(t, err, ErrBootstrapNotEmpty)
+}
@@ -0,0 +1,179 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	gosync "sync"
+
+	"github.com/bdstest/zohosync/internal/api"
+)
+
+// DefaultChunkWorkers bounds how many chunks of one file upload at once
+// when the config does not say
+const DefaultChunkWorkers = 4
+
+// UploadProgressFunc is called as the bytes of an upload are sent. A
+// retried upload or chunk counts its bytes again, so sent can go back.
+type UploadProgressFunc func(path string, sent, total int64)
+
+// SetUploadProgressFunc registers a callback for the progress of uploads
+func (e *Engine) SetUploadProgressFunc(fn UploadProgressFunc) {
+	e.mu.Lock()
+	e.uploadProgress = fn
+	e.mu.Unlock()
+}
+
+// sendUpload sends a file's content into its upload session. Files larger
+// than one chunk are split into ranges that upload concurrently when the
+// backend supports it; otherwise the content is streamed in one request.
+func (e *Engine) sendUpload(ctx context.Context, content *uploadContent, session *api.FileUploadInfo, size int64) (*api.FileInfo, error) {
+	uploader, ok := e.backend.(api.ChunkedUploader)
+	if chunkSize := e.chunkSizeFor(content.path); !ok || chunkSize <= 0 || size <= chunkSize {
+		return e.sendUploadContent(ctx, content, session, size)
+	}
+
+	remote, err := e.sendUploadChunks(ctx, uploader, content, session, size, e.chunkWorkerCount())
+	if errors.Is(err, api.ErrChunkOutOfOrder) {
+		e.logger.Infof("Server requires chunks of %s in order, uploading them sequentially", content.path)
+		remote, err = e.sendUploadChunks(ctx, uploader, content, session, size, 1)
+	}
+	if errors.Is(err, api.ErrChunkedUploadUnsupported) {
+		e.logger.Infof("Server does not accept chunks, uploading %s in one request", content.path)
+		return e.sendUploadContent(ctx, content, session, size)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload content: %w", err)
+	}
+
+	if err := e.database.DeleteUploadSession(session.UploadID); err != nil {
+		e.logger.Warnf("Failed to clear upload session %s: %v", session.UploadID, err)
+	}
+	return remote, nil
+}
+
+// chunkWorkerCount returns how many chunks of a file may upload at once
+func (e *Engine) chunkWorkerCount() int {
+	if e.chunkWorkers <= 0 {
+		return DefaultChunkWorkers
+	}
+	return e.chunkWorkers
+}
+
+// sendUploadChunks uploads a file as ranges of its folder's chunk size, at
+// most workers at a time and started in order, then commits the session
+// once all of them have arrived. The first failure cancels the chunks still
+// in flight.
+func (e *Engine) sendUploadChunks(ctx context.Context, uploader api.ChunkedUploader, content *uploadContent, session *api.FileUploadInfo, size int64, workers int) (*api.FileInfo, error) {
+	chunkSize := e.chunkSizeFor(content.path)
+	chunks := int((size + chunkSize - 1) / chunkSize)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       gosync.WaitGroup
+		errOnce  gosync.Once
+		firstErr error
+		progress = &chunkProgress{sent: make([]int64, chunks)}
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	slots := make(chan struct{}, workers)
+	for index := 0; index < chunks; index++ {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		offset := int64(index) * chunkSize
+		length := chunkSize
+		if offset+length > size {
+			length = size - offset
+		}
+
+		wg.Add(1)
+		go func(index int, offset, length int64) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			err := e.withRetry(ctx, "upload", func() error {
+				source, file, err := content.open(size)
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+
+				section := io.NewSectionReader(source, offset, length)
+				chunkCtx := api.WithProgress(ctx, func(sent, _ int64) {
+					progress.report(e, content.path, index, sent, size)
+				})
+				return uploader.UploadChunk(chunkCtx, session, index, offset, section, length, size)
+			})
+			if err != nil {
+				fail(err)
+				return
+			}
+			// Backends that do not report progress are covered here
+			progress.report(e, content.path, index, length, size)
+		}(index, offset, length)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var remote *api.FileInfo
+	err := e.withRetry(ctx, "upload", func() error {
+		var err error
+		remote, err = uploader.CommitUpload(ctx, session, chunks)
+		return err
+	})
+	return remote, err
+}
+
+// chunkProgress adds up the bytes sent of the chunks of an upload
+type chunkProgress struct {
+	mu   gosync.Mutex
+	sent []int64
+	sum  int64
+}
+
+// report records the bytes sent of a chunk and passes the bytes sent of the
+// whole upload on if they changed. Reports are passed on one at a time, so
+// chunks in flight at once cannot pass each other's on out of order.
+func (p *chunkProgress) report(e *Engine, path string, index int, sent, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if sent == p.sent[index] {
+		return
+	}
+	p.sum += sent - p.sent[index]
+	p.sent[index] = sent
+	e.reportUploadProgress(path, p.sum, total)
+}
+
+// reportUploadProgress invokes the registered upload progress callback, if
+// any
+func (e *Engine) reportUploadProgress(path string, sent, total int64) {
+	e.mu.RLock()
+	fn := e.uploadProgress
+	e.mu.RUnlock()
+
+	if fn != nil {
+		fn(path, sent, total)
+	}
+}
@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chunkServer is a mock upload endpoint that accepts chunks and assembles
+// them on commit
+type chunkServer struct {
+	mu        gosync.Mutex
+	chunks    map[int][]byte
+	inFlight  int
+	maxFlight int
+	committed []byte
+	// inOrder rejects a chunk that arrives before its predecessors
+	inOrder  bool
+	rejected int
+}
+
+func newChunkServer() *chunkServer {
+	return &chunkServer{chunks: make(map[int][]byte)}
+}
+
+func (s *chunkServer) handle(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/upload/initiate":
+		w.Write([]byte(`{"data":{"upload_id":"big"}}`))
+	case strings.HasPrefix(r.URL.Path, "/upload/big/chunks/"):
+		index, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/upload/big/chunks/"))
+
+		s.mu.Lock()
+		if s.inOrder && index > len(s.chunks) {
+			s.rejected++
+			s.mu.Unlock()
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.inFlight++
+		if s.inFlight > s.maxFlight {
+			s.maxFlight = s.inFlight
+		}
+		s.mu.Unlock()
+
+		data, _ := io.ReadAll(r.Body)
+		time.Sleep(20 * time.Millisecond)
+
+		s.mu.Lock()
+		s.inFlight--
+		s.chunks[index] = data
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.URL.Path == "/upload/big/commit":
+		s.mu.Lock()
+		var assembled bytes.Buffer
+		for i := 0; i < len(s.chunks); i++ {
+			assembled.Write(s.chunks[i])
+		}
+		s.committed = assembled.Bytes()
+		s.mu.Unlock()
+		w.Write([]byte(`{"data":{"id":"remote-big"}}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// writeRandomFile creates a file of size random bytes
+func writeRandomFile(t *testing.T, size int) (string, []byte) {
+	t.Helper()
+	content := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	path := filepath.Join(t.TempDir(), "video.mkv")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	return path, content
+}
+
+func TestLargeFileUploadsChunksConcurrently(t *testing.T) {
+	server := newChunkServer()
+	engine, _ := newTestEngine(t, nil, server.handle)
+	engine.chunkSize = 1000
+	engine.chunkWorkers = 3
+
+	var (
+		mu       gosync.Mutex
+		progress []int64
+	)
+	engine.SetUploadProgressFunc(func(path string, sent, total int64) {
+		mu.Lock()
+		progress = append(progress, sent)
+		mu.Unlock()
+	})
+
+	path, content := writeRandomFile(t, 5500)
+	metadata := &types.FileMetadata{Path: path}
+	require.NoError(t, engine.uploadFile(context.Background(), metadata))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Len(t, server.chunks, 6)
+	assert.Equal(t, len(content), len(server.committed))
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content)), fmt.Sprintf("%x", sha256.Sum256(server.committed)))
+	assert.Greater(t, server.maxFlight, 1, "chunks upload concurrently")
+	assert.LessOrEqual(t, server.maxFlight, 3, "no more than the configured chunks are in flight")
+	assert.Equal(t, "remote-big", metadata.RemoteID)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, progress, 6)
+	assert.Equal(t, int64(5500), progress[len(progress)-1])
+}
+
+func TestChunksFallBackToSequentialOrder(t *testing.T) {
+	server := newChunkServer()
+	server.inOrder = true
+	engine, _ := newTestEngine(t, nil, server.handle)
+	engine.chunkSize = 1000
+	engine.chunkWorkers = 4
+
+	path, content := writeRandomFile(t, 4000)
+	require.NoError(t, engine.uploadFile(context.Background(), &types.FileMetadata{Path: path}))
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, content, server.committed)
+	assert.Greater(t, server.rejected, 0, "concurrent chunks were rejected before falling back")
+}
+
+func TestChunkedUploadFallsBackToSingleRequest(t *testing.T) {
+	var streamed []byte
+	engine, _ := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"big"}}`))
+		case "/upload/big":
+			streamed, _ = io.ReadAll(r.Body)
+			w.Write([]byte(`{"data":{"id":"remote-big"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	engine.chunkSize = 1000
+
+	path, content := writeRandomFile(t, 2500)
+	require.NoError(t, engine.uploadFile(context.Background(), &types.FileMetadata{Path: path}))
+	assert.Equal(t, content, streamed)
+}
@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultSlowTransferThreshold is the duration above which a successful
+// transfer no longer counts towards raising concurrency
+const DefaultSlowTransferThreshold = 10 * time.Second
+
+// maxConcurrentSyncs returns the most transfers the config lets run at
+// once, the same default as the config file's if it is not set
+func maxConcurrentSyncs(cfg *types.Config) int {
+	if cfg.Sync.MaxConcurrentSyncs > 0 {
+		return cfg.Sync.MaxConcurrentSyncs
+	}
+	return config.DefaultMaxConcurrentSyncs
+}
+
+// ConcurrencyController adapts the number of concurrent transfers using
+// additive-increase/multiplicative-decrease (AIMD). Rate limiting and
+// timeouts halve the limit, while a full window of fast successes raises
+// it by one, never exceeding the configured maximum or dropping below one.
+type ConcurrencyController struct {
+	mu            sync.Mutex
+	limit         int
+	max           int
+	inFlight      int
+	successes     int
+	slowThreshold time.Duration
+	changed       chan struct{}
+}
+
+// NewConcurrencyController creates a controller bounded by max
+func NewConcurrencyController(max int) *ConcurrencyController {
+	if max < 1 {
+		max = 1
+	}
+	return &ConcurrencyController{
+		limit:         max,
+		max:           max,
+		slowThreshold: DefaultSlowTransferThreshold,
+		changed:       make(chan struct{}),
+	}
+}
+
+// Limit returns the current effective concurrency
+func (c *ConcurrencyController) Limit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.limit
+}
+
+// InFlight returns the number of transfers currently holding a slot
+func (c *ConcurrencyController) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.inFlight
+}
+
+// Acquire blocks until a transfer slot is available or ctx is done
+func (c *ConcurrencyController) Acquire(ctx context.Context) error {
+	for {
+		c.mu.Lock()
+		if c.inFlight < c.limit {
+			c.inFlight++
+			c.mu.Unlock()
+			return nil
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees a transfer slot and feeds the outcome into the controller
+func (c *ConcurrencyController) Release(duration time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inFlight > 0 {
+		c.inFlight--
+	}
+	c.record(duration, err)
+	c.notify()
+}
+
+// record adjusts the limit based on a single transfer outcome
+func (c *ConcurrencyController) record(duration time.Duration, err error) {
+	if err != nil {
+		if isBackpressure(err) {
+			c.limit /= 2
+			if c.limit < 1 {
+				c.limit = 1
+			}
+			c.successes = 0
+		}
+		return
+	}
+
+	if duration > c.slowThreshold {
+		return
+	}
+
+	c.successes++
+	if c.successes >= c.limit {
+		c.successes = 0
+		if c.limit < c.max {
+			c.limit++
+		}
+	}
+}
+
+// notify wakes up any goroutines waiting in Acquire
+func (c *ConcurrencyController) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// isBackpressure reports whether an error signals the server is overloaded
+func isBackpressure(err error) bool {
+	syncErr := ClassifyError("transfer", err)
+	return syncErr.Type == ErrorTypeQuota || syncErr.Type == ErrorTypeTimeout
+}
@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyControllerAIMD(t *testing.T) {
+	controller := NewConcurrencyController(8)
+	assert.Equal(t, 8, controller.Limit())
+
+	rateLimited := &api.StatusError{Operation: "upload initiation", StatusCode: http.StatusTooManyRequests}
+
+	// Each rate-limit error halves concurrency down to the floor of one
+	controller.Release(time.Millisecond, rateLimited)
+	assert.Equal(t, 4, controller.Limit())
+	controller.Release(time.Millisecond, rateLimited)
+	controller.Release(time.Millisecond, context.DeadlineExceeded)
+	assert.Equal(t, 1, controller.Limit())
+	controller.Release(time.Millisecond, rateLimited)
+	assert.Equal(t, 1, controller.Limit())
+
+	// Fast successes raise it additively, bounded by the maximum
+	for i := 0; i < 100; i++ {
+		controller.Release(time.Millisecond, nil)
+	}
+	assert.Equal(t, 8, controller.Limit())
+
+	// Slow successes and non-backpressure errors leave it unchanged
+	controller.Release(time.Millisecond, rateLimited)
+	controller.Release(time.Minute, nil)
+	controller.Release(time.Millisecond, &api.StatusError{Operation: "delete", StatusCode: http.StatusNotFound})
+	assert.Equal(t, 4, controller.Limit())
+}
+
+func TestConcurrencyControllerAcquire(t *testing.T) {
+	controller := NewConcurrencyController(2)
+	ctx := context.Background()
+
+	require.NoError(t, controller.Acquire(ctx))
+	require.NoError(t, controller.Acquire(ctx))
+	assert.Equal(t, 2, controller.InFlight())
+
+	// A third acquire blocks until a slot is released
+	acquired := make(chan error, 1)
+	go func() {
+		acquired <- controller.Acquire(ctx)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("acquire should block while at the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	controller.Release(time.Millisecond, nil)
+	require.NoError(t, <-acquired)
+
+	// Cancelled contexts stop waiting
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, controller.Acquire(cancelled), context.Canceled)
+}
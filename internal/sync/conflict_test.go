@@ -0,0 +1,281 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadRejectedByChangedRemoteIsResolvedAgain(t *testing.T) {
+	var (
+		mu       gosync.Mutex
+		infos    int
+		ifMatch  []string
+		uploaded bool
+	)
+	dir := t.TempDir()
+	config := &types.Config{
+		Sync:    types.SyncConfig{ConflictResolution: "newer"},
+		Folders: []types.FolderConfig{{Local: dir, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}},
+	}
+	engine, _ := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/files/r1":
+			infos++
+			if infos == 1 {
+				// Older than the local file when the sync is planned
+				w.Write([]byte(`{"data":{"id":"r1","name":"doc.txt","size":3,"modified_time":"2000-01-01T00:00:00Z","version":"v1"}}`))
+				return
+			}
+			// Edited remotely while the upload was being prepared
+			w.Write([]byte(`{"data":{"id":"r1","name":"doc.txt","size":10,"modified_time":"2100-01-01T00:00:00Z","version":"v2"}}`))
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			ifMatch = append(ifMatch, r.Header.Get("If-Match"))
+			if r.Header.Get("If-Match") != "v2" {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			uploaded = true
+			w.Write([]byte(`{"data":{"id":"r1"}}`))
+		case "/files/r1/download":
+			w.Write([]byte("remote-new"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	engine.remoteRoots.set(dir, "docs")
+
+	path := filepath.Join(dir, "doc.txt")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now()))
+
+	metadata := &types.FileMetadata{Path: path, RemoteID: "r1", Size: 3, SyncStatus: "pending"}
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"v1"}, ifMatch, "the upload expects the version seen at plan time")
+	assert.False(t, uploaded, "the newer remote content must not be overwritten")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "remote-new", string(data), "the re-resolved conflict downloads the newer remote file")
+	assert.Equal(t, "synced", metadata.SyncStatus)
+}
+
+func TestEqualModTimesResolveByContent(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		local          string
+		remoteSize     int
+		recordedSize   int64
+		localUnchanged bool
+		baseUnknown    bool
+		wantContent    string
+		wantUpload     bool
+		wantCopy       bool
+	}{
+		{name: "local unchanged", local: "base", remoteSize: 11, recordedSize: 4, localUnchanged: true, wantContent: "remote-edit"},
+		{name: "only local size changed", local: "local edit", remoteSize: 4, recordedSize: 4, wantContent: "local edit", wantUpload: true},
+		{name: "local edited in place, remote size changed", local: "edit", remoteSize: 11, recordedSize: 4, wantContent: "remote-edit", wantCopy: true},
+		{name: "both changed", local: "local edit", remoteSize: 11, recordedSize: 4, wantContent: "remote-edit", wantCopy: true},
+		{name: "last sync unknown", local: "local edit", remoteSize: 4, recordedSize: 4, baseUnknown: true, wantContent: "remote-edit", wantCopy: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       gosync.Mutex
+				uploaded bool
+			)
+			dir := t.TempDir()
+			config := &types.Config{
+				Sync:    types.SyncConfig{ConflictResolution: "newer"},
+				Folders: []types.FolderConfig{{Local: dir, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}},
+			}
+			engine, _ := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				switch r.URL.Path {
+				case "/files/r1":
+					// Sub-second precision is lost remotely
+					fmt.Fprintf(w, `{"data":{"id":"r1","name":"doc.txt","size":%d,"modified_time":"2024-03-01T12:00:00Z","version":"v2"}}`, tt.remoteSize)
+				case "/upload/initiate":
+					w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+				case "/upload/upload1":
+					uploaded = true
+					w.Write([]byte(`{"data":{"id":"r1"}}`))
+				case "/files/r1/download":
+					w.Write([]byte("remote-edit"))
+				default:
+					http.NotFound(w, r)
+				}
+			})
+			engine.remoteRoots.set(dir, "docs")
+
+			path := filepath.Join(dir, "doc.txt")
+			require.NoError(t, os.WriteFile(path, []byte(tt.local), 0644))
+			localModified := modified.Add(400 * time.Millisecond)
+			require.NoError(t, os.Chtimes(path, localModified, localModified))
+
+			metadata := &types.FileMetadata{Path: path, RemoteID: "r1", SyncedSize: tt.recordedSize, SyncedHash: "recorded-before-the-edit"}
+			if tt.localUnchanged {
+				hash, err := engine.calculateFileHash(path)
+				require.NoError(t, err)
+				metadata.SyncedHash = hash
+			}
+			if tt.baseUnknown {
+				metadata.SyncedHash = ""
+			}
+			require.NoError(t, engine.resolveConflict(context.Background(), metadata))
+
+			mu.Lock()
+			assert.Equal(t, tt.wantUpload, uploaded)
+			mu.Unlock()
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantContent, string(data))
+
+			copies, err := filepath.Glob(filepath.Join(dir, "doc_conflict_*.txt"))
+			require.NoError(t, err)
+			if tt.wantCopy {
+				require.Len(t, copies, 1)
+				kept, err := os.ReadFile(copies[0])
+				require.NoError(t, err)
+				assert.Equal(t, tt.local, string(kept), "the local edit is kept")
+			} else {
+				assert.Empty(t, copies)
+			}
+		})
+	}
+}
+
+func TestQueuedEditReplacesTheRemoteVersionItSaw(t *testing.T) {
+	var (
+		mu       gosync.Mutex
+		ifMatch  []string
+		initiate int
+	)
+	dir := t.TempDir()
+	config := &types.Config{
+		Sync:    types.SyncConfig{ConflictResolution: "newer"},
+		Folders: []types.FolderConfig{{Local: dir, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}},
+	}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/files/r1":
+			w.Write([]byte(`{"data":{"id":"r1","name":"doc.txt","size":3,"modified_time":"2000-01-01T00:00:00Z","version":"v1"}}`))
+		case "/upload/initiate":
+			initiate++
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			ifMatch = append(ifMatch, r.Header.Get("If-Match"))
+			w.Write([]byte(`{"data":{"id":"r1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	engine.remoteRoots.set(dir, "docs")
+
+	path := filepath.Join(dir, "doc.txt")
+	synced := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeFileAt(t, path, "old", synced)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: path, RemoteID: "r1", Size: 3, ModifiedTime: synced, SyncStatus: "synced",
+	}))
+
+	// An event for a file that has not changed queues nothing
+	engine.queueFileForSync(path, fsnotify.Write)
+	record, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", record.SyncStatus)
+
+	// An edit seen by the watcher keeps the file's remote mapping
+	require.NoError(t, os.WriteFile(path, []byte("edited"), 0644))
+	engine.queueFileForSync(path, fsnotify.Write)
+	record, err = database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", record.SyncStatus)
+	assert.Equal(t, "r1", record.RemoteID)
+
+	engine.performSync(context.Background())
+
+	mu.Lock()
+	assert.Equal(t, []string{"v1"}, ifMatch, "the edit replaces the remote version it was resolved against")
+	assert.Equal(t, 1, initiate)
+	mu.Unlock()
+	record, err = database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", record.SyncStatus)
+	assert.Equal(t, "r1", record.RemoteID)
+}
+
+func TestQueuedEditIsKeptWhenModTimesTie(t *testing.T) {
+	var mu gosync.Mutex
+	dir := t.TempDir()
+	config := &types.Config{
+		Sync:    types.SyncConfig{ConflictResolution: "newer"},
+		Folders: []types.FolderConfig{{Local: dir, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}},
+	}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/files/r1":
+			w.Write([]byte(`{"data":{"id":"r1","name":"doc.txt","size":11,"modified_time":"2024-03-01T12:00:00Z","version":"v2"}}`))
+		case "/files/r1/download":
+			w.Write([]byte("remote-edit"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	engine.remoteRoots.set(dir, "docs")
+
+	path := filepath.Join(dir, "doc.txt")
+	writeFileAt(t, path, "base", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: path, RemoteID: "r1", Size: 4, Hash: hash, SyncStatus: "synced",
+	}))
+
+	// Edited in place within the second the remote file was changed
+	writeFileAt(t, path, "mine", time.Date(2024, 3, 1, 12, 0, 0, 400000000, time.UTC))
+	engine.queueFileForSync(path, fsnotify.Write)
+	record, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, hash, record.SyncedHash, "queueing keeps the state of the last sync")
+
+	engine.performSync(context.Background())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "remote-edit", string(data))
+	copies, err := filepath.Glob(filepath.Join(dir, "doc_conflict_*.txt"))
+	require.NoError(t, err)
+	require.Len(t, copies, 1)
+	kept, err := os.ReadFile(copies[0])
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(kept), "the local edit is kept")
+}
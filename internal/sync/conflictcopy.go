@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// ConflictKeepBoth is the conflict_resolution strategy that keeps the remote
+// copy in place and the local one as a conflict copy
+const ConflictKeepBoth = "keep_both"
+
+// Where sync.conflict_mode puts conflict copies
+const (
+	// ConflictModeInline keeps a conflict copy next to the file
+	ConflictModeInline = "inline"
+	// ConflictModeQuarantine moves conflict copies under
+	// ConflictQuarantineDir at the root of the sync folder, mirroring the
+	// path of the original file
+	ConflictModeQuarantine = "quarantine"
+)
+
+// ConflictQuarantineDir is the folder conflict copies are moved to in
+// quarantine mode. Nothing inside it is synced.
+const ConflictQuarantineDir = ".zohosync-conflicts"
+
+// resolveKeepBoth keeps both sides of a conflict: the local file is moved
+// aside as a conflict copy and the remote file downloaded in its place. The
+// copy's path is recorded in the conflict.
+func (e *Engine) resolveKeepBoth(ctx context.Context, metadata *types.FileMetadata, conflict *types.ConflictInfo) error {
+	copyPath := e.conflictCopyPath(metadata.Path, time.Now())
+	if err := os.MkdirAll(filepath.Dir(copyPath), 0755); err != nil {
+		return fmt.Errorf("failed to create conflict folder: %w", err)
+	}
+	if err := os.Rename(metadata.Path, copyPath); err != nil {
+		return fmt.Errorf("failed to keep conflict copy: %w", err)
+	}
+
+	if err := e.downloadFile(ctx, metadata); err != nil {
+		// Put the local copy back rather than leave the file missing
+		if _, statErr := os.Lstat(metadata.Path); os.IsNotExist(statErr) {
+			os.Rename(copyPath, metadata.Path)
+		}
+		return err
+	}
+
+	conflict.CopyPath = copyPath
+	e.logger.Infof("Kept local copy of %s as %s", metadata.Path, copyPath)
+	return nil
+}
+
+// conflictCopyPath returns where the local copy of a conflicting file is
+// kept: "report.txt" becomes "report_conflict_20060102-150405.txt", either
+// beside the file or, in quarantine mode, at the same relative path under
+// the quarantine folder of its sync folder
+func (e *Engine) conflictCopyPath(path string, now time.Time) string {
+	ext := filepath.Ext(path)
+	name := fmt.Sprintf("%s_conflict_%s%s",
+		strings.TrimSuffix(filepath.Base(path), ext), now.Format("20060102-150405"), ext)
+	dir := filepath.Dir(path)
+
+	if e.config.Sync.ConflictMode == ConflictModeQuarantine {
+		if folder := e.folderForPath(path); folder != nil {
+			root := filepath.Clean(folder.Local)
+			if rel, err := filepath.Rel(root, dir); err == nil {
+				dir = filepath.Join(root, ConflictQuarantineDir, rel)
+			}
+		}
+	}
+	return filepath.Join(dir, name)
+}
+
+// isQuarantined reports whether path lies inside a conflict quarantine folder
+func isQuarantined(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ConflictQuarantineDir {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeepBothConflictCopy(t *testing.T) {
+	for _, mode := range []string{ConflictModeInline, ConflictModeQuarantine} {
+		t.Run(mode, func(t *testing.T) {
+			engine, database, remoteDir := newLocalTestEngine(t)
+			localDir := t.TempDir()
+			engine.config.Sync.ConflictResolution = ConflictKeepBoth
+			engine.config.Sync.ConflictMode = mode
+			engine.syncFolders = []types.FolderConfig{{Local: localDir, Remote: "/", Enabled: true}}
+
+			require.NoError(t, os.MkdirAll(filepath.Join(remoteDir, "docs"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "docs", "report.txt"), []byte("remote edit"), 0644))
+			path := filepath.Join(localDir, "docs", "report.txt")
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+			require.NoError(t, os.WriteFile(path, []byte("local edit"), 0644))
+
+			metadata := &types.FileMetadata{Path: path, RemoteID: "/docs/report.txt", SyncStatus: "pending"}
+			require.NoError(t, engine.resolveConflict(context.Background(), metadata))
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "remote edit", string(data))
+
+			conflicts, err := database.GetConflicts(false, 0)
+			require.NoError(t, err)
+			require.Len(t, conflicts, 1)
+			copyPath := conflicts[0].CopyPath
+			assert.Equal(t, types.ConflictWinnerRemote, conflicts[0].Winner)
+
+			data, err = os.ReadFile(copyPath)
+			require.NoError(t, err)
+			assert.Equal(t, "local edit", string(data))
+
+			wantDir := filepath.Join(localDir, "docs")
+			if mode == ConflictModeQuarantine {
+				wantDir = filepath.Join(localDir, ConflictQuarantineDir, "docs")
+			}
+			assert.Equal(t, wantDir, filepath.Dir(copyPath))
+			name := filepath.Base(copyPath)
+			assert.True(t, strings.HasPrefix(name, "report_conflict_") && strings.HasSuffix(name, ".txt"), name)
+
+			// Quarantined copies are never synced; inline ones are
+			assert.Equal(t, mode == ConflictModeQuarantine, engine.shouldIgnoreFile(copyPath))
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package sync
+
+import (
+	"os"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// newConflictInfo describes both sides of a conflict before it is resolved.
+// The base hash is the one recorded at the last successful sync, before
+// either side changed. The strategy is the one for the path, taking a pinned policy
+// into account.
+func (e *Engine) newConflictInfo(metadata *types.FileMetadata, localInfo os.FileInfo, remoteInfo *api.FileInfo) *types.ConflictInfo {
+	conflict := &types.ConflictInfo{
+		Path:           metadata.Path,
+		Strategy:       e.conflictStrategy(metadata.Path),
+		DetectedAt:     e.clock.Now(),
+		BaseHash:       metadata.SyncedHash,
+		LocalSize:      localInfo.Size(),
+		RemoteSize:     e.plaintextSize(remoteInfo.Size),
+		LocalModified:  localInfo.ModTime(),
+		RemoteModified: remoteInfo.ModifiedTime,
+	}
+	if hash, err := e.calculateFileHash(metadata.Path); err == nil {
+		conflict.LocalHash = hash
+	} else {
+		e.logger.Warnf("Failed to hash %s for the conflict log: %v", metadata.Path, err)
+	}
+	return conflict
+}
+
+// recordConflict adds a conflict to the persistent log read by the
+// conflicts command
+func (e *Engine) recordConflict(conflict *types.ConflictInfo) {
+	e.tallies.update(func(result *SyncResult) { result.Conflicts++ })
+	if err := e.database.SaveConflict(conflict); err != nil {
+		e.logger.Warnf("Failed to record conflict for %s: %v", conflict.Path, err)
+		return
+	}
+	if conflict.Resolved() {
+		e.logger.Infof("Resolved conflict for %s in favour of the %s copy (%s)", conflict.Path, conflict.Winner, conflict.Strategy)
+	} else {
+		e.logger.Warnf("Conflict for %s needs manual resolution", conflict.Path)
+	}
+}
@@ -0,0 +1,135 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conflictingFile creates a file with different content on both sides,
+// with the remote copy modified at remoteTime
+func conflictingFile(t *testing.T, remoteDir string, remoteTime time.Time) *types.FileMetadata {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "plan.txt")
+	require.NoError(t, os.WriteFile(path, []byte("local edit"), 0644))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now()))
+
+	remotePath := filepath.Join(remoteDir, "plan.txt")
+	require.NoError(t, os.WriteFile(remotePath, []byte("remote edit, longer"), 0644))
+	require.NoError(t, os.Chtimes(remotePath, remoteTime, remoteTime))
+
+	return &types.FileMetadata{Path: path, RemoteID: "/plan.txt", SyncedHash: "base", SyncStatus: "pending"}
+}
+
+func TestNewestConflictIsLoggedWithWinningSide(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	engine.config.Sync.ConflictResolution = "newer"
+
+	metadata := conflictingFile(t, remoteDir, time.Now().Add(time.Hour))
+	localHash, err := engine.calculateFileHash(metadata.Path)
+	require.NoError(t, err)
+
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	conflicts, err := database.GetConflicts(true, 0)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+
+	conflict := conflicts[0]
+	assert.Equal(t, metadata.Path, conflict.Path)
+	assert.Equal(t, types.ConflictWinnerRemote, conflict.Winner)
+	assert.True(t, conflict.AutoResolved)
+	assert.Equal(t, "newer", conflict.Strategy)
+	assert.Equal(t, "base", conflict.BaseHash)
+	assert.Equal(t, localHash, conflict.LocalHash)
+	assert.Equal(t, int64(len("local edit")), conflict.LocalSize)
+	assert.Equal(t, int64(len("remote edit, longer")), conflict.RemoteSize)
+	assert.True(t, conflict.RemoteModified.After(conflict.LocalModified))
+
+	downloadedHash, err := engine.calculateFileHash(metadata.Path)
+	require.NoError(t, err)
+	assert.Equal(t, downloadedHash, conflict.RemoteHash, "the remote hash is taken from the downloaded copy")
+	assert.NotEqual(t, conflict.LocalHash, conflict.RemoteHash)
+}
+
+func TestUnresolvedConflictIsLoggedOnce(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	engine.config.Sync.ConflictResolution = "manual"
+
+	metadata := conflictingFile(t, remoteDir, time.Now().Add(-time.Hour))
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	all, err := database.GetConflicts(false, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 1, "a conflict seen again by later syncs keeps one entry")
+	assert.False(t, all[0].Resolved())
+	assert.False(t, all[0].AutoResolved)
+
+	resolved, err := database.GetConflicts(true, 0)
+	require.NoError(t, err)
+	assert.Empty(t, resolved)
+
+	// Once a strategy is configured the same entry records the outcome
+	engine.config.Sync.ConflictResolution = "local"
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	all, err = database.GetConflicts(false, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, types.ConflictWinnerLocal, all[0].Winner)
+
+	remote, err := os.ReadFile(filepath.Join(remoteDir, "plan.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "local edit", string(remote))
+}
+
+func TestManualConflictFallsBackAfterTimeout(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	fake := clock.NewFake(time.Now())
+	engine.clock = fake
+	engine.config.Sync.ConflictResolution = "manual"
+	engine.config.Sync.ManualConflictTimeout = 3600
+	engine.config.Sync.ManualConflictFallback = ConflictKeepBoth
+
+	metadata := conflictingFile(t, remoteDir, time.Now().Add(-time.Hour))
+	ctx := context.Background()
+	require.NoError(t, engine.syncFile(ctx, metadata))
+	fake.Advance(30 * time.Minute)
+	require.NoError(t, engine.syncFile(ctx, metadata))
+
+	conflicts, err := database.GetConflicts(false, 0)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.False(t, conflicts[0].Resolved(), "the conflict still waits for the user")
+	firstSeen := conflicts[0].FirstSeen
+	assert.Equal(t, "conflict", metadata.SyncStatus)
+
+	// Past the timeout the next sync resolves it on its own
+	fake.Advance(31 * time.Minute)
+	require.NoError(t, engine.syncFile(ctx, metadata))
+
+	conflicts, err = database.GetConflicts(false, 0)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	conflict := conflicts[0]
+	assert.True(t, conflict.AutoResolved)
+	assert.Equal(t, ConflictKeepBoth, conflict.Strategy)
+	assert.Equal(t, types.ConflictWinnerRemote, conflict.Winner)
+	assert.True(t, conflict.FirstSeen.Equal(firstSeen), "the first-seen time is kept")
+
+	data, err := os.ReadFile(metadata.Path)
+	require.NoError(t, err)
+	assert.Equal(t, "remote edit, longer", string(data))
+	data, err = os.ReadFile(conflict.CopyPath)
+	require.NoError(t, err)
+	assert.Equal(t, "local edit", string(data))
+}
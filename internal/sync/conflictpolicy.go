@@ -0,0 +1,87 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Conflict policies pinned on a path with set-policy. They override
+// sync.conflict_resolution for the path, or for everything under it if it
+// is a folder.
+const (
+	ConflictPolicyPreferLocal  = "prefer-local"
+	ConflictPolicyPreferRemote = "prefer-remote"
+	ConflictPolicyManual       = "manual"
+	// ConflictPolicyDefault removes a pinned policy, leaving the path to
+	// the global strategy
+	ConflictPolicyDefault = "default"
+)
+
+// ParseConflictPolicy checks a policy given to set-policy. The default
+// policy is returned as an empty string.
+func ParseConflictPolicy(s string) (string, error) {
+	switch policy := strings.ToLower(strings.TrimSpace(s)); policy {
+	case ConflictPolicyPreferLocal, ConflictPolicyPreferRemote, ConflictPolicyManual:
+		return policy, nil
+	case ConflictPolicyDefault:
+		return "", nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q (expected %s, %s, %s or %s)", s,
+			ConflictPolicyPreferLocal, ConflictPolicyPreferRemote, ConflictPolicyManual, ConflictPolicyDefault)
+	}
+}
+
+// conflictStrategy returns the conflict_resolution strategy for a path: the
+// one its pinned policy stands for, if it has one, or else the global one
+func (e *Engine) conflictStrategy(path string) string {
+	policy, err := e.database.GetConflictPolicy(path)
+	if err != nil {
+		e.logger.Warnf("Failed to look up the conflict policy of %s, using %s: %v", path, e.config.Sync.ConflictResolution, err)
+	}
+	switch policy {
+	case ConflictPolicyPreferLocal:
+		return "local"
+	case ConflictPolicyPreferRemote:
+		return "remote"
+	case ConflictPolicyManual:
+		return "manual"
+	default:
+		return e.config.Sync.ConflictResolution
+	}
+}
+
+// manualConflictExpired reports whether a conflict left for manual
+// resolution has waited longer than sync.manual_conflict_timeout, and if so
+// returns the strategy that resolves it instead
+func (e *Engine) manualConflictExpired(conflict *types.ConflictInfo) (string, bool) {
+	timeout := time.Duration(e.config.Sync.ManualConflictTimeout) * time.Second
+	if timeout <= 0 {
+		return "", false
+	}
+	switch conflict.Strategy {
+	case "newer", "local", "remote", ConflictKeepBoth:
+		return "", false
+	}
+
+	firstSeen, err := e.database.ConflictFirstSeen(conflict.Path)
+	if err != nil {
+		e.logger.Warnf("Failed to look up when the conflict for %s was first seen: %v", conflict.Path, err)
+		return "", false
+	}
+	if firstSeen.IsZero() || e.clock.Now().Sub(firstSeen) < timeout {
+		return "", false
+	}
+
+	fallback := e.config.Sync.ManualConflictFallback
+	switch fallback {
+	case "newer", "local", "remote", ConflictKeepBoth:
+	default:
+		fallback = ConflictKeepBoth
+	}
+	e.logger.Warnf("Conflict for %s unresolved since %s, resolving it automatically (%s)",
+		conflict.Path, firstSeen.Format(time.RFC3339), fallback)
+	return fallback, true
+}
@@ -0,0 +1,50 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinnedConflictPolicyOverridesGlobalStrategy(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	engine.config.Sync.ConflictResolution = "newer"
+
+	// The remote copy is newer, so the global strategy would download it
+	metadata := conflictingFile(t, remoteDir, time.Now().Add(time.Hour))
+	policy, err := ParseConflictPolicy("prefer-local")
+	require.NoError(t, err)
+	require.NoError(t, database.SetConflictPolicy(filepath.Dir(metadata.Path), policy))
+
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	conflicts, err := database.GetConflicts(true, 0)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, types.ConflictWinnerLocal, conflicts[0].Winner)
+	assert.Equal(t, "local", conflicts[0].Strategy)
+
+	remote, err := os.ReadFile(filepath.Join(remoteDir, "plan.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "local edit", string(remote), "the policy on the parent folder applies to the file")
+
+	// A policy on the file itself wins over its folder's
+	require.NoError(t, database.SetConflictPolicy(metadata.Path, ConflictPolicyManual))
+	assert.Equal(t, "manual", engine.conflictStrategy(metadata.Path))
+
+	// Going back to the default leaves the path to the global strategy
+	policy, err = ParseConflictPolicy("default")
+	require.NoError(t, err)
+	require.NoError(t, database.SetConflictPolicy(metadata.Path, policy))
+	require.NoError(t, database.SetConflictPolicy(filepath.Dir(metadata.Path), policy))
+	assert.Equal(t, "newer", engine.conflictStrategy(metadata.Path))
+
+	_, err = ParseConflictPolicy("prefer-newest")
+	assert.Error(t, err)
+}
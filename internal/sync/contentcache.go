@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/contentcache"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// contentCacheDir is where the content cache is kept, one directory per
+// hash algorithm
+func contentCacheDir(algorithm string) string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "zohosync", "cache", "content", algorithm)
+}
+
+// lazyContentCache opens the content cache the first time it is needed
+type lazyContentCache struct {
+	once  sync.Once
+	cache *contentcache.Cache
+}
+
+// contentCache returns the content cache, or nil if sync.content_cache_size
+// leaves it off or it cannot be opened
+func (e *Engine) contentCache() *contentcache.Cache {
+	e.contents.once.Do(func() {
+		size := int64(e.config.Sync.ContentCacheSize) << 20
+		if size <= 0 {
+			return
+		}
+		cache, err := contentcache.New(contentCacheDir(e.hashAlgorithm()), size)
+		if err != nil {
+			e.logger.Warnf("Content cache disabled: %v", err)
+			return
+		}
+		e.contents.cache = cache
+	})
+	return e.contents.cache
+}
+
+// cacheContent adds a file that was just transferred to the content cache
+func (e *Engine) cacheContent(hash, path string) {
+	cache := e.contentCache()
+	if cache == nil || hash == "" {
+		return
+	}
+	if err := cache.Add(hash, path); err != nil {
+		e.logger.Warnf("Failed to cache the content of %s: %v", path, err)
+	}
+}
+
+// copyLocalContent fills tempPath with the content of a remote file from a
+// copy already on this machine, the content cache or another synced file,
+// instead of downloading it. Synced files are looked at even with the cache
+// off. Only files whose hash the server reports can be matched, and what is
+// copied is checked against that hash. It returns
+// the hash of the content copied, or "" if there was no usable copy.
+func (e *Engine) copyLocalContent(metadata *types.FileMetadata, remoteInfo *api.FileInfo, tempPath string) string {
+	if api.IsNativeDocument(remoteInfo.Type) {
+		return ""
+	}
+	hash := strings.ToLower(e.comparableRemoteHash(remoteInfo))
+	if hash == "" {
+		return ""
+	}
+
+	if cache := e.contentCache(); cache != nil {
+		if source, err := cache.Open(hash); err == nil {
+			copied := e.copyVerified(source, tempPath, hash)
+			source.Close()
+			if copied {
+				e.logger.Infof("Copied %s from the content cache", metadata.Path)
+				return hash
+			}
+			// The cached copy is damaged
+			cache.Remove(hash)
+		}
+	}
+
+	other, err := e.database.FindUploadedByHash(hash, remoteInfo.Size, metadata.Path)
+	if err != nil || other == nil {
+		return ""
+	}
+	source, err := os.Open(other.Path)
+	if err != nil {
+		return ""
+	}
+	defer source.Close()
+	if !e.copyVerified(source, tempPath, hash) {
+		// Changed since it was synced
+		return ""
+	}
+	e.logger.Infof("Copied %s from %s, which has the same content", metadata.Path, other.Path)
+	return hash
+}
+
+// copyVerified copies source to path and reports whether what it copied
+// has the given hash. The copy is removed if it does not.
+func (e *Engine) copyVerified(source io.Reader, path, hash string) bool {
+	hasher, err := utils.NewHash(e.hashAlgorithm())
+	if err != nil {
+		return false
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return false
+	}
+	_, err = io.Copy(file, io.TeeReader(source, hasher))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || fmt.Sprintf("%x", hasher.Sum(nil)) != hash {
+		os.Remove(path)
+		return false
+	}
+	return true
+}
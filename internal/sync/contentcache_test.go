@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	stdsync "sync"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sameContentHandler serves two remote files, report-a and report-b, with
+// the same content, and counts the downloads of each
+func sameContentHandler(content []byte) (http.HandlerFunc, func(id string) int) {
+	hash := fmt.Sprintf("%x", sha256.Sum256(content))
+	var mu stdsync.Mutex
+	downloads := make(map[string]int)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/files/")
+		if id, ok := strings.CutSuffix(id, "/download"); ok {
+			mu.Lock()
+			downloads[id]++
+			mu.Unlock()
+			w.Write(content)
+			return
+		}
+		if id != "report-a" && id != "report-b" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"id": id, "name": "report.pdf", "size": len(content), "hash": hash, "hash_algorithm": "sha256"},
+		})
+	}
+	return handler, func(id string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return downloads[id]
+	}
+}
+
+func TestIdenticalContentComesFromTheCache(t *testing.T) {
+	content := []byte("annual report, shared by two teams")
+	handler, downloads := sameContentHandler(content)
+	config := &types.Config{Sync: types.SyncConfig{ContentCacheSize: 1}}
+	engine, _ := newTestEngine(t, config, handler)
+	ctx := context.Background()
+
+	first := &types.FileMetadata{Path: filepath.Join(t.TempDir(), "report.pdf"), RemoteID: "report-a"}
+	require.NoError(t, engine.downloadFile(ctx, first))
+	assert.Equal(t, 1, downloads("report-a"))
+	require.NotNil(t, engine.contentCache())
+	assert.True(t, engine.contentCache().Has(first.Hash))
+
+	// The first copy is gone, so only the cache can supply the second
+	require.NoError(t, os.Remove(first.Path))
+	second := &types.FileMetadata{Path: filepath.Join(t.TempDir(), "report.pdf"), RemoteID: "report-b"}
+	require.NoError(t, engine.downloadFile(ctx, second))
+	assert.Zero(t, downloads("report-b"), "the content is copied from the cache")
+	data, err := os.ReadFile(second.Path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, first.Hash, second.Hash)
+
+	// Damaged content in the cache is dropped and downloaded instead
+	cached := filepath.Join(contentCacheDir("sha256"), first.Hash[:2], first.Hash)
+	require.NoError(t, os.WriteFile(cached, []byte("bit rot"), 0600))
+	third := &types.FileMetadata{Path: filepath.Join(t.TempDir(), "report.pdf"), RemoteID: "report-b"}
+	require.NoError(t, os.Remove(second.Path))
+	require.NoError(t, engine.downloadFile(ctx, third))
+	assert.Equal(t, 1, downloads("report-b"))
+	data, err = os.ReadFile(third.Path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
+func TestIdenticalContentComesFromSyncedCopy(t *testing.T) {
+	content := []byte("annual report, shared by two teams")
+	handler, downloads := sameContentHandler(content)
+	engine, database := newTestEngine(t, nil, handler)
+	ctx := context.Background()
+
+	first := &types.FileMetadata{Path: filepath.Join(t.TempDir(), "report.pdf"), RemoteID: "report-a"}
+	require.NoError(t, engine.downloadFile(ctx, first))
+	first.Size, first.SyncStatus = int64(len(content)), "synced"
+	require.NoError(t, database.SaveFileMetadata(first))
+	assert.Nil(t, engine.contentCache(), "the cache is off by default")
+
+	second := &types.FileMetadata{Path: filepath.Join(t.TempDir(), "report.pdf"), RemoteID: "report-b"}
+	require.NoError(t, engine.downloadFile(ctx, second))
+	assert.Zero(t, downloads("report-b"), "the content is copied from the other folder")
+	data, err := os.ReadFile(second.Path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
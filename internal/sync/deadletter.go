@@ -0,0 +1,44 @@
+package sync
+
+import (
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// countFailure counts a failed sync of a file against its retry budget and
+// sets the file aside once the budget is spent, so a file that cannot sync,
+// such as one the server refuses, stops being retried every cycle
+func (e *Engine) countFailure(metadata *types.FileMetadata, syncErr error) {
+	failed, err := e.database.RecordSyncFailure(metadata.Path, syncErr.Error(), e.config.Sync.RetryBudget)
+	if err != nil {
+		e.logger.Warnf("Failed to count failure of %s: %v", metadata.Path, err)
+		return
+	}
+	if !failed {
+		return
+	}
+
+	metadata.SyncStatus = storage.SyncStatusFailed
+	e.logger.Warnf("%s failed %d times in a row and is set aside; fix the cause and run 'zohosync-cli retry-failed'",
+		metadata.Path, e.config.Sync.RetryBudget)
+	e.database.LogSyncOperation(metadata.ID, "sync", "dead-lettered", syncErr.Error())
+}
+
+// FailedFiles returns the files set aside after spending their retry budget
+func (e *Engine) FailedFiles() ([]types.FailedFile, error) {
+	return e.database.GetFailedFiles()
+}
+
+// RetryFailed gives the failed files in paths, or all of them if paths is
+// empty, a fresh retry budget and starts a sync cycle. It returns how many
+// files were re-queued.
+func (e *Engine) RetryFailed(paths ...string) (int, error) {
+	retried, err := e.database.RetryFailedFiles(paths...)
+	if err != nil {
+		return 0, err
+	}
+	if retried > 0 {
+		e.TriggerSync()
+	}
+	return retried, nil
+}
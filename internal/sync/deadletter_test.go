@@ -0,0 +1,71 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileIsSetAsideAfterRetryBudget(t *testing.T) {
+	var mu stdsync.Mutex
+	uploads := 0
+
+	config := &types.Config{Sync: types.SyncConfig{RetryBudget: 2}}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/upload/initiate" {
+			uploads++
+			http.Error(w, `{"error":"file type not allowed"}`, http.StatusForbidden)
+			return
+		}
+		http.NotFound(w, r)
+	})
+	uploadCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return uploads
+	}
+
+	path := filepath.Join(t.TempDir(), "setup.exe")
+	require.NoError(t, os.WriteFile(path, []byte("binary"), 0644))
+	engine.queueFileForSync(path, fsnotify.Create)
+
+	ctx := context.Background()
+	engine.performSync(ctx)
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "error", metadata.SyncStatus)
+
+	engine.performSync(ctx)
+	metadata, err = database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, storage.SyncStatusFailed, metadata.SyncStatus)
+	assert.Equal(t, 2, uploadCount())
+
+	// Set aside: later cycles leave it alone
+	engine.performSync(ctx)
+	assert.Equal(t, 2, uploadCount())
+
+	failed, err := engine.FailedFiles()
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, path, failed[0].Path)
+	assert.Equal(t, 2, failed[0].Attempts)
+	assert.NotEmpty(t, failed[0].LastError)
+
+	retried, err := engine.RetryFailed(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, retried)
+	engine.performSync(ctx)
+	assert.Equal(t, 3, uploadCount())
+}
@@ -0,0 +1,194 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDuplicateContentIsCopiedNotUploaded(t *testing.T) {
+	var requests []string
+	var copyBody map[string]interface{}
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/files/remote-original/copy":
+			json.NewDecoder(r.Body).Decode(&copyBody)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"remote-copy"}}`))
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	content := []byte("the same report contents")
+	original := filepath.Join(dir, "report.pdf")
+	duplicate := filepath.Join(dir, "report copy.pdf")
+	require.NoError(t, os.WriteFile(original, content, 0644))
+	require.NoError(t, os.WriteFile(duplicate, content, 0644))
+
+	hash, err := engine.calculateFileHash(original)
+	require.NoError(t, err)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: original, RemoteID: "remote-original", Size: int64(len(content)), Hash: hash, SyncStatus: "synced",
+	}))
+
+	metadata := &types.FileMetadata{Path: duplicate, Hash: hash}
+	require.NoError(t, engine.uploadFile(context.Background(), metadata))
+
+	assert.Equal(t, []string{"POST /files/remote-original/copy"}, requests)
+	assert.Equal(t, "report copy.pdf", copyBody["name"])
+	assert.Equal(t, "remote-copy", metadata.RemoteID)
+}
+
+func TestHashMatchWithDifferentSizeStillUploads(t *testing.T) {
+	var requests []string
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			w.Write([]byte(`{"data":{"id":"remote-notes"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("notes"), 0644))
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+
+	// Same hash but a different size is treated as a collision, not a duplicate
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: "/elsewhere/other.txt", RemoteID: "remote-other", Size: 999, Hash: hash, SyncStatus: "synced",
+	}))
+
+	require.NoError(t, engine.uploadFile(context.Background(), &types.FileMetadata{Path: path, Hash: hash}))
+	assert.Equal(t, []string{"POST /upload/initiate", "PUT /upload/upload1"}, requests)
+}
+
+func TestMismatchedCopyIsDeletedAndUploaded(t *testing.T) {
+	var requests []string
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/files/remote-original/copy":
+			// The original changed remotely since it was recorded
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"remote-copy","size":24,"hash":"0000","hash_algorithm":"sha256"}}`))
+		case "/files/remote-copy":
+			w.WriteHeader(http.StatusNoContent)
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			w.Write([]byte(`{"data":{"id":"remote-duplicate"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	content := []byte("the same report contents")
+	original := filepath.Join(dir, "report.pdf")
+	duplicate := filepath.Join(dir, "report copy.pdf")
+	require.NoError(t, os.WriteFile(original, content, 0644))
+	require.NoError(t, os.WriteFile(duplicate, content, 0644))
+
+	hash, err := engine.calculateFileHash(original)
+	require.NoError(t, err)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: original, RemoteID: "remote-original", Size: int64(len(content)), Hash: hash, SyncStatus: "synced",
+	}))
+
+	metadata := &types.FileMetadata{Path: duplicate, Hash: hash}
+	require.NoError(t, engine.uploadFile(context.Background(), metadata))
+
+	assert.Equal(t, []string{
+		"POST /files/remote-original/copy",
+		"DELETE /files/remote-copy",
+		"POST /upload/initiate",
+		"PUT /upload/upload1",
+	}, requests)
+	assert.Equal(t, "remote-duplicate", metadata.RemoteID)
+}
+
+func TestPendingFilesAreNotCopied(t *testing.T) {
+	var requests []string
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			w.Write([]byte(`{"data":{"id":"remote-notes"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("notes"), 0644))
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+
+	// The record of a file waiting to sync may not match its remote copy
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: "/elsewhere/other.txt", RemoteID: "remote-other", Size: 5, Hash: hash, SyncStatus: "pending",
+	}))
+
+	require.NoError(t, engine.uploadFile(context.Background(), &types.FileMetadata{Path: path, Hash: hash}))
+	assert.Equal(t, []string{"POST /upload/initiate", "PUT /upload/upload1"}, requests)
+}
+
+func TestReplacedFilesAreUploadedAgainstTheirVersion(t *testing.T) {
+	var requests []string
+	var ifMatch string
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			ifMatch = r.Header.Get("If-Match")
+			w.Write([]byte(`{"data":{"id":"remote-notes"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	content := []byte("notes")
+	original := filepath.Join(dir, "other.txt")
+	path := filepath.Join(dir, "notes.txt")
+	require.NoError(t, os.WriteFile(original, content, 0644))
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: original, RemoteID: "remote-other", Size: 5, Hash: hash, SyncStatus: "synced",
+	}))
+
+	// A copy would skip the check that the remote file is still the version
+	// the edit was made to
+	metadata := &types.FileMetadata{Path: path, RemoteID: "remote-notes", RemoteVersion: "v1", Hash: hash}
+	require.NoError(t, engine.uploadFile(context.Background(), metadata))
+	assert.Equal(t, []string{"POST /upload/initiate", "PUT /upload/upload1"}, requests)
+	assert.Equal(t, "v1", ifMatch)
+}
@@ -0,0 +1,307 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/notify"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrNoPendingDeletions is returned when deletions are confirmed or rejected
+// but none are waiting
+var ErrNoPendingDeletions = errors.New("no deletions are waiting for confirmation")
+
+// PendingDeletion is a removed file or folder whose remote copy is only
+// deleted once the user confirms it. Files counts the files it takes with
+// it: one for a file.
+type PendingDeletion struct {
+	Path        string `json:"path"`
+	IsDirectory bool   `json:"is_directory,omitempty"`
+	Files       int    `json:"files"`
+}
+
+// deletionGate holds back remote deletions that exceed the delete threshold
+type deletionGate struct {
+	mu      sync.Mutex
+	pending map[string]*types.FileMetadata
+	files   map[string]int
+}
+
+// newDeletionGate creates a gate with nothing held back
+func newDeletionGate() *deletionGate {
+	return &deletionGate{
+		pending: make(map[string]*types.FileMetadata),
+		files:   make(map[string]int),
+	}
+}
+
+// take returns and forgets the held back entries
+func (g *deletionGate) take() []*types.FileMetadata {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	entries := make([]*types.FileMetadata, 0, len(g.pending))
+	for _, entry := range g.pending {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	g.pending = make(map[string]*types.FileMetadata)
+	g.files = make(map[string]int)
+	return entries
+}
+
+// holds reports whether the deletion of path is held back
+func (g *deletionGate) holds(path string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.pending[path] != nil
+}
+
+// deleteRemoved deletes removed files and folders remotely, in one batch
+// when the backend supports it, and drops their records. Entries that were
+// never uploaded, or belong to download-only folders, are only dropped from
+// the database. If the remote deletions would remove more files than
+// sync.delete_threshold, or others are already waiting, they are held back
+// until ConfirmDeletions. It returns how many were deleted remotely.
+func (e *Engine) deleteRemoved(ctx context.Context, entries []*types.FileMetadata) int {
+	var remote []*types.FileMetadata
+	for _, entry := range entries {
+		if e.deletesRemotely(entry) {
+			remote = append(remote, entry)
+		} else {
+			e.forgetEntry(entry)
+		}
+	}
+	if len(remote) == 0 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(remote))
+	added := 0
+	for _, entry := range remote {
+		counts[entry.Path] = e.countFilesUnder(entry.Path)
+		added += counts[entry.Path]
+	}
+
+	g := e.deletions
+	g.mu.Lock()
+	threshold := e.config.Sync.DeleteThreshold
+	if len(g.pending) == 0 && (threshold <= 0 || added <= threshold) {
+		g.mu.Unlock()
+		return len(e.removeEntries(ctx, remote))
+	}
+
+	for _, entry := range remote {
+		g.pending[entry.Path] = entry
+		g.files[entry.Path] = counts[entry.Path]
+	}
+	total := 0
+	for _, files := range g.files {
+		total += files
+	}
+	held := len(g.pending)
+	g.mu.Unlock()
+
+	message := fmt.Sprintf("%d files (in %d removed items) are waiting to be deleted from WorkDrive. "+
+		"Run 'zohosync-cli deletions' to review them.", total, held)
+	e.logger.Warnf("Holding back remote deletion of %d files (threshold %d): %s", total, threshold, message)
+
+	e.alerts.mu.Lock()
+	n := e.alerts.notifier
+	e.alerts.mu.Unlock()
+	if n != nil {
+		e.sendAlert(n, notify.Event{
+			Kind:    notify.KindDeleteConfirmation,
+			Title:   "Confirm deletions",
+			Message: message,
+		})
+	}
+	return 0
+}
+
+// PendingDeletions returns the removed files and folders waiting for their
+// remote deletion to be confirmed, sorted by path
+func (e *Engine) PendingDeletions() []PendingDeletion {
+	g := e.deletions
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pending := make([]PendingDeletion, 0, len(g.pending))
+	for path, entry := range g.pending {
+		pending = append(pending, PendingDeletion{Path: path, IsDirectory: entry.IsDirectory, Files: g.files[path]})
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Path < pending[j].Path })
+	return pending
+}
+
+// ConfirmDeletions deletes the held back entries remotely and returns how
+// many were deleted. Entries that have since been recreated locally are
+// left alone, and ones replaced by a local entry of the other kind are
+// queued to be created in their place.
+func (e *Engine) ConfirmDeletions(ctx context.Context) (int, error) {
+	entries := e.deletions.take()
+	if len(entries) == 0 {
+		return 0, ErrNoPendingDeletions
+	}
+
+	var gone []*types.FileMetadata
+	for _, entry := range entries {
+		if info, err := os.Lstat(entry.Path); err == nil && info.IsDir() == entry.IsDirectory {
+			e.logger.Infof("Not deleting %s remotely: it exists again locally", entry.Path)
+			continue
+		}
+		gone = append(gone, entry)
+	}
+
+	removed := e.removeEntries(ctx, gone)
+	for _, entry := range removed {
+		if pathExists(entry.Path) {
+			e.queueReplacement(ctx, entry.Path)
+		}
+	}
+	return len(removed), nil
+}
+
+// RejectDeletions drops the held back deletions and marks the files to be
+// downloaded again, returning the paths to sync. Entries of folders that do
+// not download stop being tracked, keeping their remote copy.
+func (e *Engine) RejectDeletions() ([]string, error) {
+	entries := e.deletions.take()
+	if len(entries) == 0 {
+		return nil, ErrNoPendingDeletions
+	}
+
+	var restore []string
+	for _, entry := range entries {
+		if !e.strategyForPath(entry.Path).AllowsDownload() {
+			e.forgetEntry(entry)
+			continue
+		}
+		paths, err := e.MarkResync(entry.Path, ResyncDown)
+		if err != nil {
+			e.logger.Errorf("Failed to restore %s: %v", entry.Path, err)
+			continue
+		}
+		restore = append(restore, paths...)
+	}
+	e.logger.Infof("Rejected the deletion of %d files and folders, restoring %d files", len(entries), len(restore))
+	return restore, nil
+}
+
+// removeEntries deletes files and folders remotely and drops the records of
+// those deleted, returning them
+func (e *Engine) removeEntries(ctx context.Context, entries []*types.FileMetadata) []*types.FileMetadata {
+	failed := make(map[string]error)
+	if batch, ok := e.backend.(api.BatchDeleter); ok && len(entries) > 1 {
+		ids := make([]string, len(entries))
+		for i, entry := range entries {
+			ids[i] = entry.RemoteID
+		}
+		var err error
+		if failed, err = batch.BatchDelete(ctx, ids); err != nil {
+			for _, id := range ids {
+				if failed[id] == nil {
+					failed[id] = err
+				}
+			}
+		}
+	} else {
+		for _, entry := range entries {
+			remove := e.backend.DeleteFile
+			if entry.IsDirectory {
+				remove = e.backend.DeleteFolder
+			}
+			if err := remove(ctx, entry.RemoteID); err != nil && !api.IsNotFound(err) {
+				failed[entry.RemoteID] = err
+			}
+		}
+	}
+
+	var removed []*types.FileMetadata
+	for _, entry := range entries {
+		if err := failed[entry.RemoteID]; err != nil {
+			e.logger.Errorf("Failed to delete remote %s for %s: %v", entryKind(entry.IsDirectory), entry.Path, err)
+			e.database.LogSyncOperation(entry.ID, "delete", "failed", err.Error())
+			continue
+		}
+		e.forgetEntry(entry)
+		removed = append(removed, entry)
+	}
+	return removed
+}
+
+// forgetEntry drops a file, or a directory and all of its descendants, from
+// the database in one transaction
+func (e *Engine) forgetEntry(entry *types.FileMetadata) {
+	count, err := e.database.DeleteFilesUnder(entry.Path)
+	if err != nil {
+		e.logger.Errorf("Failed to clean up records under %s: %v", entry.Path, err)
+		return
+	}
+
+	e.database.LogSyncOperation(entry.ID, "delete", "success", "")
+	if entry.IsDirectory {
+		e.logger.Infof("Deleted folder %s (%d tracked entries)", entry.Path, count)
+	} else {
+		e.logger.Infof("Deleted %s", entry.Path)
+	}
+}
+
+// replaceRetyped deletes the remote entry of a path that changed between
+// file and folder locally, as metadata still records it, through the
+// deletion gate. Once it is deleted the local entry is queued to be created
+// in its place.
+func (e *Engine) replaceRetyped(ctx context.Context, metadata *types.FileMetadata) error {
+	if e.deletions.holds(metadata.Path) {
+		return nil
+	}
+
+	e.logger.Infof("%s changed from a %s to a %s, replacing it remotely",
+		metadata.Path, entryKind(metadata.IsDirectory), entryKind(!metadata.IsDirectory))
+	if e.deleteRemoved(ctx, []*types.FileMetadata{metadata}) > 0 {
+		e.queueReplacement(ctx, metadata.Path)
+	}
+	return nil
+}
+
+// queueReplacement queues a local entry that takes the place of a deleted
+// remote one, with everything in it if it is a folder
+func (e *Engine) queueReplacement(ctx context.Context, path string) {
+	e.queueFileForSync(path, fsnotify.Create)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		if _, err := e.ScanFolder(ctx, path, nil); err != nil {
+			e.logger.Errorf("Failed to queue the contents of %s: %v", path, err)
+		}
+	}
+}
+
+// deletesRemotely reports whether removing an entry locally deletes it
+// remotely
+func (e *Engine) deletesRemotely(entry *types.FileMetadata) bool {
+	return entry.RemoteID != "" && e.strategyForPath(entry.Path).AllowsUpload()
+}
+
+// countFilesUnder returns the number of tracked files in a directory tree
+func (e *Engine) countFilesUnder(dirPath string) int {
+	files, err := e.database.GetFilesUnder(filepath.Clean(dirPath))
+	if err != nil {
+		e.logger.Errorf("Failed to count files under %s: %v", dirPath, err)
+		return 0
+	}
+	count := 0
+	for _, file := range files {
+		if !file.IsDirectory {
+			count++
+		}
+	}
+	return count
+}
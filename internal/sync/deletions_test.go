@@ -0,0 +1,200 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedRemovedFolders tracks two synced folders holding three files between
+// them and buffers their removal
+func seedRemovedFolders(t *testing.T, engine *Engine) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	photos, music := filepath.Join(root, "photos"), filepath.Join(root, "music")
+	seed := []types.FileMetadata{
+		{Path: photos, RemoteID: "folder1", IsDirectory: true, SyncStatus: "synced"},
+		{Path: filepath.Join(photos, "a.jpg"), RemoteID: "file1", SyncStatus: "synced"},
+		{Path: filepath.Join(photos, "b.jpg"), RemoteID: "file2", SyncStatus: "synced"},
+		{Path: music, RemoteID: "folder2", IsDirectory: true, SyncStatus: "synced"},
+		{Path: filepath.Join(music, "song.mp3"), RemoteID: "file3", SyncStatus: "synced"},
+	}
+	for i := range seed {
+		require.NoError(t, engine.database.SaveFileMetadata(&seed[i]))
+	}
+
+	for _, path := range []string{photos, music} {
+		engine.bufferDirectoryChange(context.Background(), fsnotify.Event{Name: path, Op: fsnotify.Remove}, false)
+	}
+	return photos, music
+}
+
+func TestDeleteThresholdHoldsBackDeletions(t *testing.T) {
+	var mu stdsync.Mutex
+	var batches [][]string
+
+	config := &types.Config{Sync: types.SyncConfig{DeleteThreshold: 2}}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method != http.MethodPost || r.URL.Path != "/files/delete" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		batches = append(batches, body.IDs)
+
+		var data []map[string]interface{}
+		for _, id := range body.IDs {
+			data = append(data, map[string]interface{}{"id": id, "status": http.StatusNoContent})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+
+	photos, music := seedRemovedFolders(t, engine)
+	ctx := context.Background()
+	engine.flushDirectoryChanges(ctx)
+
+	// Three files exceed the threshold of two, so nothing is deleted yet
+	mu.Lock()
+	assert.Empty(t, batches)
+	mu.Unlock()
+	assert.Equal(t, []PendingDeletion{{Path: music, IsDirectory: true, Files: 1}, {Path: photos, IsDirectory: true, Files: 2}}, engine.PendingDeletions())
+	existing, err := database.GetFileMetadata(photos)
+	require.NoError(t, err)
+	assert.NotNil(t, existing, "records are kept while the deletion is held back")
+
+	deleted, err := engine.ConfirmDeletions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	mu.Lock()
+	assert.Equal(t, [][]string{{"folder2", "folder1"}}, batches, "confirmed deletions are sent in one batch")
+	mu.Unlock()
+	assert.Empty(t, engine.PendingDeletions())
+	for _, path := range []string{photos, filepath.Join(photos, "a.jpg"), music} {
+		metadata, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		assert.Nil(t, metadata, path)
+	}
+
+	_, err = engine.ConfirmDeletions(ctx)
+	assert.ErrorIs(t, err, ErrNoPendingDeletions)
+}
+
+func TestRejectedDeletionsAreRestored(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	engine.config.Sync.DeleteThreshold = 1
+	ctx := context.Background()
+
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/", SyncMode: "bidirectional", Enabled: true}}
+	src := filepath.Join(local, "project")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	for _, name := range []string{"a.txt", "b.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(src, name), []byte(name), 0644))
+	}
+	_, err := engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(src))
+	engine.bufferDirectoryChange(ctx, fsnotify.Event{Name: src, Op: fsnotify.Remove}, false)
+	engine.flushDirectoryChanges(ctx)
+	require.Len(t, engine.PendingDeletions(), 1)
+
+	paths, err := engine.RejectDeletions()
+	require.NoError(t, err)
+	engine.SyncPaths(ctx, paths)
+
+	assert.Empty(t, engine.PendingDeletions())
+	assert.FileExists(t, filepath.Join(remoteDir, "project", "a.txt"), "the remote copy is kept")
+	restored, err := os.ReadFile(filepath.Join(src, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "b.txt", string(restored))
+
+	metadata, err := database.GetFileMetadata(filepath.Join(src, "a.txt"))
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "synced", metadata.SyncStatus)
+}
+
+func TestRemovedFileIsDeletedRemotely(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/", SyncMode: "bidirectional", Enabled: true}}
+	src := filepath.Join(local, "project")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	for _, name := range []string{"a.txt", "b.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(src, name), []byte(name), 0644))
+	}
+	_, err := engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+
+	removed := filepath.Join(src, "a.txt")
+	require.NoError(t, os.Remove(removed))
+	engine.bufferDirectoryChange(ctx, fsnotify.Event{Name: removed, Op: fsnotify.Remove}, false)
+	engine.flushDirectoryChanges(ctx)
+
+	assert.NoFileExists(t, filepath.Join(remoteDir, "project", "a.txt"))
+	assert.FileExists(t, filepath.Join(remoteDir, "project", "b.txt"))
+	metadata, err := database.GetFileMetadata(removed)
+	require.NoError(t, err)
+	assert.Nil(t, metadata)
+}
+
+func TestFileReplacedByFolderReplacesRemoteEntry(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/", SyncMode: "bidirectional", Enabled: true}}
+	src := filepath.Join(local, "project")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	notes := filepath.Join(src, "notes")
+	require.NoError(t, os.WriteFile(notes, []byte("notes"), 0644))
+	_, err := engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(notes))
+	require.NoError(t, os.MkdirAll(notes, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(notes, "todo.txt"), []byte("todo"), 0644))
+	engine.queueFileForSync(notes, fsnotify.Create)
+
+	// The record keeps the remote file until it is deleted
+	record, err := database.GetFileMetadata(notes)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.False(t, record.IsDirectory)
+	assert.NotEmpty(t, record.RemoteID)
+
+	engine.performSync(ctx)
+	engine.performSync(ctx)
+
+	info, err := os.Stat(filepath.Join(remoteDir, "project", "notes"))
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "the remote file was replaced by a folder")
+	data, err := os.ReadFile(filepath.Join(remoteDir, "project", "notes", "todo.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "todo", string(data))
+
+	record, err = database.GetFileMetadata(notes)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.IsDirectory)
+	assert.Equal(t, "synced", record.SyncStatus)
+}
@@ -0,0 +1,197 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DiffCategory classifies a difference between a local and a remote tree
+type DiffCategory string
+
+const (
+	// DiffOnlyLocal means the path only exists locally
+	DiffOnlyLocal DiffCategory = "only-local"
+	// DiffOnlyRemote means the path only exists remotely
+	DiffOnlyRemote DiffCategory = "only-remote"
+	// DiffModified means one side changed since the last sync
+	DiffModified DiffCategory = "modified"
+	// DiffConflict means both sides changed, or there is no sync record
+	// to tell which one did
+	DiffConflict DiffCategory = "conflict"
+	// DiffTypeChanged means the path is a folder on one side and a file on
+	// the other. IsDirectory tells what it is locally.
+	DiffTypeChanged DiffCategory = "type-changed"
+)
+
+// DiffEntry is one path that differs between the two sides. Path is
+// relative to the sync folder and uses forward slashes.
+type DiffEntry struct {
+	Category       DiffCategory  `json:"category"`
+	Path           string        `json:"path"`
+	IsDirectory    bool          `json:"is_directory,omitempty"`
+	Changed        string        `json:"changed,omitempty"`
+	LocalSize      int64         `json:"local_size,omitempty"`
+	RemoteSize     int64         `json:"remote_size,omitempty"`
+	LocalModified  time.Time     `json:"local_modified,omitempty"`
+	RemoteModified time.Time     `json:"remote_modified,omitempty"`
+	SizeDelta      int64         `json:"size_delta,omitempty"`
+	ModTimeDelta   time.Duration `json:"mod_time_delta,omitempty"`
+}
+
+// FolderDiff lists the differences within one sync folder
+type FolderDiff struct {
+	Local   string      `json:"local"`
+	Remote  string      `json:"remote"`
+	Entries []DiffEntry `json:"entries"`
+}
+
+// Count returns how many entries fall in a category
+func (d *FolderDiff) Count(category DiffCategory) int {
+	count := 0
+	for _, entry := range d.Entries {
+		if entry.Category == category {
+			count++
+		}
+	}
+	return count
+}
+
+// Diff compares a sync folder with its remote counterpart using the sync
+// planner. It only reads: nothing is transferred and the database is left
+// untouched. A remote folder that does not exist yet counts as empty.
+func (e *Engine) Diff(ctx context.Context, folder types.FolderConfig) (*FolderDiff, error) {
+	diff := &FolderDiff{Local: folder.Local, Remote: folder.Remote}
+
+	var remote EntryIterator = &treeIterator{list: func(*PlanEntry) ([]PlanEntry, error) { return nil, nil }}
+	remoteID, err := e.resolveRemoteFolder(ctx, folder.Remote)
+	switch {
+	case err == nil:
+		remote = newRemoteIterator(ctx, e.backend, remoteID, DefaultListPageSize, e.remoteEntry)
+	case !errors.Is(err, errRemoteFolderMissing):
+		return nil, err
+	}
+
+	local := newLocalIterator(folder.Local, e.shouldIgnoreFile)
+	err = streamSyncOperations(local, remote, func(op PlanOperation) error {
+		entry, err := e.diffEntry(folder.Local, op)
+		if err != nil {
+			return err
+		}
+		diff.Entries = append(diff.Entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s: %w", folder.Local, err)
+	}
+	return diff, nil
+}
+
+// diffEntry describes a planned operation, using the sync record to tell a
+// one-sided modification from a conflict
+func (e *Engine) diffEntry(root string, op PlanOperation) (DiffEntry, error) {
+	entry := DiffEntry{Path: op.Path}
+	if op.Local != nil {
+		entry.IsDirectory = op.Local.IsDirectory
+		entry.LocalSize = op.Local.Size
+		entry.LocalModified = op.Local.ModifiedTime
+	}
+	if op.Remote != nil {
+		entry.IsDirectory = entry.IsDirectory || op.Remote.IsDirectory
+		entry.RemoteSize = op.Remote.Size
+		entry.RemoteModified = op.Remote.ModifiedTime
+	}
+
+	switch op.Type {
+	case PlanUpload:
+		entry.Category = DiffOnlyLocal
+		return entry, nil
+	case PlanDownload:
+		entry.Category = DiffOnlyRemote
+		return entry, nil
+	case PlanReplace:
+		entry.Category, entry.IsDirectory = DiffTypeChanged, op.Local.IsDirectory
+		return entry, nil
+	}
+
+	entry.SizeDelta = entry.LocalSize - entry.RemoteSize
+	entry.ModTimeDelta = entry.LocalModified.Sub(entry.RemoteModified)
+
+	record, err := e.database.GetFileMetadata(filepath.Join(root, filepath.FromSlash(op.Path)))
+	if err != nil {
+		return entry, err
+	}
+	localChanged := record == nil || !sameVersion(op.Local, record)
+	remoteChanged := record == nil || !sameVersion(op.Remote, record)
+
+	switch {
+	case localChanged && remoteChanged:
+		entry.Category = DiffConflict
+	case localChanged:
+		entry.Category, entry.Changed = DiffModified, types.ConflictWinnerLocal
+	default:
+		entry.Category, entry.Changed = DiffModified, types.ConflictWinnerRemote
+	}
+	return entry, nil
+}
+
+// sameVersion reports whether an entry still has the size and modification
+// time recorded at its last sync
+func sameVersion(entry *PlanEntry, record *types.FileMetadata) bool {
+	return entry.Size == record.Size &&
+		entry.ModifiedTime.Truncate(time.Second).Equal(record.ModifiedTime.Truncate(time.Second))
+}
+
+// errRemoteFolderMissing is returned when a configured remote folder does
+// not exist
+var errRemoteFolderMissing = errors.New("remote folder does not exist")
+
+// resolveRemoteFolder finds the ID of a remote folder from its slash
+// separated path, optionally in a workspace, by listing each level from the
+// root
+func (e *Engine) resolveRemoteFolder(ctx context.Context, remote string) (string, error) {
+	folderID, remotePath, err := e.remoteRoot(ctx, remote, false)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range strings.Split(strings.Trim(remotePath, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		childID, err := e.findRemoteChild(ctx, folderID, name)
+		if err != nil {
+			return "", err
+		}
+		folderID = childID
+	}
+	return folderID, nil
+}
+
+// findRemoteChild returns the ID of the subfolder called name in folderID
+func (e *Engine) findRemoteChild(ctx context.Context, folderID, name string) (string, error) {
+	for offset := 0; ; offset += DefaultListPageSize {
+		page, err := e.backend.ListFilesPage(ctx, folderID, offset, DefaultListPageSize)
+		if err != nil {
+			var statusErr *api.StatusError
+			if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+				return "", errRemoteFolderMissing
+			}
+			return "", fmt.Errorf("failed to list remote folder: %w", err)
+		}
+		for _, file := range page {
+			if file.IsFolder && file.Name == name {
+				return file.ID, nil
+			}
+		}
+		if len(page) < DefaultListPageSize {
+			return "", errRemoteFolderMissing
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFileAt writes content to path with the given modification time
+func writeFileAt(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestDiffCategorizesDivergentTrees(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	local := t.TempDir()
+	remote := filepath.Join(remoteDir, "Docs")
+	folder := types.FolderConfig{Local: local, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}
+
+	synced := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	later := synced.Add(time.Hour)
+
+	writeFileAt(t, filepath.Join(local, "same.txt"), "same", synced)
+	writeFileAt(t, filepath.Join(remote, "same.txt"), "same", synced)
+
+	writeFileAt(t, filepath.Join(local, "draft.txt"), "local only", synced)
+	writeFileAt(t, filepath.Join(remote, "shared", "report.pdf"), "remote only", synced)
+
+	// Edited locally since the recorded sync; the remote copy is unchanged
+	writeFileAt(t, filepath.Join(local, "notes.txt"), "notes, edited", later)
+	writeFileAt(t, filepath.Join(remote, "notes.txt"), "notes", synced)
+	notes := &types.FileMetadata{Path: filepath.Join(local, "notes.txt"), RemoteID: "/Docs/notes.txt", Size: 5, ModifiedTime: synced, SyncStatus: "synced"}
+	require.NoError(t, database.SaveFileMetadata(notes))
+
+	// Edited on both sides
+	writeFileAt(t, filepath.Join(local, "plan.txt"), "local plan", later)
+	writeFileAt(t, filepath.Join(remote, "plan.txt"), "remote plan, longer", later.Add(time.Minute))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: filepath.Join(local, "plan.txt"), RemoteID: "/Docs/plan.txt", Size: 4, ModifiedTime: synced, SyncStatus: "synced"}))
+
+	diff, err := engine.Diff(context.Background(), folder)
+	require.NoError(t, err)
+
+	categories := make(map[string]DiffCategory)
+	for _, entry := range diff.Entries {
+		categories[entry.Path] = entry.Category
+	}
+	assert.Equal(t, map[string]DiffCategory{
+		"draft.txt":         DiffOnlyLocal,
+		"shared":            DiffOnlyRemote,
+		"shared/report.pdf": DiffOnlyRemote,
+		"notes.txt":         DiffModified,
+		"plan.txt":          DiffConflict,
+	}, categories)
+
+	for _, entry := range diff.Entries {
+		if entry.Path == "notes.txt" {
+			assert.Equal(t, types.ConflictWinnerLocal, entry.Changed)
+			assert.Equal(t, int64(8), entry.SizeDelta)
+			assert.Equal(t, time.Hour, entry.ModTimeDelta)
+		}
+	}
+
+	// Diffing is read-only
+	untracked, err := database.GetFileMetadata(filepath.Join(local, "draft.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, untracked)
+	saved, err := database.GetFileMetadata(notes.Path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), saved.Size)
+	assert.Equal(t, "synced", saved.SyncStatus)
+	assert.NoFileExists(t, filepath.Join(local, "shared", "report.pdf"))
+}
+
+func TestDiffTreatsMissingRemoteFolderAsEmpty(t *testing.T) {
+	engine, _, _ := newLocalTestEngine(t)
+	local := t.TempDir()
+	writeFileAt(t, filepath.Join(local, "a.txt"), "a", time.Now())
+
+	diff, err := engine.Diff(context.Background(), types.FolderConfig{Local: local, Remote: "/Nowhere", Enabled: true})
+	require.NoError(t, err)
+	require.Len(t, diff.Entries, 1)
+	assert.Equal(t, DiffOnlyLocal, diff.Entries[0].Category)
+}
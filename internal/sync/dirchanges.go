@@ -0,0 +1,217 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultCoalesceWindow is how long removal, rename and directory-create
+// events are buffered so that directory-level changes can be recognised
+// before falling back to per-file processing
+const DefaultCoalesceWindow = 500 * time.Millisecond
+
+// changeCoalescer buffers structural file system events, and file creates
+// and writes so that editors' atomic saves can be recognised
+type changeCoalescer struct {
+	mu      sync.Mutex
+	removed map[string]fsnotify.Op
+	created map[string]bool
+	written map[string]fsnotify.Op
+	timer   *time.Timer
+}
+
+// newChangeCoalescer creates an empty coalescer
+func newChangeCoalescer() *changeCoalescer {
+	return &changeCoalescer{
+		removed: make(map[string]fsnotify.Op),
+		created: make(map[string]bool),
+		written: make(map[string]fsnotify.Op),
+	}
+}
+
+// drain returns and clears the buffered events
+func (c *changeCoalescer) drain() (removed map[string]fsnotify.Op, created map[string]bool, written map[string]fsnotify.Op) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+
+	removed, created, written = c.removed, c.created, c.written
+	c.removed = make(map[string]fsnotify.Op)
+	c.created = make(map[string]bool)
+	c.written = make(map[string]fsnotify.Op)
+	return removed, created, written
+}
+
+// bufferDirectoryChange records a structural event and (re)arms the flush timer
+func (e *Engine) bufferDirectoryChange(ctx context.Context, event fsnotify.Event, isDirCreate bool) {
+	c := e.coalescer
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if isDirCreate {
+		c.created[event.Name] = true
+	} else {
+		c.removed[event.Name] |= event.Op
+	}
+	e.rearmFlush(ctx)
+}
+
+// rearmFlush restarts the flush timer; the coalescer must be locked
+func (e *Engine) rearmFlush(ctx context.Context) {
+	c := e.coalescer
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	c.timer = time.AfterFunc(e.coalesceWindow, func() {
+		e.flushDirectoryChanges(ctx)
+	})
+}
+
+// flushDirectoryChanges turns buffered events into the smallest set of
+// operations: one recursive remote call per moved directory, one batch
+// deleting every removed file and directory, one change per file however an
+// editor saved it, and ordinary per-file queueing for everything else
+func (e *Engine) flushDirectoryChanges(ctx context.Context) {
+	removed, created, written := e.coalescer.drain()
+
+	for _, path := range resolveAtomicSaves(removed, written, pathExists) {
+		go e.queueFileForSync(path, fsnotify.Write)
+	}
+
+	var deleted []*types.FileMetadata
+	for _, path := range collapseToRoots(removed) {
+		existing, err := e.database.GetFileMetadata(path)
+		if err != nil {
+			e.logger.Errorf("Failed to look up %s: %v", path, err)
+			continue
+		}
+
+		if existing == nil || !existing.IsDirectory {
+			if _, err := os.Stat(filepath.Dir(path)); os.IsNotExist(err) {
+				// The parent directory is gone too; its own event covers this entry
+				continue
+			}
+			if existing == nil || pathExists(path) {
+				go e.queueFileForSync(path, removed[path])
+			} else {
+				deleted = append(deleted, existing)
+			}
+			continue
+		}
+
+		if info, err := os.Lstat(path); err == nil && !info.IsDir() {
+			// A file took the folder's place, and its sync replaces it
+			go e.queueFileForSync(path, removed[path])
+			continue
+		}
+
+		if removed[path]&fsnotify.Rename == fsnotify.Rename {
+			if newPath, ok := matchMovedDirectory(path, created); ok {
+				delete(created, newPath)
+				e.moveDirectory(ctx, existing, newPath)
+				continue
+			}
+		}
+
+		deleted = append(deleted, existing)
+	}
+	e.deleteRemoved(ctx, deleted)
+
+	for path := range created {
+		if err := e.addWatchRecursive(ctx, path); err != nil {
+			e.logger.Errorf("Failed to watch new folder %s: %v", path, err)
+		}
+		go e.queueFileForSync(path, fsnotify.Create)
+	}
+}
+
+// moveDirectory moves a directory remotely with a single call
+// (skipped for download-only folders) and rewrites all descendant paths in
+// the database in one transaction
+func (e *Engine) moveDirectory(ctx context.Context, dir *types.FileMetadata, newPath string) {
+	if dir.RemoteID != "" && e.strategyForPath(dir.Path).AllowsUpload() {
+		parentID, err := e.remoteParent(ctx, newPath)
+		var name string
+		if err == nil {
+			name, err = e.sealName(filepath.Base(newPath))
+		}
+		if err == nil {
+			_, err = e.backend.MoveFolder(ctx, dir.RemoteID, parentID, name)
+		}
+		if err != nil {
+			e.logger.Errorf("Failed to move remote folder for %s: %v", dir.Path, err)
+			e.database.LogSyncOperation(dir.ID, "move", "failed", err.Error())
+			return
+		}
+	}
+
+	count, err := e.database.MoveFilesUnder(dir.Path, newPath)
+	if err != nil {
+		e.logger.Errorf("Failed to update records for %s: %v", dir.Path, err)
+		return
+	}
+
+	if err := e.addWatchRecursive(ctx, newPath); err != nil {
+		e.logger.Errorf("Failed to watch moved folder %s: %v", newPath, err)
+	}
+
+	e.database.LogSyncOperation(dir.ID, "move", "success", "")
+	e.logger.Infof("Moved folder %s to %s (%d tracked entries)", dir.Path, newPath, count)
+}
+
+// collapseToRoots returns the buffered paths that have no buffered ancestor,
+// so that child events arriving before or after their parent's event are
+// absorbed by the parent's directory-level operation
+func collapseToRoots(paths map[string]fsnotify.Op) []string {
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var roots []string
+	for _, path := range sorted {
+		covered := false
+		for _, root := range roots {
+			if strings.HasPrefix(path, root+string(filepath.Separator)) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			roots = append(roots, path)
+		}
+	}
+	return roots
+}
+
+// matchMovedDirectory pairs a renamed directory with a newly created one,
+// either renamed in place (same parent) or moved elsewhere (same name)
+func matchMovedDirectory(oldPath string, created map[string]bool) (string, bool) {
+	var candidates []string
+	for path := range created {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if filepath.Dir(path) == filepath.Dir(oldPath) || filepath.Base(path) == filepath.Base(oldPath) {
+			candidates = append(candidates, path)
+		}
+	}
+
+	if len(candidates) != 1 {
+		return "", false
+	}
+	return candidates[0], true
+}
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletePopulatedDirectoryCoalesces(t *testing.T) {
+	var mu stdsync.Mutex
+	var deletes []string
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodDelete {
+			deletes = append(deletes, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	root := t.TempDir()
+	photos := filepath.Join(root, "photos")
+	seed := []types.FileMetadata{
+		{Path: photos, RemoteID: "folder1", IsDirectory: true, SyncStatus: "synced"},
+		{Path: filepath.Join(photos, "a.jpg"), RemoteID: "file1", SyncStatus: "synced"},
+		{Path: filepath.Join(photos, "trip"), RemoteID: "folder2", IsDirectory: true, SyncStatus: "synced"},
+		{Path: filepath.Join(photos, "trip", "b.jpg"), RemoteID: "file2", SyncStatus: "synced"},
+		{Path: filepath.Join(root, "photos_backup.txt"), RemoteID: "file3", SyncStatus: "synced"},
+	}
+	for i := range seed {
+		require.NoError(t, database.SaveFileMetadata(&seed[i]))
+	}
+
+	// Child events arrive before the parent's own removal event
+	ctx := context.Background()
+	for _, path := range []string{
+		filepath.Join(photos, "trip", "b.jpg"),
+		filepath.Join(photos, "a.jpg"),
+		filepath.Join(photos, "trip"),
+		photos,
+	} {
+		engine.bufferDirectoryChange(ctx, fsnotify.Event{Name: path, Op: fsnotify.Remove}, false)
+	}
+	engine.flushDirectoryChanges(ctx)
+
+	mu.Lock()
+	assert.Equal(t, []string{"/files/folder1"}, deletes)
+	mu.Unlock()
+
+	for _, entry := range seed[:4] {
+		metadata, err := database.GetFileMetadata(entry.Path)
+		require.NoError(t, err)
+		assert.Nil(t, metadata, "expected %s to be removed", entry.Path)
+	}
+
+	sibling, err := database.GetFileMetadata(filepath.Join(root, "photos_backup.txt"))
+	require.NoError(t, err)
+	assert.NotNil(t, sibling)
+}
+
+func TestCollapseToRoots(t *testing.T) {
+	roots := collapseToRoots(map[string]fsnotify.Op{
+		"/sync/a/b/c.txt": fsnotify.Remove,
+		"/sync/a":         fsnotify.Remove,
+		"/sync/a-b":       fsnotify.Remove,
+		"/sync/a/b":       fsnotify.Remove,
+	})
+	assert.Equal(t, []string{"/sync/a", "/sync/a-b"}, roots)
+}
@@ -0,0 +1,42 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/bdstest/zohosync/internal/api"
+)
+
+// DownloadProgressFunc is called as the bytes of a download arrive. total
+// is -1 for downloads of unknown size, such as exported documents.
+type DownloadProgressFunc func(path string, received, total int64)
+
+// SetDownloadProgressFunc registers a callback for the progress of downloads
+func (e *Engine) SetDownloadProgressFunc(fn DownloadProgressFunc) {
+	e.mu.Lock()
+	e.downloadProgress = fn
+	e.mu.Unlock()
+}
+
+// downloadProgressContext returns ctx with a callback passing the bytes of
+// the download of path on to the download progress callback. size, the
+// remote size if known, stands in for a total the server does not send.
+func (e *Engine) downloadProgressContext(ctx context.Context, path string, size int64) context.Context {
+	return api.WithProgress(ctx, func(received, total int64) {
+		if total < 0 && size >= 0 {
+			total = size
+		}
+		e.reportDownloadProgress(path, received, total)
+	})
+}
+
+// reportDownloadProgress invokes the registered download progress callback,
+// if any
+func (e *Engine) reportDownloadProgress(path string, received, total int64) {
+	e.mu.RLock()
+	fn := e.downloadProgress
+	e.mu.RUnlock()
+
+	if fn != nil {
+		fn(path, received, total)
+	}
+}
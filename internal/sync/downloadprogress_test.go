@@ -0,0 +1,38 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadProgressIsReportedAsBytesArrive(t *testing.T) {
+	content := bytes.Repeat([]byte("meeting notes "), 50<<10)
+	engine, _ := newTestEngine(t, nil, downloadHandler(content, fmt.Sprintf("%x", sha256.Sum256(content))))
+
+	var received []int64
+	engine.SetDownloadProgressFunc(func(path string, done, total int64) {
+		assert.Equal(t, int64(len(content)), total)
+		received = append(received, done)
+	})
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, engine.downloadFile(context.Background(), &types.FileMetadata{Path: path, RemoteID: "doc1"}))
+
+	require.Greater(t, len(received), 1, "progress is reported within the file")
+	for i := 1; i < len(received); i++ {
+		assert.Greater(t, received[i], received[i-1])
+	}
+	assert.Equal(t, int64(len(content)), received[len(received)-1])
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
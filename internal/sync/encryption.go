@@ -0,0 +1,181 @@
+package sync
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/bdstest/zohosync/internal/encryption"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// contentEncryption holds the cipher of an engine with encryption enabled.
+// Deriving the keys is deliberately slow, so it happens on first use.
+type contentEncryption struct {
+	passphrase   string
+	encryptNames bool
+
+	once   sync.Once
+	cipher *encryption.Cipher
+	err    error
+}
+
+// newContentEncryption returns the encryption state for config, or nil when
+// encryption is off
+func newContentEncryption(config types.EncryptionConfig) *contentEncryption {
+	if !config.Enabled {
+		return nil
+	}
+	return &contentEncryption{passphrase: config.Passphrase, encryptNames: config.EncryptNames}
+}
+
+// contentCipher returns the cipher files are encrypted with, or nil when
+// encryption is off
+func (e *Engine) contentCipher() (*encryption.Cipher, error) {
+	if e.encryption == nil {
+		return nil, nil
+	}
+	e.encryption.once.Do(func() {
+		e.encryption.cipher, e.encryption.err = encryption.New(e.encryption.passphrase)
+	})
+	return e.encryption.cipher, e.encryption.err
+}
+
+// sealName returns the remote form of a local name, encrypted when name
+// encryption is on
+func (e *Engine) sealName(name string) (string, error) {
+	if e.encryption == nil || !e.encryption.encryptNames {
+		return name, nil
+	}
+	c, err := e.contentCipher()
+	if err != nil {
+		return "", err
+	}
+	return c.EncryptName(name), nil
+}
+
+// openName returns the plaintext of a remote name. Names that were not
+// encrypted, such as those uploaded before name encryption was turned on,
+// are returned unchanged.
+func (e *Engine) openName(name string) string {
+	if e.encryption == nil || !e.encryption.encryptNames {
+		return name
+	}
+	c, err := e.contentCipher()
+	if err != nil {
+		return name
+	}
+	if plain, err := c.DecryptName(name); err == nil {
+		return plain
+	}
+	return name
+}
+
+// remoteSize returns the size a local file of size bytes takes up remotely
+func (e *Engine) remoteSize(size int64) int64 {
+	if e.encryption == nil {
+		return size
+	}
+	return encryption.EncryptedSize(size)
+}
+
+// plaintextSize returns the size of the local copy of a remote file of size
+// bytes. Sizes no encrypted file can have are returned unchanged.
+func (e *Engine) plaintextSize(size int64) int64 {
+	if e.encryption == nil {
+		return size
+	}
+	if plain, ok := encryption.PlaintextSize(size); ok {
+		return plain
+	}
+	return size
+}
+
+// uploadContent is what an upload of a local file sends: the file itself,
+// or its ciphertext when encryption is on. Every open of the same
+// uploadContent reads the same bytes.
+type uploadContent struct {
+	path   string
+	sealer *encryption.Sealer
+
+	// algorithm is what a single-request upload hashes the file with as
+	// it reads it; hash then holds the digest once the upload completed
+	algorithm string
+	hash      string
+}
+
+// newUploadContent prepares the content of an upload of path
+func (e *Engine) newUploadContent(path string) (*uploadContent, error) {
+	c, err := e.contentCipher()
+	if err != nil || c == nil {
+		return &uploadContent{path: path, algorithm: e.hashAlgorithm()}, err
+	}
+	sealer, err := c.NewSealer()
+	if err != nil {
+		return nil, err
+	}
+	return &uploadContent{path: path, sealer: sealer, algorithm: e.hashAlgorithm()}, nil
+}
+
+// open returns a reader of the size bytes of content to upload. The caller
+// closes the returned file.
+func (u *uploadContent) open(size int64) (io.ReaderAt, *os.File, error) {
+	file, err := os.Open(u.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u.seal(file, size), file, nil
+}
+
+// openHashing is open that also hashes the plaintext as it is read. The
+// caller closes the returned file.
+func (u *uploadContent) openHashing(size int64) (io.ReaderAt, *os.File, *hashingReaderAt, error) {
+	file, err := os.Open(u.path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	plain := size
+	if u.sealer != nil {
+		plain, _ = encryption.PlaintextSize(size)
+	}
+	hashing, err := newHashingReaderAt(file, plain, u.algorithm)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, err
+	}
+	return u.seal(hashing, size), file, hashing, nil
+}
+
+// seal returns the content to upload for the plaintext read from plain
+func (u *uploadContent) seal(plain io.ReaderAt, size int64) io.ReaderAt {
+	if u.sealer == nil {
+		return plain
+	}
+	plainSize, _ := encryption.PlaintextSize(size)
+	return u.sealer.Reader(plain, plainSize)
+}
+
+// decryptDownload wraps the content of a download so it is decrypted as it
+// is read. Files that were uploaded before encryption was turned on are
+// passed through as they are.
+func (e *Engine) decryptDownload(path string, reader io.ReadCloser) (io.ReadCloser, error) {
+	c, err := e.contentCipher()
+	if err != nil || c == nil {
+		return reader, err
+	}
+
+	buffered := bufio.NewReader(reader)
+	prefix, _ := buffered.Peek(encryption.HeaderSize)
+	if !encryption.IsEncrypted(prefix) {
+		e.logger.Warnf("%s is not encrypted remotely, downloading it as is", path)
+		return readCloser{buffered, reader}, nil
+	}
+	return readCloser{c.NewReader(buffered), reader}, nil
+}
+
+// readCloser reads from one reader and closes another
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
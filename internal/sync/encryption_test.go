@@ -0,0 +1,81 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestEncryption turns encryption on with cheap key derivation
+func useTestEncryption(t *testing.T, engine *Engine, passphrase string, names bool) *encryption.Cipher {
+	t.Helper()
+	c, err := encryption.NewWithParams(passphrase, encryption.Params{LogN: 10, R: 8, P: 1})
+	require.NoError(t, err)
+
+	engine.encryption = &contentEncryption{passphrase: passphrase, encryptNames: names}
+	engine.encryption.once.Do(func() { engine.encryption.cipher = c })
+	return c
+}
+
+func TestEncryptedUploadDownloadRoundTrip(t *testing.T) {
+	engine, _, remoteDir := newLocalTestEngine(t)
+	c := useTestEncryption(t, engine, "correct horse", true)
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	secret := []byte("the launch is on tuesday")
+	require.NoError(t, os.WriteFile(filepath.Join(src, "plans.txt"), secret, 0644))
+
+	result, err := engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Uploaded)
+
+	// Only ciphertext and encrypted names reach the remote side
+	remoteFolder := c.EncryptName("project")
+	uploaded, err := os.ReadFile(filepath.Join(remoteDir, remoteFolder, c.EncryptName("plans.txt")))
+	require.NoError(t, err)
+	assert.Equal(t, encryption.EncryptedSize(int64(len(secret))), int64(len(uploaded)))
+	assert.NotContains(t, string(uploaded), "launch")
+
+	// Change detection works on the plaintext, so nothing is sent again
+	result, err = engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+	assert.Zero(t, result.Uploaded)
+
+	dst := filepath.Join(t.TempDir(), "checkout")
+	result, err = engine.DownloadFolder(ctx, "/"+remoteFolder, dst)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Downloaded)
+
+	downloaded, err := os.ReadFile(filepath.Join(dst, "plans.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, secret, downloaded)
+}
+
+func TestEncryptedDownloadWithWrongPassphraseFails(t *testing.T) {
+	engine, _, _ := newLocalTestEngine(t)
+	useTestEncryption(t, engine, "correct horse", false)
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "plans.txt"), []byte("the launch is on tuesday"), 0644))
+	_, err := engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+
+	useTestEncryption(t, engine, "battery staple", false)
+	dst := filepath.Join(t.TempDir(), "checkout")
+	result, err := engine.DownloadFolder(ctx, "/project", dst)
+	require.NoError(t, err)
+
+	assert.Zero(t, result.Downloaded)
+	require.Equal(t, 1, result.Failed)
+	assert.ErrorIs(t, result.Errors[0], encryption.ErrWrongPassphrase)
+	assert.NoFileExists(t, filepath.Join(dst, "plans.txt"), "nothing is written in place of the file")
+}
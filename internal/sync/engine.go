@@ -3,7 +3,7 @@ package sync
 
 import (
 	"context"
-	"crypto/md5"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -13,6 +13,8 @@ import (
 	"time"
 
 	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/backoff"
+	"github.com/bdstest/zohosync/internal/clock"
 	"github.com/bdstest/zohosync/internal/storage"
 	"github.com/bdstest/zohosync/pkg/types"
 	"github.com/bdstest/zohosync/internal/utils"
@@ -21,26 +23,92 @@ import (
 
 // Engine represents the synchronization engine
 type Engine struct {
-	apiClient    *api.Client
-	database     *storage.Database
-	watcher      *fsnotify.Watcher
-	config       *types.Config
-	logger       *utils.Logger
-	isRunning    bool
-	stopChan     chan struct{}
-	mu           sync.RWMutex
-	syncFolders  []types.FolderConfig
+	backend        api.RemoteBackend
+	database       *storage.Database
+	watcher        *fsnotify.Watcher
+	config         *types.Config
+	logger         *utils.Logger
+	isRunning      bool
+	stopChan       chan struct{}
+	mu             sync.RWMutex
+	syncFolders    []types.FolderConfig
+	concurrency    *ConcurrencyController
+	pool           *TransferPool
+	coalescer      *changeCoalescer
+	coalesceWindow time.Duration
+	paused         bool
+	offline        bool
+	reachable      reachabilityFunc
+	syncTrigger    chan struct{}
+	transfers      *transferRegistry
+	errorFeed      chan *SyncError
+	progress       ProgressFunc
+	uploadProgress UploadProgressFunc
+	downloadProgress DownloadProgressFunc
+	retry          *ErrorRecovery
+	stableFor      time.Duration
+	clock          clock.Clock
+	alerts         *alerter
+	resyncs        *resyncSet
+	deletions      *deletionGate
+	encryption     *contentEncryption
+	initialDone    chan struct{}
+	chunkSize      int64
+	chunkWorkers   int
+	pathLocks      *pathLocks
+	scan           *scanThrottle
+	watchFailures  *watchFailures
+	ignores        *ignoreFiles
+	storage        *storageFull
+	tallies        *syncTallies
+	remoteRoots    *remoteRoots
+	recent         *recentTransfers
+	contents       lazyContentCache
+	hashCheckpointEvery int64
 }
 
-// NewEngine creates a new synchronization engine
-func NewEngine(apiClient *api.Client, database *storage.Database, config *types.Config) *Engine {
+// NewEngine creates a new synchronization engine that mirrors the configured
+// folders to the given remote backend
+func NewEngine(backend api.RemoteBackend, database *storage.Database, config *types.Config) *Engine {
+	maxConcurrent := maxConcurrentSyncs(config)
+	concurrency := NewConcurrencyController(maxConcurrent)
+	retryConfig := DefaultRetryConfig()
+	retryConfig.Jitter = backoff.ParseJitter(config.Network.RetryJitter)
+
 	return &Engine{
-		apiClient:   apiClient,
-		database:    database,
-		config:      config,
-		logger:      utils.GetLogger(),
-		stopChan:    make(chan struct{}),
-		syncFolders: config.Folders,
+		backend:        backend,
+		database:       database,
+		config:         config,
+		logger:         utils.GetLogger(),
+		stopChan:       make(chan struct{}),
+		syncFolders:    config.Folders,
+		concurrency:    concurrency,
+		pool:           NewTransferPool(maxConcurrent, concurrency),
+		coalescer:      newChangeCoalescer(),
+		coalesceWindow: DefaultCoalesceWindow,
+		paused:         loadPausedState(database),
+		syncTrigger:    make(chan struct{}, 1),
+		transfers:      newTransferRegistry(),
+		errorFeed:      make(chan *SyncError, DefaultErrorFeedSize),
+		retry:          NewErrorRecovery(retryConfig),
+		stableFor:      time.Duration(config.Sync.StableFor) * time.Second,
+		clock:          clock.Real{},
+		alerts:         newAlerter(),
+		resyncs:        newResyncSet(),
+		deletions:      newDeletionGate(),
+		encryption:     newContentEncryption(config.Encryption),
+		chunkSize:      int64(config.Sync.ChunkSize) << 20,
+		chunkWorkers:   config.Sync.ChunkConcurrency,
+		reachable:      newReachability(backend),
+		pathLocks:      newPathLocks(),
+		scan:           newScanThrottle(config.Sync),
+		watchFailures:  newWatchFailures(),
+		ignores:        newIgnoreFiles(),
+		storage:        newStorageFull(),
+		tallies:        newSyncTallies(),
+		remoteRoots:    newRemoteRoots(),
+		recent:         newRecentTransfers(),
+		hashCheckpointEvery: utils.DefaultHashCheckpointEvery,
 	}
 }
 
@@ -60,10 +128,19 @@ func (e *Engine) Start(ctx context.Context) error {
 	}
 	e.watcher = watcher
 
+	// Record the folder roots so per-folder status can group files by them
+	roots := make([]string, 0, len(e.syncFolders))
+	for _, folder := range e.syncFolders {
+		roots = append(roots, folder.Local)
+	}
+	if err := e.database.SetSyncFolders(roots); err != nil {
+		e.logger.Errorf("Failed to record sync folders: %v", err)
+	}
+
 	// Add folders to watch
 	for _, folder := range e.syncFolders {
 		if folder.Enabled {
-			if err := e.addWatchRecursive(folder.Local); err != nil {
+			if err := e.addWatchRecursive(ctx, folder.Local); err != nil {
 				e.logger.Errorf("Failed to watch folder %s: %v", folder.Local, err)
 			} else {
 				e.logger.Infof("Watching folder: %s", folder.Local)
@@ -71,11 +148,27 @@ func (e *Engine) Start(ctx context.Context) error {
 		}
 	}
 
+	if client, ok := e.backend.(*api.Client); ok && client.ReadOnly() {
+		e.logger.Warnf("Local changes will not be uploaded: %v", api.ErrReadOnly)
+	}
+
 	e.isRunning = true
+	e.recoverInterruptedDownloads()
 	
 	// Start background goroutines
+	go e.migrateHashes()
+	go e.cleanupUploadSessions(ctx)
+	go e.pruneOperationsPeriodically(ctx)
+	go e.monitorConnectivity(ctx)
 	go e.watchFileChanges(ctx)
 	go e.periodicSync(ctx)
+	initialDone := make(chan struct{})
+	e.initialDone = initialDone
+	go func() {
+		defer close(initialDone)
+		e.resolveRemoteRoots(ctx)
+		e.runInitialSyncs(ctx)
+	}()
 
 	e.logger.Info("Sync engine started successfully")
 	return nil
@@ -91,6 +184,7 @@ func (e *Engine) Stop() error {
 	}
 
 	close(e.stopChan)
+	e.pool.Close()
 	
 	if e.watcher != nil {
 		e.watcher.Close()
@@ -101,18 +195,53 @@ func (e *Engine) Stop() error {
 	return nil
 }
 
-// addWatchRecursive adds a directory and all its subdirectories to the watcher
-func (e *Engine) addWatchRecursive(dir string) error {
-	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+// addWatchRecursive adds a directory and all its subdirectories to the
+// watcher, stopping if ctx is cancelled. A directory that cannot be read or
+// watched is remembered for WatchStatus.
+func (e *Engine) addWatchRecursive(ctx context.Context, dir string) error {
+	return utils.WalkWithContext(ctx, dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
+			e.watchFailures.record(path, err)
 			return err
 		}
 		
 		if info.IsDir() {
-			return e.watcher.Add(path)
+			return e.addWatch(path)
 		}
 		return nil
-	})
+	}, nil)
+}
+
+// ScanFolder queues every existing file under dir for synchronization and
+// returns the number of entries queued. It is used for the initial scan of a
+// newly added sync folder, and stops if ctx is cancelled. progress, if not
+// nil, is called from time to time with the number of entries scanned.
+func (e *Engine) ScanFolder(ctx context.Context, dir string, progress func(scanned int)) (int, error) {
+	count := 0
+	err := utils.WalkWithContext(ctx, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if e.shouldIgnoreFile(path) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		e.queueFileForSync(path, fsnotify.Create)
+		count++
+		return nil
+	}, progress)
+	if err != nil {
+		return count, fmt.Errorf("failed to scan %s: %w", dir, err)
+	}
+
+	e.logger.Infof("Queued %d entries from %s", count, dir)
+	return count, nil
 }
 
 // watchFileChanges monitors file system changes
@@ -127,7 +256,7 @@ func (e *Engine) watchFileChanges(ctx context.Context) {
 			if !ok {
 				return
 			}
-			e.handleFileEvent(event)
+			e.handleFileEvent(ctx, event)
 		case err, ok := <-e.watcher.Errors:
 			if !ok {
 				return
@@ -138,7 +267,7 @@ func (e *Engine) watchFileChanges(ctx context.Context) {
 }
 
 // handleFileEvent processes file system events
-func (e *Engine) handleFileEvent(event fsnotify.Event) {
+func (e *Engine) handleFileEvent(ctx context.Context, event fsnotify.Event) {
 	e.logger.Debugf("File event: %s %s", event.Op.String(), event.Name)
 
 	// Skip temporary files and hidden files
@@ -146,27 +275,23 @@ func (e *Engine) handleFileEvent(event fsnotify.Event) {
 		return
 	}
 
-	// Determine operation type
-	var syncRequired bool
-	
-	switch {
-	case event.Op&fsnotify.Create == fsnotify.Create:
-		syncRequired = true
-		e.logger.Debugf("File created: %s", event.Name)
-	case event.Op&fsnotify.Write == fsnotify.Write:
-		syncRequired = true
-		e.logger.Debugf("File modified: %s", event.Name)
-	case event.Op&fsnotify.Remove == fsnotify.Remove:
-		syncRequired = true
-		e.logger.Debugf("File removed: %s", event.Name)
-	case event.Op&fsnotify.Rename == fsnotify.Rename:
-		syncRequired = true
-		e.logger.Debugf("File renamed: %s", event.Name)
-	}
-
-	if syncRequired {
-		// Queue file for synchronization
-		go e.queueFileForSync(event.Name, event.Op)
+	// Removals, renames and new directories are buffered briefly so that
+	// whole-directory operations can be coalesced into a single remote call
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		e.bufferDirectoryChange(ctx, event, false)
+		return
+	}
+	if event.Op&fsnotify.Create == fsnotify.Create {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			e.bufferDirectoryChange(ctx, event, true)
+			return
+		}
+	}
+
+	// File creates and writes are buffered as well, so that an editor
+	// saving through a temp file and a rename is seen as one change
+	if event.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+		e.bufferFileWrite(ctx, event)
 	}
 }
 
@@ -178,6 +303,11 @@ func (e *Engine) shouldIgnoreFile(path string) bool {
 	if strings.HasPrefix(name, ".") {
 		return true
 	}
+
+	// Conflict copies in quarantine and the local trash stay local
+	if isQuarantined(path) || isInLocalTrash(path) {
+		return true
+	}
 	
 	// Ignore temporary files
 	tmpExtensions := []string{".tmp", ".temp", ".swp", ".swo", "~"}
@@ -194,12 +324,26 @@ func (e *Engine) shouldIgnoreFile(path string) bool {
 			return true
 		}
 	}
-	
+
+	if folder := e.folderForPath(path); folder != nil {
+		return e.excludedByFolder(folder, path)
+	}
 	return false
 }
 
-// queueFileForSync adds a file to the sync queue
+// queueFileForSync adds a file to the sync queue. A file synced before
+// keeps its remote mapping, so its sync replaces the remote copy rather than
+// uploading a new one; one unchanged since its last sync is left alone. A
+// path that changed between file and folder keeps the record of its remote
+// entry, which its sync then replaces.
 func (e *Engine) queueFileForSync(filePath string, operation fsnotify.Op) {
+	// Stat and save under the path's lock, so the last change to be
+	// queued is the one recorded
+	lock := e.pathLocks.acquire(filePath)
+	defer e.pathLocks.release(filePath)
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
 	// Get file info
 	fileInfo, err := os.Stat(filePath)
 	if err != nil && !os.IsNotExist(err) {
@@ -207,6 +351,12 @@ func (e *Engine) queueFileForSync(filePath string, operation fsnotify.Op) {
 		return
 	}
 
+	existing, err := e.database.GetFileMetadata(filePath)
+	if err != nil {
+		e.logger.Errorf("Failed to look up %s: %v", filePath, err)
+		return
+	}
+
 	// Create file metadata
 	metadata := &types.FileMetadata{
 		Path:        filePath,
@@ -217,46 +367,81 @@ func (e *Engine) queueFileForSync(filePath string, operation fsnotify.Op) {
 	if fileInfo != nil {
 		metadata.Size = fileInfo.Size()
 		metadata.ModifiedTime = fileInfo.ModTime()
-		
-		// Calculate hash for files (not directories)
-		if !metadata.IsDirectory {
-			hash, err := e.calculateFileHash(filePath)
-			if err != nil {
-				e.logger.Errorf("Failed to calculate hash for %s: %v", filePath, err)
-			} else {
-				metadata.Hash = hash
-			}
+		metadata.Mode = uint32(fileInfo.Mode().Perm())
+		// The hash is left empty here and computed by the upload as it
+		// reads the file, rather than reading the whole file twice
+	}
+
+	if fileInfo != nil && existing != nil && existing.RemoteID != "" {
+		switch {
+		case existing.IsDirectory != metadata.IsDirectory:
+			metadata.IsDirectory = existing.IsDirectory
+		case existing.SyncStatus == "synced" && unchangedSince(metadata, existing):
+			return
 		}
+		metadata.RemoteID = existing.RemoteID
 	}
 
 	// Save to database
 	if err := e.database.SaveFileMetadata(metadata); err != nil {
 		e.logger.Errorf("Failed to save file metadata: %v", err)
 	}
+	lock.queued++
 
 	e.logger.Debugf("Queued file for sync: %s", filePath)
 }
 
-// calculateFileHash calculates MD5 hash of a file
+// unchangedSince reports whether a local entry is as recorded at its last
+// sync. Folders have no content to change.
+func unchangedSince(local, record *types.FileMetadata) bool {
+	return local.IsDirectory || local.Size == record.Size && local.ModifiedTime.Equal(record.ModifiedTime)
+}
+
+// calculateFileHash hashes a file with the configured algorithm, held back
+// while a startup scan runs. Files too large to hash again lightly are
+// hashed with checkpoints.
 func (e *Engine) calculateFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
+	if info, err := os.Stat(filePath); err == nil && info.Mode().IsRegular() && info.Size() > e.hashCheckpointEvery {
+		return e.hashResumable(filePath)
 	}
-	defer file.Close()
+	return e.scan.hashFile(filePath, e.hashAlgorithm())
+}
 
-	hash := md5.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
+// hashAlgorithm returns the configured hash algorithm or the default
+func (e *Engine) hashAlgorithm() string {
+	if e.config.Sync.HashAlgorithm == "" {
+		return utils.DefaultHashAlgorithm
 	}
+	return e.config.Sync.HashAlgorithm
+}
 
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+// migrateHashes re-hashes files recorded with a different algorithm so that
+// all stored hashes are comparable. It counts as a startup scan.
+func (e *Engine) migrateHashes() {
+	defer e.scan.begin()()
+
+	length, err := utils.HashLength(e.hashAlgorithm())
+	if err != nil {
+		e.logger.Errorf("Cannot migrate file hashes: %v", err)
+		return
+	}
+
+	if _, err := e.database.MigrateHashes(length, e.calculateFileHash); err != nil {
+		e.logger.Errorf("Failed to migrate file hashes: %v", err)
+	}
 }
 
-// periodicSync performs periodic synchronization
+// periodicSync performs periodic synchronization. Folders with their own
+// interval are synced on separate schedules; everything else follows the
+// global interval.
 func (e *Engine) periodicSync(ctx context.Context) {
-	interval := time.Duration(e.config.Sync.Interval) * time.Second
-	ticker := time.NewTicker(interval)
+	for _, folder := range e.syncFolders {
+		if folder.Enabled && folder.Interval > 0 {
+			go e.periodicFolderSync(ctx, folder)
+		}
+	}
+
+	ticker := e.clock.NewTicker(e.globalInterval())
 	defer ticker.Stop()
 
 	for {
@@ -265,57 +450,179 @@ func (e *Engine) periodicSync(ctx context.Context) {
 			return
 		case <-e.stopChan:
 			return
-		case <-ticker.C:
+		case <-ticker.C():
+			e.performSyncWhere(ctx, e.followsGlobalInterval, PriorityBackground)
+		case <-e.syncTrigger:
 			e.performSync(ctx)
 		}
 	}
 }
 
-// performSync executes a synchronization cycle
-func (e *Engine) performSync(ctx context.Context) {
+// performSync executes a synchronization cycle that was asked for and
+// returns what it did
+func (e *Engine) performSync(ctx context.Context) *SyncResult {
+	return e.performSyncWhere(ctx, nil, PriorityOnDemand)
+}
+
+// performSyncWhere executes a synchronization cycle for the pending files
+// accepted by include, or all pending files if include is nil, queueing
+// their transfers in the transfer pool at priority. The result counts
+// what the engine did while the cycle ran.
+func (e *Engine) performSyncWhere(ctx context.Context, include func(path string) bool, priority int) *SyncResult {
+	result := &SyncResult{}
+	if e.IsPaused() {
+		e.logger.Debug("Sync is paused, leaving pending files queued")
+		return result
+	}
+	if e.IsOffline() {
+		e.logger.Debug("Remote is unreachable, leaving pending files queued")
+		return result
+	}
+
 	e.logger.Info("Starting sync cycle")
-	
-	// Get pending files
-	pendingFiles, err := e.database.GetPendingFiles()
-	if err != nil {
+	started := time.Now()
+	stop := e.tallies.add(result)
+	defer func() {
+		stop()
+		result.Duration = time.Since(started)
+	}()
+	e.resumeUploads()
+
+	// Pending files are read and queued a batch at a time, so a huge tree
+	// is never held in memory at once. The queue policy orders each batch.
+	policy := e.queuePolicy()
+	batch := make([]types.FileMetadata, 0, pendingBatchSize)
+	total := 0
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		total += len(batch)
+		e.logger.Infof("Found %d files to sync (%s)", len(batch), policy)
+		err := e.queueBatch(ctx, orderQueue(batch, policy), priority)
+		batch = batch[:0]
+		return err
+	}
+
+	err := e.database.IteratePendingFiles(pendingBatchSize, func(file types.FileMetadata) error {
+		if include != nil && !include(file.Path) {
+			return nil
+		}
+		batch = append(batch, file)
+		if len(batch) < pendingBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil && !errors.Is(err, errQueueClosed) {
 		e.logger.Errorf("Failed to get pending files: %v", err)
-		return
+		return result
 	}
 
-	if len(pendingFiles) == 0 {
+	if total == 0 {
 		e.logger.Debug("No pending files to sync")
-		return
+		return result
+	}
+	if err := e.database.SaveSyncCycle(started, time.Now()); err != nil {
+		e.logger.Warnf("Failed to record sync cycle: %v", err)
 	}
+	e.logger.Infof("Sync cycle completed (effective concurrency: %d)", e.concurrency.Limit())
+	return result
+}
 
-	e.logger.Infof("Found %d files to sync", len(pendingFiles))
+// pendingBatchSize is how many pending files a sync cycle reads and queues
+// at a time
+const pendingBatchSize = 1000
+
+// errQueueClosed stops a sync cycle whose transfers can no longer be queued
+var errQueueClosed = errors.New("transfer queue closed")
+
+// queueBatch runs the sync of files in the transfer pool at priority and
+// waits for all of them to finish
+func (e *Engine) queueBatch(ctx context.Context, files []types.FileMetadata, priority int) error {
+	var handles []*TransferHandle
+	var err error
+	for _, file := range files {
+		f := file
+		handle, submitErr := e.pool.Submit(ctx, TransferJob{
+			Key:      f.Path,
+			Priority: priority,
+			Run: func(ctx context.Context) error {
+				if e.isStopping() {
+					// Shutting down: leave the file pending for the next start
+					return context.Canceled
+				}
+				if e.IsOffline() {
+					// Went offline while queued: leave it for the drain
+					return nil
+				}
+				return e.syncFile(ctx, &f)
+			},
+		})
+		if submitErr != nil {
+			err = errQueueClosed
+			break
+		}
+		handles = append(handles, handle)
+	}
 
-	// Process files with limited concurrency
-	maxConcurrent := e.config.Sync.MaxConcurrentSyncs
-	if maxConcurrent <= 0 {
-		maxConcurrent = 3
+	for _, handle := range handles {
+		<-handle.Done()
 	}
+	return err
+}
+
+// syncFile synchronizes a single file and returns the transfer error, if any
+func (e *Engine) syncFile(ctx context.Context, metadata *types.FileMetadata) error {
+	e.logger.Debugf("Syncing file: %s", metadata.Path)
 
-	sem := make(chan struct{}, maxConcurrent)
-	var wg sync.WaitGroup
+	ctx, done := e.beginTransfer(ctx, metadata.Path)
+	defer done()
 
-	for _, file := range pendingFiles {
-		wg.Add(1)
-		go func(f types.FileMetadata) {
-			defer wg.Done()
-			sem <- struct{}{} // Acquire
-			defer func() { <-sem }() // Release
+	lock := e.pathLocks.acquire(metadata.Path)
+	defer e.pathLocks.release(metadata.Path)
+	generation := lock.queueGeneration()
 
-			e.syncFile(ctx, &f)
-		}(file)
+	strategy := e.strategyForPath(metadata.Path)
+
+	// Don't upload a file that is still being written
+	if info, err := os.Stat(metadata.Path); err == nil && !info.IsDir() && strategy.AllowsUpload() {
+		stable, err := e.waitUntilStable(ctx, metadata.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil && !stable {
+			e.logger.Infof("%s is still changing, deferring it to a later sync", metadata.Path)
+			return nil
+		}
+		if e.config.Sync.SkipOpenFiles {
+			if open, err := isOpenForWriting(metadata.Path); err == nil && open {
+				e.logger.Infof("%s is open for writing, deferring it to a later sync", metadata.Path)
+				return nil
+			}
+		}
 	}
 
-	wg.Wait()
-	e.logger.Info("Sync cycle completed")
-}
+	// A path that changed between file and folder locally replaces its
+	// remote entry of the other kind
+	if info, err := os.Stat(metadata.Path); err == nil && metadata.RemoteID != "" && info.IsDir() != metadata.IsDirectory {
+		if !strategy.AllowsUpload() {
+			return e.skipFile(metadata, strategy)
+		}
+		return e.replaceRetyped(ctx, metadata)
+	}
 
-// syncFile synchronizes a single file
-func (e *Engine) syncFile(ctx context.Context, metadata *types.FileMetadata) {
-	e.logger.Debugf("Syncing file: %s", metadata.Path)
+	// Keep other clients off the remote copy while this one may replace it
+	if _, err := os.Stat(metadata.Path); err == nil && strategy.AllowsUpload() {
+		release, held := e.lockRemoteCopy(ctx, metadata)
+		if held {
+			return nil
+		}
+		defer release()
+	}
 
 	// Log sync operation start
 	if err := e.database.LogSyncOperation(metadata.ID, "sync", "started", ""); err != nil {
@@ -328,49 +635,169 @@ func (e *Engine) syncFile(ctx context.Context, metadata *types.FileMetadata) {
 
 	var syncErr error
 
-	switch {
+	switch direction := e.resyncs.take(metadata.Path); {
+	case direction == ResyncUp && fileExists:
+		syncErr = e.uploadFile(ctx, metadata)
+	case direction == ResyncDown && metadata.RemoteID != "":
+		syncErr = e.downloadFile(ctx, metadata)
+	case fileExists && metadata.IsDirectory && metadata.RemoteID != "":
+		// A folder has no content; one that exists on both sides is in sync
 	case fileExists && metadata.RemoteID == "":
 		// Local file, needs upload
+		if !strategy.AllowsUpload() {
+			return e.skipFile(metadata, strategy)
+		}
 		syncErr = e.uploadFile(ctx, metadata)
 	case !fileExists && metadata.RemoteID != "":
 		// Remote file, needs download
+		if !strategy.AllowsDownload() {
+			return e.skipFile(metadata, strategy)
+		}
 		syncErr = e.downloadFile(ctx, metadata)
 	case fileExists && metadata.RemoteID != "":
-		// File exists both locally and remotely, check for conflicts
-		syncErr = e.resolveConflict(ctx, metadata)
+		// File exists both locally and remotely; one-way folders always
+		// take their source side, otherwise check for conflicts
+		switch {
+		case !strategy.AllowsUpload():
+			syncErr = e.downloadFile(ctx, metadata)
+		case !strategy.AllowsDownload():
+			syncErr = e.uploadFile(ctx, metadata)
+		default:
+			syncErr = e.resolveConflict(ctx, metadata)
+		}
 	default:
 		// File doesn't exist anywhere, mark as synced
 		metadata.SyncStatus = "synced"
 		syncErr = e.database.SaveFileMetadata(metadata)
 	}
 
+	if fileExists && metadata.RemoteID != "" && api.IsNotFound(syncErr) {
+		// The file was deleted remotely
+		if e.followsRemoteDeletion(metadata, strategy) {
+			return e.deleteLocalCopy(metadata)
+		}
+		if strategy.AllowsUpload() {
+			// The local copy changed since; it wins and is uploaded anew
+			metadata.RemoteID = ""
+			syncErr = e.uploadFile(ctx, metadata)
+		}
+	}
+
 	// Update sync status
-	if syncErr != nil {
+	if errors.Is(syncErr, errConflictUnresolved) {
+		// Not a failure; the file waits for the user
+		metadata.SyncStatus = "conflict"
+		syncErr = nil
+		e.database.LogSyncOperation(metadata.ID, "sync", "conflict", "")
+	} else if syncErr != nil && (errors.Is(syncErr, context.Canceled) || ctx.Err() == context.Canceled) {
+		// Cancelled transfers are retried on a later cycle rather than failed
+		e.logger.Infof("Transfer of %s cancelled, leaving it pending", metadata.Path)
+		metadata.SyncStatus = "pending"
+		e.database.LogSyncOperation(metadata.ID, "sync", "cancelled", "")
+	} else if syncErr != nil && e.deferredOffline(ctx, syncErr) {
+		// Not the file's fault; it is synced once the remote is back
+		e.logger.Infof("Remote unreachable while syncing %s, leaving it pending", metadata.Path)
+		metadata.SyncStatus = "pending"
+		e.database.LogSyncOperation(metadata.ID, "sync", "deferred", syncErr.Error())
+	} else if syncErr != nil && e.deferredStorageFull(ctx, metadata.Path, syncErr) {
+		// Not the file's fault either; it is uploaded once space is freed
+		e.logger.Infof("Remote storage full, leaving %s pending", metadata.Path)
+		metadata.SyncStatus = "pending"
+		e.database.LogSyncOperation(metadata.ID, "sync", "deferred", syncErr.Error())
+	} else if syncErr != nil && errors.Is(syncErr, errUploadMismatch) {
+		// The content was likely damaged on the way; it is sent again
+		// rather than resolved against the damaged remote copy
+		e.logger.Warnf("Upload of %s did not verify, leaving it pending: %v", metadata.Path, syncErr)
+		metadata.SyncStatus = "pending"
+		e.resyncs.set(metadata.Path, ResyncUp)
+		e.database.LogSyncOperation(metadata.ID, "sync", "unverified", syncErr.Error())
+	} else if syncErr != nil {
 		e.logger.Errorf("Failed to sync file %s: %v", metadata.Path, syncErr)
 		metadata.SyncStatus = "error"
-		e.database.LogSyncOperation(metadata.ID, "sync", "failed", syncErr.Error())
+		e.reportError("sync", metadata.Path, syncErr)
+		e.recordFailure(metadata, syncErr)
 	} else {
 		metadata.SyncStatus = "synced"
+		if metadata.Hash == "" && !metadata.IsDirectory {
+			// Transfers that could not hash the content as they went, such
+			// as chunked uploads, leave it to be computed here
+			if hash, err := e.calculateFileHash(metadata.Path); err == nil {
+				metadata.Hash = hash
+			}
+		}
+		e.clearFailures(metadata.Path)
 		e.database.LogSyncOperation(metadata.ID, "sync", "success", "")
 	}
 
-	e.database.SaveFileMetadata(metadata)
+	if err := e.saveSyncResult(lock, generation, metadata); err != nil {
+		e.logger.Errorf("Failed to save sync result of %s: %v", metadata.Path, err)
+	}
+	switch metadata.SyncStatus {
+	case "synced":
+		if err := e.database.ResetSyncAttempts(metadata.Path); err != nil {
+			e.logger.Warnf("Failed to reset retry count of %s: %v", metadata.Path, err)
+		}
+	case "error":
+		e.countFailure(metadata, syncErr)
+	}
+	return syncErr
 }
 
-// uploadFile uploads a local file to remote storage
+// skipFile records that a file was left alone because its folder's strategy
+// does not allow the required transfer direction
+func (e *Engine) skipFile(metadata *types.FileMetadata, strategy SyncStrategy) error {
+	e.logger.Debugf("Skipping %s: folder is %s", metadata.Path, strategy)
+
+	metadata.SyncStatus = "skipped"
+	e.tallies.update(func(result *SyncResult) { result.Skipped++ })
+	e.database.LogSyncOperation(metadata.ID, "sync", "skipped", string(strategy))
+	return e.database.SaveFileMetadata(metadata)
+}
+
+// uploadFile uploads a local file to remote storage, into the remote
+// folder of its local parent below the remote folder of its sync folder
 func (e *Engine) uploadFile(ctx context.Context, metadata *types.FileMetadata) error {
+	if folder := e.folderForPath(metadata.Path); folder != nil && metadata.IsDirectory && filepath.Clean(folder.Local) == metadata.Path {
+		// The sync folder itself is its remote folder
+		rootID, err := e.remoteFolderRoot(ctx, *folder)
+		if err != nil {
+			return err
+		}
+		metadata.RemoteID = rootID
+		return nil
+	}
+
+	parentID, err := e.remoteParent(ctx, metadata.Path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve remote folder: %w", err)
+	}
+	return e.uploadFileTo(ctx, metadata, parentID)
+}
+
+// uploadFileTo uploads a local file into the remote folder parentID
+func (e *Engine) uploadFileTo(ctx context.Context, metadata *types.FileMetadata, parentID string) error {
 	e.logger.Infof("Uploading file: %s", metadata.Path)
 
+	name, err := e.remoteName(metadata.Path)
+	if err != nil {
+		return err
+	}
+	if name, err = e.sealName(name); err != nil {
+		return err
+	}
+
 	if metadata.IsDirectory {
 		// Create directory remotely
-		// This is a simplified implementation - would need proper parent resolution
-		folderInfo, err := e.apiClient.CreateFolder(ctx, "root", filepath.Base(metadata.Path))
+		folderInfo, err := e.backend.CreateFolder(ctx, parentID, name)
 		if err != nil {
 			return fmt.Errorf("failed to create remote folder: %w", err)
 		}
 		metadata.RemoteID = folderInfo.ID
 		return nil
 	}
+	if e.uploadsHalted() {
+		return errStorageFull
+	}
 
 	// For files, initiate upload
 	fileInfo, err := os.Stat(metadata.Path)
@@ -378,24 +805,122 @@ func (e *Engine) uploadFile(ctx context.Context, metadata *types.FileMetadata) e
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	uploadInfo, err := e.apiClient.InitiateUpload(ctx, filepath.Base(metadata.Path), fileInfo.Size(), "root")
+	if copied, err := e.copyDuplicate(ctx, metadata, name, fileInfo.Size(), parentID); err != nil {
+		e.logger.Warnf("Server-side copy failed for %s, uploading instead: %v", metadata.Path, err)
+	} else if copied {
+		return nil
+	}
+
+	var uploadMetadata *api.UploadMetadata
+	if e.config.Sync.PreserveMetadata {
+		uploadMetadata = &api.UploadMetadata{
+			ModifiedTime: fileInfo.ModTime(),
+			Executable:   fileInfo.Mode()&0111 != 0,
+		}
+	}
+
+	content, err := e.newUploadContent(metadata.Path)
+	if err != nil {
+		return fmt.Errorf("failed to prepare upload: %w", err)
+	}
+	size := e.remoteSize(fileInfo.Size())
+
+	uploadInfo, err := e.uploadSession(ctx, metadata, name, size, parentID, uploadMetadata)
 	if err != nil {
 		return fmt.Errorf("failed to initiate upload: %w", err)
 	}
+	uploadInfo.IfMatch = metadata.RemoteVersion
+
+	started := time.Now()
+	remoteInfo, err := e.sendUpload(ctx, content, uploadInfo, size)
+	if err != nil {
+		return err
+	}
+	if remoteInfo.ID != "" {
+		// Kept even if the upload does not verify, so sending it again
+		// replaces the remote copy
+		metadata.RemoteID = remoteInfo.ID
+	}
+	hash, err := e.verifyUpload(ctx, metadata, remoteInfo, size, content.hash)
+	if err != nil {
+		return err
+	}
+	if hash != "" {
+		metadata.Hash = hash
+	}
+	metadata.Size = fileInfo.Size()
+	e.recordTransfer(metadata, types.DirectionUpload, size, started)
+	e.rememberTransfer(metadata.Path, types.DirectionUpload, hash, remoteInfo)
+	e.cacheContent(hash, metadata.Path)
+	e.uploadSucceeded()
 
-	// Upload would continue here with actual file transfer
-	// This is a skeleton implementation
-	e.logger.Infof("Upload initiated for %s with ID: %s", metadata.Path, uploadInfo.UploadID)
-	
 	return nil
 }
 
+// copyDuplicate avoids re-uploading content that already exists remotely by
+// copying the existing remote file server-side into parentID as name. It
+// reports whether a copy was made; one that turns out not to hold the
+// content is deleted again, leaving the file to be uploaded. Only files new
+// to the remote are copied: a file that already has a remote copy is
+// replaced by an upload, which is checked against the remote version.
+func (e *Engine) copyDuplicate(ctx context.Context, metadata *types.FileMetadata, name string, size int64, parentID string) (bool, error) {
+	if metadata.RemoteID != "" {
+		return false, nil
+	}
+	if metadata.Hash == "" {
+		// Only read the file up front if it can have a duplicate; otherwise
+		// the upload hashes it
+		if candidate, err := e.database.HasUploadedWithSize(size, metadata.Path); err != nil || !candidate {
+			return false, err
+		}
+		hash, err := e.calculateFileHash(metadata.Path)
+		if err != nil {
+			return false, err
+		}
+		metadata.Hash = hash
+	}
+
+	source, err := e.database.FindUploadedByHash(metadata.Hash, size, metadata.Path)
+	if err != nil || source == nil {
+		return false, err
+	}
+
+	copied, err := e.backend.CopyFile(ctx, source.RemoteID, parentID, name)
+	if err != nil {
+		return false, err
+	}
+
+	// The remote file may have changed since its record was made, so the
+	// copy is checked like an upload would be
+	remoteSize := e.remoteSize(size)
+	err = e.verifyRemoteHash(copied, metadata.Hash)
+	if err == nil && copied.Size != 0 && copied.Size != remoteSize {
+		err = fmt.Errorf("%w: the copy is %d bytes, %d expected", errUploadMismatch, copied.Size, remoteSize)
+	}
+	if err == nil {
+		_, err = e.verifyUpload(ctx, metadata, copied, remoteSize, metadata.Hash)
+	}
+	if err != nil {
+		e.logger.Warnf("Copy of %s does not match %s, uploading it instead: %v", source.Path, metadata.Path, err)
+		if err := e.backend.DeleteFile(ctx, copied.ID); err != nil {
+			return false, fmt.Errorf("failed to delete mismatched copy: %w", err)
+		}
+		return false, nil
+	}
+
+	metadata.RemoteID = copied.ID
+	metadata.Size = size
+	e.logger.Infof("Copied %s from existing remote content of %s", metadata.Path, source.Path)
+	return true, nil
+}
+
 // downloadFile downloads a remote file to local storage
 func (e *Engine) downloadFile(ctx context.Context, metadata *types.FileMetadata) error {
 	e.logger.Infof("Downloading file: %s", metadata.Path)
+	started := time.Now()
 
 	// Get remote file info
-	remoteInfo, err := e.apiClient.GetFileInfo(ctx, metadata.RemoteID)
+	remoteInfo, err := e.backend.GetFileInfo(ctx, metadata.RemoteID)
 	if err != nil {
 		return fmt.Errorf("failed to get remote file info: %w", err)
 	}
@@ -405,43 +930,159 @@ func (e *Engine) downloadFile(ctx context.Context, metadata *types.FileMetadata)
 		return os.MkdirAll(metadata.Path, 0755)
 	}
 
-	// Download file content
-	reader, err := e.apiClient.DownloadFile(ctx, metadata.RemoteID)
-	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
-	}
-	defer reader.Close()
-
 	// Ensure local directory exists
 	if err := os.MkdirAll(filepath.Dir(metadata.Path), 0755); err != nil {
 		return fmt.Errorf("failed to create local directory: %w", err)
 	}
 
-	// Create local file
-	localFile, err := os.Create(metadata.Path)
+	// Write to a hidden temp file so a cancelled or failed download never
+	// leaves a partial file in place. The download is marked in the
+	// database so one cut short by a crash is recovered on the next start.
+	e.markDownloading(metadata)
+	tempPath := downloadTempPath(metadata.Path)
+
+	// Content already on this machine is copied rather than downloaded
+	hash := e.copyLocalContent(metadata, remoteInfo, tempPath)
+	fetched := hash == ""
+	var written int64
+	if fetched {
+		if hash, written, err = e.fetchRemoteContent(ctx, metadata, remoteInfo, tempPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tempPath, metadata.Path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to move download into place: %w", err)
+	}
+	metadata.Hash = hash
+	metadata.RemoteModified = remoteInfo.ModifiedTime
+
+	if e.config.Sync.PreserveMetadata {
+		if err := e.applyRemoteMetadata(metadata, remoteInfo); err != nil {
+			return err
+		}
+	}
+
+	if fetched {
+		e.recordTransfer(metadata, types.DirectionDownload, written, started)
+	}
+	e.rememberTransfer(metadata.Path, types.DirectionDownload, hash, remoteInfo)
+	e.cacheContent(hash, metadata.Path)
+	e.logger.Infof("Downloaded file: %s", metadata.Path)
+	return nil
+}
+
+// fetchRemoteContent downloads the content of a remote file into tempPath
+// and returns its hash and the bytes written
+func (e *Engine) fetchRemoteContent(ctx context.Context, metadata *types.FileMetadata, remoteInfo *api.FileInfo, tempPath string) (string, int64, error) {
+	openContent, size := e.openDownload, remoteInfo.Size
+	if api.IsNativeDocument(remoteInfo.Type) {
+		// Native documents have no content of their own to download, and
+		// their exports are of unknown size
+		openContent, size = e.openExport, -1
+	}
+	ctx = e.downloadProgressContext(ctx, metadata.Path, size)
+	reader, localFile, err := openContent(ctx, metadata, remoteInfo, tempPath)
 	if err != nil {
-		return fmt.Errorf("failed to create local file: %w", err)
+		return "", 0, err
+	}
+	defer reader.Close()
+
+	// Hash the content as it arrives so the download is verified without
+	// reading it back. A resumed download hashes what it already has first.
+	hasher, err := utils.NewHash(e.hashAlgorithm())
+	if err == nil {
+		if info, statErr := localFile.Stat(); statErr == nil && info.Size() > 0 {
+			err = hashPartial(hasher, tempPath)
+		}
+	}
+	if err != nil {
+		localFile.Close()
+		return "", 0, fmt.Errorf("failed to hash download: %w", err)
 	}
-	defer localFile.Close()
 
 	// Copy content
-	if _, err := io.Copy(localFile, reader); err != nil {
-		return fmt.Errorf("failed to write file content: %w", err)
+	written, err := io.Copy(localFile, io.TeeReader(reader, hasher))
+	if err != nil {
+		localFile.Close()
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("failed to write file content: %w", err)
+	}
+	if err := localFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("failed to close local file: %w", err)
+	}
+	hash := fmt.Sprintf("%x", hasher.Sum(nil))
+	if err := e.verifyRemoteHash(remoteInfo, hash); err != nil {
+		os.Remove(tempPath)
+		return "", 0, fmt.Errorf("download of %s was corrupted: %w", metadata.Path, err)
+	}
+	return hash, written, nil
+}
+
+// applyRemoteMetadata restores the remote modification time and executable
+// bit on a freshly downloaded file
+func (e *Engine) applyRemoteMetadata(metadata *types.FileMetadata, remoteInfo *api.FileInfo) error {
+	mode := os.FileMode(metadata.Mode).Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	if remoteInfo.Executable {
+		mode |= 0111 & (mode >> 2) // grant execute wherever read is granted
+	}
+	if err := os.Chmod(metadata.Path, mode); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	metadata.Mode = uint32(mode)
+
+	if !remoteInfo.ModifiedTime.IsZero() {
+		if err := os.Chtimes(metadata.Path, remoteInfo.ModifiedTime, remoteInfo.ModifiedTime); err != nil {
+			return fmt.Errorf("failed to set modification time: %w", err)
+		}
+		metadata.ModifiedTime = remoteInfo.ModifiedTime
 	}
 
-	e.logger.Infof("Downloaded file: %s", metadata.Path)
 	return nil
 }
 
-// resolveConflict handles conflicts between local and remote files
+// maxConflictAttempts bounds how often a conflict is resolved again because
+// the remote file kept changing underneath an upload
+const maxConflictAttempts = 3
+
+// errConflictUnresolved is returned by resolveConflict for a conflict left
+// to the user. The file stays in conflict and is looked at again by every
+// sync until it is resolved.
+var errConflictUnresolved = errors.New("conflict left for manual resolution")
+
+// resolveConflict handles conflicts between local and remote files,
+// starting over if the remote file changes before an upload lands
 func (e *Engine) resolveConflict(ctx context.Context, metadata *types.FileMetadata) error {
+	var err error
+	for attempt := 1; attempt <= maxConflictAttempts; attempt++ {
+		err = e.resolveConflictOnce(ctx, metadata)
+		if !api.IsPreconditionFailed(err) {
+			return err
+		}
+		// The remote file changed after it was inspected; look again
+		// rather than overwrite the newer content
+		e.logger.Infof("%s changed remotely during upload, resolving again", metadata.Path)
+	}
+	return err
+}
+
+// resolveConflictOnce picks a side for a file that exists on both ends. An
+// upload only replaces the remote version it inspected.
+func (e *Engine) resolveConflictOnce(ctx context.Context, metadata *types.FileMetadata) error {
 	e.logger.Debugf("Resolving conflict for: %s", metadata.Path)
 
-	// Get remote file info
-	remoteInfo, err := e.apiClient.GetFileInfo(ctx, metadata.RemoteID)
+	// Get remote file info. The upload is conditional on this version, so
+	// it must not come from the metadata cache.
+	remoteInfo, err := e.backend.GetFileInfo(api.WithoutCache(ctx), metadata.RemoteID)
 	if err != nil {
 		return fmt.Errorf("failed to get remote file info: %w", err)
 	}
+	metadata.RemoteVersion = remoteInfo.Version
 
 	// Get local file info
 	localInfo, err := os.Stat(metadata.Path)
@@ -449,28 +1090,108 @@ func (e *Engine) resolveConflict(ctx context.Context, metadata *types.FileMetada
 		return fmt.Errorf("failed to get local file info: %w", err)
 	}
 
+	conflict := e.newConflictInfo(metadata, localInfo, remoteInfo)
+	if fallback, ok := e.manualConflictExpired(conflict); ok {
+		conflict.Strategy = fallback
+	}
+	if e.settleSyncLoop(metadata, conflict, remoteInfo) {
+		return nil
+	}
+
 	// Simple conflict resolution based on modification time
-	switch e.config.Sync.ConflictResolution {
+	keepBoth := conflict.Strategy == ConflictKeepBoth
+	switch conflict.Strategy {
 	case "newer":
-		if localInfo.ModTime().After(remoteInfo.ModifiedTime) {
-			return e.uploadFile(ctx, metadata)
-		} else {
-			return e.downloadFile(ctx, metadata)
-		}
+		conflict.Winner, keepBoth = e.newerWinner(metadata, conflict)
 	case "local":
-		return e.uploadFile(ctx, metadata)
-	case "remote":
-		return e.downloadFile(ctx, metadata)
+		conflict.Winner = types.ConflictWinnerLocal
+	case "remote", ConflictKeepBoth:
+		conflict.Winner = types.ConflictWinnerRemote
 	default:
 		// Mark as conflict for manual resolution
 		metadata.SyncStatus = "conflict"
-		return nil
+		e.recordConflict(conflict)
+		return errConflictUnresolved
+	}
+
+	if conflict.Winner == types.ConflictWinnerLocal {
+		err = e.uploadFile(ctx, metadata)
+	} else if keepBoth {
+		err = e.resolveKeepBoth(ctx, metadata, conflict)
+	} else {
+		err = e.downloadFile(ctx, metadata)
+	}
+	if err != nil {
+		return err
+	}
+
+	conflict.AutoResolved = true
+	if conflict.Winner == types.ConflictWinnerRemote {
+		// The remote content is only known once it has been downloaded
+		conflict.RemoteHash = metadata.Hash
+	}
+	e.recordConflict(conflict)
+	return nil
+}
+
+// newerWinner picks the side of a conflict under the "newer" strategy and
+// reports whether the local copy must be kept as well. Modification times
+// are compared to the second, the finest precision both sides keep. When
+// they are equal the side shown unchanged since the last successful sync
+// loses: the local file if it still has the hash recorded then, otherwise
+// the remote one if it still has the size. If neither can be told apart, or
+// the last sync is not known, the remote file wins and the local one is
+// kept as a conflict copy, so equal timestamps never lose data or depend on
+// which side is inspected first.
+func (e *Engine) newerWinner(metadata *types.FileMetadata, conflict *types.ConflictInfo) (string, bool) {
+	local := conflict.LocalModified.Truncate(time.Second)
+	remote := conflict.RemoteModified.Truncate(time.Second)
+	switch {
+	case local.After(remote):
+		return types.ConflictWinnerLocal, false
+	case remote.After(local):
+		return types.ConflictWinnerRemote, false
 	}
+
+	e.logger.Infof("%s has the same modification time on both sides, comparing content", metadata.Path)
+	switch {
+	case metadata.SyncedHash == "":
+		// Without the state of the last sync neither side can be shown
+		// unchanged
+	case conflict.LocalHash == metadata.SyncedHash:
+		return types.ConflictWinnerRemote, false
+	case conflict.RemoteSize == metadata.SyncedSize:
+		return types.ConflictWinnerLocal, false
+	}
+	return types.ConflictWinnerRemote, true
 }
 
 // GetSyncStatus returns current synchronization status
 func (e *Engine) GetSyncStatus() (*types.SyncStatus, error) {
-	return e.database.GetSyncStats()
+	status, err := e.database.GetSyncStats()
+	if err != nil {
+		return nil, err
+	}
+
+	status.Concurrency = e.concurrency.Limit()
+	pool := e.pool.Stats()
+	status.Queued, status.Transferring = pool.Queued, pool.InFlight
+	status.InProgress = pool.Queued+pool.InFlight > 0
+	for _, pending := range e.PendingDeletions() {
+		status.PendingDeletions += pending.Files
+	}
+	if metrics, err := e.database.GetSyncMetrics(); err == nil {
+		status.Metrics = metrics
+	} else {
+		e.logger.Warnf("Failed to compute sync metrics: %v", err)
+	}
+	switch {
+	case e.IsPaused():
+		status.State = types.SyncStatePaused
+	case e.IsOffline():
+		status.State = types.SyncStateOffline
+	}
+	return status, nil
 }
 
 // IsRunning returns whether the sync engine is currently running
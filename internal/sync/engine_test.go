@@ -0,0 +1,35 @@
+package sync
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestEngine creates an engine backed by a temporary database and an API
+// client pointed at a mock server using handler
+func newTestEngine(t *testing.T, config *types.Config, handler http.HandlerFunc) (*Engine, *storage.Database) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	client := api.NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+
+	if config == nil {
+		config = &types.Config{}
+	}
+	return NewEngine(client, database, config), database
+}
@@ -1,12 +1,17 @@
 package sync
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"syscall"
 	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/backoff"
 )
 
 // ErrorType represents different types of sync errors
@@ -20,9 +25,36 @@ const (
 	ErrorTypeConflict
 	ErrorTypeValidation
 	ErrorTypeTimeout
+	// ErrorTypeStorageFull means the remote drive has no space left, as
+	// opposed to ErrorTypeQuota's rate limits
+	ErrorTypeStorageFull
 	ErrorTypeUnknown
 )
 
+// String returns a short human-readable name for the error type
+func (t ErrorType) String() string {
+	switch t {
+	case ErrorTypeNetwork:
+		return "network"
+	case ErrorTypeAuth:
+		return "authentication"
+	case ErrorTypePermission:
+		return "permission"
+	case ErrorTypeQuota:
+		return "quota"
+	case ErrorTypeConflict:
+		return "conflict"
+	case ErrorTypeValidation:
+		return "validation"
+	case ErrorTypeTimeout:
+		return "timeout"
+	case ErrorTypeStorageFull:
+		return "storage_full"
+	default:
+		return "unknown"
+	}
+}
+
 // SyncError represents a sync operation error with additional context
 type SyncError struct {
 	Type      ErrorType
@@ -32,13 +64,20 @@ type SyncError struct {
 	Cause     error
 	Retryable bool
 	Timestamp time.Time
+	// RequestID is the correlation ID of the API request that failed, to
+	// look up in the request log
+	RequestID string
 }
 
 func (e *SyncError) Error() string {
+	message := e.Message
+	if e.RequestID != "" && !strings.Contains(message, e.RequestID) {
+		message += fmt.Sprintf(" (request %s)", e.RequestID)
+	}
 	if e.FilePath != "" {
-		return fmt.Sprintf("%s operation failed for %s: %s", e.Operation, e.FilePath, e.Message)
+		return fmt.Sprintf("%s operation failed for %s: %s", e.Operation, e.FilePath, message)
 	}
-	return fmt.Sprintf("%s operation failed: %s", e.Operation, e.Message)
+	return fmt.Sprintf("%s operation failed: %s", e.Operation, message)
 }
 
 func (e *SyncError) Unwrap() error {
@@ -54,6 +93,7 @@ func NewSyncError(errType ErrorType, operation, message string, cause error) *Sy
 		Cause:     cause,
 		Retryable: isRetryable(errType, cause),
 		Timestamp: time.Now(),
+		RequestID: api.RequestID(cause),
 	}
 }
 
@@ -71,6 +111,8 @@ func isRetryable(errType ErrorType, cause error) bool {
 		return true
 	case ErrorTypeQuota:
 		return false // Don't retry quota errors immediately
+	case ErrorTypeStorageFull:
+		return false // Space must be freed first
 	case ErrorTypeAuth:
 		return false // Auth errors need manual intervention
 	case ErrorTypePermission:
@@ -134,10 +176,16 @@ func ClassifyHTTPError(statusCode int, operation string, cause error) *SyncError
 		return NewSyncError(ErrorTypeQuota, operation, "Rate limit exceeded", cause)
 	case http.StatusConflict:
 		return NewSyncError(ErrorTypeConflict, operation, "Conflict detected", cause)
+	case http.StatusPreconditionFailed:
+		syncErr := NewSyncError(ErrorTypeConflict, operation, "Remote file changed", cause)
+		syncErr.Retryable = false // the same version would be rejected again
+		return syncErr
 	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
 		return NewSyncError(ErrorTypeTimeout, operation, "Request timeout", cause)
 	case http.StatusBadRequest:
 		return NewSyncError(ErrorTypeValidation, operation, "Invalid request", cause)
+	case http.StatusInsufficientStorage:
+		return NewSyncError(ErrorTypeStorageFull, operation, "Remote storage is full", cause)
 	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
 		return NewSyncError(ErrorTypeNetwork, operation, "Server error", cause)
 	default:
@@ -148,6 +196,45 @@ func ClassifyHTTPError(statusCode int, operation string, cause error) *SyncError
 	}
 }
 
+// ClassifyError converts an arbitrary error into a SyncError
+func ClassifyError(operation string, err error) *SyncError {
+	if err == nil {
+		return nil
+	}
+
+	var syncErr *SyncError
+	if errors.As(err, &syncErr) {
+		return syncErr
+	}
+
+	var statusErr *api.StatusError
+	if errors.As(err, &statusErr) {
+		return ClassifyHTTPError(statusErr.StatusCode, operation, err)
+	}
+
+	if errors.Is(err, api.ErrReadOnly) {
+		return NewSyncError(ErrorTypePermission, operation, err.Error(), err)
+	}
+
+	if errors.Is(err, errStorageFull) {
+		return NewSyncError(ErrorTypeStorageFull, operation, "Remote storage is full", err)
+	}
+
+	if errors.Is(err, api.ErrTransferStalled) {
+		return NewSyncError(ErrorTypeTimeout, operation, "Transfer stalled", err)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewSyncError(ErrorTypeTimeout, operation, err.Error(), err)
+	}
+
+	if isNetworkError(err) {
+		return NewSyncError(ErrorTypeNetwork, operation, err.Error(), err)
+	}
+
+	return NewSyncError(ErrorTypeUnknown, operation, err.Error(), err)
+}
+
 // RetryConfig defines retry behavior
 type RetryConfig struct {
 	MaxAttempts    int
@@ -155,6 +242,9 @@ type RetryConfig struct {
 	MaxDelay       time.Duration
 	BackoffFactor  float64
 	RetryableTypes []ErrorType
+	// Jitter randomizes delays so that transfers failing together do not
+	// retry in lockstep
+	Jitter backoff.Jitter
 }
 
 // DefaultRetryConfig returns a sensible default retry configuration
@@ -164,6 +254,7 @@ func DefaultRetryConfig() *RetryConfig {
 		InitialDelay:  1 * time.Second,
 		MaxDelay:      30 * time.Second,
 		BackoffFactor: 2.0,
+		Jitter:        backoff.DefaultJitter,
 		RetryableTypes: []ErrorType{
 			ErrorTypeNetwork,
 			ErrorTypeTimeout,
@@ -194,22 +285,17 @@ func (rc *RetryConfig) ShouldRetry(err *SyncError, attempt int) bool {
 
 // GetDelay calculates delay before next retry attempt
 func (rc *RetryConfig) GetDelay(attempt int) time.Duration {
-	delay := float64(rc.InitialDelay) * pow(rc.BackoffFactor, float64(attempt))
-	
-	if delay > float64(rc.MaxDelay) {
-		return rc.MaxDelay
-	}
-	
-	return time.Duration(delay)
+	return rc.policy().Delay(attempt)
 }
 
-// pow is a simple power function for backoff calculation
-func pow(base float64, exp float64) float64 {
-	result := 1.0
-	for i := 0; i < int(exp); i++ {
-		result *= base
+// policy returns the backoff the config describes
+func (rc *RetryConfig) policy() backoff.Policy {
+	return backoff.Policy{
+		Initial: rc.InitialDelay,
+		Max:     rc.MaxDelay,
+		Factor:  rc.BackoffFactor,
+		Jitter:  rc.Jitter,
 	}
-	return result
 }
 
 // ErrorRecovery provides strategies for recovering from specific errors
@@ -0,0 +1,49 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/bdstest/zohosync/internal/api"
+)
+
+func TestSyncErrorCarriesRequestID(t *testing.T) {
+	cause := fmt.Errorf("download: %w", &api.StatusError{Operation: "download", StatusCode: 503, RequestID: "abc123"})
+
+	syncErr := ClassifyError("download", cause)
+	assert.Equal(t, "abc123", syncErr.RequestID)
+	assert.Equal(t, ErrorTypeNetwork, syncErr.Type)
+	assert.Contains(t, syncErr.Error(), "request abc123")
+
+	syncErr = ClassifyError("sync", errors.New("disk full"))
+	assert.Empty(t, syncErr.RequestID)
+}
+
+func TestRetryDelaysAreJittered(t *testing.T) {
+	config := DefaultRetryConfig()
+	for attempt := 0; attempt < config.MaxAttempts; attempt++ {
+		exponential := config.policy().Exponential(attempt)
+		delay := config.GetDelay(attempt)
+		assert.GreaterOrEqual(t, delay, exponential/2)
+		assert.LessOrEqual(t, delay, exponential)
+	}
+
+	// Two transfers failing together wait different times
+	delays := make(chan time.Duration, 2)
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, delay := NewErrorRecovery(DefaultRetryConfig()).HandleError(NewSyncError(ErrorTypeNetwork, "upload", "reset", nil), 1)
+			delays <- delay
+		}()
+	}
+	wg.Wait()
+	assert.NotEqual(t, <-delays, <-delays)
+}
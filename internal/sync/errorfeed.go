@@ -0,0 +1,66 @@
+package sync
+
+import (
+	"fmt"
+)
+
+// DefaultErrorFeedSize is how many unread sync errors are buffered for
+// subscribers before new ones are dropped
+const DefaultErrorFeedSize = 100
+
+// reportError classifies a failed operation, raises any alert it warrants and
+// publishes it on the error feed without blocking the sync
+func (e *Engine) reportError(operation, path string, err error) {
+	syncErr := ClassifyError(operation, err)
+	if syncErr.FilePath == "" {
+		syncErr.FilePath = path
+	}
+	e.alert(syncErr)
+
+	select {
+	case e.errorFeed <- syncErr:
+	default:
+		e.logger.Debugf("Error feed full, dropping error for %s", path)
+	}
+}
+
+// Errors returns the stream of classified sync errors for display
+func (e *Engine) Errors() <-chan *SyncError {
+	return e.errorFeed
+}
+
+// RetryFile marks a failed file pending again with a fresh retry budget and
+// starts a sync cycle
+func (e *Engine) RetryFile(path string) error {
+	if err := e.database.ResetSyncAttempts(path); err != nil {
+		return err
+	}
+	return e.setFileStatus(path, "pending", true)
+}
+
+// SkipFailedFile marks a failed file skipped so it is no longer retried
+// until it changes again
+func (e *Engine) SkipFailedFile(path string) error {
+	return e.setFileStatus(path, "skipped", false)
+}
+
+// setFileStatus updates the stored status of a tracked file
+func (e *Engine) setFileStatus(path, status string, trigger bool) error {
+	metadata, err := e.database.GetFileMetadata(path)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		return fmt.Errorf("%s is not tracked", path)
+	}
+
+	metadata.SyncStatus = status
+	if err := e.database.SaveFileMetadata(metadata); err != nil {
+		return err
+	}
+
+	if trigger {
+		e.TriggerSync()
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// exportFormat returns the format a native document of fileType is
+// downloaded as: the one set in sync.export_formats, or the type's default
+func (e *Engine) exportFormat(fileType string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(e.config.Sync.ExportFormats[fileType], "."))
+	if format == "" {
+		if formats := api.ExportFormats[fileType]; len(formats) > 0 {
+			format = formats[0]
+		}
+	}
+	return format, api.CheckExportFormat(fileType, format)
+}
+
+// remoteEntry returns the local name and size of a remote file. Encrypted
+// names and sizes are given as their plaintext. Native documents are named
+// after their export format, so "Plan" exported to docx becomes
+// "Plan.docx".
+func (e *Engine) remoteEntry(file *api.FileInfo) (string, int64) {
+	if file.IsFolder {
+		return e.openName(file.Name), file.Size
+	}
+	if !api.IsNativeDocument(file.Type) {
+		return e.openName(file.Name), e.plaintextSize(file.Size)
+	}
+	// An unsupported format is reported when the document is downloaded
+	format, _ := e.exportFormat(file.Type)
+	if format == "" || strings.EqualFold(path.Ext(file.Name), "."+format) {
+		return file.Name, file.Size
+	}
+	return file.Name + "." + format, file.Size
+}
+
+// openExport starts the export of a native document into tempPath
+func (e *Engine) openExport(ctx context.Context, metadata *types.FileMetadata, remoteInfo *api.FileInfo, tempPath string) (io.ReadCloser, *os.File, error) {
+	exporter, ok := e.backend.(api.DocumentExporter)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is a %s document, which this remote cannot export", metadata.Path, remoteInfo.Type)
+	}
+	format, err := e.exportFormat(remoteInfo.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := exporter.ExportDocument(ctx, metadata.RemoteID, format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to export document: %w", err)
+	}
+	localFile, err := os.Create(tempPath)
+	if err != nil {
+		reader.Close()
+		return nil, nil, fmt.Errorf("failed to create local file: %w", err)
+	}
+	return reader, localFile, nil
+}
@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportHandler serves a folder holding one Writer document and records
+// the export format asked for
+func exportHandler(format *string) http.HandlerFunc {
+	tree := remoteTree{}
+	tree.add("root", api.FileInfo{ID: "plan", Name: "Plan", Type: "writer"})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/files"):
+			tree.handler(w, r)
+		case r.URL.Path == "/files/plan/export":
+			*format = r.URL.Query().Get("format")
+			w.Write([]byte("PK-" + *format))
+		case r.URL.Path == "/files/plan/download":
+			http.Error(w, "native documents have no content", http.StatusBadRequest)
+		default:
+			json.NewEncoder(w).Encode(map[string]api.FileInfo{"data": {ID: "plan", Name: "Plan", Type: "writer"}})
+		}
+	}
+}
+
+func TestDownloadExportsNativeDocuments(t *testing.T) {
+	var format string
+	engine, _ := newTestEngine(t, nil, exportHandler(&format))
+
+	localDir := t.TempDir()
+	result, err := engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Downloaded)
+
+	assert.Equal(t, "docx", format)
+	data, err := os.ReadFile(filepath.Join(localDir, "Plan.docx"))
+	require.NoError(t, err)
+	assert.Equal(t, "PK-docx", string(data))
+}
+
+func TestDownloadUsesConfiguredExportFormat(t *testing.T) {
+	var format string
+	config := &types.Config{Sync: types.SyncConfig{ExportFormats: map[string]string{"writer": ".PDF"}}}
+	engine, _ := newTestEngine(t, config, exportHandler(&format))
+
+	localDir := t.TempDir()
+	_, err := engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Equal(t, "pdf", format)
+	assert.FileExists(t, filepath.Join(localDir, "Plan.pdf"))
+}
+
+func TestDownloadRejectsUnsupportedExportFormat(t *testing.T) {
+	var format string
+	config := &types.Config{Sync: types.SyncConfig{ExportFormats: map[string]string{"writer": "xlsx"}}}
+	engine, _ := newTestEngine(t, config, exportHandler(&format))
+
+	result, err := engine.DownloadFolder(context.Background(), "root", t.TempDir())
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Failed)
+	assert.Empty(t, format, "nothing is requested from the server")
+	assert.True(t, errors.Is(result.Errors[0], api.ErrExportUnsupported))
+	assert.Contains(t, result.Errors[0].Error(), "supported: docx")
+}
@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SyncIgnoreFile is the file at the root of a sync folder listing patterns
+// of paths to leave out, one per line. A pattern without a slash matches
+// any file or folder of that name, one with a slash matches paths relative
+// to the root; both use filepath.Match syntax. Lines starting with # are
+// comments.
+const SyncIgnoreFile = ".syncignore"
+
+// ignoreFiles caches the parsed .syncignore of each sync folder, reading it
+// again whenever it changes
+type ignoreFiles struct {
+	mu    sync.Mutex
+	files map[string]*ignoreFile
+}
+
+// ignoreFile is one parsed .syncignore
+type ignoreFile struct {
+	modTime  time.Time
+	patterns []string
+}
+
+// newIgnoreFiles creates an empty cache
+func newIgnoreFiles() *ignoreFiles {
+	return &ignoreFiles{files: make(map[string]*ignoreFile)}
+}
+
+// patterns returns the patterns of the .syncignore in root, if any
+func (c *ignoreFiles) patterns(root string) []string {
+	path := filepath.Join(root, SyncIgnoreFile)
+	info, err := os.Stat(path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		delete(c.files, root)
+		return nil
+	}
+	if cached, ok := c.files[root]; ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.patterns
+	}
+
+	patterns, err := readIgnorePatterns(path)
+	if err != nil {
+		return nil
+	}
+	c.files[root] = &ignoreFile{modTime: info.ModTime(), patterns: patterns}
+	return patterns
+}
+
+// readIgnorePatterns reads the patterns of an ignore file
+func readIgnorePatterns(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.TrimSuffix(filepath.ToSlash(line), "/"))
+	}
+	return patterns, scanner.Err()
+}
+
+// excludedByFolder reports whether the folder's own filters, its
+// .syncignore and included extensions, leave path out
+func (e *Engine) excludedByFolder(folder *types.FolderConfig, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(folder.Local), path)
+	if err != nil || rel == "." {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	if matchesIgnorePattern(e.ignores.patterns(folder.Local), rel) {
+		return true
+	}
+	if len(folder.IncludeExtensions) == 0 || hasExtension(path, folder.IncludeExtensions) {
+		return false
+	}
+	// Folders are kept so that the files inside them can be included; a
+	// path that is gone is left to the deletion handling
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// matchesIgnorePattern reports whether rel, a slash-separated path relative
+// to the sync folder, or one of its parent folders matches a pattern
+func matchesIgnorePattern(patterns []string, rel string) bool {
+	names := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			for i := range names {
+				if ok, _ := filepath.Match(pattern, strings.Join(names[:i+1], "/")); ok {
+					return true
+				}
+			}
+			continue
+		}
+		for _, name := range names {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasExtension reports whether path ends in one of extensions, which may
+// be given with or without the leading dot, ignoring case
+func hasExtension(path string, extensions []string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, included := range extensions {
+		if strings.EqualFold(ext, strings.TrimPrefix(included, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkSizeFor returns the upload chunk size of the folder holding path
+func (e *Engine) chunkSizeFor(path string) int64 {
+	if folder := e.folderForPath(path); folder != nil && folder.ChunkSize > 0 {
+		return int64(folder.ChunkSize) << 20
+	}
+	return e.chunkSize
+}
@@ -0,0 +1,58 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolderFiltersLeaveOutFiles(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, nil)
+	docs, code := t.TempDir(), t.TempDir()
+	engine.syncFolders = []types.FolderConfig{
+		{Local: docs, IncludeExtensions: []string{"docx", ".PDF"}},
+		{Local: code},
+	}
+
+	require.NoError(t, os.Mkdir(filepath.Join(docs, "reports.d"), 0755))
+	for _, name := range []string{"plan.docx", "scan.pdf", "notes.txt", "reports.d/q1.pdf"} {
+		require.NoError(t, os.WriteFile(filepath.Join(docs, name), nil, 0644))
+	}
+	assert.False(t, engine.shouldIgnoreFile(filepath.Join(docs, "plan.docx")))
+	assert.False(t, engine.shouldIgnoreFile(filepath.Join(docs, "scan.pdf")))
+	assert.True(t, engine.shouldIgnoreFile(filepath.Join(docs, "notes.txt")))
+	assert.False(t, engine.shouldIgnoreFile(filepath.Join(docs, "reports.d")), "folders are kept")
+	assert.False(t, engine.shouldIgnoreFile(filepath.Join(docs, "reports.d", "q1.pdf")))
+
+	ignore := "# build output\nnode_modules\n*.o\nbuild/\ndocs/*.tmp.md\n"
+	require.NoError(t, os.WriteFile(filepath.Join(code, SyncIgnoreFile), []byte(ignore), 0644))
+	assert.True(t, engine.shouldIgnoreFile(filepath.Join(code, "node_modules")))
+	assert.True(t, engine.shouldIgnoreFile(filepath.Join(code, "web", "node_modules", "lib", "index.js")))
+	assert.True(t, engine.shouldIgnoreFile(filepath.Join(code, "src", "main.o")))
+	assert.True(t, engine.shouldIgnoreFile(filepath.Join(code, "build", "app")))
+	assert.True(t, engine.shouldIgnoreFile(filepath.Join(code, "docs", "draft.tmp.md")))
+	assert.False(t, engine.shouldIgnoreFile(filepath.Join(code, "src", "main.c")))
+	assert.False(t, engine.shouldIgnoreFile(filepath.Join(code, "notes", "docs", "draft.tmp.md")))
+
+	// An edited .syncignore is read again
+	require.NoError(t, os.WriteFile(filepath.Join(code, SyncIgnoreFile), []byte("*.c\n"), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(code, SyncIgnoreFile), later, later))
+	assert.True(t, engine.shouldIgnoreFile(filepath.Join(code, "src", "main.c")))
+	assert.False(t, engine.shouldIgnoreFile(filepath.Join(code, "node_modules")))
+}
+
+func TestFolderChunkSizeOverridesGlobal(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, nil)
+	engine.chunkSize = 16 << 20
+	engine.syncFolders = []types.FolderConfig{{Local: "/photos", ChunkSize: 64}, {Local: "/docs"}}
+
+	assert.Equal(t, int64(64<<20), engine.chunkSizeFor("/photos/beach.jpg"))
+	assert.Equal(t, int64(16<<20), engine.chunkSizeFor("/docs/plan.docx"))
+	assert.Equal(t, int64(16<<20), engine.chunkSizeFor("/elsewhere/file"))
+}
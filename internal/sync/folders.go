@@ -0,0 +1,436 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SyncResult summarises a bulk folder transfer or a sync pass. The errors
+// it holds are *SyncError.
+type SyncResult = types.SyncResult
+
+// ProgressFunc is called after each file of a folder transfer completes
+type ProgressFunc func(done, total int, path string, err error)
+
+// SetProgressFunc registers a callback for folder transfer progress
+func (e *Engine) SetProgressFunc(fn ProgressFunc) {
+	e.mu.Lock()
+	e.progress = fn
+	e.mu.Unlock()
+}
+
+// reportProgress invokes the registered progress callback, if any
+func (e *Engine) reportProgress(done, total int, path string, err error) {
+	e.mu.RLock()
+	fn := e.progress
+	e.mu.RUnlock()
+
+	if fn != nil {
+		fn(done, total, path, err)
+	}
+}
+
+// DownloadFolder recreates a remote folder tree under localDir and downloads
+// every file in it, skipping files that are already present with the hash
+// recorded at their last download and renaming local copies of files that
+// were renamed remotely
+func (e *Engine) DownloadFolder(ctx context.Context, remoteFolderID, localDir string) (*SyncResult, error) {
+	start := time.Now()
+	result := &SyncResult{}
+
+	if err := os.MkdirAll(localDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", localDir, err)
+	}
+
+	// Recreate the directory structure while collecting the files. Parents
+	// are listed before their children, so each entry's local parent is
+	// known by the time it is reached.
+	var files []*types.FileMetadata
+	var entries []*PlanEntry
+	localDirs := map[string]string{".": localDir}
+	it := newRemoteIterator(ctx, e.backend, remoteFolderID, DefaultListPageSize, e.remoteEntry)
+	for {
+		entry, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list remote folder: %w", err)
+		}
+
+		parent := localDirs[path.Dir(entry.Path)]
+		localPath := filepath.Join(parent, e.localName(parent, path.Base(entry.Path)))
+		if entry.IsDirectory {
+			localDirs[entry.Path] = localPath
+			if err := os.MkdirAll(localPath, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create %s: %w", localPath, err)
+			}
+			e.database.SaveFileMetadata(&types.FileMetadata{
+				Path:        localPath,
+				RemoteID:    entry.RemoteID,
+				IsDirectory: true,
+				SyncStatus:  "synced",
+			})
+			continue
+		}
+
+		if renamed, err := e.followRemoteRename(localPath, entry); err != nil {
+			e.logger.Warnf("Failed to follow remote rename of %s: %v", localPath, err)
+		} else if renamed {
+			result.Renamed++
+			continue
+		}
+
+		files = append(files, &types.FileMetadata{
+			Path:         localPath,
+			RemoteID:     entry.RemoteID,
+			Size:         entry.Size,
+			ModifiedTime: entry.ModifiedTime,
+		})
+		entries = append(entries, entry)
+	}
+
+	tracker := newFolderTransfer(e, result, len(files))
+	for i, file := range files {
+		if e.isDownloadCurrent(file, entries[i]) {
+			tracker.skipped(file.Path)
+			continue
+		}
+
+		if !tracker.start(ctx, file, "download", e.downloadTracked) {
+			break
+		}
+	}
+	if err := tracker.wait(ctx); err != nil {
+		return result, err
+	}
+
+	result.Duration = time.Since(start)
+	e.logger.Infof("Downloaded folder %s to %s: %d downloaded, %d renamed, %d skipped, %d failed",
+		remoteFolderID, localDir, result.Downloaded, result.Renamed, result.Skipped, result.Failed)
+	return result, nil
+}
+
+// UploadFolder recreates localDir as a new folder inside remoteParentID and
+// uploads every file beneath it. Remote folder IDs are recorded as they are
+// created and unchanged files are skipped, so running it again only uploads
+// what changed. Files that cannot be read are reported in the result without
+// aborting the rest of the upload.
+func (e *Engine) UploadFolder(ctx context.Context, localDir, remoteParentID string) (*SyncResult, error) {
+	start := time.Now()
+	result := &SyncResult{}
+
+	if remoteParentID == "" {
+		remoteParentID = "root"
+	}
+
+	root, err := filepath.Abs(localDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %s: %w", localDir, err)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	// WalkDir visits a directory before its contents, so every folder is
+	// listed after its parent
+	var folders, files []*types.FileMetadata
+	var unreadable []*SyncError
+	err = filepath.WalkDir(root, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if path == root {
+				return walkErr
+			}
+			unreadable = append(unreadable, withPath(ClassifyError("upload", walkErr), path))
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if path != root && e.shouldIgnoreFile(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			folders = append(folders, &types.FileMetadata{Path: path, IsDirectory: true})
+		} else if d.Type().IsRegular() {
+			files = append(files, &types.FileMetadata{Path: path})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", root, err)
+	}
+
+	tracker := newFolderTransfer(e, result, len(files)+len(unreadable))
+	for _, syncErr := range unreadable {
+		tracker.failed(syncErr)
+	}
+
+	remoteIDs := map[string]string{filepath.Dir(root): remoteParentID}
+	for _, folder := range folders {
+		parentID, ok := remoteIDs[filepath.Dir(folder.Path)]
+		if !ok {
+			// The parent folder failed and was already reported
+			continue
+		}
+		if err := e.ensureRemoteFolder(ctx, folder, parentID); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.Failed++
+			result.Errors = append(result.Errors, withPath(ClassifyError("upload", err), folder.Path))
+			continue
+		}
+		remoteIDs[folder.Path] = folder.RemoteID
+	}
+
+	for _, file := range files {
+		parentID, ok := remoteIDs[filepath.Dir(file.Path)]
+		if !ok {
+			tracker.failed(withPath(ClassifyError("upload",
+				fmt.Errorf("remote folder for %s was not created", filepath.Dir(file.Path))), file.Path))
+			continue
+		}
+
+		if e.isUploadCurrent(file) {
+			tracker.skipped(file.Path)
+			continue
+		}
+
+		upload := func(ctx context.Context, f *types.FileMetadata) error {
+			return e.uploadTracked(ctx, f, parentID)
+		}
+		if !tracker.start(ctx, file, "upload", upload) {
+			break
+		}
+	}
+	if err := tracker.wait(ctx); err != nil {
+		return result, err
+	}
+
+	result.Duration = time.Since(start)
+	e.logger.Infof("Uploaded folder %s to %s: %d uploaded, %d skipped, %d failed",
+		root, remoteParentID, result.Uploaded, result.Skipped, result.Failed)
+	return result, nil
+}
+
+// ensureRemoteFolder sets folder.RemoteID, creating the remote folder inside
+// parentID unless an earlier upload already recorded one
+func (e *Engine) ensureRemoteFolder(ctx context.Context, folder *types.FileMetadata, parentID string) error {
+	if existing, err := e.database.GetFileMetadata(folder.Path); err == nil && existing != nil &&
+		existing.IsDirectory && existing.RemoteID != "" {
+		folder.RemoteID = existing.RemoteID
+		return nil
+	}
+
+	if err := e.uploadFileTo(ctx, folder, parentID); err != nil {
+		return err
+	}
+	folder.SyncStatus = "synced"
+	return e.database.SaveFileMetadata(folder)
+}
+
+// isUploadCurrent reports whether a local file is unchanged since it was
+// last uploaded. It fills in file.Hash as a side effect.
+func (e *Engine) isUploadCurrent(file *types.FileMetadata) bool {
+	hash, err := e.calculateFileHash(file.Path)
+	if err != nil {
+		return false
+	}
+	file.Hash = hash
+
+	existing, err := e.database.GetFileMetadata(file.Path)
+	return err == nil && existing != nil && existing.SyncStatus == "synced" && existing.Hash == hash
+}
+
+// uploadTracked uploads one file into parentID and records it as synced
+func (e *Engine) uploadTracked(ctx context.Context, file *types.FileMetadata, parentID string) error {
+	ctx, finish := e.beginTransfer(ctx, file.Path)
+	defer finish()
+
+	// Hashing reads the whole file, so unreadable files fail here rather
+	// than partway through the transfer
+	if file.Hash == "" {
+		hash, err := e.calculateFileHash(file.Path)
+		if err != nil {
+			return err
+		}
+		file.Hash = hash
+	}
+
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return err
+	}
+	file.Size = info.Size()
+	file.ModifiedTime = info.ModTime()
+	file.Mode = uint32(info.Mode().Perm())
+
+	if err := e.uploadFileTo(ctx, file, parentID); err != nil {
+		return err
+	}
+
+	file.SyncStatus = "synced"
+	return e.database.SaveFileMetadata(file)
+}
+
+// folderTransfer runs the per-file transfers of a folder operation in the
+// engine's transfer pool and accumulates their outcomes
+type folderTransfer struct {
+	engine  *Engine
+	result  *SyncResult
+	total   int
+	handles []*TransferHandle
+	mu      sync.Mutex
+	done    int
+}
+
+// newFolderTransfer creates a tracker for total files
+func newFolderTransfer(e *Engine, result *SyncResult, total int) *folderTransfer {
+	return &folderTransfer{engine: e, result: result, total: total}
+}
+
+// skipped records a file that needed no transfer
+func (t *folderTransfer) skipped(path string) {
+	t.mu.Lock()
+	t.result.Skipped++
+	t.done++
+	done := t.done
+	t.mu.Unlock()
+
+	t.engine.reportProgress(done, t.total, path, nil)
+}
+
+// failed records a file that could not be transferred
+func (t *folderTransfer) failed(syncErr *SyncError) {
+	t.mu.Lock()
+	t.result.Failed++
+	t.result.Errors = append(t.result.Errors, syncErr)
+	t.done++
+	done := t.done
+	t.mu.Unlock()
+
+	t.engine.reportProgress(done, t.total, syncErr.FilePath, syncErr)
+}
+
+// start queues the transfer of a file in the transfer pool. It returns
+// false if ctx was cancelled while waiting for room in the queue or the
+// engine is stopping.
+func (t *folderTransfer) start(ctx context.Context, file *types.FileMetadata, operation string, transfer func(context.Context, *types.FileMetadata) error) bool {
+	if t.engine.isStopping() {
+		return false
+	}
+
+	handle, err := t.engine.pool.Submit(ctx, TransferJob{
+		Key:      file.Path,
+		Priority: PriorityOnDemand,
+		Run: func(ctx context.Context) error {
+			err := transfer(ctx, file)
+			t.record(file, operation, err)
+			return err
+		},
+	})
+	if err != nil {
+		return false
+	}
+	t.handles = append(t.handles, handle)
+	return true
+}
+
+// record accounts for a finished transfer
+func (t *folderTransfer) record(file *types.FileMetadata, operation string, err error) {
+	t.mu.Lock()
+	if err != nil {
+		t.result.Failed++
+		t.result.Errors = append(t.result.Errors, withPath(ClassifyError(operation, err), file.Path))
+	} else if operation == "upload" {
+		t.result.Uploaded++
+		t.result.Bytes += file.Size
+	} else {
+		t.result.Downloaded++
+		t.result.Bytes += file.Size
+	}
+	t.done++
+	done := t.done
+	t.mu.Unlock()
+
+	t.engine.reportProgress(done, t.total, file.Path, err)
+}
+
+// wait blocks until all started transfers finish and reports cancellation
+func (t *folderTransfer) wait(ctx context.Context) error {
+	for _, handle := range t.handles {
+		<-handle.Done()
+	}
+	return ctx.Err()
+}
+
+// isDownloadCurrent reports whether the local copy of a remote file holds
+// what entry lists for it. That is known from the hash the server reports
+// where it can be compared, and otherwise only if both copies are unchanged
+// since the last download: the local one by its hash and the remote one by
+// its modification time.
+func (e *Engine) isDownloadCurrent(file *types.FileMetadata, entry *PlanEntry) bool {
+	existing, err := e.database.GetFileMetadata(file.Path)
+	if err != nil || existing == nil || existing.RemoteID != file.RemoteID || existing.Hash == "" {
+		return false
+	}
+
+	info, err := os.Stat(file.Path)
+	if err != nil || info.Size() != file.Size {
+		return false
+	}
+
+	hash, err := e.calculateFileHash(file.Path)
+	if err != nil {
+		return false
+	}
+	if remoteHash := e.comparableRemoteHash(&api.FileInfo{Hash: entry.Hash, HashAlgorithm: entry.HashAlgorithm}); remoteHash != "" {
+		return strings.EqualFold(remoteHash, hash)
+	}
+	return hash == existing.Hash && existing.RemoteModified.Equal(entry.ModifiedTime)
+}
+
+// downloadTracked downloads one file and records it as synced
+func (e *Engine) downloadTracked(ctx context.Context, file *types.FileMetadata) error {
+	ctx, finish := e.beginTransfer(ctx, file.Path)
+	defer finish()
+
+	if err := e.downloadFile(ctx, file); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(file.Path); err == nil {
+		file.ModifiedTime = info.ModTime()
+		file.Mode = uint32(info.Mode().Perm())
+	}
+	file.SyncStatus = "synced"
+	return e.database.SaveFileMetadata(file)
+}
+
+// withPath fills in the file path of a classified error
+func withPath(err *SyncError, path string) *SyncError {
+	if err.FilePath == "" {
+		err.FilePath = path
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadFolderReconstructsTree(t *testing.T) {
+	tree := remoteTree{}
+	tree.add("root", api.FileInfo{ID: "a", Name: "a.txt", Size: 6})
+	tree.add("root", api.FileInfo{ID: "sub", Name: "sub", IsFolder: true})
+	tree.add("sub", api.FileInfo{ID: "b", Name: "b.txt", Size: 6})
+	tree.add("sub", api.FileInfo{ID: "deeper", Name: "deeper", IsFolder: true})
+	tree.add("deeper", api.FileInfo{ID: "c", Name: "c.txt", Size: 6})
+
+	var downloads int32
+	engine, _ := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/files/"), "/")[0]
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/files"):
+			tree.handler(w, r)
+		case strings.HasSuffix(r.URL.Path, "/download"):
+			atomic.AddInt32(&downloads, 1)
+			w.Write([]byte(id + "-body"))
+		default:
+			json.NewEncoder(w).Encode(api.FileInfo{ID: id, Name: id + ".txt", Size: 6})
+		}
+	})
+
+	var progress int32
+	engine.SetProgressFunc(func(done, total int, path string, err error) {
+		atomic.AddInt32(&progress, 1)
+		assert.Equal(t, 3, total)
+		assert.NoError(t, err)
+	})
+
+	localDir := filepath.Join(t.TempDir(), "mirror")
+	result, err := engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Downloaded)
+	assert.Equal(t, 0, result.Failed)
+	assert.Equal(t, int64(18), result.Bytes)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&progress))
+
+	for rel, body := range map[string]string{
+		"a.txt":            "a-body",
+		"sub/b.txt":        "b-body",
+		"sub/deeper/c.txt": "c-body",
+	} {
+		data, err := os.ReadFile(filepath.Join(localDir, filepath.FromSlash(rel)))
+		require.NoError(t, err, rel)
+		assert.Equal(t, body, string(data), rel)
+	}
+
+	// Unchanged files are not downloaded again
+	result, err = engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Skipped)
+	assert.Equal(t, 0, result.Downloaded)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&downloads))
+}
+
+func TestUploadFolderCreatesFoldersParentFirst(t *testing.T) {
+	var (
+		mu      gosync.Mutex
+		folders = map[string]string{} // folder name -> parent id
+		uploads = map[string]string{} // file name -> parent id
+		created int
+	)
+	engine, _ := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/files":
+			name, parent := body["name"].(string), body["parent_id"].(string)
+			if parent != "dest" {
+				_, known := folders[parent]
+				assert.True(t, known, "folder %s created before its parent %s", name, parent)
+			}
+			created++
+			id := name
+			folders[id] = parent
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": api.FileInfo{ID: id, Name: name, IsFolder: true}})
+		case "/upload/initiate":
+			name := body["filename"].(string)
+			uploads[name] = body["parent_id"].(string)
+			fmt.Fprintf(w, `{"data":{"upload_id":"upload-%s"}}`, name)
+		case "/upload/upload-readme.md", "/upload/upload-main.go", "/upload/upload-util.go":
+			w.Write([]byte(`{"data":{}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	root := filepath.Join(t.TempDir(), "project")
+	for rel, body := range map[string]string{
+		"readme.md":         "readme",
+		"src/main.go":       "package main",
+		"src/util/util.go":  "package util",
+		".git/HEAD":         "ref: refs/heads/main",
+		"src/editor.go.swp": "swap",
+	} {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(body), 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "empty"), 0755))
+
+	result, err := engine.UploadFolder(context.Background(), root, "dest")
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Uploaded)
+	assert.Equal(t, 0, result.Failed)
+
+	assert.Equal(t, map[string]string{
+		"project": "dest",
+		"src":     "project",
+		"util":    "src",
+		"empty":   "project",
+	}, folders)
+	assert.Equal(t, map[string]string{
+		"readme.md": "project",
+		"main.go":   "src",
+		"util.go":   "util",
+	}, uploads)
+
+	// A second push reuses the recorded folders and skips unchanged files
+	require.NoError(t, os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main // edited"), 0644))
+	result, err = engine.UploadFolder(context.Background(), root, "dest")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Uploaded)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Equal(t, 4, created, "folders are not recreated")
+}
+
+func TestUploadFolderContinuesPastUnreadableFiles(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced for root")
+	}
+
+	engine, _ := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"folder1","is_folder":true}}`))
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			w.Write([]byte(`{"data":{}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "ok.txt"), []byte("ok"), 0644))
+	locked := filepath.Join(root, "locked.txt")
+	require.NoError(t, os.WriteFile(locked, []byte("secret"), 0000))
+
+	result, err := engine.UploadFolder(context.Background(), root, "")
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Uploaded)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Errors, 1)
+	var syncErr *SyncError
+	require.ErrorAs(t, result.Errors[0], &syncErr)
+	assert.Equal(t, locked, syncErr.FilePath)
+}
+
+func TestDownloadFolderFetchesSameSizeRemoteChanges(t *testing.T) {
+	engine, _, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+	first := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	remote := filepath.Join(remoteDir, "project", "figures.txt")
+	local := filepath.Join(t.TempDir(), "checkout")
+
+	writeFileAt(t, remote, "figures: 42", first)
+	result, err := engine.DownloadFolder(ctx, "/project", local)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Downloaded)
+
+	// Without a remote hash, the modification time tells the change apart
+	writeFileAt(t, remote, "figures: 24", first.Add(time.Hour))
+	result, err = engine.DownloadFolder(ctx, "/project", local)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Downloaded)
+	data, err := os.ReadFile(filepath.Join(local, "figures.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "figures: 24", string(data))
+
+	// With one, the hash does even when the modification time is the same
+	engine.backend = &hashingBackend{RemoteBackend: engine.backend, dir: remoteDir}
+	writeFileAt(t, remote, "figures: 99", first.Add(time.Hour))
+	result, err = engine.DownloadFolder(ctx, "/project", local)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Downloaded)
+	data, err = os.ReadFile(filepath.Join(local, "figures.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "figures: 99", string(data))
+
+	result, err = engine.DownloadFolder(ctx, "/project", local)
+	require.NoError(t, err)
+	assert.Zero(t, result.Downloaded)
+	assert.Equal(t, 1, result.Skipped)
+}
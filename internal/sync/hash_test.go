@@ -0,0 +1,124 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateFileHashUsesConfiguredAlgorithm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.txt")
+	content := []byte("quarterly numbers")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	// The default matches the shared helper so every caller stores the same digest
+	engine, _ := newTestEngine(t, nil, http.NotFound)
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content)), hash)
+
+	helperHash, err := utils.HashFile(path, utils.DefaultHashAlgorithm)
+	require.NoError(t, err)
+	assert.Equal(t, helperHash, hash)
+
+	md5Engine, _ := newTestEngine(t, &types.Config{Sync: types.SyncConfig{HashAlgorithm: "md5"}}, http.NotFound)
+	hash, err = md5Engine.calculateFileHash(path)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%x", md5.Sum(content)), hash)
+}
+
+func TestMigrateHashesReplacesMD5(t *testing.T) {
+	dir := t.TempDir()
+	content := []byte("hello")
+	recorded := time.Now().Add(-time.Hour).Truncate(time.Second)
+	present := filepath.Join(dir, "present.txt")
+	edited := filepath.Join(dir, "edited.txt")
+	missing := filepath.Join(dir, "missing.txt")
+	writeFileAt(t, present, string(content), recorded)
+	writeFileAt(t, edited, "hello again", recorded.Add(time.Minute))
+
+	engine, database := newTestEngine(t, nil, http.NotFound)
+	legacy := fmt.Sprintf("%x", md5.Sum(content))
+	for _, path := range []string{present, edited, missing} {
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+			Path:         path,
+			Size:         int64(len(content)),
+			ModifiedTime: recorded,
+			Hash:         legacy,
+			SyncStatus:   "synced",
+		}))
+	}
+
+	engine.migrateHashes()
+
+	migrated, err := database.GetFileMetadata(present)
+	require.NoError(t, err)
+	sha := fmt.Sprintf("%x", sha256.Sum256(content))
+	assert.Equal(t, sha, migrated.Hash)
+	assert.Equal(t, sha, migrated.SyncedHash)
+
+	// An edit made since the last sync is not taken for the synced content
+	for _, path := range []string{edited, missing} {
+		cleared, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		assert.Empty(t, cleared.Hash, path)
+		assert.Empty(t, cleared.SyncedHash, path)
+	}
+}
+
+// cancelOnRead cancels a context the first time it is read from
+type cancelOnRead struct {
+	r      io.Reader
+	cancel context.CancelFunc
+}
+
+func (c cancelOnRead) Read(p []byte) (int, error) {
+	c.cancel()
+	return c.r.Read(p)
+}
+
+func TestLargeFileHashResumesFromDatabaseCheckpoint(t *testing.T) {
+	engine, database := newTestEngine(t, nil, http.NotFound)
+	engine.hashCheckpointEvery = 4096
+	engine.chunkSize = 8192
+
+	path := filepath.Join(t.TempDir(), "video.bin")
+	content := bytes.Repeat([]byte("frame "), 5000)
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	// A hash of the file stopped after its first block leaves its progress behind
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupted := &utils.ResumableHash{
+		Algorithm: utils.DefaultHashAlgorithm,
+		BlockSize: 8192,
+		Store:     database,
+		Reader:    func(r io.Reader) io.Reader { return cancelOnRead{r: r, cancel: cancel} },
+	}
+	_, err := interrupted.HashFile(ctx, path)
+	require.ErrorIs(t, err, context.Canceled)
+	checkpoint, err := database.GetHashCheckpoint(path)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, int64(8192), checkpoint.Offset)
+
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content)), hash)
+
+	checkpoint, err = database.GetHashCheckpoint(path)
+	require.NoError(t, err)
+	assert.Nil(t, checkpoint, "the checkpoint is dropped once the file is hashed")
+}
@@ -0,0 +1,149 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// ErrHashMismatch is returned when transferred content does not hash to
+// what the server reports for it
+var ErrHashMismatch = errors.New("content hash mismatch")
+
+// errUploadMismatch marks an upload whose remote copy does not match what
+// was sent. The file is left pending so the next sync uploads it again.
+var errUploadMismatch = errors.New("remote copy does not match the upload")
+
+// hashingReaderAt hashes the content read through it, so a file is hashed
+// in the same pass that uploads it. This only works while the content is
+// read from the start without gaps, as a single-request upload does;
+// re-reading bytes already hashed is fine.
+type hashingReaderAt struct {
+	reader io.ReaderAt
+	hash   hash.Hash
+	size   int64
+	hashed int64
+	gap    bool
+}
+
+// newHashingReaderAt hashes the size bytes of reader with algorithm
+func newHashingReaderAt(reader io.ReaderAt, size int64, algorithm string) (*hashingReaderAt, error) {
+	h, err := utils.NewHash(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return &hashingReaderAt{reader: reader, hash: h, size: size}, nil
+}
+
+func (h *hashingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := h.reader.ReadAt(p, off)
+	switch {
+	case off > h.hashed:
+		h.gap = true
+	case off+int64(n) > h.hashed:
+		h.hash.Write(p[h.hashed-off : n])
+		h.hashed = off + int64(n)
+	}
+	return n, err
+}
+
+// sum returns the hex digest of the content, or false if it was not read
+// through completely and in order
+func (h *hashingReaderAt) sum() (string, bool) {
+	if h.gap || h.hashed != h.size {
+		return "", false
+	}
+	return fmt.Sprintf("%x", h.hash.Sum(nil)), true
+}
+
+// hashPartial feeds the content already in a resumed download's temp file
+// to h, so the finished download hashes as a whole
+func hashPartial(h hash.Hash, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(h, file)
+	return err
+}
+
+// verifyRemoteHash checks a hash computed during a transfer against the
+// one the server reports for the file. Files whose hash the server does not
+// report, reports with another algorithm, or cannot know because the
+// content is encrypted are accepted as they are.
+func (e *Engine) verifyRemoteHash(remoteInfo *api.FileInfo, hash string) error {
+	remoteHash := e.comparableRemoteHash(remoteInfo)
+	if remoteHash == "" {
+		return nil
+	}
+	if !strings.EqualFold(remoteHash, hash) {
+		return fmt.Errorf("%w: remote has %s, transferred %s", ErrHashMismatch, remoteHash, hash)
+	}
+	return nil
+}
+
+// comparableRemoteHash returns the hash the server reports for a file if it
+// can be compared with local hashes, or "" if there is none, it was made
+// with another algorithm or it is of encrypted content
+func (e *Engine) comparableRemoteHash(remoteInfo *api.FileInfo) string {
+	if e.encryption != nil || remoteInfo == nil || remoteInfo.Hash == "" {
+		return ""
+	}
+	algorithm := remoteInfo.HashAlgorithm
+	if algorithm == "" {
+		algorithm = utils.DefaultHashAlgorithm
+	}
+	if !strings.EqualFold(algorithm, e.hashAlgorithm()) {
+		return ""
+	}
+	return remoteInfo.Hash
+}
+
+// verifyUpload checks that the remote copy of an upload holds what was sent
+// when sync.verify_uploads is set: its size, and its hash where the server
+// reports one. If the upload reply leaves either out, the remote file is
+// looked up again. hash is the hash computed while uploading, if any; it is
+// computed here when needed and returned either way.
+func (e *Engine) verifyUpload(ctx context.Context, metadata *types.FileMetadata, remote *api.FileInfo, size int64, hash string) (string, error) {
+	if !e.config.Sync.VerifyUploads || remote == nil {
+		return hash, nil
+	}
+
+	fetched := false
+	if remote.ID != "" && (remote.Hash == "" || remote.Size == 0 && size != 0) {
+		info, err := e.backend.GetFileInfo(ctx, remote.ID)
+		if err != nil {
+			return hash, fmt.Errorf("failed to verify upload of %s: %w", metadata.Path, err)
+		}
+		remote, fetched = info, true
+	}
+
+	if (fetched || remote.Size != 0) && remote.Size != size {
+		return hash, fmt.Errorf("%w: %s is %d bytes remotely, %d were uploaded", errUploadMismatch, metadata.Path, remote.Size, size)
+	}
+	if remote.Hash == "" || e.encryption != nil {
+		return hash, nil
+	}
+	if hash == "" {
+		// Chunked uploads cannot hash the content as they go
+		computed, err := e.calculateFileHash(metadata.Path)
+		if err != nil {
+			return hash, err
+		}
+		hash = computed
+	}
+	if err := e.verifyRemoteHash(remote, hash); err != nil {
+		return hash, fmt.Errorf("%w: %s: %w", errUploadMismatch, metadata.Path, err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,117 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadHashesContentInOnePass(t *testing.T) {
+	content := []byte("the quarterly report, final version")
+	expected := fmt.Sprintf("%x", sha256.Sum256(content))
+
+	var received []byte
+	engine, _ := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			received, _ = io.ReadAll(r.Body)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"id": "remote-report", "hash": expected, "hash_algorithm": "sha256"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	metadata := &types.FileMetadata{Path: path}
+	require.NoError(t, engine.uploadFile(context.Background(), metadata))
+	assert.Equal(t, content, received)
+	assert.Equal(t, expected, metadata.Hash, "the hash is taken while uploading")
+	assert.Equal(t, "remote-report", metadata.RemoteID)
+}
+
+func downloadHandler(content []byte, remoteHash string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/doc1":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"id": "doc1", "name": "notes.txt", "size": len(content), "hash": remoteHash},
+			})
+		case "/files/doc1/download":
+			w.Write(content)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestDownloadVerifiesHashInOnePass(t *testing.T) {
+	content := []byte("meeting notes")
+	expected := fmt.Sprintf("%x", sha256.Sum256(content))
+	engine, _ := newTestEngine(t, nil, downloadHandler(content, expected))
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	metadata := &types.FileMetadata{Path: path, RemoteID: "doc1"}
+	require.NoError(t, engine.downloadFile(context.Background(), metadata))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+	assert.Equal(t, expected, metadata.Hash)
+}
+
+func TestDownloadHashMismatchAborts(t *testing.T) {
+	content := []byte("meeting notes")
+	other := fmt.Sprintf("%x", sha256.Sum256([]byte("something else")))
+	engine, _ := newTestEngine(t, nil, downloadHandler(content, other))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	metadata := &types.FileMetadata{Path: path, RemoteID: "doc1"}
+	err := engine.downloadFile(context.Background(), metadata)
+	assert.ErrorIs(t, err, ErrHashMismatch)
+	assert.Empty(t, metadata.Hash)
+
+	// Neither the corrupt file nor its temp file is left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestHashingReaderAtNeedsSequentialReads(t *testing.T) {
+	content := []byte("0123456789")
+	expected := fmt.Sprintf("%x", sha256.Sum256(content))
+
+	hashing, err := newHashingReaderAt(bytes.NewReader(content), int64(len(content)), "sha256")
+	require.NoError(t, err)
+	buf := make([]byte, 6)
+	hashing.ReadAt(buf, 0)
+	hashing.ReadAt(buf[:4], 2) // overlaps what was already hashed
+	hashing.ReadAt(buf[:4], 6)
+	sum, ok := hashing.sum()
+	require.True(t, ok)
+	assert.Equal(t, expected, sum)
+
+	hashing, err = newHashingReaderAt(bytes.NewReader(content), int64(len(content)), "sha256")
+	require.NoError(t, err)
+	hashing.ReadAt(buf[:4], 6)
+	hashing.ReadAt(buf, 0)
+	_, ok = hashing.sum()
+	assert.False(t, ok, "content read out of order cannot be hashed in one pass")
+}
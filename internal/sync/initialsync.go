@@ -0,0 +1,446 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// initialSyncBatch is how many planned operations are written or read in one
+// go while checkpointing an initial sync
+const initialSyncBatch = 500
+
+// InitialSync brings a sync folder and its remote counterpart in line for the
+// first time. The whole tree is enumerated up front into a plan stored in the
+// database, and the operations are then run in plan order with the number
+// completed checkpointed after each one, so an interrupted initial sync
+// resumes where it stopped instead of starting over. An enumeration that was
+// interrupted is redone from scratch.
+func (e *Engine) InitialSync(ctx context.Context, folder types.FolderConfig) (*SyncResult, error) {
+	start := time.Now()
+	result := &SyncResult{}
+
+	state, err := e.database.GetInitialSync(folder.Local)
+	if err != nil {
+		return nil, err
+	}
+	if state != nil && state.Done() {
+		return result, nil
+	}
+	if state == nil || !state.Planned {
+		if state, err = e.planInitialSync(ctx, folder); err != nil {
+			return nil, err
+		}
+	} else {
+		e.logger.Infof("Resuming initial sync of %s at operation %d of %d", folder.Local, state.Completed, state.Total)
+	}
+
+	for completed := state.Completed; completed < state.Total; {
+		ops, err := e.database.GetInitialSyncOps(folder.Local, completed, initialSyncBatch)
+		if err != nil {
+			return result, err
+		}
+		if len(ops) == 0 {
+			return result, fmt.Errorf("initial sync plan of %s ends at operation %d of %d", folder.Local, completed, state.Total)
+		}
+
+		for _, op := range ops {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			localPath := filepath.Join(folder.Local, filepath.FromSlash(op.Path))
+			err := e.runInitialSyncOp(ctx, folder, state.RemoteID, op, localPath, result)
+			if err != nil {
+				// An operation cut short is left for the resumed sync to redo
+				if ctx.Err() != nil {
+					return result, ctx.Err()
+				}
+				failure := withPath(ClassifyError(string(op.Type), err), localPath)
+				result.Failed++
+				result.Errors = append(result.Errors, failure)
+				if PlanOperationType(op.Type) != PlanResolve {
+					// A resolve goes through syncFile, which counts its
+					// own failures
+					e.tallies.failed(failure)
+				}
+			}
+
+			completed = op.Seq + 1
+			if err := e.database.SetInitialSyncCompleted(folder.Local, completed); err != nil {
+				return result, err
+			}
+			e.reportProgress(completed, state.Total, localPath, err)
+		}
+	}
+
+	result.Duration = time.Since(start)
+	e.logger.Infof("Initial sync of %s finished: %d downloaded, %d uploaded, %d skipped, %d failed",
+		folder.Local, result.Downloaded, result.Uploaded, result.Skipped, result.Failed)
+	return result, nil
+}
+
+// planInitialSync enumerates both trees of a sync folder and stores the
+// operations needed to reconcile them. Folders that already exist on both
+// sides are recorded as they are found, so uploads into them know their
+// remote IDs.
+func (e *Engine) planInitialSync(ctx context.Context, folder types.FolderConfig) (*types.InitialSyncState, error) {
+	remoteID, err := e.remoteFolderRoot(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(folder.Local, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", folder.Local, err)
+	}
+	if err := e.database.StartInitialSync(folder.Local, remoteID); err != nil {
+		return nil, err
+	}
+	e.logger.Infof("Planning initial sync of %s", folder.Local)
+
+	remote := &sharedFolderRecorder{
+		EntryIterator: newRemoteIterator(ctx, e.backend, remoteID, DefaultListPageSize, e.remoteEntry),
+		engine:        e,
+		root:          folder.Local,
+	}
+
+	var batch []types.InitialSyncOp
+	total := 0
+	err = streamSyncOperations(newLocalIterator(folder.Local, e.shouldIgnoreFile), remote, func(op PlanOperation) error {
+		planned := types.InitialSyncOp{Seq: total, Type: string(op.Type), Path: op.Path}
+		entry := op.Remote
+		if entry == nil {
+			entry = op.Local
+		}
+		planned.Size, planned.ModifiedTime, planned.IsDirectory = entry.Size, entry.ModifiedTime, entry.IsDirectory
+		if op.Remote != nil {
+			planned.RemoteID = op.Remote.RemoteID
+		}
+
+		batch = append(batch, planned)
+		total++
+		if len(batch) < initialSyncBatch {
+			return nil
+		}
+		err := e.database.AddInitialSyncOps(folder.Local, batch)
+		batch = batch[:0]
+		return err
+	})
+	if err == nil {
+		err = e.database.AddInitialSyncOps(folder.Local, batch)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan initial sync of %s: %w", folder.Local, err)
+	}
+
+	if err := e.database.FinishInitialSyncPlan(folder.Local, total); err != nil {
+		return nil, err
+	}
+	e.logger.Infof("Planned initial sync of %s: %d operations", folder.Local, total)
+	return e.database.GetInitialSync(folder.Local)
+}
+
+// sharedFolderRecorder passes remote entries through, recording remote
+// folders that also exist locally as synced
+type sharedFolderRecorder struct {
+	EntryIterator
+	engine *Engine
+	root   string
+}
+
+// Next returns the next remote entry
+func (r *sharedFolderRecorder) Next() (*PlanEntry, error) {
+	entry, err := r.EntryIterator.Next()
+	if err != nil || !entry.IsDirectory {
+		return entry, err
+	}
+
+	localPath := filepath.Join(r.root, filepath.FromSlash(entry.Path))
+	if info, statErr := os.Stat(localPath); statErr == nil && info.IsDir() {
+		if err := r.engine.database.SaveFileMetadata(&types.FileMetadata{
+			Path:        localPath,
+			RemoteID:    entry.RemoteID,
+			IsDirectory: true,
+			SyncStatus:  "synced",
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return entry, nil
+}
+
+// runInitialSyncOp carries out one planned operation of an initial sync
+func (e *Engine) runInitialSyncOp(ctx context.Context, folder types.FolderConfig, rootID string, op types.InitialSyncOp, localPath string, result *SyncResult) error {
+	strategy := ParseSyncStrategy(folder.SyncMode)
+
+	switch PlanOperationType(op.Type) {
+	case PlanDownload:
+		if !strategy.AllowsDownload() {
+			result.Skipped++
+			return nil
+		}
+		if op.IsDirectory {
+			if err := os.MkdirAll(localPath, 0755); err != nil {
+				return err
+			}
+			return e.database.SaveFileMetadata(&types.FileMetadata{
+				Path:        localPath,
+				RemoteID:    op.RemoteID,
+				IsDirectory: true,
+				SyncStatus:  "synced",
+			})
+		}
+		file := &types.FileMetadata{Path: localPath, RemoteID: op.RemoteID, Size: op.Size, ModifiedTime: op.ModifiedTime}
+		if err := e.downloadTracked(ctx, file); err != nil {
+			return err
+		}
+		result.Downloaded++
+		result.Bytes += file.Size
+
+	case PlanUpload:
+		if !strategy.AllowsUpload() {
+			result.Skipped++
+			return nil
+		}
+		parentID, err := e.initialSyncParent(rootID, folder.Local, op.Path)
+		if err != nil {
+			return err
+		}
+		if op.IsDirectory {
+			return e.ensureRemoteFolder(ctx, &types.FileMetadata{Path: localPath, IsDirectory: true}, parentID)
+		}
+		file := &types.FileMetadata{Path: localPath}
+		if err := e.uploadTracked(ctx, file, parentID); err != nil {
+			return err
+		}
+		result.Uploaded++
+		result.Bytes += file.Size
+
+	case PlanReplace:
+		return e.resolveTypeChange(ctx, folder, rootID, op, localPath, result)
+
+	case PlanResolve:
+		// Both sides exist and differ, which is what conflict resolution is for
+		return e.syncFile(ctx, &types.FileMetadata{
+			Path:        localPath,
+			RemoteID:    op.RemoteID,
+			IsDirectory: op.IsDirectory,
+			SyncStatus:  "pending",
+		})
+
+	default:
+		return fmt.Errorf("unknown initial sync operation %q", op.Type)
+	}
+	return nil
+}
+
+// resolveTypeChange handles a path that is a folder on one side and a file
+// on the other, telling from the record of its last sync which side changed
+// type. A local change replaces the remote entry, and a remote one replaces
+// a local file that is unchanged since. Anything else, such as a path never
+// synced before, is recorded as a conflict and left alone on both sides. op
+// describes the remote entry.
+func (e *Engine) resolveTypeChange(ctx context.Context, folder types.FolderConfig, rootID string, op types.InitialSyncOp, localPath string, result *SyncResult) error {
+	strategy := ParseSyncStrategy(folder.SyncMode)
+	record, err := e.database.GetFileMetadata(localPath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case record != nil && record.RemoteID == op.RemoteID && record.IsDirectory == op.IsDirectory:
+		// The remote entry is the one last synced
+		if !strategy.AllowsUpload() {
+			e.logger.Warnf("%s is a %s locally but not remotely; leaving it alone in a %s folder", localPath, entryKind(!op.IsDirectory), strategy)
+			result.Skipped++
+			return nil
+		}
+		return e.replaceRemoteEntry(ctx, rootID, folder.Local, record, op, localPath, result)
+
+	case record != nil && record.IsDirectory == info.IsDir() && !info.IsDir() &&
+		unchangedSince(&types.FileMetadata{Size: info.Size(), ModifiedTime: info.ModTime()}, record):
+		// The local file is the one last synced
+		if !strategy.AllowsDownload() {
+			e.logger.Warnf("%s is a %s remotely but not locally; leaving it alone in a %s folder", localPath, entryKind(op.IsDirectory), strategy)
+			result.Skipped++
+			return nil
+		}
+		return e.replaceLocalEntry(ctx, record, op, localPath, result)
+	}
+
+	e.logger.Warnf("%s is a %s locally but a %s remotely, and either may have changed; leaving both alone",
+		localPath, entryKind(info.IsDir()), entryKind(op.IsDirectory))
+	e.recordConflict(&types.ConflictInfo{
+		Path:           localPath,
+		Strategy:       e.conflictStrategy(localPath),
+		DetectedAt:     e.clock.Now(),
+		LocalSize:      info.Size(),
+		RemoteSize:     op.Size,
+		LocalModified:  info.ModTime(),
+		RemoteModified: op.ModifiedTime,
+	})
+	result.Conflicts++
+	return nil
+}
+
+// replaceLocalEntry replaces a local file by the remote folder or file that
+// took its place, as a remote deletion would the file
+func (e *Engine) replaceLocalEntry(ctx context.Context, record *types.FileMetadata, op types.InitialSyncOp, localPath string, result *SyncResult) error {
+	if err := e.deleteLocalCopy(record); err != nil {
+		return err
+	}
+	e.logger.Infof("%s changed from a file to a %s remotely, replacing it locally", localPath, entryKind(op.IsDirectory))
+
+	if !op.IsDirectory {
+		file := &types.FileMetadata{Path: localPath, RemoteID: op.RemoteID, Size: op.Size, ModifiedTime: op.ModifiedTime}
+		if err := e.downloadTracked(ctx, file); err != nil {
+			return err
+		}
+		result.Downloaded++
+		result.Bytes += file.Size
+		return nil
+	}
+
+	folderResult, err := e.DownloadFolder(ctx, op.RemoteID, localPath)
+	if err != nil {
+		return err
+	}
+	result.Downloaded += folderResult.Downloaded
+	result.Bytes += folderResult.Bytes
+	return e.database.SaveFileMetadata(&types.FileMetadata{
+		Path:        localPath,
+		RemoteID:    op.RemoteID,
+		IsDirectory: true,
+		SyncStatus:  "synced",
+	})
+}
+
+// replaceRemoteEntry deletes the remote entry of a path that changed type
+// locally through the deletion gate, which forgets its stale mapping, and
+// creates the local entry in its place. A deletion held back for
+// confirmation leaves the path to be uploaded once it is confirmed.
+func (e *Engine) replaceRemoteEntry(ctx context.Context, rootID, root string, record *types.FileMetadata, op types.InitialSyncOp, localPath string, result *SyncResult) error {
+	e.logger.Infof("%s changed from a %s to a %s, replacing it remotely", localPath, entryKind(op.IsDirectory), entryKind(!op.IsDirectory))
+	if e.deleteRemoved(ctx, []*types.FileMetadata{record}) == 0 {
+		if !e.deletions.holds(localPath) {
+			return fmt.Errorf("failed to delete remote %s %s", entryKind(op.IsDirectory), op.Path)
+		}
+		result.Skipped++
+		return nil
+	}
+
+	parentID, err := e.initialSyncParent(rootID, root, op.Path)
+	if err != nil {
+		return err
+	}
+	if !op.IsDirectory {
+		return e.ensureRemoteFolder(ctx, &types.FileMetadata{Path: localPath, IsDirectory: true}, parentID)
+	}
+	file := &types.FileMetadata{Path: localPath}
+	if err := e.uploadTracked(ctx, file, parentID); err != nil {
+		return err
+	}
+	result.Uploaded++
+	result.Bytes += file.Size
+	return nil
+}
+
+// entryKind names a folder or a file in log messages
+func entryKind(isDirectory bool) string {
+	if isDirectory {
+		return "folder"
+	}
+	return "file"
+}
+
+// initialSyncParent returns the remote ID of the folder an upload of
+// relPath goes into. Parents come before their children in the plan, so
+// the parent is either the sync root or a folder already recorded.
+func (e *Engine) initialSyncParent(rootID, root, relPath string) (string, error) {
+	dir := path.Dir(relPath)
+	if dir == "." {
+		return rootID, nil
+	}
+
+	parent, err := e.database.GetFileMetadata(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return "", err
+	}
+	if parent == nil || parent.RemoteID == "" {
+		return "", fmt.Errorf("remote folder for %s was not created", dir)
+	}
+	return parent.RemoteID, nil
+}
+
+// ensureRemoteFolderPath returns the ID of a remote folder given its slash
+// separated path, optionally in a workspace, creating any missing folders
+// along the way. write requires write access to the workspace.
+func (e *Engine) ensureRemoteFolderPath(ctx context.Context, remote string, write bool) (string, error) {
+	folderID, remotePath, err := e.remoteRoot(ctx, remote, write)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range strings.Split(strings.Trim(remotePath, "/"), "/") {
+		if name == "" {
+			continue
+		}
+		childID, err := e.findRemoteChild(ctx, folderID, name)
+		if errors.Is(err, errRemoteFolderMissing) {
+			info, createErr := e.backend.CreateFolder(ctx, folderID, name)
+			if createErr != nil {
+				return "", fmt.Errorf("failed to create remote folder %s: %w", name, createErr)
+			}
+			childID, err = info.ID, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		folderID = childID
+	}
+	return folderID, nil
+}
+
+// runInitialSyncs runs or resumes the initial sync of every enabled folder
+// that has not finished one. Folders that were already synced before
+// initial syncs were checkpointed are left to the regular sync. Hashing is
+// throttled until they are done.
+func (e *Engine) runInitialSyncs(ctx context.Context) {
+	defer e.scan.begin()()
+
+	for _, folder := range e.syncFolders {
+		if !folder.Enabled {
+			continue
+		}
+
+		state, err := e.database.GetInitialSync(folder.Local)
+		if err != nil {
+			e.logger.Errorf("Failed to read initial sync state of %s: %v", folder.Local, err)
+			continue
+		}
+		if state == nil {
+			files, err := e.database.GetFilesUnder(folder.Local)
+			if err != nil || len(files) > 0 {
+				continue
+			}
+		} else if state.Done() {
+			continue
+		}
+
+		if _, err := e.InitialSync(ctx, folder); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			e.logger.Errorf("Initial sync of %s failed: %v", folder.Local, err)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitialSyncResumesFromCheckpoint(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	localDir := t.TempDir()
+	folder := types.FolderConfig{Local: localDir, Remote: "/", Enabled: true}
+	engine.syncFolders = []types.FolderConfig{folder}
+
+	write := func(root, rel, content string) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	write(remoteDir, "a.txt", "remote a")
+	write(remoteDir, "b.txt", "remote b")
+	write(remoteDir, "shared/c.txt", "remote c")
+	write(localDir, "d.txt", "local d")
+	write(localDir, "new/f.txt", "local f")
+	write(localDir, "shared/e.txt", "local e")
+
+	// Interrupt the first run once three operations have finished
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var reported []string
+	engine.SetProgressFunc(func(done, total int, path string, err error) {
+		rel, _ := filepath.Rel(localDir, path)
+		reported = append(reported, filepath.ToSlash(rel))
+		if done == 3 {
+			cancel()
+		}
+	})
+
+	_, err := engine.InitialSync(ctx, folder)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []string{"a.txt", "b.txt", "d.txt"}, reported)
+
+	state, err := database.GetInitialSync(localDir)
+	require.NoError(t, err)
+	require.NotNil(t, state)
+	assert.True(t, state.Planned)
+	assert.Equal(t, 3, state.Completed)
+	assert.Equal(t, 7, state.Total)
+
+	// A finished operation is not redone: a.txt changing remotely after it
+	// was downloaded is left to the regular sync
+	write(remoteDir, "a.txt", "remote a, edited")
+
+	reported = nil
+	result, err := engine.InitialSync(context.Background(), folder)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new", "new/f.txt", "shared/c.txt", "shared/e.txt"}, reported)
+	assert.Equal(t, 1, result.Downloaded)
+	assert.Equal(t, 2, result.Uploaded)
+	assert.Zero(t, result.Failed)
+
+	local, err := os.ReadFile(filepath.Join(localDir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "remote a", string(local))
+	for _, rel := range []string{"d.txt", "new/f.txt", "shared/e.txt"} {
+		assert.FileExists(t, filepath.Join(remoteDir, filepath.FromSlash(rel)))
+	}
+	assert.FileExists(t, filepath.Join(localDir, "shared", "c.txt"))
+
+	state, err = database.GetInitialSync(localDir)
+	require.NoError(t, err)
+	assert.True(t, state.Done())
+	ops, err := database.GetInitialSyncOps(localDir, 0, initialSyncBatch)
+	require.NoError(t, err)
+	assert.Empty(t, ops, "a finished plan is dropped")
+
+	// Once finished, the initial sync does nothing
+	reported = nil
+	result, err = engine.InitialSync(context.Background(), folder)
+	require.NoError(t, err)
+	assert.Empty(t, reported)
+	assert.Zero(t, result.Downloaded+result.Uploaded)
+}
+
+func TestInitialSyncReplansInterruptedEnumeration(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	localDir := t.TempDir()
+	folder := types.FolderConfig{Local: localDir, Remote: "/", Enabled: true}
+	engine.syncFolders = []types.FolderConfig{folder}
+
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "a.txt"), []byte("a"), 0644))
+
+	// A plan that was never finished holds nothing worth resuming
+	require.NoError(t, database.StartInitialSync(localDir, "root"))
+	require.NoError(t, database.AddInitialSyncOps(localDir, []types.InitialSyncOp{
+		{Seq: 0, Type: string(PlanDownload), Path: "stale.txt", RemoteID: "/stale.txt"},
+	}))
+
+	result, err := engine.InitialSync(context.Background(), folder)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Downloaded)
+	assert.Zero(t, result.Failed)
+	assert.FileExists(t, filepath.Join(localDir, "a.txt"))
+	assert.NoFileExists(t, filepath.Join(localDir, "stale.txt"))
+}
+
+func TestInitialSyncReplacesEntriesThatChangedType(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	localDir := t.TempDir()
+	folder := types.FolderConfig{Local: localDir, Remote: "/", Enabled: true}
+
+	write := func(root, rel, content string) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	// notes turned from a file into a folder, photos from a folder into a file
+	write(remoteDir, "notes", "old notes")
+	write(localDir, "notes/todo.txt", "todo")
+	write(remoteDir, "photos/a.jpg", "jpeg")
+	write(localDir, "photos", "photo list")
+
+	// Both were last synced as the kind they still are remotely, so they
+	// changed type locally
+	notes := filepath.Join(localDir, "notes")
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: notes, RemoteID: "/notes", SyncStatus: "synced"}))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: filepath.Join(localDir, "photos"), RemoteID: "/photos", IsDirectory: true, SyncStatus: "synced",
+	}))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: filepath.Join(localDir, "photos", "a.jpg"), RemoteID: "/photos/a.jpg", SyncStatus: "synced",
+	}))
+
+	result, err := engine.InitialSync(context.Background(), folder)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Uploaded)
+	assert.Zero(t, result.Downloaded)
+	assert.Zero(t, result.Failed)
+
+	info, err := os.Stat(filepath.Join(remoteDir, "notes"))
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "the remote file was replaced by a folder")
+	data, err := os.ReadFile(filepath.Join(remoteDir, "notes", "todo.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "todo", string(data))
+
+	data, err = os.ReadFile(filepath.Join(remoteDir, "photos"))
+	require.NoError(t, err)
+	assert.Equal(t, "photo list", string(data), "the remote folder was replaced by a file")
+	assert.NoFileExists(t, filepath.Join(localDir, "photos", "a.jpg"))
+
+	record, err := database.GetFileMetadata(notes)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.IsDirectory)
+	assert.Equal(t, "/notes", record.RemoteID, "the folder is mapped in place of the file")
+
+	record, err = database.GetFileMetadata(filepath.Join(localDir, "photos"))
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.False(t, record.IsDirectory)
+	assert.Equal(t, "synced", record.SyncStatus)
+}
+
+// deleteRecorder records the remote entries deleted through a backend
+type deleteRecorder struct {
+	api.RemoteBackend
+	deleted []string
+}
+
+func (b *deleteRecorder) DeleteFile(ctx context.Context, fileID string) error {
+	b.deleted = append(b.deleted, fileID)
+	return b.RemoteBackend.DeleteFile(ctx, fileID)
+}
+
+func (b *deleteRecorder) DeleteFolder(ctx context.Context, folderID string) error {
+	b.deleted = append(b.deleted, folderID)
+	return b.RemoteBackend.DeleteFolder(ctx, folderID)
+}
+
+func TestInitialSyncFollowsRemoteTypeChanges(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	backend := &deleteRecorder{RemoteBackend: engine.backend}
+	engine.backend = backend
+	localDir := t.TempDir()
+	folder := types.FolderConfig{Local: localDir, Remote: "/", Enabled: true}
+	engine.syncFolders = []types.FolderConfig{folder}
+	synced := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	// Another client turned the synced file report into a folder
+	writeFileAt(t, filepath.Join(localDir, "report"), "draft", synced)
+	writeFileAt(t, filepath.Join(remoteDir, "report", "summary.txt"), "summary", synced)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: filepath.Join(localDir, "report"), RemoteID: "/report", Size: 5, ModifiedTime: synced, SyncStatus: "synced",
+	}))
+
+	// A file never synced, where there is a remote folder
+	writeFileAt(t, filepath.Join(localDir, "archive"), "stray", synced)
+	writeFileAt(t, filepath.Join(remoteDir, "archive", "2023.txt"), "old", synced)
+
+	result, err := engine.InitialSync(context.Background(), folder)
+	require.NoError(t, err)
+	assert.Empty(t, backend.deleted, "nothing is deleted remotely")
+	assert.Zero(t, result.Uploaded)
+	assert.Equal(t, 1, result.Downloaded)
+	assert.Equal(t, 1, result.Conflicts)
+
+	data, err := os.ReadFile(filepath.Join(localDir, "report", "summary.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "summary", string(data), "the local file was replaced by the folder")
+	record, err := database.GetFileMetadata(filepath.Join(localDir, "report"))
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.IsDirectory)
+
+	// The conflict leaves both sides as they were
+	data, err = os.ReadFile(filepath.Join(localDir, "archive"))
+	require.NoError(t, err)
+	assert.Equal(t, "stray", string(data))
+	assert.FileExists(t, filepath.Join(remoteDir, "archive", "2023.txt"))
+}
@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLocalTestEngine creates an engine that syncs against a local directory
+// instead of the Zoho API, returning that directory as well
+func newLocalTestEngine(t *testing.T) (*Engine, *storage.Database, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	remoteDir := t.TempDir()
+	config := &types.Config{Remote: types.RemoteConfig{Type: api.RemoteTypeLocal, Path: remoteDir}}
+	backend, err := api.NewBackend(config, nil)
+	require.NoError(t, err)
+
+	return NewEngine(backend, database, config), database, remoteDir
+}
+
+func TestLocalBackendFolderRoundTrip(t *testing.T) {
+	engine, _, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	src := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "docs"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "README.md"), []byte("readme"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "docs", "guide.txt"), []byte("guide"), 0644))
+
+	result, err := engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Uploaded)
+	assert.Zero(t, result.Failed)
+
+	uploaded, err := os.ReadFile(filepath.Join(remoteDir, "project", "docs", "guide.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "guide", string(uploaded))
+
+	// Nothing changed, so a second push transfers nothing
+	result, err = engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+	assert.Zero(t, result.Uploaded)
+	assert.Equal(t, 2, result.Skipped)
+
+	dst := filepath.Join(t.TempDir(), "checkout")
+	result, err = engine.DownloadFolder(ctx, "/project", dst)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Downloaded)
+
+	for _, name := range []string{"README.md", filepath.Join("docs", "guide.txt")} {
+		want, err := os.ReadFile(filepath.Join(src, name))
+		require.NoError(t, err)
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), name)
+	}
+}
+
+func TestLocalBackendSyncCycle(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("first draft"), 0644))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "pending"}))
+
+	// A new local file is uploaded
+	engine.performSync(ctx)
+
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", saved.SyncStatus)
+	assert.Equal(t, "/notes.txt", saved.RemoteID)
+	remote, err := os.ReadFile(filepath.Join(remoteDir, "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "first draft", string(remote))
+
+	// A file that only exists remotely is downloaded
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "notes.txt"), []byte("edited remotely"), 0644))
+	saved.SyncStatus = "pending"
+	require.NoError(t, database.SaveFileMetadata(saved))
+
+	engine.performSync(ctx)
+
+	local, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "edited remotely", string(local))
+	saved, err = database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", saved.SyncStatus)
+}
@@ -0,0 +1,243 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// LocalTrashDir is the folder at the root of each sync folder that local
+// files deleted because they were deleted remotely are moved to when
+// sync.local_trash is on. Nothing inside it is synced.
+const LocalTrashDir = ".zohosync-trash"
+
+// ErrNotInLocalTrash is returned when restoring a file the local trash does
+// not hold
+var ErrNotInLocalTrash = errors.New("file is not in the local trash")
+
+// TrashedFile is a file in a local trash. Path is where it was before it
+// was moved to the trash.
+type TrashedFile struct {
+	Path      string    `json:"path"`
+	TrashPath string    `json:"trash_path"`
+	Size      int64     `json:"size"`
+	TrashedAt time.Time `json:"trashed_at"`
+}
+
+// isInLocalTrash reports whether path lies inside a local trash folder
+func isInLocalTrash(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == LocalTrashDir {
+			return true
+		}
+	}
+	return false
+}
+
+// localTrashPath returns where path is kept in the local trash: the same
+// relative path under the trash folder of its sync folder, or of its own
+// directory if it is outside every sync folder
+func localTrashPath(folders []types.FolderConfig, path string) string {
+	root := filepath.Dir(path)
+	if folder := findFolder(folders, path); folder != nil {
+		root = filepath.Clean(folder.Local)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		rel = filepath.Base(path)
+	}
+	return filepath.Join(root, LocalTrashDir, rel)
+}
+
+// localTrashRoots returns the trash folder of every sync folder
+func localTrashRoots(folders []types.FolderConfig) []string {
+	roots := make([]string, 0, len(folders))
+	for _, folder := range folders {
+		roots = append(roots, filepath.Join(filepath.Clean(folder.Local), LocalTrashDir))
+	}
+	return roots
+}
+
+// followsRemoteDeletion reports whether a file that was deleted remotely is
+// deleted locally too: always in folders that only download, and in two-way
+// folders unless it changed locally since it last synced. A queued local
+// change clears the recorded hash, so a file that still has one is
+// unchanged.
+func (e *Engine) followsRemoteDeletion(metadata *types.FileMetadata, strategy SyncStrategy) bool {
+	if !strategy.AllowsDownload() {
+		return false
+	}
+	return !strategy.AllowsUpload() || metadata.Hash != ""
+}
+
+// deleteLocalCopy removes the local copy of a file that was deleted
+// remotely, moving it to the local trash when that is on, and stops
+// tracking it
+func (e *Engine) deleteLocalCopy(metadata *types.FileMetadata) error {
+	if e.config.Sync.LocalTrash {
+		trashPath, err := e.moveToLocalTrash(metadata.Path)
+		if err != nil {
+			return fmt.Errorf("failed to move %s to the local trash: %w", metadata.Path, err)
+		}
+		e.logger.Infof("%s was deleted remotely, moved the local copy to %s", metadata.Path, trashPath)
+	} else {
+		if err := os.RemoveAll(metadata.Path); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", metadata.Path, err)
+		}
+		e.logger.Infof("%s was deleted remotely, deleted the local copy", metadata.Path)
+	}
+
+	if _, err := e.database.DeleteFilesUnder(metadata.Path); err != nil {
+		return err
+	}
+	e.database.LogSyncOperation(metadata.ID, "delete", "success", "deleted remotely")
+	return nil
+}
+
+// moveToLocalTrash moves path into the local trash, replacing an earlier
+// trashed copy of the same path, and returns where it now is. Its
+// modification time becomes the time it was trashed, which the retention
+// policy goes by.
+func (e *Engine) moveToLocalTrash(path string) (string, error) {
+	trashPath := localTrashPath(e.syncFolders, path)
+	if err := os.MkdirAll(filepath.Dir(trashPath), 0755); err != nil {
+		return "", err
+	}
+	if err := os.RemoveAll(trashPath); err != nil {
+		return "", err
+	}
+	if err := os.Rename(path, trashPath); err != nil {
+		return "", err
+	}
+
+	now := e.clock.Now()
+	if err := os.Chtimes(trashPath, now, now); err != nil {
+		e.logger.Warnf("Failed to stamp %s with the time it was trashed: %v", trashPath, err)
+	}
+	return trashPath, nil
+}
+
+// pruneLocalTrash deletes files that have been in the local trash longer
+// than sync.local_trash_retention days, along with folders left empty
+func (e *Engine) pruneLocalTrash() {
+	days := e.config.Sync.LocalTrashRetention
+	if days <= 0 {
+		return
+	}
+	cutoff := e.clock.Now().Add(-time.Duration(days) * 24 * time.Hour)
+
+	pruned := 0
+	for _, root := range localTrashRoots(e.syncFolders) {
+		var dirs []string
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if entry.IsDir() {
+				if path != root {
+					dirs = append(dirs, path)
+				}
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil || !info.ModTime().Before(cutoff) {
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				e.logger.Warnf("Failed to delete %s from the local trash: %v", path, err)
+				return nil
+			}
+			pruned++
+			return nil
+		})
+		if err != nil {
+			e.logger.Errorf("Failed to prune local trash %s: %v", root, err)
+		}
+
+		// Deepest first, so emptied parents go too; non-empty ones stay
+		for i := len(dirs) - 1; i >= 0; i-- {
+			os.Remove(dirs[i])
+		}
+	}
+
+	if pruned > 0 {
+		e.logger.Infof("Deleted %d files from the local trash after %d days", pruned, days)
+	}
+}
+
+// ListLocalTrash returns the files in the local trash of folders, most
+// recently trashed first
+func ListLocalTrash(folders []types.FolderConfig) ([]TrashedFile, error) {
+	var files []TrashedFile
+	for _, folder := range folders {
+		local := filepath.Clean(folder.Local)
+		root := filepath.Join(local, LocalTrashDir)
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if entry.IsDir() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, TrashedFile{
+				Path:      filepath.Join(local, rel),
+				TrashPath: path,
+				Size:      info.Size(),
+				TrashedAt: info.ModTime(),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list local trash of %s: %w", local, err)
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool { return files[i].TrashedAt.After(files[j].TrashedAt) })
+	return files, nil
+}
+
+// RestoreFromLocalTrash moves a file or folder in the local trash of
+// folders back to path, where it was before it was trashed. An existing
+// file at path is never overwritten. Restoring a file in a two-way folder
+// uploads it again.
+func RestoreFromLocalTrash(folders []types.FolderConfig, path string) error {
+	path = filepath.Clean(path)
+	trashPath := localTrashPath(folders, path)
+	if _, err := os.Lstat(trashPath); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrNotInLocalTrash, path)
+	} else if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists; move it away before restoring", path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.Rename(trashPath, path); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// deletedRemotely answers every request for a file with 404, as for a file
+// deleted in WorkDrive
+func deletedRemotely(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
+
+// syncedFile writes a file and records it as synced with remote ID remoteID
+func syncedFile(t *testing.T, engine *Engine, path, remoteID string) *types.FileMetadata {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("minutes"), 0644))
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+
+	require.NoError(t, engine.database.SaveFileMetadata(&types.FileMetadata{
+		Path: path, RemoteID: remoteID, Hash: hash, Size: 7, SyncStatus: "pending",
+	}))
+	metadata, err := engine.database.GetFileMetadata(path)
+	require.NoError(t, err)
+	return metadata
+}
+
+func TestRemoteDeletionMovesFileToLocalTrash(t *testing.T) {
+	engine, database := newTestEngine(t, &types.Config{Sync: types.SyncConfig{LocalTrash: true}}, deletedRemotely)
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}}
+
+	path := filepath.Join(local, "meetings", "minutes.txt")
+	metadata := syncedFile(t, engine, path, "remote-minutes")
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "the local copy is gone")
+	trashed := filepath.Join(local, LocalTrashDir, "meetings", "minutes.txt")
+	data, err := os.ReadFile(trashed)
+	require.NoError(t, err)
+	assert.Equal(t, "minutes", string(data))
+	assert.True(t, engine.shouldIgnoreFile(trashed), "the trash is not synced")
+
+	record, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Nil(t, record, "the file is no longer tracked")
+
+	files, err := ListLocalTrash(engine.syncFolders)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, path, files[0].Path)
+
+	require.NoError(t, RestoreFromLocalTrash(engine.syncFolders, path))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "minutes", string(data))
+
+	err = RestoreFromLocalTrash(engine.syncFolders, path)
+	assert.ErrorIs(t, err, ErrNotInLocalTrash)
+}
+
+func TestRemoteDeletionWithoutLocalTrash(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, deletedRemotely)
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/Docs", SyncMode: "download_only", Enabled: true}}
+
+	path := filepath.Join(local, "minutes.txt")
+	metadata := syncedFile(t, engine, path, "remote-minutes")
+	// Download-only folders follow the remote even without a recorded hash
+	metadata.Hash = ""
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	entries, err := os.ReadDir(local)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the file is deleted outright")
+}
+
+func TestPruneLocalTrash(t *testing.T) {
+	engine, _ := newTestEngine(t, &types.Config{Sync: types.SyncConfig{LocalTrash: true, LocalTrashRetention: 30}}, deletedRemotely)
+	fake := clock.NewFake(time.Now())
+	engine.clock = fake
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}}
+
+	old := filepath.Join(local, "old", "a.txt")
+	recent := filepath.Join(local, "recent.txt")
+	for _, path := range []string{old, recent} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+	}
+	_, err := engine.moveToLocalTrash(old)
+	require.NoError(t, err)
+	fake.Advance(20 * 24 * time.Hour)
+	_, err = engine.moveToLocalTrash(recent)
+	require.NoError(t, err)
+
+	fake.Advance(15 * 24 * time.Hour)
+	engine.pruneLocalTrash()
+
+	files, err := ListLocalTrash(engine.syncFolders)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, recent, files[0].Path)
+	_, err = os.Stat(filepath.Join(local, LocalTrashDir, "old"))
+	assert.True(t, os.IsNotExist(err), "emptied folders are removed")
+}
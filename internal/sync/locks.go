@@ -0,0 +1,60 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultLockTTL is how long an advisory lock lasts when sync.lock_ttl is
+// not set. It only needs to outlast one transfer; a client that crashes
+// while holding it blocks the file for no longer than this.
+const DefaultLockTTL = 2 * time.Minute
+
+// lockTimeout bounds the requests that take and release a lock
+const lockTimeout = 10 * time.Second
+
+// lockRemoteCopy takes an advisory lock on the remote copy of a file about
+// to be synced, if sync.advisory_locks is on and the backend supports it.
+// It returns a function releasing the lock, or held if another client
+// holds it and the file should wait. Failing to take the lock for any
+// other reason does not hold up syncing.
+func (e *Engine) lockRemoteCopy(ctx context.Context, metadata *types.FileMetadata) (release func(), held bool) {
+	release = func() {}
+	if !e.config.Sync.AdvisoryLocks || metadata.RemoteID == "" || metadata.IsDirectory {
+		return release, false
+	}
+	locker, ok := e.backend.(api.Locker)
+	if !ok {
+		return release, false
+	}
+
+	ttl := time.Duration(e.config.Sync.LockTTL) * time.Second
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+	lock, err := locker.AcquireLock(lockCtx, metadata.RemoteID, ttl)
+	if errors.Is(err, api.ErrLockHeld) {
+		e.logger.Infof("%s: %v, deferring it to a later sync", metadata.Path, err)
+		return release, true
+	}
+	if err != nil {
+		e.logger.Warnf("Failed to lock %s, syncing it unlocked: %v", metadata.Path, err)
+		return release, false
+	}
+
+	return func() {
+		// Released even if the sync was cancelled; the TTL covers failures
+		ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+		defer cancel()
+		if err := locker.ReleaseLock(ctx, lock); err != nil {
+			e.logger.Warnf("Failed to release the lock on %s, it expires on its own: %v", metadata.Path, err)
+		}
+	}, false
+}
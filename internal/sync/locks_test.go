@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lockServer keeps the advisory locks of a remote shared by several clients
+type lockServer struct {
+	mu      stdsync.Mutex
+	locks   map[string]api.Lock
+	uploads map[string]int
+}
+
+func newLockServer() *lockServer {
+	return &lockServer{locks: make(map[string]api.Lock), uploads: make(map[string]int)}
+}
+
+// handler serves the remote to the client called owner
+func (s *lockServer) handler(owner string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "lock":
+			if lock, ok := s.locks[parts[1]]; ok && lock.ExpiresAt.After(time.Now()) {
+				w.WriteHeader(http.StatusLocked)
+				json.NewEncoder(w).Encode(map[string]api.Lock{"data": lock})
+				return
+			}
+			var body struct{ TTL int }
+			json.NewDecoder(r.Body).Decode(&body)
+			lock := api.Lock{ID: "lock-" + owner, FileID: parts[1], Owner: owner, ExpiresAt: time.Now().Add(time.Duration(body.TTL) * time.Second)}
+			s.locks[parts[1]] = lock
+			json.NewEncoder(w).Encode(map[string]api.Lock{"data": lock})
+		case r.Method == http.MethodDelete && len(parts) == 4 && parts[2] == "lock":
+			if lock, ok := s.locks[parts[1]]; ok && lock.ID == parts[3] {
+				delete(s.locks, parts[1])
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload-` + owner + `"}}`))
+		case r.URL.Path == "/upload/upload-"+owner:
+			s.uploads[owner]++
+			w.Write([]byte(`{"data":{"id":"doc1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func (s *lockServer) uploaded(owner string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.uploads[owner]
+}
+
+func (s *lockServer) held() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.locks)
+}
+
+func TestAdvisoryLockDefersUploadUntilReleased(t *testing.T) {
+	remote := newLockServer()
+	dir := t.TempDir()
+	config := &types.Config{
+		Sync:    types.SyncConfig{AdvisoryLocks: true, LockTTL: 60},
+		Folders: []types.FolderConfig{{Local: dir, SyncMode: string(StrategyUploadOnly), Enabled: true}},
+	}
+	first, _ := newTestEngine(t, config, remote.handler("first"))
+	second, database := newTestEngine(t, config, remote.handler("second"))
+	ctx := context.Background()
+
+	// The first client is busy with the shared file
+	lock, err := first.backend.(api.Locker).AcquireLock(ctx, "doc1", time.Minute)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "budget.xlsx")
+	require.NoError(t, os.WriteFile(path, []byte("second's numbers"), 0644))
+	metadata := &types.FileMetadata{Path: path, RemoteID: "doc1", SyncStatus: "pending"}
+	require.NoError(t, database.SaveFileMetadata(metadata))
+
+	require.NoError(t, second.syncFile(ctx, metadata))
+	assert.Zero(t, remote.uploaded("second"), "the upload waits for the lock")
+	pending, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", pending.SyncStatus)
+
+	// Once the first client is done the second one goes ahead, locking the
+	// file itself while it uploads
+	require.NoError(t, first.backend.(api.Locker).ReleaseLock(ctx, lock))
+	require.NoError(t, second.syncFile(ctx, pending))
+	assert.Equal(t, 1, remote.uploaded("second"))
+	assert.Zero(t, remote.held(), "the lock is released after the upload")
+
+	synced, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", synced.SyncStatus)
+}
+
+func TestAdvisoryLocksAreOptIn(t *testing.T) {
+	remote := newLockServer()
+	remote.locks["doc1"] = api.Lock{ID: "lock-first", FileID: "doc1", Owner: "first", ExpiresAt: time.Now().Add(time.Minute)}
+	dir := t.TempDir()
+	config := &types.Config{Folders: []types.FolderConfig{{Local: dir, SyncMode: string(StrategyUploadOnly), Enabled: true}}}
+	engine, _ := newTestEngine(t, config, remote.handler("second"))
+
+	path := filepath.Join(dir, "budget.xlsx")
+	require.NoError(t, os.WriteFile(path, []byte("numbers"), 0644))
+	require.NoError(t, engine.syncFile(context.Background(), &types.FileMetadata{Path: path, RemoteID: "doc1"}))
+	assert.Equal(t, 1, remote.uploaded("second"))
+}
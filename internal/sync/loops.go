@@ -0,0 +1,153 @@
+package sync
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// syncLoopWindow is how long the transfers of a file are remembered to
+// notice it bouncing between this client and another one
+const syncLoopWindow = 10 * time.Minute
+
+// maxRecentTransfers bounds the transfers remembered for one file
+const maxRecentTransfers = 4
+
+// recentTransfer is a finished transfer of a file. Version is the remote
+// version the transfer left or found, if the server reported one.
+type recentTransfer struct {
+	direction string
+	hash      string
+	version   string
+	at        time.Time
+}
+
+// recentTransfers remembers the transfers of the last syncLoopWindow, by
+// local path
+type recentTransfers struct {
+	mu        sync.Mutex
+	transfers map[string][]recentTransfer
+	swept     time.Time
+}
+
+// newRecentTransfers creates an empty history
+func newRecentTransfers() *recentTransfers {
+	return &recentTransfers{transfers: make(map[string][]recentTransfer)}
+}
+
+// record adds a transfer of path
+func (r *recentTransfers) record(path string, transfer recentTransfer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if transfer.at.Sub(r.swept) > syncLoopWindow {
+		// Forget the files that have not moved for a while
+		for key, transfers := range r.transfers {
+			if transfer.at.Sub(transfers[len(transfers)-1].at) > syncLoopWindow {
+				delete(r.transfers, key)
+			}
+		}
+		r.swept = transfer.at
+	}
+
+	transfers := append(r.recent(path, transfer.at), transfer)
+	if len(transfers) > maxRecentTransfers {
+		transfers = transfers[len(transfers)-maxRecentTransfers:]
+	}
+	r.transfers[path] = transfers
+}
+
+// recent returns the transfers of path within the window ending at now.
+// The caller holds the lock.
+func (r *recentTransfers) recent(path string, now time.Time) []recentTransfer {
+	var recent []recentTransfer
+	for _, transfer := range r.transfers[path] {
+		if now.Sub(transfer.at) <= syncLoopWindow {
+			recent = append(recent, transfer)
+		}
+	}
+	return recent
+}
+
+// bounced reports whether the content with hash was both uploaded and
+// downloaded for path within the window ending at now
+func (r *recentTransfers) bounced(path, hash string, now time.Time) bool {
+	if hash == "" {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	up, down := false, false
+	for _, transfer := range r.recent(path, now) {
+		if !strings.EqualFold(transfer.hash, hash) {
+			continue
+		}
+		if transfer.direction == types.DirectionUpload {
+			up = true
+		} else {
+			down = true
+		}
+	}
+	return up && down
+}
+
+// last returns the latest transfer of path within the window ending at now
+func (r *recentTransfers) last(path string, now time.Time) (recentTransfer, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recent := r.recent(path, now)
+	if len(recent) == 0 {
+		return recentTransfer{}, false
+	}
+	return recent[len(recent)-1], true
+}
+
+// rememberTransfer adds a finished transfer of the content with hash to the
+// history sync loops are detected from
+func (e *Engine) rememberTransfer(path, direction, hash string, remoteInfo *api.FileInfo) {
+	transfer := recentTransfer{direction: direction, hash: hash, at: e.clock.Now()}
+	if remoteInfo != nil {
+		transfer.version = remoteInfo.Version
+	}
+	e.recent.record(path, transfer)
+}
+
+// settleSyncLoop stops a file from bouncing between this client and another
+// one, each sending back what it just got from the other because their
+// modification times disagree. Once the local content has gone both up and
+// down within syncLoopWindow, the two sides are compared by content instead:
+// if the remote copy holds the same content, nothing is transferred and the
+// file is recorded as synced to the current remote version. It reports
+// whether the file was settled.
+func (e *Engine) settleSyncLoop(metadata *types.FileMetadata, conflict *types.ConflictInfo, remoteInfo *api.FileInfo) bool {
+	now := e.clock.Now()
+	if !e.recent.bounced(metadata.Path, conflict.LocalHash, now) {
+		return false
+	}
+	e.logger.Warnf("Sync loop detected for %s: the same content was uploaded and downloaded again within %s", metadata.Path, syncLoopWindow)
+	if !e.remoteHolds(metadata.Path, remoteInfo, conflict.LocalHash, now) {
+		return false
+	}
+
+	e.logger.Infof("%s has the same content on both sides, leaving it as it is", metadata.Path)
+	metadata.Hash = conflict.LocalHash
+	metadata.Size = conflict.LocalSize
+	e.database.LogSyncOperation(metadata.ID, "sync", "loop", "settled on identical content")
+	return true
+}
+
+// remoteHolds reports whether the remote copy of path is known to hold the
+// content with hash: by the hash the server reports, or else by still being
+// at the version the last transfer of the file left or found
+func (e *Engine) remoteHolds(path string, remoteInfo *api.FileInfo, hash string, now time.Time) bool {
+	if remoteHash := e.comparableRemoteHash(remoteInfo); remoteHash != "" {
+		return strings.EqualFold(remoteHash, hash)
+	}
+	last, ok := e.recent.last(path, now)
+	return ok && strings.EqualFold(last.hash, hash) && last.version != "" && last.version == remoteInfo.Version
+}
@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncLoopBetweenClientsConverges(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/", SyncMode: "bidirectional", Enabled: true}}
+	engine.config.Sync.ConflictResolution = "newer"
+	ctx := context.Background()
+
+	path := filepath.Join(local, "report.txt")
+	remote := filepath.Join(remoteDir, "report.txt")
+	touch := func(path string, modified time.Time) {
+		require.NoError(t, os.Chtimes(path, modified, modified))
+	}
+	// sync marks the file changed, as the watcher or the remote poll
+	// would, and returns the transfers of the cycle that follows
+	sync := func() int {
+		metadata, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		if metadata == nil {
+			metadata = &types.FileMetadata{Path: path}
+		}
+		metadata.SyncStatus = "pending"
+		require.NoError(t, database.SaveFileMetadata(metadata))
+		result := engine.performSync(ctx)
+		require.Zero(t, result.Failed, "%v", result.Errors)
+		return result.Uploaded + result.Downloaded
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("quarterly numbers"), 0644))
+	assert.Equal(t, 1, sync(), "the new file is uploaded")
+
+	// The other client downloads it, gives it a newer modification time and
+	// sends the same content back, which is downloaded here in turn
+	require.NoError(t, os.WriteFile(remote, []byte("quarterly numbers"), 0644))
+	touch(remote, time.Now().Add(time.Minute))
+	assert.Equal(t, 1, sync())
+
+	// Downloading gave the local file a newer modification time again; the
+	// content it holds has now gone both ways and is not sent back
+	for i := 0; i < 3; i++ {
+		touch(path, time.Now().Add(time.Duration(i+2)*time.Minute))
+		assert.Zero(t, sync(), "round %d", i)
+		metadata, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		assert.Equal(t, "synced", metadata.SyncStatus)
+	}
+	content, err := os.ReadFile(remote)
+	require.NoError(t, err)
+	assert.Equal(t, "quarterly numbers", string(content))
+
+	// A real edit still goes up
+	require.NoError(t, os.WriteFile(path, []byte("revised numbers"), 0644))
+	touch(path, time.Now().Add(10*time.Minute))
+	assert.Equal(t, 1, sync())
+	content, err = os.ReadFile(remote)
+	require.NoError(t, err)
+	assert.Equal(t, "revised numbers", string(content))
+}
+
+func TestRecentTransfersForgetOldTransfers(t *testing.T) {
+	transfers := newRecentTransfers()
+	start := time.Now()
+	transfers.record("/a", recentTransfer{direction: types.DirectionUpload, hash: "h1", at: start})
+	transfers.record("/a", recentTransfer{direction: types.DirectionDownload, hash: "h1", at: start.Add(time.Minute)})
+	assert.True(t, transfers.bounced("/a", "h1", start.Add(time.Minute)))
+	assert.False(t, transfers.bounced("/a", "h2", start.Add(time.Minute)))
+	assert.False(t, transfers.bounced("/a", "h1", start.Add(syncLoopWindow+time.Second)), "the upload is too old to count")
+
+	transfers.record("/b", recentTransfer{direction: types.DirectionUpload, hash: "h3", at: start.Add(2 * syncLoopWindow)})
+	_, ok := transfers.transfers["/a"]
+	assert.False(t, ok, "files that stopped moving are forgotten")
+}
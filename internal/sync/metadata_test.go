@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadPreservesMetadata(t *testing.T) {
+	remoteModified := time.Date(2023, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	config := &types.Config{Sync: types.SyncConfig{PreserveMetadata: true}}
+	engine, _ := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/script1":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"id":            "script1",
+					"name":          "build.sh",
+					"modified_time": remoteModified,
+					"executable":    true,
+				},
+			})
+		case "/files/script1/download":
+			w.Write([]byte("#!/bin/sh\necho hello\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	metadata := &types.FileMetadata{
+		Path:     filepath.Join(t.TempDir(), "build.sh"),
+		RemoteID: "script1",
+	}
+	require.NoError(t, engine.downloadFile(context.Background(), metadata))
+
+	info, err := os.Stat(metadata.Path)
+	require.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(remoteModified), "mtime %s", info.ModTime())
+	assert.NotZero(t, info.Mode()&0100, "file should be executable, got %s", info.Mode())
+}
+
+func TestUploadSendsLocalMetadata(t *testing.T) {
+	var body map[string]interface{}
+
+	config := &types.Config{Sync: types.SyncConfig{PreserveMetadata: true}}
+	engine, _ := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload/initiate":
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			w.Write([]byte(`{"data":{"id":"remote-run"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "run.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	localModified := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, os.Chtimes(path, localModified, localModified))
+
+	require.NoError(t, engine.uploadFile(context.Background(), &types.FileMetadata{Path: path}))
+	assert.Equal(t, "2022-01-02T03:04:05Z", body["modified_time"])
+	assert.Equal(t, true, body["executable"])
+}
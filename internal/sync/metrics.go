@@ -0,0 +1,45 @@
+package sync
+
+import (
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// recordTransfer adds a finished transfer to the sync history, which the
+// status metrics are computed from
+func (e *Engine) recordTransfer(metadata *types.FileMetadata, direction string, bytes int64, started time.Time) {
+	operation := "download"
+	if direction == types.DirectionUpload {
+		operation = "upload"
+	}
+	err := e.database.LogOperation(types.OperationRecord{
+		FileID:    metadata.ID,
+		Type:      operation,
+		Status:    "success",
+		Direction: direction,
+		Bytes:     bytes,
+		Duration:  time.Since(started),
+	})
+	if err != nil {
+		e.logger.Warnf("Failed to record transfer of %s: %v", metadata.Path, err)
+	}
+	e.tallies.transferred(direction, bytes)
+}
+
+// recordFailure adds a failed sync to the history along with the type of
+// error, so failures can be counted by cause
+func (e *Engine) recordFailure(metadata *types.FileMetadata, err error) {
+	classified := withPath(ClassifyError("sync", err), metadata.Path)
+	record := types.OperationRecord{
+		FileID:    metadata.ID,
+		Type:      "sync",
+		Status:    "failed",
+		Error:     err.Error(),
+		ErrorType: classified.Type.String(),
+	}
+	if logErr := e.database.LogOperation(record); logErr != nil {
+		e.logger.Errorf("Failed to log sync operation: %v", logErr)
+	}
+	e.tallies.failed(classified)
+}
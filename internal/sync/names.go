@@ -0,0 +1,180 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on the names and paths uploaded to WorkDrive
+const (
+	MaxNameLength = 250
+	MaxPathLength = 1024
+)
+
+// invalidNameChars are the characters WorkDrive does not allow in names
+const invalidNameChars = `<>:"/\|?*`
+
+// nameProblem describes why WorkDrive would reject a name, or returns an
+// empty string if the name is acceptable
+func nameProblem(name string) string {
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return fmt.Sprintf("name is longer than %d characters", MaxNameLength)
+	}
+
+	var found []string
+	for _, r := range name {
+		if r < 0x20 || strings.ContainsRune(invalidNameChars, r) {
+			char := fmt.Sprintf("%q", r)
+			if !containsString(found, char) {
+				found = append(found, char)
+			}
+		}
+	}
+	if len(found) > 0 {
+		return "name contains characters WorkDrive does not allow: " + strings.Join(found, " ")
+	}
+
+	if strings.HasSuffix(name, " ") || strings.HasSuffix(name, ".") {
+		return "name ends with a space or period"
+	}
+	return ""
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeName replaces the parts of a name WorkDrive would reject
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(invalidNameChars, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if trimmed := strings.TrimRight(name, " ."); trimmed != name {
+		name = trimmed + "_"
+	}
+
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		ext := filepath.Ext(name)
+		if utf8.RuneCountInString(ext) >= MaxNameLength/2 {
+			ext = ""
+		}
+		runes := []rune(strings.TrimSuffix(name, ext))
+		name = string(runes[:MaxNameLength-utf8.RuneCountInString(ext)]) + ext
+	}
+	return name
+}
+
+// withSuffix numbers a name to tell it apart from a colliding one, keeping
+// the extension and the length limit
+func withSuffix(name string, n int) string {
+	suffix := fmt.Sprintf(" (%d)", n)
+	ext := filepath.Ext(name)
+	runes := []rune(strings.TrimSuffix(name, ext))
+	if keep := MaxNameLength - len(suffix) - utf8.RuneCountInString(ext); len(runes) > keep && keep > 0 {
+		runes = runes[:keep]
+	}
+	return string(runes) + suffix + ext
+}
+
+// remotePathLength returns the length of the remote path a local file is
+// uploaded to, relative to its sync folder
+func (e *Engine) remotePathLength(path string) int {
+	folder := e.folderForPath(path)
+	if folder == nil {
+		return utf8.RuneCountInString(path)
+	}
+	rel, err := filepath.Rel(folder.Local, path)
+	if err != nil {
+		return utf8.RuneCountInString(path)
+	}
+	return utf8.RuneCountInString(folder.Remote) + 1 + utf8.RuneCountInString(filepath.ToSlash(rel))
+}
+
+// remoteName returns the name a local file is uploaded under. Names WorkDrive
+// would reject fail with a validation error unless sync.sanitize_names is
+// set, in which case a substitute name is chosen and recorded so downloads
+// can restore the original.
+func (e *Engine) remoteName(path string) (string, error) {
+	if length := e.remotePathLength(path); length > MaxPathLength {
+		return "", NewSyncErrorWithFile(ErrorTypeValidation, "upload", path,
+			fmt.Sprintf("remote path would be %d characters, longer than the %d allowed", length, MaxPathLength), nil)
+	}
+
+	mapped, err := e.database.GetRemoteName(path)
+	if err != nil {
+		return "", err
+	}
+	if mapped != "" {
+		return mapped, nil
+	}
+
+	name := filepath.Base(path)
+	problem := nameProblem(name)
+	if problem == "" {
+		return name, nil
+	}
+	if !e.config.Sync.SanitizeNames {
+		return "", NewSyncErrorWithFile(ErrorTypeValidation, "upload", path,
+			problem+" (enable sync.sanitize_names to upload it under a substitute name)", nil)
+	}
+
+	sanitized := sanitizeName(name)
+	candidate := sanitized
+	for n := 1; ; n++ {
+		taken, err := e.remoteNameTaken(path, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			break
+		}
+		candidate = withSuffix(sanitized, n)
+	}
+
+	if err := e.database.SaveNameMapping(path, candidate); err != nil {
+		return "", err
+	}
+	e.logger.Infof("Uploading %s as %q: %s", path, candidate, problem)
+	return candidate, nil
+}
+
+// remoteNameTaken reports whether a substitute name for path would collide
+// with a sibling's real name or another substitute
+func (e *Engine) remoteNameTaken(path, name string) (bool, error) {
+	dir := filepath.Dir(path)
+	if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+		return true, nil
+	}
+
+	other, err := e.database.GetMappedLocalPath(dir, name)
+	if err != nil {
+		return false, err
+	}
+	return other != "" && other != path, nil
+}
+
+// localName returns the local name for a remote entry downloaded into dir,
+// restoring the original name if the entry was uploaded under a substitute
+func (e *Engine) localName(dir, remoteName string) string {
+	original, err := e.database.GetMappedLocalPath(dir, remoteName)
+	if err != nil {
+		e.logger.Warnf("Failed to look up original name of %s: %v", remoteName, err)
+	}
+	if original != "" {
+		return filepath.Base(original)
+	}
+	return remoteName
+}
@@ -0,0 +1,136 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameProblem(t *testing.T) {
+	assert.Empty(t, nameProblem("report (final).txt"))
+	assert.Contains(t, nameProblem("a:b?.txt"), `':' '?'`)
+	assert.Contains(t, nameProblem("tab\there"), `'\t'`)
+	assert.Contains(t, nameProblem("trailing."), "ends with")
+	assert.Contains(t, nameProblem(strings.Repeat("x", MaxNameLength+1)), "longer than")
+}
+
+func TestSanitizeName(t *testing.T) {
+	assert.Equal(t, "a_b_.txt", sanitizeName("a:b?.txt"))
+	assert.Equal(t, "notes_", sanitizeName("notes. "))
+	assert.Equal(t, "a_b (2).txt", withSuffix("a_b.txt", 2))
+
+	long := sanitizeName(strings.Repeat("x", 300) + ".txt")
+	assert.Len(t, long, MaxNameLength)
+	assert.True(t, strings.HasSuffix(long, ".txt"))
+	assert.Empty(t, nameProblem(withSuffix(long, 1)))
+}
+
+func TestInvalidNamesRejectedBeforeUpload(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	dir := t.TempDir()
+	for _, name := range []string{"bad:name.txt", "trailing ", strings.Repeat("x", MaxNameLength+1)} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("data"), 0644))
+
+		err := engine.uploadFileTo(context.Background(), &types.FileMetadata{Path: path}, "root")
+		var syncErr *SyncError
+		require.True(t, errors.As(err, &syncErr), name)
+		assert.Equal(t, ErrorTypeValidation, syncErr.Type)
+		assert.Equal(t, path, syncErr.FilePath)
+		assert.Contains(t, syncErr.Message, "sanitize_names")
+	}
+}
+
+func TestSanitizedNamesRoundTrip(t *testing.T) {
+	var (
+		mu       gosync.Mutex
+		tree     = remoteTree{}
+		names    = map[string]string{} // upload id -> remote name
+		contents = map[string]string{} // upload id -> body
+	)
+	config := &types.Config{Sync: types.SyncConfig{SanitizeNames: true}}
+	engine, _ := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.URL.Path == "/files":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"proj","is_folder":true}}`))
+		case r.URL.Path == "/upload/initiate":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			id := fmt.Sprintf("up%d", len(names))
+			names[id] = body["filename"].(string)
+			fmt.Fprintf(w, `{"data":{"upload_id":%q}}`, id)
+		case strings.HasPrefix(r.URL.Path, "/upload/"):
+			id := strings.TrimPrefix(r.URL.Path, "/upload/")
+			data, _ := io.ReadAll(r.Body)
+			contents[id] = string(data)
+			tree.add("proj", api.FileInfo{ID: id, Name: names[id], Size: int64(len(data))})
+			fmt.Fprintf(w, `{"data":{"id":%q}}`, id)
+		case strings.HasSuffix(r.URL.Path, "/files"):
+			tree.handler(w, r)
+		case strings.HasSuffix(r.URL.Path, "/download"):
+			id := strings.Split(strings.TrimPrefix(r.URL.Path, "/files/"), "/")[0]
+			w.Write([]byte(contents[id]))
+		case strings.HasPrefix(r.URL.Path, "/files/"):
+			id := strings.TrimPrefix(r.URL.Path, "/files/")
+			json.NewEncoder(w).Encode(api.FileInfo{ID: id, Name: names[id], Size: int64(len(contents[id]))})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	root := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	files := map[string]string{
+		"a:b.txt": "colon",
+		"a_b.txt": "plain",
+		"c?d.txt": "question",
+	}
+	for name, body := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(body), 0644))
+	}
+
+	result, err := engine.UploadFolder(context.Background(), root, "dest")
+	require.NoError(t, err)
+	require.Equal(t, 3, result.Uploaded)
+
+	// The sanitized name of a:b.txt collides with the real a_b.txt
+	var remote []string
+	for _, name := range names {
+		remote = append(remote, name)
+	}
+	assert.ElementsMatch(t, []string{"a_b (1).txt", "a_b.txt", "c_d.txt"}, remote)
+
+	// Downloading restores the original names
+	for name := range files {
+		require.NoError(t, os.Remove(filepath.Join(root, name)))
+	}
+	result, err = engine.DownloadFolder(context.Background(), "proj", root)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Downloaded)
+
+	for name, body := range files {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		require.NoError(t, err, name)
+		assert.Equal(t, body, string(data), name)
+	}
+}
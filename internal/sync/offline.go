@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"context"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/backoff"
+)
+
+// How often the remote is probed: rarely while it answers, more often while
+// it is unreachable so transfers resume soon after it comes back. Probes of
+// an unreachable remote back off from offlineProbeInterval to
+// onlineProbeInterval with jitter, so that clients cut off by the same outage
+// do not all probe it at once when it ends.
+const (
+	onlineProbeInterval  = 60 * time.Second
+	offlineProbeInterval = 10 * time.Second
+	probeTimeout         = 10 * time.Second
+)
+
+// reachabilityFunc returns an error if the remote cannot be reached
+type reachabilityFunc func(ctx context.Context) error
+
+// newReachability returns the probe of backend, or nil if the backend
+// cannot be probed, in which case the engine always assumes it is online
+func newReachability(backend api.RemoteBackend) reachabilityFunc {
+	if prober, ok := backend.(api.Prober); ok {
+		return prober.Probe
+	}
+	return nil
+}
+
+// IsOffline returns whether transfers are suspended because the remote
+// cannot be reached
+func (e *Engine) IsOffline() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.offline
+}
+
+// checkConnectivity probes the remote, switching the engine to or from
+// offline mode, and reports whether the remote is reachable
+func (e *Engine) checkConnectivity(ctx context.Context) bool {
+	if e.reachable == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	err := e.reachable(ctx)
+	if ctx.Err() == context.Canceled {
+		// The caller gave up, which says nothing about the network
+		return !e.IsOffline()
+	}
+
+	e.mu.Lock()
+	wasOffline := e.offline
+	e.offline = err != nil
+	e.mu.Unlock()
+
+	switch {
+	case err != nil && !wasOffline:
+		e.logger.Warnf("Remote unreachable, deferring transfers until it is back: %v", err)
+	case err == nil && wasOffline:
+		e.logger.Info("Remote reachable again, resuming transfers")
+		e.TriggerSync()
+	}
+	return err == nil
+}
+
+// monitorConnectivity probes the remote until the engine stops
+func (e *Engine) monitorConnectivity(ctx context.Context) {
+	probes := backoff.Policy{
+		Initial: offlineProbeInterval,
+		Max:     onlineProbeInterval,
+		Factor:  2,
+		Jitter:  e.retry.retryConfig.Jitter,
+	}
+
+	failures := 0
+	for {
+		interval := onlineProbeInterval
+		if e.checkConnectivity(ctx) {
+			failures = 0
+		} else {
+			interval = probes.Delay(failures)
+			failures++
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopChan:
+			return
+		case <-e.clock.After(interval):
+		}
+	}
+}
+
+// deferredOffline reports whether a failed transfer failed because the
+// remote became unreachable. Such transfers are left pending rather than
+// failed and wait for the remote to come back.
+func (e *Engine) deferredOffline(ctx context.Context, err error) bool {
+	switch ClassifyError("sync", err).Type {
+	case ErrorTypeNetwork, ErrorTypeTimeout:
+		return !e.checkConnectivity(ctx)
+	default:
+		return false
+	}
+}
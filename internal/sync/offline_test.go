@@ -0,0 +1,118 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reachabilityStub is a remote whose reachability the test switches
+type reachabilityStub struct {
+	mu     stdsync.Mutex
+	online bool
+}
+
+func (s *reachabilityStub) set(online bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.online = online
+}
+
+func (s *reachabilityStub) probe(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.online {
+		return errors.New("network is unreachable")
+	}
+	return nil
+}
+
+func TestOfflineEngineDefersTransfersUntilReachable(t *testing.T) {
+	var mu stdsync.Mutex
+	uploads := 0
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/upload/initiate" {
+			uploads++
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+			return
+		}
+		http.NotFound(w, r)
+	})
+	stub := &reachabilityStub{}
+	engine.reachable = stub.probe
+	ctx := context.Background()
+
+	assert.False(t, engine.checkConnectivity(ctx))
+	assert.True(t, engine.IsOffline())
+
+	// Changes are still recorded while offline, but nothing is sent
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	engine.queueFileForSync(path, fsnotify.Create)
+	engine.performSync(ctx)
+
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "pending", metadata.SyncStatus)
+	mu.Lock()
+	assert.Equal(t, 0, uploads)
+	mu.Unlock()
+
+	status, err := engine.GetSyncStatus()
+	require.NoError(t, err)
+	assert.Equal(t, types.SyncStateOffline, status.State)
+
+	// Coming back online asks for a sync cycle that drains the queue
+	stub.set(true)
+	assert.True(t, engine.checkConnectivity(ctx))
+	assert.False(t, engine.IsOffline())
+	select {
+	case <-engine.syncTrigger:
+	default:
+		t.Fatal("going back online did not trigger a sync")
+	}
+	engine.performSync(ctx)
+
+	mu.Lock()
+	assert.Equal(t, 1, uploads)
+	mu.Unlock()
+}
+
+func TestTransferFailingOfflineStaysPending(t *testing.T) {
+	// The connection drops mid-request, as it does when the network goes
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	})
+	stub := &reachabilityStub{online: true}
+	engine.reachable = func(ctx context.Context) error {
+		stub.set(false)
+		return stub.probe(ctx)
+	}
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	engine.queueFileForSync(path, fsnotify.Create)
+	engine.performSync(ctx)
+
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "pending", metadata.SyncStatus, "a deferred file is not an error")
+	assert.True(t, engine.IsOffline())
+}
@@ -0,0 +1,85 @@
+package sync
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procDir is where the process information isOpenForWriting reads lives
+const procDir = "/proc"
+
+// openAccessMode masks the access mode in the open flags Linux reports in
+// fdinfo, which is zero for read-only
+const openAccessMode = 0x3
+
+// isOpenForWriting reports whether any process has path open for writing.
+// It reads /proc, so it only finds anything on Linux, and only in processes
+// it may inspect: it is a best-effort check for deferring uploads of files
+// still being written.
+func isOpenForWriting(path string) (bool, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false, err
+	}
+	target, err = filepath.Abs(target)
+	if err != nil {
+		return false, err
+	}
+
+	processes, err := os.ReadDir(procDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, process := range processes {
+		if _, err := strconv.Atoi(process.Name()); err != nil {
+			continue
+		}
+		if hasOpenForWriting(filepath.Join(procDir, process.Name()), target) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasOpenForWriting reports whether the process at pidDir has target open
+// for writing. Processes that exit or cannot be inspected don't count.
+func hasOpenForWriting(pidDir, target string) bool {
+	fds, err := os.ReadDir(filepath.Join(pidDir, "fd"))
+	if err != nil {
+		return false
+	}
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(pidDir, "fd", fd.Name()))
+		if err != nil || link != target {
+			continue
+		}
+		if flags, ok := fdFlags(filepath.Join(pidDir, "fdinfo", fd.Name())); ok && flags&openAccessMode != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// fdFlags reads the open flags of a file descriptor from its fdinfo file
+func fdFlags(fdinfo string) (int64, bool) {
+	file, err := os.Open(fdinfo)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, found := strings.CutPrefix(scanner.Text(), "flags:"); found {
+			flags, err := strconv.ParseInt(strings.TrimSpace(value), 8, 64)
+			return flags, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOpenForWriting(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("open files are found through /proc")
+	}
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("draft"), 0644))
+
+	open, err := isOpenForWriting(path)
+	require.NoError(t, err)
+	assert.False(t, open)
+
+	reader, err := os.Open(path)
+	require.NoError(t, err)
+	open, err = isOpenForWriting(path)
+	require.NoError(t, err)
+	assert.False(t, open, "readers don't count")
+	reader.Close()
+
+	writer, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	open, err = isOpenForWriting(path)
+	require.NoError(t, err)
+	assert.True(t, open)
+	writer.Close()
+}
+
+func TestOpenFileIsDeferred(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("open files are found through /proc")
+	}
+	var uploads atomic.Int32
+	config := &types.Config{Sync: types.SyncConfig{SkipOpenFiles: true}}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			uploads.Add(1)
+			w.Write([]byte(`{"data":{"id":"remote-db"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "app.db")
+	require.NoError(t, os.WriteFile(path, []byte("pages"), 0644))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "pending"}))
+	held, err := os.OpenFile(path, os.O_RDWR, 0)
+	require.NoError(t, err)
+
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+	assert.Zero(t, uploads.Load(), "a file open for writing is not uploaded")
+	deferred, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", deferred.SyncStatus)
+
+	// Once released it goes up with the next sync
+	require.NoError(t, held.Close())
+	require.NoError(t, engine.syncFile(context.Background(), deferred))
+	assert.Equal(t, int32(1), uploads.Load())
+}
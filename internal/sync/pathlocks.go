@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// pathLocks coordinates the updates of one file's metadata. Events for the
+// same path arrive in quick succession and are queued from separate
+// goroutines, so without it one could record the file as it was before
+// another's newer change, or a finishing sync could mark a file synced that
+// was changed again while it transferred.
+type pathLocks struct {
+	mu    sync.Mutex
+	paths map[string]*pathLock
+}
+
+// pathLock guards the metadata of one path. queued counts how often the
+// path was queued while the lock was in use.
+type pathLock struct {
+	mu     sync.Mutex
+	refs   int
+	queued uint64
+}
+
+// newPathLocks creates an empty set of path locks
+func newPathLocks() *pathLocks {
+	return &pathLocks{paths: make(map[string]*pathLock)}
+}
+
+// acquire returns the lock of path, keeping it alive until release
+func (p *pathLocks) acquire(path string) *pathLock {
+	path = filepath.Clean(path)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l := p.paths[path]
+	if l == nil {
+		l = &pathLock{}
+		p.paths[path] = l
+	}
+	l.refs++
+	return l
+}
+
+// release gives up a lock returned by acquire
+func (p *pathLocks) release(path string) {
+	path = filepath.Clean(path)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if l := p.paths[path]; l != nil {
+		if l.refs--; l.refs <= 0 {
+			delete(p.paths, path)
+		}
+	}
+}
+
+// queueGeneration returns how often the path of l has been queued, to be
+// compared once a sync of it finishes
+func (l *pathLock) queueGeneration() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.queued
+}
+
+// saveSyncResult records the outcome of a sync of metadata, begun when the
+// file had been queued generation times. If it was queued again since, the
+// newer change has not been synced: the file stays pending with its current
+// size and time instead of being recorded as synced.
+func (e *Engine) saveSyncResult(l *pathLock, generation uint64, metadata *types.FileMetadata) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.queued != generation && metadata.SyncStatus == "synced" {
+		e.logger.Infof("%s changed while it synced, keeping it pending", metadata.Path)
+		metadata.SyncStatus = "pending"
+		metadata.Hash = ""
+		if info, err := os.Stat(metadata.Path); err == nil {
+			metadata.Size = info.Size()
+			metadata.ModifiedTime = info.ModTime()
+		}
+	}
+	return e.database.SaveFileMetadata(metadata)
+}
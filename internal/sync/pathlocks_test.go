@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentQueueingOfOnePath(t *testing.T) {
+	engine, database := newTestEngine(t, nil, http.NotFound)
+	path := filepath.Join(t.TempDir(), "draft.txt")
+	require.NoError(t, os.WriteFile(path, []byte("v0"), 0644))
+
+	// Writers grow the file while many events for it are queued at once;
+	// whatever the interleaving, the last queue sees the final content
+	var wg stdsync.WaitGroup
+	var writes stdsync.Mutex
+	content := []byte("v0")
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i%5 == 0 {
+				writes.Lock()
+				content = append(content, '+')
+				os.WriteFile(path, content, 0644)
+				writes.Unlock()
+			}
+			engine.queueFileForSync(path, fsnotify.Write)
+		}(i)
+	}
+	wg.Wait()
+	engine.queueFileForSync(path, fsnotify.Write)
+
+	pending, err := database.GetPendingFiles()
+	require.NoError(t, err)
+	require.Len(t, pending, 1, "one record per path")
+	assert.Equal(t, "pending", pending[0].SyncStatus)
+	assert.Equal(t, int64(len(content)), pending[0].Size)
+
+	engine.pathLocks.mu.Lock()
+	assert.Empty(t, engine.pathLocks.paths, "unused locks are dropped")
+	engine.pathLocks.mu.Unlock()
+}
+
+func TestFileChangedDuringSyncStaysPending(t *testing.T) {
+	var engine *Engine
+	path := filepath.Join(t.TempDir(), "notes.txt")
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			// The file is saved again while its upload is in flight
+			require.NoError(t, os.WriteFile(path, []byte("second draft"), 0644))
+			engine.queueFileForSync(path, fsnotify.Write)
+			w.Write([]byte(`{"data":{"id":"remote-notes"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte("draft"), 0644))
+	engine.queueFileForSync(path, fsnotify.Create)
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", saved.SyncStatus, "the newer change still needs syncing")
+	assert.Equal(t, int64(len("second draft")), saved.Size)
+	assert.Equal(t, "remote-notes", saved.RemoteID, "the completed upload is kept")
+}
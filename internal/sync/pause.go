@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/bdstest/zohosync/internal/storage"
+)
+
+// pausedConfigKey persists the paused state across daemon restarts
+const pausedConfigKey = "sync.paused"
+
+// loadPausedState reads the persisted paused flag
+func loadPausedState(database *storage.Database) bool {
+	value, err := database.GetConfigValue(pausedConfigKey)
+	if err != nil {
+		return false
+	}
+	return value == "true"
+}
+
+// Pause suspends transfers without stopping the engine. Change events are
+// still recorded as pending and are transferred once sync is resumed.
+func (e *Engine) Pause() error {
+	e.mu.Lock()
+	e.paused = true
+	e.mu.Unlock()
+
+	if err := e.database.SetConfigValue(pausedConfigKey, "true"); err != nil {
+		return err
+	}
+
+	e.logger.Info("Sync paused")
+	return nil
+}
+
+// Resume re-enables transfers and triggers an immediate sync cycle
+func (e *Engine) Resume() error {
+	e.mu.Lock()
+	e.paused = false
+	e.mu.Unlock()
+
+	if err := e.database.SetConfigValue(pausedConfigKey, "false"); err != nil {
+		return err
+	}
+
+	e.TriggerSync()
+	e.logger.Info("Sync resumed")
+	return nil
+}
+
+// IsPaused returns whether transfers are currently suspended
+func (e *Engine) IsPaused() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.paused
+}
+
+// TriggerSync requests a sync cycle as soon as the engine is idle
+func (e *Engine) TriggerSync() {
+	select {
+	case e.syncTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// SyncNow runs a sync cycle of every pending file and returns once it has
+// finished. A running engine first finishes the initial syncs it started
+// with; otherwise they are run here.
+func (e *Engine) SyncNow(ctx context.Context) error {
+	e.mu.RLock()
+	running, initialDone := e.isRunning, e.initialDone
+	e.mu.RUnlock()
+
+	if running {
+		select {
+		case <-initialDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else {
+		e.runInitialSyncs(ctx)
+	}
+
+	e.performSync(ctx)
+	return ctx.Err()
+}
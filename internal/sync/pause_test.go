@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPausedEngineQueuesWithoutTransferring(t *testing.T) {
+	var mu stdsync.Mutex
+	uploads := 0
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/upload/initiate" {
+			uploads++
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	require.NoError(t, engine.Pause())
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	engine.queueFileForSync(path, fsnotify.Create)
+
+	ctx := context.Background()
+	engine.performSync(ctx)
+
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "pending", metadata.SyncStatus)
+	mu.Lock()
+	assert.Equal(t, 0, uploads)
+	mu.Unlock()
+
+	require.NoError(t, engine.Resume())
+	engine.performSync(ctx)
+
+	mu.Lock()
+	assert.Equal(t, 1, uploads)
+	mu.Unlock()
+}
+
+func TestSyncNowWaitsForInitialSync(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}}
+
+	report := filepath.Join(local, "report.txt")
+	require.NoError(t, os.WriteFile(report, []byte("report"), 0644))
+
+	ctx := context.Background()
+	require.NoError(t, engine.Start(ctx))
+	defer engine.Stop()
+	require.NoError(t, engine.SyncNow(ctx))
+
+	// No waiting: the upload has finished when SyncNow returns
+	assert.FileExists(t, filepath.Join(remoteDir, "Docs", "report.txt"))
+	metadata, err := database.GetFileMetadata(report)
+	require.NoError(t, err)
+	require.NotNil(t, metadata)
+	assert.Equal(t, "synced", metadata.SyncStatus)
+}
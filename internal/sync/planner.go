@@ -0,0 +1,378 @@
+package sync
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+)
+
+// DefaultListPageSize is the number of remote entries requested per page
+// when listing a folder for planning
+const DefaultListPageSize = 200
+
+// PlanOperationType is the action the planner decided on for a path
+type PlanOperationType string
+
+const (
+	// PlanUpload means the path only exists locally
+	PlanUpload PlanOperationType = "upload"
+	// PlanDownload means the path only exists remotely
+	PlanDownload PlanOperationType = "download"
+	// PlanResolve means both sides exist but differ and need conflict resolution
+	PlanResolve PlanOperationType = "resolve"
+	// PlanReplace means the path is a folder on one side and a file on the
+	// other. The record of its last sync tells which side changed type.
+	PlanReplace PlanOperationType = "replace"
+)
+
+// PlanEntry describes one file or folder on either side of a sync folder.
+// Path is relative to the sync root and always uses forward slashes.
+type PlanEntry struct {
+	Path         string
+	Size         int64
+	ModifiedTime time.Time
+	IsDirectory  bool
+	RemoteID     string
+	// Hash and HashAlgorithm are the content hash the server reports for
+	// a remote file, if any
+	Hash          string
+	HashAlgorithm string
+	// Exported marks a remote native document, whose local copy is an
+	// export of it
+	Exported bool
+}
+
+// PlanOperation is a single planned sync action
+type PlanOperation struct {
+	Type   PlanOperationType
+	Path   string
+	Local  *PlanEntry
+	Remote *PlanEntry
+}
+
+// EntryIterator yields plan entries in comparePaths order and returns io.EOF
+// once exhausted
+type EntryIterator interface {
+	Next() (*PlanEntry, error)
+}
+
+// planSyncOperations builds the plan by loading both listings into maps.
+// It is simple but holds every entry of both trees in memory at once; large
+// trees should use streamSyncOperations instead, which produces the same
+// operations in the same order.
+func planSyncOperations(local, remote []PlanEntry) []PlanOperation {
+	localByPath := make(map[string]*PlanEntry, len(local))
+	remoteByPath := make(map[string]*PlanEntry, len(remote))
+	paths := make([]string, 0, len(local)+len(remote))
+
+	for i := range local {
+		localByPath[local[i].Path] = &local[i]
+		paths = append(paths, local[i].Path)
+	}
+	for i := range remote {
+		if _, ok := localByPath[remote[i].Path]; !ok {
+			paths = append(paths, remote[i].Path)
+		}
+		remoteByPath[remote[i].Path] = &remote[i]
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		return comparePaths(paths[i], paths[j]) < 0
+	})
+
+	var operations []PlanOperation
+	var replaced replacedFolders
+	for _, p := range paths {
+		if op, ok := decidePlanOperation(localByPath[p], remoteByPath[p]); ok && !replaced.covers(op) {
+			operations = append(operations, op)
+		}
+	}
+	return operations
+}
+
+// streamSyncOperations merge-joins two sorted listings and emits operations
+// as it goes, so memory is bounded by the iterators rather than tree size
+func streamSyncOperations(local, remote EntryIterator, emit func(PlanOperation) error) error {
+	var replaced replacedFolders
+	return pairEntries(local, remote, func(localEntry, remoteEntry *PlanEntry) error {
+		if op, ok := decidePlanOperation(localEntry, remoteEntry); ok && !replaced.covers(op) {
+			return emit(op)
+		}
+		return nil
+	})
+}
+
+// pairEntries merge-joins two sorted listings, calling fn with the entries
+// for each path; the one missing from a side is nil
+func pairEntries(local, remote EntryIterator, fn func(local, remote *PlanEntry) error) error {
+	l, err := nextEntry(local)
+	if err != nil {
+		return err
+	}
+	r, err := nextEntry(remote)
+	if err != nil {
+		return err
+	}
+
+	for l != nil || r != nil {
+		var localEntry, remoteEntry *PlanEntry
+
+		switch {
+		case r == nil:
+			localEntry = l
+		case l == nil:
+			remoteEntry = r
+		default:
+			switch cmp := comparePaths(l.Path, r.Path); {
+			case cmp < 0:
+				localEntry = l
+			case cmp > 0:
+				remoteEntry = r
+			default:
+				localEntry, remoteEntry = l, r
+			}
+		}
+
+		if err := fn(localEntry, remoteEntry); err != nil {
+			return err
+		}
+
+		if localEntry != nil {
+			if l, err = nextEntry(local); err != nil {
+				return err
+			}
+		}
+		if remoteEntry != nil {
+			if r, err = nextEntry(remote); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// nextEntry advances an iterator, mapping io.EOF to a nil entry
+func nextEntry(it EntryIterator) (*PlanEntry, error) {
+	entry, err := it.Next()
+	if err == io.EOF {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// decidePlanOperation picks the action for a path given what exists on each side
+func decidePlanOperation(local, remote *PlanEntry) (PlanOperation, bool) {
+	switch {
+	case local == nil && remote == nil:
+		return PlanOperation{}, false
+	case remote == nil:
+		return PlanOperation{Type: PlanUpload, Path: local.Path, Local: local}, true
+	case local == nil:
+		return PlanOperation{Type: PlanDownload, Path: remote.Path, Remote: remote}, true
+	}
+
+	if local.IsDirectory && remote.IsDirectory {
+		return PlanOperation{}, false
+	}
+	if local.IsDirectory != remote.IsDirectory {
+		return PlanOperation{Type: PlanReplace, Path: local.Path, Local: local, Remote: remote}, true
+	}
+	if local.Size == remote.Size &&
+		local.ModifiedTime.Truncate(time.Second).Equal(remote.ModifiedTime.Truncate(time.Second)) {
+		return PlanOperation{}, false
+	}
+
+	return PlanOperation{Type: PlanResolve, Path: local.Path, Local: local, Remote: remote}, true
+}
+
+// replacedFolders drops the operations for the contents of a remote folder
+// where there is a local file, as the replace operation on the folder
+// takes care of them either way. It relies on operations arriving in
+// comparePaths order, which puts a folder's contents right after it.
+type replacedFolders struct {
+	path string
+}
+
+// covers reports whether op lies inside the last replaced remote folder
+func (r *replacedFolders) covers(op PlanOperation) bool {
+	if r.path != "" && strings.HasPrefix(op.Path, r.path+"/") {
+		return true
+	}
+	r.path = ""
+	if op.Type == PlanReplace && op.Remote.IsDirectory {
+		r.path = op.Path
+	}
+	return false
+}
+
+// comparePaths orders slash-separated paths component by component, which is
+// the depth-first order in which the tree iterators visit entries
+func comparePaths(a, b string) int {
+	for {
+		aHead, aRest, aMore := strings.Cut(a, "/")
+		bHead, bRest, bMore := strings.Cut(b, "/")
+
+		if c := strings.Compare(aHead, bHead); c != 0 {
+			return c
+		}
+		switch {
+		case !aMore && !bMore:
+			return 0
+		case !aMore:
+			return -1
+		case !bMore:
+			return 1
+		}
+		a, b = aRest, bRest
+	}
+}
+
+// treeIterator walks a tree depth-first, listing one folder at a time and
+// visiting siblings in name order, so only the folders on the current path
+// are held in memory
+type treeIterator struct {
+	list    func(parent *PlanEntry) ([]PlanEntry, error)
+	stack   []*treeFrame
+	started bool
+}
+
+// treeFrame holds the sorted children of one folder being walked
+type treeFrame struct {
+	entries []PlanEntry
+	next    int
+}
+
+// Next returns the next entry in comparePaths order
+func (t *treeIterator) Next() (*PlanEntry, error) {
+	if !t.started {
+		t.started = true
+		if err := t.push(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(t.stack) > 0 {
+		frame := t.stack[len(t.stack)-1]
+		if frame.next >= len(frame.entries) {
+			t.stack = t.stack[:len(t.stack)-1]
+			continue
+		}
+
+		entry := &frame.entries[frame.next]
+		frame.next++
+
+		if entry.IsDirectory {
+			if err := t.push(entry); err != nil {
+				return nil, err
+			}
+		}
+		return entry, nil
+	}
+
+	return nil, io.EOF
+}
+
+// push lists a folder's children and makes them the next entries to visit
+func (t *treeIterator) push(parent *PlanEntry) error {
+	entries, err := t.list(parent)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	t.stack = append(t.stack, &treeFrame{entries: entries})
+	return nil
+}
+
+// newLocalIterator walks a local sync folder, skipping names rejected by ignore
+func newLocalIterator(root string, ignore func(path string) bool) EntryIterator {
+	return &treeIterator{
+		list: func(parent *PlanEntry) ([]PlanEntry, error) {
+			dir, prefix := root, ""
+			if parent != nil {
+				dir, prefix = filepath.Join(root, filepath.FromSlash(parent.Path)), parent.Path
+			}
+
+			children, err := os.ReadDir(dir)
+			if err != nil {
+				return nil, err
+			}
+
+			entries := make([]PlanEntry, 0, len(children))
+			for _, child := range children {
+				if ignore != nil && ignore(filepath.Join(dir, child.Name())) {
+					continue
+				}
+				info, err := child.Info()
+				if err != nil {
+					if os.IsNotExist(err) {
+						continue
+					}
+					return nil, err
+				}
+				entries = append(entries, PlanEntry{
+					Path:         path.Join(prefix, child.Name()),
+					Size:         info.Size(),
+					ModifiedTime: info.ModTime(),
+					IsDirectory:  info.IsDir(),
+				})
+			}
+			return entries, nil
+		},
+	}
+}
+
+// newRemoteIterator walks a remote folder, fetching each folder's children
+// page by page. local gives the local name and size of each file; nil keeps
+// the remote ones.
+func newRemoteIterator(ctx context.Context, client api.RemoteBackend, rootID string, pageSize int, local func(file *api.FileInfo) (string, int64)) EntryIterator {
+	if pageSize <= 0 {
+		pageSize = DefaultListPageSize
+	}
+	if local == nil {
+		local = func(file *api.FileInfo) (string, int64) { return file.Name, file.Size }
+	}
+
+	return &treeIterator{
+		list: func(parent *PlanEntry) ([]PlanEntry, error) {
+			folderID, prefix := rootID, ""
+			if parent != nil {
+				folderID, prefix = parent.RemoteID, parent.Path
+			}
+
+			var entries []PlanEntry
+			for offset := 0; ; offset += pageSize {
+				page, err := client.ListFilesPage(ctx, folderID, offset, pageSize)
+				if err != nil {
+					return nil, err
+				}
+				for i := range page {
+					file := &page[i]
+					name, size := local(file)
+					entries = append(entries, PlanEntry{
+						Path:          path.Join(prefix, name),
+						Size:          size,
+						ModifiedTime:  file.ModifiedTime,
+						IsDirectory:   file.IsFolder,
+						RemoteID:      file.ID,
+						Hash:          file.Hash,
+						HashAlgorithm: file.HashAlgorithm,
+						Exported:      !file.IsFolder && api.IsNativeDocument(file.Type),
+					})
+				}
+				if len(page) < pageSize {
+					return entries, nil
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,268 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sliceIterator yields pre-sorted entries from memory
+type sliceIterator struct {
+	entries []PlanEntry
+	next    int
+}
+
+func (s *sliceIterator) Next() (*PlanEntry, error) {
+	if s.next >= len(s.entries) {
+		return nil, io.EOF
+	}
+	s.next++
+	return &s.entries[s.next-1], nil
+}
+
+// drain collects every entry from an iterator
+func drain(t testing.TB, it EntryIterator) []PlanEntry {
+	var entries []PlanEntry
+	for {
+		entry, err := it.Next()
+		if err == io.EOF {
+			return entries
+		}
+		require.NoError(t, err)
+		entries = append(entries, *entry)
+	}
+}
+
+// remoteTree serves a paginated folder listing from memory
+type remoteTree map[string][]api.FileInfo
+
+func (tree remoteTree) add(parentID string, info api.FileInfo) {
+	tree[parentID] = append(tree[parentID], info)
+}
+
+func (tree remoteTree) handler(w http.ResponseWriter, r *http.Request) {
+	folderID := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/files/"), "/files")
+	children := tree[folderID]
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	end := offset + limit
+	if offset > len(children) {
+		offset = len(children)
+	}
+	if end > len(children) {
+		end = len(children)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": children[offset:end]})
+}
+
+func TestComparePaths(t *testing.T) {
+	assert.Equal(t, 0, comparePaths("a/b", "a/b"))
+	assert.Negative(t, comparePaths("a", "a/b"))
+	// Component order puts a directory's children before a sibling like "a.txt",
+	// even though '.' sorts before '/' as plain strings
+	assert.Negative(t, comparePaths("a/b", "a.txt"))
+	assert.Positive(t, comparePaths("b", "a/z"))
+}
+
+func TestStreamingPlanMatchesInMemoryPlan(t *testing.T) {
+	root := t.TempDir()
+	modified := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tree := remoteTree{}
+	rng := rand.New(rand.NewSource(1))
+
+	writeLocal := func(rel string, size int) {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, make([]byte, size), 0644))
+		require.NoError(t, os.Chtimes(full, modified, modified))
+	}
+	addRemote := func(parentID, name string, size int64, isFolder bool) {
+		tree.add(parentID, api.FileInfo{
+			ID:           "id-" + name + "-" + parentID,
+			Name:         name,
+			Size:         size,
+			ModifiedTime: modified,
+			IsFolder:     isFolder,
+		})
+	}
+
+	var uploads, downloads, resolves int
+	for d := 0; d < 20; d++ {
+		dir := fmt.Sprintf("dir%02d", d)
+		dirID := "id-" + dir + "-root"
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+		addRemote("root", dir, 0, true)
+
+		for f := 0; f < 50; f++ {
+			name := fmt.Sprintf("file%03d.txt", f)
+			writeLocal(path.Join(dir, name), f)
+
+			switch {
+			case f%7 == 0:
+				uploads++
+			case f%5 == 0:
+				addRemote(dirID, name, int64(f+1), false)
+				resolves++
+			default:
+				addRemote(dirID, name, int64(f), false)
+			}
+		}
+
+		addRemote(dirID, "remote-only.txt", 10, false)
+		downloads++
+	}
+
+	// A root file whose name sorts between "dir00" and "dir00/..." as a string
+	writeLocal("dir00.txt", 3)
+	uploads++
+
+	addRemote("root", "shared", 0, true)
+	downloads++
+	for f := 0; f < 10; f++ {
+		addRemote("id-shared-root", fmt.Sprintf("doc%d.pdf", f), 100, false)
+		downloads++
+	}
+
+	// Listings are not guaranteed to be sorted by the server
+	for id := range tree {
+		rng.Shuffle(len(tree[id]), func(i, j int) { tree[id][i], tree[id][j] = tree[id][j], tree[id][i] })
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(tree.handler))
+	defer server.Close()
+	t.Setenv("HOME", t.TempDir())
+	client := api.NewClient(&types.TokenInfo{AccessToken: "test_token"})
+	client.SetBaseURL(server.URL)
+	ctx := context.Background()
+
+	// Reference plan from fully loaded, shuffled listings
+	local := drain(t, newLocalIterator(root, nil))
+	remote := drain(t, newRemoteIterator(ctx, client, "root", 16, nil))
+	rng.Shuffle(len(local), func(i, j int) { local[i], local[j] = local[j], local[i] })
+	rng.Shuffle(len(remote), func(i, j int) { remote[i], remote[j] = remote[j], remote[i] })
+	expected := planSyncOperations(local, remote)
+
+	var streamed []PlanOperation
+	err := streamSyncOperations(
+		newLocalIterator(root, nil),
+		newRemoteIterator(ctx, client, "root", 16, nil),
+		func(op PlanOperation) error {
+			streamed = append(streamed, op)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, len(expected), len(streamed))
+	for i := range expected {
+		assert.Equal(t, expected[i].Type, streamed[i].Type, "operation %d", i)
+		assert.Equal(t, expected[i].Path, streamed[i].Path, "operation %d", i)
+	}
+
+	counts := map[PlanOperationType]int{}
+	for _, op := range streamed {
+		counts[op.Type]++
+	}
+	assert.Equal(t, uploads, counts[PlanUpload])
+	assert.Equal(t, downloads, counts[PlanDownload])
+	assert.Equal(t, resolves, counts[PlanResolve])
+}
+
+// syntheticListings returns sorted local and remote listings of n files
+// spread over folders, differing in a predictable fraction of entries
+func syntheticListings(n int) ([]PlanEntry, []PlanEntry) {
+	var local, remote []PlanEntry
+	for i := 0; i < n; i++ {
+		if i%100 == 0 {
+			dir := PlanEntry{Path: fmt.Sprintf("d%06d", i/100), IsDirectory: true}
+			local = append(local, dir)
+			remote = append(remote, dir)
+		}
+		entry := PlanEntry{Path: fmt.Sprintf("d%06d/f%06d", i/100, i), Size: int64(i)}
+		if i%3 != 0 {
+			local = append(local, entry)
+		}
+		if i%4 != 0 {
+			remote = append(remote, entry)
+		}
+	}
+	return local, remote
+}
+
+func BenchmarkPlanInMemory(b *testing.B) {
+	local, remote := syntheticListings(100000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		planSyncOperations(local, remote)
+	}
+}
+
+func BenchmarkPlanStreaming(b *testing.B) {
+	local, remote := syntheticListings(100000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		count := 0
+		streamSyncOperations(&sliceIterator{entries: local}, &sliceIterator{entries: remote}, func(PlanOperation) error {
+			count++
+			return nil
+		})
+	}
+}
+
+func TestPlanReplacesEntriesThatChangedType(t *testing.T) {
+	local := []PlanEntry{
+		{Path: "notes", IsDirectory: true},
+		{Path: "notes/todo.txt", Size: 4},
+		{Path: "photos", Size: 10},
+		{Path: "photos.txt", Size: 1},
+	}
+	remote := []PlanEntry{
+		{Path: "notes", Size: 9, RemoteID: "notes-file"},
+		{Path: "photos", IsDirectory: true, RemoteID: "photos-folder"},
+		{Path: "photos/a.jpg", Size: 100, RemoteID: "a"},
+		{Path: "photos/old", IsDirectory: true, RemoteID: "old"},
+		{Path: "photos/old/b.jpg", Size: 100, RemoteID: "b"},
+		{Path: "photos.txt", Size: 1},
+	}
+
+	var streamed []PlanOperation
+	err := streamSyncOperations(&sliceIterator{entries: local}, &sliceIterator{entries: remote}, func(op PlanOperation) error {
+		streamed = append(streamed, op)
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, operations := range [][]PlanOperation{planSyncOperations(local, remote), streamed} {
+		// The contents of the replaced remote folder go with it
+		require.Len(t, operations, 3)
+		assert.Equal(t, PlanReplace, operations[0].Type)
+		assert.Equal(t, "notes", operations[0].Path)
+		assert.Equal(t, PlanUpload, operations[1].Type)
+		assert.Equal(t, "notes/todo.txt", operations[1].Path)
+		assert.Equal(t, PlanReplace, operations[2].Type)
+		assert.Equal(t, "photos", operations[2].Path)
+		assert.Equal(t, "photos-folder", operations[2].Remote.RemoteID)
+	}
+}
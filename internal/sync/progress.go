@@ -0,0 +1,151 @@
+package sync
+
+import (
+	"math"
+	gosync "sync"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+)
+
+// ProgressInfo is a snapshot of the progress of a transfer: files of a
+// folder transfer, or bytes of a single file
+type ProgressInfo struct {
+	Path  string
+	Done  int64
+	Total int64
+	Err   error
+}
+
+// Percent returns how much of the transfer is done, from 0 to 100
+func (p ProgressInfo) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Done) / float64(p.Total) * 100
+}
+
+// Complete reports whether the transfer has finished
+func (p ProgressInfo) Complete() bool {
+	return p.Total > 0 && p.Done >= p.Total
+}
+
+// ProgressThresholds decide which updates a ProgressNotifier passes on
+type ProgressThresholds struct {
+	// MinPercent is the change in Percent that counts as progress
+	MinPercent float64
+	// MinInterval is the shortest gap between two callbacks; changes that
+	// arrive sooner are coalesced into the next one
+	MinInterval time.Duration
+	// Heartbeat passes on the latest snapshot even if nothing changed once
+	// this long has gone by without a callback. Zero disables it.
+	Heartbeat time.Duration
+}
+
+// DefaultProgressThresholds report every percent, at most ten times a second,
+// with a heartbeat every five seconds
+var DefaultProgressThresholds = ProgressThresholds{
+	MinPercent:  1,
+	MinInterval: 100 * time.Millisecond,
+	Heartbeat:   5 * time.Second,
+}
+
+// ProgressNotifier passes progress snapshots on to a callback only when they
+// say something new: the first and final snapshot and errors always, and
+// otherwise a new file or a change of at least MinPercent, no more than once
+// per MinInterval. Bursts of updates in between are coalesced, so the
+// callback sees the latest state when it next fires or on Flush. The
+// callback is called with the notifier locked and must not call back into it.
+type ProgressNotifier struct {
+	mu         gosync.Mutex
+	fn         func(ProgressInfo)
+	thresholds ProgressThresholds
+	clock      clock.Clock
+
+	last    ProgressInfo
+	lastAt  time.Time
+	fired   bool
+	latest  ProgressInfo
+	pending bool
+}
+
+// NewProgressNotifier creates a notifier that calls fn as set out by
+// thresholds
+func NewProgressNotifier(fn func(ProgressInfo), thresholds ProgressThresholds) *ProgressNotifier {
+	return &ProgressNotifier{
+		fn:         fn,
+		thresholds: thresholds,
+		clock:      clock.Real{},
+	}
+}
+
+// Update records a progress snapshot, calling back if it is worth reporting
+func (n *ProgressNotifier) Update(info ProgressInfo) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.clock.Now()
+	elapsed := now.Sub(n.lastAt)
+
+	switch {
+	case !n.fired, info.Err != nil, info.Complete() && !(n.last.Complete() && n.last.Path == info.Path):
+		// Always worth reporting
+	case n.changed(info) && elapsed >= n.thresholds.MinInterval:
+	case n.thresholds.Heartbeat > 0 && elapsed >= n.thresholds.Heartbeat:
+	default:
+		n.latest = info
+		n.pending = info.Path != n.last.Path || info.Done != n.last.Done || info.Total != n.last.Total
+		return
+	}
+	n.fire(info, now)
+}
+
+// Flush passes on the latest snapshot if it differs from the last one
+// passed on, so the callback ends up with the final state
+func (n *ProgressNotifier) Flush() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.pending {
+		n.fire(n.latest, n.clock.Now())
+	}
+}
+
+// ProgressFunc adapts the notifier to folder transfer progress callbacks
+func (n *ProgressNotifier) ProgressFunc() ProgressFunc {
+	return func(done, total int, path string, err error) {
+		n.Update(ProgressInfo{Path: path, Done: int64(done), Total: int64(total), Err: err})
+	}
+}
+
+// UploadProgressFunc adapts the notifier to upload progress callbacks
+func (n *ProgressNotifier) UploadProgressFunc() UploadProgressFunc {
+	return func(path string, sent, total int64) {
+		n.Update(ProgressInfo{Path: path, Done: sent, Total: total})
+	}
+}
+
+// DownloadProgressFunc adapts the notifier to download progress callbacks
+func (n *ProgressNotifier) DownloadProgressFunc() DownloadProgressFunc {
+	return func(path string, received, total int64) {
+		n.Update(ProgressInfo{Path: path, Done: received, Total: total})
+	}
+}
+
+// changed reports whether info differs meaningfully from the last snapshot
+// passed on
+func (n *ProgressNotifier) changed(info ProgressInfo) bool {
+	if info.Path != n.last.Path {
+		return true
+	}
+	return math.Abs(info.Percent()-n.last.Percent()) >= n.thresholds.MinPercent
+}
+
+// fire calls back with info; the notifier must be locked
+func (n *ProgressNotifier) fire(info ProgressInfo, now time.Time) {
+	n.last = info
+	n.lastAt = now
+	n.fired = true
+	n.pending = false
+	n.fn(info)
+}
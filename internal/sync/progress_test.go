@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestNotifier creates a notifier on a clock that only moves when the
+// returned function is called, collecting what it passes on
+func newTestNotifier(thresholds ProgressThresholds) (*ProgressNotifier, *[]ProgressInfo, func(time.Duration)) {
+	var got []ProgressInfo
+	fake := clock.NewFake(time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC))
+	notifier := NewProgressNotifier(func(info ProgressInfo) { got = append(got, info) }, thresholds)
+	notifier.clock = fake
+	return notifier, &got, fake.Advance
+}
+
+func TestProgressNotifierDropsIdenticalSnapshots(t *testing.T) {
+	notifier, got, advance := newTestNotifier(DefaultProgressThresholds)
+
+	// Ten seconds of identical snapshots every millisecond
+	for i := 0; i < 10000; i++ {
+		notifier.Update(ProgressInfo{Path: "big.iso", Done: 500, Total: 1000})
+		advance(time.Millisecond)
+	}
+
+	// The first snapshot plus one heartbeat every five seconds
+	assert.LessOrEqual(t, len(*got), 3)
+	assert.GreaterOrEqual(t, len(*got), 2)
+}
+
+func TestProgressNotifierCoalescesBursts(t *testing.T) {
+	notifier, got, advance := newTestNotifier(ProgressThresholds{MinPercent: 1, MinInterval: 100 * time.Millisecond})
+
+	// A fast transfer reports every byte within a few milliseconds
+	for done := int64(1); done <= 1000; done++ {
+		notifier.Update(ProgressInfo{Path: "fast.bin", Done: done, Total: 1000})
+		if done%100 == 0 {
+			advance(10 * time.Millisecond)
+		}
+	}
+
+	require.NotEmpty(t, *got)
+	assert.LessOrEqual(t, len(*got), 3, "updates within MinInterval are coalesced")
+	last := (*got)[len(*got)-1]
+	assert.True(t, last.Complete(), "the final snapshot is always passed on")
+}
+
+func TestProgressNotifierReportsMeaningfulChanges(t *testing.T) {
+	notifier, got, advance := newTestNotifier(ProgressThresholds{MinPercent: 10, MinInterval: time.Second})
+
+	notifier.Update(ProgressInfo{Path: "a.txt", Done: 1, Total: 100})
+	advance(2 * time.Second)
+	notifier.Update(ProgressInfo{Path: "a.txt", Done: 5, Total: 100})
+	assert.Len(t, *got, 1, "a small change is held back")
+
+	notifier.Flush()
+	require.Len(t, *got, 2, "flushing passes on the held back change")
+	assert.Equal(t, int64(5), (*got)[1].Done)
+
+	advance(2 * time.Second)
+	notifier.Update(ProgressInfo{Path: "b.txt", Done: 6, Total: 100})
+	assert.Len(t, *got, 3, "a new file is reported")
+
+	notifier.Update(ProgressInfo{Path: "b.txt", Done: 7, Total: 100, Err: errors.New("boom")})
+	assert.Len(t, *got, 4, "errors are never held back")
+
+	notifier.Flush()
+	assert.Len(t, *got, 4, "nothing is pending")
+}
@@ -0,0 +1,87 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// QueuePolicy decides the order in which pending files are synced
+type QueuePolicy string
+
+const (
+	// QueueSmallestFirst syncs small files first so progress shows quickly
+	QueueSmallestFirst QueuePolicy = "smallest-first"
+	// QueueFIFO syncs files in the order they were changed
+	QueueFIFO QueuePolicy = "fifo"
+	// QueueLargestFirst starts the longest transfers first
+	QueueLargestFirst QueuePolicy = "largest-first"
+)
+
+// DefaultQueuePolicy is used when the config does not set sync.queue_order
+const DefaultQueuePolicy = QueueSmallestFirst
+
+// queueFairnessInterval is how often a size-ordered queue takes a file from
+// its other end, so files at the back are not starved by the front
+const queueFairnessInterval = 4
+
+// ParseQueuePolicy validates a sync.queue_order value; empty selects the
+// default
+func ParseQueuePolicy(value string) (QueuePolicy, error) {
+	switch policy := QueuePolicy(value); policy {
+	case "":
+		return DefaultQueuePolicy, nil
+	case QueueSmallestFirst, QueueFIFO, QueueLargestFirst:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid queue order %q (expected %s, %s or %s)",
+			value, QueueSmallestFirst, QueueFIFO, QueueLargestFirst)
+	}
+}
+
+// queuePolicy returns the configured queue policy, falling back to the
+// default for an invalid value
+func (e *Engine) queuePolicy() QueuePolicy {
+	policy, err := ParseQueuePolicy(e.config.Sync.QueueOrder)
+	if err != nil {
+		e.logger.Warnf("%v, using %s", err, DefaultQueuePolicy)
+		return DefaultQueuePolicy
+	}
+	return policy
+}
+
+// orderQueue returns the pending files in the order the policy syncs them.
+// Size-ordered policies give every queueFairnessInterval-th slot to the file
+// at the other end of the queue, so a steady supply of small files cannot
+// hold back a large one indefinitely, and vice versa.
+func orderQueue(files []types.FileMetadata, policy QueuePolicy) []types.FileMetadata {
+	sorted := make([]types.FileMetadata, len(files))
+	copy(sorted, files)
+
+	// Ties, and the FIFO policy, go by modification time
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ModifiedTime.Before(sorted[j].ModifiedTime)
+	})
+	switch policy {
+	case QueueSmallestFirst:
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Size < sorted[j].Size })
+	case QueueLargestFirst:
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Size > sorted[j].Size })
+	default:
+		return sorted
+	}
+
+	ordered := make([]types.FileMetadata, 0, len(sorted))
+	front, back := 0, len(sorted)-1
+	for front <= back {
+		if (len(ordered)+1)%queueFairnessInterval == 0 {
+			ordered = append(ordered, sorted[back])
+			back--
+		} else {
+			ordered = append(ordered, sorted[front])
+			front++
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,76 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queueOf builds pending files with the given sizes, changed one minute
+// apart in the given order
+func queueOf(sizes ...int64) []types.FileMetadata {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	files := make([]types.FileMetadata, len(sizes))
+	for i, size := range sizes {
+		files[i] = types.FileMetadata{Size: size, ModifiedTime: start.Add(time.Duration(i) * time.Minute)}
+	}
+	return files
+}
+
+// sizesOf returns the sizes of files in order
+func sizesOf(files []types.FileMetadata) []int64 {
+	sizes := make([]int64, len(files))
+	for i, file := range files {
+		sizes[i] = file.Size
+	}
+	return sizes
+}
+
+func TestOrderQueuePolicies(t *testing.T) {
+	queue := queueOf(500, 10, 3000, 20, 40, 1000)
+
+	tests := []struct {
+		policy QueuePolicy
+		want   []int64
+	}{
+		// Every fourth slot is taken from the other end of the queue
+		{QueueSmallestFirst, []int64{10, 20, 40, 3000, 500, 1000}},
+		{QueueLargestFirst, []int64{3000, 1000, 500, 10, 40, 20}},
+		{QueueFIFO, []int64{500, 10, 3000, 20, 40, 1000}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			assert.Equal(t, tt.want, sizesOf(orderQueue(queue, tt.policy)))
+		})
+	}
+
+	assert.Equal(t, []int64{500, 10, 3000, 20, 40, 1000}, sizesOf(queue), "the input queue is left alone")
+}
+
+func TestOrderQueueDoesNotStarveLargeFiles(t *testing.T) {
+	sizes := make([]int64, 0, 41)
+	for i := 0; i < 40; i++ {
+		sizes = append(sizes, int64(i+1))
+	}
+	sizes = append(sizes, 1<<30)
+
+	ordered := orderQueue(queueOf(sizes...), QueueSmallestFirst)
+	require.Len(t, ordered, len(sizes))
+	assert.Equal(t, int64(1<<30), ordered[queueFairnessInterval-1].Size)
+}
+
+func TestParseQueuePolicy(t *testing.T) {
+	policy, err := ParseQueuePolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultQueuePolicy, policy)
+
+	policy, err = ParseQueuePolicy("largest-first")
+	require.NoError(t, err)
+	assert.Equal(t, QueueLargestFirst, policy)
+
+	_, err = ParseQueuePolicy("random")
+	assert.Error(t, err)
+}
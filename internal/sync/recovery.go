@@ -0,0 +1,125 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// syncStatusDownloading marks a file whose download has started but not
+// finished. It only outlives the download if the process dies mid-way.
+const syncStatusDownloading = "downloading"
+
+// downloadTempSuffix ends the name of every download temp file
+const downloadTempSuffix = ".zohosync-part"
+
+// markDownloading records that a download is in progress
+func (e *Engine) markDownloading(metadata *types.FileMetadata) {
+	tracked, err := e.database.SetSyncStatus(metadata.Path, syncStatusDownloading)
+	if err == nil && !tracked {
+		marker := *metadata
+		marker.SyncStatus = syncStatusDownloading
+		err = e.database.SaveFileMetadata(&marker)
+	}
+	if err != nil {
+		e.logger.Warnf("Failed to mark %s as downloading: %v", metadata.Path, err)
+	}
+}
+
+// openDownload starts the download of a file into tempPath. A temp file
+// left by an interrupted download is resumed where it stopped if the
+// backend supports ranges and the remote file has not changed since;
+// otherwise the download starts over. Encrypted downloads always start
+// over, since the temp file holds plaintext.
+func (e *Engine) openDownload(ctx context.Context, metadata *types.FileMetadata, remoteInfo *api.FileInfo, tempPath string) (io.ReadCloser, *os.File, error) {
+	if ranged, ok := e.backend.(api.RangeDownloader); ok && remoteInfo.Version != "" && e.encryption == nil {
+		if info, err := os.Stat(tempPath); err == nil && info.Size() > 0 {
+			reader, partial, err := ranged.DownloadFileRange(ctx, metadata.RemoteID, info.Size(), remoteInfo.Version)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to download file: %w", err)
+			}
+
+			flags := os.O_WRONLY | os.O_TRUNC
+			if partial {
+				e.logger.Infof("Resuming download of %s at %d bytes", metadata.Path, info.Size())
+				flags = os.O_WRONLY | os.O_APPEND
+			}
+			localFile, err := os.OpenFile(tempPath, flags, 0644)
+			if err != nil {
+				reader.Close()
+				return nil, nil, fmt.Errorf("failed to open local file: %w", err)
+			}
+			return reader, localFile, nil
+		}
+	}
+
+	reader, err := e.backend.DownloadFile(ctx, metadata.RemoteID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	if reader, err = e.decryptDownload(metadata.Path, reader); err != nil {
+		reader.Close()
+		return nil, nil, fmt.Errorf("failed to decrypt file: %w", err)
+	}
+	localFile, err := os.Create(tempPath)
+	if err != nil {
+		reader.Close()
+		return nil, nil, fmt.Errorf("failed to create local file: %w", err)
+	}
+	return reader, localFile, nil
+}
+
+// recoverInterruptedDownloads runs at startup to clean up after a crash.
+// Files left in the downloading state go back to pending so the next sync
+// fetches them, resuming from their temp file where possible; temp files
+// that no interrupted download can use are removed.
+func (e *Engine) recoverInterruptedDownloads() {
+	interrupted, err := e.database.GetFilesByStatus(syncStatusDownloading)
+	if err != nil {
+		e.logger.Warnf("Failed to look up interrupted downloads: %v", err)
+		return
+	}
+
+	_, resumable := e.backend.(api.RangeDownloader)
+	keep := make(map[string]bool)
+	for _, file := range interrupted {
+		tempPath := downloadTempPath(file.Path)
+		if resumable && file.RemoteID != "" {
+			keep[tempPath] = true
+		}
+		if _, err := e.database.SetSyncStatus(file.Path, "pending"); err != nil {
+			e.logger.Warnf("Failed to requeue interrupted download of %s: %v", file.Path, err)
+			continue
+		}
+		e.logger.Infof("Requeued interrupted download of %s", file.Path)
+	}
+
+	for _, folder := range e.syncFolders {
+		if !folder.Enabled {
+			continue
+		}
+		filepath.WalkDir(folder.Local, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !isDownloadTemp(d.Name()) || keep[path] {
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				e.logger.Warnf("Failed to remove orphaned download %s: %v", path, err)
+			} else {
+				e.logger.Infof("Removed orphaned download %s", path)
+			}
+			return nil
+		})
+	}
+}
+
+// isDownloadTemp reports whether a file name is a download temp file
+func isDownloadTemp(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, downloadTempSuffix)
+}
@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverInterruptedDownloads(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	content := "the quick brown fox jumps over the lazy dog"
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "report.txt"), []byte(content), 0644))
+
+	localDir := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: localDir, Remote: "/", Enabled: true}}
+
+	// A crash part way through a download leaves its row and temp file
+	// behind, along with the temp file of a download nothing knows about
+	path := filepath.Join(localDir, "report.txt")
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: path, RemoteID: "/report.txt", Size: int64(len(content)), SyncStatus: syncStatusDownloading,
+	}))
+	tempPath := downloadTempPath(path)
+	require.NoError(t, os.WriteFile(tempPath, []byte(content[:16]), 0644))
+	orphan := downloadTempPath(filepath.Join(localDir, "gone.txt"))
+	require.NoError(t, os.WriteFile(orphan, []byte("stale"), 0644))
+
+	engine.recoverInterruptedDownloads()
+
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", saved.SyncStatus)
+	assert.FileExists(t, tempPath, "a resumable download keeps its temp file")
+	assert.NoFileExists(t, orphan)
+
+	// The next sync finishes the download from where it stopped
+	require.NoError(t, engine.syncFile(ctx, saved))
+
+	local, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(local))
+	assert.NoFileExists(t, tempPath)
+
+	saved, err = database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", saved.SyncStatus)
+}
+
+func TestResumedDownloadRestartsWhenRemoteChanged(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	content := "rewritten remotely since the download began"
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "report.txt"), []byte(content), 0644))
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	metadata := &types.FileMetadata{Path: path, RemoteID: "/report.txt", SyncStatus: "pending"}
+	require.NoError(t, database.SaveFileMetadata(metadata))
+
+	// A temp file longer than the remote file cannot be a prefix of it
+	require.NoError(t, os.WriteFile(downloadTempPath(path), []byte(content+" and then some"), 0644))
+
+	require.NoError(t, engine.downloadFile(ctx, metadata))
+
+	local, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(local))
+}
@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// Reindex rebuilds the file records of a sync folder, or of every sync
+// folder if folder is empty, from what is on disk. The records are dropped
+// and the local tree is walked again alongside its remote counterpart, so
+// files already in WorkDrive are recorded as synced, or left for conflict
+// resolution if they differ, instead of being uploaded again. Files only
+// one side has are marked pending, which uploads or downloads them; a file
+// removed locally while the records were wrong is therefore restored rather
+// than deleted remotely. progress, if not nil, is called with the number of
+// entries indexed so far. It returns the number of entries indexed.
+func (e *Engine) Reindex(ctx context.Context, folder string, progress func(indexed int)) (int, error) {
+	folders := e.syncFolders
+	if folder != "" {
+		folder = filepath.Clean(folder)
+		folders = nil
+		for _, candidate := range e.syncFolders {
+			if filepath.Clean(candidate.Local) == folder {
+				folders = append(folders, candidate)
+			}
+		}
+		if len(folders) == 0 {
+			return 0, fmt.Errorf("%s is not a sync folder", folder)
+		}
+		if _, err := e.database.DeleteFilesUnder(folder); err != nil {
+			return 0, err
+		}
+	} else if _, err := e.database.ClearFiles(); err != nil {
+		return 0, err
+	}
+
+	indexed := 0
+	for _, f := range folders {
+		var err error
+		if indexed, err = e.reindexFolder(ctx, f, indexed, progress); err != nil {
+			return indexed, fmt.Errorf("failed to reindex %s: %w", f.Local, err)
+		}
+	}
+	if progress != nil {
+		progress(indexed)
+	}
+	e.logger.Infof("Reindexed %d entries in %d sync folder(s)", indexed, len(folders))
+	return indexed, nil
+}
+
+// reindexFolder records the entries of one sync folder, matching the local
+// and remote trees path by path, and returns the running count of entries
+// indexed
+func (e *Engine) reindexFolder(ctx context.Context, folder types.FolderConfig, indexed int, progress func(indexed int)) (int, error) {
+	remoteID, err := e.ensureRemoteFolderPath(ctx, folder.Remote, false)
+	if err != nil {
+		return indexed, err
+	}
+
+	local := newLocalIterator(folder.Local, e.shouldIgnoreFile)
+	remote := newRemoteIterator(ctx, e.backend, remoteID, DefaultListPageSize, e.remoteEntry)
+	l, err := nextEntry(local)
+	if err != nil {
+		return indexed, err
+	}
+	r, err := nextEntry(remote)
+	if err != nil {
+		return indexed, err
+	}
+
+	// mismatched is a path that is a file on one side and a folder on the
+	// other; nothing under it is recorded
+	mismatched := ""
+	for l != nil || r != nil {
+		if err := ctx.Err(); err != nil {
+			return indexed, err
+		}
+
+		var localEntry, remoteEntry *PlanEntry
+		switch {
+		case r == nil:
+			localEntry = l
+		case l == nil:
+			remoteEntry = r
+		default:
+			switch cmp := comparePaths(l.Path, r.Path); {
+			case cmp < 0:
+				localEntry = l
+			case cmp > 0:
+				remoteEntry = r
+			default:
+				localEntry, remoteEntry = l, r
+			}
+		}
+
+		entry := localEntry
+		if entry == nil {
+			entry = remoteEntry
+		}
+		switch {
+		case mismatched != "" && strings.HasPrefix(entry.Path, mismatched+"/"):
+		case localEntry != nil && remoteEntry != nil && localEntry.IsDirectory != remoteEntry.IsDirectory:
+			mismatched = entry.Path
+			e.logger.Warnf("%s is a %s locally but a %s remotely; leaving it out of the index",
+				filepath.Join(folder.Local, filepath.FromSlash(entry.Path)),
+				entryKind(localEntry.IsDirectory), entryKind(remoteEntry.IsDirectory))
+		default:
+			if err := e.database.SaveFileMetadata(reindexedFile(folder.Local, localEntry, remoteEntry)); err != nil {
+				return indexed, err
+			}
+			indexed++
+			if progress != nil && indexed%utils.WalkProgressEvery == 0 {
+				progress(indexed)
+			}
+		}
+
+		if localEntry != nil {
+			if l, err = nextEntry(local); err != nil {
+				return indexed, err
+			}
+		}
+		if remoteEntry != nil {
+			if r, err = nextEntry(remote); err != nil {
+				return indexed, err
+			}
+		}
+	}
+	return indexed, nil
+}
+
+// reindexedFile builds the record of a path given what exists on each side.
+// A path on both sides that the planner would leave alone is synced;
+// anything else is pending.
+func reindexedFile(root string, local, remote *PlanEntry) *types.FileMetadata {
+	entry := local
+	if entry == nil {
+		entry = remote
+	}
+
+	metadata := &types.FileMetadata{
+		Path:         filepath.Join(root, filepath.FromSlash(entry.Path)),
+		Size:         entry.Size,
+		ModifiedTime: entry.ModifiedTime,
+		IsDirectory:  entry.IsDirectory,
+		SyncStatus:   "pending",
+	}
+	if remote != nil {
+		metadata.RemoteID = remote.RemoteID
+		if _, differ := decidePlanOperation(local, remote); local != nil && !differ {
+			metadata.SyncStatus = "synced"
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReindexRebuildsFilesAndKeepsAuth(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	localDir := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: localDir, Remote: "/", Enabled: true}}
+
+	modTime := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	write := func(root, rel, content string) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	write(localDir, "docs/same.txt", "same")
+	write(remoteDir, "docs/same.txt", "same")
+	write(localDir, "docs/edited.txt", "edited locally")
+	write(remoteDir, "docs/edited.txt", "original")
+	write(localDir, "local-only.txt", "new")
+	write(remoteDir, "remote-only.txt", "remote")
+
+	token := &types.TokenInfo{AccessToken: "access", RefreshToken: "refresh", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, database.SaveAuthToken(token))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path:       filepath.Join(localDir, "deleted-by-hand.txt"),
+		RemoteID:   "stale",
+		SyncStatus: "synced",
+	}))
+
+	var reported []int
+	indexed, err := engine.Reindex(context.Background(), "", func(n int) { reported = append(reported, n) })
+	require.NoError(t, err)
+	assert.Equal(t, 5, indexed)
+	assert.Equal(t, []int{5}, reported)
+
+	status := func(rel string) *types.FileMetadata {
+		metadata, err := database.GetFileMetadata(filepath.Join(localDir, filepath.FromSlash(rel)))
+		require.NoError(t, err)
+		return metadata
+	}
+	assert.Nil(t, status("deleted-by-hand.txt"), "stale records are dropped")
+	assert.Equal(t, "synced", status("docs").SyncStatus)
+	assert.Equal(t, "synced", status("docs/same.txt").SyncStatus, "files already uploaded are not uploaded again")
+	assert.NotEmpty(t, status("docs/same.txt").RemoteID)
+
+	edited := status("docs/edited.txt")
+	assert.Equal(t, "pending", edited.SyncStatus)
+	assert.NotEmpty(t, edited.RemoteID, "differing files are left for conflict resolution")
+	assert.Equal(t, "pending", status("local-only.txt").SyncStatus)
+	assert.Empty(t, status("local-only.txt").RemoteID)
+	assert.NotEmpty(t, status("remote-only.txt").RemoteID)
+
+	stored, err := database.GetAuthToken()
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "access", stored.AccessToken)
+	assert.Equal(t, "refresh", stored.RefreshToken)
+}
+
+func TestReindexRejectsUnknownFolder(t *testing.T) {
+	engine, _, _ := newLocalTestEngine(t)
+	engine.syncFolders = []types.FolderConfig{{Local: t.TempDir(), Remote: "/", Enabled: true}}
+
+	_, err := engine.Reindex(context.Background(), t.TempDir(), nil)
+	assert.ErrorContains(t, err, "not a sync folder")
+}
@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// remoteRoots caches the ID of the remote folder each sync folder maps to,
+// by local path
+type remoteRoots struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+// newRemoteRoots creates an empty cache
+func newRemoteRoots() *remoteRoots {
+	return &remoteRoots{ids: make(map[string]string)}
+}
+
+func (r *remoteRoots) get(local string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.ids[local]
+	return id, ok
+}
+
+func (r *remoteRoots) set(local, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids[local] = id
+}
+
+// resolveRemoteRoots resolves the remote folder of every enabled sync
+// folder, creating it if it does not exist yet. A folder that cannot be
+// resolved now is tried again by its first upload.
+func (e *Engine) resolveRemoteRoots(ctx context.Context) {
+	for _, folder := range e.syncFolders {
+		if !folder.Enabled {
+			continue
+		}
+		if _, err := e.remoteFolderRoot(ctx, folder); err != nil {
+			e.logger.Warnf("Failed to resolve remote folder %s of %s: %v", folder.Remote, folder.Local, err)
+		}
+	}
+}
+
+// remoteFolderRoot returns the ID of the remote folder a sync folder maps
+// to, resolving it on first use
+func (e *Engine) remoteFolderRoot(ctx context.Context, folder types.FolderConfig) (string, error) {
+	local := filepath.Clean(folder.Local)
+	if id, ok := e.remoteRoots.get(local); ok {
+		return id, nil
+	}
+	id, err := e.ensureRemoteFolderPath(ctx, folder.Remote, ParseSyncStrategy(folder.SyncMode).AllowsUpload())
+	if err != nil {
+		return "", err
+	}
+	e.remoteRoots.set(local, id)
+	return id, nil
+}
+
+// remoteParent returns the ID of the remote folder an upload of path goes
+// into: the remote folder of its local parent once that has been uploaded,
+// or else the one found or created at the same place below the remote
+// folder of its sync folder. Paths outside every sync folder go to the
+// root of the drive.
+func (e *Engine) remoteParent(ctx context.Context, path string) (string, error) {
+	folder := e.folderForPath(path)
+	if folder == nil {
+		return "root", nil
+	}
+	rootID, err := e.remoteFolderRoot(ctx, *folder)
+	if err != nil {
+		return "", err
+	}
+
+	local := filepath.Clean(folder.Local)
+	dir := filepath.Dir(path)
+	if dir == local {
+		return rootID, nil
+	}
+	if parent, err := e.database.GetFileMetadata(dir); err == nil && parent != nil && parent.IsDirectory && parent.RemoteID != "" {
+		return parent.RemoteID, nil
+	}
+
+	rel, err := filepath.Rel(local, dir)
+	if err != nil {
+		return "", err
+	}
+	folderID, current := rootID, local
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		if folderID, err = e.ensureRemoteSubfolder(ctx, folderID, current); err != nil {
+			return "", err
+		}
+	}
+	return folderID, nil
+}
+
+// ensureRemoteSubfolder returns the ID of the remote folder of the local
+// folder path inside parentID, creating it if need be, and records it
+func (e *Engine) ensureRemoteSubfolder(ctx context.Context, parentID, path string) (string, error) {
+	metadata, err := e.database.GetFileMetadata(path)
+	if err != nil {
+		return "", err
+	}
+	if metadata != nil && metadata.RemoteID != "" {
+		return metadata.RemoteID, nil
+	}
+	if metadata == nil {
+		metadata = &types.FileMetadata{Path: path, IsDirectory: true}
+	}
+
+	name, err := e.remoteName(path)
+	if err != nil {
+		return "", err
+	}
+	if name, err = e.sealName(name); err != nil {
+		return "", err
+	}
+	id, err := e.findRemoteChild(ctx, parentID, name)
+	if errors.Is(err, errRemoteFolderMissing) {
+		info, createErr := e.backend.CreateFolder(ctx, parentID, name)
+		if createErr != nil {
+			return "", fmt.Errorf("failed to create remote folder %s: %w", name, createErr)
+		}
+		id, err = info.ID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	metadata.RemoteID = id
+	metadata.SyncStatus = "synced"
+	if err := e.database.SaveFileMetadata(metadata); err != nil {
+		e.logger.Warnf("Failed to record remote folder of %s: %v", path, err)
+	}
+	return id, nil
+}
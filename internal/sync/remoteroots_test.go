@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadsGoUnderMappedRemoteFolder(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	ctx := context.Background()
+
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "Projects/Client", SyncMode: "bidirectional", Enabled: true}}
+
+	// The remote folder does not exist yet and is created when resolved
+	engine.resolveRemoteRoots(ctx)
+	rootID, ok := engine.remoteRoots.get(local)
+	require.True(t, ok)
+	assert.NotEqual(t, "root", rootID)
+	assert.DirExists(t, filepath.Join(remoteDir, "Projects", "Client"))
+
+	report := filepath.Join(local, "report.txt")
+	notes := filepath.Join(local, "sub", "notes.txt")
+	require.NoError(t, os.MkdirAll(filepath.Dir(notes), 0755))
+	require.NoError(t, os.WriteFile(report, []byte("report"), 0644))
+	require.NoError(t, os.WriteFile(notes, []byte("notes"), 0644))
+	engine.queueFileForSync(report, fsnotify.Create)
+	engine.queueFileForSync(notes, fsnotify.Create)
+	engine.performSync(ctx)
+
+	for _, path := range []string{report, notes} {
+		metadata, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		require.NotNil(t, metadata)
+		assert.Equal(t, "synced", metadata.SyncStatus, path)
+	}
+	content, err := os.ReadFile(filepath.Join(remoteDir, "Projects", "Client", "report.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "report", string(content))
+	content, err = os.ReadFile(filepath.Join(remoteDir, "Projects", "Client", "sub", "notes.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "notes", string(content))
+	assert.NoFileExists(t, filepath.Join(remoteDir, "report.txt"), "nothing is uploaded to the root")
+}
@@ -0,0 +1,118 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// RenameStrategy is the conflict strategy recorded when a file renamed
+// remotely had also changed locally
+const RenameStrategy = "remote-rename"
+
+// followRemoteRename renames the local copy of a remote file that was
+// renamed or moved remotely, so it is not downloaded again under its new
+// name while the old copy is left behind. The file is recognised by its
+// remote ID, recorded under its old name. If the local copy changed since
+// it was synced, both versions are kept: the local one stays where it is as
+// a new file and a conflict is recorded, and the remote one is downloaded
+// to newPath. It reports whether the file was renamed with the content the
+// remote has, leaving nothing to download.
+func (e *Engine) followRemoteRename(newPath string, entry *PlanEntry) (bool, error) {
+	if !e.config.Sync.FollowRemoteRenames || entry.IsDirectory || entry.RemoteID == "" {
+		return false, nil
+	}
+
+	record, err := e.database.GetFileByRemoteID(entry.RemoteID)
+	if err != nil || record == nil || record.IsDirectory || filepath.Clean(record.Path) == filepath.Clean(newPath) {
+		return false, err
+	}
+	if _, err := os.Lstat(newPath); err == nil {
+		// Something already has the new name; the download sorts it out
+		return false, nil
+	}
+	localInfo, err := os.Stat(record.Path)
+	if err != nil {
+		return false, nil
+	}
+
+	lock := e.pathLocks.acquire(record.Path)
+	defer e.pathLocks.release(record.Path)
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	// A queued local change clears the recorded hash
+	localHash, err := e.calculateFileHash(record.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash %s: %w", record.Path, err)
+	}
+	if record.Hash == "" || localHash != record.Hash {
+		return false, e.keepDivergedCopy(record, localInfo, localHash, newPath, entry)
+	}
+
+	oldPath := record.Path
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create %s: %w", filepath.Dir(newPath), err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return false, fmt.Errorf("failed to rename %s to %s: %w", oldPath, newPath, err)
+	}
+	if _, err := e.database.MoveFilesUnder(oldPath, newPath); err != nil {
+		return false, err
+	}
+	e.logger.Infof("%s was renamed remotely, renamed the local copy to %s", oldPath, newPath)
+	e.database.LogSyncOperation(record.ID, "rename", "success", "")
+
+	if !e.remoteContentMatches(entry, record) {
+		// It changed remotely as well, so the new content is downloaded
+		// over the renamed copy
+		record.Path = newPath
+		record.Hash = ""
+		return false, e.database.SaveFileMetadata(record)
+	}
+	return true, nil
+}
+
+// remoteContentMatches reports whether a remote file still has the content
+// recorded for it. The server's hash decides when it reports a comparable
+// one; otherwise the size does.
+func (e *Engine) remoteContentMatches(entry *PlanEntry, record *types.FileMetadata) bool {
+	if entry.Size != record.Size {
+		return false
+	}
+	remoteInfo := &api.FileInfo{Hash: entry.Hash, HashAlgorithm: entry.HashAlgorithm}
+	return e.verifyRemoteHash(remoteInfo, record.Hash) == nil
+}
+
+// keepDivergedCopy handles a remote rename of a file that changed locally.
+// The local copy keeps its name and is no longer tied to the remote file,
+// so a two-way folder uploads it as a new file, and the conflict is logged
+// with the local copy as the one kept aside.
+func (e *Engine) keepDivergedCopy(record *types.FileMetadata, localInfo os.FileInfo, localHash, newPath string, entry *PlanEntry) error {
+	e.logger.Warnf("%s was renamed remotely to %s but changed locally, keeping both", record.Path, newPath)
+
+	e.recordConflict(&types.ConflictInfo{
+		Path:           newPath,
+		Strategy:       RenameStrategy,
+		Winner:         types.ConflictWinnerRemote,
+		AutoResolved:   true,
+		BaseHash:       record.Hash,
+		LocalHash:      localHash,
+		RemoteHash:     entry.Hash,
+		CopyPath:       record.Path,
+		LocalSize:      localInfo.Size(),
+		RemoteSize:     entry.Size,
+		LocalModified:  localInfo.ModTime(),
+		RemoteModified: entry.ModifiedTime,
+	})
+
+	record.RemoteID = ""
+	record.Hash = ""
+	record.Size = localInfo.Size()
+	record.ModifiedTime = localInfo.ModTime()
+	record.SyncStatus = "pending"
+	return e.database.SaveFileMetadata(record)
+}
@@ -0,0 +1,144 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renamedFile is a remote file whose name a test can change, counting its
+// downloads
+type renamedFile struct {
+	mu        sync.Mutex
+	name      string
+	content   string
+	downloads atomic.Int32
+}
+
+func (f *renamedFile) rename(name string) {
+	f.mu.Lock()
+	f.name = name
+	f.mu.Unlock()
+}
+
+func (f *renamedFile) info() api.FileInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sum := sha256.Sum256([]byte(f.content))
+	return api.FileInfo{ID: "doc1", Name: f.name, Size: int64(len(f.content)), Hash: hex.EncodeToString(sum[:]), HashAlgorithm: "sha256"}
+}
+
+func (f *renamedFile) handler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/files"):
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": []api.FileInfo{f.info()}})
+	case r.URL.Path == "/files/doc1/download":
+		f.downloads.Add(1)
+		w.Write([]byte(f.content))
+	default:
+		json.NewEncoder(w).Encode(map[string]api.FileInfo{"data": f.info()})
+	}
+}
+
+// newRenameTest downloads a remote folder holding report.txt once
+func newRenameTest(t *testing.T, follow bool) (*Engine, *renamedFile, string) {
+	t.Helper()
+
+	remote := &renamedFile{name: "report.txt", content: "quarterly numbers"}
+	config := &types.Config{Sync: types.SyncConfig{FollowRemoteRenames: follow}}
+	engine, _ := newTestEngine(t, config, remote.handler)
+
+	localDir := t.TempDir()
+	result, err := engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Downloaded)
+	return engine, remote, localDir
+}
+
+func TestRemoteRenameRenamesLocalCopy(t *testing.T) {
+	engine, remote, localDir := newRenameTest(t, true)
+	remote.rename("final.txt")
+
+	result, err := engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Renamed)
+	assert.Zero(t, result.Downloaded)
+	assert.Equal(t, int32(1), remote.downloads.Load(), "the renamed file is not downloaded again")
+
+	assert.NoFileExists(t, filepath.Join(localDir, "report.txt"))
+	data, err := os.ReadFile(filepath.Join(localDir, "final.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "quarterly numbers", string(data))
+
+	old, err := engine.database.GetFileMetadata(filepath.Join(localDir, "report.txt"))
+	require.NoError(t, err)
+	assert.Nil(t, old)
+	moved, err := engine.database.GetFileMetadata(filepath.Join(localDir, "final.txt"))
+	require.NoError(t, err)
+	require.NotNil(t, moved)
+	assert.Equal(t, "doc1", moved.RemoteID)
+	assert.NotEmpty(t, moved.Hash)
+
+	// Nothing is left to do on the next pass
+	result, err = engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Zero(t, result.Renamed)
+	assert.Equal(t, 1, result.Skipped)
+}
+
+func TestRemoteRenameOfLocallyChangedFileKeepsBoth(t *testing.T) {
+	engine, remote, localDir := newRenameTest(t, true)
+	oldPath := filepath.Join(localDir, "report.txt")
+	require.NoError(t, os.WriteFile(oldPath, []byte("my local edits"), 0644))
+	remote.rename("final.txt")
+
+	result, err := engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Zero(t, result.Renamed)
+	assert.Equal(t, 1, result.Downloaded)
+
+	data, err := os.ReadFile(oldPath)
+	require.NoError(t, err)
+	assert.Equal(t, "my local edits", string(data), "the local changes are kept")
+	data, err = os.ReadFile(filepath.Join(localDir, "final.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "quarterly numbers", string(data))
+
+	kept, err := engine.database.GetFileMetadata(oldPath)
+	require.NoError(t, err)
+	require.NotNil(t, kept)
+	assert.Empty(t, kept.RemoteID, "the local copy is uploaded as a new file")
+	assert.Equal(t, "pending", kept.SyncStatus)
+
+	conflicts, err := engine.database.GetConflicts(false, 0)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, RenameStrategy, conflicts[0].Strategy)
+	assert.Equal(t, filepath.Join(localDir, "final.txt"), conflicts[0].Path)
+	assert.Equal(t, oldPath, conflicts[0].CopyPath)
+}
+
+func TestRemoteRenameFollowThroughCanBeTurnedOff(t *testing.T) {
+	engine, remote, localDir := newRenameTest(t, false)
+	remote.rename("final.txt")
+
+	result, err := engine.DownloadFolder(context.Background(), "root", localDir)
+	require.NoError(t, err)
+	assert.Zero(t, result.Renamed)
+	assert.Equal(t, 1, result.Downloaded)
+	assert.FileExists(t, filepath.Join(localDir, "report.txt"))
+	assert.FileExists(t, filepath.Join(localDir, "final.txt"))
+}
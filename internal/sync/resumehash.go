@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/bdstest/zohosync/internal/utils"
+)
+
+// hashResumable hashes a large file in blocks of its folder's chunk size,
+// checkpointing its progress in the database. A hash cut short by stopping
+// the engine, or by a crash, resumes from the last checkpoint the next time
+// the file is hashed instead of reading it all again.
+func (e *Engine) hashResumable(path string) (string, error) {
+	defer e.scan.hold()()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-e.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	blockSize := e.chunkSizeFor(path)
+	if blockSize <= 0 {
+		blockSize = utils.DefaultHashBlockSize
+	}
+	hasher := &utils.ResumableHash{
+		Algorithm:       e.hashAlgorithm(),
+		BlockSize:       blockSize,
+		CheckpointEvery: e.hashCheckpointEvery,
+		Store:           e.database,
+		Reader:          e.scan.throttle,
+	}
+	digest, err := hasher.HashFile(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	e.logger.Debugf("Hashed %s in %d blocks", path, len(digest.Blocks))
+	return digest.Hash, nil
+}
@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ResyncDirection selects which side wins when a file is force-resynced
+type ResyncDirection string
+
+const (
+	// ResyncAuto resolves the file as a normal sync would
+	ResyncAuto ResyncDirection = ""
+	// ResyncUp re-uploads the local file
+	ResyncUp ResyncDirection = "up"
+	// ResyncDown re-downloads the remote file
+	ResyncDown ResyncDirection = "down"
+)
+
+// ParseResyncDirection validates a direction given on the command line
+func ParseResyncDirection(value string) (ResyncDirection, error) {
+	switch direction := ResyncDirection(value); direction {
+	case ResyncAuto, ResyncUp, ResyncDown:
+		return direction, nil
+	default:
+		return "", fmt.Errorf("invalid direction %q: use up or down", value)
+	}
+}
+
+// resyncSet remembers the forced direction of files marked for resync until
+// they are next synced
+type resyncSet struct {
+	mu         sync.Mutex
+	directions map[string]ResyncDirection
+}
+
+// newResyncSet creates an empty set
+func newResyncSet() *resyncSet {
+	return &resyncSet{directions: make(map[string]ResyncDirection)}
+}
+
+// take returns and forgets the forced direction of path
+func (s *resyncSet) take(path string) ResyncDirection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := filepath.Clean(path)
+	direction := s.directions[key]
+	delete(s.directions, key)
+	return direction
+}
+
+// set forces the direction of path's next sync
+func (s *resyncSet) set(path string, direction ResyncDirection) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.directions[filepath.Clean(path)] = direction
+}
+
+// MarkResync marks path, or every file under it if it is a directory, as
+// pending with its cached hash cleared, so the next sync transfers it even
+// if it looks unchanged. A direction other than ResyncAuto forces the
+// transfer that way. It returns the marked paths.
+func (e *Engine) MarkResync(path string, direction ResyncDirection) ([]string, error) {
+	path = filepath.Clean(path)
+	if e.folderForPath(path) == nil {
+		return nil, fmt.Errorf("%s is not inside a sync folder", path)
+	}
+
+	strategy := e.strategyForPath(path)
+	if direction == ResyncUp && !strategy.AllowsUpload() || direction == ResyncDown && !strategy.AllowsDownload() {
+		return nil, fmt.Errorf("cannot resync %s %s: folder is %s", path, direction, strategy)
+	}
+
+	if direction != ResyncDown {
+		if err := e.trackLocalFiles(path); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := e.database.GetFilesUnder(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var marked []string
+	e.resyncs.mu.Lock()
+	defer e.resyncs.mu.Unlock()
+	for i := range files {
+		file := &files[i]
+		if file.IsDirectory {
+			continue
+		}
+
+		file.Hash = ""
+		file.SyncStatus = "pending"
+		if err := e.database.SaveFileMetadata(file); err != nil {
+			return marked, err
+		}
+		e.resyncs.directions[filepath.Clean(file.Path)] = direction
+		marked = append(marked, file.Path)
+	}
+
+	if len(marked) == 0 {
+		return nil, fmt.Errorf("no tracked files under %s", path)
+	}
+	e.logger.Infof("Marked %d file(s) under %s for resync", len(marked), path)
+	return marked, nil
+}
+
+// trackLocalFiles starts tracking local files under path that are not yet
+// in the database so a resync includes them
+func (e *Engine) trackLocalFiles(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.shouldIgnoreFile(p) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		existing, err := e.database.GetFileMetadata(p)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			e.queueFileForSync(p, 0)
+		}
+		return nil
+	})
+}
+
+// SyncPaths runs a sync cycle limited to the given pending paths
+func (e *Engine) SyncPaths(ctx context.Context, paths []string) {
+	selected := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		selected[filepath.Clean(path)] = true
+	}
+
+	e.performSyncWhere(ctx, func(path string) bool {
+		return selected[filepath.Clean(path)]
+	}, PriorityOnDemand)
+}
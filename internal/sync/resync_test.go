@@ -0,0 +1,72 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResyncForcesDownloadOfIdenticalFile(t *testing.T) {
+	var downloads, uploads int32
+	dir := t.TempDir()
+	config := &types.Config{
+		Sync:    types.SyncConfig{ConflictResolution: "local"},
+		Folders: []types.FolderConfig{{Local: dir, Remote: "/Docs", SyncMode: "bidirectional", Enabled: true}},
+	}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/r1":
+			w.Write([]byte(`{"data":{"id":"r1","name":"doc.txt","size":5}}`))
+		case "/files/r1/download":
+			atomic.AddInt32(&downloads, 1)
+			w.Write([]byte("hello"))
+		default:
+			atomic.AddInt32(&uploads, 1)
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(dir, "doc.txt")
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+	hash, err := engine.calculateFileHash(path)
+	require.NoError(t, err)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: path, RemoteID: "r1", Size: 5, Hash: hash, SyncStatus: "synced",
+	}))
+
+	paths, err := engine.MarkResync(dir, ResyncDown)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path}, paths)
+
+	marked, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", marked.SyncStatus)
+	assert.Empty(t, marked.Hash, "resync clears the cached hash")
+
+	// Conflict resolution alone would upload; the forced direction downloads
+	engine.SyncPaths(context.Background(), paths)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&downloads))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&uploads))
+
+	synced, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", synced.SyncStatus)
+	assert.Equal(t, hash, synced.Hash)
+}
+
+func TestResyncRejectsPathsOutsideSyncFolders(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, nil)
+
+	_, err := engine.MarkResync(t.TempDir(), ResyncAuto)
+	assert.Error(t, err)
+
+	_, err = ParseResyncDirection("sideways")
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"context"
+	"time"
+)
+
+// operationsPruneInterval is how often the sync history is pruned
+const operationsPruneInterval = 24 * time.Hour
+
+// operationsVacuumThreshold is how many history entries a prune must delete
+// before the database file is compacted
+const operationsVacuumThreshold = 10000
+
+// pruneOperationsPeriodically prunes the sync history and the local trash
+// now and then once a day until the engine stops
+func (e *Engine) pruneOperationsPeriodically(ctx context.Context) {
+	e.pruneOperations()
+	e.pruneLocalTrash()
+
+	ticker := e.clock.NewTicker(operationsPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopChan:
+			return
+		case <-ticker.C():
+			e.pruneOperations()
+			e.pruneLocalTrash()
+		}
+	}
+}
+
+// pruneOperations deletes the sync history entries past their retention
+// and compacts the database after a large prune
+func (e *Engine) pruneOperations() {
+	day := 24 * time.Hour
+	deleted := 0
+
+	if days := e.config.App.OperationsRetention; days > 0 {
+		n, err := e.database.PruneOperations(time.Duration(days) * day)
+		if err != nil {
+			e.logger.Errorf("Failed to prune sync history: %v", err)
+		}
+		deleted += n
+	}
+	if days := e.config.App.FailedOperationsRetention; days > 0 {
+		n, err := e.database.PruneFailedOperations(time.Duration(days) * day)
+		if err != nil {
+			e.logger.Errorf("Failed to prune failed sync history: %v", err)
+		}
+		deleted += n
+	}
+	if deleted == 0 {
+		return
+	}
+
+	e.logger.Infof("Pruned %d sync history entries", deleted)
+	if deleted >= operationsVacuumThreshold {
+		if err := e.database.Vacuum(); err != nil {
+			e.logger.Warnf("Failed to compact database: %v", err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultScanConcurrency is how many files are hashed at once while a
+// startup scan runs, unless configured otherwise
+const DefaultScanConcurrency = 2
+
+// scanThrottle keeps the startup scans, the initial syncs and the hash
+// migration, from monopolising CPU and disk. While one runs, files are
+// hashed a few at a time and read no faster than the configured throughput;
+// otherwise hashing is not held back.
+type scanThrottle struct {
+	slots   chan struct{}
+	limiter *api.RateLimiter
+	active  atomic.Int32
+}
+
+// newScanThrottle creates the throttle configured by sync.scan_concurrency
+// and sync.scan_throughput, in KiB/s
+func newScanThrottle(cfg types.SyncConfig) *scanThrottle {
+	concurrency := cfg.ScanConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultScanConcurrency
+	}
+	return &scanThrottle{
+		slots:   make(chan struct{}, concurrency),
+		limiter: api.NewRateLimiter(int64(cfg.ScanThroughput) * 1024),
+	}
+}
+
+// begin marks a scan as running until the returned function is called
+func (t *scanThrottle) begin() func() {
+	t.active.Add(1)
+	return func() { t.active.Add(-1) }
+}
+
+// scanning reports whether a scan is running
+func (t *scanThrottle) scanning() bool {
+	return t.active.Load() > 0
+}
+
+// hashFile returns the hex-encoded digest of a file, throttled while a scan
+// runs
+func (t *scanThrottle) hashFile(path, algorithm string) (string, error) {
+	if !t.scanning() {
+		return utils.HashFile(path, algorithm)
+	}
+
+	defer t.hold()()
+
+	h, err := utils.NewHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, t.throttle(file)); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// hold takes one of the hashing slots while a scan runs and returns the
+// function that gives it back
+func (t *scanThrottle) hold() func() {
+	if !t.scanning() {
+		return func() {}
+	}
+	t.slots <- struct{}{}
+	return func() { <-t.slots }
+}
+
+// throttle returns a reader of r that reads no faster than the configured
+// throughput while a scan runs
+func (t *scanThrottle) throttle(r io.Reader) io.Reader {
+	if !t.scanning() {
+		return r
+	}
+	return t.limiter.Reader(context.Background(), r)
+}
@@ -0,0 +1,118 @@
+package sync
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeScanFiles writes count files of size bytes and returns their paths
+func writeScanFiles(t *testing.T, count, size int) []string {
+	t.Helper()
+
+	dir := t.TempDir()
+	paths := make([]string, count)
+	for i := range paths {
+		paths[i] = filepath.Join(dir, fmt.Sprintf("file%d.bin", i))
+		require.NoError(t, os.WriteFile(paths[i], []byte(strings.Repeat(fmt.Sprint(i), size)), 0644))
+	}
+	return paths
+}
+
+func TestScanThrottleCapsConcurrencyAndThroughput(t *testing.T) {
+	config := &types.Config{Sync: types.SyncConfig{ScanConcurrency: 2, ScanThroughput: 64}}
+	engine, _ := newTestEngine(t, config, http.NotFound)
+	paths := writeScanFiles(t, 8, 16*1024)
+
+	done := engine.scan.begin()
+	defer done()
+
+	stop := make(chan struct{})
+	peak := 0
+	var sampler sync.WaitGroup
+	sampler.Add(1)
+	go func() {
+		defer sampler.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if n := len(engine.scan.slots); n > peak {
+				peak = n
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	started := time.Now()
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			hash, err := engine.calculateFileHash(path)
+			assert.NoError(t, err)
+			content, _ := os.ReadFile(path)
+			assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content)), hash)
+		}(path)
+	}
+	wg.Wait()
+	elapsed := time.Since(started)
+	close(stop)
+	sampler.Wait()
+
+	assert.LessOrEqual(t, peak, 2, "no more files are hashed at once than allowed")
+	assert.Positive(t, peak)
+	// 128 KiB at 64 KiB/s, the first second's worth allowed at once
+	assert.GreaterOrEqual(t, elapsed, 900*time.Millisecond)
+}
+
+func TestThrottledHashMigrationCoversAllFiles(t *testing.T) {
+	config := &types.Config{Sync: types.SyncConfig{ScanThroughput: 32}}
+	engine, database := newTestEngine(t, config, http.NotFound)
+	paths := writeScanFiles(t, 6, 8*1024)
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+			Path: path, Size: info.Size(), ModifiedTime: info.ModTime(),
+			Hash: fmt.Sprintf("%x", md5.Sum(content)), SyncStatus: "synced",
+		}))
+	}
+
+	started := time.Now()
+	engine.migrateHashes()
+	assert.GreaterOrEqual(t, time.Since(started), 400*time.Millisecond, "48 KiB is read at 32 KiB/s")
+	assert.False(t, engine.scan.scanning(), "the scan is over")
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		file, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content)), file.Hash, path)
+	}
+
+	// Outside a scan nothing is held back
+	started = time.Now()
+	for _, path := range paths {
+		_, err := engine.calculateFileHash(path)
+		require.NoError(t, err)
+	}
+	assert.Less(t, time.Since(started), 200*time.Millisecond)
+}
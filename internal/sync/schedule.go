@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultSyncInterval is used when sync.interval is not set
+const DefaultSyncInterval = 300 * time.Second
+
+// MinFolderInterval is the shortest per-folder sync interval allowed, so a
+// misconfigured folder cannot hammer the API
+const MinFolderInterval = 10 * time.Second
+
+// SetClock sets the clock that drives sync schedules, stability checks and
+// retry backoff, so that tests can control the passage of time
+func (e *Engine) SetClock(c clock.Clock) {
+	e.clock = c
+}
+
+// globalInterval returns the configured sync.interval
+func (e *Engine) globalInterval() time.Duration {
+	if e.config.Sync.Interval <= 0 {
+		return DefaultSyncInterval
+	}
+	return time.Duration(e.config.Sync.Interval) * time.Second
+}
+
+// folderInterval returns a folder's own sync interval, raised to
+// MinFolderInterval if it is set lower, or the global interval if unset
+func (e *Engine) folderInterval(folder types.FolderConfig) time.Duration {
+	if folder.Interval <= 0 {
+		return e.globalInterval()
+	}
+
+	interval := time.Duration(folder.Interval) * time.Second
+	if interval < MinFolderInterval {
+		e.logger.Warnf("Sync interval of %s is below the %v minimum, using the minimum", folder.Local, MinFolderInterval)
+		return MinFolderInterval
+	}
+	return interval
+}
+
+// followsGlobalInterval reports whether a path is synced on the global
+// schedule, i.e. it is not in a folder with its own interval
+func (e *Engine) followsGlobalInterval(path string) bool {
+	folder := e.folderForPath(path)
+	return folder == nil || folder.Interval <= 0
+}
+
+// periodicFolderSync syncs one folder on its own interval
+func (e *Engine) periodicFolderSync(ctx context.Context, folder types.FolderConfig) {
+	local := filepath.Clean(folder.Local)
+	inFolder := func(path string) bool {
+		if path != local && !strings.HasPrefix(path, local+string(filepath.Separator)) {
+			return false
+		}
+		// Nested folders with their own settings are scheduled separately
+		return e.folderForPath(path).Local == folder.Local
+	}
+
+	ticker := e.clock.NewTicker(e.folderInterval(folder))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.stopChan:
+			return
+		case <-ticker.C():
+			e.performSyncWhere(ctx, inFolder, PriorityBackground)
+		}
+	}
+}
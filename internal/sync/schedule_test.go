@@ -0,0 +1,110 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolderIntervalsScheduleIndependently(t *testing.T) {
+	docs, media := t.TempDir(), t.TempDir()
+	config := &types.Config{
+		Sync: types.SyncConfig{Interval: 300},
+		Folders: []types.FolderConfig{
+			{Local: docs, Remote: "/Docs", Enabled: true, Interval: 10},
+			{Local: media, Remote: "/Media", Enabled: true, Interval: 60},
+		},
+	}
+
+	var mu stdsync.Mutex
+	uploads := map[string]int{}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/upload/initiate":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			uploads[body["filename"].(string)]++
+			mu.Unlock()
+			fmt.Fprintf(w, `{"data":{"upload_id":"%s"}}`, body["filename"])
+		case strings.HasPrefix(r.URL.Path, "/upload/"):
+			w.Write([]byte(`{"data":{}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	engine.remoteRoots.set(docs, "docs")
+	engine.remoteRoots.set(media, "media")
+	fake := clock.NewFake(time.Now())
+	engine.SetClock(fake)
+
+	report := filepath.Join(docs, "report.txt")
+	movie := filepath.Join(media, "movie.mkv")
+	markPending := func() {
+		for _, path := range []string{report, movie} {
+			require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "pending"}))
+		}
+	}
+	require.NoError(t, os.WriteFile(report, []byte("report"), 0644))
+	require.NoError(t, os.WriteFile(movie, []byte("movie"), 0644))
+	markPending()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go engine.periodicSync(ctx)
+
+	// One ticker per folder plus the global one
+	require.Eventually(t, func() bool { return fake.Waiters() == 3 }, time.Second, time.Millisecond)
+
+	synced := func(path string) bool {
+		metadata, err := database.GetFileMetadata(path)
+		return err == nil && metadata.SyncStatus == "synced"
+	}
+	expectUploads := func(reports, movies int) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return uploads["report.txt"] == reports && uploads["movie.mkv"] == movies
+		}, time.Second, time.Millisecond, "want %d report and %d movie uploads, got %v", reports, movies, uploads)
+
+		// Let the cycle record the result before the next one
+		require.Eventually(t, func() bool {
+			return synced(report) && (movies == 0 || synced(movie))
+		}, time.Second, time.Millisecond)
+	}
+
+	for i := 1; i <= 6; i++ {
+		fake.Advance(10 * time.Second)
+		movies := 0
+		if i == 6 {
+			movies = 1
+		}
+		expectUploads(i, movies)
+		markPending()
+	}
+}
+
+func TestFolderIntervalDefaultsAndMinimum(t *testing.T) {
+	config := &types.Config{Sync: types.SyncConfig{Interval: 120}}
+	engine, _ := newTestEngine(t, config, http.NotFound)
+
+	assert.Equal(t, 120*time.Second, engine.folderInterval(types.FolderConfig{}))
+	assert.Equal(t, MinFolderInterval, engine.folderInterval(types.FolderConfig{Interval: 1}))
+	assert.Equal(t, time.Hour, engine.folderInterval(types.FolderConfig{Interval: 3600}))
+
+	engine.config.Sync.Interval = 0
+	assert.Equal(t, DefaultSyncInterval, engine.globalInterval())
+}
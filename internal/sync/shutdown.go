@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultShutdownGrace is how long Shutdown waits for in-flight transfers
+// when sync.shutdown_grace is not set
+const DefaultShutdownGrace = 30 * time.Second
+
+// shutdownUnwindTimeout bounds the wait for cancelled transfers to record
+// their state after the grace period
+const shutdownUnwindTimeout = 5 * time.Second
+
+// ShutdownResult lists what happened to the transfers that were in flight
+// when shutdown began
+type ShutdownResult struct {
+	// Finished transfers completed, successfully or not, within the grace period
+	Finished []string
+	// Deferred transfers were cancelled and left pending for the next start.
+	// Uploads keep their upload session, so they resume rather than restart.
+	Deferred []string
+}
+
+// ShutdownGrace returns the configured sync.shutdown_grace
+func (e *Engine) ShutdownGrace() time.Duration {
+	if e.config.Sync.ShutdownGrace <= 0 {
+		return DefaultShutdownGrace
+	}
+	return time.Duration(e.config.Sync.ShutdownGrace) * time.Second
+}
+
+// Shutdown stops the engine gracefully: it stops accepting new work, waits
+// up to grace for in-flight transfers to finish and then cancels the rest,
+// which are left pending and retried after the next start
+func (e *Engine) Shutdown(grace time.Duration) *ShutdownResult {
+	e.Stop()
+
+	inFlight := e.ActiveTransfers()
+	if len(inFlight) > 0 {
+		e.logger.Infof("Waiting up to %v for %d transfer(s) to finish", grace, len(inFlight))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	remaining := e.transfers.waitIdle(ctx)
+	cancel()
+
+	deferred := make(map[string]bool, len(remaining))
+	for _, path := range remaining {
+		deferred[path] = true
+		e.CancelTransfer(path)
+	}
+	if len(remaining) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownUnwindTimeout)
+		e.transfers.waitIdle(ctx)
+		cancel()
+	}
+
+	result := &ShutdownResult{Deferred: remaining}
+	for _, path := range inFlight {
+		if !deferred[path] {
+			result.Finished = append(result.Finished, path)
+		}
+	}
+
+	e.logger.Infof("Shutdown complete: %d transfer(s) finished, %d deferred", len(result.Finished), len(result.Deferred))
+	return result
+}
+
+// isStopping reports whether Stop has been called, after which no new
+// transfers are started
+func (e *Engine) isStopping() bool {
+	select {
+	case <-e.stopChan:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowDownloadServer serves a file whose body stalls after the first chunk
+// until release is closed or the request is cancelled
+func slowDownloadServer(release <-chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/download"):
+			w.Write([]byte("first half, "))
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+				w.Write([]byte("second half"))
+			case <-r.Context().Done():
+			}
+		case strings.HasPrefix(r.URL.Path, "/files/"):
+			w.Write([]byte(`{"data":{"id":"remote-big","name":"big.bin"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// startSlowDownload begins syncing a remote-only file and waits until its
+// transfer is in flight
+func startSlowDownload(t *testing.T, engine *Engine, path string) <-chan error {
+	metadata := &types.FileMetadata{Path: path, RemoteID: "remote-big", SyncStatus: "pending"}
+	require.NoError(t, engine.database.SaveFileMetadata(metadata))
+
+	done := make(chan error, 1)
+	go func() { done <- engine.syncFile(context.Background(), metadata) }()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(downloadTempPath(path))
+		return err == nil
+	}, 2*time.Second, time.Millisecond, "download never started")
+	return done
+}
+
+func TestShutdownWaitsForInFlightTransfer(t *testing.T) {
+	release := make(chan struct{})
+	engine, database := newTestEngine(t, nil, slowDownloadServer(release))
+	require.NoError(t, engine.Start(context.Background()))
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	done := startSlowDownload(t, engine, path)
+
+	time.AfterFunc(50*time.Millisecond, func() { close(release) })
+	result := engine.Shutdown(5 * time.Second)
+
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{path}, result.Finished)
+	assert.Empty(t, result.Deferred)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first half, second half", string(content))
+
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", saved.SyncStatus)
+}
+
+func TestShutdownDefersTransferAfterGracePeriod(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	engine, database := newTestEngine(t, nil, slowDownloadServer(release))
+	require.NoError(t, engine.Start(context.Background()))
+
+	path := filepath.Join(t.TempDir(), "big.bin")
+	done := startSlowDownload(t, engine, path)
+
+	result := engine.Shutdown(50 * time.Millisecond)
+	<-done
+
+	assert.Empty(t, result.Finished)
+	assert.Equal(t, []string{path}, result.Deferred)
+
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", saved.SyncStatus, "deferred files are retried on the next start")
+
+	_, err = os.Stat(downloadTempPath(path))
+	assert.True(t, os.IsNotExist(err), "partial download should be cleaned up")
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	// No new transfers start once shutdown has begun
+	engine.performSync(context.Background())
+	saved, err = database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", saved.SyncStatus)
+}
@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	cutoff, err := ParseSince("2h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-2*time.Hour), cutoff)
+
+	cutoff, err = ParseSince("3d", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -3), cutoff)
+
+	cutoff, err = ParseSince("2024-05-01T08:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), cutoff)
+
+	cutoff, err = ParseSince("2024-05-09", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC), cutoff)
+
+	for _, invalid := range []string{"", "yesterday", "xd", "-2h", "2024-06-01"} {
+		_, err := ParseSince(invalid, now)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPlanSinceOnlyIncludesRecentChanges(t *testing.T) {
+	root := t.TempDir()
+	config := &types.Config{Folders: []types.FolderConfig{{Local: root, Enabled: true}}}
+	engine, database := newTestEngine(t, config, http.NotFound)
+
+	now := time.Now()
+	write := func(rel string, age time.Duration) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(rel), 0644))
+		modified := now.Add(-age)
+		require.NoError(t, os.Chtimes(path, modified, modified))
+		return path
+	}
+
+	recentNew := write("notes/today.txt", 30*time.Minute)
+	recentEdited := write("report.doc", time.Hour)
+	write("archive/old.txt", 48*time.Hour)
+	write("last-week.txt", 7*24*time.Hour)
+	write(".hidden-recent", time.Minute)
+	unchanged := write("synced-recent.txt", 10*time.Minute)
+
+	// Tracked files: one edited since its last sync, one untouched
+	info, err := os.Stat(unchanged)
+	require.NoError(t, err)
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: unchanged, RemoteID: "r1", Size: info.Size(), ModifiedTime: info.ModTime(), SyncStatus: "synced",
+	}))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path: recentEdited, RemoteID: "r2", Size: 1, ModifiedTime: now.Add(-3 * time.Hour), SyncStatus: "synced",
+	}))
+
+	operations, err := engine.PlanSince(now.Add(-2 * time.Hour))
+	require.NoError(t, err)
+
+	planned := map[string]PlanOperationType{}
+	var paths []string
+	for _, op := range operations {
+		planned[op.Path] = op.Type
+		paths = append(paths, op.Path)
+	}
+	sort.Strings(paths)
+
+	assert.Equal(t, []string{recentNew, recentEdited}, paths)
+	assert.Equal(t, PlanUpload, planned[recentNew])
+	assert.Equal(t, PlanResolve, planned[recentEdited])
+
+	// Without a cutoff the older files are included as well
+	all, err := engine.PlanSince(time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, all, 4)
+}
+
+func TestSyncOperationsSyncsOnlyThePlannedFiles(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	engine.config.Sync.ConflictResolution = "newer"
+	ctx := context.Background()
+
+	local := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: local, Remote: "/", SyncMode: "bidirectional", Enabled: true}}
+	src := filepath.Join(local, "project")
+	require.NoError(t, os.MkdirAll(src, 0755))
+	report := filepath.Join(src, "report.txt")
+	require.NoError(t, os.WriteFile(report, []byte("first draft"), 0644))
+	_, err := engine.UploadFolder(ctx, src, "root")
+	require.NoError(t, err)
+	synced, err := database.GetFileMetadata(report)
+	require.NoError(t, err)
+	require.NotNil(t, synced)
+
+	// An older change waiting for the next sync cycle, which is not planned
+	other := filepath.Join(src, "other.txt")
+	writeFileAt(t, other, "other", time.Now().Add(-48*time.Hour))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: other, SyncStatus: "pending"}))
+
+	uploaded := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(remoteDir, "project", "report.txt"), uploaded, uploaded))
+	require.NoError(t, os.WriteFile(report, []byte("second draft, longer"), 0644))
+	operations, err := engine.PlanSince(time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Len(t, operations, 1)
+	assert.Equal(t, PlanResolve, operations[0].Type)
+
+	result := engine.SyncOperations(ctx, operations)
+	assert.Zero(t, result.Failed)
+
+	data, err := os.ReadFile(filepath.Join(remoteDir, "project", "report.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "second draft, longer", string(data), "the remote file is replaced in place")
+	entries, err := os.ReadDir(filepath.Join(remoteDir, "project"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "nothing else is uploaded")
+
+	record, err := database.GetFileMetadata(report)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, synced.RemoteID, record.RemoteID)
+	assert.Equal(t, "synced", record.SyncStatus)
+
+	record, err = database.GetFileMetadata(other)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "pending", record.SyncStatus)
+}
@@ -0,0 +1,58 @@
+package sync
+
+import (
+	"context"
+	"os"
+)
+
+// stabilityTimeoutFactor bounds how long a file is watched before giving up:
+// a file still changing after this many stability windows is deferred
+const stabilityTimeoutFactor = 5
+
+// waitUntilStable waits until a file's size and modification time have not
+// changed for the engine's stability window. It returns false if the file
+// kept changing, so that it can be deferred to a later cycle instead of
+// uploading a partial copy. Files last modified longer than the window ago
+// are stable without polling.
+func (e *Engine) waitUntilStable(ctx context.Context, path string) (bool, error) {
+	if e.stableFor <= 0 {
+		return true, nil
+	}
+
+	last, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if e.clock.Now().Sub(last.ModTime()) >= e.stableFor {
+		return true, nil
+	}
+
+	poll := e.clock.NewTicker(e.stableFor / 4)
+	defer poll.Stop()
+
+	stableSince := e.clock.Now()
+	deadline := stableSince.Add(stabilityTimeoutFactor * e.stableFor)
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-poll.C():
+		}
+
+		current, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+
+		now := e.clock.Now()
+		if current.Size() != last.Size() || !current.ModTime().Equal(last.ModTime()) {
+			last, stableSince = current, now
+		} else if now.Sub(stableSince) >= e.stableFor {
+			return true, nil
+		}
+
+		if now.After(deadline) {
+			return false, nil
+		}
+	}
+}
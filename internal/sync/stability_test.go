@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// growFile appends a chunk to path every interval, count times, and sends
+// the time the last append started
+func growFile(t *testing.T, path string, count int, interval time.Duration) <-chan time.Time {
+	finished := make(chan time.Time, 1)
+	go func() {
+		var lastWrite time.Time
+		for i := 0; i < count; i++ {
+			time.Sleep(interval)
+			lastWrite = time.Now()
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+			if err != nil {
+				t.Error(err)
+				break
+			}
+			f.Write([]byte("chunk\n"))
+			f.Close()
+		}
+		finished <- lastWrite
+	}()
+	return finished
+}
+
+func TestUploadWaitsForFileToStabilize(t *testing.T) {
+	var (
+		mu       stdsync.Mutex
+		uploaded []byte
+		at       time.Time
+	)
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			body, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			uploaded, at = body, time.Now()
+			mu.Unlock()
+			w.Write([]byte(`{"data":{"id":"remote-render"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	engine.stableFor = 150 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "render.mov")
+	require.NoError(t, os.WriteFile(path, []byte("header\n"), 0644))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "pending"}))
+
+	finished := growFile(t, path, 5, 40*time.Millisecond)
+
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	lastWrite := <-finished
+	final, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, string(final), string(uploaded), "only the complete file is uploaded")
+	assert.False(t, at.Before(lastWrite.Add(engine.stableFor)), "upload started before the file was stable")
+}
+
+func TestFileThatKeepsChangingIsDeferred(t *testing.T) {
+	requests := 0
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	})
+	engine.stableFor = 50 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "download.iso")
+	require.NoError(t, os.WriteFile(path, []byte("partial\n"), 0644))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "pending"}))
+
+	// Keeps growing for longer than the stability timeout
+	finished := growFile(t, path, 40, 10*time.Millisecond)
+	defer func() { <-finished }()
+
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	assert.Zero(t, requests)
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", saved.SyncStatus)
+}
@@ -0,0 +1,137 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/notify"
+)
+
+// errStorageFull fails the uploads left in a sync cycle after the remote
+// drive reported it was full
+var errStorageFull = errors.New("remote storage is full, uploads are paused until the next sync")
+
+// usageTimeout bounds the request for the storage usage reported when the
+// drive fills up
+const usageTimeout = 10 * time.Second
+
+// storageFull halts uploads for the rest of a sync cycle once the remote
+// drive reports it is full. Each cycle starts with uploads allowed again,
+// so syncing resumes by itself once the user frees space.
+type storageFull struct {
+	mu     sync.Mutex
+	halted bool
+	// notified is set once the user was told, and cleared by the next
+	// upload that succeeds, so a drive that stays full is reported once
+	notified bool
+}
+
+// newStorageFull creates a tracker with uploads allowed
+func newStorageFull() *storageFull {
+	return &storageFull{}
+}
+
+// resumeUploads allows uploads again at the start of a sync cycle
+func (e *Engine) resumeUploads() {
+	e.storage.mu.Lock()
+	defer e.storage.mu.Unlock()
+	e.storage.halted = false
+}
+
+// uploadsHalted reports whether the drive was found full this cycle
+func (e *Engine) uploadsHalted() bool {
+	e.storage.mu.Lock()
+	defer e.storage.mu.Unlock()
+	return e.storage.halted
+}
+
+// uploadSucceeded records that the drive took an upload, so it filling up
+// again is reported anew
+func (e *Engine) uploadSucceeded() {
+	e.storage.mu.Lock()
+	defer e.storage.mu.Unlock()
+	e.storage.notified = false
+}
+
+// deferredStorageFull reports whether a failed transfer failed because the
+// remote drive is full. The first such failure halts the remaining uploads
+// of the cycle and tells the user; the files are left pending rather than
+// failed.
+func (e *Engine) deferredStorageFull(ctx context.Context, path string, err error) bool {
+	if ClassifyError("sync", err).Type != ErrorTypeStorageFull {
+		return false
+	}
+
+	e.storage.mu.Lock()
+	first := !e.storage.halted
+	tell := !e.storage.notified
+	e.storage.halted = true
+	e.storage.notified = true
+	e.storage.mu.Unlock()
+
+	if first {
+		e.logger.Warnf("Remote storage is full, halting uploads until the next sync")
+	}
+	if tell {
+		e.notifyStorageFull(ctx, path)
+	}
+	return true
+}
+
+// notifyStorageFull tells the user the drive is full, with how much of it
+// is used if the backend can tell
+func (e *Engine) notifyStorageFull(ctx context.Context, path string) {
+	e.alerts.mu.Lock()
+	n := e.alerts.notifier
+	e.alerts.mu.Unlock()
+	if n == nil {
+		return
+	}
+
+	message := "WorkDrive has no space left. Uploads are paused and resume on the next sync once space is freed."
+	if usage := e.storageUsage(ctx); usage != nil && usage.Total > 0 {
+		message = fmt.Sprintf("WorkDrive has no space left (%s of %s used). Uploads are paused and resume on the next sync once space is freed.",
+			formatSize(usage.Used), formatSize(usage.Total))
+	}
+	e.sendAlert(n, notify.Event{
+		Kind:    notify.KindQuotaWarning,
+		Title:   "WorkDrive storage full",
+		Message: message,
+		Path:    path,
+	})
+}
+
+// storageUsage asks the backend how much storage is used, or returns nil
+// if it cannot tell
+func (e *Engine) storageUsage(ctx context.Context) *api.StorageUsage {
+	reporter, ok := e.backend.(api.UsageReporter)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usageTimeout)
+	defer cancel()
+	usage, err := reporter.GetStorageUsage(ctx)
+	if err != nil {
+		e.logger.Warnf("Failed to get storage usage: %v", err)
+		return nil
+	}
+	return usage
+}
+
+// formatSize formats a byte count for messages
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
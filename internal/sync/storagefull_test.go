@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/notify"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullStorageHaltsUploadsUntilNextCycle(t *testing.T) {
+	var mu stdsync.Mutex
+	initiated, capacity := 0, 1
+
+	config := &types.Config{Sync: types.SyncConfig{MaxConcurrentSyncs: 1}}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/users/me/storage":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"data":{"used":5368709120,"total":5368709120}}`))
+		case r.URL.Path == "/upload/initiate":
+			initiated++
+			if capacity == 0 {
+				http.Error(w, `{"error":"storage quota exceeded"}`, http.StatusInsufficientStorage)
+				return
+			}
+			capacity--
+			fmt.Fprintf(w, `{"data":{"upload_id":"upload%d"}}`, initiated)
+		case strings.HasPrefix(r.URL.Path, "/upload/"):
+			fmt.Fprintf(w, `{"data":{"id":"remote-%s"}}`, strings.TrimPrefix(r.URL.Path, "/upload/"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	recorder := &recordingNotifier{}
+	engine.SetNotifier(recorder)
+
+	dir := t.TempDir()
+	var paths []string
+	for i := 1; i <= 4; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("scan%d.pdf", i))
+		require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", i)), 0644))
+		engine.queueFileForSync(path, fsnotify.Create)
+		paths = append(paths, path)
+	}
+
+	ctx := context.Background()
+	engine.performSync(ctx)
+
+	mu.Lock()
+	assert.Equal(t, 2, initiated, "uploads stop after the first one the full drive refuses")
+	mu.Unlock()
+
+	statuses := map[string]int{}
+	for _, path := range paths {
+		metadata, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		statuses[metadata.SyncStatus]++
+	}
+	assert.Equal(t, map[string]int{"synced": 1, "pending": 3}, statuses, "files left over are pending, not failed")
+
+	require.Eventually(t, func() bool { return len(recorder.kinds()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, notify.KindQuotaWarning, recorder.kinds()[0])
+	assert.Contains(t, recorder.events[0].Message, "5.0 GB of 5.0 GB used")
+
+	// Once space is freed the next cycle uploads the rest
+	mu.Lock()
+	capacity = 10
+	mu.Unlock()
+	engine.performSync(ctx)
+
+	for _, path := range paths {
+		metadata, err := database.GetFileMetadata(path)
+		require.NoError(t, err)
+		assert.Equal(t, "synced", metadata.SyncStatus, path)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, recorder.kinds(), 1, "a drive that filled up once is reported once")
+}
+
+func TestStorageFullIsClassifiedApartFromRateLimits(t *testing.T) {
+	assert.Equal(t, ErrorTypeStorageFull, ClassifyHTTPError(http.StatusInsufficientStorage, "upload", nil).Type)
+	assert.Equal(t, ErrorTypeQuota, ClassifyHTTPError(http.StatusTooManyRequests, "upload", nil).Type)
+	assert.Equal(t, ErrorTypeStorageFull, ClassifyError("upload", errStorageFull).Type)
+	assert.False(t, ClassifyError("upload", errStorageFull).Retryable)
+}
@@ -0,0 +1,79 @@
+package sync
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// SyncStrategy controls which directions changes are allowed to flow for a
+// sync folder
+type SyncStrategy string
+
+const (
+	// StrategyBidirectional propagates changes both ways
+	StrategyBidirectional SyncStrategy = "bidirectional"
+	// StrategyUploadOnly pushes local changes and never writes locally
+	StrategyUploadOnly SyncStrategy = "upload_only"
+	// StrategyDownloadOnly mirrors the remote folder and never modifies WorkDrive
+	StrategyDownloadOnly SyncStrategy = "download_only"
+)
+
+// ParseSyncStrategy converts a folder's sync_mode setting into a strategy,
+// accepting either dashes or underscores and defaulting to bidirectional
+func ParseSyncStrategy(mode string) SyncStrategy {
+	normalized := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(mode)), "-", "_")
+
+	switch SyncStrategy(normalized) {
+	case StrategyUploadOnly, StrategyDownloadOnly:
+		return SyncStrategy(normalized)
+	default:
+		return StrategyBidirectional
+	}
+}
+
+// AllowsUpload reports whether the strategy may create, modify or delete
+// anything on WorkDrive
+func (s SyncStrategy) AllowsUpload() bool {
+	return s != StrategyDownloadOnly
+}
+
+// AllowsDownload reports whether the strategy may write to the local folder
+func (s SyncStrategy) AllowsDownload() bool {
+	return s != StrategyUploadOnly
+}
+
+// strategyForPath returns the strategy of the configured folder containing
+// path, preferring the most specific folder when several are nested
+func (e *Engine) strategyForPath(path string) SyncStrategy {
+	if folder := e.folderForPath(path); folder != nil {
+		return ParseSyncStrategy(folder.SyncMode)
+	}
+	return StrategyBidirectional
+}
+
+// folderForPath returns the most specific configured folder containing
+// path, or nil if it is outside every sync folder
+func (e *Engine) folderForPath(path string) *types.FolderConfig {
+	return findFolder(e.syncFolders, path)
+}
+
+// findFolder returns the most specific of folders containing path, or nil
+func findFolder(folders []types.FolderConfig, path string) *types.FolderConfig {
+	var match *types.FolderConfig
+	longest := -1
+
+	for i, folder := range folders {
+		local := filepath.Clean(folder.Local)
+		if path != local && !strings.HasPrefix(path, local+string(filepath.Separator)) {
+			continue
+		}
+		if len(local) > longest {
+			longest = len(local)
+			match = &folders[i]
+		}
+	}
+
+	return match
+}
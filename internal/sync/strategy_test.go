@@ -0,0 +1,54 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyncStrategy(t *testing.T) {
+	assert.Equal(t, StrategyDownloadOnly, ParseSyncStrategy("download_only"))
+	assert.Equal(t, StrategyDownloadOnly, ParseSyncStrategy("Download-Only"))
+	assert.Equal(t, StrategyUploadOnly, ParseSyncStrategy("upload-only"))
+	assert.Equal(t, StrategyBidirectional, ParseSyncStrategy("bidirectional"))
+	assert.Equal(t, StrategyBidirectional, ParseSyncStrategy(""))
+}
+
+func TestDownloadOnlySkipsLocalFiles(t *testing.T) {
+	mirror := t.TempDir()
+
+	var requests []string
+	config := &types.Config{
+		Folders: []types.FolderConfig{
+			{Local: mirror, Remote: "/Shared", SyncMode: "download_only", Enabled: true},
+		},
+	}
+	engine, database := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		http.NotFound(w, r)
+	})
+
+	path := filepath.Join(mirror, "notes.txt")
+	require.NoError(t, os.WriteFile(path, []byte("local only"), 0644))
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, SyncStatus: "pending"}))
+
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	require.NoError(t, engine.syncFile(context.Background(), metadata))
+
+	assert.Empty(t, requests, "download-only folders must not call the API for local-only files")
+
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "skipped", saved.SyncStatus)
+
+	pending, err := database.GetPendingFiles()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
@@ -0,0 +1,91 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// ErrSyncPaused is returned by SyncOnce while sync is paused
+var ErrSyncPaused = errors.New("sync is paused; resume it first")
+
+// syncTallies adds what the engine does to the results of the SyncOnce
+// passes in progress
+type syncTallies struct {
+	mu      sync.Mutex
+	results []*SyncResult
+}
+
+// newSyncTallies creates a tally with no pass in progress
+func newSyncTallies() *syncTallies {
+	return &syncTallies{}
+}
+
+// add starts adding to result and returns a function that stops it
+func (t *syncTallies) add(result *SyncResult) func() {
+	t.mu.Lock()
+	t.results = append(t.results, result)
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for i, r := range t.results {
+			if r == result {
+				t.results = append(t.results[:i], t.results[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// update applies fn to every result being added to
+func (t *syncTallies) update(fn func(result *SyncResult)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, result := range t.results {
+		fn(result)
+	}
+}
+
+// transferred counts a finished transfer
+func (t *syncTallies) transferred(direction string, bytes int64) {
+	t.update(func(result *SyncResult) {
+		if direction == types.DirectionUpload {
+			result.Uploaded++
+		} else {
+			result.Downloaded++
+		}
+		result.Bytes += bytes
+	})
+}
+
+// failed counts a file that failed to sync
+func (t *syncTallies) failed(err *SyncError) {
+	t.update(func(result *SyncResult) {
+		result.Failed++
+		result.Errors = append(result.Errors, err)
+	})
+}
+
+// SyncOnce runs one full sync pass and returns once it has finished: the
+// initial syncs still to do, then a sync cycle of every pending file. The
+// result counts what the pass transferred, the conflicts it met and the
+// files that failed.
+func (e *Engine) SyncOnce(ctx context.Context) (*SyncResult, error) {
+	if e.IsPaused() {
+		return nil, ErrSyncPaused
+	}
+
+	start := time.Now()
+	result := &SyncResult{}
+	stop := e.tallies.add(result)
+	err := e.SyncNow(ctx)
+	stop()
+
+	result.Duration = time.Since(start)
+	return result, err
+}
@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncOnceCountsWhatItDid(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	localDir := t.TempDir()
+	engine.syncFolders = []types.FolderConfig{{Local: localDir, Remote: "/", SyncMode: "bidirectional", Enabled: true}}
+	engine.config.Sync.ConflictResolution = "newer"
+
+	write := func(root, rel, content string, modTime time.Time) {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	old := time.Now().Add(-time.Hour)
+	write(localDir, "up1.txt", "12345", old)
+	write(localDir, "docs/up2.txt", "123", old)
+	write(remoteDir, "down.txt", "1234567", old)
+	write(localDir, "both.txt", "local edit", time.Now())
+	write(remoteDir, "both.txt", "remote", old)
+
+	ctx := context.Background()
+	result, err := engine.SyncOnce(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Uploaded, "two new files and the newer side of the conflict")
+	assert.Equal(t, 1, result.Downloaded)
+	assert.Equal(t, 1, result.Conflicts)
+	assert.Equal(t, int64(5+3+7+len("local edit")), result.Bytes)
+	assert.Zero(t, result.Failed)
+	assert.Positive(t, result.Duration)
+
+	// A file that fails is reported with its path
+	broken := filepath.Join(localDir, "broken.txt")
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{
+		Path:       broken,
+		RemoteID:   "no-such-file",
+		SyncStatus: "pending",
+	}))
+	result, err = engine.SyncOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.Uploaded+result.Downloaded)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Errors, 1)
+	var syncErr *SyncError
+	require.ErrorAs(t, result.Errors[0], &syncErr)
+	assert.Equal(t, broken, syncErr.FilePath)
+	_, err = database.DeleteFilesUnder(broken)
+	require.NoError(t, err)
+
+	// Nothing left to do
+	result, err = engine.SyncOnce(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.Uploaded+result.Downloaded+result.Conflicts)
+
+	stats, err := database.GetSyncStats()
+	require.NoError(t, err)
+	assert.Positive(t, stats.SyncedFiles)
+}
+
+func TestSyncOnceRefusesWhilePaused(t *testing.T) {
+	engine, _, _ := newLocalTestEngine(t)
+	require.NoError(t, engine.Pause())
+
+	_, err := engine.SyncOnce(context.Background())
+	assert.ErrorIs(t, err, ErrSyncPaused)
+}
+
+func TestSyncCycleReturnsWhatItDid(t *testing.T) {
+	engine, database, remoteDir := newLocalTestEngine(t)
+	docs, mirror := t.TempDir(), t.TempDir()
+	engine.syncFolders = []types.FolderConfig{
+		{Local: docs, Remote: "/", SyncMode: "bidirectional", Enabled: true},
+		{Local: mirror, Remote: "/", SyncMode: "download_only", Enabled: true},
+	}
+
+	pending := func(path, remoteID string) {
+		require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, RemoteID: remoteID, SyncStatus: "pending"}))
+	}
+	for name, content := range map[string]string{"a.txt": "12345", "b.txt": "123"} {
+		path := filepath.Join(docs, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		pending(path, "")
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(remoteDir, "c.txt"), []byte("1234567"), 0644))
+	pending(filepath.Join(docs, "c.txt"), "/c.txt")
+	// A download-only folder leaves its local files alone
+	local := filepath.Join(mirror, "local.txt")
+	require.NoError(t, os.WriteFile(local, []byte("local"), 0644))
+	pending(local, "")
+	broken := filepath.Join(docs, "broken.txt")
+	pending(broken, "no-such-file")
+
+	result := engine.performSync(context.Background())
+	assert.Equal(t, 2, result.Uploaded)
+	assert.Equal(t, 1, result.Downloaded)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Zero(t, result.Conflicts)
+	assert.Equal(t, int64(5+3+7), result.Bytes)
+	assert.Equal(t, 1, result.Failed)
+	require.Len(t, result.Errors, 1)
+	var syncErr *SyncError
+	require.ErrorAs(t, result.Errors[0], &syncErr)
+	assert.Equal(t, broken, syncErr.FilePath)
+	assert.Positive(t, result.Duration)
+
+	// A cycle with nothing to do reports nothing
+	_, err := database.DeleteFilesUnder(broken)
+	require.NoError(t, err)
+	result = engine.performSync(context.Background())
+	assert.Zero(t, result.Uploaded+result.Downloaded+result.Skipped+result.Failed)
+}
@@ -0,0 +1,289 @@
+package sync
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Transfer priorities; jobs with a higher priority run first
+const (
+	// PriorityBackground is used by scheduled sync cycles
+	PriorityBackground = 0
+	// PriorityOnDemand is used by transfers a user asked for
+	PriorityOnDemand = 10
+)
+
+// DefaultTransferQueueSize bounds how many jobs wait in a TransferPool per
+// worker before Submit blocks
+const DefaultTransferQueueSize = 256
+
+// ErrTransferPoolClosed is returned for jobs submitted to, or still queued
+// in, a closed TransferPool
+var ErrTransferPoolClosed = errors.New("transfer pool is closed")
+
+// TransferJob is one upload or download waiting for a worker
+type TransferJob struct {
+	// Key identifies the transfer, usually by its local path. A job whose
+	// key is already queued or running is not queued again.
+	Key      string
+	Priority int
+	Run      func(ctx context.Context) error
+}
+
+// TransferHandle tracks a submitted job until it has run
+type TransferHandle struct {
+	done chan struct{}
+	err  error
+}
+
+// Done is closed once the job has run or been dropped
+func (h *TransferHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait blocks until the job has run and returns its error, or until ctx is
+// done
+func (h *TransferHandle) Wait(ctx context.Context) error {
+	select {
+	case <-h.done:
+		return h.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TransferPoolStats is a snapshot of a TransferPool's load
+type TransferPoolStats struct {
+	Workers  int
+	Queued   int
+	InFlight int
+}
+
+// TransferPool runs transfers on a fixed set of long-lived workers, taking
+// jobs from a priority queue shared by every sync cycle and command, so that
+// overlapping triggers queue behind each other instead of oversubscribing
+// the connection. Each running job also holds a slot of the concurrency
+// controller, whose adaptive limit can hold workers back below their number.
+// Workers start with the first job.
+type TransferPool struct {
+	mu       sync.Mutex
+	workers  int
+	capacity int
+	limiter  *ConcurrencyController
+	queue    transferQueue
+	keys     map[string]*TransferHandle
+	seq      uint64
+	inFlight int
+	started  bool
+	closed   bool
+	changed  chan struct{}
+}
+
+// NewTransferPool creates a pool of workers whose running jobs are further
+// limited by limiter, if it is not nil
+func NewTransferPool(workers int, limiter *ConcurrencyController) *TransferPool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &TransferPool{
+		workers:  workers,
+		capacity: workers * DefaultTransferQueueSize,
+		limiter:  limiter,
+		keys:     make(map[string]*TransferHandle),
+		changed:  make(chan struct{}),
+	}
+}
+
+// Submit queues a job, blocking while the queue is full until there is
+// room or ctx is done. A job with the key of one already queued or running
+// is not queued again; the handle of the earlier job is returned instead.
+// The job runs with ctx and is dropped with ctx's error if it is cancelled
+// while queued.
+func (p *TransferPool) Submit(ctx context.Context, job TransferJob) (*TransferHandle, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrTransferPoolClosed
+		}
+		if handle, ok := p.keys[job.Key]; ok && job.Key != "" {
+			p.mu.Unlock()
+			return handle, nil
+		}
+		if len(p.queue) < p.capacity {
+			break
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	defer p.mu.Unlock()
+
+	if !p.started {
+		p.started = true
+		for i := 0; i < p.workers; i++ {
+			go p.work()
+		}
+	}
+
+	handle := &TransferHandle{done: make(chan struct{})}
+	p.seq++
+	heap.Push(&p.queue, &queuedTransfer{job: job, ctx: ctx, seq: p.seq, handle: handle})
+	if job.Key != "" {
+		p.keys[job.Key] = handle
+	}
+	p.notify()
+	return handle, nil
+}
+
+// Stats returns the current number of workers, queued and running jobs
+func (p *TransferPool) Stats() TransferPoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return TransferPoolStats{Workers: p.workers, Queued: len(p.queue), InFlight: p.inFlight}
+}
+
+// QueueDepth returns the number of jobs waiting for a worker
+func (p *TransferPool) QueueDepth() int {
+	return p.Stats().Queued
+}
+
+// InFlight returns the number of jobs running
+func (p *TransferPool) InFlight() int {
+	return p.Stats().InFlight
+}
+
+// Close stops the workers once their current jobs finish and drops every
+// queued job with ErrTransferPoolClosed
+func (p *TransferPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	dropped := p.queue
+	p.queue = nil
+	for _, t := range dropped {
+		delete(p.keys, t.job.Key)
+	}
+	p.notify()
+	p.mu.Unlock()
+
+	for _, t := range dropped {
+		t.handle.err = ErrTransferPoolClosed
+		close(t.handle.done)
+	}
+}
+
+// work runs queued jobs until the pool is closed
+func (p *TransferPool) work() {
+	for {
+		t, ok := p.next()
+		if !ok {
+			return
+		}
+
+		err := t.ctx.Err()
+		if err == nil && p.limiter != nil {
+			err = p.limiter.Acquire(t.ctx)
+		}
+		if err == nil {
+			p.setRunning(1)
+			start := time.Now()
+			err = t.job.Run(t.ctx)
+			if p.limiter != nil {
+				p.limiter.Release(time.Since(start), err)
+			}
+			p.setRunning(-1)
+		}
+		p.finish(t, err)
+	}
+}
+
+// next blocks until a job is queued, returning false once the pool closes
+func (p *TransferPool) next() (*queuedTransfer, bool) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, false
+		}
+		if len(p.queue) > 0 {
+			t := heap.Pop(&p.queue).(*queuedTransfer)
+			p.notify()
+			p.mu.Unlock()
+			return t, true
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		<-changed
+	}
+}
+
+// setRunning adjusts the count of running jobs
+func (p *TransferPool) setRunning(delta int) {
+	p.mu.Lock()
+	p.inFlight += delta
+	p.mu.Unlock()
+}
+
+// finish records the outcome of a job and releases its key
+func (p *TransferPool) finish(t *queuedTransfer, err error) {
+	p.mu.Lock()
+	if p.keys[t.job.Key] == t.handle {
+		delete(p.keys, t.job.Key)
+	}
+	p.mu.Unlock()
+
+	t.handle.err = err
+	close(t.handle.done)
+}
+
+// notify wakes up goroutines waiting for the queue to change; the pool must
+// be locked
+func (p *TransferPool) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// queuedTransfer is a job waiting in the queue
+type queuedTransfer struct {
+	job    TransferJob
+	ctx    context.Context
+	seq    uint64
+	handle *TransferHandle
+}
+
+// transferQueue orders jobs by priority, then by submission
+type transferQueue []*queuedTransfer
+
+func (q transferQueue) Len() int { return len(q) }
+
+func (q transferQueue) Less(i, j int) bool {
+	if q[i].job.Priority != q[j].job.Priority {
+		return q[i].job.Priority > q[j].job.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q transferQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *transferQueue) Push(x interface{}) { *q = append(*q, x.(*queuedTransfer)) }
+
+func (q *transferQueue) Pop() interface{} {
+	old := *q
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return t
+}
@@ -0,0 +1,144 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferPoolBoundsConcurrency(t *testing.T) {
+	const workers, jobs = 3, 20
+	pool := NewTransferPool(workers, nil)
+	defer pool.Close()
+
+	var (
+		mu        gosync.Mutex
+		running   int
+		maxSeen   int
+		completed int
+	)
+	release := make(chan struct{})
+	ctx := context.Background()
+
+	var handles []*TransferHandle
+	for i := 0; i < jobs; i++ {
+		handle, err := pool.Submit(ctx, TransferJob{
+			Key: fmt.Sprintf("file-%d", i),
+			Run: func(ctx context.Context) error {
+				mu.Lock()
+				running++
+				if running > maxSeen {
+					maxSeen = running
+				}
+				mu.Unlock()
+
+				<-release
+
+				mu.Lock()
+				running--
+				completed++
+				mu.Unlock()
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		handles = append(handles, handle)
+	}
+
+	require.Eventually(t, func() bool { return pool.InFlight() == workers }, time.Second, time.Millisecond)
+	assert.Equal(t, TransferPoolStats{Workers: workers, Queued: jobs - workers, InFlight: workers}, pool.Stats())
+
+	close(release)
+	for _, handle := range handles {
+		require.NoError(t, handle.Wait(ctx))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, jobs, completed)
+	assert.LessOrEqual(t, maxSeen, workers)
+	assert.Equal(t, TransferPoolStats{Workers: workers}, pool.Stats())
+}
+
+func TestTransferPoolRunsHigherPriorityFirst(t *testing.T) {
+	pool := NewTransferPool(1, nil)
+	defer pool.Close()
+	ctx := context.Background()
+
+	// Hold the only worker so the rest queue up
+	gate := make(chan struct{})
+	_, err := pool.Submit(ctx, TransferJob{Key: "busy", Run: func(context.Context) error {
+		<-gate
+		return nil
+	}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return pool.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	var mu gosync.Mutex
+	var order []string
+	job := func(key string, priority int) TransferJob {
+		return TransferJob{Key: key, Priority: priority, Run: func(context.Context) error {
+			mu.Lock()
+			order = append(order, key)
+			mu.Unlock()
+			return nil
+		}}
+	}
+
+	var handles []*TransferHandle
+	for _, j := range []TransferJob{
+		job("periodic-1", PriorityBackground),
+		job("periodic-2", PriorityBackground),
+		job("requested", PriorityOnDemand),
+	} {
+		handle, err := pool.Submit(ctx, j)
+		require.NoError(t, err)
+		handles = append(handles, handle)
+	}
+
+	// A second trigger for a queued file does not queue it again
+	again, err := pool.Submit(ctx, job("periodic-1", PriorityBackground))
+	require.NoError(t, err)
+	assert.Same(t, handles[0], again)
+	assert.Equal(t, 3, pool.QueueDepth())
+
+	close(gate)
+	for _, handle := range handles {
+		require.NoError(t, handle.Wait(ctx))
+	}
+	assert.Equal(t, []string{"requested", "periodic-1", "periodic-2"}, order)
+}
+
+func TestTransferPoolCloseDropsQueuedJobs(t *testing.T) {
+	pool := NewTransferPool(1, nil)
+	ctx := context.Background()
+
+	gate := make(chan struct{})
+	running, err := pool.Submit(ctx, TransferJob{Key: "running", Run: func(context.Context) error {
+		<-gate
+		return nil
+	}})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return pool.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	queued, err := pool.Submit(ctx, TransferJob{Key: "queued", Run: func(context.Context) error {
+		t.Error("a job queued when the pool closed must not run")
+		return nil
+	}})
+	require.NoError(t, err)
+
+	pool.Close()
+	assert.ErrorIs(t, queued.Wait(ctx), ErrTransferPoolClosed)
+
+	// The running job still finishes
+	close(gate)
+	assert.NoError(t, running.Wait(ctx))
+
+	_, err = pool.Submit(ctx, TransferJob{Key: "late", Run: func(context.Context) error { return nil }})
+	assert.ErrorIs(t, err, ErrTransferPoolClosed)
+}
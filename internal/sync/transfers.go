@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// transferRegistry tracks the cancel functions of in-flight transfers by path
+type transferRegistry struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+	// changed is closed and replaced whenever a transfer ends
+	changed chan struct{}
+}
+
+// newTransferRegistry creates an empty registry
+func newTransferRegistry() *transferRegistry {
+	return &transferRegistry{
+		cancels: make(map[string]context.CancelFunc),
+		changed: make(chan struct{}),
+	}
+}
+
+// waitIdle blocks until no transfers are in flight or ctx is done, and
+// returns the paths still being transferred
+func (r *transferRegistry) waitIdle(ctx context.Context) []string {
+	for {
+		r.mu.Lock()
+		if len(r.cancels) == 0 {
+			r.mu.Unlock()
+			return nil
+		}
+		changed := r.changed
+		r.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return r.paths()
+		}
+	}
+}
+
+// paths returns the paths being transferred in sorted order
+func (r *transferRegistry) paths() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	paths := make([]string, 0, len(r.cancels))
+	for path := range r.cancels {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// beginTransfer derives a cancellable context for a transfer of path. The
+// returned function must be called when the transfer ends.
+func (e *Engine) beginTransfer(ctx context.Context, path string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := filepath.Clean(path)
+
+	r := e.transfers
+	r.mu.Lock()
+	r.cancels[key] = cancel
+	r.mu.Unlock()
+
+	return ctx, func() {
+		r.mu.Lock()
+		delete(r.cancels, key)
+		close(r.changed)
+		r.changed = make(chan struct{})
+		r.mu.Unlock()
+		cancel()
+	}
+}
+
+// CancelTransfer aborts the in-flight transfer of path, if any. The file is
+// left pending so it is retried on a later sync cycle. It reports whether a
+// transfer was found.
+func (e *Engine) CancelTransfer(path string) bool {
+	key := filepath.Clean(path)
+
+	r := e.transfers
+	r.mu.Lock()
+	cancel, ok := r.cancels[key]
+	r.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	cancel()
+	e.logger.Infof("Cancelled transfer of %s", key)
+	return true
+}
+
+// ActiveTransfers returns the paths currently being transferred
+func (e *Engine) ActiveTransfers() []string {
+	return e.transfers.paths()
+}
+
+// downloadTempPath returns the hidden file a download is written to before
+// being moved into place
+func downloadTempPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+downloadTempSuffix)
+}
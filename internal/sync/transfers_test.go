@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelTransferLeavesFilePending(t *testing.T) {
+	started := make(chan struct{})
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/big1":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"id": "big1", "name": "video.mp4", "size": 1 << 30},
+			})
+		case "/files/big1/download":
+			// Send part of the body, then stall like a slow transfer
+			w.Write(make([]byte, 4096))
+			w.(http.Flusher).Flush()
+			close(started)
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "video.mp4")
+	require.NoError(t, database.SaveFileMetadata(&types.FileMetadata{Path: path, RemoteID: "big1", SyncStatus: "pending"}))
+	metadata, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- engine.syncFile(context.Background(), metadata)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("download did not start")
+	}
+	assert.Equal(t, []string{path}, engine.ActiveTransfers())
+	require.True(t, engine.CancelTransfer(path))
+
+	select {
+	case err := <-result:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled transfer did not stop")
+	}
+
+	// No partial or temporary files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	saved, err := database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", saved.SyncStatus)
+
+	assert.Empty(t, engine.ActiveTransfers())
+	assert.False(t, engine.CancelTransfer(path))
+}
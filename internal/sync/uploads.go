@@ -0,0 +1,162 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultUploadSessionTTL is assumed for upload sessions whose expiry the
+// server did not report
+const DefaultUploadSessionTTL = 24 * time.Hour
+
+// uploadSessionMargin is how long before expiry a session stops being reused,
+// so a resumed upload does not run into the deadline
+const uploadSessionMargin = 5 * time.Minute
+
+// uploadSession returns the upload session for a file, resuming the one
+// recorded for the same path, size and hash if it is still valid and
+// initiating a new one under name otherwise
+func (e *Engine) uploadSession(ctx context.Context, metadata *types.FileMetadata, name string, size int64, parentID string, uploadMetadata *api.UploadMetadata) (*api.FileUploadInfo, error) {
+	existing, err := e.database.GetUploadSession(metadata.Path)
+	if err != nil {
+		e.logger.Warnf("Failed to look up upload session for %s: %v", metadata.Path, err)
+	}
+	if existing != nil && existing.Hash != "" && metadata.Hash == "" {
+		// Telling whether the file changed since the session began takes
+		// its hash, which is otherwise computed during the upload
+		if hash, err := e.calculateFileHash(metadata.Path); err == nil {
+			metadata.Hash = hash
+		}
+	}
+	if existing != nil {
+		// A session begun before the file was hashed is not tied to its
+		// content; that is safe because every attempt sends all of it
+		if existing.Size == size && (existing.Hash == "" || existing.Hash == metadata.Hash) &&
+			e.clock.Now().Add(uploadSessionMargin).Before(existing.ExpiresAt) {
+			e.logger.Infof("Resuming upload session %s for %s", existing.UploadID, metadata.Path)
+			return &api.FileUploadInfo{
+				UploadID:  existing.UploadID,
+				UploadURL: existing.UploadURL,
+				ExpiresAt: existing.ExpiresAt,
+			}, nil
+		}
+		// The file changed or the session is about to expire
+		e.abandonUploadSession(ctx, existing)
+	}
+
+	var info *api.FileUploadInfo
+	err = e.withRetry(ctx, "upload", func() error {
+		var err error
+		info, err = e.backend.InitiateUploadWithMetadata(ctx, name, size, parentID, uploadMetadata)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := info.ExpiresAt
+	if expiresAt.IsZero() {
+		expiresAt = e.clock.Now().Add(DefaultUploadSessionTTL)
+	}
+	if err := e.database.SaveUploadSession(&types.UploadSession{
+		UploadID:  info.UploadID,
+		Path:      metadata.Path,
+		Size:      size,
+		Hash:      metadata.Hash,
+		UploadURL: info.UploadURL,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		e.logger.Warnf("Failed to record upload session for %s: %v", metadata.Path, err)
+	}
+
+	e.logger.Infof("Upload initiated for %s with ID: %s", metadata.Path, info.UploadID)
+	return info, nil
+}
+
+// sendUploadContent streams a file into its upload session, hashing it on
+// the way into content.hash, and retries transient failures. The session is forgotten once the upload completes;
+// after a failure it is kept so the next attempt resumes it.
+func (e *Engine) sendUploadContent(ctx context.Context, content *uploadContent, session *api.FileUploadInfo, size int64) (*api.FileInfo, error) {
+	var remote *api.FileInfo
+	err := e.withRetry(ctx, "upload", func() error {
+		source, file, hashing, err := content.openHashing(size)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		progressCtx := api.WithProgress(ctx, func(sent, total int64) {
+			e.reportUploadProgress(content.path, sent, total)
+		})
+		remote, err = e.backend.UploadContent(progressCtx, session, io.NewSectionReader(source, 0, size), size)
+		if err == nil {
+			content.hash, _ = hashing.sum()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload content: %w", err)
+	}
+
+	if err := e.database.DeleteUploadSession(session.UploadID); err != nil {
+		e.logger.Warnf("Failed to clear upload session %s: %v", session.UploadID, err)
+	}
+	return remote, nil
+}
+
+// abandonUploadSession aborts a session on the server and forgets it
+func (e *Engine) abandonUploadSession(ctx context.Context, session *types.UploadSession) {
+	if err := e.backend.AbortUpload(ctx, session.UploadID); err != nil {
+		// Keep the record so the cleanup pass tries again later
+		e.logger.Warnf("Failed to abort upload session %s: %v", session.UploadID, err)
+		return
+	}
+	if err := e.database.DeleteUploadSession(session.UploadID); err != nil {
+		e.logger.Warnf("Failed to clear upload session %s: %v", session.UploadID, err)
+	}
+}
+
+// cleanupUploadSessions abandons upload sessions that expired before they
+// were completed
+func (e *Engine) cleanupUploadSessions(ctx context.Context) {
+	sessions, err := e.database.GetExpiredUploadSessions(e.clock.Now())
+	if err != nil {
+		e.logger.Errorf("Failed to list expired upload sessions: %v", err)
+		return
+	}
+
+	for i := range sessions {
+		e.logger.Infof("Abandoning expired upload session %s for %s", sessions[i].UploadID, sessions[i].Path)
+		e.abandonUploadSession(ctx, &sessions[i])
+	}
+}
+
+// withRetry runs fn until it succeeds, the error is not retryable or the
+// retry policy gives up, backing off between attempts
+func (e *Engine) withRetry(ctx context.Context, operation string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		retry, delay := e.retry.HandleError(ClassifyError(operation, err), attempt)
+		if !retry || ctx.Err() != nil || e.deferredOffline(ctx, err) {
+			// Retrying against an unreachable remote only burns time;
+			// the transfer waits for it to come back instead
+			return err
+		}
+
+		e.logger.Warnf("%s attempt %d failed, retrying in %v: %v", operation, attempt, delay, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-e.clock.After(delay):
+		}
+	}
+}
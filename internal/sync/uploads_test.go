@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/clock"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastRetry retries network errors once without waiting
+var fastRetry = &RetryConfig{
+	MaxAttempts:    2,
+	InitialDelay:   time.Millisecond,
+	MaxDelay:       time.Millisecond,
+	BackoffFactor:  1,
+	RetryableTypes: []ErrorType{ErrorTypeNetwork},
+}
+
+func TestInterruptedUploadResumesSession(t *testing.T) {
+	var initiated, attempts int
+	failing := true
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/upload/initiate":
+			initiated++
+			w.Write([]byte(`{"data":{"upload_id":"upload1"}}`))
+		case "/upload/upload1":
+			attempts++
+			if failing {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"data":{"id":"remote-video"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	engine.retry = NewErrorRecovery(fastRetry)
+
+	path := filepath.Join(t.TempDir(), "video.mp4")
+	require.NoError(t, os.WriteFile(path, []byte("frames"), 0644))
+
+	metadata := &types.FileMetadata{Path: path}
+	require.Error(t, engine.uploadFile(context.Background(), metadata))
+	assert.Equal(t, 1, initiated)
+	assert.Equal(t, 2, attempts, "transient failures are retried")
+
+	session, err := database.GetUploadSession(path)
+	require.NoError(t, err)
+	require.NotNil(t, session, "the session is kept after a failed upload")
+	assert.Equal(t, "upload1", session.UploadID)
+
+	// The next attempt resumes the session instead of initiating another
+	failing = false
+	metadata = &types.FileMetadata{Path: path}
+	require.NoError(t, engine.uploadFile(context.Background(), metadata))
+	assert.Equal(t, 1, initiated)
+	assert.Equal(t, "remote-video", metadata.RemoteID)
+
+	session, err = database.GetUploadSession(path)
+	require.NoError(t, err)
+	assert.Nil(t, session, "completed sessions are forgotten")
+}
+
+func TestChangedFileAbandonsStaleSession(t *testing.T) {
+	var requests []string
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/upload/initiate":
+			w.Write([]byte(`{"data":{"upload_id":"upload2"}}`))
+		case "/upload/upload1":
+			w.WriteHeader(http.StatusNoContent)
+		case "/upload/upload2":
+			w.Write([]byte(`{"data":{"id":"remote-doc"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	require.NoError(t, os.WriteFile(path, []byte("second draft"), 0644))
+	require.NoError(t, database.SaveUploadSession(&types.UploadSession{
+		UploadID: "upload1", Path: path, Size: 11, Hash: "first-draft-hash", ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	require.NoError(t, engine.uploadFile(context.Background(), &types.FileMetadata{Path: path}))
+	assert.Equal(t, []string{"DELETE /upload/upload1", "POST /upload/initiate", "PUT /upload/upload2"}, requests)
+}
+
+func TestCleanupAbortsExpiredSessions(t *testing.T) {
+	var aborted []string
+
+	engine, database := newTestEngine(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			aborted = append(aborted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	require.NoError(t, database.SaveUploadSession(&types.UploadSession{
+		UploadID: "expired", Path: "/data/old.iso", ExpiresAt: time.Now().Add(-time.Hour),
+	}))
+	require.NoError(t, database.SaveUploadSession(&types.UploadSession{
+		UploadID: "active", Path: "/data/new.iso", ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	engine.cleanupUploadSessions(context.Background())
+	assert.Equal(t, []string{"/upload/expired"}, aborted)
+
+	session, err := database.GetUploadSession("/data/old.iso")
+	require.NoError(t, err)
+	assert.Nil(t, session)
+
+	session, err = database.GetUploadSession("/data/new.iso")
+	require.NoError(t, err)
+	assert.NotNil(t, session)
+}
+
+func TestRetryBackoffFollowsClock(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, http.NotFound)
+	fake := clock.NewFake(time.Now())
+	engine.SetClock(fake)
+
+	attempts := make(chan int, 3)
+	done := make(chan error, 1)
+	go func() {
+		n := 0
+		done <- engine.withRetry(context.Background(), "upload", func() error {
+			n++
+			attempts <- n
+			if n < 3 {
+				return context.DeadlineExceeded
+			}
+			return nil
+		})
+	}()
+
+	// Backoff doubles from one second: 2s after the first attempt, 4s after
+	// the second
+	require.Equal(t, 1, <-attempts)
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+	assert.Empty(t, attempts, "retried before the backoff passed")
+	fake.Advance(time.Second)
+	require.Equal(t, 2, <-attempts)
+
+	fake.BlockUntil(1)
+	fake.Advance(4 * time.Second)
+	require.Equal(t, 3, <-attempts)
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	stdsync "sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVerifyServer builds an engine whose server replies to each upload
+// with reply given the hash of what it received, and counts the uploads
+// and file lookups
+func newVerifyServer(t *testing.T, config *types.Config, reply func(hash string) string) (*Engine, func() (uploads, lookups int)) {
+	var mu stdsync.Mutex
+	uploads, lookups := 0, 0
+	engine, _ := newTestEngine(t, config, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/upload/initiate":
+			fmt.Fprint(w, `{"data":{"upload_id":"upload1"}}`)
+		case strings.HasPrefix(r.URL.Path, "/upload/"):
+			uploads++
+			fmt.Fprint(w, reply(uploadedHash(t, r)))
+		case r.URL.Path == "/files/remote1":
+			lookups++
+			fmt.Fprint(w, `{"data":{"id":"remote1","size":3}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return engine, func() (int, int) {
+		mu.Lock()
+		defer mu.Unlock()
+		return uploads, lookups
+	}
+}
+
+// uploadedHash returns the SHA-256 of an uploaded body
+func uploadedHash(t *testing.T, r *http.Request) string {
+	h := sha256.New()
+	_, err := io.Copy(h, r.Body)
+	assert.NoError(t, err)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func verifyConfig(verify bool) *types.Config {
+	return &types.Config{Sync: types.SyncConfig{VerifyUploads: verify, HashAlgorithm: "sha256", MaxConcurrentSyncs: 1}}
+}
+
+func TestUploadWithMismatchedRemoteHashIsRequeued(t *testing.T) {
+	var corrupt atomic.Bool
+	corrupt.Store(true)
+	engine, counts := newVerifyServer(t, verifyConfig(true), func(hash string) string {
+		if corrupt.Load() {
+			hash = strings.Repeat("0", len(hash))
+		}
+		return fmt.Sprintf(`{"data":{"id":"remote1","size":7,"hash":"%s","hash_algorithm":"sha256"}}`, hash)
+	})
+
+	path := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(path, []byte("content"), 0644))
+	engine.queueFileForSync(path, fsnotify.Create)
+	ctx := context.Background()
+	engine.performSync(ctx)
+
+	metadata, err := engine.database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", metadata.SyncStatus, "an upload that does not verify is sent again, not marked synced")
+	assert.Equal(t, "remote1", metadata.RemoteID)
+
+	// The next cycle uploads it again, this time intact
+	corrupt.Store(false)
+	engine.performSync(ctx)
+	metadata, err = engine.database.GetFileMetadata(path)
+	require.NoError(t, err)
+	assert.Equal(t, "synced", metadata.SyncStatus)
+	uploads, _ := counts()
+	assert.Equal(t, 2, uploads)
+}
+
+func TestUploadVerificationLooksUpMissingSize(t *testing.T) {
+	for _, verify := range []bool{true, false} {
+		t.Run(fmt.Sprintf("verify=%v", verify), func(t *testing.T) {
+			engine, counts := newVerifyServer(t, verifyConfig(verify), func(string) string {
+				return `{"data":{"id":"remote1"}}`
+			})
+
+			// The remote copy reports 3 bytes of the 7 uploaded
+			path := filepath.Join(t.TempDir(), "notes.txt")
+			require.NoError(t, os.WriteFile(path, []byte("content"), 0644))
+			engine.queueFileForSync(path, fsnotify.Create)
+			engine.performSync(context.Background())
+
+			metadata, err := engine.database.GetFileMetadata(path)
+			require.NoError(t, err)
+			_, lookups := counts()
+			if verify {
+				assert.Equal(t, "pending", metadata.SyncStatus)
+				assert.Equal(t, 1, lookups)
+			} else {
+				assert.Equal(t, "synced", metadata.SyncStatus, "verification can be turned off")
+				assert.Zero(t, lookups)
+			}
+		})
+	}
+}
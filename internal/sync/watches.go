@@ -0,0 +1,145 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// inotifyWatchLimit holds the per-user limit on inotify watches on Linux
+const inotifyWatchLimit = "/proc/sys/fs/inotify/max_user_watches"
+
+// WatchFailure is a folder the file watcher could not watch. Changes in it
+// are not noticed until it is watched again.
+type WatchFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+	// LimitReached is set if the system's limit on watches was hit
+	LimitReached bool `json:"limit_reached,omitempty"`
+}
+
+// WatchStatus lists the directories the file watcher covers
+type WatchStatus struct {
+	Watched []string       `json:"watched"`
+	Failed  []WatchFailure `json:"failed,omitempty"`
+	// Limit is the system's limit on watches, or zero if it is unknown
+	Limit int `json:"limit,omitempty"`
+}
+
+// watchFailures remembers the folders that could not be watched
+type watchFailures struct {
+	mu     sync.Mutex
+	failed map[string]WatchFailure
+}
+
+// newWatchFailures creates an empty set
+func newWatchFailures() *watchFailures {
+	return &watchFailures{failed: make(map[string]WatchFailure)}
+}
+
+func (w *watchFailures) record(path string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.failed[path] = WatchFailure{
+		Path:         path,
+		Error:        err.Error(),
+		LimitReached: errors.Is(err, syscall.ENOSPC),
+	}
+}
+
+func (w *watchFailures) forget(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.failed, path)
+}
+
+// list returns the failures ordered by path
+func (w *watchFailures) list() []WatchFailure {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	failures := make([]WatchFailure, 0, len(w.failed))
+	for _, failure := range w.failed {
+		failures = append(failures, failure)
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Path < failures[j].Path })
+	return failures
+}
+
+// addWatch watches dir, remembering whether that failed
+func (e *Engine) addWatch(dir string) error {
+	if err := e.watcher.Add(dir); err != nil {
+		e.watchFailures.record(dir, err)
+		return err
+	}
+	e.watchFailures.forget(dir)
+	return nil
+}
+
+// WatchedPaths returns the directories the file watcher covers, sorted
+func (e *Engine) WatchedPaths() []string {
+	e.mu.RLock()
+	watcher := e.watcher
+	e.mu.RUnlock()
+	if watcher == nil {
+		return nil
+	}
+
+	paths := watcher.WatchList()
+	sort.Strings(paths)
+	return paths
+}
+
+// WatchStatus returns the watched directories, the folders that could not be
+// watched and the system's limit on watches
+func (e *Engine) WatchStatus() *WatchStatus {
+	return &WatchStatus{
+		Watched: e.WatchedPaths(),
+		Failed:  e.watchFailures.list(),
+		Limit:   watchLimit(),
+	}
+}
+
+// RetryWatches tries again to watch the folders that could not be watched,
+// e.g. after the watch limit was raised, and returns the ones that still
+// cannot be
+func (e *Engine) RetryWatches(ctx context.Context) []WatchFailure {
+	e.mu.RLock()
+	running := e.watcher != nil
+	e.mu.RUnlock()
+	if !running {
+		return e.watchFailures.list()
+	}
+
+	for _, failure := range e.watchFailures.list() {
+		if _, err := os.Stat(failure.Path); os.IsNotExist(err) {
+			e.watchFailures.forget(failure.Path)
+			continue
+		}
+		if err := e.addWatchRecursive(ctx, failure.Path); err != nil {
+			e.logger.Warnf("Still unable to watch %s: %v", failure.Path, err)
+		} else {
+			e.logger.Infof("Watching folder: %s", failure.Path)
+		}
+	}
+	return e.watchFailures.list()
+}
+
+// watchLimit returns the system's limit on inotify watches, or zero where
+// there is none or it cannot be read
+func watchLimit() int {
+	data, err := os.ReadFile(inotifyWatchLimit)
+	if err != nil {
+		return 0
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return limit
+}
@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchStatusListsWatchedAndFailedFolders(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, nil)
+	watcher, err := fsnotify.NewWatcher()
+	require.NoError(t, err)
+	t.Cleanup(func() { watcher.Close() })
+	engine.watcher = watcher
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "projects")
+	require.NoError(t, os.Mkdir(sub, 0755))
+	require.NoError(t, engine.addWatchRecursive(context.Background(), root))
+
+	// One folder is missing, another hit the watch limit
+	missing := filepath.Join(t.TempDir(), "photos")
+	assert.Error(t, engine.addWatchRecursive(context.Background(), missing))
+	limited := filepath.Join(root, "archive")
+	engine.watchFailures.record(limited, fmt.Errorf("watch %s: %w", limited, syscall.ENOSPC))
+
+	status := engine.WatchStatus()
+	assert.Equal(t, []string{root, sub}, status.Watched)
+	assert.Equal(t, []string{root, sub}, engine.WatchedPaths())
+	require.Len(t, status.Failed, 2)
+	assert.Equal(t, limited, status.Failed[0].Path)
+	assert.True(t, status.Failed[0].LimitReached)
+	assert.Equal(t, missing, status.Failed[1].Path)
+	assert.False(t, status.Failed[1].LimitReached)
+	assert.NotEmpty(t, status.Failed[1].Error)
+
+	// Once the folder exists it can be watched; the one that is gone is dropped
+	require.NoError(t, os.Mkdir(missing, 0755))
+	assert.Empty(t, engine.RetryWatches(context.Background()))
+	assert.ElementsMatch(t, []string{root, sub, missing}, engine.WatchedPaths())
+}
@@ -0,0 +1,40 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bdstest/zohosync/internal/api"
+)
+
+// remoteRoot splits a folder's remote path into the ID of the folder it
+// starts from and the path below that folder. Paths in a workspace start
+// from the workspace's top folder, the others from the personal drive.
+// Folders that upload need write access to their workspace.
+func (e *Engine) remoteRoot(ctx context.Context, remote string, write bool) (string, string, error) {
+	name, remotePath := api.ParseRemotePath(remote)
+	if name == "" {
+		return "root", remotePath, nil
+	}
+
+	lister, ok := e.backend.(api.WorkspaceLister)
+	if !ok {
+		return "", "", fmt.Errorf("%s: the remote has no workspaces", remote)
+	}
+	workspaces, err := lister.ListWorkspaces(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	workspace, err := api.FindWorkspace(workspaces, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	if write && !workspace.Can(api.PermissionWrite) {
+		return "", "", fmt.Errorf("workspace %s is read-only for you; sync %s with sync_mode download_only", workspace.Name, remote)
+	}
+	if write && !workspace.Can(api.PermissionDelete) {
+		e.logger.Warnf("Workspace %s does not let you delete files; local deletions under %s will not be synced", workspace.Name, remote)
+	}
+	return workspace.ID, remotePath, nil
+}
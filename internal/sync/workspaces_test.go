@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// workspaceHandler serves a personal drive and a Marketing workspace, each
+// with a Campaigns folder holding one file
+func workspaceHandler(t *testing.T, marketingPermissions string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/workspaces":
+			w.Write([]byte(`{"data":[
+				{"id":"root","name":"My WorkDrive","type":"privatespace","permissions":["read","write","delete"]},
+				{"id":"ws-42","name":"Marketing","type":"teamfolder","permissions":` + marketingPermissions + `}
+			]}`))
+		case "/files/root/files":
+			w.Write([]byte(`{"data":[{"id":"personal-campaigns","name":"Campaigns","is_folder":true}]}`))
+		case "/files/ws-42/files":
+			w.Write([]byte(`{"data":[{"id":"team-campaigns","name":"Campaigns","is_folder":true}]}`))
+		case "/files/team-campaigns/files":
+			w.Write([]byte(`{"data":[{"id":"f1","name":"launch.txt","size":6}]}`))
+		case "/files/personal-campaigns/files":
+			w.Write([]byte(`{"data":[]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func TestDiffInWorkspace(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, workspaceHandler(t, `["read","write","delete"]`))
+	ctx := context.Background()
+
+	// The same path resolves to different folders in different workspaces
+	personal, err := engine.resolveRemoteFolder(ctx, "/Campaigns")
+	require.NoError(t, err)
+	assert.Equal(t, "personal-campaigns", personal)
+	team, err := engine.resolveRemoteFolder(ctx, "Marketing:/Campaigns")
+	require.NoError(t, err)
+	assert.Equal(t, "team-campaigns", team)
+
+	diff, err := engine.Diff(ctx, types.FolderConfig{Local: t.TempDir(), Remote: "Marketing:/Campaigns", SyncMode: "bidirectional"})
+	require.NoError(t, err)
+	require.Len(t, diff.Entries, 1)
+	assert.Equal(t, "launch.txt", diff.Entries[0].Path)
+	assert.Equal(t, DiffOnlyRemote, diff.Entries[0].Category)
+
+	_, err = engine.resolveRemoteFolder(ctx, "Sales:/Campaigns")
+	assert.ErrorContains(t, err, "workspace not found")
+}
+
+func TestReadOnlyWorkspaceRefusesUploads(t *testing.T) {
+	engine, _ := newTestEngine(t, nil, workspaceHandler(t, `["read"]`))
+	ctx := context.Background()
+
+	local := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(local, "draft.txt"), []byte("draft"), 0644))
+
+	_, err := engine.InitialSync(ctx, types.FolderConfig{Local: local, Remote: "Marketing:/Campaigns", SyncMode: "bidirectional", Enabled: true})
+	assert.ErrorContains(t, err, "read-only")
+
+	// Downloading from it is fine
+	_, err = engine.ensureRemoteFolderPath(ctx, "Marketing:/Campaigns", false)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateAuditCommand creates the audit command
+func (c *CLI) CreateAuditCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit [folder]",
+		Short: "Check that local and remote files are identical",
+		Long: `Compare a sync folder, or every enabled one, with WorkDrive and check that
+both sides hold the same files with the same content, using the hashes
+WorkDrive reports. Files missing from either side, of different sizes or
+with different content are listed, and the command fails if there are any.
+Nothing is transferred or changed.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+			folder := ""
+			if len(args) > 0 {
+				folder = args[0]
+			}
+			return c.handleAudit(cmd.Context(), folder, asJSON)
+		},
+	}
+
+	cmd.Flags().Bool("json", false, "Print the results as JSON")
+	return cmd
+}
+
+// handleAudit audits the selected sync folders and returns an error if any
+// discrepancy was found
+func (c *CLI) handleAudit(ctx context.Context, path string, asJSON bool) error {
+	folders, err := c.diffFolders(path)
+	if err != nil {
+		return err
+	}
+
+	backend, err := c.syncBackend()
+	if err != nil {
+		return err
+	}
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
+
+	audits := make([]*sync.FolderAudit, 0, len(folders))
+	discrepancies := 0
+	for _, folder := range folders {
+		audit, err := syncEngine.Audit(ctx, folder)
+		if err != nil {
+			return err
+		}
+		audits = append(audits, audit)
+		discrepancies += len(audit.Entries)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(audits); err != nil {
+			return err
+		}
+	} else {
+		for _, audit := range audits {
+			printFolderAudit(audit)
+		}
+	}
+
+	if discrepancies > 0 {
+		return fmt.Errorf("audit found %d discrepancies", discrepancies)
+	}
+	return nil
+}
+
+// printFolderAudit prints one folder's discrepancies grouped by category
+func printFolderAudit(audit *sync.FolderAudit) {
+	fmt.Printf("📁 %s -> %s\n", audit.Local, audit.Remote)
+
+	categories := []sync.AuditCategory{sync.AuditOnlyLocal, sync.AuditOnlyRemote, sync.AuditTypeMismatch,
+		sync.AuditSizeMismatch, sync.AuditHashMismatch, sync.AuditUnreadable}
+	for _, category := range categories {
+		for _, entry := range audit.Entries {
+			if entry.Category != category {
+				continue
+			}
+			name := entry.Path
+			if entry.IsDirectory {
+				name += "/"
+			}
+			fmt.Printf("   %-13s %s%s\n", category, name, auditDetail(&entry))
+		}
+	}
+
+	if len(audit.Entries) == 0 {
+		fmt.Printf("   ✅ %d files identical", audit.Checked-audit.Unverified)
+	} else {
+		fmt.Printf("   ❌ %d discrepancies in %d files checked", len(audit.Entries), audit.Checked)
+	}
+	if audit.Unverified > 0 {
+		fmt.Printf(", %d compared by size only (no comparable remote hash)", audit.Unverified)
+	}
+	fmt.Print("\n\n")
+}
+
+// auditDetail describes what differs for an entry
+func auditDetail(entry *sync.AuditEntry) string {
+	switch entry.Category {
+	case sync.AuditSizeMismatch:
+		return fmt.Sprintf(" (local %d bytes, remote %d bytes)", entry.LocalSize, entry.RemoteSize)
+	case sync.AuditHashMismatch:
+		return fmt.Sprintf(" (local %s, remote %s)", entry.LocalHash, entry.RemoteHash)
+	case sync.AuditTypeMismatch:
+		if entry.IsDirectory {
+			return " (folder locally, file remotely)"
+		}
+		return " (file locally, folder remotely)"
+	case sync.AuditUnreadable:
+		return " (" + entry.Error + ")"
+	default:
+		return ""
+	}
+}
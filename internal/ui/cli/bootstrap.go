@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateBootstrapCommand creates the bootstrap command
+func (c *CLI) CreateBootstrapCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "bootstrap [folder]",
+		Short: "Set up sync folders on a new machine from WorkDrive",
+		Long: `Download the remote copy of a sync folder, or of every enabled one, into
+its empty local folder and record it as synced, so that later syncs only
+transfer what changes. Folders that have local content or were synced
+before are refused: an emptied folder that was synced means its files were
+deleted.
+
+Run it before starting the daemon.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			folder := ""
+			if len(args) > 0 {
+				folder = args[0]
+			}
+			return c.handleBootstrap(cmd.Context(), folder)
+		},
+	}
+}
+
+// handleBootstrap bootstraps the selected sync folders
+func (c *CLI) handleBootstrap(ctx context.Context, path string) error {
+	// The daemon would start its own initial sync of the same folders
+	if _, err := control.Send(control.DefaultSocketPath(), control.Request{Command: "ping"}); err == nil {
+		return errors.New("the daemon is running; stop it before bootstrapping")
+	}
+
+	folders, err := c.diffFolders(path)
+	if err != nil {
+		return err
+	}
+
+	backend, err := c.syncBackend()
+	if err != nil {
+		return err
+	}
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
+	syncEngine.SetProgressFunc(folderProgress())
+
+	failed := 0
+	for _, folder := range folders {
+		fmt.Printf("⬇️  Bootstrapping %s from %s...\n", folder.Local, folder.Remote)
+		result, err := syncEngine.Bootstrap(ctx, folder)
+		if result != nil {
+			printSyncResult(result)
+			failed += result.Failed
+		}
+		if err != nil {
+			return fmt.Errorf("failed to bootstrap %s: %w", folder.Local, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) failed to download", failed)
+	}
+	fmt.Println("✅ Bootstrap completed")
+	return nil
+}
@@ -2,15 +2,21 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/bdstest/zohosync/internal/api"
 	"github.com/bdstest/zohosync/internal/auth"
 	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/internal/control"
 	"github.com/bdstest/zohosync/internal/storage"
 	"github.com/bdstest/zohosync/internal/sync"
 	"github.com/bdstest/zohosync/internal/utils"
@@ -22,6 +28,7 @@ import (
 type CLI struct {
 	config    *types.Config
 	database  *storage.Database
+	tokens    auth.TokenStore
 	logger    *utils.Logger
 }
 
@@ -40,10 +47,17 @@ func NewCLI() (*CLI, error) {
 	}
 
 	logger := utils.InitLogger(cfg.App.LogLevel)
+	tokens := auth.NewTokenStore(cfg.Auth, db)
+
+	// Pick up a login made with the standalone CLI before the upgrade
+	if _, err := auth.ImportLegacyTokens(context.Background(), db, tokens, api.NewClientWithConfig(nil, cfg), auth.LegacyTokenPath()); err != nil {
+		logger.Warnf("Failed to import legacy tokens: %v", err)
+	}
 
 	return &CLI{
 		config:   cfg,
 		database: db,
+		tokens:   tokens,
 		logger:   logger,
 	}, nil
 }
@@ -71,8 +85,20 @@ func (c *CLI) handleLogin(ctx context.Context) error {
 	fmt.Println("Initiating OAuth 2.0 login with Zoho WorkDrive...")
 	fmt.Println()
 
-	// Create OAuth client
+	if err := config.ValidateCredentials(c.config.Auth); err != nil {
+		return err
+	}
+
+	// Create OAuth client, keeping the login's state in the database so a
+	// restarted login can still accept the callback
 	oauthClient := auth.NewOAuthClient(c.config)
+	oauthClient.SetStateStore(c.database)
+
+	// Bind the callback port first; the authorization URL names it
+	if err := oauthClient.ListenCallback(); err != nil {
+		return err
+	}
+	defer oauthClient.CloseCallback()
 
 	// Get authorization URL
 	authURL, err := oauthClient.GetAuthURL()
@@ -95,16 +121,11 @@ func (c *CLI) handleLogin(ctx context.Context) error {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
-	// Save token to database
-	if err := c.database.SaveAuthToken(token); err != nil {
-		return fmt.Errorf("failed to save token: %w", err)
-	}
-
-	// Test API connection
-	apiClient := api.NewClient(token)
-	userInfo, err := apiClient.GetUserInfo(ctx)
+	// Verify the account and save the token, keeping existing sync state
+	apiClient := api.NewClientWithConfig(token, c.config)
+	userInfo, err := auth.CompleteLogin(ctx, c.database, c.tokens, apiClient, token, confirmAccountSwitch)
 	if err != nil {
-		return fmt.Errorf("failed to verify authentication: %w", err)
+		return err
 	}
 
 	fmt.Printf("✅ Successfully authenticated as: %s (%s)\n", userInfo.DisplayName, userInfo.Email)
@@ -113,16 +134,90 @@ func (c *CLI) handleLogin(ctx context.Context) error {
 	return nil
 }
 
+// confirmAccountSwitch asks on the terminal whether to continue with a
+// different account than the one the sync state belongs to
+func confirmAccountSwitch(stored, new string) bool {
+	fmt.Printf("⚠️  You logged in as %s, but ZohoSync has been syncing %s.\n", new, stored)
+	fmt.Println("   Your sync folders and file state will be kept, but they refer to the other account.")
+	fmt.Print("   Continue with the new account? [y/N]: ")
+
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 // CreateStatusCommand creates the status command
 func (c *CLI) CreateStatusCommand() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show synchronization status",
-		Long:  "Display current sync status, statistics, and pending operations",
+		Long:  "Display current sync status, statistics, transfer metrics, and pending operations",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+			if asJSON {
+				return c.printStatusJSON()
+			}
 			return c.handleStatus(cmd.Context())
 		},
 	}
+
+	cmd.Flags().Bool("json", false, "Print the status and metrics as JSON")
+	return cmd
+}
+
+// statusReport is the status command's JSON output
+type statusReport struct {
+	Authenticated bool                     `json:"authenticated"`
+	Status        *types.SyncStatus        `json:"status"`
+	Folders       []types.FolderSyncStatus `json:"folders"`
+}
+
+// syncStatus reads the sync statistics and metrics from the database, and
+// the state from the running daemon, which knows whether sync is paused
+// or offline
+func (c *CLI) syncStatus() (*types.SyncStatus, error) {
+	stats, err := c.database.GetSyncStats()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync stats: %w", err)
+	}
+	if stats.Metrics, err = c.database.GetSyncMetrics(); err != nil {
+		return nil, fmt.Errorf("failed to get sync metrics: %w", err)
+	}
+	if resp, err := control.Send(control.DefaultSocketPath(), control.Request{Command: "status"}); err == nil && resp.State != "" {
+		stats.State = types.SyncState(resp.State)
+	}
+	return stats, nil
+}
+
+// printStatusJSON prints the sync status, metrics and folders as JSON
+func (c *CLI) printStatusJSON() error {
+	token, err := c.tokens.GetAuthToken()
+	if err != nil {
+		return fmt.Errorf("failed to get auth token: %w", err)
+	}
+	stats, err := c.syncStatus()
+	if err != nil {
+		return err
+	}
+	folderStats, err := c.database.GetFolderStats()
+	if err != nil {
+		return fmt.Errorf("failed to get folder stats: %w", err)
+	}
+
+	report := statusReport{
+		Authenticated: token != nil && auth.NewOAuthClient(c.config).ValidateToken(token),
+		Status:        stats,
+		Folders:       []types.FolderSyncStatus{},
+	}
+	for _, folder := range c.config.Folders {
+		folderStatus := folderStats[filepath.Clean(folder.Local)]
+		folderStatus.Folder = folder.Local
+		report.Folders = append(report.Folders, folderStatus)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
 }
 
 // handleStatus processes the status command
@@ -132,7 +227,7 @@ func (c *CLI) handleStatus(ctx context.Context) error {
 	fmt.Println()
 
 	// Check authentication
-	token, err := c.database.GetAuthToken()
+	token, err := c.tokens.GetAuthToken()
 	if err != nil {
 		return fmt.Errorf("failed to get auth token: %w", err)
 	}
@@ -156,7 +251,7 @@ func (c *CLI) handleStatus(ctx context.Context) error {
 	fmt.Println()
 
 	// Get user info
-	apiClient := api.NewClient(token)
+	apiClient := api.NewClientWithConfig(token, c.config)
 	userInfo, err := apiClient.GetUserInfo(ctx)
 	if err != nil {
 		fmt.Printf("⚠️  Failed to get user info: %v\n", err)
@@ -166,9 +261,9 @@ func (c *CLI) handleStatus(ctx context.Context) error {
 	}
 
 	// Get sync statistics
-	stats, err := c.database.GetSyncStats()
+	stats, err := c.syncStatus()
 	if err != nil {
-		return fmt.Errorf("failed to get sync stats: %w", err)
+		return err
 	}
 
 	fmt.Println("📈 Sync Statistics:")
@@ -183,8 +278,29 @@ func (c *CLI) handleStatus(ctx context.Context) error {
 		fmt.Println("   Last sync: Never")
 	}
 
+	if initial := stats.InitialSync; initial != nil {
+		if initial.Planning {
+			fmt.Println("   Initial sync: planning")
+		} else {
+			fmt.Printf("   Initial sync: %d%% complete (%d/%d operations)\n",
+				initial.Percent(), initial.Completed, initial.Total)
+		}
+	}
+
 	fmt.Println()
 
+	if metrics := stats.Metrics; metrics != nil {
+		fmt.Println("⚡ Transfers:")
+		printTransferMetrics("Last cycle", metrics.LastCycle)
+		printTransferMetrics("All time", &metrics.Total)
+		fmt.Println()
+	}
+
+	folderStats, err := c.database.GetFolderStats()
+	if err != nil {
+		return fmt.Errorf("failed to get folder stats: %w", err)
+	}
+
 	// Show configured folders
 	fmt.Println("📁 Configured Folders:")
 	for i, folder := range c.config.Folders {
@@ -193,6 +309,14 @@ func (c *CLI) handleStatus(ctx context.Context) error {
 			status = "🟢 Enabled"
 		}
 		fmt.Printf("   %d. %s %s -> %s (%s)\n", i+1, status, folder.Local, folder.Remote, folder.SyncMode)
+
+		folderStatus := folderStats[filepath.Clean(folder.Local)]
+		fmt.Printf("      %d/%d files synced, %d pending", folderStatus.SyncedFiles, folderStatus.TotalFiles, folderStatus.PendingFiles)
+		if !folderStatus.LastSync.IsZero() {
+			fmt.Printf(", last synced %s\n", folderStatus.LastSync.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Println(", never synced")
+		}
 	}
 
 	return nil
@@ -205,59 +329,107 @@ func (c *CLI) CreateSyncCommand() *cobra.Command {
 		Short: "Perform manual synchronization",
 		Long:  "Trigger immediate synchronization of all configured folders",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return c.handleSync(cmd.Context())
+			if err := c.applyConcurrency(cmd); err != nil {
+				return err
+			}
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			sinceValue, _ := cmd.Flags().GetString("since")
+
+			var since time.Time
+			if sinceValue != "" {
+				var err error
+				if since, err = sync.ParseSince(sinceValue, time.Now()); err != nil {
+					return err
+				}
+			}
+			return c.handleSync(cmd.Context(), dryRun, since)
 		},
 	}
 
 	cmd.Flags().BoolP("dry-run", "n", false, "Show what would be synced without making changes")
+	cmd.Flags().String("since", "", "Only sync local files changed since a duration ago (2h, 3d) or a date/RFC 3339 time")
+	addConcurrencyFlag(cmd)
 	return cmd
 }
 
-// handleSync processes the sync command
-func (c *CLI) handleSync(ctx context.Context) error {
-	// Check authentication
-	token, err := c.database.GetAuthToken()
+// handleSync processes the sync command. A non-zero since restricts the run
+// to files changed after it; dryRun only prints the plan.
+func (c *CLI) handleSync(ctx context.Context, dryRun bool, since time.Time) error {
+	// Create the remote backend and sync engine
+	backend, err := c.syncBackend()
 	if err != nil {
-		return fmt.Errorf("failed to get auth token: %w", err)
+		return err
 	}
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
 
-	if token == nil {
-		return fmt.Errorf("not authenticated - run 'zohosync-cli login' first")
+	if dryRun || !since.IsZero() {
+		return c.syncChanges(ctx, syncEngine, dryRun, since)
 	}
 
-	// Validate token
-	oauthClient := auth.NewOAuthClient(c.config)
-	if !oauthClient.ValidateToken(token) {
-		return fmt.Errorf("authentication token expired - run 'zohosync-cli login'")
+	fmt.Println("🔄 Synchronizing...")
+	result, err := syncEngine.SyncOnce(ctx)
+	if err != nil {
+		return fmt.Errorf("sync failed: %w", err)
 	}
 
-	fmt.Println("🔄 Starting manual synchronization...")
-
-	// Create API client and sync engine
-	apiClient := api.NewClient(token)
-	syncEngine := sync.NewEngine(apiClient, c.database, c.config)
+	return reportSync(result)
+}
 
-	// Start sync engine
-	if err := syncEngine.Start(ctx); err != nil {
-		return fmt.Errorf("failed to start sync engine: %w", err)
+// reportSync prints the outcome of a sync and fails if any file did not sync
+func reportSync(result *sync.SyncResult) error {
+	fmt.Printf("✅ Synchronization completed in %s\n", result.Duration.Round(time.Millisecond))
+	fmt.Printf("   Uploaded: %d\n", result.Uploaded)
+	fmt.Printf("   Downloaded: %d\n", result.Downloaded)
+	fmt.Printf("   Transferred: %s\n", formatFileSize(result.Bytes))
+	if result.Conflicts > 0 {
+		fmt.Printf("   Conflicts: %d (see 'zohosync-cli conflicts')\n", result.Conflicts)
+	}
+	if result.Skipped > 0 {
+		fmt.Printf("   Skipped: %d\n", result.Skipped)
+	}
+	if result.Failed > 0 {
+		fmt.Printf("   Failed: %d\n", result.Failed)
+		for _, err := range result.Errors {
+			var syncErr *sync.SyncError
+			if errors.As(err, &syncErr) {
+				fmt.Printf("   ❌ %s: %s\n", syncErr.FilePath, syncErr.Message)
+			} else {
+				fmt.Printf("   ❌ %v\n", err)
+			}
+		}
+		return fmt.Errorf("%d file(s) failed to sync", result.Failed)
 	}
-	defer syncEngine.Stop()
 
-	// Wait for sync to complete
-	fmt.Println("⏳ Synchronizing...")
-	time.Sleep(2 * time.Second) // Allow time for initial sync
+	return nil
+}
 
-	// Get final status
-	stats, err := syncEngine.GetSyncStatus()
+// syncChanges plans local changes since the cutoff and either prints them
+// (dry run) or syncs just those files
+func (c *CLI) syncChanges(ctx context.Context, syncEngine *sync.Engine, dryRun bool, since time.Time) error {
+	operations, err := syncEngine.PlanSince(since)
 	if err != nil {
-		return fmt.Errorf("failed to get sync status: %w", err)
+		return fmt.Errorf("failed to plan sync: %w", err)
 	}
 
-	fmt.Printf("✅ Synchronization completed!\n")
-	fmt.Printf("   Files processed: %d\n", stats.TotalFiles)
-	fmt.Printf("   Successfully synced: %d\n", stats.SyncedFiles)
+	if !since.IsZero() {
+		fmt.Printf("🕒 Changes since %s\n", since.Format("2006-01-02 15:04:05"))
+	}
+	if len(operations) == 0 {
+		fmt.Println("✅ Nothing to sync")
+		return nil
+	}
 
-	return nil
+	for _, op := range operations {
+		fmt.Printf("   %-8s %s\n", op.Type, op.Path)
+	}
+
+	if dryRun {
+		fmt.Printf("📝 Dry run: %d file(s) would be synced\n", len(operations))
+		return nil
+	}
+
+	fmt.Printf("🔄 Syncing %d file(s)...\n", len(operations))
+	return reportSync(syncEngine.SyncOperations(ctx, operations))
 }
 
 // CreateListCommand creates the list command
@@ -282,7 +454,7 @@ func (c *CLI) CreateListCommand() *cobra.Command {
 // handleList processes the list command
 func (c *CLI) handleList(ctx context.Context, folderID string) error {
 	// Check authentication
-	token, err := c.database.GetAuthToken()
+	token, err := c.tokens.GetAuthToken()
 	if err != nil {
 		return fmt.Errorf("failed to get auth token: %w", err)
 	}
@@ -292,7 +464,7 @@ func (c *CLI) handleList(ctx context.Context, folderID string) error {
 	}
 
 	// Create API client
-	apiClient := api.NewClient(token)
+	apiClient := api.NewClientWithConfig(token, c.config)
 
 	// Get limit from flags
 	limit := 50 // Default value would be set from command flags in real implementation
@@ -335,6 +507,31 @@ func (c *CLI) handleList(ctx context.Context, folderID string) error {
 	return nil
 }
 
+// printTransferMetrics prints one period of the status metrics
+func printTransferMetrics(label string, metrics *types.TransferMetrics) {
+	if metrics == nil {
+		fmt.Printf("   %s: none yet\n", label)
+		return
+	}
+	fmt.Printf("   %s: ⬆️  %s in %d file(s), ⬇️  %s in %d file(s)\n", label,
+		formatFileSize(metrics.BytesUp), metrics.FilesUp, formatFileSize(metrics.BytesDown), metrics.FilesDown)
+	if metrics.Files() > 0 {
+		fmt.Printf("      %s/s, %.1f files/s\n", formatFileSize(int64(metrics.BytesPerSecond())), metrics.FilesPerSecond())
+	}
+	if count := metrics.ErrorCount(); count > 0 {
+		errorTypes := make([]string, 0, len(metrics.Errors))
+		for errorType := range metrics.Errors {
+			errorTypes = append(errorTypes, errorType)
+		}
+		sort.Strings(errorTypes)
+		parts := make([]string, 0, len(errorTypes))
+		for _, errorType := range errorTypes {
+			parts = append(parts, fmt.Sprintf("%s %d", errorType, metrics.Errors[errorType]))
+		}
+		fmt.Printf("      ❌ %d error(s): %s\n", count, strings.Join(parts, ", "))
+	}
+}
+
 // formatFileSize formats file size in human-readable format
 func formatFileSize(size int64) string {
 	const unit = 1024
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// addConcurrencyFlag adds the --concurrency flag to a command that transfers
+// files
+func addConcurrencyFlag(cmd *cobra.Command) {
+	cmd.Flags().Int("concurrency", 0, "Maximum number of concurrent transfers for this run (overrides sync.max_concurrent_syncs)")
+}
+
+// applyConcurrency overrides sync.max_concurrent_syncs for this run if
+// --concurrency was given. It must run before the backend and engine are
+// created, as both size themselves from the config.
+func (c *CLI) applyConcurrency(cmd *cobra.Command) error {
+	if !cmd.Flags().Changed("concurrency") {
+		return nil
+	}
+	concurrency, err := cmd.Flags().GetInt("concurrency")
+	if err != nil {
+		return err
+	}
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1, got %d", concurrency)
+	}
+	c.config.Sync.MaxConcurrentSyncs = concurrency
+	return nil
+}
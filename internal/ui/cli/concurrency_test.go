@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTransferCLI returns a logged in CLI whose config allows maxConcurrent
+// transfers, and a function returning the most uploads its API ever had in
+// progress at once
+func newTransferCLI(t *testing.T, maxConcurrent int) (*CLI, func() int) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	var mu stdsync.Mutex
+	uploads, inFlight, peak := 0, 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/files":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"data":{"id":"folder1","type":"folder"}}`))
+		case r.URL.Path == "/api/v1/upload/initiate":
+			mu.Lock()
+			uploads++
+			id := uploads
+			mu.Unlock()
+			fmt.Fprintf(w, `{"data":{"upload_id":"upload%d"}}`, id)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/upload/"):
+			mu.Lock()
+			inFlight++
+			if inFlight > peak {
+				peak = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			fmt.Fprintf(w, `{"data":{"id":"%s"}}`, strings.TrimPrefix(r.URL.Path, "/api/v1/upload/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	require.NoError(t, database.SaveAuthToken(&types.TokenInfo{
+		AccessToken: "access",
+		TokenType:   "Bearer",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}))
+
+	config := &types.Config{
+		API:  types.APIConfig{Host: server.URL, BasePath: "/api", Version: "v1"},
+		Sync: types.SyncConfig{MaxConcurrentSyncs: maxConcurrent},
+	}
+	c := &CLI{config: config, database: database, tokens: database, logger: utils.GetLogger()}
+	return c, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return peak
+	}
+}
+
+// folderOfFiles creates a directory holding n small files
+func folderOfFiles(t *testing.T, n int) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "project")
+	require.NoError(t, os.Mkdir(dir, 0755))
+	for i := 0; i < n; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.txt", i)), []byte(fmt.Sprintf("content %d", i)), 0644))
+	}
+	return dir
+}
+
+func TestConcurrencyFlagCapsTransfers(t *testing.T) {
+	run := func(c *CLI, args ...string) error {
+		cmd := c.CreatePushCommand()
+		cmd.SetArgs(args)
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+		return cmd.ExecuteContext(context.Background())
+	}
+
+	// Without the flag the config decides
+	c, peak := newTransferCLI(t, 4)
+	require.NoError(t, run(c, folderOfFiles(t, 12)))
+	assert.Equal(t, 4, peak())
+
+	// The flag overrides it for the run
+	c, peak = newTransferCLI(t, 4)
+	require.NoError(t, run(c, folderOfFiles(t, 12), "--concurrency", "2"))
+	assert.Equal(t, 2, peak())
+	assert.Equal(t, 2, c.config.Sync.MaxConcurrentSyncs)
+
+	c, _ = newTransferCLI(t, 4)
+	err := run(c, folderOfFiles(t, 1), "--concurrency", "0")
+	assert.ErrorContains(t, err, "--concurrency must be at least 1")
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// CreateConfigCommand creates the config command
+func (c *CLI) CreateConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Copy the configuration and sync folders between machines",
+	}
+
+	export := &cobra.Command{
+		Use:   "export <file>",
+		Short: "Write the configuration to a file for another machine",
+		Long: `Write the configuration, including the sync folders, to a file that
+'zohosync-cli config import' reads. Secrets are left out unless
+--include-secrets is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			includeSecrets, _ := cmd.Flags().GetBool("include-secrets")
+			return c.handleConfigExport(args[0], includeSecrets)
+		},
+	}
+	export.Flags().Bool("include-secrets", false, "Include the client secret, SMTP password, encryption passphrase and web UI token")
+
+	imp := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Apply a configuration exported on another machine",
+		Long: `Apply a file written by 'zohosync-cli config export', or a plain config.yaml.
+Settings it sets replace the current ones and its folders are added to
+the current folders. With --replace, the file replaces the configuration
+and settings it does not set take their defaults.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			replace, _ := cmd.Flags().GetBool("replace")
+			return c.handleConfigImport(args[0], replace)
+		},
+	}
+	imp.Flags().Bool("replace", false, "Replace the configuration instead of merging into it")
+
+	cmd.AddCommand(export, imp)
+	return cmd
+}
+
+// handleConfigExport writes the configuration to path
+func (c *CLI) handleConfigExport(path string, includeSecrets bool) error {
+	if err := config.ExportConfig(c.config, path, includeSecrets); err != nil {
+		return err
+	}
+
+	fmt.Printf("📦 Exported the configuration and %d sync folder(s) to %s\n", len(c.config.Folders), path)
+	if includeSecrets {
+		fmt.Println("⚠️  The file contains secrets; keep it private")
+	}
+	return nil
+}
+
+// handleConfigImport applies the configuration in path and saves it
+func (c *CLI) handleConfigImport(path string, replace bool) error {
+	imported, err := config.ImportConfig(c.config, path, replace)
+	if err != nil {
+		return err
+	}
+	if err := config.SaveConfig(imported); err != nil {
+		return err
+	}
+	*c.config = *imported
+
+	fmt.Printf("✅ Imported %s: %d sync folder(s) configured\n", path, len(imported.Folders))
+	fmt.Println("   Restart the daemon to apply the new configuration")
+	return nil
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// CreateConflictsCommand creates the conflicts command
+func (c *CLI) CreateConflictsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "conflicts",
+		Short: "Show files that changed on both sides",
+		Long: `List conflicts between local and remote changes, most recent first,
+with which copy won and the hash, size and modification time of both sides.
+Use --resolved to only show conflicts a side was chosen for.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resolved, _ := cmd.Flags().GetBool("resolved")
+			limit, _ := cmd.Flags().GetInt("limit")
+			return c.handleConflicts(resolved, limit)
+		},
+	}
+
+	cmd.Flags().Bool("resolved", false, "Only show resolved conflicts")
+	cmd.Flags().Int("limit", 50, "Maximum number of conflicts to show (0 for all)")
+	return cmd
+}
+
+// handleConflicts prints the conflict log
+func (c *CLI) handleConflicts(resolvedOnly bool, limit int) error {
+	conflicts, err := c.database.GetConflicts(resolvedOnly, limit)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		fmt.Println("✅ No conflicts recorded")
+		return nil
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Printf("⚔️  %s\n", conflict.Path)
+		fmt.Printf("   Detected: %s\n", conflict.DetectedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("   Outcome:  %s\n", conflictOutcome(&conflict))
+		printConflictSide("Local: ", conflict.LocalHash, conflict.LocalSize, conflict.LocalModified)
+		printConflictSide("Remote:", conflict.RemoteHash, conflict.RemoteSize, conflict.RemoteModified)
+		if conflict.CopyPath != "" {
+			fmt.Printf("   Copy:     %s\n", conflict.CopyPath)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// conflictOutcome describes how a conflict was resolved
+func conflictOutcome(conflict *types.ConflictInfo) string {
+	if !conflict.Resolved() {
+		return "unresolved, needs manual resolution"
+	}
+	how := "manually"
+	if conflict.AutoResolved {
+		how = "automatically"
+	}
+	return fmt.Sprintf("%s copy kept (%s, %s)", conflict.Winner, how, conflict.Strategy)
+}
+
+// printConflictSide prints the state of one side of a conflict
+func printConflictSide(label, hash string, size int64, modified time.Time) {
+	if hash == "" {
+		hash = "unknown"
+	}
+	fmt.Printf("   %s  %d bytes, modified %s, hash %s\n", label, size, modified.Format("2006-01-02 15:04:05"), hash)
+}
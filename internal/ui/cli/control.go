@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/spf13/cobra"
+)
+
+// CreatePauseCommand creates the pause command
+func (c *CLI) CreatePauseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause",
+		Short: "Pause synchronization in the running daemon",
+		Long:  "Suspend transfers in the running daemon without stopping it. Changes are still recorded and synced after resume.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.sendControlCommand("pause", "⏸️  Sync paused")
+		},
+	}
+}
+
+// CreateResumeCommand creates the resume command
+func (c *CLI) CreateResumeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "Resume synchronization in the running daemon",
+		Long:  "Resume transfers in the running daemon and sync any changes recorded while paused",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.sendControlCommand("resume", "▶️  Sync resumed")
+		},
+	}
+}
+
+// CreateCancelCommand creates the cancel command
+func (c *CLI) CreateCancelCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel <path>",
+		Short: "Cancel the in-flight transfer of a file",
+		Long:  "Abort the upload or download of a single file in the running daemon. The file stays pending and is retried on a later sync.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.handleCancel(args[0])
+		},
+	}
+}
+
+// handleCancel asks the daemon to cancel the transfer of path
+func (c *CLI) handleCancel(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	_, err = control.Send(control.DefaultSocketPath(), control.Request{
+		Command: "cancel",
+		Args:    map[string]string{"path": absPath},
+	})
+	if errors.Is(err, control.ErrDaemonNotRunning) {
+		fmt.Println("🔌 The ZohoSync daemon is not running")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🛑 Cancelled transfer of %s\n", absPath)
+	fmt.Println("   It will be retried on the next sync")
+	return nil
+}
+
+// sendControlCommand sends a command to the daemon and reports the new state
+func (c *CLI) sendControlCommand(command, message string) error {
+	resp, err := control.Send(control.DefaultSocketPath(), control.Request{Command: command})
+	if errors.Is(err, control.ErrDaemonNotRunning) {
+		fmt.Println("🔌 The ZohoSync daemon is not running")
+		fmt.Println("   Start it with 'zohosync-daemon' and try again")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s failed: %w", command, err)
+	}
+
+	fmt.Println(message)
+	fmt.Printf("   Sync state: %s\n", resp.State)
+	return nil
+}
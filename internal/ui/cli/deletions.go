@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateDeletionsCommand creates the deletions command
+func (c *CLI) CreateDeletionsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deletions",
+		Short: "Review remote deletions waiting for confirmation",
+		Long: `List the removed files and folders whose remote copies the running daemon
+is holding back because together they hold more than sync.delete_threshold
+files, and ask whether to delete them from WorkDrive. Rejecting the deletions
+downloads them again.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			yes, _ := cmd.Flags().GetBool("yes")
+			reject, _ := cmd.Flags().GetBool("reject")
+			if yes && reject {
+				return fmt.Errorf("--yes and --reject cannot be used together")
+			}
+			return c.handleDeletions(yes, reject)
+		},
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Confirm the deletions without asking")
+	cmd.Flags().Bool("reject", false, "Reject the deletions and restore the files and folders")
+	return cmd
+}
+
+// handleDeletions lists the held back deletions and confirms or rejects them
+func (c *CLI) handleDeletions(yes, reject bool) error {
+	resp, err := control.Send(control.DefaultSocketPath(), control.Request{Command: "deletions"})
+	if errors.Is(err, control.ErrDaemonNotRunning) {
+		fmt.Println("🔌 The ZohoSync daemon is not running")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list deletions: %w", err)
+	}
+
+	var pending []sync.PendingDeletion
+	if err := json.Unmarshal(resp.Data, &pending); err != nil {
+		return fmt.Errorf("failed to decode deletions: %w", err)
+	}
+	if len(pending) == 0 {
+		fmt.Println("✅ No deletions are waiting for confirmation")
+		return nil
+	}
+
+	total := 0
+	fmt.Println("🗑️  Removed files and folders waiting to be deleted from WorkDrive:")
+	for _, deletion := range pending {
+		if deletion.IsDirectory {
+			fmt.Printf("   %s (%d files)\n", deletion.Path, deletion.Files)
+		} else {
+			fmt.Printf("   %s\n", deletion.Path)
+		}
+		total += deletion.Files
+	}
+
+	if !yes && !reject {
+		fmt.Printf("   Delete these %d files from WorkDrive? [y/N]: ", total)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		yes = answer == "y" || answer == "yes"
+		reject = !yes
+	}
+
+	if reject {
+		resp, err := control.Send(control.DefaultSocketPath(), control.Request{Command: "reject-deletions"})
+		if err != nil {
+			return fmt.Errorf("failed to reject deletions: %w", err)
+		}
+		var paths []string
+		json.Unmarshal(resp.Data, &paths)
+		fmt.Printf("↩️  Deletions rejected; restoring %d file(s) from WorkDrive\n", len(paths))
+		return nil
+	}
+
+	resp, err = control.Send(control.DefaultSocketPath(), control.Request{Command: "confirm-deletions"})
+	if err != nil {
+		return fmt.Errorf("failed to confirm deletions: %w", err)
+	}
+	var deleted int
+	json.Unmarshal(resp.Data, &deleted)
+	fmt.Printf("✅ Deleted %d file(s) and folder(s) from WorkDrive\n", deleted)
+	return nil
+}
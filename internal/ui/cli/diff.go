@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// CreateDiffCommand creates the diff command
+func (c *CLI) CreateDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff [folder]",
+		Short: "Show what differs between local and remote files",
+		Long: `Compare a sync folder, or every enabled one, with WorkDrive and list the
+files that only exist locally, only exist remotely, were modified on one
+side, or conflict. Nothing is transferred.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+			folder := ""
+			if len(args) > 0 {
+				folder = args[0]
+			}
+			return c.handleDiff(cmd.Context(), folder, asJSON)
+		},
+	}
+
+	cmd.Flags().Bool("json", false, "Print the differences as JSON")
+	return cmd
+}
+
+// handleDiff prints the differences for the selected sync folders
+func (c *CLI) handleDiff(ctx context.Context, path string, asJSON bool) error {
+	folders, err := c.diffFolders(path)
+	if err != nil {
+		return err
+	}
+
+	backend, err := c.syncBackend()
+	if err != nil {
+		return err
+	}
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
+
+	diffs := make([]*sync.FolderDiff, 0, len(folders))
+	for _, folder := range folders {
+		diff, err := syncEngine.Diff(ctx, folder)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, diff)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diffs)
+	}
+
+	for _, diff := range diffs {
+		printFolderDiff(diff)
+	}
+	return nil
+}
+
+// diffFolders returns the configured sync folder at path, or every enabled
+// one if path is empty
+func (c *CLI) diffFolders(path string) ([]types.FolderConfig, error) {
+	if path == "" {
+		var folders []types.FolderConfig
+		for _, folder := range c.config.Folders {
+			if folder.Enabled {
+				folders = append(folders, folder)
+			}
+		}
+		if len(folders) == 0 {
+			return nil, fmt.Errorf("no sync folders configured - run 'zohosync-cli setup' first")
+		}
+		return folders, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+	for _, folder := range c.config.Folders {
+		if filepath.Clean(folder.Local) == absPath {
+			return []types.FolderConfig{folder}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a configured sync folder", absPath)
+}
+
+// printFolderDiff prints one folder's differences grouped by category
+func printFolderDiff(diff *sync.FolderDiff) {
+	fmt.Printf("📁 %s -> %s\n", diff.Local, diff.Remote)
+	if len(diff.Entries) == 0 {
+		fmt.Println("   ✅ In sync")
+		fmt.Println()
+		return
+	}
+
+	categories := []sync.DiffCategory{sync.DiffOnlyLocal, sync.DiffOnlyRemote, sync.DiffModified, sync.DiffConflict, sync.DiffTypeChanged}
+	for _, category := range categories {
+		for _, entry := range diff.Entries {
+			if entry.Category != category {
+				continue
+			}
+			name := entry.Path
+			if entry.IsDirectory {
+				name += "/"
+			}
+			fmt.Printf("   %-12s %s%s\n", category, name, diffDetail(&entry))
+		}
+	}
+
+	fmt.Printf("   %d only local, %d only remote, %d modified, %d conflicts",
+		diff.Count(sync.DiffOnlyLocal), diff.Count(sync.DiffOnlyRemote),
+		diff.Count(sync.DiffModified), diff.Count(sync.DiffConflict))
+	if changed := diff.Count(sync.DiffTypeChanged); changed > 0 {
+		fmt.Printf(", %d changed type", changed)
+	}
+	fmt.Print("\n\n")
+}
+
+// diffDetail describes the size and modification time deltas of a file
+// present on both sides
+func diffDetail(entry *sync.DiffEntry) string {
+	switch entry.Category {
+	case sync.DiffModified:
+		return fmt.Sprintf(" (%s changed, %+d bytes, local mtime %s)", entry.Changed, entry.SizeDelta, signedDuration(entry.ModTimeDelta))
+	case sync.DiffConflict:
+		return fmt.Sprintf(" (local %d bytes, remote %d bytes, local mtime %s)", entry.LocalSize, entry.RemoteSize, signedDuration(entry.ModTimeDelta))
+	case sync.DiffTypeChanged:
+		if entry.IsDirectory {
+			return " (folder locally, file remotely)"
+		}
+		return " (file locally, folder remotely)"
+	default:
+		return ""
+	}
+}
+
+// signedDuration formats how far the local modification time is ahead of
+// the remote one
+func signedDuration(d time.Duration) string {
+	if d >= 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
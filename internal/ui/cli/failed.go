@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/spf13/cobra"
+)
+
+// CreateFailedCommand creates the failed command
+func (c *CLI) CreateFailedCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "failed",
+		Short: "List files set aside after failing to sync",
+		Long: `List the files that failed to sync sync.retry_budget times in a row, with
+the last error of each. They are left out of sync cycles until
+'zohosync-cli retry-failed' re-queues them.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.handleFailed()
+		},
+	}
+}
+
+// handleFailed prints the files set aside as failed
+func (c *CLI) handleFailed() error {
+	files, err := c.database.GetFailedFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("✅ No files have been set aside")
+		return nil
+	}
+
+	fmt.Printf("❌ %d file(s) stopped syncing after repeated failures:\n\n", len(files))
+	for _, file := range files {
+		fmt.Printf("%s\n", file.Path)
+		fmt.Printf("   Attempts: %d, last at %s\n", file.Attempts, file.FailedAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("   Error: %s\n", file.LastError)
+		fmt.Println()
+	}
+	fmt.Println("Fix the cause, then run 'zohosync-cli retry-failed [path]' to sync them again")
+	return nil
+}
+
+// CreateRetryFailedCommand creates the retry-failed command
+func (c *CLI) CreateRetryFailedCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "retry-failed [path]",
+		Short: "Re-queue files set aside after failing to sync",
+		Long:  "Give a failed file, or every failed file, a fresh retry budget so the next sync tries it again",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) > 0 {
+				abs, err := filepath.Abs(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid path: %w", err)
+				}
+				path = abs
+			}
+			return c.handleRetryFailed(path)
+		},
+	}
+}
+
+// handleRetryFailed re-queues failed files through the daemon, which syncs
+// them right away, or directly in the database if it is not running
+func (c *CLI) handleRetryFailed(path string) error {
+	var paths []string
+	if path != "" {
+		paths = append(paths, path)
+	}
+
+	var retried int
+	resp, err := control.Send(control.DefaultSocketPath(), control.Request{
+		Command: "retry-failed",
+		Args:    map[string]string{"path": path},
+	})
+	switch {
+	case errors.Is(err, control.ErrDaemonNotRunning):
+		if retried, err = c.database.RetryFailedFiles(paths...); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("retry failed: %w", err)
+	default:
+		if err := json.Unmarshal(resp.Data, &retried); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	if retried == 0 {
+		fmt.Println("✅ No failed files to retry")
+		return nil
+	}
+	fmt.Printf("🔁 Re-queued %d file(s); they are synced on the next cycle\n", retried)
+	return nil
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateLocalTrashCommand creates the local-trash command
+func (c *CLI) CreateLocalTrashCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "local-trash",
+		Short: "List local files moved to the trash because they were deleted remotely",
+		Long: `List the files in the local trash: local copies of files that were deleted
+in WorkDrive, kept in .zohosync-trash/ in their sync folder for
+sync.local_trash_retention days. Use 'local-trash restore <path>' to put one back.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.handleLocalTrash()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "restore <path>",
+		Short: "Move a file or folder from the local trash back to where it was",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid path: %w", err)
+			}
+			return c.handleLocalTrashRestore(path)
+		},
+	})
+	return cmd
+}
+
+// handleLocalTrash prints the files in the local trash
+func (c *CLI) handleLocalTrash() error {
+	files, err := sync.ListLocalTrash(c.config.Folders)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Println("🗑️  The local trash is empty")
+		return nil
+	}
+
+	fmt.Printf("🗑️  %d file(s) in the local trash:\n\n", len(files))
+	for _, file := range files {
+		fmt.Printf("%s\n", file.Path)
+		fmt.Printf("   Trashed %s, %d bytes\n", file.TrashedAt.Local().Format("2006-01-02 15:04:05"), file.Size)
+	}
+	fmt.Println()
+	fmt.Println("Run 'zohosync-cli local-trash restore <path>' to put a file back")
+	return nil
+}
+
+// handleLocalTrashRestore moves a file out of the local trash
+func (c *CLI) handleLocalTrashRestore(path string) error {
+	if err := sync.RestoreFromLocalTrash(c.config.Folders, path); err != nil {
+		return err
+	}
+	fmt.Printf("♻️  Restored %s\n", path)
+	return nil
+}
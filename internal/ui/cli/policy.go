@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateSetPolicyCommand creates the set-policy command
+func (c *CLI) CreateSetPolicyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "set-policy <path> <policy>",
+		Short: "Pin how conflicts on a file or folder are resolved",
+		Long: `Pin a conflict policy on a file or folder, overriding sync.conflict_resolution
+for it and everything under it:
+
+  prefer-local   the local copy always wins
+  prefer-remote  the remote copy always wins
+  manual         conflicts are left for manual resolution
+  default        remove the pinned policy and use the global strategy
+
+A policy pinned on a file wins over one pinned on its folders.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, err := filepath.Abs(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid path: %w", err)
+			}
+			policy, err := sync.ParseConflictPolicy(args[1])
+			if err != nil {
+				return err
+			}
+			return c.handleSetPolicy(path, policy)
+		},
+	}
+}
+
+// handleSetPolicy pins a conflict policy on a path
+func (c *CLI) handleSetPolicy(path, policy string) error {
+	if err := c.database.SetConflictPolicy(path, policy); err != nil {
+		return err
+	}
+	if policy == "" {
+		fmt.Printf("📌 Conflicts on %s use the global strategy (%s)\n", path, c.config.Sync.ConflictResolution)
+		return nil
+	}
+	fmt.Printf("📌 Conflicts on %s are resolved with %s\n", path, policy)
+	return nil
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/setup"
+	"github.com/spf13/cobra"
+)
+
+// CreatePresetsCommand creates the presets command
+func (c *CLI) CreatePresetsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "presets",
+		Short: "Show the folder presets 'setup --preset' can apply",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the folder presets and their settings",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printPresets(os.Stdout, setup.Presets())
+			return nil
+		},
+	})
+	return cmd
+}
+
+// printPresets prints the presets and the settings each one applies
+func printPresets(out io.Writer, presets []setup.Preset) {
+	for _, preset := range presets {
+		fmt.Fprintf(out, "🧩 %s: %s\n", preset.Name, preset.Description)
+		if preset.Interval > 0 {
+			fmt.Fprintf(out, "   Interval: %ds\n", preset.Interval)
+		}
+		if len(preset.IncludeExtensions) > 0 {
+			fmt.Fprintf(out, "   Include: %s\n", strings.Join(preset.IncludeExtensions, ", "))
+		}
+		if preset.ChunkSize > 0 {
+			fmt.Fprintf(out, "   Chunk size: %d MiB\n", preset.ChunkSize)
+		}
+		if len(preset.Ignore) > 0 {
+			fmt.Fprintf(out, "   .syncignore: %s\n", strings.Join(preset.Ignore, ", "))
+		}
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run 'zohosync-cli setup --preset <name>' to add a folder with a preset.")
+	fmt.Fprintln(out, "Its settings are copied into the folder's configuration, where they can be changed.")
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateReindexCommand creates the reindex command
+func (c *CLI) CreateReindexCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reindex [folder]",
+		Short: "Rebuild the local file index",
+		Long: `Drop the recorded state of every tracked file, or of those in one sync
+folder, and rebuild it by walking the folder again and matching it against
+WorkDrive. Files that are the same on both sides are not transferred again;
+the rest are synced by the next sync. Use it when the index no longer matches
+the disk, for example after moving files around while ZohoSync was stopped.
+Your login and configuration are kept.
+
+The daemon must be stopped while reindexing.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			folder := ""
+			if len(args) == 1 {
+				absPath, err := filepath.Abs(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid path: %w", err)
+				}
+				folder = absPath
+			}
+			yes, _ := cmd.Flags().GetBool("yes")
+			return c.handleReindex(cmd, folder, yes)
+		},
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "Rebuild the index without asking")
+	return cmd
+}
+
+// handleReindex confirms and rebuilds the file index of folder, or of every
+// sync folder if it is empty
+func (c *CLI) handleReindex(cmd *cobra.Command, folder string, yes bool) error {
+	_, err := control.Send(control.DefaultSocketPath(), control.Request{Command: "status"})
+	if err == nil {
+		return fmt.Errorf("the ZohoSync daemon is running; stop it before reindexing")
+	}
+	if !errors.Is(err, control.ErrDaemonNotRunning) {
+		return fmt.Errorf("failed to check for the daemon: %w", err)
+	}
+
+	if !yes {
+		target := "every sync folder"
+		if folder != "" {
+			target = folder
+		}
+		fmt.Printf("⚠️  This drops the recorded state of %s and rebuilds it from disk.\n", target)
+		fmt.Print("   Continue? [y/N]: ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Println("❌ Reindex cancelled")
+			return nil
+		}
+	}
+
+	backend, err := c.syncBackend()
+	if err != nil {
+		return err
+	}
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
+
+	indexed, err := syncEngine.Reindex(cmd.Context(), folder, func(indexed int) {
+		fmt.Printf("\r🔍 Indexed %d entries", indexed)
+	})
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("reindex failed: %w", err)
+	}
+
+	fmt.Printf("✅ Rebuilt the index with %d entries; run 'zohosync-cli sync' to sync the differences\n", indexed)
+	return nil
+}
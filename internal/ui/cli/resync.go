@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateResyncCommand creates the resync command
+func (c *CLI) CreateResyncCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resync <path>",
+		Short: "Force a file or folder to sync again",
+		Long: `Mark a file, or every file under a directory, as pending and sync it
+immediately even if it looks unchanged. Use --direction to force a re-upload
+(up) or re-download (down) instead of the usual conflict resolution.
+
+The running daemon performs the resync if there is one.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, _ := cmd.Flags().GetString("direction")
+			direction, err := sync.ParseResyncDirection(value)
+			if err != nil {
+				return err
+			}
+			return c.handleResync(cmd.Context(), args[0], direction)
+		},
+	}
+
+	cmd.Flags().String("direction", "", "Force the transfer direction (up or down)")
+	return cmd
+}
+
+// handleResync resyncs path through the daemon, or in this process if the
+// daemon is not running
+func (c *CLI) handleResync(ctx context.Context, path string, direction sync.ResyncDirection) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	resp, err := control.Send(control.DefaultSocketPath(), control.Request{
+		Command: "resync",
+		Args:    map[string]string{"path": absPath, "direction": string(direction)},
+	})
+	if err == nil {
+		var paths []string
+		json.Unmarshal(resp.Data, &paths)
+		fmt.Printf("🔁 Resyncing %d file(s) under %s in the daemon\n", len(paths), absPath)
+		return nil
+	}
+	if !errors.Is(err, control.ErrDaemonNotRunning) {
+		return fmt.Errorf("resync failed: %w", err)
+	}
+
+	backend, err := c.syncBackend()
+	if err != nil {
+		return err
+	}
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
+
+	paths, err := syncEngine.MarkResync(absPath, direction)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🔁 Resyncing %d file(s) under %s...\n", len(paths), absPath)
+	syncEngine.SyncPaths(ctx, paths)
+
+	failed := 0
+	for _, p := range paths {
+		metadata, err := c.database.GetFileMetadata(p)
+		if err != nil || metadata == nil || metadata.SyncStatus != "synced" {
+			failed++
+			fmt.Printf("   ❌ %s\n", p)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) failed to resync", failed)
+	}
+	fmt.Println("✅ Resync completed")
+	return nil
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/auth"
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/internal/setup"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/spf13/cobra"
+)
+
+// CreateSetupCommand creates the setup command
+func (c *CLI) CreateSetupCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "setup",
+		Short: "Add a sync folder with a guided wizard",
+		Long: `Choose a local folder, a WorkDrive folder and a sync mode, then run the initial
+scan. Run it again to add more folders. --preset applies one of the presets
+listed by 'zohosync-cli presets list' to the new folder.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			presetName, _ := cmd.Flags().GetString("preset")
+			return c.handleSetup(cmd.Context(), presetName)
+		},
+	}
+
+	cmd.Flags().String("preset", "", "Preset for the new folder (documents, code or photos)")
+	return cmd
+}
+
+// handleSetup processes the setup command
+func (c *CLI) handleSetup(ctx context.Context, presetName string) error {
+	var preset *setup.Preset
+	if presetName != "" {
+		var err error
+		if preset, err = setup.FindPreset(presetName); err != nil {
+			return err
+		}
+	}
+
+	token, err := c.tokens.GetAuthToken()
+	if err != nil {
+		return fmt.Errorf("failed to get auth token: %w", err)
+	}
+	if token == nil {
+		return fmt.Errorf("not authenticated - run 'zohosync-cli login' first")
+	}
+
+	oauthClient := auth.NewOAuthClient(c.config)
+	if !oauthClient.ValidateToken(token) {
+		return fmt.Errorf("authentication token expired - run 'zohosync-cli login'")
+	}
+
+	apiClient := api.NewClientWithConfig(token, c.config)
+
+	folder, err := setup.RunWizard(ctx, setup.Deps{
+		Prompter:   newTerminalPrompter(os.Stdin, os.Stdout),
+		Remote:     apiClient,
+		Config:     c.config,
+		SaveConfig: config.SaveConfig,
+		Scan: func(ctx context.Context, folder types.FolderConfig) error {
+			// Created once the folder is configured, so its filters apply
+			syncEngine := sync.NewEngine(apiClient, c.database, c.config)
+			_, err := syncEngine.ScanFolder(ctx, folder.Local, func(scanned int) {
+				fmt.Printf("\r🔍 Scanned %d entries", scanned)
+			})
+			fmt.Println()
+			return err
+		},
+		Preset: preset,
+	})
+	if errors.Is(err, setup.ErrCancelled) {
+		fmt.Println("Setup cancelled, nothing was changed")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Added %s -> %s\n", folder.Local, folder.Remote)
+	fmt.Println("   Run 'zohosync-cli sync' or start the daemon to begin syncing")
+	return nil
+}
+
+// terminalPrompter implements setup.Prompter on a line-based terminal
+type terminalPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// newTerminalPrompter creates a prompter reading answers from in
+func newTerminalPrompter(in io.Reader, out io.Writer) *terminalPrompter {
+	return &terminalPrompter{in: bufio.NewReader(in), out: out}
+}
+
+// Info prints a message
+func (t *terminalPrompter) Info(message string) {
+	fmt.Fprintln(t.out, message)
+}
+
+// Input reads a line, returning defaultValue for an empty answer
+func (t *terminalPrompter) Input(question, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(t.out, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(t.out, "%s: ", question)
+	}
+
+	answer, err := t.readLine()
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
+
+// Select prints numbered options and reads the chosen number
+func (t *terminalPrompter) Select(question string, options []string) (int, error) {
+	fmt.Fprintln(t.out, question)
+	for i, option := range options {
+		fmt.Fprintf(t.out, "  %d) %s\n", i+1, option)
+	}
+
+	for {
+		fmt.Fprint(t.out, "Choice: ")
+		answer, err := t.readLine()
+		if err != nil {
+			return 0, err
+		}
+
+		choice, err := strconv.Atoi(answer)
+		if err == nil && choice >= 1 && choice <= len(options) {
+			return choice - 1, nil
+		}
+		fmt.Fprintf(t.out, "Please enter a number between 1 and %d\n", len(options))
+	}
+}
+
+// readLine reads one trimmed line, treating end of input as cancellation
+func (t *terminalPrompter) readLine() (string, error) {
+	line, err := t.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", setup.ErrCancelled
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/auth"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreatePullCommand creates the pull command
+func (c *CLI) CreatePullCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pull <folder-id> <local-dir>",
+		Short: "Download a remote folder recursively",
+		Long:  "Download every file in a remote folder and its subfolders into a local directory. Files already downloaded and unchanged are skipped.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.applyConcurrency(cmd); err != nil {
+				return err
+			}
+			return c.handlePull(cmd.Context(), args[0], args[1])
+		},
+	}
+
+	addConcurrencyFlag(cmd)
+	return cmd
+}
+
+// handlePull downloads a remote folder into localDir
+func (c *CLI) handlePull(ctx context.Context, folderID, localDir string) error {
+	absDir, err := filepath.Abs(localDir)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	backend, err := c.syncBackend()
+	if err != nil {
+		return err
+	}
+
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
+	syncEngine.SetProgressFunc(folderProgress())
+
+	fmt.Printf("⬇️  Downloading folder %s to %s...\n", folderID, absDir)
+	result, err := syncEngine.DownloadFolder(ctx, folderID, absDir)
+	if result != nil {
+		printSyncResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download folder: %w", err)
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d file(s) failed to download", result.Failed)
+	}
+	return nil
+}
+
+// CreatePushCommand creates the push command
+func (c *CLI) CreatePushCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "push <local-dir>",
+		Short: "Upload a local folder recursively",
+		Long:  "Upload a local directory and everything beneath it as a new remote folder. Running it again only uploads files that changed.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := c.applyConcurrency(cmd); err != nil {
+				return err
+			}
+			parentID, _ := cmd.Flags().GetString("parent")
+			return c.handlePush(cmd.Context(), args[0], parentID)
+		},
+	}
+
+	cmd.Flags().String("parent", "root", "ID of the remote folder to upload into")
+	addConcurrencyFlag(cmd)
+	return cmd
+}
+
+// handlePush uploads localDir into the remote folder parentID
+func (c *CLI) handlePush(ctx context.Context, localDir, parentID string) error {
+	absDir, err := filepath.Abs(localDir)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+
+	backend, err := c.syncBackend()
+	if err != nil {
+		return err
+	}
+
+	syncEngine := sync.NewEngine(backend, c.database, c.config)
+	syncEngine.SetProgressFunc(folderProgress())
+
+	fmt.Printf("⬆️  Uploading %s to folder %s...\n", absDir, parentID)
+	result, err := syncEngine.UploadFolder(ctx, absDir, parentID)
+	if result != nil {
+		printSyncResult(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload folder: %w", err)
+	}
+	if result.Failed > 0 {
+		return fmt.Errorf("%d file(s) failed to upload", result.Failed)
+	}
+	return nil
+}
+
+// syncBackend returns the remote backend selected by the config. A local
+// remote needs no login; otherwise this is the authenticated Zoho client.
+func (c *CLI) syncBackend() (api.RemoteBackend, error) {
+	if api.IsLocalRemote(c.config) {
+		return api.NewBackend(c.config, nil)
+	}
+	return c.authenticatedClient()
+}
+
+// authenticatedClient returns an API client for the stored token, failing
+// if the user is not logged in or the token has expired
+func (c *CLI) authenticatedClient() (*api.Client, error) {
+	token, err := c.tokens.GetAuthToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth token: %w", err)
+	}
+
+	if token == nil {
+		return nil, fmt.Errorf("not authenticated - run 'zohosync-cli login' first")
+	}
+
+	oauthClient := auth.NewOAuthClient(c.config)
+	if !oauthClient.ValidateToken(token) {
+		return nil, fmt.Errorf("authentication token expired - run 'zohosync-cli login'")
+	}
+
+	return api.NewClientWithConfig(token, c.config), nil
+}
+
+// folderProgress returns the progress callback of a folder transfer. Large
+// transfers print a line per percent rather than one per file, but still
+// print every failure.
+func folderProgress() sync.ProgressFunc {
+	return sync.NewProgressNotifier(printProgress, sync.DefaultProgressThresholds).ProgressFunc()
+}
+
+// printProgress prints a folder transfer progress update
+func printProgress(info sync.ProgressInfo) {
+	if info.Err != nil {
+		fmt.Printf("   [%d/%d] ❌ %s: %v\n", info.Done, info.Total, info.Path, info.Err)
+		return
+	}
+	fmt.Printf("   [%d/%d] %s\n", info.Done, info.Total, info.Path)
+}
+
+// printSyncResult prints the summary of a folder transfer
+func printSyncResult(result *sync.SyncResult) {
+	fmt.Printf("📊 Finished in %s\n", result.Duration.Round(time.Millisecond))
+	if result.Downloaded > 0 {
+		fmt.Printf("   Downloaded: %d\n", result.Downloaded)
+	}
+	if result.Uploaded > 0 {
+		fmt.Printf("   Uploaded: %d\n", result.Uploaded)
+	}
+	if result.Renamed > 0 {
+		fmt.Printf("   Renamed: %d\n", result.Renamed)
+	}
+	fmt.Printf("   Skipped: %d\n", result.Skipped)
+	fmt.Printf("   Failed: %d\n", result.Failed)
+	fmt.Printf("   Transferred: %s\n", formatFileSize(result.Bytes))
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/spf13/cobra"
+)
+
+// CreateWatchesCommand creates the watches command
+func (c *CLI) CreateWatchesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watches",
+		Short: "List the folders the running daemon watches for changes",
+		Long: `List the directories the running daemon watches for local changes, and the
+folders it could not watch, e.g. because the system's inotify watch limit
+was reached. Changes in those folders go unnoticed; raise the limit
+(fs.inotify.max_user_watches) and run 'zohosync-cli watches --retry'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			retry, _ := cmd.Flags().GetBool("retry")
+			return c.handleWatches(os.Stdout, retry)
+		},
+	}
+
+	cmd.Flags().Bool("retry", false, "Try again to watch the folders that could not be watched")
+	return cmd
+}
+
+// handleWatches asks the daemon for its watch list and prints it
+func (c *CLI) handleWatches(out io.Writer, retry bool) error {
+	req := control.Request{Command: "watches"}
+	if retry {
+		req.Args = map[string]string{"retry": "true"}
+	}
+	resp, err := control.Send(control.DefaultSocketPath(), req)
+	if errors.Is(err, control.ErrDaemonNotRunning) {
+		fmt.Fprintln(out, "🔌 The ZohoSync daemon is not running")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list watches: %w", err)
+	}
+
+	var status sync.WatchStatus
+	if err := json.Unmarshal(resp.Data, &status); err != nil {
+		return fmt.Errorf("failed to decode watches: %w", err)
+	}
+	printWatches(out, &status)
+	return nil
+}
+
+// printWatches prints the watched directories and the failed folders
+func printWatches(out io.Writer, status *sync.WatchStatus) {
+	if status.Limit > 0 {
+		fmt.Fprintf(out, "👀 Watching %d directories (system limit %d):\n", len(status.Watched), status.Limit)
+	} else {
+		fmt.Fprintf(out, "👀 Watching %d directories:\n", len(status.Watched))
+	}
+	for _, path := range status.Watched {
+		fmt.Fprintf(out, "   %s\n", path)
+	}
+
+	if len(status.Failed) == 0 {
+		return
+	}
+	limitReached := false
+	fmt.Fprintf(out, "\n⚠️  %d folder(s) could not be watched; changes in them go unnoticed:\n", len(status.Failed))
+	for _, failure := range status.Failed {
+		fmt.Fprintf(out, "   %s\n", failure.Path)
+		fmt.Fprintf(out, "      Error: %s\n", failure.Error)
+		limitReached = limitReached || failure.LimitReached
+	}
+	if limitReached {
+		fmt.Fprintln(out, "\nThe inotify watch limit was reached. Raise it, e.g. with")
+		fmt.Fprintln(out, "   sudo sysctl fs.inotify.max_user_watches=524288")
+		fmt.Fprintln(out, "then run 'zohosync-cli watches --retry'")
+		return
+	}
+	fmt.Fprintln(out, "\nFix the cause, then run 'zohosync-cli watches --retry'")
+}
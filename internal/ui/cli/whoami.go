@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/auth"
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// whoamiReport describes the logged in account and its token
+type whoamiReport struct {
+	User      *api.UserInfo `json:"user,omitempty"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	Expired   bool          `json:"expired"`
+	// Scopes is empty for tokens saved before their scope was recorded
+	Scopes        []string `json:"scopes"`
+	MissingScopes []string `json:"missing_scopes,omitempty"`
+	// ReadOnly is set if the scopes do not allow uploading local changes
+	ReadOnly bool   `json:"read_only,omitempty"`
+	Config   string `json:"config"`
+	API      string `json:"api"`
+}
+
+// CreateWhoamiCommand creates the whoami command
+func (c *CLI) CreateWhoamiCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "whoami",
+		Short: "Show the logged in account and its token",
+		Long: `Show the WorkDrive account ZohoSync is logged in as, when its token expires,
+the scopes it grants, and the configuration in use. Warns if a scope that
+syncing needs was not granted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+			return c.handleWhoami(cmd.Context(), os.Stdout, asJSON)
+		},
+	}
+
+	cmd.Flags().Bool("json", false, "Print the account and token details as JSON")
+	return cmd
+}
+
+// handleWhoami prints the logged in account
+func (c *CLI) handleWhoami(ctx context.Context, out io.Writer, asJSON bool) error {
+	report, err := c.whoami(ctx)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	printWhoami(out, report)
+	return nil
+}
+
+// whoami looks up the logged in account. An expired token is reported
+// without the account, which cannot be looked up with it.
+func (c *CLI) whoami(ctx context.Context) (*whoamiReport, error) {
+	token, err := c.tokens.GetAuthToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get auth token: %w", err)
+	}
+	if token == nil {
+		return nil, fmt.Errorf("not authenticated - run 'zohosync-cli login' first")
+	}
+
+	report := &whoamiReport{
+		ExpiresAt: token.ExpiresAt,
+		Expired:   !auth.NewOAuthClient(c.config).ValidateToken(token),
+		Scopes:    auth.ParseScopes(token.Scope),
+		ReadOnly:  !api.GrantsWrites(token.Scope),
+		Config:    config.LoadedConfigPath(),
+		API:       api.BaseURL(c.config.API),
+	}
+	if len(report.Scopes) > 0 {
+		requested, err := auth.RequestedScopes(c.config.Auth)
+		if err != nil {
+			return nil, err
+		}
+		report.MissingScopes = auth.MissingScopes(token.Scope, requested)
+	}
+	if report.Expired {
+		return report, nil
+	}
+
+	report.User, err = api.NewClientWithConfig(token, c.config).GetUserInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user info: %w", err)
+	}
+	return report, nil
+}
+
+// printWhoami prints a whoami report
+func printWhoami(out io.Writer, report *whoamiReport) {
+	if report.User != nil {
+		fmt.Fprintf(out, "👤 %s (%s)\n", report.User.DisplayName, report.User.Email)
+		fmt.Fprintf(out, "   User ID: %s\n", report.User.ID)
+	}
+
+	if report.Expired {
+		fmt.Fprintf(out, "🔐 Token expired %s\n", report.ExpiresAt.Format("2006-01-02 15:04:05"))
+		fmt.Fprintln(out, "   Run 'zohosync-cli login' to re-authenticate")
+	} else {
+		fmt.Fprintf(out, "🔐 Token expires: %s\n", report.ExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+
+	if len(report.Scopes) == 0 {
+		fmt.Fprintln(out, "🔑 Scopes: unknown (log in again to record them)")
+	} else {
+		fmt.Fprintf(out, "🔑 Scopes: %s\n", strings.Join(report.Scopes, ", "))
+	}
+	for _, scope := range report.MissingScopes {
+		fmt.Fprintf(out, "   ⚠️  Missing scope %s, which the configured scopes ask for; log in again to grant it\n", scope)
+	}
+	if report.ReadOnly {
+		fmt.Fprintln(out, "   📖 Read-only: local changes are not uploaded; set auth.scope_profile to full and log in again to sync them")
+	}
+
+	fmt.Fprintf(out, "⚙️  Config: %s\n", report.Config)
+	fmt.Fprintf(out, "🌐 API: %s\n", report.API)
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWhoamiCLI returns a CLI logged in with a token granting scope, whose
+// API serves a fixed user
+func newWhoamiCLI(t *testing.T, scope string) *CLI {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/users/me", r.URL.Path)
+		w.Write([]byte(`{"data":{"id":"u-42","email":"ada@example.com","display_name":"Ada Lovelace"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+	require.NoError(t, database.SaveAuthToken(&types.TokenInfo{
+		AccessToken: "access",
+		TokenType:   "Bearer",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Scope:       scope,
+	}))
+
+	config := &types.Config{API: types.APIConfig{Host: server.URL, BasePath: "/api", Version: "v1"}}
+	return &CLI{config: config, database: database, tokens: database, logger: utils.GetLogger()}
+}
+
+func TestWhoamiReportsUserAndMissingScope(t *testing.T) {
+	c := newWhoamiCLI(t, "WorkDrive.files.READ,WorkDrive.folders.ALL")
+
+	var out bytes.Buffer
+	require.NoError(t, c.handleWhoami(context.Background(), &out, false))
+	assert.Contains(t, out.String(), "Ada Lovelace (ada@example.com)")
+	assert.Contains(t, out.String(), "Missing scope WorkDrive.files.ALL")
+	assert.NotContains(t, out.String(), "Missing scope WorkDrive.folders.ALL")
+
+	out.Reset()
+	require.NoError(t, c.handleWhoami(context.Background(), &out, true))
+	var report whoamiReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	require.NotNil(t, report.User)
+	assert.Equal(t, "u-42", report.User.ID)
+	assert.False(t, report.Expired)
+	assert.Equal(t, []string{"WorkDrive.files.READ", "WorkDrive.folders.ALL"}, report.Scopes)
+	assert.Equal(t, []string{"WorkDrive.files.ALL"}, report.MissingScopes)
+}
+
+func TestWhoamiWithAllScopes(t *testing.T) {
+	c := newWhoamiCLI(t, "WorkDrive.files.ALL WorkDrive.folders.ALL")
+
+	var out bytes.Buffer
+	require.NoError(t, c.handleWhoami(context.Background(), &out, false))
+	assert.Contains(t, out.String(), "ada@example.com")
+	assert.NotContains(t, out.String(), "Missing scope")
+}
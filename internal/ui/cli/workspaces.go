@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/spf13/cobra"
+)
+
+// CreateWorkspacesCommand creates the workspaces command
+func (c *CLI) CreateWorkspacesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "workspaces",
+		Short: "List the WorkDrive workspaces you can sync",
+		Long: `List your personal drive and the team folders you have access to, with
+your permissions in each. To sync a folder in a workspace, set the remote
+of a sync folder to "<workspace>:/path/in/workspace".`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.handleWorkspaces(cmd.Context())
+		},
+	}
+}
+
+// handleWorkspaces prints the workspaces of the logged in user
+func (c *CLI) handleWorkspaces(ctx context.Context) error {
+	client, err := c.authenticatedClient()
+	if err != nil {
+		return err
+	}
+
+	workspaces, err := client.ListWorkspaces(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	if len(workspaces) == 0 {
+		fmt.Println("📂 No workspaces found")
+		return nil
+	}
+
+	fmt.Printf("🏢 %d workspace(s):\n\n", len(workspaces))
+	for _, workspace := range workspaces {
+		access := "read-only"
+		if workspace.Can(api.PermissionWrite) {
+			access = "read-write"
+		}
+		fmt.Printf("%s (%s)\n", workspace.Name, workspace.Type)
+		fmt.Printf("   ID: %s\n", workspace.ID)
+		fmt.Printf("   Access: %s (%s)\n", access, strings.Join(workspace.Permissions, ", "))
+		fmt.Printf("   Remote: %s:/\n", workspace.Name)
+		fmt.Println()
+	}
+	return nil
+}
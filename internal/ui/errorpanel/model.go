@@ -0,0 +1,203 @@
+// Package errorpanel holds the toolkit-independent model behind the GUI's
+// sync errors panel
+package errorpanel
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	gosync "sync"
+
+	"github.com/bdstest/zohosync/internal/sync"
+)
+
+// DefaultLimit is the number of recent errors kept for display
+const DefaultLimit = 50
+
+// Source is the part of the sync engine the panel depends on
+type Source interface {
+	Errors() <-chan *sync.SyncError
+	RetryFile(path string) error
+	SkipFailedFile(path string) error
+}
+
+// Entry is one error shown in the panel
+type Entry struct {
+	ID         int
+	Error      *sync.SyncError
+	Suggestion string
+}
+
+// Model collects recent sync errors and applies the user's chosen action
+type Model struct {
+	source   Source
+	limit    int
+	mu       gosync.Mutex
+	entries  []Entry
+	nextID   int
+	onChange func()
+}
+
+// NewModel creates a model reading from source and keeping up to limit errors
+func NewModel(source Source, limit int) *Model {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return &Model{source: source, limit: limit}
+}
+
+// OnChange registers a callback invoked whenever the entries change
+func (m *Model) OnChange(fn func()) {
+	m.mu.Lock()
+	m.onChange = fn
+	m.mu.Unlock()
+}
+
+// Run consumes the source's error stream until ctx is cancelled
+func (m *Model) Run(ctx context.Context) {
+	errs := m.source.Errors()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			m.Add(err)
+		}
+	}
+}
+
+// Add records an error, replacing any older entry for the same file and
+// discarding the oldest entries beyond the limit
+func (m *Model) Add(err *sync.SyncError) {
+	m.mu.Lock()
+	if err.FilePath != "" {
+		m.removeLocked(func(e Entry) bool { return e.Error.FilePath == err.FilePath })
+	}
+
+	m.nextID++
+	m.entries = append([]Entry{{ID: m.nextID, Error: err, Suggestion: Suggestion(err.Type)}}, m.entries...)
+	if len(m.entries) > m.limit {
+		m.entries = m.entries[:m.limit]
+	}
+	m.mu.Unlock()
+
+	m.changed()
+}
+
+// Entries returns the current errors, newest first
+func (m *Model) Entries() []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]Entry, len(m.entries))
+	copy(entries, m.entries)
+	return entries
+}
+
+// Retry asks the engine to sync the entry's file again and removes it
+func (m *Model) Retry(id int) error {
+	return m.resolve(id, m.source.RetryFile)
+}
+
+// Skip tells the engine to stop retrying the entry's file and removes it
+func (m *Model) Skip(id int) error {
+	return m.resolve(id, m.source.SkipFailedFile)
+}
+
+// Dismiss removes an entry without acting on it
+func (m *Model) Dismiss(id int) {
+	m.mu.Lock()
+	m.removeLocked(func(e Entry) bool { return e.ID == id })
+	m.mu.Unlock()
+	m.changed()
+}
+
+// Location returns the directory containing the entry's file
+func (m *Model) Location(id int) (string, error) {
+	entry, ok := m.find(id)
+	if !ok {
+		return "", fmt.Errorf("no such error: %d", id)
+	}
+	if entry.Error.FilePath == "" {
+		return "", fmt.Errorf("error is not associated with a file")
+	}
+	return filepath.Dir(entry.Error.FilePath), nil
+}
+
+// resolve applies action to the entry's file and removes the entry on success
+func (m *Model) resolve(id int, action func(path string) error) error {
+	entry, ok := m.find(id)
+	if !ok {
+		return fmt.Errorf("no such error: %d", id)
+	}
+	if entry.Error.FilePath == "" {
+		return fmt.Errorf("error is not associated with a file")
+	}
+
+	if err := action(entry.Error.FilePath); err != nil {
+		return err
+	}
+
+	m.Dismiss(id)
+	return nil
+}
+
+// find returns the entry with the given ID
+func (m *Model) find(id int) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, entry := range m.entries {
+		if entry.ID == id {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// removeLocked drops entries matching fn; m.mu must be held
+func (m *Model) removeLocked(fn func(Entry) bool) {
+	kept := m.entries[:0]
+	for _, entry := range m.entries {
+		if !fn(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	m.entries = kept
+}
+
+// changed notifies the registered callback
+func (m *Model) changed() {
+	m.mu.Lock()
+	fn := m.onChange
+	m.mu.Unlock()
+
+	if fn != nil {
+		fn()
+	}
+}
+
+// Suggestion returns a short hint on how to fix an error of the given type
+func Suggestion(t sync.ErrorType) string {
+	switch t {
+	case sync.ErrorTypeAuth:
+		return "Sign in to Zoho WorkDrive again"
+	case sync.ErrorTypePermission:
+		return "Check that you have access to this file or folder"
+	case sync.ErrorTypeQuota:
+		return "Free up space in WorkDrive or wait for the rate limit to reset"
+	case sync.ErrorTypeStorageFull:
+		return "Free up space in WorkDrive; uploads resume on the next sync"
+	case sync.ErrorTypeNetwork, sync.ErrorTypeTimeout:
+		return "Check your internet connection and retry"
+	case sync.ErrorTypeConflict:
+		return "Resolve the conflicting change and retry"
+	case sync.ErrorTypeValidation:
+		return "Rename or fix the file, then retry"
+	default:
+		return "Retry, or skip this file"
+	}
+}
@@ -0,0 +1,89 @@
+package errorpanel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubEngine emits scripted errors and records the actions taken
+type stubEngine struct {
+	errs    chan *sync.SyncError
+	retried []string
+	skipped []string
+}
+
+func (s *stubEngine) Errors() <-chan *sync.SyncError { return s.errs }
+
+func (s *stubEngine) RetryFile(path string) error {
+	s.retried = append(s.retried, path)
+	return nil
+}
+
+func (s *stubEngine) SkipFailedFile(path string) error {
+	s.skipped = append(s.skipped, path)
+	return nil
+}
+
+func TestModelReflectsEngineErrors(t *testing.T) {
+	engine := &stubEngine{errs: make(chan *sync.SyncError, 2)}
+	model := NewModel(engine, 0)
+
+	changes := make(chan struct{}, 10)
+	model.OnChange(func() { changes <- struct{}{} })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go model.Run(ctx)
+
+	engine.errs <- sync.NewSyncErrorWithFile(sync.ErrorTypeAuth, "sync", "/home/u/Docs/a.txt", "token expired", errors.New("401"))
+	engine.errs <- sync.NewSyncErrorWithFile(sync.ErrorTypeNetwork, "sync", "/home/u/Docs/b.txt", "connection reset", errors.New("reset"))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-changes:
+		case <-time.After(5 * time.Second):
+			t.Fatal("model did not receive errors")
+		}
+	}
+
+	entries := model.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/home/u/Docs/b.txt", entries[0].Error.FilePath, "newest first")
+	assert.Equal(t, sync.ErrorTypeNetwork, entries[0].Error.Type)
+	assert.Equal(t, "connection reset", entries[0].Error.Message)
+	assert.Equal(t, sync.ErrorTypeAuth, entries[1].Error.Type)
+	assert.Equal(t, "Sign in to Zoho WorkDrive again", entries[1].Suggestion)
+
+	location, err := model.Location(entries[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "/home/u/Docs", location)
+
+	require.NoError(t, model.Retry(entries[0].ID))
+	require.NoError(t, model.Skip(entries[1].ID))
+	assert.Equal(t, []string{"/home/u/Docs/b.txt"}, engine.retried)
+	assert.Equal(t, []string{"/home/u/Docs/a.txt"}, engine.skipped)
+	assert.Empty(t, model.Entries())
+	assert.Error(t, model.Retry(entries[0].ID))
+}
+
+func TestModelKeepsLatestErrorPerFileWithinLimit(t *testing.T) {
+	model := NewModel(&stubEngine{}, 2)
+
+	model.Add(sync.NewSyncErrorWithFile(sync.ErrorTypeNetwork, "sync", "/a", "first", nil))
+	model.Add(sync.NewSyncErrorWithFile(sync.ErrorTypeTimeout, "sync", "/a", "second", nil))
+	assert.Len(t, model.Entries(), 1)
+	assert.Equal(t, "second", model.Entries()[0].Error.Message)
+
+	model.Add(sync.NewSyncErrorWithFile(sync.ErrorTypeQuota, "sync", "/b", "quota", nil))
+	model.Add(sync.NewSyncErrorWithFile(sync.ErrorTypeQuota, "sync", "/c", "quota", nil))
+	entries := model.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "/c", entries[0].Error.FilePath)
+	assert.Equal(t, "/b", entries[1].Error.FilePath)
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/bdstest/zohosync/internal/api"
 	"github.com/bdstest/zohosync/internal/auth"
+	"github.com/bdstest/zohosync/internal/config"
 	"github.com/bdstest/zohosync/internal/storage"
 	"github.com/bdstest/zohosync/internal/utils"
 	"github.com/bdstest/zohosync/pkg/types"
@@ -23,6 +24,7 @@ type AuthWindow struct {
 	window    fyne.Window
 	config    *types.Config
 	database  *storage.Database
+	tokens    auth.TokenStore
 	logger    *utils.Logger
 	onSuccess func(*types.TokenInfo)
 }
@@ -33,6 +35,7 @@ func NewAuthWindow(parent fyne.Window, config *types.Config, database *storage.D
 		window:    parent,
 		config:    config,
 		database:  database,
+		tokens:    auth.NewTokenStore(config.Auth, database),
 		logger:    utils.GetLogger(),
 		onSuccess: onSuccess,
 	}
@@ -41,7 +44,7 @@ func NewAuthWindow(parent fyne.Window, config *types.Config, database *storage.D
 // Show displays the authentication window
 func (a *AuthWindow) Show() {
 	// Check if already authenticated
-	existingToken, err := a.database.GetAuthToken()
+	existingToken, err := a.tokens.GetAuthToken()
 	if err == nil && existingToken != nil {
 		oauthClient := auth.NewOAuthClient(a.config)
 		if oauthClient.ValidateToken(existingToken) {
@@ -130,12 +133,25 @@ func (a *AuthWindow) showLoginForm() {
 
 // handleLogin processes the OAuth login flow
 func (a *AuthWindow) handleLogin() {
+	if err := config.ValidateCredentials(a.config.Auth); err != nil {
+		a.showError("ZohoSync is not configured for login", err)
+		return
+	}
+
 	// Create OAuth client
 	oauthClient := auth.NewOAuthClient(a.config)
+	oauthClient.SetStateStore(a.database)
+
+	// Bind the callback port first; the authorization URL names it
+	if err := oauthClient.ListenCallback(); err != nil {
+		a.showError("Cannot receive the login callback", err)
+		return
+	}
 
 	// Generate auth URL
 	authURL, err := oauthClient.GetAuthURL()
 	if err != nil {
+		oauthClient.CloseCallback()
 		a.showError("Failed to generate authentication URL", err)
 		return
 	}
@@ -158,17 +174,11 @@ func (a *AuthWindow) handleLogin() {
 			return
 		}
 
-		// Save token
-		if err := a.database.SaveAuthToken(token); err != nil {
-			a.showError("Failed to save authentication token", err)
-			return
-		}
-
-		// Verify token by getting user info
-		apiClient := api.NewClient(token)
-		userInfo, err := apiClient.GetUserInfo(ctx)
+		// Verify the account and save the token, keeping existing sync state
+		apiClient := api.NewClientWithConfig(token, a.config)
+		userInfo, err := auth.CompleteLogin(ctx, a.database, a.tokens, apiClient, token, a.confirmAccountSwitch)
 		if err != nil {
-			a.showError("Failed to verify authentication", err)
+			a.showError("Login was not completed", err)
 			return
 		}
 
@@ -177,6 +187,19 @@ func (a *AuthWindow) handleLogin() {
 	}()
 }
 
+// confirmAccountSwitch asks whether to continue with a different account
+// than the one the sync state belongs to, blocking until the user answers
+func (a *AuthWindow) confirmAccountSwitch(stored, new string) bool {
+	answer := make(chan bool, 1)
+	message := fmt.Sprintf("You logged in as %s, but ZohoSync has been syncing %s.\n\n"+
+		"Your sync folders and file state will be kept, but they refer to the other account.\n"+
+		"Continue with the new account?", new, stored)
+	dialog.ShowConfirm("Different Account", message, func(ok bool) {
+		answer <- ok
+	}, a.window)
+	return <-answer
+}
+
 // showLoginProgress displays the login progress dialog
 func (a *AuthWindow) showLoginProgress(authURL string) *dialog.CustomDialog {
 	progressBar := widget.NewProgressBarInfinite()
@@ -221,9 +244,18 @@ func (a *AuthWindow) showLoginSuccess(userInfo *api.UserInfo, token *types.Token
 			),
 		),
 		widget.NewButton("Continue", func() {
-			if a.onSuccess != nil {
-				a.onSuccess(token)
+			finish := func() {
+				if a.onSuccess != nil {
+					a.onSuccess(token)
+				}
+			}
+
+			// First login: help the user pick a folder before starting
+			if len(a.config.Folders) == 0 {
+				a.runSetupWizard(token, finish)
+				return
 			}
+			finish()
 		}),
 	)
 
@@ -0,0 +1,119 @@
+package gui
+
+import (
+	"fmt"
+	"net/url"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/internal/ui/errorpanel"
+)
+
+// ErrorsPanel lists recent sync errors with per-error actions
+type ErrorsPanel struct {
+	model    *errorpanel.Model
+	window   fyne.Window
+	onReauth func()
+	list     *widget.List
+	entries  []errorpanel.Entry
+}
+
+// NewErrorsPanel creates a panel bound to model. onReauth is offered for
+// authentication errors in place of a plain retry.
+func NewErrorsPanel(model *errorpanel.Model, window fyne.Window, onReauth func()) *ErrorsPanel {
+	p := &ErrorsPanel{
+		model:    model,
+		window:   window,
+		onReauth: onReauth,
+		entries:  model.Entries(),
+	}
+
+	p.list = widget.NewList(
+		func() int { return len(p.entries) },
+		p.createRow,
+		p.updateRow,
+	)
+
+	model.OnChange(func() {
+		p.entries = model.Entries()
+		p.list.Refresh()
+	})
+
+	return p
+}
+
+// Content returns the panel's canvas object
+func (p *ErrorsPanel) Content() fyne.CanvasObject {
+	header := widget.NewLabelWithStyle("Sync Errors", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	return container.NewBorder(header, nil, nil, nil, p.list)
+}
+
+// createRow builds an empty error row template
+func (p *ErrorsPanel) createRow() fyne.CanvasObject {
+	title := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	message := widget.NewLabel("")
+	message.Wrapping = fyne.TextWrapWord
+	suggestion := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+
+	actions := container.NewHBox(
+		widget.NewButton("Retry", nil),
+		widget.NewButton("Skip", nil),
+		widget.NewButton("Open Location", nil),
+	)
+
+	return container.NewVBox(title, message, suggestion, actions)
+}
+
+// updateRow fills a row template with the entry at index i
+func (p *ErrorsPanel) updateRow(i widget.ListItemID, row fyne.CanvasObject) {
+	if i >= len(p.entries) {
+		return
+	}
+	entry := p.entries[i]
+
+	parts := row.(*fyne.Container).Objects
+	parts[0].(*widget.Label).SetText(fmt.Sprintf("⚠️ %s error: %s", entry.Error.Type, entry.Error.FilePath))
+	parts[1].(*widget.Label).SetText(entry.Error.Message)
+	parts[2].(*widget.Label).SetText("💡 " + entry.Suggestion)
+
+	buttons := parts[3].(*fyne.Container).Objects
+	retry := buttons[0].(*widget.Button)
+	skip := buttons[1].(*widget.Button)
+	open := buttons[2].(*widget.Button)
+
+	if entry.Error.Type == sync.ErrorTypeAuth && p.onReauth != nil {
+		retry.SetText("Sign In Again")
+		retry.OnTapped = func() {
+			p.model.Dismiss(entry.ID)
+			p.onReauth()
+		}
+	} else {
+		retry.SetText("Retry")
+		retry.OnTapped = func() { p.act(p.model.Retry, entry.ID) }
+	}
+	skip.OnTapped = func() { p.act(p.model.Skip, entry.ID) }
+	open.OnTapped = func() { p.openLocation(entry.ID) }
+}
+
+// act runs a model action and reports failures
+func (p *ErrorsPanel) act(action func(id int) error, id int) {
+	if err := action(id); err != nil {
+		dialog.ShowError(err, p.window)
+	}
+}
+
+// openLocation opens the folder containing the entry's file
+func (p *ErrorsPanel) openLocation(id int) {
+	dir, err := p.model.Location(id)
+	if err != nil {
+		dialog.ShowError(err, p.window)
+		return
+	}
+	if err := fyne.CurrentApp().OpenURL(&url.URL{Scheme: "file", Path: dir}); err != nil {
+		dialog.ShowError(err, p.window)
+	}
+}
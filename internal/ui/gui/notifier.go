@@ -0,0 +1,51 @@
+package gui
+
+import (
+	"context"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/notify"
+)
+
+// desktopNotifier shows sync alerts as desktop notifications
+type desktopNotifier struct {
+	tray *SystemTray
+}
+
+// Notify displays the event through the system tray
+func (d desktopNotifier) Notify(ctx context.Context, event notify.Event) error {
+	d.tray.showNotification(event.Title, event.Message)
+	return nil
+}
+
+// deletionPrompt asks in a dialog whether remote deletions held back by the
+// sync engine should go ahead
+type deletionPrompt struct {
+	tray *SystemTray
+}
+
+// Notify shows the dialog for delete confirmation events
+func (d deletionPrompt) Notify(ctx context.Context, event notify.Event) error {
+	if event.Kind == notify.KindDeleteConfirmation {
+		d.tray.confirmDeletions()
+	}
+	return nil
+}
+
+// notifier combines desktop notifications, when enabled, with any webhook or
+// email alerts from the configuration, and always prompts for held back
+// deletions
+func (st *SystemTray) notifier() notify.Notifier {
+	backends := notify.Multi{deletionPrompt{tray: st}}
+	if st.config.UI.ShowNotifications {
+		interval := time.Duration(st.config.Notifications.RateLimit) * time.Second
+		if interval <= 0 {
+			interval = notify.DefaultRateLimit
+		}
+		backends = append(backends, notify.NewRateLimited(desktopNotifier{tray: st}, interval))
+	}
+	if remote := notify.FromConfig(st.config.Notifications); remote != nil {
+		backends = append(backends, remote)
+	}
+	return backends
+}
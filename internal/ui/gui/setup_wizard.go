@@ -0,0 +1,116 @@
+package gui
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/bdstest/zohosync/internal/api"
+	"github.com/bdstest/zohosync/internal/config"
+	"github.com/bdstest/zohosync/internal/setup"
+	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// runSetupWizard walks a newly authenticated user through adding a sync
+// folder and calls done once the wizard finishes or is cancelled
+func (a *AuthWindow) runSetupWizard(token *types.TokenInfo, done func()) {
+	go func() {
+		defer done()
+
+		apiClient := api.NewClientWithConfig(token, a.config)
+		syncEngine := sync.NewEngine(apiClient, a.database, a.config)
+
+		_, err := setup.RunWizard(context.Background(), setup.Deps{
+			Prompter:   &dialogPrompter{window: a.window},
+			Remote:     apiClient,
+			Config:     a.config,
+			SaveConfig: config.SaveConfig,
+			Scan: func(ctx context.Context, folder types.FolderConfig) error {
+				_, err := syncEngine.ScanFolder(ctx, folder.Local, func(scanned int) {
+					a.logger.Infof("Scanned %d entries of %s", scanned, folder.Local)
+				})
+				return err
+			},
+		})
+		if errors.Is(err, setup.ErrCancelled) {
+			a.logger.Info("Setup wizard cancelled")
+			return
+		}
+		if err != nil {
+			a.logger.Errorf("Setup wizard failed: %v", err)
+			dialog.ShowError(err, a.window)
+		}
+	}()
+}
+
+// dialogPrompter implements setup.Prompter with blocking Fyne dialogs.
+// Informational messages are shown at the top of the next dialog.
+type dialogPrompter struct {
+	window fyne.Window
+	notes  []string
+}
+
+// Info queues a message for the next dialog
+func (d *dialogPrompter) Info(message string) {
+	d.notes = append(d.notes, message)
+}
+
+// Input shows a text entry dialog
+func (d *dialogPrompter) Input(question, defaultValue string) (string, error) {
+	entry := widget.NewEntry()
+	entry.SetText(defaultValue)
+
+	ok := d.ask(question, entry)
+	if !ok {
+		return "", setup.ErrCancelled
+	}
+	if strings.TrimSpace(entry.Text) == "" {
+		return defaultValue, nil
+	}
+	return entry.Text, nil
+}
+
+// Select shows a list of options and returns the chosen index
+func (d *dialogPrompter) Select(question string, options []string) (int, error) {
+	radio := widget.NewRadioGroup(options, nil)
+	radio.SetSelected(options[0])
+
+	scroll := container.NewVScroll(radio)
+	scroll.SetMinSize(fyne.NewSize(400, 250))
+
+	if !d.ask(question, scroll) {
+		return 0, setup.ErrCancelled
+	}
+	for i, option := range options {
+		if option == radio.Selected {
+			return i, nil
+		}
+	}
+	return 0, nil
+}
+
+// ask shows a modal dialog with any queued notes and blocks until the user
+// answers, reporting whether they confirmed
+func (d *dialogPrompter) ask(question string, input fyne.CanvasObject) bool {
+	content := container.NewVBox()
+	for _, note := range d.notes {
+		content.Add(widget.NewLabel(note))
+	}
+	d.notes = nil
+	content.Add(widget.NewLabel(question))
+	content.Add(input)
+
+	answered := make(chan bool, 1)
+	confirm := dialog.NewCustomConfirm("Set Up ZohoSync", "Next", "Cancel", content, func(ok bool) {
+		answered <- ok
+	}, d.window)
+	confirm.Show()
+
+	return <-answered
+}
@@ -4,16 +4,21 @@ package gui
 import (
 	"context"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/systray"
 
 	"github.com/bdstest/zohosync/internal/api"
 	"github.com/bdstest/zohosync/internal/storage"
 	"github.com/bdstest/zohosync/internal/sync"
+	"github.com/bdstest/zohosync/internal/ui/errorpanel"
 	"github.com/bdstest/zohosync/internal/utils"
 	"github.com/bdstest/zohosync/pkg/types"
 )
@@ -25,9 +30,18 @@ type SystemTray struct {
 	config     *types.Config
 	database   *storage.Database
 	syncEngine *sync.Engine
+	apiClient  *api.Client
+	errorModel *errorpanel.Model
 	token      *types.TokenInfo
 	logger     *utils.Logger
 	isRunning  bool
+
+	// detectTray reports why no system tray can be shown, if it can't
+	detectTray func() error
+	// runTray runs the system tray until it quits
+	runTray    func(onReady, onExit func())
+	available  atomic.Bool
+	lastStatus string
 }
 
 // NewSystemTray creates a new system tray instance
@@ -39,6 +53,9 @@ func NewSystemTray(app fyne.App, window fyne.Window, config *types.Config, datab
 		database: database,
 		token:    token,
 		logger:   utils.GetLogger(),
+
+		detectTray: detectTray,
+		runTray:    systray.Run,
 	}
 }
 
@@ -49,24 +66,51 @@ func (st *SystemTray) Start() error {
 	}
 
 	// Initialize sync engine
-	apiClient := api.NewClient(st.token)
-	st.syncEngine = sync.NewEngine(apiClient, st.database, st.config)
+	st.apiClient = api.NewClientWithConfig(st.token, st.config)
+	st.syncEngine = sync.NewEngine(st.apiClient, st.database, st.config)
+	st.errorModel = errorpanel.NewModel(st.syncEngine, errorpanel.DefaultLimit)
+	go st.errorModel.Run(context.Background())
 
 	// Start sync engine
 	if err := st.syncEngine.Start(context.Background()); err != nil {
 		return fmt.Errorf("failed to start sync engine: %w", err)
 	}
 
-	// Initialize system tray
-	go func() {
-		systray.Run(st.onTrayReady, st.onTrayExit)
-	}()
-
 	st.isRunning = true
+
+	// Without a tray the engine keeps running and its status is logged
+	if err := st.detectTray(); err != nil {
+		st.logger.Warnf("System tray unavailable, running without it: %v", err)
+		go st.updateTrayStatus()
+		return nil
+	}
+
+	st.available.Store(true)
+	go st.startTray()
 	st.logger.Info("System tray started")
 	return nil
 }
 
+// startTray runs the system tray, falling back to no-tray mode if it fails
+// to initialize
+func (st *SystemTray) startTray() {
+	defer func() {
+		if r := recover(); r != nil {
+			st.available.Store(false)
+			st.logger.Warnf("System tray failed, running without it: %v", r)
+			go st.updateTrayStatus()
+		}
+	}()
+	st.runTray(st.onTrayReady, st.onTrayExit)
+}
+
+// Available reports whether the system tray is shown. It is false before
+// Start and when no tray could be shown, in which case sync still runs and
+// notifications are logged instead.
+func (st *SystemTray) Available() bool {
+	return st.available.Load()
+}
+
 // Stop stops the system tray and sync engine
 func (st *SystemTray) Stop() error {
 	if !st.isRunning {
@@ -77,7 +121,9 @@ func (st *SystemTray) Stop() error {
 		st.syncEngine.Stop()
 	}
 
-	systray.Quit()
+	if st.available.Swap(false) {
+		systray.Quit()
+	}
 	st.isRunning = false
 	st.logger.Info("System tray stopped")
 	return nil
@@ -97,6 +143,7 @@ func (st *SystemTray) onTrayReady() {
 	
 	mSync := systray.AddMenuItem("🔄 Sync Now", "Trigger manual sync")
 	mPause := systray.AddMenuItem("⏸️ Pause Sync", "Pause synchronization")
+	mErrors := systray.AddMenuItem("⚠️ Errors", "Show recent sync errors")
 	systray.AddSeparator()
 	
 	mSettings := systray.AddMenuItem("⚙️ Settings", "Open settings")
@@ -120,6 +167,8 @@ func (st *SystemTray) onTrayReady() {
 				st.triggerManualSync()
 			case <-mPause.ClickedCh:
 				st.toggleSyncPause()
+			case <-mErrors.ClickedCh:
+				st.showErrors()
 			case <-mSettings.ClickedCh:
 				st.showSettings()
 			case <-mAbout.ClickedCh:
@@ -144,11 +193,13 @@ func (st *SystemTray) createTrayIcon() []byte {
 	return []byte{}
 }
 
-// updateTrayStatus updates the tray tooltip with current status
+// updateTrayStatus updates the tray tooltip with current status, or logs
+// it when there is no tray
 func (st *SystemTray) updateTrayStatus() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
+	st.refreshTrayStatus()
 	for {
 		select {
 		case <-ticker.C:
@@ -176,6 +227,37 @@ func (st *SystemTray) refreshTrayStatus() {
 		tooltip += fmt.Sprintf("\nLast sync: %s", status.LastSync.Format("15:04:05"))
 	}
 
+	if status.State == types.SyncStateOffline {
+		tooltip += "\nOffline: changes are queued until WorkDrive is reachable"
+	}
+
+	if status.Concurrency > 0 {
+		tooltip += fmt.Sprintf("\nConcurrency: %d", status.Concurrency)
+	}
+
+	if folderStats, err := st.database.GetFolderStats(); err != nil {
+		st.logger.Errorf("Failed to get folder stats: %v", err)
+	} else {
+		for _, folder := range st.config.Folders {
+			if !folder.Enabled {
+				continue
+			}
+			folderStatus := folderStats[filepath.Clean(folder.Local)]
+			tooltip += fmt.Sprintf("\n%s: %d/%d", filepath.Base(folder.Local), folderStatus.SyncedFiles, folderStatus.TotalFiles)
+			if !folderStatus.LastSync.IsZero() {
+				tooltip += folderStatus.LastSync.Format(" (15:04:05)")
+			}
+		}
+	}
+
+	if !st.Available() {
+		// Only changes are logged, not every refresh
+		if tooltip != st.lastStatus {
+			st.lastStatus = tooltip
+			st.logger.Info(strings.ReplaceAll(tooltip, "\n", ", "))
+		}
+		return
+	}
 	systray.SetTooltip(tooltip)
 }
 
@@ -252,8 +334,9 @@ func (st *SystemTray) showAbout() {
 
 // showNotification displays a system notification
 func (st *SystemTray) showNotification(title, message string) {
-	// Check if desktop notifications are supported
-	if deskApp, ok := st.app.(desktop.App); ok {
+	// Check if desktop notifications are supported, which without a tray
+	// they are not
+	if deskApp, ok := st.app.(desktop.App); ok && st.Available() {
 		if deskApp.SendNotification != nil {
 			notification := &fyne.Notification{
 				Title:   title,
@@ -271,9 +354,76 @@ func (st *SystemTray) showNotification(title, message string) {
 // SetSyncEngine updates the sync engine reference
 func (st *SystemTray) SetSyncEngine(engine *sync.Engine) {
 	st.syncEngine = engine
+	if notifier := st.notifier(); engine != nil && notifier != nil {
+		engine.SetNotifier(notifier)
+	}
 }
 
 // IsRunning returns whether the system tray is running
 func (st *SystemTray) IsRunning() bool {
 	return st.isRunning
-}
\ No newline at end of file
+}
+
+// confirmDeletions asks whether to delete the removed files and folders the
+// sync engine is holding back from WorkDrive, or to download them again
+func (st *SystemTray) confirmDeletions() {
+	if st.syncEngine == nil {
+		return
+	}
+	pending := st.syncEngine.PendingDeletions()
+	if len(pending) == 0 {
+		return
+	}
+
+	var message strings.Builder
+	total := 0
+	message.WriteString("These files and folders were removed locally:\n\n")
+	for _, deletion := range pending {
+		if deletion.IsDirectory {
+			fmt.Fprintf(&message, "%s (%d files)\n", deletion.Path, deletion.Files)
+		} else {
+			fmt.Fprintf(&message, "%s\n", deletion.Path)
+		}
+		total += deletion.Files
+	}
+	fmt.Fprintf(&message, "\nDelete these %d files from WorkDrive too? If not, they are downloaded again.", total)
+
+	dialog.ShowConfirm("Confirm Deletions", message.String(), func(ok bool) {
+		go func() {
+			ctx := context.Background()
+			if ok {
+				if _, err := st.syncEngine.ConfirmDeletions(ctx); err != nil {
+					st.logger.Errorf("Failed to confirm deletions: %v", err)
+				}
+				return
+			}
+			paths, err := st.syncEngine.RejectDeletions()
+			if err != nil {
+				st.logger.Errorf("Failed to reject deletions: %v", err)
+				return
+			}
+			st.syncEngine.SyncPaths(ctx, paths)
+		}()
+	}, st.window)
+}
+
+// showErrors opens the sync errors panel
+func (st *SystemTray) showErrors() {
+	if st.errorModel == nil {
+		return
+	}
+
+	window := st.app.NewWindow("ZohoSync Errors")
+	panel := NewErrorsPanel(st.errorModel, window, func() {
+		NewAuthWindow(window, st.config, st.database, func(token *types.TokenInfo) {
+			st.token = token
+			st.apiClient.SetToken(token)
+			st.syncEngine.TriggerSync()
+			window.Close()
+		}).Show()
+	})
+
+	window.SetContent(panel.Content())
+	window.Resize(fyne.NewSize(600, 400))
+	window.Show()
+}
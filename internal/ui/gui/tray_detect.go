@@ -0,0 +1,26 @@
+package gui
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// detectTray checks for what the system tray needs on Linux: a display to
+// show it on, and a D-Bus session bus to reach the desktop's tray host
+// through. systray.Run gives no error without them, it just never shows.
+func detectTray() error {
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return errors.New("no display: neither $DISPLAY nor $WAYLAND_DISPLAY is set")
+	}
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") != "" {
+		return nil
+	}
+	// Systemd user sessions put the bus here without setting the variable
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		if _, err := os.Stat(filepath.Join(runtimeDir, "bus")); err == nil {
+			return nil
+		}
+	}
+	return errors.New("no D-Bus session bus: $DBUS_SESSION_BUS_ADDRESS is not set")
+}
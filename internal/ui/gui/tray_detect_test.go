@@ -0,0 +1,79 @@
+package gui
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/test"
+	"github.com/bdstest/zohosync/internal/storage"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTray returns a tray whose engine talks to a local test server
+func newTestTray(t *testing.T) *SystemTray {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	database, err := storage.NewDatabase(filepath.Join(t.TempDir(), "zohosync.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { database.Close() })
+
+	app := test.NewApp()
+	config := &types.Config{API: types.APIConfig{Host: server.URL}}
+	tray := NewSystemTray(app, app.NewWindow("ZohoSync"), config, database, &types.TokenInfo{AccessToken: "test_token"})
+	t.Cleanup(func() { tray.Stop() })
+	return tray
+}
+
+func TestStartWithoutTrayRunsEngine(t *testing.T) {
+	tray := newTestTray(t)
+	tray.detectTray = func() error { return errors.New("no display") }
+	tray.runTray = func(onReady, onExit func()) {
+		t.Error("the tray must not be run without a display")
+	}
+
+	require.NoError(t, tray.Start())
+	assert.True(t, tray.IsRunning())
+	assert.True(t, tray.syncEngine.IsRunning(), "sync runs without a tray")
+	assert.False(t, tray.Available())
+
+	// Notifications are logged rather than sent
+	tray.showNotification("Sync Started", "Manual synchronization triggered")
+}
+
+func TestStartFallsBackWhenTrayFails(t *testing.T) {
+	tray := newTestTray(t)
+	tray.detectTray = func() error { return nil }
+	tray.runTray = func(onReady, onExit func()) {
+		panic("failed to connect to the status notifier host")
+	}
+
+	require.NoError(t, tray.Start())
+	assert.True(t, tray.syncEngine.IsRunning())
+	assert.Eventually(t, func() bool { return !tray.Available() }, time.Second, 10*time.Millisecond)
+}
+
+func TestDetectTray(t *testing.T) {
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+	assert.ErrorContains(t, detectTray(), "no display")
+
+	t.Setenv("DISPLAY", ":0")
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	assert.ErrorContains(t, detectTray(), "D-Bus")
+
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path=/run/user/1000/bus")
+	assert.NoError(t, detectTray())
+}
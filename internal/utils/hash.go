@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+)
+
+// Supported file hashing algorithms
+const (
+	HashSHA256 = "sha256"
+	HashMD5    = "md5"
+)
+
+// DefaultHashAlgorithm is used when no algorithm is configured
+const DefaultHashAlgorithm = HashSHA256
+
+// NewHash returns a fresh hasher for the named algorithm, falling back to
+// the default when name is empty
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", HashSHA256:
+		return sha256.New(), nil
+	case HashMD5:
+		return md5.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm: %s", algorithm)
+	}
+}
+
+// HashLength returns the length of a hex-encoded digest for the algorithm
+func HashLength(algorithm string) (int, error) {
+	h, err := NewHash(algorithm)
+	if err != nil {
+		return 0, err
+	}
+	return h.Size() * 2, nil
+}
+
+// HashFile returns the hex-encoded digest of a file's contents
+func HashFile(path, algorithm string) (string, error) {
+	h, err := NewHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
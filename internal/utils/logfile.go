@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// reopenableFile is a log output that can be reopened in place, so that
+// external rotation (logrotate moving or truncating the file) does not leave
+// writes going to an unlinked file
+type reopenableFile struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// openLogFile opens path for appending
+func openLogFile(path string) (*reopenableFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &reopenableFile{path: path, file: file}, nil
+}
+
+// Write appends to the current file
+func (r *reopenableFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return os.Stderr.Write(p)
+	}
+	return r.file.Write(p)
+}
+
+// Reopen opens the path afresh and swaps it in for the current file
+func (r *reopenableFile) Reopen() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	old := r.file
+	r.file = file
+	r.mu.Unlock()
+
+	if old != nil {
+		return old.Close()
+	}
+	return nil
+}
+
+// Close closes the current file; later writes fall back to stderr
+func (r *reopenableFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// ReopenLogger reopens the log file, typically after it has been rotated
+func ReopenLogger() error {
+	if logFile == nil {
+		return nil
+	}
+	return logFile.Reopen()
+}
+
+// CloseLogger flushes and closes the log file for a clean shutdown
+func CloseLogger() error {
+	if logFile == nil {
+		return nil
+	}
+	if log != nil {
+		log.SetOutput(os.Stderr)
+	}
+	return logFile.Close()
+}
+
+// ReopenLoggerOnSignal reopens the log file whenever the process receives
+// SIGHUP, the usual logrotate postrotate signal. The returned function stops
+// listening.
+func ReopenLoggerOnSignal() func() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				if err := ReopenLogger(); err != nil {
+					GetLogger().Errorf("Failed to reopen log file: %v", err)
+				} else {
+					GetLogger().Info("Reopened log file")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
@@ -10,6 +10,9 @@ import (
 
 var log *logrus.Logger
 
+// logFile is the reopenable output behind log, if logging to a file
+var logFile *reopenableFile
+
 // InitLogger initializes the application logger
 func InitLogger(level string) *logrus.Logger {
 	if log != nil {
@@ -34,9 +37,9 @@ func InitLogger(level string) *logrus.Logger {
 	// Create log directory
 	logDir := filepath.Join(os.Getenv("HOME"), ".config", "zohosync", "logs")
 	if err := os.MkdirAll(logDir, 0755); err == nil {
-		logFile := filepath.Join(logDir, "zohosync.log")
-		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		file, err := openLogFile(filepath.Join(logDir, "zohosync.log"))
 		if err == nil {
+			logFile = file
 			log.SetOutput(file)
 		}
 	}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freshLogger initializes a new logger writing under a temporary HOME
+func freshLogger(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	log, logFile = nil, nil
+	t.Cleanup(func() {
+		CloseLogger()
+		log, logFile = nil, nil
+	})
+
+	InitLogger("info")
+	return filepath.Join(home, ".config", "zohosync", "logs", "zohosync.log")
+}
+
+func TestReopenLoggerAfterRotation(t *testing.T) {
+	path := freshLogger(t)
+
+	GetLogger().Info("before rotation")
+
+	// Simulate logrotate moving the file aside
+	rotated := path + ".1"
+	require.NoError(t, os.Rename(path, rotated))
+	GetLogger().Info("still going to the rotated file")
+
+	require.NoError(t, ReopenLogger())
+	GetLogger().Info("after reopen")
+
+	old, err := os.ReadFile(rotated)
+	require.NoError(t, err)
+	assert.Contains(t, string(old), "before rotation")
+	assert.Contains(t, string(old), "still going to the rotated file")
+	assert.NotContains(t, string(old), "after reopen")
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(current), "after reopen")
+	assert.NotContains(t, string(current), "before rotation")
+}
+
+func TestReopenLoggerOnSIGHUP(t *testing.T) {
+	path := freshLogger(t)
+
+	stop := ReopenLoggerOnSignal()
+	defer stop()
+
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "log file should be recreated after SIGHUP")
+}
+
+func TestCloseLogger(t *testing.T) {
+	freshLogger(t)
+
+	require.NoError(t, CloseLogger())
+	require.NoError(t, CloseLogger(), "closing twice is harmless")
+	assert.NotPanics(t, func() { GetLogger().Info("after close goes to stderr") })
+}
@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultHashBlockSize is the size of the blocks a resumable hash digests on
+// their own as well, the default upload chunk size
+const DefaultHashBlockSize = 16 << 20
+
+// DefaultHashCheckpointEvery is how many bytes a resumable hash reads
+// between two checkpoints
+const DefaultHashCheckpointEvery = 256 << 20
+
+// hashBufferSize is the buffer a resumable hash reads through, whatever the
+// size of the file
+const hashBufferSize = 1 << 20
+
+// HashCheckpointStore keeps the checkpoints of resumable hashes
+type HashCheckpointStore interface {
+	// GetHashCheckpoint returns the checkpoint of a path, or nil
+	GetHashCheckpoint(path string) (*types.HashCheckpoint, error)
+	SaveHashCheckpoint(checkpoint *types.HashCheckpoint) error
+	DeleteHashCheckpoint(path string) error
+}
+
+// FileDigest is the digest of a file's contents together with the digest of
+// each of its blocks, which let a block be checked or sent on its own
+type FileDigest struct {
+	Hash   string
+	Blocks []string
+}
+
+// ResumableHash hashes files in blocks, saving its progress in Store every
+// CheckpointEvery bytes and when it is cancelled. Hashing a file that has a
+// checkpoint carries on from it, so an interrupted hash of a large file does
+// not start over. Memory use does not grow with the file beyond one digest
+// per block.
+type ResumableHash struct {
+	Algorithm       string
+	BlockSize       int64
+	CheckpointEvery int64
+	Store           HashCheckpointStore
+	// Reader, if set, wraps the file as it is read, e.g. to throttle it
+	Reader func(r io.Reader) io.Reader
+}
+
+// HashFile returns the digest of a file, resuming from its checkpoint if it
+// has one that still applies. If ctx is cancelled the progress so far is
+// saved and ctx.Err() returned.
+func (r *ResumableHash) HashFile(ctx context.Context, path string) (*FileDigest, error) {
+	blockSize := r.BlockSize
+	if blockSize <= 0 {
+		blockSize = DefaultHashBlockSize
+	}
+	every := r.CheckpointEvery
+	if every <= 0 {
+		every = DefaultHashCheckpointEvery
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	whole, err := NewHash(r.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	checkpoint := &types.HashCheckpoint{
+		Path:      path,
+		Algorithm: r.Algorithm,
+		BlockSize: blockSize,
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+	}
+	if saved := r.loadCheckpoint(checkpoint, whole); saved != nil {
+		checkpoint = saved
+		if _, err := file.Seek(checkpoint.Offset, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+
+	var source io.Reader = file
+	if r.Reader != nil {
+		source = r.Reader(file)
+	}
+	buf := make([]byte, hashBufferSize)
+	lastSaved := checkpoint.Offset
+	for checkpoint.Offset < checkpoint.Size {
+		if err := ctx.Err(); err != nil {
+			r.saveCheckpoint(checkpoint, whole)
+			return nil, err
+		}
+
+		length := blockSize
+		if remaining := checkpoint.Size - checkpoint.Offset; remaining < length {
+			length = remaining
+		}
+		block, _ := NewHash(r.Algorithm)
+		n, err := io.CopyBuffer(io.MultiWriter(whole, block), io.LimitReader(source, length), buf)
+		if err != nil {
+			return nil, err
+		}
+		if n < length {
+			return nil, fmt.Errorf("%s shrank while it was hashed", path)
+		}
+		checkpoint.Blocks = append(checkpoint.Blocks, fmt.Sprintf("%x", block.Sum(nil)))
+		checkpoint.Offset += length
+
+		if checkpoint.Offset-lastSaved >= every && checkpoint.Offset < checkpoint.Size {
+			r.saveCheckpoint(checkpoint, whole)
+			lastSaved = checkpoint.Offset
+		}
+	}
+
+	if after, err := file.Stat(); err != nil || after.Size() != checkpoint.Size || !after.ModTime().Equal(checkpoint.ModTime) {
+		r.deleteCheckpoint(path)
+		return nil, fmt.Errorf("%s changed while it was hashed", path)
+	}
+	r.deleteCheckpoint(path)
+	return &FileDigest{Hash: fmt.Sprintf("%x", whole.Sum(nil)), Blocks: checkpoint.Blocks}, nil
+}
+
+// loadCheckpoint returns the saved checkpoint of the file described by
+// current, with its state restored into whole, or nil if there is none that
+// still applies
+func (r *ResumableHash) loadCheckpoint(current *types.HashCheckpoint, whole hash.Hash) *types.HashCheckpoint {
+	if r.Store == nil {
+		return nil
+	}
+	saved, err := r.Store.GetHashCheckpoint(current.Path)
+	if err != nil || saved == nil {
+		return nil
+	}
+	if saved.Algorithm != current.Algorithm || saved.BlockSize != current.BlockSize ||
+		saved.Size != current.Size || !saved.ModTime.Equal(current.ModTime) ||
+		saved.Offset <= 0 || saved.Offset > saved.Size || saved.Offset%saved.BlockSize != 0 ||
+		int64(len(saved.Blocks)) != saved.Offset/saved.BlockSize {
+		return nil
+	}
+	unmarshaler, ok := whole.(encoding.BinaryUnmarshaler)
+	if !ok || unmarshaler.UnmarshalBinary(saved.State) != nil {
+		return nil
+	}
+	return saved
+}
+
+// saveCheckpoint records the progress of a hash. A checkpoint that cannot
+// be saved only means hashing starts over if it is interrupted.
+func (r *ResumableHash) saveCheckpoint(checkpoint *types.HashCheckpoint, whole hash.Hash) {
+	marshaler, ok := whole.(encoding.BinaryMarshaler)
+	if r.Store == nil || !ok || checkpoint.Offset == 0 {
+		return
+	}
+	state, err := marshaler.MarshalBinary()
+	if err != nil {
+		return
+	}
+	checkpoint.State = state
+	r.Store.SaveHashCheckpoint(checkpoint)
+}
+
+// deleteCheckpoint forgets the checkpoint of a path once it is hashed
+func (r *ResumableHash) deleteCheckpoint(path string) {
+	if r.Store != nil {
+		r.Store.DeleteHashCheckpoint(path)
+	}
+}
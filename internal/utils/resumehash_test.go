@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCheckpoints keeps hash checkpoints in memory
+type memoryCheckpoints map[string]types.HashCheckpoint
+
+func (m memoryCheckpoints) GetHashCheckpoint(path string) (*types.HashCheckpoint, error) {
+	checkpoint, ok := m[path]
+	if !ok {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
+
+func (m memoryCheckpoints) SaveHashCheckpoint(checkpoint *types.HashCheckpoint) error {
+	saved := *checkpoint
+	saved.Blocks = append([]string(nil), checkpoint.Blocks...)
+	m[checkpoint.Path] = saved
+	return nil
+}
+
+func (m memoryCheckpoints) DeleteHashCheckpoint(path string) error {
+	delete(m, path)
+	return nil
+}
+
+// countingReader counts the bytes read through it and calls after once
+// limit of them have been read
+type countingReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+	after func()
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	if c.after != nil && c.read >= c.limit {
+		c.after()
+		c.after = nil
+	}
+	return n, err
+}
+
+// randomFile writes size random bytes to a new file
+func randomFile(t *testing.T, size int) string {
+	t.Helper()
+	content := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(content)
+	path := filepath.Join(t.TempDir(), "large.bin")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	return path
+}
+
+func TestResumableHashResumesAfterInterruption(t *testing.T) {
+	const blockSize = 4096
+	path := randomFile(t, 10*blockSize+123)
+	want, err := HashFile(path, HashSHA256)
+	require.NoError(t, err)
+
+	// Interrupt the first hash part way through the fourth block
+	store := memoryCheckpoints{}
+	ctx, cancel := context.WithCancel(context.Background())
+	var first *countingReader
+	hasher := &ResumableHash{
+		Algorithm:       HashSHA256,
+		BlockSize:       blockSize,
+		CheckpointEvery: 2 * blockSize,
+		Store:           store,
+		Reader: func(r io.Reader) io.Reader {
+			first = &countingReader{r: r, limit: 3*blockSize + 1, after: cancel}
+			return first
+		},
+	}
+	_, err = hasher.HashFile(ctx, path)
+	require.ErrorIs(t, err, context.Canceled)
+	checkpoint, err := store.GetHashCheckpoint(path)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint, "the progress is saved when interrupted")
+	assert.Equal(t, int64(4*blockSize), checkpoint.Offset)
+
+	// The second hash only reads what the first did not
+	var second *countingReader
+	hasher.Reader = func(r io.Reader) io.Reader {
+		second = &countingReader{r: r}
+		return second
+	}
+	digest, err := hasher.HashFile(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, want, digest.Hash, "a resumed hash matches one made in a single pass")
+	assert.Equal(t, int64(6*blockSize+123), second.read)
+	assert.Empty(t, store, "the checkpoint is dropped once the file is hashed")
+
+	// Each block has its own digest
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, digest.Blocks, 11)
+	for i, block := range digest.Blocks {
+		end := (i + 1) * blockSize
+		if end > len(content) {
+			end = len(content)
+		}
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content[i*blockSize:end])), block, "block %d", i)
+	}
+}
+
+func TestResumableHashIgnoresStaleCheckpoint(t *testing.T) {
+	const blockSize = 1024
+	path := randomFile(t, 8*blockSize)
+	store := memoryCheckpoints{}
+	ctx, cancel := context.WithCancel(context.Background())
+	hasher := &ResumableHash{
+		Algorithm: HashSHA256,
+		BlockSize: blockSize,
+		Store:     store,
+		Reader: func(r io.Reader) io.Reader {
+			return &countingReader{r: r, limit: 2 * blockSize, after: cancel}
+		},
+	}
+	_, err := hasher.HashFile(ctx, path)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, store, 1)
+
+	// The file changes before the hash resumes
+	require.NoError(t, os.WriteFile(path, []byte("rewritten"), 0644))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	hasher.Reader = nil
+	digest, err := hasher.HashFile(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("rewritten"))), digest.Hash)
+}
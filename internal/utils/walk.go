@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// WalkProgressEvery is how many entries WalkWithContext visits between two
+// progress reports
+const WalkProgressEvery = 500
+
+// WalkFunc is called by WalkWithContext for each entry, as by filepath.Walk
+type WalkFunc = filepath.WalkFunc
+
+// WalkWithContext walks the tree rooted at root like filepath.Walk, but
+// stops with ctx.Err() as soon as ctx is cancelled. If progress is not nil
+// it is called with the number of entries visited so far every
+// WalkProgressEvery entries, and once more when the walk ends.
+func WalkWithContext(ctx context.Context, root string, fn WalkFunc, progress func(count int)) error {
+	count := 0
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		count++
+		if progress != nil && count%WalkProgressEvery == 0 {
+			progress(count)
+		}
+		return fn(path, info, err)
+	})
+	if progress != nil && count%WalkProgressEvery != 0 {
+		progress(count)
+	}
+	return err
+}
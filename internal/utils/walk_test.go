@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// makeTree creates dirs folders of files files each under a new root
+func makeTree(t *testing.T, dirs, files int) string {
+	t.Helper()
+	root := t.TempDir()
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%03d", d))
+		require.NoError(t, os.Mkdir(dir, 0755))
+		for f := 0; f < files; f++ {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d", f)), nil, 0644))
+		}
+	}
+	return root
+}
+
+func TestWalkWithContextReportsProgress(t *testing.T) {
+	root := makeTree(t, 10, 99)
+
+	var reports []int
+	visited := 0
+	err := WalkWithContext(context.Background(), root, func(path string, info os.FileInfo, err error) error {
+		visited++
+		return err
+	}, func(count int) {
+		reports = append(reports, count)
+	})
+	require.NoError(t, err)
+
+	// The root, 10 folders and 990 files
+	assert.Equal(t, 1001, visited)
+	assert.Equal(t, []int{500, 1000, 1001}, reports, "progress is reported periodically and at the end")
+}
+
+func TestWalkWithContextStopsWhenCancelled(t *testing.T) {
+	root := makeTree(t, 20, 50)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	visited := 0
+	started := time.Now()
+	err := WalkWithContext(ctx, root, func(path string, info os.FileInfo, err error) error {
+		visited++
+		if visited == 100 {
+			cancel()
+		}
+		return err
+	}, nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 100, visited, "no entry is visited after cancellation")
+	assert.Less(t, time.Since(started), time.Second)
+}
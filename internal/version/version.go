@@ -0,0 +1,10 @@
+// Package version holds the build information of the ZohoSync binaries
+package version
+
+// Set at build time with -ldflags "-X", as the Makefile does. Plain go
+// builds report a development build.
+var (
+	Version   = "dev"
+	BuildDate = "unknown"
+	Commit    = "unknown"
+)
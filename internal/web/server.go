@@ -0,0 +1,260 @@
+// Package web serves a browser dashboard for the daemon, for machines
+// without a desktop
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/internal/utils"
+	"github.com/bdstest/zohosync/pkg/types"
+)
+
+// DefaultListLimit is how many operations and conflicts the dashboard
+// lists unless a request asks for another number
+const DefaultListLimit = 50
+
+//go:embed static
+var static embed.FS
+
+// Dispatcher runs control commands; the daemon's control server is one
+type Dispatcher interface {
+	Dispatch(ctx context.Context, req control.Request) control.Response
+}
+
+// History provides the sync history and conflicts the dashboard lists
+type History interface {
+	GetRecentOperations(limit int) ([]types.SyncOperation, error)
+	GetConflicts(resolvedOnly bool, limit int) ([]types.ConflictInfo, error)
+}
+
+// commands maps the control endpoints to the control commands they run
+var commands = map[string]string{
+	"/api/pause":  "pause",
+	"/api/resume": "resume",
+	"/api/sync":   "sync-now",
+}
+
+// Server serves the dashboard and its JSON API. Every request must carry
+// the token, either as a bearer token or as the password of HTTP basic
+// auth, which browsers prompt for.
+type Server struct {
+	addr       string
+	token      string
+	dispatcher Dispatcher
+	history    History
+	server     *http.Server
+	listener   net.Listener
+	logger     *utils.Logger
+}
+
+// NewServer creates a web server for addr that runs commands through
+// dispatcher and reads the history from history
+func NewServer(addr, token string, dispatcher Dispatcher, history History) *Server {
+	s := &Server{
+		addr:       addr,
+		token:      token,
+		dispatcher: dispatcher,
+		history:    history,
+		logger:     utils.GetLogger(),
+	}
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler serving the dashboard and the API
+func (s *Server) Handler() http.Handler {
+	assets, _ := fs.Sub(static, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/operations", s.handleOperations)
+	mux.HandleFunc("/api/conflicts", s.handleConflicts)
+	for path := range commands {
+		mux.HandleFunc(path, s.handleCommand)
+	}
+	return s.authenticate(mux)
+}
+
+// Start begins serving until ctx is done or Close is called
+func (s *Server) Start(ctx context.Context) error {
+	if s.token == "" {
+		return fmt.Errorf("the web UI needs app.web_token to be set")
+	}
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	s.listener = listener
+
+	go func() {
+		<-ctx.Done()
+		s.Close()
+	}()
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Errorf("Web server error: %v", err)
+		}
+	}()
+
+	s.logger.Infof("Web UI listening on http://%s", listener.Addr())
+	return nil
+}
+
+// Addr returns the address the server is listening on
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.addr
+	}
+	return s.listener.Addr().String()
+}
+
+// Close stops the server
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// authenticate rejects requests without the token. An empty token rejects
+// everything rather than leaving the dashboard open.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := ""
+		if _, password, ok := r.BasicAuth(); ok {
+			given = password
+		} else if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			given = bearer
+		}
+
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ZohoSync"`)
+			writeError(w, http.StatusUnauthorized, errors.New("a valid token is required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleStatus reports the sync status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed", r.Method))
+		return
+	}
+
+	resp := s.dispatcher.Dispatch(r.Context(), control.Request{Command: "status"})
+	if !resp.OK {
+		writeError(w, http.StatusInternalServerError, errors.New(resp.Error))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp.Data)
+}
+
+// handleOperations lists the most recent sync operations
+func (s *Server) handleOperations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed", r.Method))
+		return
+	}
+
+	operations, err := s.history.GetRecentOperations(listLimit(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if operations == nil {
+		operations = []types.SyncOperation{}
+	}
+	writeJSON(w, http.StatusOK, operations)
+}
+
+// handleConflicts lists the most recent conflicts
+func (s *Server) handleConflicts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed", r.Method))
+		return
+	}
+
+	conflicts, err := s.history.GetConflicts(false, listLimit(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if conflicts == nil {
+		conflicts = []types.ConflictInfo{}
+	}
+	writeJSON(w, http.StatusOK, conflicts)
+}
+
+// handleCommand runs the control command of the requested endpoint
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed", r.Method))
+		return
+	}
+	// Browsers resend basic auth credentials on requests other sites
+	// trigger, so only accept commands from the dashboard's own pages
+	if !sameOrigin(r) {
+		writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+		return
+	}
+
+	resp := s.dispatcher.Dispatch(r.Context(), control.Request{Command: commands[r.URL.Path]})
+	status := http.StatusOK
+	if !resp.OK {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, resp)
+}
+
+// sameOrigin reports whether r was sent from a page served by this server,
+// or by a client that is not a browser and sends no Origin
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	return err == nil && parsed.Host == r.Host
+}
+
+// listLimit returns the limit query parameter, or DefaultListLimit
+func listLimit(r *http.Request) int {
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+		return limit
+	}
+	return DefaultListLimit
+}
+
+// writeJSON encodes v as the JSON response body
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError reports err as a JSON error body
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,215 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bdstest/zohosync/internal/control"
+	"github.com/bdstest/zohosync/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "secret-token"
+
+// fakeEngine answers control commands the way the daemon's handlers do,
+// recording the commands it ran
+type fakeEngine struct {
+	mu       sync.Mutex
+	paused   bool
+	commands []string
+}
+
+func (f *fakeEngine) Dispatch(ctx context.Context, req control.Request) control.Response {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, req.Command)
+
+	switch req.Command {
+	case "status":
+		state := types.SyncStateIdle
+		if f.paused {
+			state = types.SyncStatePaused
+		}
+		return control.DataResponse(&types.SyncStatus{State: state, TotalFiles: 3, SyncedFiles: 2})
+	case "pause":
+		f.paused = true
+		return control.Response{OK: true, State: string(types.SyncStatePaused)}
+	case "resume":
+		f.paused = false
+		return control.Response{OK: true, State: string(types.SyncStateIdle)}
+	case "sync-now":
+		if f.paused {
+			return control.ErrorResponse(fmt.Errorf("sync is paused; resume it first"))
+		}
+		return control.Response{OK: true, State: string(types.SyncStateSyncing)}
+	}
+	return control.ErrorResponse(fmt.Errorf("unknown command: %s", req.Command))
+}
+
+// fakeHistory returns fixed operations and conflicts
+type fakeHistory struct {
+	limit int
+}
+
+func (f *fakeHistory) GetRecentOperations(limit int) ([]types.SyncOperation, error) {
+	f.limit = limit
+	return []types.SyncOperation{
+		{ID: 2, Path: "/sync/b.txt", Type: "sync", Status: "failed", Error: "timeout", StartedAt: time.Now()},
+		{ID: 1, Path: "/sync/a.txt", Type: "sync", Status: "success", StartedAt: time.Now()},
+	}, nil
+}
+
+func (f *fakeHistory) GetConflicts(resolvedOnly bool, limit int) ([]types.ConflictInfo, error) {
+	f.limit = limit
+	return nil, nil
+}
+
+// newTestServer serves the web UI over a fake engine and history
+func newTestServer(t *testing.T) (*httptest.Server, *fakeEngine, *fakeHistory) {
+	t.Helper()
+
+	engine, history := &fakeEngine{}, &fakeHistory{}
+	server := httptest.NewServer(NewServer("127.0.0.1:0", testToken, engine, history).Handler())
+	t.Cleanup(server.Close)
+	return server, engine, history
+}
+
+// call sends an authenticated request and returns the status and body
+func call(t *testing.T, server *httptest.Server, method, path string) (int, []byte) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, server.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, body
+}
+
+func TestStatusEndpoint(t *testing.T) {
+	server, _, _ := newTestServer(t)
+
+	status, body := call(t, server, http.MethodGet, "/api/status")
+	require.Equal(t, http.StatusOK, status)
+
+	var syncStatus types.SyncStatus
+	require.NoError(t, json.Unmarshal(body, &syncStatus))
+	assert.Equal(t, types.SyncStateIdle, syncStatus.State)
+	assert.Equal(t, 3, syncStatus.TotalFiles)
+	assert.Equal(t, 2, syncStatus.SyncedFiles)
+
+	status, _ = call(t, server, http.MethodPost, "/api/status")
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+}
+
+func TestControlEndpoints(t *testing.T) {
+	server, engine, _ := newTestServer(t)
+
+	status, body := call(t, server, http.MethodPost, "/api/pause")
+	require.Equal(t, http.StatusOK, status)
+	var resp control.Response
+	require.NoError(t, json.Unmarshal(body, &resp))
+	assert.Equal(t, string(types.SyncStatePaused), resp.State)
+
+	_, body = call(t, server, http.MethodGet, "/api/status")
+	assert.Contains(t, string(body), `"state":"paused"`)
+
+	status, body = call(t, server, http.MethodPost, "/api/sync")
+	assert.Equal(t, http.StatusConflict, status, "a paused engine refuses to sync")
+	assert.Contains(t, string(body), "resume it first")
+
+	status, _ = call(t, server, http.MethodPost, "/api/resume")
+	require.Equal(t, http.StatusOK, status)
+	status, body = call(t, server, http.MethodPost, "/api/sync")
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body, &resp))
+	assert.Equal(t, string(types.SyncStateSyncing), resp.State)
+
+	status, _ = call(t, server, http.MethodGet, "/api/pause")
+	assert.Equal(t, http.StatusMethodNotAllowed, status, "commands must be posted")
+
+	assert.Equal(t, []string{"pause", "status", "sync-now", "resume", "sync-now"}, engine.commands)
+}
+
+func TestControlRejectsOtherOrigins(t *testing.T) {
+	server, engine, _ := newTestServer(t)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/pause", nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("", testToken)
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	req.Header.Set("Origin", server.URL)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"pause"}, engine.commands)
+}
+
+func TestHistoryEndpoints(t *testing.T) {
+	server, _, history := newTestServer(t)
+
+	status, body := call(t, server, http.MethodGet, "/api/operations?limit=10")
+	require.Equal(t, http.StatusOK, status)
+	var operations []types.SyncOperation
+	require.NoError(t, json.Unmarshal(body, &operations))
+	require.Len(t, operations, 2)
+	assert.Equal(t, "timeout", operations[0].Error)
+	assert.Equal(t, 10, history.limit)
+
+	status, body = call(t, server, http.MethodGet, "/api/conflicts")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, `[]`, string(body))
+	assert.Equal(t, DefaultListLimit, history.limit)
+}
+
+func TestRequestsNeedToken(t *testing.T) {
+	server, engine, _ := newTestServer(t)
+
+	for _, auth := range []string{"", "Bearer wrong", "Basic " + "dXNlcjp3cm9uZw=="} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/pause", nil)
+		require.NoError(t, err)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "auth %q", auth)
+		assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+	}
+	assert.Empty(t, engine.commands)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/", nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("admin", testToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	page, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(page), "<title>ZohoSync</title>", "the dashboard page is served")
+}
+
+func TestStartNeedsToken(t *testing.T) {
+	server := NewServer("127.0.0.1:0", "", &fakeEngine{}, &fakeHistory{})
+	assert.Error(t, server.Start(context.Background()))
+}
@@ -1,49 +1,110 @@
 // Package types contains shared type definitions for ZohoSync
 package types
 
-// Config represents the application configuration
+// Config represents the application configuration. Settings are checked
+// against their validate tags when loaded: oneof lists the values allowed,
+// fold ignores case and "-" versus "_" when comparing with them, and min is
+// the smallest number allowed. Empty strings are always allowed.
 type Config struct {
-	App      AppConfig      `yaml:"app" json:"app"`
-	Auth     AuthConfig     `yaml:"auth" json:"auth"`
-	Sync     SyncConfig     `yaml:"sync" json:"sync"`
-	Network  NetworkConfig  `yaml:"network" json:"network"`
-	UI       UIConfig       `yaml:"ui" json:"ui"`
-	Folders  []FolderConfig `yaml:"folders" json:"folders"`
+	App           AppConfig           `yaml:"app" json:"app"`
+	Auth          AuthConfig          `yaml:"auth" json:"auth"`
+	Sync          SyncConfig          `yaml:"sync" json:"sync"`
+	Network       NetworkConfig       `yaml:"network" json:"network"`
+	UI            UIConfig            `yaml:"ui" json:"ui"`
+	Folders       []FolderConfig      `yaml:"folders" json:"folders"`
+	Notifications NotificationsConfig `yaml:"notifications" json:"notifications"`
+	Remote        RemoteConfig        `yaml:"remote" json:"remote"`
+	API           APIConfig           `yaml:"api" json:"api"`
+	Encryption    EncryptionConfig    `yaml:"encryption" json:"encryption"`
 }
 
 // AppConfig contains general application settings
 type AppConfig struct {
 	Name    string `yaml:"name" json:"name"`
 	Version string `yaml:"version" json:"version"`
-	LogLevel string `yaml:"log_level" json:"log_level"`
+	LogLevel string `yaml:"log_level" json:"log_level" validate:"fold,oneof=panic fatal error warn warning info debug trace"`
+	HealthAddr string `yaml:"health_addr" json:"health_addr"`
+	// WebAddr is where the web UI listens; it is only served once WebToken,
+	// which its requests must carry, is set
+	WebAddr  string `yaml:"web_addr" json:"web_addr"`
+	WebToken string `yaml:"web_token" json:"-"`
+	// OperationsRetention and FailedOperationsRetention are the days the
+	// sync history keeps entries; 0 keeps them forever
+	OperationsRetention       int `yaml:"operations_retention" json:"operations_retention" validate:"min=0"`
+	FailedOperationsRetention int `yaml:"failed_operations_retention" json:"failed_operations_retention" validate:"min=0"`
 }
 
 // AuthConfig contains authentication settings
 type AuthConfig struct {
-	ClientID     string `yaml:"client_id" json:"client_id"`
-	ClientSecret string `yaml:"client_secret" json:"client_secret"`
-	RedirectURI  string `yaml:"redirect_uri" json:"redirect_uri"`
-	Scopes       []string `yaml:"scopes" json:"scopes"`
+	ClientID         string `yaml:"client_id" json:"client_id"`
+	ClientSecret     string `yaml:"client_secret" json:"-"`
+	ClientSecretFile string `yaml:"client_secret_file,omitempty" json:"client_secret_file,omitempty"`
+	RedirectURI      string `yaml:"redirect_uri" json:"redirect_uri"`
+	Scopes           []string `yaml:"scopes" json:"scopes"`
+	// ScopeProfile picks the scopes to ask for, "full" or "readonly"; if
+	// empty, Scopes are asked for as listed
+	ScopeProfile string `yaml:"scope_profile,omitempty" json:"scope_profile,omitempty" validate:"fold,oneof=full readonly"`
+	// TokenStore is where the OAuth token is kept, "db" or "keyring"
+	TokenStore string `yaml:"token_store,omitempty" json:"token_store,omitempty" validate:"fold,oneof=db keyring"`
+	// FlexibleRedirectPort lets the login callback move to a nearby port
+	// when the redirect URI's is in use, for OAuth apps that accept any
+	// loopback port
+	FlexibleRedirectPort bool `yaml:"flexible_redirect_port,omitempty" json:"flexible_redirect_port,omitempty"`
 }
 
 // SyncConfig contains synchronization settings
 type SyncConfig struct {
-	Interval            int    `yaml:"interval" json:"interval"`
-	ConflictResolution  string `yaml:"conflict_resolution" json:"conflict_resolution"`
-	MaxConcurrentSyncs  int    `yaml:"max_concurrent_syncs" json:"max_concurrent_syncs"`
+	Interval            int    `yaml:"interval" json:"interval" validate:"min=0"`
+	ConflictResolution  string `yaml:"conflict_resolution" json:"conflict_resolution" validate:"oneof=newer local remote keep_both manual"`
+	ConflictMode        string `yaml:"conflict_mode" json:"conflict_mode" validate:"oneof=inline quarantine"`
+	MaxConcurrentSyncs  int    `yaml:"max_concurrent_syncs" json:"max_concurrent_syncs" validate:"min=0"`
+	PreserveMetadata    bool   `yaml:"preserve_metadata" json:"preserve_metadata"`
+	HashAlgorithm       string `yaml:"hash_algorithm" json:"hash_algorithm" validate:"fold,oneof=sha256 md5"`
+	StableFor           int    `yaml:"stable_for" json:"stable_for" validate:"min=0"`
+	ShutdownGrace       int    `yaml:"shutdown_grace" json:"shutdown_grace" validate:"min=0"`
+	SanitizeNames       bool   `yaml:"sanitize_names" json:"sanitize_names"`
+	ChunkSize           int    `yaml:"chunk_size" json:"chunk_size" validate:"min=0"`
+	ChunkConcurrency    int    `yaml:"chunk_concurrency" json:"chunk_concurrency" validate:"min=0"`
+	QueueOrder          string `yaml:"queue_order" json:"queue_order" validate:"oneof=smallest-first fifo largest-first"`
+	ExportFormats       map[string]string `yaml:"export_formats" json:"export_formats"`
+	DeleteThreshold     int    `yaml:"delete_threshold" json:"delete_threshold" validate:"min=0"`
+	RetryBudget         int    `yaml:"retry_budget" json:"retry_budget" validate:"min=0"`
+	LocalTrash          bool   `yaml:"local_trash" json:"local_trash"`
+	LocalTrashRetention int    `yaml:"local_trash_retention" json:"local_trash_retention" validate:"min=0"`
+	FollowRemoteRenames bool   `yaml:"follow_remote_renames" json:"follow_remote_renames"`
+	ScanConcurrency     int    `yaml:"scan_concurrency" json:"scan_concurrency" validate:"min=0"`
+	ScanThroughput      int    `yaml:"scan_throughput" json:"scan_throughput" validate:"min=0"`
+	SkipOpenFiles       bool   `yaml:"skip_open_files" json:"skip_open_files"`
+	AdvisoryLocks       bool   `yaml:"advisory_locks" json:"advisory_locks"`
+	LockTTL             int    `yaml:"lock_ttl" json:"lock_ttl" validate:"min=0"`
+	VerifyUploads       bool   `yaml:"verify_uploads" json:"verify_uploads"`
+	ManualConflictTimeout  int    `yaml:"manual_conflict_timeout" json:"manual_conflict_timeout" validate:"min=0"`
+	ManualConflictFallback string `yaml:"manual_conflict_fallback" json:"manual_conflict_fallback" validate:"oneof=newer local remote keep_both"`
+	// ContentCacheSize is the MiB of content kept by hash so identical files
+	// are copied rather than downloaded again; 0 disables the cache
+	ContentCacheSize int `yaml:"content_cache_size" json:"content_cache_size" validate:"min=0"`
 }
 
 // NetworkConfig contains network settings
 type NetworkConfig struct {
-	ProxyURL         string `yaml:"proxy_url" json:"proxy_url"`
-	Timeout          int    `yaml:"timeout" json:"timeout"`
-	MaxRetries       int    `yaml:"max_retries" json:"max_retries"`
-	BandwidthLimit   int    `yaml:"bandwidth_limit" json:"bandwidth_limit"`
+	ProxyURL            string `yaml:"proxy_url" json:"proxy_url"`
+	Timeout             int    `yaml:"timeout" json:"timeout" validate:"min=0"`
+	MaxRetries          int    `yaml:"max_retries" json:"max_retries" validate:"min=0"`
+	BandwidthLimit      int    `yaml:"bandwidth_limit" json:"bandwidth_limit" validate:"min=0"`
+	MaxIdleConns        int    `yaml:"max_idle_conns" json:"max_idle_conns" validate:"min=0"`
+	MaxIdleConnsPerHost int    `yaml:"max_idle_conns_per_host" json:"max_idle_conns_per_host" validate:"min=0"`
+	IdleConnTimeout     int    `yaml:"idle_conn_timeout" json:"idle_conn_timeout" validate:"min=0"`
+	EnableHTTP2         bool   `yaml:"enable_http2" json:"enable_http2"`
+	CAFile              string `yaml:"ca_file" json:"ca_file"`
+	PinSHA256           string `yaml:"pin_sha256" json:"pin_sha256"`
+	StallTimeout        int    `yaml:"stall_timeout" json:"stall_timeout" validate:"min=0"`
+	RetryJitter         string `yaml:"retry_jitter" json:"retry_jitter" validate:"fold,oneof=none full equal"`
+	UserAgent           string `yaml:"user_agent" json:"user_agent"`
 }
 
 // UIConfig contains UI settings
 type UIConfig struct {
-	Theme              string `yaml:"theme" json:"theme"`
+	Theme              string `yaml:"theme" json:"theme" validate:"oneof=light dark"`
 	ShowNotifications  bool   `yaml:"show_notifications" json:"show_notifications"`
 	MinimizeToTray     bool   `yaml:"minimize_to_tray" json:"minimize_to_tray"`
 }
@@ -52,6 +113,61 @@ type UIConfig struct {
 type FolderConfig struct {
 	Local     string `yaml:"local" json:"local"`
 	Remote    string `yaml:"remote" json:"remote"`
-	SyncMode  string `yaml:"sync_mode" json:"sync_mode"`
+	SyncMode  string `yaml:"sync_mode" json:"sync_mode" validate:"fold,oneof=bidirectional upload_only download_only"`
 	Enabled   bool   `yaml:"enabled" json:"enabled"`
+	Interval  int    `yaml:"interval,omitempty" json:"interval,omitempty" validate:"min=0"`
+	// Preset names the preset the folder was set up with. Its settings are
+	// copied into the fields below, where they can be changed freely.
+	Preset string `yaml:"preset,omitempty" json:"preset,omitempty"`
+	// IncludeExtensions limits syncing to files with these extensions;
+	// empty syncs every file
+	IncludeExtensions []string `yaml:"include_extensions,omitempty" json:"include_extensions,omitempty"`
+	// ChunkSize overrides sync.chunk_size, in MiB, for the folder's uploads
+	ChunkSize int `yaml:"chunk_size,omitempty" json:"chunk_size,omitempty" validate:"min=0"`
+}
+
+// RemoteConfig selects where synced folders are mirrored to
+type RemoteConfig struct {
+	Type string `yaml:"type" json:"type" validate:"oneof=zoho local"`
+	Path string `yaml:"path" json:"path"`
+}
+
+// APIConfig locates the WorkDrive API; endpoints are built as
+// host + base_path + "/" + version. CacheTTL is how many seconds file
+// metadata and folder listings are reused, zero disabling the cache.
+type APIConfig struct {
+	Host     string `yaml:"host" json:"host"`
+	BasePath string `yaml:"base_path" json:"base_path"`
+	Version  string `yaml:"version" json:"version"`
+	CacheTTL int    `yaml:"cache_ttl" json:"cache_ttl" validate:"min=0"`
+	// RequestLog is how much is logged about each request at debug level:
+	// "off", "requests" or "headers"
+	RequestLog string `yaml:"request_log" json:"request_log" validate:"fold,oneof=off requests headers"`
+}
+
+// NotificationsConfig contains alerting settings for headless daemons
+type NotificationsConfig struct {
+	WebhookURL       string     `yaml:"webhook_url" json:"webhook_url"`
+	SMTP             SMTPConfig `yaml:"smtp" json:"smtp"`
+	RateLimit        int        `yaml:"rate_limit" json:"rate_limit" validate:"min=0"`
+	FailureThreshold int        `yaml:"failure_threshold" json:"failure_threshold" validate:"min=0"`
+}
+
+// EncryptionConfig contains client-side encryption settings. The passphrase
+// is read from PassphraseFile when one is set.
+type EncryptionConfig struct {
+	Enabled        bool   `yaml:"enabled" json:"enabled"`
+	Passphrase     string `yaml:"passphrase" json:"-"`
+	PassphraseFile string `yaml:"passphrase_file,omitempty" json:"passphrase_file,omitempty"`
+	EncryptNames   bool   `yaml:"encrypt_names" json:"encrypt_names"`
+}
+
+// SMTPConfig contains mail server settings for email notifications
+type SMTPConfig struct {
+	Host     string   `yaml:"host" json:"host"`
+	Port     int      `yaml:"port" json:"port" validate:"min=0"`
+	Username string   `yaml:"username" json:"username"`
+	Password string   `yaml:"password" json:"-"`
+	From     string   `yaml:"from" json:"from"`
+	To       []string `yaml:"to" json:"to"`
 }
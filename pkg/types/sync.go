@@ -10,9 +10,100 @@ type SyncStatus struct {
 	InProgress   bool          `json:"in_progress"`
 	TotalFiles   int           `json:"total_files"`
 	SyncedFiles  int           `json:"synced_files"`
+	Concurrency  int           `json:"concurrency,omitempty"`
+	Queued       int           `json:"queued,omitempty"`
+	Transferring int           `json:"transferring,omitempty"`
+	PendingDeletions int       `json:"pending_deletions,omitempty"`
 	Errors       []SyncError   `json:"errors,omitempty"`
+	InitialSync  *InitialSyncProgress `json:"initial_sync,omitempty"`
+	Metrics      *SyncMetrics  `json:"metrics,omitempty"`
 }
 
+// SyncMetrics reports the transfers of the whole sync history and of the
+// last sync cycle that had files to sync
+type SyncMetrics struct {
+	Total     TransferMetrics  `json:"total"`
+	LastCycle *TransferMetrics `json:"last_cycle,omitempty"`
+}
+
+// TransferMetrics sums up the transfers and failures of a period. Elapsed is
+// the wall-clock length of a sync cycle, or for the whole history the time
+// spent transferring, which overlapping transfers count more than once.
+type TransferMetrics struct {
+	Since     time.Time      `json:"since,omitempty"`
+	Until     time.Time      `json:"until,omitempty"`
+	BytesUp   int64          `json:"bytes_up"`
+	BytesDown int64          `json:"bytes_down"`
+	FilesUp   int            `json:"files_up"`
+	FilesDown int            `json:"files_down"`
+	Elapsed   time.Duration  `json:"elapsed"`
+	Errors    map[string]int `json:"errors,omitempty"`
+}
+
+// Files returns how many files were transferred either way
+func (m *TransferMetrics) Files() int {
+	return m.FilesUp + m.FilesDown
+}
+
+// BytesPerSecond returns the average transfer speed
+func (m *TransferMetrics) BytesPerSecond() float64 {
+	if m.Elapsed <= 0 {
+		return 0
+	}
+	return float64(m.BytesUp+m.BytesDown) / m.Elapsed.Seconds()
+}
+
+// FilesPerSecond returns the average number of files transferred a second
+func (m *TransferMetrics) FilesPerSecond() float64 {
+	if m.Elapsed <= 0 {
+		return 0
+	}
+	return float64(m.Files()) / m.Elapsed.Seconds()
+}
+
+// ErrorCount returns the number of failures of any type
+func (m *TransferMetrics) ErrorCount() int {
+	count := 0
+	for _, n := range m.Errors {
+		count += n
+	}
+	return count
+}
+
+// SyncResult sums up what a sync cycle, a full sync pass or a bulk folder
+// transfer did. Errors holds one error per failed file, each naming the
+// file and wrapping the cause.
+type SyncResult struct {
+	Downloaded int           `json:"downloaded"`
+	Uploaded   int           `json:"uploaded"`
+	Renamed    int           `json:"renamed"`
+	Skipped    int           `json:"skipped"`
+	Conflicts  int           `json:"conflicts"`
+	Failed     int           `json:"failed"`
+	Bytes      int64         `json:"bytes"`
+	Errors     []error       `json:"-"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// OperationRecord is an entry to add to the sync history. Direction,
+// Bytes and Duration describe a transfer; ErrorType classifies a failure.
+type OperationRecord struct {
+	FileID    string
+	Type      string
+	Status    string
+	Error     string
+	ErrorType string
+	Direction string
+	Bytes     int64
+	Duration  time.Duration
+}
+
+// Transfer directions recorded in the sync history
+const (
+	DirectionUpload   = "up"
+	DirectionDownload = "down"
+)
+
 // SyncState represents the current sync state
 type SyncState string
 
@@ -21,6 +112,7 @@ const (
 	SyncStateSyncing  SyncState = "syncing"
 	SyncStatePaused   SyncState = "paused"
 	SyncStateError    SyncState = "error"
+	SyncStateOffline  SyncState = "offline"
 )
 
 // SyncError represents a synchronization error
@@ -30,6 +122,25 @@ type SyncError struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// FolderSyncStatus summarizes the sync state of the files under one sync
+// folder
+type FolderSyncStatus struct {
+	Folder       string    `json:"folder"`
+	TotalFiles   int       `json:"total_files"`
+	SyncedFiles  int       `json:"synced_files"`
+	PendingFiles int       `json:"pending_files"`
+	LastSync     time.Time `json:"last_sync"`
+}
+
+// FailedFile is a file set aside after failing to sync too many times. It
+// is left out of sync cycles until it is retried explicitly.
+type FailedFile struct {
+	Path      string    `json:"path"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
 // FileMetadata represents file metadata for sync tracking
 type FileMetadata struct {
 	ID           string    `json:"id"`
@@ -39,5 +150,132 @@ type FileMetadata struct {
 	ModifiedTime time.Time `json:"modified_time"`
 	Hash         string    `json:"hash"`
 	IsDirectory  bool      `json:"is_directory"`
+	Mode         uint32    `json:"mode,omitempty"`
 	SyncStatus   string    `json:"sync_status"`
+	// RemoteVersion is the remote version seen when the sync was planned;
+	// an upload only replaces the remote file if it still has this version
+	RemoteVersion string   `json:"remote_version,omitempty"`
+	// RemoteModified is the remote modification time of the copy last
+	// downloaded, telling whether the remote file changed since
+	RemoteModified time.Time `json:"remote_modified,omitempty"`
+	// SyncedHash and SyncedSize are the content hash and size recorded at
+	// the last successful sync, kept while local changes wait to sync. An
+	// empty SyncedHash means they are not known.
+	SyncedHash string `json:"synced_hash,omitempty"`
+	SyncedSize int64  `json:"synced_size,omitempty"`
+}
+
+// HashCheckpoint is the saved progress of hashing a large file: the state
+// of the hasher after Offset bytes and the digests of the blocks before it.
+// It only applies while the file keeps the recorded size and modification
+// time.
+type HashCheckpoint struct {
+	Path      string    `json:"path"`
+	Algorithm string    `json:"algorithm"`
+	BlockSize int64     `json:"block_size"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Offset    int64     `json:"offset"`
+	State     []byte    `json:"state"`
+	Blocks    []string  `json:"blocks"`
+}
+
+// UploadSession is a server-side upload that was initiated for a local file
+// but has not completed yet
+type UploadSession struct {
+	UploadID  string    `json:"upload_id"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	Hash      string    `json:"hash"`
+	UploadURL string    `json:"upload_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Sides of a conflict that can win its resolution
+const (
+	ConflictWinnerLocal  = "local"
+	ConflictWinnerRemote = "remote"
+)
+
+// ConflictInfo records a file that changed both locally and remotely, and
+// which side won if the conflict was resolved
+type ConflictInfo struct {
+	ID             int64     `json:"id"`
+	Path           string    `json:"path"`
+	Strategy       string    `json:"strategy"`
+	Winner         string    `json:"winner,omitempty"`
+	AutoResolved   bool      `json:"auto_resolved"`
+	BaseHash       string    `json:"base_hash,omitempty"`
+	LocalHash      string    `json:"local_hash,omitempty"`
+	RemoteHash     string    `json:"remote_hash,omitempty"`
+	CopyPath       string    `json:"copy_path,omitempty"`
+	LocalSize      int64     `json:"local_size"`
+	RemoteSize     int64     `json:"remote_size"`
+	LocalModified  time.Time `json:"local_modified"`
+	RemoteModified time.Time `json:"remote_modified"`
+	DetectedAt     time.Time `json:"detected_at"`
+	// FirstSeen is when the conflict was first detected; a conflict left
+	// unresolved is detected again by every sync
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// Resolved reports whether a side has been chosen for the conflict
+func (c *ConflictInfo) Resolved() bool {
+	return c.Winner != ""
+}
+
+// SyncOperation is an entry of the sync history. Path is empty when the
+// file it was about is no longer tracked.
+type SyncOperation struct {
+	ID        int64     `json:"id"`
+	Path      string    `json:"path,omitempty"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// InitialSyncState is the checkpoint of the first sync of a sync folder.
+// Its operations are planned once and then run in order; the first
+// Completed of them are done.
+type InitialSyncState struct {
+	Folder    string    `json:"folder"`
+	RemoteID  string    `json:"remote_id"`
+	Planned   bool      `json:"planned"`
+	Total     int       `json:"total"`
+	Completed int       `json:"completed"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Done reports whether every planned operation has run
+func (s *InitialSyncState) Done() bool {
+	return s.Planned && s.Completed >= s.Total
+}
+
+// InitialSyncOp is one planned operation of an initial sync. Seq numbers the
+// operations in the order they run; Path is relative to the sync folder.
+type InitialSyncOp struct {
+	Seq          int       `json:"seq"`
+	Type         string    `json:"type"`
+	Path         string    `json:"path"`
+	RemoteID     string    `json:"remote_id,omitempty"`
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modified_time"`
+	IsDirectory  bool      `json:"is_directory,omitempty"`
+}
+
+// InitialSyncProgress sums up the initial syncs that have not finished
+type InitialSyncProgress struct {
+	Completed int  `json:"completed"`
+	Total     int  `json:"total"`
+	Planning  bool `json:"planning,omitempty"`
+}
+
+// Percent returns how much of the planned work is done, from 0 to 100
+func (p *InitialSyncProgress) Percent() int {
+	if p.Total == 0 {
+		return 0
+	}
+	return p.Completed * 100 / p.Total
 }